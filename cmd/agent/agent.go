@@ -0,0 +1,389 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/agent"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/log"
+	"github.com/drapon/envy/internal/refresh"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// defaultRefresh is used when neither --refresh nor agent.refresh is set
+const defaultRefresh = config.DefaultAgentRefresh
+
+var (
+	environments []string
+	socketPath   string
+	listenAddr   string
+	refreshEvery time.Duration
+	notifyPID    int
+	notifySignal string
+	system       bool
+
+	// get subcommand
+	getEnvironment string
+	tokenEnv       string
+)
+
+// agentCmd represents the agent command
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Serve variables to local applications over a Unix socket",
+	Long: `Run a local agent that applications query at runtime for individual
+variables, instead of receiving every secret in their process environment.
+
+Variables are pulled from AWS on first use, kept in memory and refreshed in
+the background. If a refresh fails the previous values keep being served.
+
+Access is controlled per process. On Linux the agent identifies callers by
+the Unix socket peer credentials (PID, UID and executable); rules in the
+'agent.acl' section of .envyrc grant access by executable path, UID or bearer
+token, per environment and key pattern. On macOS and FreeBSD only the UID
+(and on macOS the PID) is known, so executable rules never match. Elsewhere
+callers cannot be identified and at least one token rule is required.
+Without rules only processes running as the agent's own user are allowed.
+Every request is written to the log
+(key names only, never values).
+
+Variables annotated with a refresh interval in the 'variables' section of
+.envyrc are re-fetched individually on their own schedule, e.g. rotating
+database tokens. With --notify-pid the given process is signalled when one
+of them changes.
+
+With --system the agent serves every project of the user rather than one,
+on a socket of its own ($XDG_RUNTIME_DIR/envy/system.sock). 'envy run --from
+aws' asks it for the environment of the project it runs in, found by the
+path of its .envyrc, so new shells share one cache and one set of AWS
+credentials instead of each calling AWS. Only processes of the same user may
+connect. 'envy agent install' runs it as a systemd user service or launchd
+agent from login. Changes pushed from elsewhere are picked up on the next
+refresh.
+
+API:
+  GET /v1/secrets/{env}/{key}   value of one variable
+  GET /v1/secrets/{env}         JSON list of the keys the caller may read`,
+	Example: `  # Serve the production environment on the default socket
+  envy agent --env prod
+
+  # Query it from a shell script or application
+  envy agent get DB_PASSWORD --env prod
+  curl --unix-socket "$XDG_RUNTIME_DIR/envy/agent.sock" http://agent/v1/secrets/prod/DB_PASSWORD
+
+  # Listen on localhost TCP (only token rules can match TCP callers)
+  envy agent --env prod --addr 127.0.0.1:8200
+
+  # Share one agent between every shell and project, started at login
+  envy agent install`,
+	Args: cobra.NoArgs,
+	RunE: runAgent,
+}
+
+// getCmd represents the agent get command
+var getCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Read a variable from a running agent",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runGet,
+}
+
+// GetAgentCmd returns the agent command.
+func GetAgentCmd() *cobra.Command {
+	return agentCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(agentCmd)
+	agentCmd.AddCommand(getCmd)
+
+	agentCmd.Flags().StringSliceVarP(&environments, "env", "e", nil, "Environments to serve (default: all)")
+	agentCmd.Flags().StringVar(&socketPath, "socket", "", "Unix socket path (default: agent.socket or $XDG_RUNTIME_DIR/envy/agent.sock)")
+	agentCmd.Flags().StringVar(&listenAddr, "addr", "", "Listen on a loopback TCP address instead of a Unix socket")
+	agentCmd.Flags().DurationVar(&refreshEvery, "refresh", 0, "How often cached variables are refreshed (default: agent.refresh or 5m)")
+	agentCmd.Flags().IntVar(&notifyPID, "notify-pid", 0, "Process to signal when a variable with its own refresh interval changes")
+	agentCmd.Flags().StringVar(&notifySignal, "notify-signal", "HUP", "Signal sent to --notify-pid")
+	agentCmd.Flags().BoolVar(&system, "system", false, "Serve every project of the user to all shells on the system socket")
+
+	getCmd.Flags().StringVarP(&getEnvironment, "env", "e", "", "Environment to read from")
+	getCmd.Flags().StringVar(&socketPath, "socket", "", "Agent socket path or http:// address")
+	getCmd.Flags().StringVar(&tokenEnv, "token-env", "", "Environment variable holding a bearer token")
+}
+
+func runAgent(cmd *cobra.Command, args []string) error {
+	if system {
+		return runSystem()
+	}
+	logger := log.WithContext(zap.String("command", "agent"))
+
+	// Load configuration
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	served, err := resolveEnvironments(cfg, environments)
+	if err != nil {
+		return err
+	}
+
+	ttl, err := resolveRefresh(cfg, refreshEvery)
+	if err != nil {
+		return err
+	}
+
+	rules, err := aclRules(cfg.Agent.ACL)
+	if err != nil {
+		return err
+	}
+	acl, err := agent.NewACL(rules, os.Getuid())
+	if err != nil {
+		return fmt.Errorf("invalid agent ACL: %w", err)
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	listener, address, err := listen(cfg, len(rules) > 0)
+	if err != nil {
+		return err
+	}
+
+	cache := agent.NewCache(awsManager, ttl)
+	server := &agent.Server{
+		Cache:        cache,
+		ACL:          acl,
+		Environments: served,
+		Logger:       logger,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go cache.Run(ctx, func(err error) {
+		logger.Warn("Failed to refresh variables, serving cached values", zap.Error(err))
+	})
+
+	if err := startRefreshers(ctx, cfg, cache, awsManager, served, logger); err != nil {
+		return err
+	}
+
+	color.PrintInfof("Serving %d environment(s) on %s (Ctrl+C to stop)", len(served), address)
+	return server.Serve(ctx, listener)
+}
+
+func runGet(cmd *cobra.Command, args []string) error {
+	address := socketPath
+	envName := getEnvironment
+
+	if address == "" || envName == "" {
+		// Fall back to the project configuration when it is available
+		if cfg, err := config.Load(viper.GetString("config")); err == nil {
+			if address == "" {
+				address = cfg.Agent.Socket
+			}
+			if envName == "" {
+				envName = cfg.DefaultEnvironment
+			}
+		}
+	}
+	if address == "" {
+		address = agent.DefaultSocketPath()
+	}
+	if envName == "" {
+		return fmt.Errorf("--env is required")
+	}
+
+	client := agent.NewClient(address)
+	if tokenEnv != "" {
+		client.Token = os.Getenv(tokenEnv)
+	}
+
+	value, err := client.Get(context.Background(), envName, args[0])
+	if err != nil {
+		if errors.Is(err, agent.ErrAccessDenied) {
+			return fmt.Errorf("access to %s in %s denied by the agent ACL", args[0], envName)
+		}
+		return err
+	}
+
+	fmt.Println(value)
+	return nil
+}
+
+// startRefreshers loads each served environment and re-fetches its
+// variables that have their own refresh interval
+func startRefreshers(ctx context.Context, cfg *config.Config, cache *agent.Cache, awsManager *aws.Manager, served []string, logger *zap.Logger) error {
+	rules, err := refresh.ParseRules(cfg.Variables)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+
+	var notify func()
+	if notifyPID > 0 {
+		sig, err := refresh.ParseSignal(notifySignal)
+		if err != nil {
+			return err
+		}
+		process, err := os.FindProcess(notifyPID)
+		if err != nil {
+			return fmt.Errorf("failed to find process %d: %w", notifyPID, err)
+		}
+		notify = func() {
+			if err := process.Signal(sig); err != nil {
+				logger.Warn("Failed to notify process", zap.Int("pid", notifyPID), zap.Error(err))
+			}
+		}
+	}
+
+	for _, envName := range served {
+		file, err := cache.Get(ctx, envName)
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", envName, err)
+		}
+
+		intervals := refresh.Intervals(rules, file.Keys())
+		if len(intervals) == 0 {
+			continue
+		}
+
+		envName := envName
+		refresher := refresh.NewRefresher(awsManager, envName, intervals, file.ToMap())
+		refresher.OnError = func(err error) {
+			logger.Warn("Failed to refresh variables", zap.String("environment", envName), zap.Error(err))
+		}
+		go refresher.Run(ctx, func(changed map[string]string) {
+			cache.Update(envName, changed)
+
+			keys := make([]string, 0, len(changed))
+			for key := range changed {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			logger.Info("Variables refreshed", zap.String("environment", envName), zap.Strings("keys", keys))
+
+			if notify != nil {
+				notify()
+			}
+		})
+	}
+	return nil
+}
+
+// listen opens the TCP or Unix socket listener and returns its address
+func listen(cfg *config.Config, hasRules bool) (net.Listener, string, error) {
+	if listenAddr != "" {
+		if err := validateLoopback(listenAddr); err != nil {
+			return nil, "", err
+		}
+		listener, err := net.Listen("tcp", listenAddr)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to listen on %s: %w", listenAddr, err)
+		}
+		return listener, "http://" + listener.Addr().String(), nil
+	}
+
+	path := socketPath
+	if path == "" {
+		path = cfg.Agent.Socket
+	}
+	if path == "" {
+		path = agent.DefaultSocketPath()
+	}
+
+	// Without rules only the agent's own user is allowed, so other users
+	// need not be able to connect at all. With rules the ACL decides.
+	mode := os.FileMode(0600)
+	if hasRules {
+		mode = 0666
+	}
+
+	listener, err := agent.ListenUnix(path, mode)
+	if err != nil {
+		return nil, "", err
+	}
+	return listener, path, nil
+}
+
+// validateLoopback rejects TCP addresses reachable from other hosts
+func validateLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("address %q is not a loopback address", addr)
+}
+
+func resolveEnvironments(cfg *config.Config, names []string) ([]string, error) {
+	if len(names) == 0 {
+		for name := range cfg.Environments {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+
+	for _, name := range names {
+		if _, err := cfg.GetEnvironment(name); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+func resolveRefresh(cfg *config.Config, flagValue time.Duration) (time.Duration, error) {
+	if flagValue > 0 {
+		return flagValue, nil
+	}
+	if cfg.Agent.Refresh == "" {
+		return defaultRefresh, nil
+	}
+
+	d, err := config.ParseDuration(cfg.Agent.Refresh)
+	if err != nil {
+		return 0, fmt.Errorf("agent.refresh: %w", err)
+	}
+	return d, nil
+}
+
+// aclRules converts the .envyrc rules, resolving tokens from the environment
+func aclRules(configured []config.AgentACLRule) ([]agent.Rule, error) {
+	rules := make([]agent.Rule, 0, len(configured))
+	for i, c := range configured {
+		rule := agent.Rule{
+			Process:      c.Process,
+			UIDs:         c.UIDs,
+			Environments: c.Environments,
+			Keys:         c.Keys,
+		}
+		if c.TokenEnv != "" {
+			rule.Token = os.Getenv(c.TokenEnv)
+			if rule.Token == "" {
+				return nil, fmt.Errorf("agent acl rule %d: environment variable %s is not set", i+1, c.TokenEnv)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
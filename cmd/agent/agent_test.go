@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAgentCmd(t *testing.T) {
+	cmd := GetAgentCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "agent", cmd.Use)
+	assert.NotNil(t, cmd.RunE)
+
+	get, _, err := cmd.Find([]string{"get"})
+	require.NoError(t, err)
+	assert.Equal(t, "get KEY", get.Use)
+}
+
+func TestAgentCommandFlags(t *testing.T) {
+	cmd := GetAgentCmd()
+
+	assert.NotNil(t, cmd.Flags().Lookup("env"))
+	assert.NotNil(t, cmd.Flags().Lookup("socket"))
+	assert.NotNil(t, cmd.Flags().Lookup("addr"))
+	assert.NotNil(t, cmd.Flags().Lookup("refresh"))
+	assert.NotNil(t, cmd.Flags().Lookup("notify-pid"))
+	assert.NotNil(t, cmd.Flags().Lookup("notify-signal"))
+
+	assert.NotNil(t, getCmd.Flags().Lookup("env"))
+	assert.NotNil(t, getCmd.Flags().Lookup("socket"))
+	assert.NotNil(t, getCmd.Flags().Lookup("token-env"))
+}
+
+func TestValidateLoopback(t *testing.T) {
+	assert.NoError(t, validateLoopback("127.0.0.1:8200"))
+	assert.NoError(t, validateLoopback("[::1]:8200"))
+	assert.NoError(t, validateLoopback("localhost:8200"))
+	assert.Error(t, validateLoopback("0.0.0.0:8200"))
+	assert.Error(t, validateLoopback(":8200"))
+	assert.Error(t, validateLoopback("10.0.0.1:8200"))
+	assert.Error(t, validateLoopback("127.0.0.1"))
+}
+
+func TestResolveRefresh(t *testing.T) {
+	cfg := &config.Config{}
+
+	d, err := resolveRefresh(cfg, 0)
+	require.NoError(t, err)
+	assert.Equal(t, defaultRefresh, d)
+
+	cfg.Agent.Refresh = "30s"
+	d, err = resolveRefresh(cfg, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, d)
+
+	d, err = resolveRefresh(cfg, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, d)
+
+	cfg.Agent.Refresh = "soon"
+	_, err = resolveRefresh(cfg, 0)
+	assert.Error(t, err)
+}
+
+func TestACLRules(t *testing.T) {
+	t.Setenv("API_AGENT_TOKEN", "abc")
+
+	rules, err := aclRules([]config.AgentACLRule{
+		{Process: "/usr/bin/api", Keys: []string{"DB_*"}},
+		{TokenEnv: "API_AGENT_TOKEN", Environments: []string{"dev"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "/usr/bin/api", rules[0].Process)
+	assert.Equal(t, "abc", rules[1].Token)
+
+	_, err = aclRules([]config.AgentACLRule{{TokenEnv: "ENVY_TEST_UNSET_TOKEN"}})
+	assert.Error(t, err)
+}
+
+func TestServiceUnit(t *testing.T) {
+	path, unit, err := serviceUnit("linux", "/home/ada", "/usr/local/bin/envy")
+	require.NoError(t, err)
+	assert.Equal(t, "/home/ada/.config/systemd/user/envy-agent.service", path)
+	assert.Contains(t, unit, "ExecStart=/usr/local/bin/envy agent --system\n")
+
+	_, unit, err = serviceUnit("linux", "/home/ada", "/opt/my tools/envy")
+	require.NoError(t, err)
+	assert.Contains(t, unit, `ExecStart="/opt/my tools/envy" agent --system`)
+
+	path, unit, err = serviceUnit("darwin", "/Users/ada", "/opt/homebrew/bin/envy")
+	require.NoError(t, err)
+	assert.Equal(t, "/Users/ada/Library/LaunchAgents/dev.envy.agent.plist", path)
+	assert.Contains(t, unit, "<string>/opt/homebrew/bin/envy</string>\n\t\t<string>agent</string>\n\t\t<string>--system</string>")
+
+	_, _, err = serviceUnit("windows", `C:\Users\ada`, "envy.exe")
+	assert.ErrorContains(t, err, "not supported on windows")
+}
+
+func TestRunSystem_Flags(t *testing.T) {
+	defer func() { environments = nil }()
+	environments = []string{"prod"}
+	assert.ErrorContains(t, runSystem(), "cannot be combined with --env")
+
+	assert.NotNil(t, GetAgentCmd().Flags().Lookup("system"))
+	install, _, err := GetAgentCmd().Find([]string{"install"})
+	require.NoError(t, err)
+	assert.NotNil(t, install.Flags().Lookup("print"))
+}
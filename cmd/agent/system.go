@@ -0,0 +1,285 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/drapon/envy/internal/agent"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/log"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+const (
+	// systemdUnit is the name of the systemd user service of the system agent
+	systemdUnit = "envy-agent.service"
+	// launchdLabel is the label of the launchd agent of the system agent
+	launchdLabel = "dev.envy.agent"
+)
+
+// printUnit makes install show the service definition instead of installing it
+var printUnit bool
+
+// installCmd represents the agent install command
+var installCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Run the system agent as a user service started at login",
+	Long: `Install and start 'envy agent --system' as a systemd user service on Linux
+or a launchd agent on macOS, so it runs in the background from login and
+every shell shares it.`,
+	Example: `  # Install and start the service
+  envy agent install
+
+  # Show the service definition without installing it
+  envy agent install --print`,
+	Args: cobra.NoArgs,
+	RunE: runInstall,
+}
+
+// uninstallCmd represents the agent uninstall command
+var uninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Stop the system agent service and remove it",
+	Args:  cobra.NoArgs,
+	RunE:  runUninstall,
+}
+
+func init() {
+	agentCmd.AddCommand(installCmd)
+	agentCmd.AddCommand(uninstallCmd)
+
+	installCmd.Flags().BoolVar(&printUnit, "print", false, "Print the service definition instead of installing it")
+}
+
+// runSystem serves every project of the user on the system socket
+func runSystem() error {
+	if len(environments) > 0 || listenAddr != "" || notifyPID > 0 {
+		return fmt.Errorf("--system serves every project on its own socket and cannot be combined with --env, --addr or --notify-pid")
+	}
+	logger := log.WithContext(zap.String("command", "agent"), zap.Bool("system", true))
+
+	ttl, err := resolveRefresh(&config.Config{}, refreshEvery)
+	if err != nil {
+		return err
+	}
+
+	// Only the user's own processes may read whole environments
+	acl, err := agent.NewACL(nil, os.Getuid())
+	if err != nil {
+		return fmt.Errorf("the system agent is not supported on %s: %w", runtime.GOOS, err)
+	}
+
+	path := socketPath
+	if path == "" {
+		path = agent.SystemSocketPath()
+	}
+	listener, err := agent.ListenUnix(path, 0600)
+	if err != nil {
+		return err
+	}
+
+	projects := agent.NewProjects(openProject, ttl)
+	server := &agent.Server{
+		ACL:      acl,
+		Projects: projects,
+		Logger:   logger,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go projects.Run(ctx, func(err error) {
+		logger.Warn("Failed to refresh variables, serving cached values", zap.Error(err))
+	})
+
+	color.PrintInfof("Serving every project on %s (Ctrl+C to stop)", path)
+	return server.Serve(ctx, listener)
+}
+
+// openProject reads the AWS variables of the project configured at
+// configPath, with profile in place of the configured one when set
+func openProject(configPath, profile string) (agent.Source, []string, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.UsesAzure() {
+		return nil, nil, fmt.Errorf("the system agent only serves AWS projects")
+	}
+	if profile != "" {
+		cfg.AWS.Profile = profile
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+	names := make([]string, 0, len(cfg.Environments))
+	for name := range cfg.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return awsManager, names, nil
+}
+
+func runInstall(cmd *cobra.Command, args []string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the envy executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+
+	path, unit, err := serviceUnit(runtime.GOOS, home, executable)
+	if err != nil {
+		return err
+	}
+	if printUnit {
+		fmt.Print(unit)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(unit), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	color.PrintInfof("Wrote %s", path)
+
+	for _, command := range startCommands(runtime.GOOS, path) {
+		if err := runService(command); err != nil {
+			return err
+		}
+	}
+	color.PrintSuccessf("The system agent is running on %s", agent.SystemSocketPath())
+	return nil
+}
+
+func runUninstall(cmd *cobra.Command, args []string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	path, _, err := serviceUnit(runtime.GOOS, home, "envy")
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		color.PrintInfof("The system agent is not installed")
+		return nil
+	}
+
+	for _, command := range stopCommands(runtime.GOOS, path) {
+		if err := runService(command); err != nil {
+			color.PrintWarningf("%v", err)
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	color.PrintSuccessf("Removed %s", path)
+	return nil
+}
+
+// serviceUnit returns where the service definition of the system agent is
+// installed for goos and its content
+func serviceUnit(goos, home, executable string) (string, string, error) {
+	switch goos {
+	case "linux":
+		unit := fmt.Sprintf(`[Unit]
+Description=envy system agent, shared by every shell
+
+[Service]
+ExecStart=%s agent --system
+Restart=on-failure
+
+[Install]
+WantedBy=default.target
+`, systemdQuote(executable))
+		return filepath.Join(home, ".config", "systemd", "user", systemdUnit), unit, nil
+	case "darwin":
+		logFile := filepath.Join(home, "Library", "Logs", "envy-agent.log")
+		unit := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>%s</string>
+		<string>agent</string>
+		<string>--system</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, launchdLabel, html.EscapeString(executable), html.EscapeString(logFile))
+		return filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist"), unit, nil
+	}
+	return "", "", fmt.Errorf("envy agent install is not supported on %s; run 'envy agent --system' at login instead", goos)
+}
+
+// systemdQuote quotes a path for ExecStart when it has spaces
+func systemdQuote(path string) string {
+	if !strings.ContainsAny(path, " \t\"") {
+		return path
+	}
+	return `"` + strings.ReplaceAll(path, `"`, `\"`) + `"`
+}
+
+// startCommands load and start the installed service
+func startCommands(goos, path string) [][]string {
+	if goos == "darwin" {
+		domain := fmt.Sprintf("gui/%d", os.Getuid())
+		return [][]string{
+			{"launchctl", "bootout", domain + "/" + launchdLabel},
+			{"launchctl", "bootstrap", domain, path},
+		}
+	}
+	return [][]string{
+		{"systemctl", "--user", "daemon-reload"},
+		{"systemctl", "--user", "enable", "--now", systemdUnit},
+	}
+}
+
+// stopCommands stop the service and unload it
+func stopCommands(goos, path string) [][]string {
+	if goos == "darwin" {
+		return [][]string{{"launchctl", "bootout", fmt.Sprintf("gui/%d/%s", os.Getuid(), launchdLabel)}}
+	}
+	return [][]string{{"systemctl", "--user", "disable", "--now", systemdUnit}}
+}
+
+// runService runs a service manager command. Unloading a launchd agent
+// that is not loaded fails, which is expected on first install.
+func runService(command []string) error {
+	out, err := exec.Command(command[0], command[1:]...).CombinedOutput()
+	if err != nil && command[1] != "bootout" {
+		return fmt.Errorf("%s failed: %v: %s", strings.Join(command, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
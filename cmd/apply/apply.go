@@ -0,0 +1,375 @@
+package apply
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	awserrors "github.com/drapon/envy/internal/aws/errors"
+	parameter_store "github.com/drapon/envy/internal/aws/parameter_store"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/history"
+	"github.com/drapon/envy/internal/policy"
+	"github.com/drapon/envy/internal/precheck"
+	"github.com/drapon/envy/internal/schedule"
+)
+
+// DefaultScheduleParameter is the Parameter Store path of scheduled change
+// sets with --store ssm. {project} is replaced with the project name.
+const DefaultScheduleParameter = "/envy/scheduled/{project}"
+
+// pollInterval is how often a waiting apply checks for cancellation
+const pollInterval = 10 * time.Second
+
+var (
+	after    string
+	detach   bool
+	due      bool
+	cancelID string
+	list     bool
+	store    string
+)
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply [plan.json]",
+	Short: "Apply a change set after a delay that allows cancelling it",
+	Long: `Schedule a change set and apply it only after a delay, giving a window to
+abort risky changes, e.g. to production.
+
+A plan is a JSON file with the environment, the variables to write and the
+keys to delete; 'envy push --save-plan plan.json' writes one:
+
+  {"environment": "prod", "set": {"API_URL": "https://..."}, "delete": ["OLD_KEY"]}
+
+'envy apply --after 30m plan.json' schedules the plan, prints its ID and
+waits; when the delay has passed the plan is applied unless it was cancelled
+with 'envy apply --cancel ID' in the meantime. With --detach the command
+returns at once and 'envy apply --due', e.g. from cron or CI, applies every
+scheduled plan whose delay has passed.
+
+When a plan is applied it goes through the same checks as 'envy push' again,
+since .envyrc or the rules may have changed since it was saved: placeholder
+values in protected environments, allowed writers and, with
+validate_on_push, the validation rules. A plan that fails them is dropped
+without writing anything. Replica regions set up with 'envy replicate' are
+written as on push.
+
+Scheduled plans are kept in .envy/scheduled by default. With --store ssm
+they are kept as SecureString parameters under ` + DefaultScheduleParameter + `,
+so they can be listed and cancelled from any machine.`,
+	Example: `  # Apply a prod change in 30 minutes, unless cancelled
+  envy push --env prod --save-plan plan.json
+  envy apply --after 30m plan.json
+
+  # Schedule from CI and let a later job apply it
+  envy apply --after 1h --detach --store ssm plan.json
+  envy apply --due --store ssm
+
+  # See and cancel pending changes
+  envy apply --list
+  envy apply --cancel 20260301-120000-a1b2c3`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runApply,
+}
+
+// GetApplyCmd returns the apply command.
+func GetApplyCmd() *cobra.Command {
+	return applyCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(applyCmd)
+
+	applyCmd.Flags().StringVar(&after, "after", "", "Delay before the plan is applied (e.g. 30m, 2h)")
+	applyCmd.Flags().BoolVar(&detach, "detach", false, "Schedule the plan and return; apply it later with --due")
+	applyCmd.Flags().BoolVar(&due, "due", false, "Apply every scheduled plan whose delay has passed")
+	applyCmd.Flags().StringVar(&cancelID, "cancel", "", "Cancel the scheduled plan with this ID")
+	applyCmd.Flags().BoolVar(&list, "list", false, "List scheduled plans")
+	applyCmd.Flags().StringVar(&store, "store", "local", "Where scheduled plans are kept (local/ssm)")
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	modes := 0
+	for _, set := range []bool{len(args) == 1, due, cancelID != "", list} {
+		if set {
+			modes++
+		}
+	}
+	if modes != 1 {
+		return fmt.Errorf("give a plan file, or one of --due, --cancel or --list")
+	}
+	if store != "local" && store != "ssm" {
+		return fmt.Errorf("invalid store %q (use local or ssm)", store)
+	}
+	var delay time.Duration
+	if len(args) == 1 {
+		if after == "" {
+			return fmt.Errorf("--after is required to schedule a plan")
+		}
+		d, err := config.ParseDuration(after)
+		if err != nil {
+			return fmt.Errorf("invalid --after: %w", err)
+		}
+		delay = d
+	}
+
+	// Load configuration
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	var awsManager *aws.Manager
+	if store == "ssm" || len(args) == 1 || due {
+		if awsManager, err = aws.NewManager(cfg); err != nil {
+			return fmt.Errorf("failed to create AWS manager: %w", err)
+		}
+	}
+	entries := scheduleStore(cfg, awsManager)
+
+	switch {
+	case list:
+		return listScheduled(ctx, entries)
+	case cancelID != "":
+		if err := entries.Delete(ctx, cancelID); err != nil {
+			return fmt.Errorf("failed to cancel %s: %w", cancelID, err)
+		}
+		color.PrintSuccessf("Cancelled %s", cancelID)
+		return nil
+	case due:
+		return applyDue(ctx, cfg, entries, awsManager)
+	}
+
+	plan, err := schedule.LoadPlan(args[0])
+	if err != nil {
+		return err
+	}
+	if _, err := cfg.GetEnvironment(plan.Environment); err != nil {
+		return err
+	}
+	entry, err := schedule.New(ctx, entries, plan, delay, currentUser(), time.Now())
+	if err != nil {
+		return err
+	}
+	color.PrintInfof("Scheduled %s: %d change(s) to %s at %s", entry.ID, len(plan.Keys()), plan.Environment, entry.Due.Local().Format("15:04:05"))
+	fmt.Printf("  %s\n", strings.Join(plan.Keys(), ", "))
+	fmt.Printf("Cancel with: envy apply --cancel %s", entry.ID)
+	if store == "ssm" {
+		fmt.Print(" --store ssm")
+	}
+	fmt.Println()
+
+	if detach {
+		return nil
+	}
+
+	id := entry.ID
+	entry, ok, err := schedule.Wait(ctx, entries, id, pollInterval, time.Now)
+	if err != nil {
+		if ctx.Err() != nil {
+			color.PrintWarningf("Stopped waiting; %s is still scheduled and 'envy apply --due' applies it", id)
+			return nil
+		}
+		return err
+	}
+	if !ok {
+		color.PrintWarningf("%s was cancelled, nothing was applied", id)
+		return nil
+	}
+	return applyEntry(ctx, cfg, entries, awsManager, entry)
+}
+
+// applyDue applies the scheduled plans whose delay has passed
+func applyDue(ctx context.Context, cfg *config.Config, entries schedule.Store, awsManager *aws.Manager) error {
+	dueEntries, err := schedule.Due(ctx, entries, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(dueEntries) == 0 {
+		color.PrintInfof("No scheduled plans are due")
+		return nil
+	}
+
+	for _, entry := range dueEntries {
+		if err := applyEntry(ctx, cfg, entries, awsManager, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remoteStore is the part of the AWS manager applying a plan uses
+type remoteStore interface {
+	history.Fetcher
+	ApplyChanges(ctx context.Context, envName string, set *env.File, deleted []string) error
+	CallerARN(ctx context.Context) (string, error)
+	IsSensitive(key string) bool
+}
+
+// applyEntry claims a due entry by deleting it, so a concurrent cancel or
+// apply cannot also act on it, then applies its plan
+func applyEntry(ctx context.Context, cfg *config.Config, entries schedule.Store, awsManager remoteStore, entry *schedule.Entry) error {
+	if err := entries.Delete(ctx, entry.ID); err != nil {
+		color.PrintWarningf("%s was cancelled, nothing was applied", entry.ID)
+		return nil
+	}
+
+	plan := entry.Plan
+	set := env.NewFile()
+	for _, key := range plan.Keys() {
+		if value, ok := plan.Set[key]; ok {
+			set.Set(key, value)
+		}
+	}
+
+	// The plan was checked when it was saved, but the rules may have
+	// changed since
+	envConfig, err := cfg.GetEnvironment(plan.Environment)
+	if err != nil {
+		return err
+	}
+	if err := precheck.Placeholders(cfg, plan.Environment, envConfig, set, false); err != nil {
+		return fmt.Errorf("not applying %s: %w", entry.ID, err)
+	}
+	if err := precheck.Writers(ctx, cfg, awsManager, precheck.Keys(set, plan.Delete)); err != nil {
+		return fmt.Errorf("not applying %s: %w", entry.ID, err)
+	}
+	if cfg.ValidateOnPush {
+		if err := precheck.Validate(ctx, plan.Environment, set, nil, true); err != nil {
+			return fmt.Errorf("not applying %s: %w", entry.ID, err)
+		}
+	}
+	// Policies added since the plan was saved, or a plan edited by hand,
+	// must not get around them
+	var plaintext func(key string) bool
+	if cfg.GetAWSService(plan.Environment) != "secrets_manager" {
+		plaintext = func(key string) bool { return !awsManager.IsSensitive(key) }
+	}
+	if err := policy.Check(cfg, policy.Push{Environment: plan.Environment, Vars: set, Force: plan.Force, Plaintext: plaintext}); err != nil {
+		return fmt.Errorf("not applying %s: %w", entry.ID, err)
+	}
+
+	pending, err := history.Begin(ctx, cfg, awsManager, plan.Environment, "apply", plan.Keys())
+	if err != nil {
+		color.PrintWarningf("%s will not be recorded for envy undo: %v", entry.ID, err)
+	}
+	if err := awsManager.ApplyChanges(ctx, plan.Environment, set, plan.Delete); err != nil {
+		return fmt.Errorf("failed to apply %s: %w", entry.ID, err)
+	}
+	if err := pending.Commit(plan.Set, plan.Delete); err != nil {
+		color.PrintWarningf("Failed to record %s for envy undo: %v", entry.ID, err)
+	}
+	color.PrintSuccessf("Applied %s to %s: %d set, %d deleted", entry.ID, plan.Environment, len(plan.Set), len(plan.Delete))
+	return nil
+}
+
+// listScheduled prints the scheduled plans
+func listScheduled(ctx context.Context, entries schedule.Store) error {
+	scheduled, err := entries.List(ctx)
+	if err != nil {
+		return err
+	}
+	if len(scheduled) == 0 {
+		color.PrintInfof("No scheduled plans")
+		return nil
+	}
+
+	now := time.Now()
+	for _, entry := range scheduled {
+		when := "due now"
+		if !entry.IsDue(now) {
+			when = "in " + entry.Due.Sub(now).Round(time.Second).String()
+		}
+		by := ""
+		if entry.By != "" {
+			by = " by " + entry.By
+		}
+		fmt.Printf("%s  %-12s %s (%s)%s\n", entry.ID, entry.Plan.Environment, strings.Join(entry.Plan.Keys(), ", "), when, by)
+	}
+	return nil
+}
+
+// scheduleStore returns the store selected with --store
+func scheduleStore(cfg *config.Config, awsManager *aws.Manager) schedule.Store {
+	if store == "ssm" {
+		return &parameterScheduleStore{
+			store: awsManager.GetParameterStore(),
+			path:  strings.ReplaceAll(DefaultScheduleParameter, "{project}", cfg.Project) + "/",
+		}
+	}
+	return &schedule.DirStore{Dir: schedule.DefaultDir}
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// parameterScheduleStore keeps scheduled plans as SecureString parameters
+type parameterScheduleStore struct {
+	store *parameter_store.Store
+	path  string
+}
+
+func (s *parameterScheduleStore) Put(ctx context.Context, entry *schedule.Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.store.PutParameter(ctx, s.path+entry.ID, string(data), "envy scheduled change set", "SecureString", false)
+}
+
+func (s *parameterScheduleStore) Get(ctx context.Context, id string) (*schedule.Entry, bool, error) {
+	param, err := s.store.GetParameter(ctx, s.path+id, true)
+	if err != nil {
+		if awserrors.IsNotFoundError(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	var entry schedule.Entry
+	if err := json.Unmarshal([]byte(param.Value), &entry); err != nil {
+		return nil, false, fmt.Errorf("failed to parse scheduled entry %s: %w", id, err)
+	}
+	return &entry, true, nil
+}
+
+func (s *parameterScheduleStore) Delete(ctx context.Context, id string) error {
+	return s.store.DeleteParameter(ctx, s.path+id)
+}
+
+func (s *parameterScheduleStore) List(ctx context.Context) ([]*schedule.Entry, error) {
+	params, err := s.store.GetParametersByPath(ctx, s.path, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*schedule.Entry
+	for _, param := range params {
+		var entry schedule.Entry
+		if err := json.Unmarshal([]byte(param.Value), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse scheduled entry %s: %w", param.Name, err)
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,159 @@
+package apply
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/schedule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetApplyCmd(t *testing.T) {
+	cmd := GetApplyCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "apply [plan.json]", cmd.Use)
+
+	for _, name := range []string{"after", "detach", "due", "cancel", "list", "store"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestRunApply_Modes(t *testing.T) {
+	defer func() { due, list, cancelID, store = false, false, "", "local" }()
+
+	assert.ErrorContains(t, runApply(applyCmd, nil), "give a plan file")
+
+	due, list = true, true
+	assert.ErrorContains(t, runApply(applyCmd, nil), "give a plan file")
+
+	due, list = false, false
+	assert.ErrorContains(t, runApply(applyCmd, []string{"plan.json"}), "--after")
+	store = "s3"
+	due = true
+	assert.ErrorContains(t, runApply(applyCmd, nil), "invalid store")
+}
+
+func TestApplyEntry_Cancelled(t *testing.T) {
+	ctx := context.Background()
+	entries := &schedule.DirStore{Dir: t.TempDir()}
+	plan := &schedule.Plan{Environment: "prod", Set: map[string]string{"A": "1"}}
+
+	entry, err := schedule.New(ctx, entries, plan, time.Minute, "alice", time.Now())
+	require.NoError(t, err)
+	require.NoError(t, entries.Delete(ctx, entry.ID))
+
+	// The entry can no longer be claimed, so nothing reaches AWS
+	assert.NoError(t, applyEntry(ctx, &config.Config{}, entries, nil, entry))
+}
+
+// fakeStore records the changes applied to AWS
+type fakeStore struct {
+	arn     string
+	applied int
+}
+
+func (f *fakeStore) GetVariables(ctx context.Context, envName string, keys []string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (f *fakeStore) ApplyChanges(ctx context.Context, envName string, set *env.File, deleted []string) error {
+	f.applied++
+	return nil
+}
+
+func (f *fakeStore) CallerARN(ctx context.Context) (string, error) {
+	return f.arn, nil
+}
+
+func (f *fakeStore) IsSensitive(key string) bool {
+	return key == "API_KEY"
+}
+
+func TestApplyEntry_DeniedWriter(t *testing.T) {
+	ctx := context.Background()
+	entries := &schedule.DirStore{Dir: t.TempDir()}
+	cfg := &config.Config{
+		Environments: map[string]config.Environment{"prod": {}},
+		Variables: []config.VariableConfig{
+			{Key: "STRIPE_*", AllowedWriters: []string{"payments-deploy"}},
+		},
+		EnforceWriters: true,
+	}
+	plan := &schedule.Plan{Environment: "prod", Set: map[string]string{"PORT": "8080"}, Delete: []string{"STRIPE_KEY"}}
+
+	entry, err := schedule.New(ctx, entries, plan, 0, "alice", time.Now())
+	require.NoError(t, err)
+
+	store := &fakeStore{arn: "arn:aws:sts::123456789012:assumed-role/developer/alice"}
+	err = applyEntry(ctx, cfg, entries, store, entry)
+	assert.ErrorContains(t, err, "is not an allowed writer of STRIPE_KEY")
+	assert.Equal(t, 0, store.applied)
+}
+
+func TestApplyEntry_Rechecks(t *testing.T) {
+	ctx := context.Background()
+	entries := &schedule.DirStore{Dir: t.TempDir()}
+	cfg := &config.Config{Environments: map[string]config.Environment{"prod": {Protected: true}}}
+	plan := &schedule.Plan{Environment: "prod", Set: map[string]string{"API_KEY": "changeme"}}
+
+	entry, err := schedule.New(ctx, entries, plan, 0, "alice", time.Now())
+	require.NoError(t, err)
+
+	store := &fakeStore{}
+	err = applyEntry(ctx, cfg, entries, store, entry)
+	assert.ErrorContains(t, err, "placeholder")
+	assert.Equal(t, 0, store.applied)
+}
+
+func TestApplyEntry_Policies(t *testing.T) {
+	ctx := context.Background()
+	entries := &schedule.DirStore{Dir: t.TempDir()}
+	historyDir := t.TempDir()
+	cfg := &config.Config{
+		Environments: map[string]config.Environment{"prod": {}},
+		History:      config.HistoryConfig{Dir: historyDir, KeyFile: filepath.Join(historyDir, "history.key")},
+		Policies: []config.PolicyConfig{
+			{Name: "prod-force", Environments: []string{"prod"}, RequireForce: true},
+			{Name: "no-plaintext-passwords", PlaintextKeys: []string{"*PASSWORD*"}},
+		},
+	}
+	store := &fakeStore{}
+
+	// A plan saved before the policy was added
+	plan := &schedule.Plan{Environment: "prod", Set: map[string]string{"PORT": "8080"}}
+	entry, err := schedule.New(ctx, entries, plan, 0, "alice", time.Now())
+	require.NoError(t, err)
+	err = applyEntry(ctx, cfg, entries, store, entry)
+	assert.ErrorContains(t, err, "refused by policy prod-force")
+	assert.Equal(t, 0, store.applied)
+
+	// A plan edited by hand after it was saved with --force
+	plan = &schedule.Plan{Environment: "prod", Set: map[string]string{"DB_PASSWORD": "hunter2"}, Force: true}
+	entry, err = schedule.New(ctx, entries, plan, 0, "alice", time.Now())
+	require.NoError(t, err)
+	err = applyEntry(ctx, cfg, entries, store, entry)
+	assert.ErrorContains(t, err, "refused by policy no-plaintext-passwords")
+	assert.Equal(t, 0, store.applied)
+
+	plan = &schedule.Plan{Environment: "prod", Set: map[string]string{"PORT": "8080"}, Force: true}
+	entry, err = schedule.New(ctx, entries, plan, 0, "alice", time.Now())
+	require.NoError(t, err)
+	require.NoError(t, applyEntry(ctx, cfg, entries, store, entry))
+	assert.Equal(t, 1, store.applied)
+}
+
+func TestListScheduled(t *testing.T) {
+	ctx := context.Background()
+	entries := &schedule.DirStore{Dir: t.TempDir()}
+	assert.NoError(t, listScheduled(ctx, entries))
+
+	plan := &schedule.Plan{Environment: "prod", Set: map[string]string{"A": "1"}, Delete: []string{"B"}}
+	_, err := schedule.New(ctx, entries, plan, time.Hour, "alice", time.Now())
+	require.NoError(t, err)
+	assert.NoError(t, listScheduled(ctx, entries))
+}
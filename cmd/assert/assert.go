@@ -0,0 +1,189 @@
+// Package assertcmd implements the assert command. It is not named after its
+// directory to avoid clashing with the testify assert package of its tests.
+package assertcmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/assertion"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/inline"
+	"github.com/drapon/envy/internal/remote"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/drapon/envy/internal/structured"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	environment string
+	from        string
+	format      string
+)
+
+// assertCmd represents the assert command
+var assertCmd = &cobra.Command{
+	Use:   "assert EXPRESSION...",
+	Short: "Check that variables have the expected values",
+	Long: `Evaluate simple expressions against the variables of an environment and
+report each as passed or failed, for smoke tests in deploy pipelines that
+do not warrant a rules file.
+
+An expression is a variable, or len(VARIABLE) for the length of its value,
+followed by an operator and a value:
+
+  ==  !=        equal; numbers compare as numbers, true/false as booleans
+  <  <=  >  >=  numeric comparison
+  =~  !~        regular expression match
+
+A variable on its own must be set and not empty; !VARIABLE must not be.
+Quote values with ' or " to keep spaces, and quote each expression for the
+shell. The values of sensitive variables are never shown.
+
+The command exits with status 1 when any assertion fails.`,
+	Example: `  # Smoke test prod before a deploy
+  envy assert --env prod --from aws 'PORT == 443' 'DEBUG == false' 'len(API_KEY) >= 32'
+
+  # Check the local files of the default environment
+  envy assert 'DATABASE_URL =~ ^postgres://' 'SENTRY_DSN' '!LEGACY_TOKEN'
+
+  # As JSON, for the pipeline to parse
+  envy assert --env staging --from aws --format json 'LOG_LEVEL != debug'`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runAssert,
+}
+
+// GetAssertCmd returns the assert command.
+func GetAssertCmd() *cobra.Command {
+	return assertCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(assertCmd)
+
+	assertCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to check (default: default_environment)")
+	assertCmd.Flags().StringVar(&from, "from", "local", "Source of values (local/aws)")
+	assertCmd.Flags().StringVarP(&format, "format", "f", "text", "Output format (text/json/yaml)")
+}
+
+// report is the JSON output of assert
+type report struct {
+	Environment string             `json:"environment"`
+	Source      string             `json:"source"`
+	Passed      bool               `json:"passed"`
+	Results     []assertion.Result `json:"results"`
+}
+
+func runAssert(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	// --output json|yaml|table applies unless --format is given
+	var err error
+	if format, err = structured.ResolveFormat(cmd); err != nil {
+		return err
+	}
+	if format != "text" && format != "json" && format != "yaml" {
+		return fmt.Errorf("unsupported format: %s (use text, json or yaml)", format)
+	}
+	if from != "local" && from != "aws" {
+		return fmt.Errorf("unsupported source: %s (use local or aws)", from)
+	}
+	assertions, err := assertion.ParseAll(args)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+	isSensitive, err := sensitive.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	vars, err := loadVariables(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	results, passed := assertion.EvaluateAll(assertions, vars, isSensitive)
+	r := report{
+		Environment: environment,
+		Source:      from,
+		Passed:      passed,
+		Results:     results,
+	}
+	if format != "text" {
+		if err := structured.Write(os.Stdout, structured.Format(format), r); err != nil {
+			return err
+		}
+	} else if err := writeReport(os.Stdout, r); err != nil {
+		return err
+	}
+
+	if !passed {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// loadVariables reads the variables of the environment from its files or
+// from the remote store
+func loadVariables(ctx context.Context, cfg *config.Config) (map[string]string, error) {
+	envConfig, err := cfg.GetEnvironment(environment)
+	if err != nil {
+		return nil, err
+	}
+
+	if from == "local" {
+		file, err := env.NewManager(".").LoadFiles(envConfig.Files)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load environment files: %w", err)
+		}
+		if err := inline.Decrypt(ctx, cfg, file); err != nil {
+			return nil, err
+		}
+		return file.ToMap(), nil
+	}
+
+	backend, err := remote.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := backend.ListEnvironmentVariables(ctx, environment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", environment, err)
+	}
+	return vars, nil
+}
+
+func writeReport(w io.Writer, r report) error {
+	failed := 0
+	for _, result := range r.Results {
+		line := fmt.Sprintf("  %s %s", color.FormatSuccess("✓"), result.Expression)
+		if !result.Passed {
+			failed++
+			line = fmt.Sprintf("  %s %s: %s", color.FormatError("✗"), result.Expression, result.Detail)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		color.PrintErrorf("%d of %d assertion(s) failed for %s (%s)", failed, len(r.Results), r.Environment, r.Source)
+		return nil
+	}
+	color.PrintSuccessf("All %d assertion(s) passed for %s (%s)", len(r.Results), r.Environment, r.Source)
+	return nil
+}
@@ -0,0 +1,46 @@
+package assertcmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/drapon/envy/internal/assertion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAssertCmd(t *testing.T) {
+	cmd := GetAssertCmd()
+	assert.Equal(t, "assert EXPRESSION...", cmd.Use)
+	for _, name := range []string{"env", "from", "format"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeReport(&buf, report{
+		Environment: "prod",
+		Source:      "aws",
+		Results: []assertion.Result{
+			{Expression: "PORT == 443", Key: "PORT", Passed: true},
+			{Expression: "DEBUG == false", Key: "DEBUG", Detail: `DEBUG is "true"`},
+		},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "PORT == 443\n")
+	assert.Contains(t, buf.String(), `DEBUG == false: DEBUG is "true"`)
+}
+
+func TestRunAssert_InvalidInput(t *testing.T) {
+	defer func() { format, from = "text", "local" }()
+
+	format = "xml"
+	assert.ErrorContains(t, runAssert(assertCmd, []string{"PORT"}), "unsupported format")
+
+	format, from = "text", "s3"
+	assert.ErrorContains(t, runAssert(assertCmd, []string{"PORT"}), "unsupported source")
+
+	from = "local"
+	assert.ErrorContains(t, runAssert(assertCmd, []string{"PORT = 443"}), "invalid assertion")
+}
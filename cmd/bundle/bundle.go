@@ -0,0 +1,302 @@
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/artifact"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/bundle"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	environment string
+	output      string
+	keyFile     string
+	signingKey  string
+	publicKey   string
+	keyDir      string
+	force       bool
+	maxAge      time.Duration
+	signKey     string
+)
+
+// bundleCmd represents the bundle command
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Create and open signed, encrypted snapshots of an environment",
+	Long: `Create read-only snapshots of an environment for deploys without access
+to AWS, such as air-gapped sites and disaster recovery runbooks.
+
+A bundle holds the variables of an environment encrypted with a shared key
+(AES-256-GCM), with metadata and a fingerprint of the values, signed with an
+Ed25519 key. Consumers need the shared key to read the values and the
+public key to check that the bundle came from a trusted creator and was not
+altered.
+
+The shared key is read from --key-file or the ENVY_BUNDLE_KEY environment
+variable.`,
+}
+
+// keygenCmd represents the bundle keygen command
+var keygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Generate a bundle key and signing key pair",
+	Long: `Generate the keys bundles are created with, in --dir:
+
+  bundle.key   the shared encryption key, for creators and consumers
+  signing.pem  the private signing key, for creators only
+  signing.pub  the public key consumers verify bundles with
+
+Existing files are never overwritten.`,
+	Example: `  envy bundle keygen --dir ~/.config/envy/bundle`,
+	Args:    cobra.NoArgs,
+	RunE:    runKeygen,
+}
+
+// createCmd represents the bundle create command
+var createCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Snapshot an environment from AWS into a bundle",
+	Long: `Pull an environment from AWS and write it to a bundle encrypted with the
+shared key and signed with --signing-key. The bundle records the
+environment, its parameter path, when and by whom it was created, and a
+fingerprint that extract checks the decrypted values against.
+
+With --sign, a detached signature is also written to <bundle>.sig, so
+systems that do not use envy bundles can check the file with 'envy
+verify-artifact', e.g. against a KMS key.`,
+	Example: `  envy bundle create --env prod -o prod.envybundle \
+    --key-file keys/bundle.key --signing-key keys/signing.pem`,
+	Args: cobra.NoArgs,
+	RunE: runCreate,
+}
+
+// verifyCmd represents the bundle verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify <bundle>",
+	Short: "Check a bundle's signature and show its metadata",
+	Long: `Check that a bundle was signed with the private key of --public-key and
+show what it holds. The values are not decrypted, so no bundle key is
+needed.`,
+	Example: `  envy bundle verify prod.envybundle --public-key keys/signing.pub`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runVerify,
+}
+
+// extractCmd represents the bundle extract command
+var extractCmd = &cobra.Command{
+	Use:   "extract <bundle>",
+	Short: "Verify a bundle and write its variables as a .env file",
+	Long: `Verify a bundle's signature, decrypt it and write its variables as a
+.env file, or to standard output without --output.`,
+	Example: `  envy bundle extract prod.envybundle --public-key keys/signing.pub -o .env.prod
+
+  # With the key from the environment
+  ENVY_BUNDLE_KEY=$KEY envy bundle extract prod.envybundle --public-key signing.pub`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExtract,
+}
+
+// GetBundleCmd returns the bundle command.
+func GetBundleCmd() *cobra.Command {
+	return bundleCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(bundleCmd)
+	bundleCmd.AddCommand(keygenCmd)
+	bundleCmd.AddCommand(createCmd)
+	bundleCmd.AddCommand(verifyCmd)
+	bundleCmd.AddCommand(extractCmd)
+
+	keygenCmd.Flags().StringVar(&keyDir, "dir", ".", "Directory to write the keys to")
+
+	createCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to bundle (default: default_environment)")
+	createCmd.Flags().StringVarP(&output, "output", "o", "", "Bundle file to write (default: <env>.envybundle)")
+	createCmd.Flags().StringVar(&keyFile, "key-file", "", "Shared encryption key (default: $ENVY_BUNDLE_KEY)")
+	createCmd.Flags().StringVar(&signingKey, "signing-key", "", "Private key to sign the bundle with")
+	createCmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing bundle file")
+	createCmd.Flags().StringVar(&signKey, "sign", "", "Also write a detached signature with this key (kms:<key>, cosign:<ref> or an Ed25519 key file)")
+	_ = createCmd.MarkFlagRequired("signing-key")
+
+	verifyCmd.Flags().StringVar(&publicKey, "public-key", "", "Public key of the bundle's creator")
+	_ = verifyCmd.MarkFlagRequired("public-key")
+
+	extractCmd.Flags().StringVarP(&output, "output", "o", "", "File to write (default: standard output)")
+	extractCmd.Flags().StringVar(&keyFile, "key-file", "", "Shared encryption key (default: $ENVY_BUNDLE_KEY)")
+	extractCmd.Flags().StringVar(&publicKey, "public-key", "", "Public key of the bundle's creator")
+	extractCmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing output file")
+	extractCmd.Flags().DurationVar(&maxAge, "max-age", 0, "Refuse bundles older than this (default: any age)")
+	_ = extractCmd.MarkFlagRequired("public-key")
+}
+
+func runKeygen(cmd *cobra.Command, args []string) error {
+	paths, err := bundle.GenerateKeys(keyDir)
+	if err != nil {
+		return err
+	}
+	color.PrintSuccessf("Generated bundle keys:")
+	for _, path := range paths {
+		fmt.Printf("  %s\n", path)
+	}
+	color.PrintWarningf("Keep %s secret; share %s only with the consumers of your bundles", bundle.SigningKeyFile, bundle.KeyFile)
+	return nil
+}
+
+func runCreate(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.UsesAzure() {
+		return fmt.Errorf("envy bundle create is only supported with AWS")
+	}
+	envName := environment
+	if envName == "" {
+		envName = cfg.DefaultEnvironment
+	}
+	if _, err := cfg.GetEnvironment(envName); err != nil {
+		return err
+	}
+	if output == "" {
+		output = envName + bundle.Extension
+	}
+	if err := checkOverwrite(output); err != nil {
+		return err
+	}
+
+	key, err := bundle.ResolveKey(keyFile)
+	if err != nil {
+		return err
+	}
+	signer, err := bundle.LoadSigningKey(signingKey)
+	if err != nil {
+		return err
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+	envFile, err := awsManager.PullEnvironment(ctx, envName)
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", envName, err)
+	}
+
+	meta := bundle.Metadata{
+		Environment: envName,
+		Project:     cfg.Project,
+		Source:      cfg.GetParameterPath(envName),
+		CreatedAt:   time.Now().UTC(),
+	}
+	if arn, err := awsManager.CallerARN(ctx); err == nil {
+		meta.CreatedBy = arn
+	}
+
+	b, err := bundle.Create(meta, envFile.ToMap(), envFile.Keys(), key, signer)
+	if err != nil {
+		return err
+	}
+	if err := b.Write(output); err != nil {
+		return err
+	}
+	color.PrintSuccessf("Wrote %d variable(s) of %s to %s (signing key %s)", b.Metadata.Variables, envName, output, b.KeyID)
+
+	if signKey != "" {
+		sigPath, err := artifact.SignFile(ctx, output, signKey, cfg)
+		if err != nil {
+			return fmt.Errorf("failed to sign %s: %w", output, err)
+		}
+		color.PrintSuccessf("Signature written to %s", sigPath)
+	}
+	return nil
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	b, err := readVerified(args[0])
+	if err != nil {
+		return err
+	}
+
+	color.PrintSuccessf("%s is signed by key %s", args[0], b.KeyID)
+	fmt.Printf("  Environment: %s\n", b.Metadata.Environment)
+	if b.Metadata.Project != "" {
+		fmt.Printf("  Project:     %s\n", b.Metadata.Project)
+	}
+	if b.Metadata.Source != "" {
+		fmt.Printf("  Source:      %s\n", b.Metadata.Source)
+	}
+	fmt.Printf("  Created:     %s (%s ago)\n", b.Metadata.CreatedAt.Format(time.RFC3339), b.Age(time.Now()).Round(time.Second))
+	if b.Metadata.CreatedBy != "" {
+		fmt.Printf("  Created by:  %s\n", b.Metadata.CreatedBy)
+	}
+	fmt.Printf("  Variables:   %d\n", b.Metadata.Variables)
+	return nil
+}
+
+func runExtract(cmd *cobra.Command, args []string) error {
+	b, err := readVerified(args[0])
+	if err != nil {
+		return err
+	}
+	if err := b.CheckAge(maxAge, time.Now()); err != nil {
+		return err
+	}
+	key, err := bundle.ResolveKey(keyFile)
+	if err != nil {
+		return err
+	}
+	envFile, err := b.OpenFile(key)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		return envFile.Write(os.Stdout)
+	}
+	if err := checkOverwrite(output); err != nil {
+		return err
+	}
+	if err := envFile.WriteFile(output); err != nil {
+		return err
+	}
+	color.PrintSuccessf("Wrote %d variable(s) of %s to %s", len(envFile.Keys()), b.Metadata.Environment, output)
+	return nil
+}
+
+// readVerified reads a bundle and checks its signature against --public-key
+func readVerified(path string) (*bundle.Bundle, error) {
+	pub, err := bundle.LoadPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	b, err := bundle.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.Verify(pub); err != nil {
+		return nil, fmt.Errorf("%s failed verification: %w", path, err)
+	}
+	return b, nil
+}
+
+// checkOverwrite refuses to replace an existing file without --force
+func checkOverwrite(path string) error {
+	if force {
+		return nil
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	}
+	return nil
+}
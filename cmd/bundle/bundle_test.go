@@ -0,0 +1,18 @@
+package bundle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBundleCmd(t *testing.T) {
+	cmd := GetBundleCmd()
+	assert.Equal(t, "bundle", cmd.Use)
+
+	names := make([]string, 0)
+	for _, sub := range cmd.Commands() {
+		names = append(names, sub.Name())
+	}
+	assert.ElementsMatch(t, []string{"keygen", "create", "verify", "extract"}, names)
+}
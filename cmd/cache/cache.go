@@ -1,18 +1,27 @@
 package cache
 
 import (
+	"context"
 	"fmt"
+	"sort"
 
 	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
 	"github.com/drapon/envy/internal/cache"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
 	"github.com/drapon/envy/internal/log"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
 var (
-	clearAll bool
-	stats    bool
+	clearAll    bool
+	stats       bool
+	environment string
+	warmAll     bool
+	outputDir   string
 )
 
 // CacheCmd represents cache management commands
@@ -37,11 +46,57 @@ configuration file parsing results to improve performance.`,
 	RunE: runCache,
 }
 
+// refreshCmd represents the cache refresh command
+var refreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Refresh the cached values of an environment from AWS",
+	Long: `Check the cached values of an environment against AWS and fetch the
+parameters that changed.
+
+When an environment sets cache_soft_ttl, 'envy pull' and 'envy run' serve
+cached values older than it at once and start this command in the
+background, so they never wait for AWS until cache_hard_ttl has passed.`,
+	Example: `  envy cache refresh --env prod`,
+	Args:    cobra.NoArgs,
+	RunE:    runRefresh,
+}
+
+// warmCmd represents the cache warm command
+var warmCmd = &cobra.Command{
+	Use:   "warm",
+	Short: "Pre-fetch environments into the cache",
+	Long: `Fetch environments from AWS into the cache, so later pulls and runs only
+check parameter versions instead of downloading values.
+
+With --output-dir the values go to a separate, relocatable cache directory
+instead, for a CI cache or a Docker build layer. Its values are always
+encrypted: set cache.encryption_key_file or ENVY_CACHE_ENCRYPTION_KEY. Jobs
+use it by setting ENVY_CACHE_DIR to the restored directory and the same key.`,
+	Example: `  # Warm the default environment in the local cache
+  envy cache warm
+
+  # Build a cache of every environment for CI
+  ENVY_CACHE_ENCRYPTION_KEY=$KEY envy cache warm --all --output-dir /envy-cache
+
+  # In a job that restored /envy-cache
+  ENVY_CACHE_DIR=/envy-cache ENVY_CACHE_ENCRYPTION_KEY=$KEY envy run -- make test`,
+	Args: cobra.NoArgs,
+	RunE: runWarm,
+}
+
 func init() {
 	root.GetRootCmd().AddCommand(CacheCmd)
+	CacheCmd.AddCommand(refreshCmd)
+	CacheCmd.AddCommand(warmCmd)
 
 	CacheCmd.Flags().BoolVar(&clearAll, "clear", false, "Clear all cache")
 	CacheCmd.Flags().BoolVar(&stats, "stats", false, "Display cache statistics")
+
+	refreshCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to refresh (default: default_environment)")
+
+	warmCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to warm (default: default_environment)")
+	warmCmd.Flags().BoolVar(&warmAll, "all", false, "Warm every environment")
+	warmCmd.Flags().StringVar(&outputDir, "output-dir", "", "Write an encrypted, relocatable cache to this directory")
 }
 
 func runCache(cmd *cobra.Command, args []string) error {
@@ -59,6 +114,134 @@ func runCache(cmd *cobra.Command, args []string) error {
 	return showCacheStats(logger)
 }
 
+func runRefresh(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	envName := environment
+	if envName == "" {
+		envName = cfg.DefaultEnvironment
+	}
+	if _, err := cfg.GetEnvironment(envName); err != nil {
+		return err
+	}
+
+	store := cache.Versioned()
+	if store == nil {
+		return fmt.Errorf("refreshing needs an enabled disk or hybrid cache")
+	}
+	// Releases the lock taken by the command that started the refresh
+	defer store.DoneRefresh(envName, cfg.GetParameterPath(envName))
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	envFile, fetched, err := awsManager.PullEnvironmentCached(context.Background(), envName, store)
+	if err != nil {
+		return fmt.Errorf("failed to refresh %s: %w", envName, err)
+	}
+
+	if !viper.GetBool("quiet") {
+		color.PrintSuccessf("Refreshed %s: %d variables, %d value(s) fetched from AWS", envName, len(envFile.Keys()), fetched)
+	}
+	return nil
+}
+
+func runWarm(cmd *cobra.Command, args []string) error {
+	if warmAll && environment != "" {
+		return fmt.Errorf("--all and --env cannot be used together")
+	}
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.UsesAzure() {
+		return fmt.Errorf("cache warming is only supported with AWS")
+	}
+
+	envNames, err := warmEnvironments(cfg)
+	if err != nil {
+		return err
+	}
+
+	store, err := warmStore(outputDir)
+	if err != nil {
+		return err
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	quiet := viper.GetBool("quiet")
+	ctx := context.Background()
+	for _, envName := range envNames {
+		envFile, fetched, err := awsManager.PullEnvironmentCached(ctx, envName, store)
+		if err != nil {
+			return fmt.Errorf("failed to warm %s: %w", envName, err)
+		}
+		if !quiet {
+			color.PrintSuccessf("Warmed %s: %d variables, %d value(s) fetched from AWS", envName, len(envFile.Keys()), fetched)
+		}
+	}
+
+	if outputDir != "" && !quiet {
+		usage := store.Usage()
+		color.PrintInfof("Cache written to %s: %d values (%s)", outputDir, usage.Objects, formatSize(usage.Size))
+		color.PrintInfof("Use it with ENVY_CACHE_DIR=%s and the same encryption key", outputDir)
+	}
+	return nil
+}
+
+// warmEnvironments returns the environments selected by --env and --all
+func warmEnvironments(cfg *config.Config) ([]string, error) {
+	if warmAll {
+		names := make([]string, 0, len(cfg.Environments))
+		for name := range cfg.Environments {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+
+	envName := environment
+	if envName == "" {
+		envName = cfg.DefaultEnvironment
+	}
+	if _, err := cfg.GetEnvironment(envName); err != nil {
+		return nil, err
+	}
+	return []string{envName}, nil
+}
+
+// warmStore returns the store to warm: the local cache, or a new cache in
+// dir. A cache leaving the machine must not hold plaintext secrets, so dir
+// requires an encryption key.
+func warmStore(dir string) (*cache.VersionedStore, error) {
+	if dir == "" {
+		store := cache.Versioned()
+		if store == nil {
+			return nil, fmt.Errorf("warming needs an enabled disk or hybrid cache; use --output-dir to write a separate one")
+		}
+		return store, nil
+	}
+
+	cacheConfig, err := cache.LoadCacheConfigFromViper(viper.GetViper())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cache configuration: %w", err)
+	}
+	if cacheConfig.EncryptionKey == "" {
+		return nil, fmt.Errorf("--output-dir needs an encryption key: set cache.encryption_key_file or ENVY_CACHE_ENCRYPTION_KEY")
+	}
+	return cache.NewVersionedStore(cache.VersionedDir(dir), cacheConfig.EncryptionKey)
+}
+
 // clearCache clears the cache
 func clearCache(logger *zap.Logger) error {
 	cacheManager := cache.GetGlobalCache()
@@ -79,6 +262,9 @@ func clearCache(logger *zap.Logger) error {
 	fmt.Printf("Cache cleared successfully\n")
 	fmt.Printf("  Entries deleted: %d\n", statsBefore.Entries)
 	fmt.Printf("  Size freed: %s\n", formatSize(statsBefore.Size))
+	if v := statsBefore.Versioned; v != nil {
+		fmt.Printf("  Remote values deleted: %d (%s)\n", v.Objects, formatSize(v.Size))
+	}
 
 	logger.Info("Cache cleared",
 		zap.Int("entries_cleared", statsBefore.Entries),
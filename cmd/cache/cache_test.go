@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -8,7 +9,9 @@ import (
 	"github.com/drapon/envy/internal/config"
 	"github.com/drapon/envy/internal/log"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCacheCommand(t *testing.T) {
@@ -212,3 +215,44 @@ func TestFormatSize(t *testing.T) {
 		})
 	}
 }
+
+func TestWarmEnvironments(t *testing.T) {
+	cfg := &config.Config{
+		DefaultEnvironment: "dev",
+		Environments: map[string]config.Environment{
+			"prod": {}, "dev": {}, "staging": {},
+		},
+	}
+	defer func() { warmAll, environment = false, "" }()
+
+	warmAll, environment = true, ""
+	names, err := warmEnvironments(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dev", "prod", "staging"}, names)
+
+	warmAll = false
+	names, err = warmEnvironments(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dev"}, names)
+
+	environment = "qa"
+	_, err = warmEnvironments(cfg)
+	assert.Error(t, err)
+}
+
+func TestWarmStore(t *testing.T) {
+	log.InitLogger(false, "error")
+	defer viper.Reset()
+
+	dir := filepath.Join(t.TempDir(), "envy-cache")
+
+	viper.Set("cache.encryption_key", "")
+	_, err := warmStore(dir)
+	assert.ErrorContains(t, err, "encryption key")
+
+	viper.Set("cache.encryption_key", "ci-key")
+	store, err := warmStore(dir)
+	require.NoError(t, err)
+	assert.DirExists(t, cache.VersionedDir(dir))
+	assert.NotNil(t, store)
+}
@@ -0,0 +1,187 @@
+package changelog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/gitops"
+)
+
+var (
+	environment string
+	since       string
+	until       string
+	format      string
+)
+
+// changelogCmd represents the changelog command
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "List configuration changes between two git revisions",
+	Long: `List the variables added, removed or modified in an environment's files
+between two git revisions, for inclusion in release notes.
+
+Each change shows when it was made and by whom, taken from the commits in the
+range that touched the variable. Variables changed and then changed back are
+left out. Values are never shown.`,
+	Example: `  # Configuration changes in prod since the v1.4.0 release
+  envy changelog --env prod --since v1.4.0
+
+  # Between two releases, as plain text
+  envy changelog --env prod --since v1.4.0 --until v1.5.0 --format text`,
+	Args: cobra.NoArgs,
+	RunE: runChangelog,
+}
+
+// GetChangelogCmd returns the changelog command.
+func GetChangelogCmd() *cobra.Command {
+	return changelogCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(changelogCmd)
+
+	changelogCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment whose files to compare")
+	changelogCmd.Flags().StringVar(&since, "since", "", "Git revision to list changes since (tag, branch or SHA)")
+	changelogCmd.Flags().StringVar(&until, "until", "HEAD", "Git revision to list changes up to")
+	changelogCmd.Flags().StringVarP(&format, "format", "f", "markdown", "Output format (markdown/text/json)")
+	_ = changelogCmd.MarkFlagRequired("since")
+}
+
+func runChangelog(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	// Load configuration
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+	envConfig, err := cfg.GetEnvironment(environment)
+	if err != nil {
+		return err
+	}
+	if len(envConfig.Files) == 0 {
+		return fmt.Errorf("no files configured for environment %s", environment)
+	}
+
+	files := make([]string, len(envConfig.Files))
+	for i, file := range envConfig.Files {
+		files[i] = filepath.ToSlash(file)
+	}
+
+	repo := gitops.NewRepo(".")
+	from, err := repo.ResolveRef(ctx, since)
+	if err != nil {
+		return err
+	}
+	to, err := repo.ResolveRef(ctx, until)
+	if err != nil {
+		return err
+	}
+
+	entries, err := gitops.Changelog(ctx, repo, from, to, files)
+	if err != nil {
+		return fmt.Errorf("failed to build changelog: %w", err)
+	}
+
+	switch format {
+	case "markdown", "md":
+		return writeMarkdown(os.Stdout, entries)
+	case "text":
+		return writeText(os.Stdout, entries)
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(map[string]interface{}{
+			"environment": environment,
+			"since":       since,
+			"until":       until,
+			"changes":     entries,
+		})
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+func writeMarkdown(w io.Writer, entries []gitops.ChangelogEntry) error {
+	fmt.Fprintf(w, "### Configuration changes: %s (%s → %s)\n\n", environment, since, until)
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No configuration changes.")
+		return nil
+	}
+
+	fmt.Fprintln(w, "| Change | Key | Date | Author | Commit |")
+	fmt.Fprintln(w, "|--------|-----|------|--------|--------|")
+	for _, entry := range entries {
+		last := entry.Last()
+		fmt.Fprintf(w, "| %s | `%s` | %s | %s | %s |\n",
+			title(entry.Change), entry.Key, date(last), mdCell(last.Author), mdCell(commitRef(last)))
+	}
+
+	fmt.Fprintf(w, "\n**Summary:** %s\n", summary(entries))
+	return nil
+}
+
+func writeText(w io.Writer, entries []gitops.ChangelogEntry) error {
+	fmt.Fprintf(w, "Configuration changes in %s (%s → %s)\n\n", environment, since, until)
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No configuration changes")
+		return nil
+	}
+
+	for _, entry := range entries {
+		last := entry.Last()
+		fmt.Fprintf(w, "  %-8s %s  %s by %s (%s)\n", entry.Change, entry.Key, date(last), last.Author, commitRef(last))
+	}
+
+	fmt.Fprintf(w, "\nSummary: %s\n", summary(entries))
+	return nil
+}
+
+func summary(entries []gitops.ChangelogEntry) string {
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		counts[entry.Change]++
+	}
+	return fmt.Sprintf("%d added, %d modified, %d removed", counts["added"], counts["modified"], counts["removed"])
+}
+
+func title(change string) string {
+	if change == "" {
+		return ""
+	}
+	return strings.ToUpper(change[:1]) + change[1:]
+}
+
+func date(c gitops.Commit) string {
+	if c.Time.IsZero() {
+		return "-"
+	}
+	return c.Time.Format("2006-01-02 15:04")
+}
+
+func commitRef(c gitops.Commit) string {
+	if c.SHA == "" {
+		return "-"
+	}
+	return gitops.ShortSHA(c.SHA) + " " + c.Subject
+}
+
+// mdCell escapes text for use inside a markdown table cell
+func mdCell(text string) string {
+	return strings.ReplaceAll(text, "|", "\\|")
+}
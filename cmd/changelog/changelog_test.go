@@ -0,0 +1,60 @@
+package changelog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/drapon/envy/internal/gitops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetChangelogCmd(t *testing.T) {
+	cmd := GetChangelogCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "changelog", cmd.Use)
+	assert.NotNil(t, cmd.RunE)
+
+	for _, name := range []string{"env", "since", "until", "format"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func testEntries() []gitops.ChangelogEntry {
+	commit := gitops.Commit{
+		SHA:     "0123456789abcdef0123",
+		Author:  "Jane Doe",
+		Time:    time.Date(2024, 6, 3, 14, 5, 0, 0, time.UTC),
+		Subject: "Use the new payments API | v2",
+	}
+	return []gitops.ChangelogEntry{
+		{Key: "PAYMENTS_URL", Change: "modified", Commits: []gitops.Commit{commit}},
+		{Key: "LEGACY_FLAG", Change: "removed", Commits: []gitops.Commit{commit}},
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	environment, since, until = "prod", "v1.4.0", "HEAD"
+
+	var buf bytes.Buffer
+	require.NoError(t, writeMarkdown(&buf, testEntries()))
+	out := buf.String()
+
+	assert.Contains(t, out, "### Configuration changes: prod (v1.4.0 → HEAD)")
+	assert.Contains(t, out, "| Modified | `PAYMENTS_URL` | 2024-06-03 14:05 | Jane Doe | 0123456789ab Use the new payments API \\| v2 |")
+	assert.Contains(t, out, "**Summary:** 0 added, 1 modified, 1 removed")
+
+	buf.Reset()
+	require.NoError(t, writeMarkdown(&buf, nil))
+	assert.Contains(t, buf.String(), "No configuration changes.")
+}
+
+func TestWriteText(t *testing.T) {
+	environment, since, until = "prod", "v1.4.0", "HEAD"
+
+	var buf bytes.Buffer
+	require.NoError(t, writeText(&buf, testEntries()))
+	assert.Contains(t, buf.String(), "removed  LEGACY_FLAG  2024-06-03 14:05 by Jane Doe (0123456789ab Use the new payments API | v2)")
+	assert.Contains(t, buf.String(), "Summary: 0 added, 1 modified, 1 removed")
+}
@@ -0,0 +1,210 @@
+package checkruntime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/procenv"
+	"github.com/drapon/envy/internal/remote"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	environment string
+	pid         int
+	container   string
+	format      string
+	filterOpts  filter.Options
+)
+
+// checkRuntimeCmd represents the check-runtime command
+var checkRuntimeCmd = &cobra.Command{
+	Use:   "check-runtime",
+	Short: "Check that a running process has the current environment",
+	Long: `Compare the environment a running process or container was started
+with to the current variables of an environment, to find processes still
+running with secrets from before a rotation.
+
+The environment of --pid is read from /proc (Linux), that of --container
+with docker inspect. Only the variables of the environment are compared;
+others, such as PATH, are ignored. A value that differs is reported as
+stale when it is an earlier version of the parameter, and as different
+otherwise. Values are never printed.
+
+The command exits with status 1 when any variable is stale, different or
+missing, so it can serve as a health check.`,
+	Example: `  # Is the app in this container running with the current prod secrets?
+  envy check-runtime --env prod --pid 1
+
+  # Check a container from the host
+  envy check-runtime --env prod --container api-1
+
+  # Only the database credentials, as JSON
+  envy check-runtime --env prod --pid 1234 --filter "DB_*" --format json`,
+	Args: cobra.NoArgs,
+	RunE: runCheckRuntime,
+}
+
+// GetCheckRuntimeCmd returns the check-runtime command.
+func GetCheckRuntimeCmd() *cobra.Command {
+	return checkRuntimeCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(checkRuntimeCmd)
+
+	checkRuntimeCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment the process should have (default: default_environment)")
+	checkRuntimeCmd.Flags().IntVar(&pid, "pid", 0, "Process to check")
+	checkRuntimeCmd.Flags().StringVar(&container, "container", "", "Docker container to check")
+	checkRuntimeCmd.Flags().StringVarP(&format, "format", "f", "text", "Output format (text/json)")
+	filter.AddFlags(checkRuntimeCmd.Flags(), &filterOpts)
+	checkRuntimeCmd.MarkFlagsMutuallyExclusive("pid", "container")
+	checkRuntimeCmd.MarkFlagsOneRequired("pid", "container")
+}
+
+// report is the JSON output of check-runtime
+type report struct {
+	Target      string             `json:"target"`
+	Environment string             `json:"environment"`
+	Checked     int                `json:"checked"`
+	Current     bool               `json:"current"`
+	Mismatches  []procenv.Mismatch `json:"mismatches"`
+}
+
+func runCheckRuntime(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unsupported format: %s (use text or json)", format)
+	}
+	keyFilter, err := filter.New(filterOpts)
+	if err != nil {
+		return err
+	}
+
+	target, actual, err := readTarget(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+	if _, err := cfg.GetEnvironment(environment); err != nil {
+		return err
+	}
+
+	backend, err := remote.New(cfg)
+	if err != nil {
+		return err
+	}
+	expected, err := backend.ListEnvironmentVariables(ctx, environment)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", environment, err)
+	}
+	expected = keyFilter.ApplyMap(expected)
+
+	mismatches := procenv.Compare(expected, actual)
+	if awsManager, ok := backend.(*aws.Manager); ok {
+		findStaleVersions(ctx, awsManager, mismatches, actual)
+	}
+
+	r := report{
+		Target:      target,
+		Environment: environment,
+		Checked:     len(expected),
+		Current:     len(mismatches) == 0,
+		Mismatches:  mismatches,
+	}
+	if format == "json" {
+		if r.Mismatches == nil {
+			r.Mismatches = []procenv.Mismatch{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(r); err != nil {
+			return err
+		}
+	} else if err := writeReport(os.Stdout, r); err != nil {
+		return err
+	}
+
+	if !r.Current {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// readTarget returns a description of --pid or --container and its
+// environment
+func readTarget(ctx context.Context) (string, map[string]string, error) {
+	if container != "" {
+		vars, err := procenv.Container(ctx, container)
+		return "container " + container, vars, err
+	}
+	if pid <= 0 {
+		return "", nil, fmt.Errorf("invalid --pid %d", pid)
+	}
+	vars, err := procenv.Process(pid)
+	return fmt.Sprintf("pid %d", pid), vars, err
+}
+
+// findStaleVersions marks the differing values that are an earlier version
+// of their parameter as stale. Variables without a history, such as those
+// of Secrets Manager environments, stay different.
+func findStaleVersions(ctx context.Context, awsManager *aws.Manager, mismatches []procenv.Mismatch, actual map[string]string) {
+	for i, m := range mismatches {
+		if m.Status != procenv.StatusDifferent {
+			continue
+		}
+		versions, err := awsManager.VariableHistory(ctx, environment, m.Key)
+		if err != nil {
+			continue
+		}
+		// Newest first, in case a value was stored more than once
+		for j := len(versions) - 1; j >= 0; j-- {
+			if !versions[j].LargeValue && versions[j].Value == actual[m.Key] {
+				mismatches[i].Status = procenv.StatusStale
+				mismatches[i].Version = versions[j].Version
+				break
+			}
+		}
+	}
+}
+
+func writeReport(w io.Writer, r report) error {
+	if r.Current {
+		color.PrintSuccessf("%s runs with the current %d variable(s) of %s", r.Target, r.Checked, r.Environment)
+		return nil
+	}
+
+	color.PrintBoldf("%s does not run with the current variables of %s:", r.Target, r.Environment)
+	for _, m := range r.Mismatches {
+		var line string
+		switch m.Status {
+		case procenv.StatusStale:
+			line = fmt.Sprintf("  %s %s: stale (version %d)", color.FormatWarning("~"), m.Key, m.Version)
+		case procenv.StatusMissing:
+			line = fmt.Sprintf("  %s %s: missing", color.FormatError("-"), m.Key)
+		default:
+			line = fmt.Sprintf("  %s %s: different", color.FormatWarning("~"), m.Key)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
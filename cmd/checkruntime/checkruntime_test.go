@@ -0,0 +1,42 @@
+package checkruntime
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/drapon/envy/internal/procenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCheckRuntimeCmd(t *testing.T) {
+	cmd := GetCheckRuntimeCmd()
+	assert.Equal(t, "check-runtime", cmd.Use)
+	for _, name := range []string{"env", "pid", "container", "format", "filter"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeReport(&buf, report{
+		Target:      "pid 42",
+		Environment: "prod",
+		Checked:     3,
+		Mismatches: []procenv.Mismatch{
+			{Key: "API_KEY", Status: procenv.StatusStale, Version: 3},
+			{Key: "DB_HOST", Status: procenv.StatusDifferent},
+			{Key: "PORT", Status: procenv.StatusMissing},
+		},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "API_KEY: stale (version 3)")
+	assert.Contains(t, buf.String(), "DB_HOST: different")
+	assert.Contains(t, buf.String(), "PORT: missing")
+}
+
+func TestRunCheckRuntime_InvalidFormat(t *testing.T) {
+	defer func() { format = "text" }()
+	format = "yaml"
+	assert.ErrorContains(t, runCheckRuntime(checkRuntimeCmd, nil), "unsupported format")
+}
@@ -0,0 +1,291 @@
+package ci
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/ghactions"
+	"github.com/drapon/envy/internal/gitops"
+	"github.com/drapon/envy/internal/precheck"
+)
+
+var (
+	environment string
+	mode        string
+	comment     bool
+	prune       bool
+)
+
+// ciCmd represents the ci command
+var ciCmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Commands for CI pipelines",
+	Long: `Commands designed to run in CI, in particular as the envy GitHub Action
+(action.yml in the envy repository).`,
+}
+
+// pushCmd represents the ci push command
+var pushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Plan or apply a push of the local files to AWS",
+	Long: `Compare an environment's local files with AWS and either report the
+changes (plan) or push them (apply).
+
+In GitHub Actions, the flags default to the action's inputs (INPUT_ENVIRONMENT,
+INPUT_MODE, INPUT_COMMENT, INPUT_PRUNE). The mode defaults to plan on pull
+requests and apply otherwise. The plan is written to the job summary and, on
+pull requests, posted as a comment that later runs update. The step outputs
+added, modified, deleted and changes hold the counts.
+
+Values are never shown, only keys. Keys only in AWS are kept unless --prune
+is given. With validate_on_push: true in .envyrc, a change that breaks
+.envy-rules.yaml fails the plan as well as the apply.`,
+	Example: `  # Plan on a pull request, comment the result
+  envy ci push --env prod --mode plan
+
+  # Apply after merge, deleting keys that were removed from the files
+  envy ci push --env prod --mode apply --prune`,
+	Args: cobra.NoArgs,
+	RunE: runPush,
+}
+
+// GetCICmd returns the ci command.
+func GetCICmd() *cobra.Command {
+	return ciCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(ciCmd)
+	ciCmd.AddCommand(pushCmd)
+
+	pushCmd.Flags().StringVarP(&environment, "env", "e", ghactions.Input("environment"), "Environment to push")
+	pushCmd.Flags().StringVar(&mode, "mode", ghactions.Input("mode"), "plan or apply (default: plan on pull requests, apply otherwise)")
+	pushCmd.Flags().BoolVar(&comment, "comment", inputBool("comment", true), "Comment the plan on the pull request")
+	pushCmd.Flags().BoolVar(&prune, "prune", inputBool("prune", false), "Delete keys that are only in AWS")
+}
+
+// remoteStore is the part of the AWS manager ci push uses
+type remoteStore interface {
+	ListEnvironmentVariables(ctx context.Context, envName string) (map[string]string, error)
+	ApplyChanges(ctx context.Context, envName string, set *env.File, deleted []string) error
+	CallerARN(ctx context.Context) (string, error)
+}
+
+func runPush(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	prNumber, onPullRequest := ghactions.PullRequestNumber()
+	if mode == "" {
+		mode = "apply"
+		if onPullRequest {
+			mode = "plan"
+		}
+	}
+	if mode != "plan" && mode != "apply" {
+		return fmt.Errorf("invalid mode %q (use plan or apply)", mode)
+	}
+
+	// Load configuration
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+	envConfig, err := cfg.GetEnvironment(environment)
+	if err != nil {
+		return err
+	}
+
+	local, err := env.NewManager(".").LoadFiles(envConfig.Files)
+	if err != nil {
+		return fmt.Errorf("failed to load environment files: %w", err)
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	delta, err := plan(ctx, awsManager, environment, local)
+	if err != nil {
+		return err
+	}
+	kept := delta.Deleted
+	if !prune {
+		delta.Deleted = nil
+	} else {
+		kept = nil
+	}
+
+	if err := write(ctx, cfg, awsManager, envConfig, delta, !prune, mode == "apply"); err != nil {
+		return err
+	}
+
+	report := renderReport(delta, kept, mode)
+	printDelta(delta, kept)
+	if mode == "apply" {
+		color.PrintSuccessf("Applied to %s: %d added, %d modified, %d deleted", environment, len(delta.Added), len(delta.Modified), len(delta.Deleted))
+	}
+
+	if err := ghactions.AddStepSummary(report); err != nil {
+		color.PrintWarningf("Could not write the job summary: %v", err)
+	}
+	for name, count := range map[string]int{
+		"added":    len(delta.Added),
+		"modified": len(delta.Modified),
+		"deleted":  len(delta.Deleted),
+		"changes":  len(delta.Added) + len(delta.Modified) + len(delta.Deleted),
+	} {
+		if err := ghactions.SetOutput(name, strconv.Itoa(count)); err != nil {
+			return fmt.Errorf("failed to set output %s: %w", name, err)
+		}
+	}
+
+	if comment && onPullRequest {
+		client, err := ghactions.NewClientFromEnv()
+		if err != nil {
+			return err
+		}
+		if err := client.UpsertComment(ctx, prNumber, commentMarker(environment), report); err != nil {
+			return err
+		}
+		color.PrintInfof("Commented on pull request #%d", prNumber)
+	}
+	return nil
+}
+
+// write runs the checks push makes and, when apply is set, writes the
+// delta. partial is set when keys only in AWS are kept. The checks run in
+// plan mode too, so the pull request shows a change that would be refused;
+// allowed writers are only checked before an apply, as the plan usually
+// runs as another principal.
+func write(ctx context.Context, cfg *config.Config, store remoteStore, envConfig *config.Environment, delta *gitops.Delta, partial, apply bool) error {
+	if delta.Empty() {
+		return nil
+	}
+
+	envName := delta.Environment
+	if err := precheck.Placeholders(cfg, envName, envConfig, delta.Set(), false); err != nil {
+		return err
+	}
+	if cfg.ValidateOnPush {
+		if err := precheck.Validate(ctx, envName, delta.Current, envConfig.Files, partial); err != nil {
+			return err
+		}
+	}
+	if !apply {
+		return nil
+	}
+
+	if err := precheck.Writers(ctx, cfg, store, precheck.Keys(delta.Set(), delta.Deleted)); err != nil {
+		return err
+	}
+	if err := store.ApplyChanges(ctx, envName, delta.Set(), delta.Deleted); err != nil {
+		return fmt.Errorf("failed to apply changes: %w", err)
+	}
+	return nil
+}
+
+// plan compares the local variables with AWS. Empty local values are
+// skipped, as push does by default.
+func plan(ctx context.Context, store remoteStore, envName string, local *env.File) (*gitops.Delta, error) {
+	remoteVars, err := store.ListEnvironmentVariables(ctx, envName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from AWS: %w", envName, err)
+	}
+
+	remote := env.NewFile()
+	for key, value := range remoteVars {
+		remote.Set(key, value)
+	}
+
+	current := env.NewFile()
+	for _, key := range local.Keys() {
+		if value, _ := local.Get(key); value != "" {
+			current.Set(key, value)
+		}
+	}
+
+	return gitops.NewDelta(envName, remote, current), nil
+}
+
+func commentMarker(envName string) string {
+	return fmt.Sprintf("<!-- envy-ci:%s -->", envName)
+}
+
+// renderReport renders the plan as markdown for the job summary and the
+// pull request comment
+func renderReport(delta *gitops.Delta, kept []string, mode string) string {
+	var b strings.Builder
+	b.WriteString(commentMarker(delta.Environment) + "\n")
+
+	verb := "plan"
+	if mode == "apply" {
+		verb = "applied"
+	}
+	fmt.Fprintf(&b, "### envy %s: %s\n\n", verb, delta.Environment)
+
+	if delta.Empty() {
+		b.WriteString("No changes. AWS matches the local files.\n")
+	} else {
+		b.WriteString("| Change | Key |\n|--------|-----|\n")
+		for _, row := range []struct {
+			change string
+			keys   []string
+		}{{"Added", delta.Added}, {"Modified", delta.Modified}, {"Deleted", delta.Deleted}} {
+			for _, key := range row.keys {
+				fmt.Fprintf(&b, "| %s | `%s` |\n", row.change, key)
+			}
+		}
+		fmt.Fprintf(&b, "\n**Summary:** %d added, %d modified, %d deleted\n", len(delta.Added), len(delta.Modified), len(delta.Deleted))
+	}
+
+	if len(kept) > 0 {
+		fmt.Fprintf(&b, "\n%d key(s) only in AWS are kept: %s\n", len(kept), "`"+strings.Join(kept, "`, `")+"`")
+	}
+	return b.String()
+}
+
+// printDelta prints the keys that change. Values are never shown since the
+// output ends up in CI logs.
+func printDelta(delta *gitops.Delta, kept []string) {
+	color.PrintBoldf("%s:", delta.Environment)
+	if delta.Empty() {
+		color.PrintInfof("  No changes")
+	}
+	for _, key := range delta.Added {
+		fmt.Println(color.FormatSuccess("  + " + key))
+	}
+	for _, key := range delta.Modified {
+		fmt.Println(color.FormatWarning("  ~ " + key))
+	}
+	for _, key := range delta.Deleted {
+		fmt.Println(color.FormatError("  - " + key))
+	}
+	if len(kept) > 0 {
+		color.PrintInfof("  %d key(s) only in AWS are kept (use --prune to delete them)", len(kept))
+	}
+}
+
+// inputBool reads a boolean action input, falling back to def when it is
+// not set or invalid
+func inputBool(name string, def bool) bool {
+	value, err := strconv.ParseBool(ghactions.Input(name))
+	if err != nil {
+		return def
+	}
+	return value
+}
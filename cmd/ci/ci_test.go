@@ -0,0 +1,136 @@
+package ci
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCICmd(t *testing.T) {
+	cmd := GetCICmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "ci", cmd.Use)
+
+	push, _, err := cmd.Find([]string{"push"})
+	require.NoError(t, err)
+	assert.NotNil(t, push.RunE)
+	for _, name := range []string{"env", "mode", "comment", "prune"} {
+		assert.NotNil(t, push.Flags().Lookup(name), name)
+	}
+}
+
+type fakeStore struct {
+	vars    map[string]string
+	err     error
+	arn     string
+	applied int
+}
+
+func (f *fakeStore) ListEnvironmentVariables(ctx context.Context, envName string) (map[string]string, error) {
+	return f.vars, f.err
+}
+
+func (f *fakeStore) ApplyChanges(ctx context.Context, envName string, set *env.File, deleted []string) error {
+	f.applied++
+	return nil
+}
+
+func (f *fakeStore) CallerARN(ctx context.Context) (string, error) {
+	return f.arn, nil
+}
+
+func TestWrite_DeniedWriter(t *testing.T) {
+	cfg := &config.Config{
+		Variables: []config.VariableConfig{
+			{Key: "STRIPE_*", AllowedWriters: []string{"payments-deploy"}},
+		},
+		EnforceWriters: true,
+	}
+	store := &fakeStore{
+		vars: map[string]string{"STRIPE_KEY": "old"},
+		arn:  "arn:aws:sts::123456789012:assumed-role/developer/alice",
+	}
+	local := env.NewFile()
+	local.Set("STRIPE_KEY", "new")
+
+	delta, err := plan(context.Background(), store, "prod", local)
+	require.NoError(t, err)
+
+	// Plan mode does not check writers
+	require.NoError(t, write(context.Background(), cfg, store, &config.Environment{}, delta, true, false))
+	assert.Equal(t, 0, store.applied)
+
+	err = write(context.Background(), cfg, store, &config.Environment{}, delta, true, true)
+	assert.ErrorContains(t, err, "is not an allowed writer of STRIPE_KEY")
+	assert.Equal(t, 0, store.applied)
+
+	store.arn = "arn:aws:sts::123456789012:assumed-role/payments-deploy/ci"
+	require.NoError(t, write(context.Background(), cfg, store, &config.Environment{}, delta, true, true))
+	assert.Equal(t, 1, store.applied)
+}
+
+func TestPlan(t *testing.T) {
+	store := &fakeStore{vars: map[string]string{
+		"API_URL": "https://old.example.com",
+		"DEBUG":   "false",
+		"LEGACY":  "1",
+	}}
+
+	local := env.NewFile()
+	local.Set("API_URL", "https://new.example.com")
+	local.Set("DEBUG", "false")
+	local.Set("NEW_KEY", "value")
+	local.Set("EMPTY", "")
+
+	delta, err := plan(context.Background(), store, "prod", local)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"NEW_KEY"}, delta.Added)
+	assert.Equal(t, []string{"API_URL"}, delta.Modified)
+	assert.Equal(t, []string{"LEGACY"}, delta.Deleted)
+
+	store.err = errors.New("access denied")
+	_, err = plan(context.Background(), store, "prod", local)
+	assert.ErrorContains(t, err, "access denied")
+}
+
+func TestRenderReport(t *testing.T) {
+	store := &fakeStore{vars: map[string]string{"API_URL": "old", "LEGACY": "1"}}
+	local := env.NewFile()
+	local.Set("API_URL", "new-secret-value")
+	local.Set("NEW_KEY", "value")
+
+	delta, err := plan(context.Background(), store, "prod", local)
+	require.NoError(t, err)
+	kept := delta.Deleted
+	delta.Deleted = nil
+
+	report := renderReport(delta, kept, "plan")
+	assert.Contains(t, report, "<!-- envy-ci:prod -->")
+	assert.Contains(t, report, "### envy plan: prod")
+	assert.Contains(t, report, "| Added | `NEW_KEY` |")
+	assert.Contains(t, report, "| Modified | `API_URL` |")
+	assert.Contains(t, report, "1 added, 1 modified, 0 deleted")
+	assert.Contains(t, report, "only in AWS are kept: `LEGACY`")
+	assert.NotContains(t, report, "new-secret-value")
+
+	delta.Added, delta.Modified = nil, nil
+	report = renderReport(delta, nil, "apply")
+	assert.Contains(t, report, "### envy applied: prod")
+	assert.Contains(t, report, "No changes")
+}
+
+func TestInputBool(t *testing.T) {
+	t.Setenv("INPUT_COMMENT", "false")
+	assert.False(t, inputBool("comment", true))
+
+	t.Setenv("INPUT_COMMENT", "")
+	assert.True(t, inputBool("comment", true))
+
+	t.Setenv("INPUT_PRUNE", "true")
+	assert.True(t, inputBool("prune", false))
+}
@@ -0,0 +1,285 @@
+package compliance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/compliance"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/gitops"
+	"github.com/drapon/envy/internal/ownership"
+	"github.com/drapon/envy/internal/report"
+	"github.com/drapon/envy/internal/roster"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/drapon/envy/internal/validator"
+)
+
+var (
+	standard     string
+	environments []string
+	format       string
+	output       string
+)
+
+// complianceCmd represents the compliance command
+var complianceCmd = &cobra.Command{
+	Use:   "compliance",
+	Short: "Check environments against a compliance standard",
+	Long: `Check environments against a compliance profile and report the results for
+auditors, as markdown, JSON or an HTML page.
+
+The profile is a YAML file enabling the checks the standard requires:
+
+  name: Internal secrets standard
+  environments: [prod]          # default: every environment
+  secure_string: true           # sensitive parameters are SecureString
+  customer_managed_key: true    # encrypted with a customer managed KMS key
+  kms_keys: [alias/app-secrets] # optionally, only these keys
+  required_tags: [owner, data-classification]
+  rotation: true                # Secrets Manager rotation is enabled
+  max_rotation_days: 90
+  no_plaintext_in_git: true     # no sensitive values in the git history
+                                # of the environment's files
+
+Only metadata is read from AWS; values are never fetched. The report ends
+with the owners of the variables from .envyrc, with their contact details
+when a roster is configured. The command exits with an error if any check
+fails.`,
+	Example: `  # Markdown report for every environment in the profile
+  envy compliance --standard internal.yaml
+
+  # JSON report for prod, written to a file
+  envy compliance --standard internal.yaml --env prod --format json -o compliance.json
+
+  # HTML report to attach to the audit ticket
+  envy compliance --standard internal.yaml --format html -o compliance.html`,
+	Args: cobra.NoArgs,
+	RunE: runCompliance,
+}
+
+// GetComplianceCmd returns the compliance command.
+func GetComplianceCmd() *cobra.Command {
+	return complianceCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(complianceCmd)
+
+	complianceCmd.Flags().StringVarP(&standard, "standard", "s", "", "Compliance profile (YAML)")
+	complianceCmd.Flags().StringSliceVarP(&environments, "env", "e", nil, "Environments to check (default: the profile's, or all)")
+	complianceCmd.Flags().StringVarP(&format, "format", "f", "markdown", "Output format (markdown/json/html)")
+	complianceCmd.Flags().StringVarP(&output, "output", "o", "", "Output file (stdout if not specified)")
+	_ = complianceCmd.MarkFlagRequired("standard")
+}
+
+func runCompliance(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	renderer, isReport := report.Lookup(format)
+	if format != "markdown" && format != "md" && format != "json" && !isReport {
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+
+	profile, err := compliance.LoadProfile(standard)
+	if err != nil {
+		return err
+	}
+
+	// Load configuration
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	envNames, err := selectEnvironments(cfg, profile)
+	if err != nil {
+		return err
+	}
+
+	isSensitive, err := sensitive.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	repo := gitops.NewRepo(".")
+	var envs []compliance.Environment
+	for _, envName := range envNames {
+		color.PrintInfof("Checking %s...", envName)
+		resources, err := awsManager.DescribeEnvironment(ctx, envName)
+		if err != nil {
+			return fmt.Errorf("failed to describe environment %s: %w", envName, err)
+		}
+
+		environment := compliance.Environment{
+			Name:      envName,
+			Resources: resources,
+			Sensitive: isSensitive,
+		}
+		if profile.NoPlaintextInGit {
+			envConfig, err := cfg.GetEnvironment(envName)
+			if err != nil {
+				return err
+			}
+			environment.Leaks, err = findLeaks(ctx, repo, envConfig.Files, isSensitive)
+			if err != nil {
+				return fmt.Errorf("failed to scan git history of %s: %w", envName, err)
+			}
+		}
+		envs = append(envs, environment)
+	}
+
+	result := compliance.Evaluate(profile, envs, time.Now())
+	if result.Owners, err = owners(ctx, cfg); err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch {
+	case isReport:
+		if err := renderer.Render(w, result.Document()); err != nil {
+			return err
+		}
+	case format == "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+	default:
+		if _, err := io.WriteString(w, result.Markdown()); err != nil {
+			return err
+		}
+	}
+
+	if !result.Passed() {
+		return fmt.Errorf("environments do not comply with %s", result.Standard)
+	}
+	if output != "" {
+		color.PrintSuccessf("All checks passed; report written to %s", output)
+	}
+	return nil
+}
+
+// selectEnvironments returns the environments to check: --env, else the
+// profile's, else every configured environment
+func selectEnvironments(cfg *config.Config, profile *compliance.Profile) ([]string, error) {
+	names := environments
+	if len(names) == 0 {
+		names = profile.Environments
+	}
+	if len(names) == 0 {
+		for name := range cfg.Environments {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	for _, name := range names {
+		if _, err := cfg.GetEnvironment(name); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// owners lists who owns which variables, per the variables section of
+// .envyrc
+func owners(ctx context.Context, cfg *config.Config) ([]compliance.Ownership, error) {
+	rules, err := ownership.ParseRules(cfg.Variables)
+	if err != nil {
+		return nil, err
+	}
+	contacts, err := roster.Load(ctx, cfg)
+	if err != nil {
+		color.PrintWarningf("Owners are listed without contact details: %v", err)
+	}
+
+	var owners []compliance.Ownership
+	for _, rule := range rules {
+		if len(rule.Owners) == 0 {
+			continue
+		}
+		o := compliance.Ownership{Variables: rule.Pattern, Owners: rule.Owners}
+		if contacts != nil {
+			o.Contacts = contacts.DescribeAll(rule.Owners)
+		}
+		owners = append(owners, o)
+	}
+	return owners, nil
+}
+
+// findLeaks lists the sensitive variables with a real value in any commit
+// of the given files, reporting each variable once per file with the first
+// commit it appeared in. Placeholder values are not leaks.
+func findLeaks(ctx context.Context, repo *gitops.Repo, files []string, isSensitive func(string) bool) ([]compliance.Leak, error) {
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = filepath.ToSlash(file)
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	commits, err := repo.History(ctx, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var leaks []compliance.Leak
+	seen := make(map[string]bool)
+	for _, commit := range commits {
+		for _, path := range paths {
+			content, ok, err := repo.ShowFile(ctx, commit.SHA, path)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			file, err := env.Parse(bytes.NewReader(content))
+			if err != nil {
+				continue // not an env file at this commit
+			}
+			for _, key := range file.SortedKeys() {
+				value, _ := file.Get(key)
+				if value == "" || !isSensitive(key) || seen[path+"\x00"+key] {
+					continue
+				}
+				if _, placeholder := validator.Placeholder(value); placeholder {
+					continue
+				}
+				seen[path+"\x00"+key] = true
+				leaks = append(leaks, compliance.Leak{File: path, Commit: commit.SHA, Key: key})
+			}
+		}
+	}
+	return leaks, nil
+}
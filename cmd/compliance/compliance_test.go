@@ -0,0 +1,75 @@
+package compliance
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/drapon/envy/internal/compliance"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/gitops"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetComplianceCmd(t *testing.T) {
+	cmd := GetComplianceCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "compliance", cmd.Use)
+
+	for _, name := range []string{"standard", "env", "format", "output"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestSelectEnvironments(t *testing.T) {
+	defer func() { environments = nil }()
+	cfg := &config.Config{Environments: map[string]config.Environment{"prod": {}, "dev": {}}}
+
+	names, err := selectEnvironments(cfg, &compliance.Profile{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev", "prod"}, names)
+
+	names, err = selectEnvironments(cfg, &compliance.Profile{Environments: []string{"prod"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod"}, names)
+
+	environments = []string{"staging"}
+	_, err = selectEnvironments(cfg, &compliance.Profile{Environments: []string{"prod"}})
+	assert.Error(t, err)
+}
+
+func TestFindLeaks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	commitFile := func(content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".env.prod"), []byte(content), 0644))
+		git("add", "-A")
+		git("commit", "-q", "-m", "update")
+	}
+
+	git("init", "-q")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "test")
+	git("config", "commit.gpgsign", "false")
+	commitFile("DB_PASSWORD=changeme\nPORT=8080\n")
+	commitFile("DB_PASSWORD=hunter2-but-longer\nPORT=8080\n")
+	commitFile("DB_PASSWORD=\nPORT=8080\n")
+
+	leaks, err := findLeaks(context.Background(), gitops.NewRepo(dir), []string{".env.prod", ".env.other"}, sensitive.IsSensitive)
+	require.NoError(t, err)
+	require.Len(t, leaks, 1, "the placeholder and the removed value are not leaks, the real value is")
+	assert.Equal(t, "DB_PASSWORD", leaks[0].Key)
+	assert.Equal(t, ".env.prod", leaks[0].File)
+}
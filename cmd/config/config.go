@@ -0,0 +1,272 @@
+// Package configcmd implements the config command. It is not named after its
+// directory to avoid clashing with the internal config package it displays.
+package configcmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/cache"
+	"github.com/drapon/envy/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+var resolved bool
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the envy configuration",
+}
+
+// showCmd represents the config show command
+var showCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the configuration file",
+	Long: `Show the .envyrc in use. With --resolved, show the effective settings
+instead: defaults filled in, durations and sizes parsed, and each
+environment's service, path and region timeout. Every value is annotated
+with where it came from: a line of .envyrc, an ENVY_* or AWS_PROFILE
+environment variable, a flag, or the built-in default.`,
+	Example: `  # Print the .envyrc in use
+  envy config show
+
+  # Show effective settings and where they came from, e.g. to find out
+  # why envy is using us-east-1
+  envy config show --resolved`,
+	Args: cobra.NoArgs,
+	RunE: runShow,
+}
+
+// resolvedConfig is the effective configuration. Durations are printed in
+// Go notation and sizes in bytes alongside a readable form.
+type resolvedConfig struct {
+	File               string                         `yaml:"file"`
+	Project            string                         `yaml:"project"`
+	DefaultEnvironment string                         `yaml:"default_environment"`
+	ValidateOnPush     bool                           `yaml:"validate_on_push"`
+	Namespace          string                         `yaml:"namespace,omitempty"`
+	AWS                resolvedAWS                    `yaml:"aws"`
+	Cache              resolvedCache                  `yaml:"cache"`
+	Agent              resolvedAgent                  `yaml:"agent"`
+	Variables          []resolvedVariable             `yaml:"variables,omitempty"`
+	Environments       map[string]resolvedEnvironment `yaml:"environments"`
+}
+
+type resolvedAWS struct {
+	Service                     string `yaml:"service"`
+	Region                      string `yaml:"region"`
+	Profile                     string `yaml:"profile"`
+	LargeValuesToSecretsManager bool   `yaml:"large_values_to_secrets_manager"`
+}
+
+type resolvedCache struct {
+	Enabled      bool   `yaml:"enabled"`
+	Type         string `yaml:"type"`
+	TTL          string `yaml:"ttl"`
+	MaxSize      string `yaml:"max_size"`
+	MaxSizeBytes int64  `yaml:"max_size_bytes"`
+	MaxEntries   int    `yaml:"max_entries"`
+	Dir          string `yaml:"dir"`
+}
+
+type resolvedAgent struct {
+	Socket  string `yaml:"socket,omitempty"`
+	Refresh string `yaml:"refresh"`
+}
+
+type resolvedVariable struct {
+	Key     string `yaml:"key"`
+	Refresh string `yaml:"refresh"`
+}
+
+type resolvedEnvironment struct {
+	Files           []string `yaml:"files"`
+	Path            string   `yaml:"path"`
+	Service         string   `yaml:"service"`
+	FallbackRegions []string `yaml:"fallback_regions,omitempty"`
+	RegionTimeout   string   `yaml:"region_timeout,omitempty"`
+	CacheSoftTTL    string   `yaml:"cache_soft_ttl,omitempty"`
+	CacheHardTTL    string   `yaml:"cache_hard_ttl,omitempty"`
+}
+
+// GetConfigCmd returns the config command.
+func GetConfigCmd() *cobra.Command {
+	return configCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(configCmd)
+	configCmd.AddCommand(showCmd)
+
+	showCmd.Flags().BoolVar(&resolved, "resolved", false, "Show effective settings with defaults applied and units parsed")
+}
+
+func runShow(cmd *cobra.Command, args []string) error {
+	file := viper.ConfigFileUsed()
+
+	if !resolved {
+		if file == "" {
+			return fmt.Errorf("no .envyrc found; run 'envy init' to create one")
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read configuration: %w", err)
+		}
+		_, err = cmd.OutOrStdout().Write(data)
+		return err
+	}
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	cacheConfig, err := cache.LoadCacheConfigFromViper(viper.GetViper())
+	if err != nil {
+		return fmt.Errorf("failed to load cache configuration: %w", err)
+	}
+
+	o, err := newOrigin(file)
+	if err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("config") {
+		o.flags["file"] = "--config"
+	}
+	if viper.GetBool("no_cache") {
+		// Applied when the cache is initialized, after loading its settings
+		cacheConfig.Enabled = false
+		o.flags["cache.enabled"] = "--no-cache"
+	}
+
+	rc, notes := resolve(cfg, cacheConfig, o)
+	return writeResolved(cmd.OutOrStdout(), rc, notes)
+}
+
+// resolve builds the effective configuration and notes where each value
+// came from, keyed by its dotted path in the output
+func resolve(cfg *config.Config, cacheConfig *cache.CacheConfig, o origin) (resolvedConfig, map[string]string) {
+	notes := make(map[string]string)
+	note := func(path, key string, env bool) {
+		notes[path] = o.of(key, env)
+	}
+
+	rc := resolvedConfig{
+		File:               o.file,
+		Project:            cfg.Project,
+		DefaultEnvironment: cfg.DefaultEnvironment,
+		ValidateOnPush:     cfg.ValidateOnPush,
+		Namespace:          cfg.Namespace,
+		AWS: resolvedAWS{
+			Service:                     cfg.AWS.Service,
+			Region:                      cfg.AWS.Region,
+			Profile:                     cfg.AWS.Profile,
+			LargeValuesToSecretsManager: cfg.AWS.LargeValuesToSecretsManager,
+		},
+		Cache: resolvedCache{
+			Enabled:      cacheConfig.Enabled,
+			Type:         string(cacheConfig.Type),
+			TTL:          cacheConfig.TTL.String(),
+			MaxSize:      config.FormatSize(cacheConfig.MaxSize),
+			MaxSizeBytes: cacheConfig.MaxSize,
+			MaxEntries:   cacheConfig.MaxEntries,
+			Dir:          cacheConfig.CacheDir,
+		},
+		Agent: resolvedAgent{
+			Socket:  cfg.Agent.Socket,
+			Refresh: cfg.Agent.RefreshInterval().String(),
+		},
+		Environments: make(map[string]resolvedEnvironment, len(cfg.Environments)),
+	}
+
+	switch {
+	case o.file == "":
+		rc.File = "(none, using defaults)"
+		notes["file"] = "no .envyrc in this or any parent directory"
+	case o.flags["file"] != "":
+		notes["file"] = "flag " + o.flags["file"]
+	default:
+		notes["file"] = "found from the current directory"
+	}
+
+	for _, key := range []string{"project", "default_environment", "validate_on_push", "namespace", "aws.service", "aws.region", "aws.large_values_to_secrets_manager", "agent.socket", "agent.refresh"} {
+		note(key, key, true)
+	}
+	note("aws.profile", "aws.profile", true)
+	if profile, ok := o.lookupEnv("AWS_PROFILE"); ok && (cfg.AWS.Profile == "" || cfg.AWS.Profile == "default") {
+		// The AWS SDK picks the profile up itself when envy passes none
+		rc.AWS.Profile = profile
+		notes["aws.profile"] = "env AWS_PROFILE"
+	}
+
+	// The root configuration does not map ENVY_* variables to cache keys
+	for _, key := range []string{"cache.enabled", "cache.type", "cache.ttl", "cache.max_size", "cache.max_entries", "cache.dir"} {
+		note(key, key, false)
+	}
+	notes["cache.max_size_bytes"] = notes["cache.max_size"]
+
+	for i, v := range cfg.Variables {
+		if v.Refresh == "" {
+			continue
+		}
+		d, _ := config.ParseDuration(v.Refresh)
+		note(fmt.Sprintf("variables.%d.refresh", len(rc.Variables)), fmt.Sprintf("variables.%d.refresh", i), false)
+		rc.Variables = append(rc.Variables, resolvedVariable{Key: v.Key, Refresh: d.String()})
+	}
+
+	for name, env := range cfg.Environments {
+		prefix := "environments." + name
+		re := resolvedEnvironment{
+			Files:           env.Files,
+			Path:            cfg.GetParameterPath(name),
+			Service:         cfg.GetAWSService(name),
+			FallbackRegions: env.FallbackRegions,
+		}
+		note(prefix+".files", prefix+".files", false)
+		note(prefix+".path", prefix+".path", false)
+		if env.UseSecretsManager {
+			note(prefix+".service", prefix+".use_secrets_manager", false)
+		} else {
+			note(prefix+".service", "aws.service", true)
+		}
+
+		// The timeout only applies when there is a region to fall back to
+		if len(env.FallbackRegions) > 0 {
+			re.RegionTimeout = env.Timeout().String()
+			note(prefix+".fallback_regions", prefix+".fallback_regions", false)
+			note(prefix+".region_timeout", prefix+".region_timeout", false)
+		}
+
+		// The hard TTL only applies with a soft TTL
+		if soft, hard := env.CacheTTLs(); soft > 0 {
+			re.CacheSoftTTL = soft.String()
+			re.CacheHardTTL = hard.String()
+			note(prefix+".cache_soft_ttl", prefix+".cache_soft_ttl", false)
+			note(prefix+".cache_hard_ttl", prefix+".cache_hard_ttl", false)
+		}
+		rc.Environments[name] = re
+	}
+
+	return rc, notes
+}
+
+// writeResolved writes the configuration as YAML, with each note as a
+// comment on its value's line
+func writeResolved(w io.Writer, rc resolvedConfig, notes map[string]string) error {
+	var node yaml.Node
+	if err := node.Encode(rc); err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	annotate(&node, notes)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	return encoder.Close()
+}
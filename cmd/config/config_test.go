@@ -0,0 +1,127 @@
+package configcmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/drapon/envy/internal/cache"
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `project: myapp
+default_environment: dev
+
+aws:
+  service: parameter_store
+  region: eu-west-1
+
+cache:
+  ttl: 7d
+
+variables:
+  - key: DB_TOKEN
+    refresh: 1d
+
+environments:
+  dev:
+    files:
+      - .env.dev
+  prod:
+    files:
+      - .env.prod
+    path: /myapp/prod/
+    use_secrets_manager: true
+    fallback_regions: [us-west-2]
+`
+
+func TestGetConfigCmd(t *testing.T) {
+	cmd := GetConfigCmd()
+	assert.Equal(t, "config", cmd.Use)
+
+	sub, _, err := cmd.Find([]string{"show"})
+	require.NoError(t, err)
+	assert.NotNil(t, sub.Flags().Lookup("resolved"))
+}
+
+// newTestOrigin writes testConfig and returns its origin with env as the
+// environment
+func newTestOrigin(t *testing.T, env map[string]string) origin {
+	file := filepath.Join(t.TempDir(), ".envyrc")
+	require.NoError(t, os.WriteFile(file, []byte(testConfig), 0o600))
+
+	o, err := newOrigin(file)
+	require.NoError(t, err)
+	o.lookupEnv = func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	return o
+}
+
+func TestResolve(t *testing.T) {
+	cfg := &config.Config{
+		Project:            "myapp",
+		DefaultEnvironment: "dev",
+		AWS:                config.AWSConfig{Service: "parameter_store", Region: "eu-west-1"},
+		Variables:          []config.VariableConfig{{Key: "DB_TOKEN", Refresh: "1d"}},
+		Environments: map[string]config.Environment{
+			"dev":  {Files: []string{".env.dev"}, CacheSoftTTL: "5m"},
+			"prod": {Files: []string{".env.prod"}, Path: "/myapp/prod/", UseSecretsManager: true, FallbackRegions: []string{"us-west-2"}},
+		},
+	}
+	cacheConfig := cache.DefaultCacheConfig()
+	cacheConfig.TTL = 7 * 24 * time.Hour
+
+	o := newTestOrigin(t, map[string]string{"ENVY_AWS_REGION": "eu-west-1", "AWS_PROFILE": "staging"})
+	rc, notes := resolve(cfg, cacheConfig, o)
+
+	assert.Equal(t, "168h0m0s", rc.Cache.TTL)
+	assert.Equal(t, "100MB", rc.Cache.MaxSize)
+	assert.Equal(t, int64(100<<20), rc.Cache.MaxSizeBytes)
+	assert.Equal(t, "5m0s", rc.Agent.Refresh)
+	assert.Equal(t, []resolvedVariable{{Key: "DB_TOKEN", Refresh: "24h0m0s"}}, rc.Variables)
+	assert.Equal(t, "/myapp/dev/", rc.Environments["dev"].Path)
+	assert.Equal(t, "parameter_store", rc.Environments["dev"].Service)
+	assert.Empty(t, rc.Environments["dev"].RegionTimeout)
+	assert.Equal(t, "5m0s", rc.Environments["dev"].CacheSoftTTL)
+	assert.Equal(t, "24h0m0s", rc.Environments["dev"].CacheHardTTL)
+	assert.Empty(t, rc.Environments["prod"].CacheSoftTTL)
+	assert.Equal(t, "secrets_manager", rc.Environments["prod"].Service)
+	assert.Equal(t, "10s", rc.Environments["prod"].RegionTimeout)
+	assert.Equal(t, "staging", rc.AWS.Profile)
+
+	assert.Equal(t, "env ENVY_AWS_REGION", notes["aws.region"])
+	assert.Equal(t, ".envyrc:5", notes["aws.service"])
+	assert.Equal(t, "env AWS_PROFILE", notes["aws.profile"])
+	assert.Equal(t, ".envyrc:9", notes["cache.ttl"])
+	assert.Equal(t, "default", notes["cache.max_size"])
+	assert.Equal(t, ".envyrc:13", notes["variables.0.refresh"])
+	assert.Equal(t, "default", notes["environments.dev.path"])
+	assert.Equal(t, ".envyrc:22", notes["environments.prod.path"])
+	assert.Equal(t, ".envyrc:23", notes["environments.prod.service"])
+	assert.Equal(t, "default", notes["environments.prod.region_timeout"])
+
+	var buf bytes.Buffer
+	require.NoError(t, writeResolved(&buf, rc, notes))
+	assert.Contains(t, buf.String(), "region: eu-west-1 # env ENVY_AWS_REGION")
+	assert.Contains(t, buf.String(), "max_size_bytes: 104857600 # default")
+}
+
+func TestOrigin(t *testing.T) {
+	o := newTestOrigin(t, map[string]string{"ENVY_CACHE_TTL": "1h", "ENVY_PROJECT": "other"})
+	o.flags["cache.enabled"] = "--no-cache"
+
+	assert.Equal(t, "env ENVY_PROJECT", o.of("project", true))
+	assert.Equal(t, ".envyrc:9", o.of("cache.ttl", false))
+	assert.Equal(t, "env ENVY_CACHE_TTL", o.of("cache.ttl", true))
+	assert.Equal(t, "flag --no-cache", o.of("cache.enabled", false))
+	assert.Equal(t, "default", o.of("agent.refresh", true))
+
+	none := origin{flags: map[string]string{}, lookupEnv: o.lookupEnv}
+	assert.Equal(t, "default", none.of("project", true))
+}
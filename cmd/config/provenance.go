@@ -0,0 +1,135 @@
+package configcmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// boundEnv lists the keys config.Load reads from ENVY_* variables even when
+// they are missing from the file. Other keys are only overridden by their
+// variable when the file sets them.
+var boundEnv = map[string]bool{
+	"project":             true,
+	"default_environment": true,
+	"aws.region":          true,
+	"aws.profile":         true,
+	"aws.service":         true,
+}
+
+// origin reports where configuration values came from
+type origin struct {
+	file      string
+	lines     map[string]int    // dotted key to its line in file
+	flags     map[string]string // dotted key to the flag that set it
+	lookupEnv func(string) (string, bool)
+}
+
+// newOrigin reads the line of every key in file, which may be empty when
+// no file was found
+func newOrigin(file string) (origin, error) {
+	o := origin{file: file, flags: make(map[string]string), lookupEnv: os.LookupEnv}
+	if file == "" {
+		return o, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return o, fmt.Errorf("failed to read configuration: %w", err)
+	}
+	if o.lines, err = fileLines(data); err != nil {
+		return o, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	return o, nil
+}
+
+// of returns the source of a configuration key. Keys that can be
+// overridden by ENVY_* variables are checked in the environment unless
+// env is false.
+func (o origin) of(key string, env bool) string {
+	if flag, ok := o.flags[key]; ok {
+		return "flag " + flag
+	}
+	if o.file == "" {
+		// config.Load ignores the environment when there is no file
+		return "default"
+	}
+
+	line, inFile := o.lines[key]
+	if env && (inFile || boundEnv[key]) {
+		name := "ENVY_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+		if _, ok := o.lookupEnv(name); ok {
+			return "env " + name
+		}
+	}
+	if inFile {
+		return fmt.Sprintf("%s:%d", filepath.Base(o.file), line)
+	}
+	return "default"
+}
+
+// fileLines maps the dotted path of every value in a YAML document to its
+// line. List items are addressed by index, e.g. "variables.0.key".
+func fileLines(data []byte) (map[string]int, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	lines := make(map[string]int)
+	walkNode(&doc, "", func(path string, key, value *yaml.Node) {
+		lines[path] = key.Line
+	})
+	return lines, nil
+}
+
+// annotate adds a line comment to every value in node whose dotted path
+// has a note. Lists and mappings get it on their key's line.
+func annotate(node *yaml.Node, notes map[string]string) {
+	walkNode(node, "", func(path string, key, value *yaml.Node) {
+		note, ok := notes[path]
+		if !ok {
+			return
+		}
+		if value.Kind == yaml.ScalarNode {
+			value.LineComment = note
+		} else {
+			key.LineComment = note
+		}
+	})
+}
+
+// walkNode calls fn for every mapping entry and list item under node. For
+// list items key is the item itself.
+func walkNode(node *yaml.Node, prefix string, fn func(path string, key, value *yaml.Node)) {
+	join := func(name string) string {
+		if prefix == "" {
+			return name
+		}
+		return prefix + "." + name
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			walkNode(child, prefix, fn)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			path := join(key.Value)
+			fn(path, key, value)
+			walkNode(value, path, fn)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			path := join(strconv.Itoa(i))
+			fn(path, item, item)
+			walkNode(item, path, fn)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/drapon/envy/cmd/root"
 	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/prompt"
 	"github.com/drapon/envy/internal/wizard"
 	"github.com/spf13/cobra"
 )
@@ -52,8 +53,8 @@ func init() {
 }
 
 func runConfigure(cmd *cobra.Command, args []string) error {
-	// Check if running in non-interactive mode
-	if nonInteractive {
+	// Check if running in non-interactive mode, also without prompts
+	if nonInteractive || prompt.NonInteractive() {
 		return configureNonInteractive()
 	}
 
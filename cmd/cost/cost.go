@@ -0,0 +1,163 @@
+package cost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/cost"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	environment string
+	cloudTrail  []string
+	format      string
+)
+
+// costCmd represents the cost command
+var costCmd = &cobra.Command{
+	Use:   "cost",
+	Short: "Estimate the monthly AWS cost of an environment",
+	Long: `Estimate what an environment costs per month in AWS: Advanced tier
+parameters, secrets (including large values kept in Secrets Manager) and,
+given CloudTrail logs, the API calls billed for them. Standard parameters
+and their API calls are free at standard throughput.
+
+Cheaper options are listed with their savings, such as Advanced parameters
+that would fit in the Standard tier, or small secrets that could be
+consolidated into one JSON secret.
+
+--cloudtrail takes CloudTrail log files ({"Records": [...]}) or the output
+of 'aws cloudtrail lookup-events'; the calls are scaled to a month from the
+time they span. Prices are us-east-1 list prices.`,
+	Example: `  # Storage cost of prod
+  envy cost --env prod
+
+  # Include API calls from a week of CloudTrail events
+  aws cloudtrail lookup-events --start-time 2024-05-01 --end-time 2024-05-08 \
+    --lookup-attributes AttributeKey=EventSource,AttributeValue=secretsmanager.amazonaws.com > events.json
+  envy cost --env prod --cloudtrail events.json`,
+	Args: cobra.NoArgs,
+	RunE: runCost,
+}
+
+// GetCostCmd returns the cost command.
+func GetCostCmd() *cobra.Command {
+	return costCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(costCmd)
+
+	costCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment (default: default_environment)")
+	costCmd.Flags().StringSliceVar(&cloudTrail, "cloudtrail", nil, "CloudTrail event files to estimate API calls from")
+	costCmd.Flags().StringVar(&format, "format", "text", "Output format (text/json)")
+}
+
+func runCost(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unsupported format: %s (use text or json)", format)
+	}
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.UsesAzure() {
+		return fmt.Errorf("envy cost is only supported with AWS")
+	}
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	inv, err := awsManager.CostInventory(ctx, environment)
+	if err != nil {
+		return fmt.Errorf("failed to describe %s: %w", environment, err)
+	}
+	if len(cloudTrail) > 0 {
+		if inv.Calls, err = loadCalls(cloudTrail, inv); err != nil {
+			return err
+		}
+	}
+
+	est := cost.Calculate(inv, cost.DefaultPrices)
+	if format == "json" {
+		data, err := json.MarshalIndent(est, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printEstimate(est, inv.Service)
+	return nil
+}
+
+// loadCalls counts the API calls on the environment's Advanced parameters
+// and secrets in CloudTrail event files
+func loadCalls(files []string, inv *cost.Inventory) (*cost.Calls, error) {
+	var events []cost.Event
+	for _, filename := range files {
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := cost.ParseCloudTrail(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		events = append(events, parsed...)
+	}
+
+	var advanced, secrets []string
+	for _, item := range inv.AdvancedParameters {
+		advanced = append(advanced, item.Name)
+	}
+	for _, item := range append(inv.Secrets, inv.LargeValues...) {
+		secrets = append(secrets, item.Name)
+	}
+	return cost.MonthlyCalls(events, cost.ParameterMatcher(advanced), cost.SecretMatcher(secrets)), nil
+}
+
+func printEstimate(est *cost.Estimate, service string) {
+	storage := "Parameter Store"
+	if service == "secrets_manager" {
+		storage = "Secrets Manager"
+	}
+	color.PrintBoldf("Estimated monthly cost of %s (%s):\n", est.Environment, storage)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  ITEM\tQUANTITY\tMONTHLY")
+	for _, line := range est.Lines {
+		fmt.Fprintf(w, "  %s\t%.0f %s\t$%.2f\n", line.Item, line.Quantity, line.Unit, line.Monthly)
+	}
+	fmt.Fprintf(w, "  Total\t\t$%.2f\n", est.Total)
+	w.Flush()
+
+	if len(est.Suggestions) > 0 {
+		color.PrintBoldf("\nCheaper options:")
+		for _, s := range est.Suggestions {
+			fmt.Printf("  - %s (saves $%.2f/month)\n", s.Message, s.Savings)
+		}
+	}
+	for _, note := range est.Notes {
+		color.PrintInfof("\nNote: %s", note)
+	}
+}
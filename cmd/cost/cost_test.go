@@ -0,0 +1,28 @@
+package cost
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drapon/envy/internal/cost"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCalls(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "events.json")
+	require.NoError(t, os.WriteFile(filename, []byte(`{"Records": [
+		{"eventSource": "secretsmanager.amazonaws.com", "eventTime": "2024-05-01T00:00:00Z", "requestParameters": {"secretId": "app-prod"}},
+		{"eventSource": "ssm.amazonaws.com", "eventTime": "2024-05-01T00:00:00Z", "requestParameters": {"name": "/app/prod/CERT"}}
+	]}`), 0600))
+
+	inv := &cost.Inventory{Secrets: []cost.Item{{Name: "app-prod"}}}
+	calls, err := loadCalls([]string{filename}, inv)
+	require.NoError(t, err)
+	assert.InDelta(t, 30.0, calls.Secrets, 1e-9)
+	assert.Zero(t, calls.AdvancedParameters)
+
+	_, err = loadCalls([]string{filepath.Join(t.TempDir(), "missing.json")}, inv)
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/gitops"
+	"github.com/drapon/envy/internal/sensitive"
+)
+
+// againstSpec is a parsed --against value: REV[:FILE] for a git revision or
+// snapshot:DATE[:FILE] for the latest pull backup taken on or before DATE
+type againstSpec struct {
+	rev      string    // git revision, empty for a snapshot
+	snapshot time.Time // start of the day after DATE
+	path     string    // empty for the environment's files
+}
+
+func parseAgainst(spec string) (againstSpec, error) {
+	if rest, ok := strings.CutPrefix(spec, "snapshot:"); ok {
+		date, path, _ := strings.Cut(rest, ":")
+		day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			return againstSpec{}, fmt.Errorf("invalid snapshot date %q (use YYYY-MM-DD)", date)
+		}
+		return againstSpec{snapshot: day.AddDate(0, 0, 1), path: path}, nil
+	}
+
+	rev, path, _ := strings.Cut(spec, ":")
+	if rev == "" {
+		return againstSpec{}, fmt.Errorf("invalid --against %q (use REV[:FILE] or snapshot:DATE[:FILE])", spec)
+	}
+	return againstSpec{rev: rev, path: path}, nil
+}
+
+// compareAgainst diffs the local files, as they were at a git revision or in
+// a pull backup, against their current contents
+func compareAgainst(ctx context.Context, cfg *config.Config, spec string) error {
+	parsed, err := parseAgainst(spec)
+	if err != nil {
+		return err
+	}
+
+	files := []string{parsed.path}
+	if parsed.path == "" {
+		envConfig, err := cfg.GetEnvironment(environment)
+		if err != nil {
+			return err
+		}
+		if len(envConfig.Files) == 0 {
+			return fmt.Errorf("no files configured for environment %s", environment)
+		}
+		files = envConfig.Files
+	}
+
+	var old *env.File
+	var source string
+	if parsed.rev != "" {
+		old, err = loadAtRevision(ctx, gitops.NewRepo("."), parsed.rev, files)
+		source = parsed.rev + ":" + strings.Join(files, ",")
+	} else {
+		old, source, err = loadSnapshot(files, parsed.snapshot)
+	}
+	if err != nil {
+		return err
+	}
+
+	current, err := env.NewManager(".").LoadFiles(files)
+	if err != nil {
+		return fmt.Errorf("failed to load local files: %w", err)
+	}
+
+	diff, err := filteredDiff(old.ToMap(), current.ToMap())
+	if err != nil {
+		return err
+	}
+
+	isSensitive, err := sensitive.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	return displayDiff(diff, source, strings.Join(files, ","), isSensitive)
+}
+
+// loadAtRevision merges files as committed at rev
+func loadAtRevision(ctx context.Context, repo *gitops.Repo, rev string, files []string) (*env.File, error) {
+	commit, err := repo.ResolveRef(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	slashed := make([]string, len(files))
+	for i, file := range files {
+		slashed[i] = filepath.ToSlash(file)
+	}
+	return gitops.LoadAt(ctx, repo, commit, slashed)
+}
+
+// loadSnapshot merges the latest backup of each file taken before the given
+// time and returns the backups it read
+func loadSnapshot(files []string, before time.Time) (*env.File, string, error) {
+	result := env.NewFile()
+	var backups []string
+	for _, file := range files {
+		backup, _, err := env.LatestBackup(file, before)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w; 'envy pull --backup' keeps a copy of the file before overwriting it", err)
+		}
+
+		parsed, err := env.ParseFile(backup)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse %s: %w", backup, err)
+		}
+		result.Merge(parsed)
+		backups = append(backups, backup)
+	}
+	return result, strings.Join(backups, ","), nil
+}
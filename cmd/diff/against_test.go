@@ -0,0 +1,82 @@
+package diff
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/drapon/envy/internal/gitops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAgainst(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    againstSpec
+		wantErr bool
+	}{
+		{spec: "HEAD~5:.env.dev", want: againstSpec{rev: "HEAD~5", path: ".env.dev"}},
+		{spec: "v1.4.0", want: againstSpec{rev: "v1.4.0"}},
+		{spec: "snapshot:2024-06-01", want: againstSpec{snapshot: time.Date(2024, 6, 2, 0, 0, 0, 0, time.Local)}},
+		{spec: "snapshot:2024-06-01:.env.dev", want: againstSpec{snapshot: time.Date(2024, 6, 2, 0, 0, 0, 0, time.Local), path: ".env.dev"}},
+		{spec: "snapshot:yesterday", wantErr: true},
+		{spec: ":.env.dev", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseAgainst(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.rev, got.rev)
+			assert.Equal(t, tt.want.path, got.path)
+			assert.True(t, tt.want.snapshot.Equal(got.snapshot))
+		})
+	}
+}
+
+func TestLoadAtRevision(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "test")
+	git("config", "commit.gpgsign", "false")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("A=1\nB=2\n"), 0644))
+	git("add", "-A")
+	git("commit", "-q", "-m", "first")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("A=3\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env.local"), []byte("C=4\n"), 0644))
+	git("add", "-A")
+	git("commit", "-q", "-m", "second")
+
+	repo := gitops.NewRepo(dir)
+
+	first, err := loadAtRevision(context.Background(), repo, "HEAD~1", []string{".env", ".env.local"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "1", "B": "2"}, first.ToMap())
+
+	second, err := loadAtRevision(context.Background(), repo, "HEAD", []string{".env", ".env.local"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "3", "C": "4"}, second.ToMap())
+
+	_, err = loadAtRevision(context.Background(), repo, "does-not-exist", []string{".env"})
+	assert.Error(t, err)
+}
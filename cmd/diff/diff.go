@@ -3,6 +3,8 @@ package diff
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"sort"
 	"strings"
 
@@ -10,6 +12,11 @@ import (
 	"github.com/drapon/envy/internal/aws"
 	"github.com/drapon/envy/internal/config"
 	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/inline"
+	"github.com/drapon/envy/internal/report"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/drapon/envy/internal/structured"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -19,11 +26,22 @@ var (
 	to          string
 	file1       string
 	file2       string
+	against     string
 	format      string
 	changes     string
+	output      string
 	environment string
 	showValues  bool
 	colorOutput bool
+	mask        bool
+	metadata    bool
+	fixMetadata bool
+	force       bool
+	filterOpts  filter.Options
+
+	// documentOutput receives the json and yaml documents; everything else
+	// goes to stderr then
+	documentOutput io.Writer = os.Stdout
 )
 
 // diffCmd represents the diff command
@@ -31,7 +49,14 @@ var diffCmd = &cobra.Command{
 	Use:   "diff",
 	Short: "Show differences between environments",
 	Long: `Show differences between local and remote environment variables,
-or between different environments or files.`,
+or between different environments or files.
+
+With --metadata, compare how the parameters of an environment are stored
+with .envyrc instead of their values: variables classified as sensitive
+stored as String, SecureStrings encrypted with another key than the
+environment's kms_key_id, and missing tags. --fix-metadata reconciles them;
+String parameters are recreated as SecureStrings, which resets their
+version history.`,
 	Example: `  # Compare local file with AWS
   envy diff
   
@@ -41,11 +66,33 @@ or between different environments or files.`,
   # Compare two files
   envy diff --file1 .env.dev --file2 .env.prod
   
+  # What changed in .env.dev over the last five commits
+  envy diff --against HEAD~5:.env.dev
+
+  # Local files of the default environment vs. the last release tag
+  envy diff --against v1.4.0
+
+  # .env.dev vs. its latest pull backup (envy pull --backup) from June 1st or earlier
+  envy diff --against snapshot:2024-06-01:.env.dev
+  
   # Show only additions
   envy diff --changes additions
+
+  # Compare only database settings
+  envy diff --from dev --to prod --filter "DB_*"
   
-  # Output as JSON
-  envy diff --format json`,
+  # Output as JSON, or YAML
+  envy diff --format json
+  envy diff --from dev --to prod --output yaml
+
+  # Markdown table for a PR comment, with secret values masked
+  envy diff --from dev --to prod --format markdown --mask
+
+  # HTML report for a change ticket, with secret values masked
+  envy diff --from dev --to prod --format html --mask -o changes.html
+
+  # Secrets stored as String, wrong KMS keys and missing tags in prod, fixed
+  envy diff --env prod --metadata --fix-metadata`,
 	RunE: runDiff,
 }
 
@@ -62,16 +109,41 @@ func init() {
 	diffCmd.Flags().StringVar(&to, "to", "aws", "Target environment or 'aws'")
 	diffCmd.Flags().StringVar(&file1, "file1", "", "First file to compare")
 	diffCmd.Flags().StringVar(&file2, "file2", "", "Second file to compare")
-	diffCmd.Flags().StringVarP(&format, "format", "f", "text", "Output format (text/json)")
+	diffCmd.Flags().StringVar(&against, "against", "", "Compare local files with a git revision (REV[:FILE]) or a pull backup (snapshot:DATE[:FILE])")
+	diffCmd.Flags().StringVarP(&format, "format", "f", "text", "Output format (text/json/yaml/markdown/html)")
+	diffCmd.Flags().StringVarP(&output, "output", "o", "", "Write markdown and html reports to a file instead of stdout (json, yaml or table select the format)")
 	diffCmd.Flags().StringVarP(&changes, "changes", "c", "all", "Show changes (all/additions/deletions/modifications)")
 	diffCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to use for comparison")
-	diffCmd.Flags().BoolVar(&showValues, "show-values", false, "Show actual values in diff")
+	diffCmd.Flags().BoolVar(&showValues, "show-values", false, "Show actual values in diff (sensitive values stay masked)")
+	diffCmd.Flags().BoolVar(&mask, "mask", false, "Show values with sensitive values masked")
 	diffCmd.Flags().BoolVar(&colorOutput, "color", true, "Enable colored output")
+	diffCmd.Flags().BoolVar(&metadata, "metadata", false, "Compare parameter types, KMS keys and tags with .envyrc instead of values")
+	diffCmd.Flags().BoolVar(&fixMetadata, "fix-metadata", false, "Reconcile the metadata drift found (implies --metadata)")
+	diffCmd.Flags().BoolVar(&force, "force", false, "Skip confirmation when --fix-metadata recreates parameters")
+	filter.AddFlags(diffCmd.Flags(), &filterOpts)
 }
 
 func runDiff(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
+	// --output json|yaml|table applies unless --format is given
+	var err error
+	if format, err = structured.ResolveFormat(cmd); err != nil {
+		return err
+	}
+	if structured.IsFormat(output) {
+		output = ""
+	}
+	if format == "json" || format == "yaml" {
+		var restore func()
+		documentOutput, restore = structured.KeepStdout()
+		defer restore()
+	}
+
+	if _, isReport := report.Lookup(format); output != "" && !isReport && format != "markdown" && format != "md" {
+		return fmt.Errorf("--output applies to the markdown and html formats")
+	}
+
 	// If files are specified, compare them directly
 	if file1 != "" && file2 != "" {
 		return compareFiles(file1, file2)
@@ -88,13 +160,21 @@ func runDiff(cmd *cobra.Command, args []string) error {
 		environment = cfg.DefaultEnvironment
 	}
 
+	if against != "" {
+		return compareAgainst(ctx, cfg, against)
+	}
+
+	if metadata || fixMetadata {
+		return compareMetadata(ctx, cfg)
+	}
+
 	// Get variables for comparison
 	var vars1, vars2 map[string]string
 	var source1, source2 string
 
 	// Handle 'from' source
 	if from == "local" {
-		vars1, err = getLocalVariables(cfg, environment)
+		vars1, err = getLocalVariables(ctx, cfg, environment)
 		source1 = "local files"
 	} else if from == "aws" {
 		vars1, err = getAWSVariables(ctx, cfg, environment)
@@ -102,7 +182,7 @@ func runDiff(cmd *cobra.Command, args []string) error {
 	} else {
 		// Treat as environment name
 		if from == environment {
-			vars1, err = getLocalVariables(cfg, from)
+			vars1, err = getLocalVariables(ctx, cfg, from)
 			source1 = fmt.Sprintf("local %s", from)
 		} else {
 			vars1, err = getAWSVariables(ctx, cfg, from)
@@ -115,7 +195,7 @@ func runDiff(cmd *cobra.Command, args []string) error {
 
 	// Handle 'to' source
 	if to == "local" {
-		vars2, err = getLocalVariables(cfg, environment)
+		vars2, err = getLocalVariables(ctx, cfg, environment)
 		source2 = "local files"
 	} else if to == "aws" {
 		vars2, err = getAWSVariables(ctx, cfg, environment)
@@ -123,7 +203,7 @@ func runDiff(cmd *cobra.Command, args []string) error {
 	} else {
 		// Treat as environment name
 		if to == environment {
-			vars2, err = getLocalVariables(cfg, to)
+			vars2, err = getLocalVariables(ctx, cfg, to)
 			source2 = fmt.Sprintf("local %s", to)
 		} else {
 			vars2, err = getAWSVariables(ctx, cfg, to)
@@ -135,14 +215,17 @@ func runDiff(cmd *cobra.Command, args []string) error {
 	}
 
 	// Calculate differences
-	diff := calculateDiff(vars1, vars2)
+	diff, err := filteredDiff(vars1, vars2)
+	if err != nil {
+		return err
+	}
 
-	// Display results
-	if format == "json" {
-		return displayJSONDiff(diff, source1, source2)
+	isSensitive, err := sensitive.FromConfig(cfg)
+	if err != nil {
+		return err
 	}
 
-	return displayTextDiff(diff, source1, source2)
+	return displayDiff(diff, source1, source2, isSensitive)
 }
 
 func compareFiles(file1Path, file2Path string) error {
@@ -159,17 +242,16 @@ func compareFiles(file1Path, file2Path string) error {
 	}
 
 	// Calculate differences
-	diff := calculateDiff(f1.ToMap(), f2.ToMap())
-
-	// Display results
-	if format == "json" {
-		return displayJSONDiff(diff, file1Path, file2Path)
+	diff, err := filteredDiff(f1.ToMap(), f2.ToMap())
+	if err != nil {
+		return err
 	}
 
-	return displayTextDiff(diff, file1Path, file2Path)
+	// Plain file comparisons run without .envyrc, so use the built-in detection
+	return displayDiff(diff, file1Path, file2Path, sensitive.IsSensitive)
 }
 
-func getLocalVariables(cfg *config.Config, envName string) (map[string]string, error) {
+func getLocalVariables(ctx context.Context, cfg *config.Config, envName string) (map[string]string, error) {
 	envConfig, err := cfg.GetEnvironment(envName)
 	if err != nil {
 		return nil, err
@@ -180,6 +262,9 @@ func getLocalVariables(cfg *config.Config, envName string) (map[string]string, e
 	if err != nil {
 		return nil, err
 	}
+	if err := inline.Decrypt(ctx, cfg, file); err != nil {
+		return nil, err
+	}
 
 	return file.ToMap(), nil
 }
@@ -193,6 +278,16 @@ func getAWSVariables(ctx context.Context, cfg *config.Config, envName string) (m
 	return awsManager.ListEnvironmentVariables(ctx, envName)
 }
 
+// filteredDiff applies --filter/--exclude/--keys to both sides before diffing
+func filteredDiff(from, to map[string]string) (*DiffResult, error) {
+	keyFilter, err := filter.New(filterOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return calculateDiff(keyFilter.ApplyMap(from), keyFilter.ApplyMap(to)), nil
+}
+
 type DiffResult struct {
 	Added     map[string]string
 	Deleted   map[string]string
@@ -231,7 +326,43 @@ func calculateDiff(from, to map[string]string) *DiffResult {
 	return result
 }
 
-func displayTextDiff(diff *DiffResult, source1, source2 string) error {
+// valueMask decides which values a diff may print
+type valueMask struct {
+	show        bool              // print values at all
+	isSensitive func(string) bool // values of these keys always print as ***
+}
+
+func (m valueMask) value(key, value string) string {
+	if !m.show || m.isSensitive(key) {
+		return "***"
+	}
+	return value
+}
+
+// displayDiff renders a diff in the requested format
+func displayDiff(diff *DiffResult, source1, source2 string, isSensitive func(string) bool) error {
+	// --mask shows values; the mask always hides sensitive ones
+	values := valueMask{show: showValues || mask, isSensitive: isSensitive}
+
+	if renderer, ok := report.Lookup(format); ok {
+		return writeOutput(func(w io.Writer) error {
+			return renderer.Render(w, diffDocument(diff, source1, source2, values))
+		})
+	}
+
+	switch format {
+	case "json", "yaml":
+		return displayDocumentDiff(documentOutput, diff, source1, source2, values)
+	case "markdown", "md":
+		return writeOutput(func(w io.Writer) error {
+			return displayMarkdownDiff(w, diff, source1, source2, values)
+		})
+	default:
+		return displayTextDiff(diff, source1, source2, values)
+	}
+}
+
+func displayTextDiff(diff *DiffResult, source1, source2 string, values valueMask) error {
 	fmt.Printf("Comparing %s → %s\n\n", source1, source2)
 
 	hasChanges := false
@@ -245,8 +376,8 @@ func displayTextDiff(diff *DiffResult, source1, source2 string) error {
 		fmt.Println("Added:")
 		keys := sortedKeys(diff.Added)
 		for _, key := range keys {
-			if showValues {
-				fmt.Printf("  + %s = %s\n", key, diff.Added[key])
+			if values.show {
+				fmt.Printf("  + %s = %s\n", key, values.value(key, diff.Added[key]))
 			} else {
 				fmt.Printf("  + %s\n", key)
 			}
@@ -266,8 +397,8 @@ func displayTextDiff(diff *DiffResult, source1, source2 string) error {
 		fmt.Println("Deleted:")
 		keys := sortedKeys(diff.Deleted)
 		for _, key := range keys {
-			if showValues {
-				fmt.Printf("  - %s = %s\n", key, diff.Deleted[key])
+			if values.show {
+				fmt.Printf("  - %s = %s\n", key, values.value(key, diff.Deleted[key]))
 			} else {
 				fmt.Printf("  - %s\n", key)
 			}
@@ -287,11 +418,11 @@ func displayTextDiff(diff *DiffResult, source1, source2 string) error {
 		fmt.Println("Modified:")
 		keys := sortedKeysModified(diff.Modified)
 		for _, key := range keys {
-			values := diff.Modified[key]
-			if showValues {
+			change := diff.Modified[key]
+			if values.show {
 				fmt.Printf("  ~ %s\n", key)
-				fmt.Printf("    - %s\n", maskValue(key, values[0]))
-				fmt.Printf("    + %s\n", maskValue(key, values[1]))
+				fmt.Printf("    - %s\n", values.value(key, change[0]))
+				fmt.Printf("    + %s\n", values.value(key, change[1]))
 			} else {
 				fmt.Printf("  ~ %s\n", key)
 			}
@@ -313,75 +444,139 @@ func displayTextDiff(diff *DiffResult, source1, source2 string) error {
 	return nil
 }
 
-func displayJSONDiff(diff *DiffResult, source1, source2 string) error {
-	// Simple JSON output
-	fmt.Println("{")
-	fmt.Printf("  \"from\": \"%s\",\n", source1)
-	fmt.Printf("  \"to\": \"%s\",\n", source2)
-	fmt.Printf("  \"added\": %d,\n", len(diff.Added))
-	fmt.Printf("  \"deleted\": %d,\n", len(diff.Deleted))
-	fmt.Printf("  \"modified\": %d,\n", len(diff.Modified))
-	fmt.Printf("  \"unchanged\": %d,\n", len(diff.Unchanged))
+// documentDiff is the json and yaml output of diff. Changed values are
+// only included when values are shown.
+type documentDiff struct {
+	From      string           `json:"from"`
+	To        string           `json:"to"`
+	Added     int              `json:"added"`
+	Deleted   int              `json:"deleted"`
+	Modified  int              `json:"modified"`
+	Unchanged int              `json:"unchanged"`
+	Changes   *documentChanges `json:"changes,omitempty"`
+}
 
-	if showValues {
-		fmt.Println("  \"changes\": {")
+// documentChanges holds the values of the changed variables
+type documentChanges struct {
+	Added    map[string]string         `json:"added,omitempty"`
+	Deleted  map[string]string         `json:"deleted,omitempty"`
+	Modified map[string]documentChange `json:"modified,omitempty"`
+}
 
+// documentChange is the old and new value of a modified variable
+type documentChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// displayDocumentDiff writes a diff as a JSON or YAML document
+func displayDocumentDiff(w io.Writer, diff *DiffResult, source1, source2 string, values valueMask) error {
+	doc := documentDiff{
+		From:      source1,
+		To:        source2,
+		Added:     len(diff.Added),
+		Deleted:   len(diff.Deleted),
+		Modified:  len(diff.Modified),
+		Unchanged: len(diff.Unchanged),
+	}
+
+	if values.show {
+		doc.Changes = &documentChanges{}
 		if len(diff.Added) > 0 {
-			fmt.Println("    \"added\": {")
-			keys := sortedKeys(diff.Added)
-			for i, key := range keys {
-				fmt.Printf("      \"%s\": \"%s\"", key, diff.Added[key])
-				if i < len(keys)-1 {
-					fmt.Print(",")
-				}
-				fmt.Println()
+			doc.Changes.Added = make(map[string]string, len(diff.Added))
+			for key, value := range diff.Added {
+				doc.Changes.Added[key] = values.value(key, value)
 			}
-			fmt.Print("    }")
-			if len(diff.Deleted) > 0 || len(diff.Modified) > 0 {
-				fmt.Print(",")
-			}
-			fmt.Println()
 		}
-
 		if len(diff.Deleted) > 0 {
-			fmt.Println("    \"deleted\": {")
-			keys := sortedKeys(diff.Deleted)
-			for i, key := range keys {
-				fmt.Printf("      \"%s\": \"%s\"", key, diff.Deleted[key])
-				if i < len(keys)-1 {
-					fmt.Print(",")
-				}
-				fmt.Println()
-			}
-			fmt.Print("    }")
-			if len(diff.Modified) > 0 {
-				fmt.Print(",")
+			doc.Changes.Deleted = make(map[string]string, len(diff.Deleted))
+			for key, value := range diff.Deleted {
+				doc.Changes.Deleted[key] = values.value(key, value)
 			}
-			fmt.Println()
 		}
-
 		if len(diff.Modified) > 0 {
-			fmt.Println("    \"modified\": {")
-			keys := sortedKeysModified(diff.Modified)
-			for i, key := range keys {
-				values := diff.Modified[key]
-				fmt.Printf("      \"%s\": {\"old\": \"%s\", \"new\": \"%s\"}",
-					key, maskValue(key, values[0]), maskValue(key, values[1]))
-				if i < len(keys)-1 {
-					fmt.Print(",")
-				}
-				fmt.Println()
+			doc.Changes.Modified = make(map[string]documentChange, len(diff.Modified))
+			for key, change := range diff.Modified {
+				doc.Changes.Modified[key] = documentChange{Old: values.value(key, change[0]), New: values.value(key, change[1])}
 			}
-			fmt.Println("    }")
 		}
+	}
+
+	return structured.Write(w, structured.Format(format), doc)
+}
+
+// displayMarkdownDiff writes a GitHub-flavored markdown table of changes,
+// suitable for posting as a pull request comment
+func displayMarkdownDiff(w io.Writer, diff *DiffResult, source1, source2 string, values valueMask) error {
+	fmt.Fprintf(w, "### Environment changes: %s → %s\n\n", mdCell(source1), mdCell(source2))
 
-		fmt.Println("  }")
+	type row struct {
+		change, key, oldValue, newValue string
+	}
+	var rows []row
+
+	if changes == "all" || changes == "additions" {
+		for _, key := range sortedKeys(diff.Added) {
+			rows = append(rows, row{"Added", key, "", values.value(key, diff.Added[key])})
+		}
+	}
+	if changes == "all" || changes == "modifications" {
+		for _, key := range sortedKeysModified(diff.Modified) {
+			change := diff.Modified[key]
+			rows = append(rows, row{"Modified", key, values.value(key, change[0]), values.value(key, change[1])})
+		}
+	}
+	if changes == "all" || changes == "deletions" {
+		for _, key := range sortedKeys(diff.Deleted) {
+			rows = append(rows, row{"Removed", key, values.value(key, diff.Deleted[key]), ""})
+		}
+	}
+
+	if len(rows) == 0 {
+		fmt.Fprintln(w, "No differences found.")
+		return nil
+	}
+
+	if values.show {
+		fmt.Fprintln(w, "| Change | Key | Old | New |")
+		fmt.Fprintln(w, "|--------|-----|-----|-----|")
+	} else {
+		fmt.Fprintln(w, "| Change | Key |")
+		fmt.Fprintln(w, "|--------|-----|")
 	}
 
-	fmt.Println("}")
+	for _, r := range rows {
+		if values.show {
+			fmt.Fprintf(w, "| %s | %s | %s | %s |\n", r.change, mdCode(r.key), mdCode(r.oldValue), mdCode(r.newValue))
+		} else {
+			fmt.Fprintf(w, "| %s | %s |\n", r.change, mdCode(r.key))
+		}
+	}
+
+	fmt.Fprintf(w, "\n**Summary:** %d added, %d modified, %d removed, %d unchanged\n",
+		len(diff.Added), len(diff.Modified), len(diff.Deleted), len(diff.Unchanged))
 	return nil
 }
 
+// mdCell escapes text for use inside a markdown table cell
+func mdCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	text = strings.ReplaceAll(text, "\r\n", "<br>")
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
+
+// mdCode formats a value as inline code inside a table cell
+func mdCode(text string) string {
+	if text == "" {
+		return ""
+	}
+	// Inline code cannot contain the delimiter or line breaks
+	if strings.ContainsAny(text, "`\r\n") {
+		return mdCell(text)
+	}
+	return "`" + strings.ReplaceAll(text, "|", "\\|") + "`"
+}
+
 func sortedKeys(m map[string]string) []string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -399,26 +594,3 @@ func sortedKeysModified(m map[string][2]string) []string {
 	sort.Strings(keys)
 	return keys
 }
-
-func maskValue(key, value string) string {
-	if !showValues || isSensitiveKey(key) {
-		return "***"
-	}
-	return value
-}
-
-func isSensitiveKey(key string) bool {
-	lowerKey := strings.ToLower(key)
-	sensitivePatterns := []string{
-		"password", "secret", "key", "token",
-		"credential", "auth", "private",
-	}
-
-	for _, pattern := range sensitivePatterns {
-		if strings.Contains(lowerKey, pattern) {
-			return true
-		}
-	}
-
-	return false
-}
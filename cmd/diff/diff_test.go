@@ -1,9 +1,13 @@
 package diff
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/drapon/envy/internal/report"
+	"github.com/drapon/envy/internal/sensitive"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDiffCommand(t *testing.T) {
@@ -259,8 +263,8 @@ func TestDiffValueMasking(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			showValues = tt.showValues
-			result := maskValue(tt.key, tt.value)
+			values := valueMask{show: tt.showValues, isSensitive: sensitive.IsSensitive}
+			result := values.value(tt.key, tt.value)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -417,8 +421,8 @@ func TestMaskValue(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			showValues = tt.showValues
-			result := maskValue(tt.key, tt.value)
+			values := valueMask{show: tt.showValues, isSensitive: sensitive.IsSensitive}
+			result := values.value(tt.key, tt.value)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -469,7 +473,7 @@ func TestIsSensitiveKey(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isSensitiveKey(tt.key)
+			result := sensitive.IsSensitive(tt.key)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -536,4 +540,122 @@ func TestCalculateDiff(t *testing.T) {
 			assert.Equal(t, tt.expected, result)
 		})
 	}
-}
\ No newline at end of file
+}
+func TestDisplayMarkdownDiff(t *testing.T) {
+	defer func() {
+		changes = "all"
+	}()
+	changes = "all"
+
+	diff := calculateDiff(
+		map[string]string{"APP_NAME": "old|name", "DB_PASSWORD": "hunter2", "REMOVED": "x", "SAME": "1"},
+		map[string]string{"APP_NAME": "new", "DB_PASSWORD": "hunter3", "ADDED": "y", "SAME": "1"},
+	)
+
+	t.Run("masked values", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := displayMarkdownDiff(&buf, diff, "dev", "prod", valueMask{show: true, isSensitive: sensitive.IsSensitive})
+		assert.NoError(t, err)
+
+		out := buf.String()
+		assert.Contains(t, out, "### Environment changes: dev → prod")
+		assert.Contains(t, out, "| Change | Key | Old | New |")
+		assert.Contains(t, out, "| Added | `ADDED` |  | `y` |")
+		assert.Contains(t, out, "| Modified | `APP_NAME` | `old\\|name` | `new` |")
+		assert.Contains(t, out, "| Modified | `DB_PASSWORD` | `***` | `***` |")
+		assert.Contains(t, out, "| Removed | `REMOVED` | `x` |  |")
+		assert.Contains(t, out, "**Summary:** 1 added, 2 modified, 1 removed, 1 unchanged")
+		assert.NotContains(t, out, "hunter")
+	})
+
+	t.Run("keys only", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, displayMarkdownDiff(&buf, diff, "dev", "prod", valueMask{isSensitive: sensitive.IsSensitive}))
+		assert.Contains(t, buf.String(), "| Change | Key |\n")
+		assert.NotContains(t, buf.String(), "Old")
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		var buf bytes.Buffer
+		same := calculateDiff(map[string]string{"A": "1"}, map[string]string{"A": "1"})
+		assert.NoError(t, displayMarkdownDiff(&buf, same, "dev", "prod", valueMask{isSensitive: sensitive.IsSensitive}))
+		assert.Contains(t, buf.String(), "No differences found.")
+	})
+
+	t.Run("sensitivity overrides", func(t *testing.T) {
+		isSensitive, err := sensitive.New([]string{"APP_NAME"}, []string{"DB_PASSWORD"})
+		assert.NoError(t, err)
+
+		var buf bytes.Buffer
+		assert.NoError(t, displayMarkdownDiff(&buf, diff, "dev", "prod", valueMask{show: true, isSensitive: isSensitive}))
+		assert.Contains(t, buf.String(), "| Modified | `APP_NAME` | `***` | `***` |")
+		assert.Contains(t, buf.String(), "| Modified | `DB_PASSWORD` | `hunter2` | `hunter3` |")
+	})
+}
+
+func TestDisplayDiffMask(t *testing.T) {
+	defer func() {
+		mask = false
+		format = ""
+	}()
+	mask = true
+	format = "markdown"
+
+	diff := calculateDiff(map[string]string{}, map[string]string{"API_TOKEN": "t0ken"})
+	assert.NoError(t, displayDiff(diff, "dev", "prod", sensitive.IsSensitive))
+
+	// --mask is passed to the renderer, not folded into --show-values
+	assert.False(t, showValues)
+}
+
+func TestDiffDocument(t *testing.T) {
+	defer func() { changes = "all" }()
+	changes = "all"
+
+	diff := calculateDiff(
+		map[string]string{"API_TOKEN": "old", "HOST": "a", "GONE": "x"},
+		map[string]string{"API_TOKEN": "new", "HOST": "b"},
+	)
+	doc := diffDocument(diff, "dev", "prod", valueMask{show: true, isSensitive: sensitive.IsSensitive})
+
+	assert.Equal(t, "Environment changes: dev → prod", doc.Title)
+	assert.Equal(t, report.StatusWarn, doc.Status)
+	require.Len(t, doc.Sections, 3)
+	assert.True(t, doc.Sections[0].Collapsed, "no additions")
+	assert.Equal(t, [][]string{{"API_TOKEN", "***", "***"}, {"HOST", "a", "b"}}, doc.Sections[1].Rows)
+	assert.Equal(t, [][]string{{"GONE", "x", ""}}, doc.Sections[2].Rows)
+
+	doc = diffDocument(diff, "dev", "prod", valueMask{isSensitive: sensitive.IsSensitive})
+	assert.Equal(t, [][]string{{"GONE"}}, doc.Sections[2].Rows, "values are left out without --show-values or --mask")
+}
+
+func TestDisplayDocumentDiff(t *testing.T) {
+	defer func() { format = "text" }()
+
+	diff := calculateDiff(
+		map[string]string{"API_TOKEN": "old", "HOST": "a \"quoted\"", "GONE": "x"},
+		map[string]string{"API_TOKEN": "new", "HOST": "b", "PORT": "80"},
+	)
+	values := valueMask{show: true, isSensitive: sensitive.IsSensitive}
+
+	var buf bytes.Buffer
+	format = "json"
+	require.NoError(t, displayDocumentDiff(&buf, diff, "dev", "prod", values))
+	assert.JSONEq(t, `{
+		"from": "dev", "to": "prod",
+		"added": 1, "deleted": 1, "modified": 2, "unchanged": 0,
+		"changes": {
+			"added": {"PORT": "80"},
+			"deleted": {"GONE": "x"},
+			"modified": {
+				"API_TOKEN": {"old": "***", "new": "***"},
+				"HOST": {"old": "a \"quoted\"", "new": "b"}
+			}
+		}
+	}`, buf.String())
+
+	buf.Reset()
+	format = "yaml"
+	require.NoError(t, displayDocumentDiff(&buf, diff, "dev", "prod", valueMask{isSensitive: sensitive.IsSensitive}))
+	assert.Equal(t, "from: dev\nto: prod\nadded: 1\ndeleted: 1\nmodified: 2\nunchanged: 0\n", buf.String(), "values are left out without --show-values or --mask")
+}
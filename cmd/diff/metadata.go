@@ -0,0 +1,105 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/precheck"
+	"github.com/drapon/envy/internal/prompt"
+	"github.com/drapon/envy/internal/structured"
+)
+
+// compareMetadata reports the parameters of the environment whose type,
+// KMS key or tags differ from .envyrc, and reconciles them with
+// --fix-metadata
+func compareMetadata(ctx context.Context, cfg *config.Config) error {
+	if cfg.UsesAzure() {
+		return fmt.Errorf("metadata drift is only detected in AWS Parameter Store")
+	}
+	if format != "text" && format != "json" && format != "yaml" {
+		return fmt.Errorf("unsupported format for --metadata: %s (use text, json or yaml)", format)
+	}
+
+	keyFilter, err := filter.New(filterOpts)
+	if err != nil {
+		return err
+	}
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+	drift, err := awsManager.MetadataDrift(ctx, environment, keyFilter.Match)
+	if err != nil {
+		return err
+	}
+
+	if format != "text" {
+		if drift == nil {
+			drift = []aws.MetadataDrift{}
+		}
+		if err := structured.Write(documentOutput, structured.Format(format), drift); err != nil {
+			return err
+		}
+	} else if err := writeMetadataDrift(os.Stdout, drift); err != nil {
+		return err
+	}
+
+	if !fixMetadata || len(drift) == 0 {
+		return nil
+	}
+	return fixDrift(ctx, cfg, awsManager, drift)
+}
+
+func writeMetadataDrift(w io.Writer, drift []aws.MetadataDrift) error {
+	if len(drift) == 0 {
+		color.PrintSuccessf("Parameters of %s match their configured type, KMS key and tags", environment)
+		return nil
+	}
+
+	color.PrintBoldf("Metadata drift in %s:", environment)
+	for _, d := range drift {
+		if _, err := fmt.Fprintf(w, "  %s %s %s: expected %s, found %s\n", color.FormatWarning("~"), d.Key, d.Kind, d.Expected, d.Actual); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fixDrift(ctx context.Context, cfg *config.Config, awsManager *aws.Manager, drift []aws.MetadataDrift) error {
+	var keys []string
+	recreated := 0
+	for _, d := range drift {
+		keys = append(keys, d.Key)
+		if d.Kind == aws.DriftType {
+			recreated++
+		}
+	}
+	if err := precheck.Writers(ctx, cfg, awsManager, keys); err != nil {
+		return err
+	}
+	if recreated > 0 && !force && !confirmRecreate(recreated) {
+		color.PrintWarningf("Metadata fix cancelled")
+		return nil
+	}
+
+	now := time.Now()
+	for _, d := range drift {
+		if err := awsManager.FixMetadata(ctx, d, now); err != nil {
+			return fmt.Errorf("failed to fix %s of %s: %w", d.Kind, d.Key, err)
+		}
+		fmt.Printf("  %s %s %s\n", color.FormatSuccess("✓"), d.Key, d.Kind)
+	}
+	color.PrintSuccessf("Fixed %d metadata difference(s) in %s", len(drift), environment)
+	return nil
+}
+
+func confirmRecreate(count int) bool {
+	return prompt.Confirm(color.FormatWarning(fmt.Sprintf("%d String parameter(s) in %s will be deleted and recreated as SecureStrings, resetting their version history.", count, environment)) + " Continue?")
+}
@@ -0,0 +1,29 @@
+package diff
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMetadataDrift(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeMetadataDrift(&buf, []aws.MetadataDrift{
+		{Key: "DB_PASSWORD", Kind: aws.DriftType, Expected: "SecureString", Actual: "String"},
+		{Key: "API_TOKEN", Kind: aws.DriftTags, Expected: "Team=payments", Actual: "(missing)"},
+	}))
+	assert.Contains(t, buf.String(), "DB_PASSWORD type: expected SecureString, found String")
+	assert.Contains(t, buf.String(), "API_TOKEN tags: expected Team=payments, found (missing)")
+}
+
+func TestCompareMetadata_Format(t *testing.T) {
+	defer func() { format = "text" }()
+	format = "markdown"
+	err := compareMetadata(context.Background(), config.DefaultConfig())
+	assert.ErrorContains(t, err, "unsupported format")
+}
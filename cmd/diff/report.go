@@ -0,0 +1,91 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/report"
+)
+
+// writeOutput calls write with --output, or stdout when it is not set
+func writeOutput(write func(w io.Writer) error) error {
+	if output == "" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	color.PrintSuccessf("Report written to %s", output)
+	return nil
+}
+
+// diffDocument describes a diff for the renderers of the report package,
+// with a section per kind of change
+func diffDocument(diff *DiffResult, source1, source2 string, values valueMask) *report.Document {
+	doc := &report.Document{
+		Title: fmt.Sprintf("Environment changes: %s → %s", source1, source2),
+		Summary: []string{fmt.Sprintf("%d added, %d modified, %d removed, %d unchanged",
+			len(diff.Added), len(diff.Modified), len(diff.Deleted), len(diff.Unchanged))},
+		Status: report.StatusPass,
+	}
+	if values.show {
+		doc.Summary = append(doc.Summary, "Values of sensitive variables are masked.")
+	}
+
+	columns := []string{"Key"}
+	if values.show {
+		columns = []string{"Key", "Old", "New"}
+	}
+	row := func(key, oldValue, newValue string) []string {
+		if !values.show {
+			return []string{key}
+		}
+		return []string{key, oldValue, newValue}
+	}
+
+	if changes == "all" || changes == "additions" {
+		section := report.Section{Title: fmt.Sprintf("Added (%d)", len(diff.Added)), Columns: columns}
+		for _, key := range sortedKeys(diff.Added) {
+			section.Rows = append(section.Rows, row(key, "", values.value(key, diff.Added[key])))
+		}
+		doc.Sections = append(doc.Sections, section)
+	}
+	if changes == "all" || changes == "modifications" {
+		section := report.Section{Title: fmt.Sprintf("Modified (%d)", len(diff.Modified)), Columns: columns}
+		for _, key := range sortedKeysModified(diff.Modified) {
+			change := diff.Modified[key]
+			section.Rows = append(section.Rows, row(key, values.value(key, change[0]), values.value(key, change[1])))
+		}
+		doc.Sections = append(doc.Sections, section)
+	}
+	if changes == "all" || changes == "deletions" {
+		section := report.Section{Title: fmt.Sprintf("Removed (%d)", len(diff.Deleted)), Columns: columns}
+		for _, key := range sortedKeys(diff.Deleted) {
+			section.Rows = append(section.Rows, row(key, values.value(key, diff.Deleted[key]), ""))
+		}
+		doc.Sections = append(doc.Sections, section)
+	}
+
+	for i := range doc.Sections {
+		section := &doc.Sections[i]
+		if len(section.Rows) == 0 {
+			section.Collapsed = true
+			section.Note = "No changes."
+			continue
+		}
+		section.Status = report.StatusWarn
+		doc.Status = report.StatusWarn
+	}
+	return doc
+}
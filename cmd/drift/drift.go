@@ -0,0 +1,260 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/inline"
+	"github.com/drapon/envy/internal/remote"
+	"github.com/drapon/envy/internal/structured"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// schemaVersion is the version of the JSON report. It changes only when a
+// field is removed or changes meaning; new fields may be added.
+const schemaVersion = 1
+
+// exitDrift is the exit status with --exit-code when an environment has
+// drifted. Errors exit with status 1, so pipelines can tell them apart.
+const exitDrift = 2
+
+// Drift statuses of a variable
+const (
+	statusChanged    = "changed"     // different values locally and remotely
+	statusLocalOnly  = "local_only"  // in the local files, not pushed
+	statusRemoteOnly = "remote_only" // in the remote store, not in the files
+)
+
+var (
+	environments []string
+	all          bool
+	exitCode     bool
+	format       string
+	filterOpts   filter.Options
+)
+
+// driftCmd represents the drift command
+var driftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "Report drift between local files and the remote store, for CI",
+	Long: `Compare the local files of environments with their variables in AWS (or
+Azure) and report the keys that differ, for CI pipelines that should fail
+on configuration drift.
+
+Unlike 'envy diff', the output is meant for machines: a JSON report with a
+schema_version, keys sorted, and no values or colors, so it is stable
+between runs and safe to keep as a build artifact. Each drifted key has a
+status of changed, local_only or remote_only. --format yaml prints the
+report in YAML, and --format text for people.
+
+With --exit-code the command exits with status 2 when any environment has
+drifted, 0 when all are in sync, and 1 on errors.`,
+	Example: `  # Fail the pipeline when prod differs from .env.prod
+  envy drift --env prod --exit-code
+
+  # Check every environment and keep the report
+  envy drift --all --exit-code > drift.json
+
+  # Only the database settings, for people
+  envy drift --env staging --filter "DB_*" --format text`,
+	Args: cobra.NoArgs,
+	RunE: runDrift,
+}
+
+// GetDriftCmd returns the drift command.
+func GetDriftCmd() *cobra.Command {
+	return driftCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(driftCmd)
+
+	driftCmd.Flags().StringArrayVarP(&environments, "env", "e", nil, "Environment to check (repeatable; default: default_environment)")
+	driftCmd.Flags().BoolVarP(&all, "all", "a", false, "Check every environment")
+	driftCmd.Flags().BoolVar(&exitCode, "exit-code", false, "Exit with status 2 when drift is found")
+	driftCmd.Flags().StringVarP(&format, "format", "f", "json", "Output format (json/yaml/text)")
+	filter.AddFlags(driftCmd.Flags(), &filterOpts)
+	driftCmd.MarkFlagsMutuallyExclusive("env", "all")
+}
+
+// report is the JSON output of drift
+type report struct {
+	SchemaVersion int                 `json:"schema_version"`
+	Drifted       bool                `json:"drifted"`
+	Environments  []environmentReport `json:"environments"`
+}
+
+// environmentReport is the drift of one environment
+type environmentReport struct {
+	Name    string     `json:"name"`
+	Files   []string   `json:"files"`
+	Remote  string     `json:"remote"`
+	Drifted bool       `json:"drifted"`
+	Summary summary    `json:"summary"`
+	Keys    []driftKey `json:"keys"`
+}
+
+// summary counts the keys of an environment by status
+type summary struct {
+	Changed    int `json:"changed"`
+	LocalOnly  int `json:"local_only"`
+	RemoteOnly int `json:"remote_only"`
+	InSync     int `json:"in_sync"`
+}
+
+// driftKey is a drifted variable
+type driftKey struct {
+	Key    string `json:"key"`
+	Status string `json:"status"`
+}
+
+func runDrift(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	// --output json|yaml|table applies unless --format is given
+	var err error
+	if format, err = structured.ResolveFormat(cmd); err != nil {
+		return err
+	}
+	if format != "json" && format != "yaml" && format != "text" {
+		return fmt.Errorf("unsupported format: %s (use json, yaml or text)", format)
+	}
+	keyFilter, err := filter.New(filterOpts)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	names, err := environmentNames(cfg)
+	if err != nil {
+		return err
+	}
+
+	backend, err := remote.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	result := report{SchemaVersion: schemaVersion, Environments: []environmentReport{}}
+	for _, name := range names {
+		envConfig, _ := cfg.GetEnvironment(name)
+		file, err := env.NewManager(".").LoadFiles(envConfig.Files)
+		if err != nil {
+			return fmt.Errorf("failed to load the files of %s: %w", name, err)
+		}
+		if err := inline.Decrypt(ctx, cfg, file); err != nil {
+			return fmt.Errorf("failed to load the files of %s: %w", name, err)
+		}
+		vars, err := backend.ListEnvironmentVariables(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		r := compare(keyFilter.ApplyMap(file.ToMap()), keyFilter.ApplyMap(vars))
+		r.Name, r.Files, r.Remote = name, envConfig.Files, cfg.GetParameterPath(name)
+		result.Drifted = result.Drifted || r.Drifted
+		result.Environments = append(result.Environments, r)
+	}
+
+	if format != "text" {
+		if err := structured.Write(os.Stdout, structured.Format(format), result); err != nil {
+			return err
+		}
+	} else if err := writeText(os.Stdout, result); err != nil {
+		return err
+	}
+
+	if exitCode && result.Drifted {
+		os.Exit(exitDrift)
+	}
+	return nil
+}
+
+// environmentNames returns the environments to check, sorted
+func environmentNames(cfg *config.Config) ([]string, error) {
+	var names []string
+	switch {
+	case all:
+		for name := range cfg.Environments {
+			names = append(names, name)
+		}
+	case len(environments) > 0:
+		names = append(names, environments...)
+	default:
+		names = []string{cfg.DefaultEnvironment}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, err := cfg.GetEnvironment(name); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// compare reports the keys whose values differ between local and remote,
+// sorted by key
+func compare(local, remote map[string]string) environmentReport {
+	r := environmentReport{Keys: []driftKey{}}
+	for key, value := range local {
+		remoteValue, ok := remote[key]
+		switch {
+		case !ok:
+			r.Keys = append(r.Keys, driftKey{Key: key, Status: statusLocalOnly})
+			r.Summary.LocalOnly++
+		case value != remoteValue:
+			r.Keys = append(r.Keys, driftKey{Key: key, Status: statusChanged})
+			r.Summary.Changed++
+		default:
+			r.Summary.InSync++
+		}
+	}
+	for key := range remote {
+		if _, ok := local[key]; !ok {
+			r.Keys = append(r.Keys, driftKey{Key: key, Status: statusRemoteOnly})
+			r.Summary.RemoteOnly++
+		}
+	}
+	sort.Slice(r.Keys, func(i, j int) bool { return r.Keys[i].Key < r.Keys[j].Key })
+	r.Drifted = len(r.Keys) > 0
+	return r
+}
+
+func writeText(w io.Writer, result report) error {
+	symbols := map[string]string{
+		statusChanged:    color.FormatWarning("~"),
+		statusLocalOnly:  color.FormatSuccess("+"),
+		statusRemoteOnly: color.FormatError("-"),
+	}
+	for _, r := range result.Environments {
+		if !r.Drifted {
+			if _, err := fmt.Fprintf(w, "%s: in sync (%d variable(s))\n", r.Name, r.Summary.InSync); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s: %d changed, %d local only, %d remote only\n",
+			r.Name, r.Summary.Changed, r.Summary.LocalOnly, r.Summary.RemoteOnly); err != nil {
+			return err
+		}
+		for _, key := range r.Keys {
+			if _, err := fmt.Fprintf(w, "  %s %s (%s)\n", symbols[key.Status], key.Key, key.Status); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package drift
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	r := compare(
+		map[string]string{"PORT": "443", "DEBUG": "false", "NEW_FLAG": "on"},
+		map[string]string{"PORT": "443", "DEBUG": "true", "LEGACY_URL": "http://old"},
+	)
+
+	assert.True(t, r.Drifted)
+	assert.Equal(t, summary{Changed: 1, LocalOnly: 1, RemoteOnly: 1, InSync: 1}, r.Summary)
+	assert.Equal(t, []driftKey{
+		{Key: "DEBUG", Status: statusChanged},
+		{Key: "LEGACY_URL", Status: statusRemoteOnly},
+		{Key: "NEW_FLAG", Status: statusLocalOnly},
+	}, r.Keys)
+
+	inSync := compare(map[string]string{"PORT": "443"}, map[string]string{"PORT": "443"})
+	assert.False(t, inSync.Drifted)
+	assert.Empty(t, inSync.Keys)
+}
+
+func TestReportJSON(t *testing.T) {
+	r := compare(map[string]string{"API_KEY": "new-secret"}, map[string]string{"API_KEY": "old-secret"})
+	r.Name, r.Files, r.Remote = "prod", []string{".env.prod"}, "/myapp/prod/"
+
+	data, err := json.Marshal(report{SchemaVersion: schemaVersion, Drifted: true, Environments: []environmentReport{r}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"schema_version": 1,
+		"drifted": true,
+		"environments": [{
+			"name": "prod",
+			"files": [".env.prod"],
+			"remote": "/myapp/prod/",
+			"drifted": true,
+			"summary": {"changed": 1, "local_only": 0, "remote_only": 0, "in_sync": 0},
+			"keys": [{"key": "API_KEY", "status": "changed"}]
+		}]
+	}`, string(data))
+	assert.NotContains(t, string(data), "secret", "values never appear in the report")
+}
+
+func TestEnvironmentNames(t *testing.T) {
+	defer func() { environments, all = nil, false }()
+	cfg := &config.Config{
+		DefaultEnvironment: "dev",
+		Environments: map[string]config.Environment{
+			"dev":  {Files: []string{".env.dev"}},
+			"prod": {Files: []string{".env.prod"}},
+		},
+	}
+
+	names, err := environmentNames(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev"}, names)
+
+	all = true
+	names, err = environmentNames(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev", "prod"}, names)
+
+	all, environments = false, []string{"staging"}
+	_, err = environmentNames(cfg)
+	assert.Error(t, err)
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	drifted := compare(map[string]string{"PORT": "80"}, map[string]string{"PORT": "443"})
+	drifted.Name = "prod"
+	inSync := compare(map[string]string{"PORT": "443"}, map[string]string{"PORT": "443"})
+	inSync.Name = "staging"
+
+	require.NoError(t, writeText(&buf, report{Environments: []environmentReport{drifted, inSync}}))
+	assert.Contains(t, buf.String(), "prod: 1 changed, 0 local only, 0 remote only")
+	assert.Contains(t, buf.String(), "PORT (changed)")
+	assert.Contains(t, buf.String(), "staging: in sync (1 variable(s))")
+}
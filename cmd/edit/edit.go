@@ -0,0 +1,219 @@
+package edit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/fuzzy"
+	"github.com/drapon/envy/internal/history"
+	"github.com/drapon/envy/internal/precheck"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// defaultEditor is used when neither $VISUAL nor $EDITOR is set
+const defaultEditor = "vi"
+
+var (
+	environment string
+	pick        bool
+	allowEmpty  bool
+)
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:   "edit [KEY]",
+	Short: "Edit the value of one variable in your editor",
+	Long: `Open the value of one variable in $VISUAL or $EDITOR (vi by default) and
+write it back to AWS when it was changed.
+
+The value is put in a temporary file only you can read, which is removed
+afterwards. One trailing newline is removed from the saved value, as with
+'envy set', and the previous value can be restored with 'envy undo'. A key
+that is not in the environment yet starts empty.
+
+With --pick the key is chosen with a built-in fuzzy finder over the keys of
+the environment; KEY, if given, starts the search.`,
+	Example: `  # Edit a multi-line value
+  envy edit TLS_CERT --env prod
+
+  # Find the key by typing part of it
+  envy edit --pick --env staging
+
+  # Use another editor
+  EDITOR="code --wait" envy edit FEATURE_FLAGS`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEdit,
+}
+
+// GetEditCmd returns the edit command.
+func GetEditCmd() *cobra.Command {
+	return editCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(editCmd)
+
+	editCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to write to (default: default_environment)")
+	editCmd.Flags().BoolVarP(&pick, "pick", "p", false, "Choose the key with a fuzzy finder")
+	editCmd.Flags().BoolVar(&allowEmpty, "allow-empty", false, "Accept an empty value")
+}
+
+var validKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func runEdit(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.UsesAzure() {
+		return fmt.Errorf("envy edit is only supported with AWS")
+	}
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+	envConfig, err := cfg.GetEnvironment(environment)
+	if err != nil {
+		return err
+	}
+
+	var key string
+	if len(args) == 1 {
+		key = args[0]
+	} else if !pick {
+		return fmt.Errorf("give a KEY or --pick to choose one")
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+	vars, err := awsManager.ListEnvironmentVariables(ctx, environment)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", environment, err)
+	}
+
+	if pick {
+		if key, err = fuzzy.Pick(environment, key, sortedKeys(vars)); err != nil {
+			return err
+		}
+	}
+	if !validKey.MatchString(key) {
+		return fmt.Errorf("%q is not a valid variable name", key)
+	}
+
+	current, exists := vars[key]
+	value, err := editValue(key, current)
+	if err != nil {
+		return err
+	}
+	if exists && value == current {
+		color.PrintInfof("%s was not changed", key)
+		return nil
+	}
+	if value == "" && !allowEmpty {
+		return fmt.Errorf("the value of %s is empty (use --allow-empty to set it anyway)", key)
+	}
+
+	set := env.NewFile()
+	set.Set(key, value)
+	keys := []string{key}
+	if err := precheck.Placeholders(cfg, environment, envConfig, set, false); err != nil {
+		return err
+	}
+	if err := precheck.Writers(ctx, cfg, awsManager, keys); err != nil {
+		return err
+	}
+	// The rules are checked against the environment as it will be, so
+	// rules between variables see the other values
+	if cfg.ValidateOnPush {
+		result := env.NewFile()
+		for k, v := range vars {
+			result.Set(k, v)
+		}
+		result.Set(key, value)
+		if err := precheck.Validate(ctx, environment, result, nil, true); err != nil {
+			return err
+		}
+	}
+
+	pending, err := history.Begin(ctx, cfg, awsManager, environment, "edit", keys)
+	if err != nil {
+		color.PrintWarningf("This change will not be recorded for envy undo: %v", err)
+	}
+
+	if err := awsManager.ApplyChanges(ctx, environment, set, nil); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := pending.Commit(set.ToMap(), nil); err != nil {
+		color.PrintWarningf("Failed to record the change for envy undo: %v", err)
+	}
+	color.PrintSuccessf("Updated %s in %s", key, environment)
+	return nil
+}
+
+// editValue opens value in the user's editor and returns what was saved,
+// without one trailing newline
+func editValue(key, value string) (string, error) {
+	dir, err := os.MkdirTemp("", "envy-edit-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create a temporary file: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, key)
+	if value != "" {
+		value += "\n"
+	}
+	if err := os.WriteFile(path, []byte(value), 0600); err != nil {
+		return "", fmt.Errorf("failed to create a temporary file: %w", err)
+	}
+
+	fields := strings.Fields(editor())
+	run := exec.Command(fields[0], append(fields[1:], path)...)
+	run.Stdin = os.Stdin
+	run.Stdout = os.Stdout
+	run.Stderr = os.Stderr
+	if err := run.Run(); err != nil {
+		return "", fmt.Errorf("editor %s failed, nothing was written: %w", fields[0], err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the edited value: %w", err)
+	}
+	edited := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(edited, "\r"), nil
+}
+
+// editor returns the command of the user's editor
+func editor() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if command := strings.TrimSpace(os.Getenv(name)); command != "" {
+			return command
+		}
+	}
+	return defaultEditor
+}
+
+func sortedKeys(vars map[string]string) []string {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
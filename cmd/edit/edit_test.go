@@ -0,0 +1,58 @@
+package edit
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditFlags(t *testing.T) {
+	cmd := GetEditCmd()
+	assert.Equal(t, "edit [KEY]", cmd.Use)
+	for _, name := range []string{"env", "pick", "allow-empty"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestEditor(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	assert.Equal(t, "vi", editor())
+
+	t.Setenv("EDITOR", "nano")
+	assert.Equal(t, "nano", editor())
+
+	t.Setenv("VISUAL", "code --wait")
+	assert.Equal(t, "code --wait", editor())
+}
+
+func TestEditValue(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the editor")
+	}
+
+	// The editor appends a line and records where the file was
+	dir := t.TempDir()
+	script := filepath.Join(dir, "editor.sh")
+	seen := filepath.Join(dir, "seen")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho \"$1\" > "+seen+"\necho line2 >> \"$1\"\n"), 0700))
+	t.Setenv("VISUAL", script)
+
+	value, err := editValue("TLS_CERT", "line1")
+	require.NoError(t, err)
+	assert.Equal(t, "line1\nline2", value)
+
+	path, err := os.ReadFile(seen)
+	require.NoError(t, err)
+	assert.Contains(t, string(path), "TLS_CERT")
+	_, err = os.Stat(string(path[:len(path)-1]))
+	assert.True(t, os.IsNotExist(err), "the temporary file is removed")
+
+	t.Setenv("VISUAL", "false")
+	_, err = editValue("TLS_CERT", "line1")
+	assert.ErrorContains(t, err, "nothing was written")
+}
@@ -0,0 +1,77 @@
+package encrypt
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/envelope"
+)
+
+var (
+	decryptKeyFile string
+	decryptOutput  string
+	decryptForce   bool
+)
+
+// decryptCmd represents the decrypt command
+var decryptCmd = &cobra.Command{
+	Use:   "decrypt <file>",
+	Short: "Decrypt a file written by envy encrypt",
+	Long: `Decrypt a file written by envy encrypt. The file names its key: a KMS key
+is used with the AWS settings of .envyrc, age identities are read from
+inline_encryption.age_identity_file or ENVY_AGE_IDENTITY, and a local key is
+read from --key-file, inline_encryption.key_file or ENVY_INLINE_KEY.
+
+Use - to read stdin. The plaintext is written to stdout unless --output
+is given.`,
+	Example: `  # Print a secret
+  envy decrypt db-password.enc
+
+  # Restore a certificate
+  envy decrypt tls.key.enc -o tls.key`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDecrypt,
+}
+
+// GetDecryptCmd returns the decrypt command.
+func GetDecryptCmd() *cobra.Command {
+	return decryptCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(decryptCmd)
+
+	decryptCmd.Flags().StringVar(&decryptKeyFile, "key-file", "", "Local key file, for files encrypted with a local key")
+	decryptCmd.Flags().StringVarP(&decryptOutput, "output", "o", "-", "Output file, - for stdout")
+	decryptCmd.Flags().BoolVar(&decryptForce, "force", false, "Overwrite the output file")
+}
+
+func runDecrypt(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	cfg := loadConfig()
+
+	data, err := readInput(args[0])
+	if err != nil {
+		return err
+	}
+	doc, err := envelope.ParseDocument(data)
+	if err != nil {
+		return err
+	}
+	plaintext, err := doc.Decrypt(ctx, newKeyring(ctx, cfg, decryptKeyFile))
+	if err != nil {
+		return err
+	}
+
+	if err := writeOutput(decryptOutput, plaintext, decryptForce); err != nil {
+		return err
+	}
+	if decryptOutput != "-" {
+		color.PrintSuccessf("Decrypted %s to %s", args[0], decryptOutput)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package encrypt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/envelope"
+	"github.com/drapon/envy/internal/inline"
+)
+
+var (
+	kmsKeyID string
+	keyFile  string
+	output   string
+	force    bool
+)
+
+// encryptCmd represents the encrypt command
+var encryptCmd = &cobra.Command{
+	Use:   "encrypt <file>",
+	Short: "Encrypt a file with KMS or the local key, for ad-hoc secrets",
+	Long: `Encrypt a file, such as a certificate or a credentials export, so it can be
+committed or handed over. It is encrypted under its own data key, which is
+encrypted with a KMS key, for age recipients or with the local key, like the
+ENC[...] values of envy seal; only the holders of that key can decrypt it
+with envy decrypt.
+
+The key is --kms, else inline_encryption.kms_key_id of .envyrc, else the
+recipients of inline_encryption.age_recipients, else the local key of
+--key-file, inline_encryption.key_file or ENVY_INLINE_KEY.
+Use - to read stdin; the output is <file>.enc unless --output is given
+(- for stdout).`,
+	Example: `  # Encrypt a certificate with a KMS key
+  envy encrypt --kms alias/app tls.key
+
+  # With the configured key, from stdin
+  pg_dump app | envy encrypt - -o dump.sql.enc
+
+  # Decrypt it again
+  envy decrypt tls.key.enc -o tls.key`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEncrypt,
+}
+
+// GetEncryptCmd returns the encrypt command.
+func GetEncryptCmd() *cobra.Command {
+	return encryptCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(encryptCmd)
+
+	encryptCmd.Flags().StringVar(&kmsKeyID, "kms", "", "KMS key ID, ARN or alias (default: inline_encryption.kms_key_id)")
+	encryptCmd.Flags().StringVar(&keyFile, "key-file", "", "Local key file, as made by envy seal --generate-key")
+	encryptCmd.Flags().StringVarP(&output, "output", "o", "", "Output file, - for stdout (default: <file>.enc)")
+	encryptCmd.Flags().BoolVar(&force, "force", false, "Overwrite the output file")
+}
+
+func runEncrypt(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	if kmsKeyID != "" && keyFile != "" {
+		return fmt.Errorf("--kms and --key-file cannot be used together")
+	}
+
+	cfg := loadConfig()
+	keyring := newKeyring(ctx, cfg, keyFile)
+	wrapper, err := selectWrapper(keyring, cfg, kmsKeyID, keyFile)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := readInput(args[0])
+	if err != nil {
+		return err
+	}
+	doc, err := envelope.Encrypt(ctx, wrapper, plaintext)
+	if err != nil {
+		return err
+	}
+	data, err := doc.Marshal()
+	if err != nil {
+		return err
+	}
+
+	target := output
+	if target == "" {
+		if args[0] == "-" {
+			target = "-"
+		} else {
+			target = args[0] + ".enc"
+		}
+	}
+	if err := writeOutput(target, data, force); err != nil {
+		return err
+	}
+	if target != "-" {
+		color.PrintSuccessf("Encrypted %s to %s with %s", args[0], target, doc.Key)
+	}
+	return nil
+}
+
+// loadConfig returns the configuration for the inline_encryption keys and
+// the AWS settings; files may be encrypted and decrypted without an .envyrc
+func loadConfig() *config.Config {
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return nil
+	}
+	return cfg
+}
+
+// newKeyring returns the keys of the inline_encryption settings, with the
+// local key of path instead when it is given
+func newKeyring(ctx context.Context, cfg *config.Config, path string) *envelope.Keyring {
+	keyring := inline.Keyring(ctx, cfg)
+	if path != "" {
+		keyring.LocalKey = func() ([]byte, error) { return envelope.LoadKeyFile(path) }
+	}
+	return keyring
+}
+
+// selectWrapper returns the key to encrypt with: the KMS key of --kms or
+// the configuration, else the configured age recipients, else the local key
+func selectWrapper(keyring *envelope.Keyring, cfg *config.Config, kmsKeyID, keyFile string) (envelope.Wrapper, error) {
+	var ageRecipients []string
+	if kmsKeyID == "" && keyFile == "" && cfg != nil {
+		kmsKeyID = cfg.InlineEncryption.KMSKeyID
+		ageRecipients = cfg.InlineEncryption.AgeRecipients
+	}
+	if kmsKeyID != "" {
+		return keyring.KMS(kmsKeyID)
+	}
+	if len(ageRecipients) > 0 {
+		wrapper, err := envelope.NewAgeWrapper(ageRecipients)
+		if err != nil {
+			return nil, fmt.Errorf("inline_encryption.age_recipients: %w", err)
+		}
+		return wrapper, nil
+	}
+	local, err := keyring.Local()
+	if errors.Is(err, envelope.ErrNoLocalKey) {
+		return nil, fmt.Errorf("no key to encrypt with: pass --kms or --key-file, or set inline_encryption in .envyrc")
+	}
+	return local, err
+}
+
+// readInput reads a file, or stdin for -
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return data, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// writeOutput writes data to path, readable only by the user, or to
+// stdout for -. Existing files are only replaced with force.
+func writeOutput(path string, data []byte, force bool) error {
+	if path == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	file, err := os.OpenFile(path, flags, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s exists; pass --force to overwrite it", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
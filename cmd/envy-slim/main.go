@@ -0,0 +1,21 @@
+// Command envy-slim is a reduced envy binary for init containers and other
+// size-sensitive images. It only registers the pull/run/export core and is
+// meant to be built with -tags slim, which swaps the color, prompt and
+// progress bar packages for dependency-free implementations:
+//
+//	CGO_ENABLED=0 go build -tags slim -trimpath -o envy-slim ./cmd/envy-slim
+package main
+
+import (
+	"github.com/drapon/envy/cmd/root"
+
+	// Import only the commands needed at container start-up
+	_ "github.com/drapon/envy/cmd/export"
+	_ "github.com/drapon/envy/cmd/pull"
+	_ "github.com/drapon/envy/cmd/run"
+	_ "github.com/drapon/envy/cmd/version"
+)
+
+func main() {
+	root.Execute()
+}
@@ -4,17 +4,57 @@ import (
 	"github.com/drapon/envy/cmd/root"
 
 	// Import all commands to register them
+	_ "github.com/drapon/envy/cmd/agent"
+	_ "github.com/drapon/envy/cmd/apply"
+	_ "github.com/drapon/envy/cmd/assert"
+	_ "github.com/drapon/envy/cmd/bundle"
 	_ "github.com/drapon/envy/cmd/cache"
+	_ "github.com/drapon/envy/cmd/changelog"
+	_ "github.com/drapon/envy/cmd/checkruntime"
+	_ "github.com/drapon/envy/cmd/ci"
+	_ "github.com/drapon/envy/cmd/compliance"
+	_ "github.com/drapon/envy/cmd/config"
 	_ "github.com/drapon/envy/cmd/configure"
+	_ "github.com/drapon/envy/cmd/cost"
 	_ "github.com/drapon/envy/cmd/diff"
+	_ "github.com/drapon/envy/cmd/drift"
+	_ "github.com/drapon/envy/cmd/edit"
+	_ "github.com/drapon/envy/cmd/encrypt"
 	_ "github.com/drapon/envy/cmd/export"
+	_ "github.com/drapon/envy/cmd/fixtures"
+	_ "github.com/drapon/envy/cmd/get"
+	_ "github.com/drapon/envy/cmd/gitops"
+	_ "github.com/drapon/envy/cmd/history"
+	_ "github.com/drapon/envy/cmd/import"
 	_ "github.com/drapon/envy/cmd/init"
 	_ "github.com/drapon/envy/cmd/list"
+	_ "github.com/drapon/envy/cmd/login"
+	_ "github.com/drapon/envy/cmd/migratevars"
+	_ "github.com/drapon/envy/cmd/mv"
 	_ "github.com/drapon/envy/cmd/pull"
 	_ "github.com/drapon/envy/cmd/push"
+	_ "github.com/drapon/envy/cmd/quota"
+	_ "github.com/drapon/envy/cmd/reclassify"
+	_ "github.com/drapon/envy/cmd/rename"
+	_ "github.com/drapon/envy/cmd/renameproject"
+	_ "github.com/drapon/envy/cmd/replicate"
+	_ "github.com/drapon/envy/cmd/restructure"
+	_ "github.com/drapon/envy/cmd/role"
+	_ "github.com/drapon/envy/cmd/rollback"
+	_ "github.com/drapon/envy/cmd/rotate"
 	_ "github.com/drapon/envy/cmd/run"
+	_ "github.com/drapon/envy/cmd/scan"
+	_ "github.com/drapon/envy/cmd/scrub"
+	_ "github.com/drapon/envy/cmd/seal"
+	_ "github.com/drapon/envy/cmd/set"
+	_ "github.com/drapon/envy/cmd/share"
+	_ "github.com/drapon/envy/cmd/signartifact"
+	_ "github.com/drapon/envy/cmd/tour"
+	_ "github.com/drapon/envy/cmd/undo"
 	_ "github.com/drapon/envy/cmd/validate"
+	_ "github.com/drapon/envy/cmd/verifyartifact"
 	_ "github.com/drapon/envy/cmd/version"
+	_ "github.com/drapon/envy/cmd/watch"
 )
 
 func main() {
@@ -0,0 +1,146 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/aws/events"
+	parameter_store "github.com/drapon/envy/internal/aws/parameter_store"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+)
+
+// ecsVariable is an entry of the environment of an ECS container definition
+type ecsVariable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ecsSecret is an entry of the secrets of an ECS container definition
+type ecsSecret struct {
+	Name      string `json:"name"`
+	ValueFrom string `json:"valueFrom"`
+}
+
+// ecsReferences builds the ARNs ECS reads sensitive variables from when it
+// starts a task
+type ecsReferences struct {
+	partition string
+	region    string
+	account   string
+	path      string
+	// secretsManager is set for environments stored as one JSON secret
+	secretsManager bool
+	// perVariable is set when each variable is its own secret
+	perVariable bool
+	// largeValues is set when values over 4 KB are kept in Secrets Manager
+	largeValues bool
+}
+
+// newECSReferences returns the references to the variables of envName in
+// account
+func newECSReferences(cfg *config.Config, envName, account string) (*ecsReferences, error) {
+	envConfig, err := cfg.GetEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AWS.Region == "" {
+		return nil, fmt.Errorf("aws.region must be set for the ecs format")
+	}
+
+	path := cfg.GetParameterPath(envName)
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	return &ecsReferences{
+		partition:      events.Partition(cfg.AWS.Region),
+		region:         cfg.AWS.Region,
+		account:        account,
+		path:           path,
+		secretsManager: cfg.GetAWSService(envName) == "secrets_manager" || envConfig.UseSecretsManager,
+		perVariable:    cfg.SecretPerVariable(),
+		largeValues:    cfg.AWS.LargeValuesToSecretsManager,
+	}, nil
+}
+
+// valueFrom returns the ARN of the parameter or secret holding key, the key
+// of the environment's secret, or the secret a large value was moved to
+func (r *ecsReferences) valueFrom(key, value string) string {
+	if r.secretsManager && r.perVariable {
+		return r.arn("secretsmanager", "secret:"+aws.VariableSecretPrefix(r.path)+key)
+	}
+	if r.secretsManager {
+		return fmt.Sprintf("%s:%s::", r.arn("secretsmanager", "secret:"+aws.SecretName(r.path)), key)
+	}
+	name := r.path + key
+	if r.largeValues && len(value) > parameter_store.MaxValueSize {
+		return r.arn("secretsmanager", "secret:"+parameter_store.LargeValueSecretName(name))
+	}
+	return r.arn("ssm", "parameter"+name)
+}
+
+func (r *ecsReferences) arn(service, resource string) string {
+	return fmt.Sprintf("arn:%s:%s:%s:%s:%s", r.partition, service, r.region, r.account, resource)
+}
+
+// ecsTarget returns the references to the sensitive variables of envFile.
+// The account is only looked up when there are any, so a plain environment
+// exports without AWS credentials.
+func ecsTarget(ctx context.Context, cfg *config.Config, envName string, envFile *env.File, isSensitive func(string) bool) (*ecsReferences, error) {
+	if cfg.UsesAzure() {
+		return nil, fmt.Errorf("the ecs format is only supported with AWS")
+	}
+
+	account := accountID
+	if account == "" && hasSensitive(envFile, isSensitive) {
+		awsManager, err := aws.NewManager(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AWS manager: %w", err)
+		}
+		principal, err := awsManager.CallerARN(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine AWS account, set it with --account-id: %w", err)
+		}
+		if account, err = events.AccountID(principal); err != nil {
+			return nil, err
+		}
+	}
+	return newECSReferences(cfg, envName, account)
+}
+
+func hasSensitive(envFile *env.File, isSensitive func(string) bool) bool {
+	for _, key := range envFile.Keys() {
+		if isSensitive(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// exportECS writes the environment and secrets of an ECS container
+// definition. Sensitive variables are referenced by ARN rather than written
+// out, so the task definition never holds their values.
+func exportECS(w io.Writer, envFile *env.File, isSensitive func(string) bool, refs *ecsReferences) error {
+	container := struct {
+		Environment []ecsVariable `json:"environment"`
+		Secrets     []ecsSecret   `json:"secrets"`
+	}{Environment: []ecsVariable{}, Secrets: []ecsSecret{}}
+
+	for _, key := range envFile.SortedKeys() {
+		value, _ := envFile.Get(key)
+		if isSensitive(key) {
+			container.Secrets = append(container.Secrets, ecsSecret{Name: key, ValueFrom: refs.valueFrom(key, value)})
+		} else {
+			container.Environment = append(container.Environment, ecsVariable{Name: key, Value: value})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(container)
+}
@@ -0,0 +1,96 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/sensitive"
+)
+
+func ecsConfig() *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Project = "myapp"
+	cfg.AWS.Region = "eu-west-1"
+	cfg.Environments = map[string]config.Environment{
+		"prod": {Files: []string{".env.prod"}},
+	}
+	return cfg
+}
+
+func TestExportECS(t *testing.T) {
+	refs, err := newECSReferences(ecsConfig(), "prod", "123456789012")
+	require.NoError(t, err)
+
+	envFile := newEnvFile(map[string]string{
+		"APP_URL":     "https://app.example.org/?a=1&b=2",
+		"DB_PASSWORD": "hunter2",
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, exportECS(&buf, envFile, sensitive.IsSensitive, refs))
+	assert.Equal(t, `{
+  "environment": [
+    {
+      "name": "APP_URL",
+      "value": "https://app.example.org/?a=1&b=2"
+    }
+  ],
+  "secrets": [
+    {
+      "name": "DB_PASSWORD",
+      "valueFrom": "arn:aws:ssm:eu-west-1:123456789012:parameter/myapp/prod/DB_PASSWORD"
+    }
+  ]
+}
+`, buf.String())
+	assert.NotContains(t, buf.String(), "hunter2")
+}
+
+func TestExportECS_EmptyLists(t *testing.T) {
+	refs, err := newECSReferences(ecsConfig(), "prod", "")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, exportECS(&buf, newEnvFile(map[string]string{"PORT": "8080"}), sensitive.IsSensitive, refs))
+	assert.Contains(t, buf.String(), `"secrets": []`)
+}
+
+func TestECSReferences(t *testing.T) {
+	cfg := ecsConfig()
+	cfg.AWS.Region = "cn-north-1"
+	cfg.AWS.LargeValuesToSecretsManager = true
+	cfg.Environments["prod"] = config.Environment{Path: "/custom/prod"}
+
+	refs, err := newECSReferences(cfg, "prod", "123456789012")
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws-cn:ssm:cn-north-1:123456789012:parameter/custom/prod/API_KEY", refs.valueFrom("API_KEY", "x"))
+	assert.Equal(t, "arn:aws-cn:secretsmanager:cn-north-1:123456789012:secret:envy/large-values/custom/prod/CERT",
+		refs.valueFrom("CERT", strings.Repeat("x", 5000)))
+
+	cfg.Environments["prod"] = config.Environment{Path: "/custom/prod", UseSecretsManager: true}
+	refs, err = newECSReferences(cfg, "prod", "123456789012")
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws-cn:secretsmanager:cn-north-1:123456789012:secret:custom-prod:API_KEY::", refs.valueFrom("API_KEY", "x"))
+
+	cfg.SecretsManager.Mode = config.SecretsManagerPerVariable
+	refs, err = newECSReferences(cfg, "prod", "123456789012")
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws-cn:secretsmanager:cn-north-1:123456789012:secret:custom/prod/API_KEY", refs.valueFrom("API_KEY", "x"))
+}
+
+func TestECSTarget_SkipsAccountWithoutSecrets(t *testing.T) {
+	refs, err := ecsTarget(context.Background(), ecsConfig(), "prod", newEnvFile(map[string]string{"PORT": "8080"}), sensitive.IsSensitive)
+	require.NoError(t, err)
+	assert.Empty(t, refs.account)
+
+	cfg := ecsConfig()
+	cfg.AWS.Region = ""
+	_, err = newECSReferences(cfg, "prod", "123456789012")
+	assert.Error(t, err)
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"regexp"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -15,9 +14,14 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/artifact"
 	"github.com/drapon/envy/internal/aws"
 	"github.com/drapon/envy/internal/config"
 	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/inline"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/drapon/envy/internal/validator"
 )
 
 var (
@@ -27,10 +31,15 @@ var (
 	name        string
 	namespace   string
 	source      string
-	include     string
-	exclude     string
+	filterOpts  filter.Options
 	maskSecrets bool
 	sort        bool
+	snakeCase   bool
+	delimiter   string
+	accountID   string
+	apply       bool
+	function    string
+	signKey     string
 )
 
 // exportCmd represents the export command
@@ -41,7 +50,40 @@ var exportCmd = &cobra.Command{
 other tools and systems.
 
 This command can export variables as shell scripts, Docker env files,
-Kubernetes ConfigMaps, or other supported formats.`,
+Kubernetes ConfigMaps, or other supported formats.
+
+The ts-module and js-module formats generate a typed config module for
+frontend builds. Only client-exposed variables (NEXT_PUBLIC_, VITE_,
+REACT_APP_ or the client_exposure prefixes of .envy-rules.yaml) are
+included, and the export fails if any of them is classified as sensitive or
+holds a secret, so a build step running it stops before a secret reaches the
+bundle.
+
+The terraform and terraform-json formats write terraform.tfvars and
+terraform.tfvars.json files. Values are escaped so that they stay literal,
+including ${...} sequences, and --snake-case turns keys such as DATABASE_URL
+into Terraform-style variable names (database_url).
+
+The helm format writes a values file for 'helm --values', nesting keys split
+on --nesting-delimiter (default "__"): INGRESS__TLS__ENABLED=true becomes
+INGRESS: {TLS: {ENABLED: "true"}}. Keys keep their case and values stay
+strings.
+
+The ecs format writes the environment and secrets of an ECS container
+definition. Sensitive variables go in secrets, referenced by the ARN of their
+parameter (or of the environment's secret for Secrets Manager environments)
+so the task definition never holds their values. ARNs use aws.region and the
+account of the current credentials unless --account-id is given; the task
+execution role needs read access to them.
+
+The lambda format writes the Variables map for 'aws lambda
+update-function-configuration --environment'. With --apply --function-name
+the function's environment is replaced directly instead, reporting how many
+variables were added, changed and removed.
+
+With --sign, the output file is signed and the signature written next to it
+as <output>.sig, for 'envy verify-artifact' (see 'envy sign-artifact' for the
+key references).`,
 	Example: `  # Export as shell script
   envy export --env production --format shell
   
@@ -59,12 +101,34 @@ Kubernetes ConfigMaps, or other supported formats.`,
   
   # Export as JSON
   envy export --env production --format json --output config.json
+
+  # Generate a typed config module for the frontend build
+  envy export --env production --format ts-module --output src/config.ts
+
+  # Write Terraform variables with snake_case names
+  envy export --env production --format terraform --snake-case --output terraform.tfvars
+
+  # Write Helm values, nesting APP__DB__HOST under APP.DB.HOST
+  envy export --env production --format helm --no-secrets --output values.yaml
+
+  # Write the environment and secrets blocks of an ECS container definition
+  envy export --env production --format ecs --output container-env.json
+
+  # Replace the environment of a Lambda function
+  envy export --env production --format lambda --no-secrets --apply --function-name my-function
+
+  # Export non-secret config for editing in a spreadsheet
+  envy export --env staging --format csv --no-secrets --output staging.csv
   
   # Export specific variables only
   envy export --env production --filter "API_*"
   
   # Export excluding certain variables
-  envy export --env production --exclude "SECRET_*"`,
+  envy export --env production --exclude "SECRET_*"
+
+  # Split into a ConfigMap (plain values) and a Secret (sensitive values)
+  envy export --env production --format k8s-configmap --name myapp-config --no-secrets
+  envy export --env production --format k8s-secret --name myapp-secret --only-secrets`,
 	RunE: runExport,
 }
 
@@ -78,15 +142,21 @@ func init() {
 
 	// Add flags specific to export command
 	exportCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to export")
-	exportCmd.Flags().StringVarP(&format, "format", "f", "shell", "Export format (shell/docker/k8s-configmap/k8s-secret/github-actions/json/yaml)")
+	exportCmd.Flags().StringVarP(&format, "format", "f", "shell", "Export format (shell/docker/k8s-configmap/k8s-secret/github-actions/json/yaml/csv/ts-module/js-module/terraform/terraform-json/helm/ecs/lambda)")
 	exportCmd.Flags().StringVarP(&output, "output", "o", "", "Output file (stdout if not specified)")
 	exportCmd.Flags().StringVarP(&name, "name", "n", "", "Resource name (for k8s exports)")
 	exportCmd.Flags().String("namespace", "default", "Kubernetes namespace")
 	exportCmd.Flags().StringVarP(&source, "source", "s", "local", "Source (local/aws)")
-	exportCmd.Flags().StringVarP(&include, "include", "i", "", "Filter pattern for variables to export")
-	exportCmd.Flags().StringVarP(&exclude, "exclude", "x", "", "Pattern for variables to exclude")
+	filter.AddRegexFlags(exportCmd.Flags(), &filterOpts, "i", "x")
+	filter.AddSensitivityFlags(exportCmd.Flags(), &filterOpts)
 	exportCmd.Flags().BoolVar(&maskSecrets, "mask-secrets", false, "Mask sensitive values in output")
 	exportCmd.Flags().BoolVar(&sort, "sort", false, "Sort variables alphabetically")
+	exportCmd.Flags().BoolVar(&snakeCase, "snake-case", false, "Convert keys to snake_case variable names (terraform formats)")
+	exportCmd.Flags().StringVar(&delimiter, "nesting-delimiter", "__", "Delimiter splitting keys into nested maps (helm format)")
+	exportCmd.Flags().BoolVar(&apply, "apply", false, "Set the variables on the Lambda function instead of writing them (lambda format)")
+	exportCmd.Flags().StringVar(&function, "function-name", "", "Name or ARN of the Lambda function for --apply")
+	exportCmd.Flags().StringVar(&signKey, "sign", "", "Sign the output file with this key (kms:<key>, cosign:<ref> or an Ed25519 key file)")
+	exportCmd.Flags().StringVar(&accountID, "account-id", "", "AWS account of the secret ARNs (ecs format, default: account of the current credentials)")
 
 	// Bind namespace flag to viper
 	viper.BindPFlag("export.namespace", exportCmd.Flags().Lookup("namespace"))
@@ -117,24 +187,80 @@ func runExport(cmd *cobra.Command, args []string) error {
 		}
 	} else {
 		// Load from local files
-		envFile, err = loadLocalFiles(cfg, environment)
+		envFile, err = loadLocalFiles(ctx, cfg, environment)
 		if err != nil {
 			return fmt.Errorf("failed to load local files: %w", err)
 		}
 	}
 
 	// Apply filters
-	envFile = applyFilters(envFile, include, exclude)
+	classifier, err := sensitive.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	envFile, err = applyFilters(envFile, filterOpts, classifier)
+	if err != nil {
+		return err
+	}
 
 	// Validate required parameters for specific formats
 	if (format == "k8s-configmap" || format == "k8s-secret") && name == "" {
 		return fmt.Errorf("--name is required for %s format", format)
 	}
+	if snakeCase && !isTerraformFormat(format) {
+		return fmt.Errorf("--snake-case is only supported with the terraform formats")
+	}
+	if cmd.Flags().Changed("nesting-delimiter") && format != "helm" {
+		return fmt.Errorf("--nesting-delimiter is only supported with the helm format")
+	}
+	if signKey != "" && output == "" {
+		return fmt.Errorf("--sign requires --output")
+	}
+	if accountID != "" && format != "ecs" {
+		return fmt.Errorf("--account-id is only supported with the ecs format")
+	}
+	if apply || function != "" {
+		if format != "lambda" {
+			return fmt.Errorf("--apply is only supported with the lambda format")
+		}
+		if !apply || function == "" {
+			return fmt.Errorf("--apply and --function-name must be used together")
+		}
+		if output != "" {
+			return fmt.Errorf("--apply cannot be used with --output")
+		}
+		if cfg.UsesAzure() {
+			return fmt.Errorf("--apply is only supported with AWS")
+		}
+		return applyLambda(ctx, cfg, envFile, function, classifier)
+	}
+
+	// ECS reads sensitive variables from AWS, so their ARNs are resolved
+	// before the output file is created
+	var refs *ecsReferences
+	if format == "ecs" {
+		if refs, err = ecsTarget(ctx, cfg, environment, envFile, classifier); err != nil {
+			return err
+		}
+	}
+
+	// Config modules are bundled into browser code, so only client-safe
+	// variables go in; they are checked before the output file is created
+	var rules *validator.Rules
+	if isModuleFormat(format) {
+		if rules, err = loadModuleRules(environment); err != nil {
+			return err
+		}
+		if envFile, err = clientSafe(envFile, rules, classifier); err != nil {
+			return err
+		}
+	}
 
 	// Export in the requested format
 	var writer io.Writer = os.Stdout
+	var file *os.File
 	if output != "" {
-		file, err := os.Create(output)
+		file, err = os.Create(output)
 		if err != nil {
 			return fmt.Errorf("failed to create output file: %w", err)
 		}
@@ -157,6 +283,22 @@ func runExport(cmd *cobra.Command, args []string) error {
 		err = exportJSON(writer, envFile)
 	case "yaml":
 		err = exportYAML(writer, envFile)
+	case "csv":
+		err = env.WriteCSV(writer, envFile, classifier)
+	case "ts-module":
+		err = exportModule(writer, envFile, rules, true)
+	case "js-module":
+		err = exportModule(writer, envFile, rules, false)
+	case "terraform":
+		err = exportTerraform(writer, envFile, snakeCase)
+	case "terraform-json":
+		err = exportTerraformJSON(writer, envFile, snakeCase)
+	case "helm":
+		err = exportHelm(writer, envFile, delimiter)
+	case "ecs":
+		err = exportECS(writer, envFile, classifier, refs)
+	case "lambda":
+		err = exportLambda(writer, envFile)
 	default:
 		return fmt.Errorf("unsupported format: %s", format)
 	}
@@ -169,6 +311,17 @@ func runExport(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Successfully exported to %s\n", output)
 	}
 
+	if signKey != "" {
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		sigPath, err := artifact.SignFile(ctx, output, signKey, cfg)
+		if err != nil {
+			return fmt.Errorf("failed to sign %s: %w", output, err)
+		}
+		fmt.Printf("Signature written to %s\n", sigPath)
+	}
+
 	return nil
 }
 
@@ -181,7 +334,7 @@ func pullFromAWS(ctx context.Context, cfg *config.Config, envName string) (*env.
 	return awsManager.PullEnvironment(ctx, envName)
 }
 
-func loadLocalFiles(cfg *config.Config, envName string) (*env.File, error) {
+func loadLocalFiles(ctx context.Context, cfg *config.Config, envName string) (*env.File, error) {
 	envConfig, err := cfg.GetEnvironment(envName)
 	if err != nil {
 		return nil, err
@@ -192,43 +345,23 @@ func loadLocalFiles(cfg *config.Config, envName string) (*env.File, error) {
 	}
 
 	manager := env.NewManager(".")
-	return manager.LoadFiles(envConfig.Files)
-}
-
-func applyFilters(envFile *env.File, filterPattern, excludePattern string) *env.File {
-	if filterPattern == "" && excludePattern == "" {
-		return envFile
+	envFile, err := manager.LoadFiles(envConfig.Files)
+	if err != nil {
+		return nil, err
 	}
+	if err := inline.Decrypt(ctx, cfg, envFile); err != nil {
+		return nil, err
+	}
+	return envFile, nil
+}
 
-	result := env.NewFile()
-
-	for _, key := range envFile.Keys() {
-		include := true
-
-		// Apply filter pattern
-		if filterPattern != "" {
-			matched, err := regexp.MatchString(filterPattern, key)
-			if err != nil || !matched {
-				include = false
-			}
-		}
-
-		// Apply exclude pattern
-		if excludePattern != "" && include {
-			matched, err := regexp.MatchString(excludePattern, key)
-			if err == nil && matched {
-				include = false
-			}
-		}
-
-		if include {
-			if value, ok := envFile.Get(key); ok {
-				result.Set(key, value)
-			}
-		}
+func applyFilters(envFile *env.File, opts filter.Options, classifier func(string) bool) (*env.File, error) {
+	keyFilter, err := filter.New(opts)
+	if err != nil {
+		return nil, err
 	}
 
-	return result
+	return keyFilter.WithClassifier(classifier).ApplyFile(envFile), nil
 }
 
 func exportShell(w io.Writer, envFile *env.File) error {
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/filter"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -336,7 +337,11 @@ func TestApplyFilters(t *testing.T) {
 				envFile.Set(k, v)
 			}
 
-			result := applyFilters(envFile, tt.filter, tt.exclude)
+			result, err := applyFilters(envFile, filter.Options{
+				Include: []string{tt.filter},
+				Exclude: []string{tt.exclude},
+			}, nil)
+			assert.NoError(t, err)
 			
 			// Convert result to map for comparison
 			resultMap := result.ToMap()
@@ -0,0 +1,64 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/drapon/envy/internal/env"
+)
+
+// helmValues nests the variables of envFile by splitting their keys on
+// delimiter, so FOO__BAR__BAZ=x becomes {FOO: {BAR: {BAZ: x}}}
+func helmValues(envFile *env.File, delimiter string) (map[string]interface{}, error) {
+	if delimiter == "" {
+		return nil, fmt.Errorf("the nesting delimiter cannot be empty")
+	}
+
+	values := make(map[string]interface{})
+	for _, key := range envFile.SortedKeys() {
+		path := strings.Split(key, delimiter)
+		for _, part := range path {
+			if part == "" {
+				return nil, fmt.Errorf("%s has an empty segment when split on %q", key, delimiter)
+			}
+		}
+
+		node := values
+		for i, part := range path[:len(path)-1] {
+			switch child := node[part].(type) {
+			case nil:
+				next := make(map[string]interface{})
+				node[part] = next
+				node = next
+			case map[string]interface{}:
+				node = child
+			default:
+				return nil, fmt.Errorf("%s conflicts with %s, which sets a value instead of a map", key, strings.Join(path[:i+1], delimiter))
+			}
+		}
+
+		last := path[len(path)-1]
+		if _, exists := node[last]; exists {
+			return nil, fmt.Errorf("%s conflicts with variables nested under it", key)
+		}
+		value, _ := envFile.Get(key)
+		node[last] = value
+	}
+	return values, nil
+}
+
+// exportHelm writes the variables as a values file for helm --values
+func exportHelm(w io.Writer, envFile *env.File, delimiter string) error {
+	values, err := helmValues(envFile, delimiter)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "# Generated by envy")
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	return encoder.Encode(values)
+}
@@ -0,0 +1,47 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportHelm(t *testing.T) {
+	envFile := newEnvFile(map[string]string{
+		"INGRESS__TLS__ENABLED": "true",
+		"INGRESS__HOST":         "app.example.org",
+		"REPLICAS":              "3",
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, exportHelm(&buf, envFile, "__"))
+	assert.Equal(t, `# Generated by envy
+INGRESS:
+  HOST: app.example.org
+  TLS:
+    ENABLED: "true"
+REPLICAS: "3"
+`, buf.String())
+}
+
+func TestHelmValues_Delimiter(t *testing.T) {
+	values, err := helmValues(newEnvFile(map[string]string{"db.host": "localhost"}), ".")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"db": map[string]interface{}{"host": "localhost"}}, values)
+
+	_, err = helmValues(newEnvFile(map[string]string{"A": "1"}), "")
+	assert.Error(t, err)
+}
+
+func TestHelmValues_Conflicts(t *testing.T) {
+	_, err := helmValues(newEnvFile(map[string]string{"DB": "x", "DB__HOST": "y"}), "__")
+	assert.ErrorContains(t, err, "DB__HOST conflicts with DB")
+
+	_, err = helmValues(newEnvFile(map[string]string{"DB__HOST__PORT": "x", "DB__HOST": "y"}), "__")
+	assert.ErrorContains(t, err, "DB__HOST")
+
+	_, err = helmValues(newEnvFile(map[string]string{"DB____HOST": "x"}), "__")
+	assert.ErrorContains(t, err, "empty segment")
+}
@@ -0,0 +1,90 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/aws/lambda"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+)
+
+// exportLambda writes the environment of a Lambda function configuration,
+// for 'aws lambda update-function-configuration --environment file://...'
+func exportLambda(w io.Writer, envFile *env.File) error {
+	vars := envFile.ToMap()
+	if err := lambda.Validate(vars); err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(map[string]interface{}{"Variables": vars})
+}
+
+// applyLambda replaces the environment variables of a Lambda function with
+// envFile. The configuration is read first, both to report the changes and
+// so the update fails if someone else changes it in between.
+func applyLambda(ctx context.Context, cfg *config.Config, envFile *env.File, function string, isSensitive func(string) bool) error {
+	vars := envFile.ToMap()
+	if err := lambda.Validate(vars); err != nil {
+		return err
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+	client := lambda.NewClient(awsManager.GetClient())
+
+	current, err := client.GetEnvironment(ctx, function)
+	if err != nil {
+		return fmt.Errorf("failed to read the configuration of %s: %w", function, err)
+	}
+	added, changed, removed := lambdaChanges(current.Variables, vars)
+	if added == 0 && changed == 0 && removed == 0 {
+		color.PrintInfof("The environment of %s is up to date", function)
+		return nil
+	}
+
+	if err := client.UpdateEnvironment(ctx, function, vars, current.RevisionID); err != nil {
+		return fmt.Errorf("failed to update the configuration of %s: %w", function, err)
+	}
+	color.PrintSuccessf("Updated the environment of %s: %d added, %d changed, %d removed", function, added, changed, removed)
+
+	sensitive := 0
+	for key := range vars {
+		if isSensitive(key) {
+			sensitive++
+		}
+	}
+	if sensitive > 0 {
+		color.PrintWarningf("%d sensitive variable(s) are stored in the function's configuration; use --no-secrets to leave them out", sensitive)
+	}
+	return nil
+}
+
+// lambdaChanges counts the variables replacing current adds, changes and
+// removes
+func lambdaChanges(current, vars map[string]string) (added, changed, removed int) {
+	for key, value := range vars {
+		old, ok := current[key]
+		switch {
+		case !ok:
+			added++
+		case old != value:
+			changed++
+		}
+	}
+	for key := range current {
+		if _, ok := vars[key]; !ok {
+			removed++
+		}
+	}
+	return added, changed, removed
+}
@@ -0,0 +1,33 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportLambda(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, exportLambda(&buf, newEnvFile(map[string]string{"API_URL": "https://api.example.org/?a=1&b=2", "PORT": "8080"})))
+	assert.Equal(t, `{
+  "Variables": {
+    "API_URL": "https://api.example.org/?a=1&b=2",
+    "PORT": "8080"
+  }
+}
+`, buf.String())
+
+	assert.Error(t, exportLambda(&buf, newEnvFile(map[string]string{"AWS_REGION": "us-east-1"})))
+}
+
+func TestLambdaChanges(t *testing.T) {
+	added, changed, removed := lambdaChanges(
+		map[string]string{"A": "1", "B": "2", "C": "3"},
+		map[string]string{"A": "1", "B": "x", "D": "4"},
+	)
+	assert.Equal(t, 1, added)
+	assert.Equal(t, 1, changed)
+	assert.Equal(t, 1, removed)
+}
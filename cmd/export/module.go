@@ -0,0 +1,142 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/validator"
+)
+
+// identifier matches keys that need no quotes in a JavaScript object
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// isModuleFormat reports whether format generates a config module for
+// frontend builds
+func isModuleFormat(format string) bool {
+	return format == "ts-module" || format == "js-module"
+}
+
+// loadModuleRules returns the validation rules of the environment, or
+// empty rules when the project has none
+func loadModuleRules(envName string) (*validator.Rules, error) {
+	rules, err := validator.LoadRulesForEnvironment(".envy-rules.yaml", envName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &validator.Rules{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load validation rules: %w", err)
+	}
+	return rules, nil
+}
+
+// clientSafe returns the variables of envFile that may be bundled into
+// browser code: those with a client-exposure prefix. It fails when any of
+// them is a secret by the client exposure rule of envy validate, so a build
+// exporting the module stops before a secret reaches the bundle.
+func clientSafe(envFile *env.File, rules *validator.Rules, isSensitive func(string) bool) (*env.File, error) {
+	safe := env.NewFile()
+	for _, key := range envFile.SortedKeys() {
+		for _, prefix := range rules.ClientPrefixes() {
+			if strings.HasPrefix(key, prefix) {
+				value, _ := envFile.Get(key)
+				safe.Set(key, value)
+				break
+			}
+		}
+	}
+
+	var problems []string
+	for _, issue := range validator.New(rules).WithSensitive(isSensitive).ClientExposure(safe.ToMap()) {
+		problems = append(problems, issue.Message)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("refusing to export secrets to a client config module:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return safe, nil
+}
+
+// exportModule writes the variables as a frozen config object, typed from
+// the validation rules: int and float variables become numbers and bool
+// variables booleans
+func exportModule(w io.Writer, envFile *env.File, rules *validator.Rules, typescript bool) error {
+	type field struct {
+		name, kind, value string
+	}
+	var fields []field
+	for _, key := range envFile.SortedKeys() {
+		value, _ := envFile.Get(key)
+		kind, literal, err := moduleValue(value, rules.Variables[key])
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		name := key
+		if !identifier.MatchString(key) {
+			quoted, _ := json.Marshal(key)
+			name = string(quoted)
+		}
+		fields = append(fields, field{name: name, kind: kind, value: literal})
+	}
+
+	fmt.Fprintln(w, "// Generated by envy. Do not edit.")
+	fmt.Fprintln(w, "// Only client-safe variables are included; envy export refuses secrets.")
+	fmt.Fprintln(w)
+
+	if typescript {
+		fmt.Fprintln(w, "export interface Config {")
+		for _, f := range fields {
+			fmt.Fprintf(w, "  readonly %s: %s;\n", f.name, f.kind)
+		}
+		fmt.Fprintln(w, "}")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "export const config: Config = Object.freeze({")
+	} else {
+		fmt.Fprintln(w, "/**")
+		fmt.Fprintln(w, " * @typedef {Object} Config")
+		for _, f := range fields {
+			fmt.Fprintf(w, " * @property {%s} %s\n", f.kind, f.name)
+		}
+		fmt.Fprintln(w, " */")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "/** @type {Readonly<Config>} */")
+		fmt.Fprintln(w, "export const config = Object.freeze({")
+	}
+	for _, f := range fields {
+		fmt.Fprintf(w, "  %s: %s,\n", f.name, f.value)
+	}
+	fmt.Fprintln(w, "});")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "export default config;")
+	return nil
+}
+
+// moduleValue returns the type and literal of a value in the module
+func moduleValue(value string, rule *validator.VariableRule) (string, string, error) {
+	if rule != nil {
+		switch rule.Type {
+		case validator.TypeInt, validator.TypeFloat:
+			if n, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && !math.IsInf(n, 0) && !math.IsNaN(n) {
+				return "number", strconv.FormatFloat(n, 'g', -1, 64), nil
+			}
+		case "bool":
+			if b, err := strconv.ParseBool(strings.TrimSpace(value)); err == nil {
+				return "boolean", strconv.FormatBool(b), nil
+			}
+		}
+	}
+
+	// JSON strings are valid JavaScript; the encoder also escapes U+2028
+	// and U+2029
+	literal, err := json.Marshal(value)
+	if err != nil {
+		return "", "", err
+	}
+	return "string", string(literal), nil
+}
@@ -0,0 +1,140 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/drapon/envy/internal/env"
+)
+
+// tfIdentifier matches valid Terraform variable names
+var tfIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// isTerraformFormat reports whether format generates a Terraform variable
+// definitions file
+func isTerraformFormat(format string) bool {
+	return format == "terraform" || format == "terraform-json"
+}
+
+// terraformVariables maps the keys of envFile to Terraform variable names,
+// snake_cased if requested, in the order of the sorted keys
+func terraformVariables(envFile *env.File, snake bool) ([]string, map[string]string, error) {
+	var names []string
+	values := make(map[string]string)
+	from := make(map[string]string)
+
+	for _, key := range envFile.SortedKeys() {
+		name := key
+		if snake {
+			name = toSnakeCase(key)
+		}
+		if !tfIdentifier.MatchString(name) {
+			return nil, nil, fmt.Errorf("%s is not a valid Terraform variable name", name)
+		}
+		if other, ok := from[name]; ok {
+			return nil, nil, fmt.Errorf("%s and %s both become the Terraform variable %s", other, key, name)
+		}
+		from[name] = key
+
+		value, _ := envFile.Get(key)
+		names = append(names, name)
+		values[name] = value
+	}
+	return names, values, nil
+}
+
+// exportTerraform writes a terraform.tfvars file, with the equals signs
+// aligned as terraform fmt does
+func exportTerraform(w io.Writer, envFile *env.File, snake bool) error {
+	names, values, err := terraformVariables(envFile, snake)
+	if err != nil {
+		return err
+	}
+
+	width := 0
+	for _, name := range names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+
+	fmt.Fprintln(w, "# Generated by envy")
+	fmt.Fprintln(w)
+	for _, name := range names {
+		fmt.Fprintf(w, "%-*s = %s\n", width, name, hclString(values[name]))
+	}
+	return nil
+}
+
+// exportTerraformJSON writes a terraform.tfvars.json file. Strings in JSON
+// variable files are not templates, so values need no escaping beyond JSON.
+func exportTerraformJSON(w io.Writer, envFile *env.File, snake bool) error {
+	_, values, err := terraformVariables(envFile, snake)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(values)
+}
+
+// hclString quotes s as an HCL string literal. Template sequences are
+// escaped too, so ${...} and %{...} in values stay literal.
+func hclString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '$', '%':
+			b.WriteRune(r)
+			if strings.HasPrefix(s[i+1:], "{") {
+				b.WriteRune(r)
+			}
+		default:
+			if unicode.IsControl(r) {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// toSnakeCase converts keys such as DATABASE_URL, apiKey or app.log-level
+// to database_url, api_key and app_log_level
+func toSnakeCase(key string) string {
+	var b strings.Builder
+	runes := []rune(key)
+	for i, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
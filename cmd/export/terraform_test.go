@@ -0,0 +1,64 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHCLString(t *testing.T) {
+	tests := map[string]string{
+		"plain":               `"plain"`,
+		`say "hi"`:            `"say \"hi\""`,
+		`C:\path`:             `"C:\\path"`,
+		"line1\nline2\ttab":   `"line1\nline2\ttab"`,
+		"${HOME} and %{if x}": `"$${HOME} and %%{if x}"`,
+		"cost $5 at 50%":      `"cost $5 at 50%"`,
+		"bell\a":              `"bell\u0007"`,
+		"日本語":                 `"日本語"`,
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, hclString(in), in)
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	assert.Equal(t, "database_url", toSnakeCase("DATABASE_URL"))
+	assert.Equal(t, "api_key", toSnakeCase("apiKey"))
+	assert.Equal(t, "app_log_level", toSnakeCase("app.log-level"))
+	assert.Equal(t, "oauth2_client", toSnakeCase("OAUTH2_CLIENT"))
+}
+
+func TestExportTerraform(t *testing.T) {
+	envFile := newEnvFile(map[string]string{
+		"DATABASE_URL": "postgres://app@db/app",
+		"PORT":         "8080",
+		"TEMPLATE":     "Hello ${name}",
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, exportTerraform(&buf, envFile, true))
+	assert.Equal(t, `# Generated by envy
+
+database_url = "postgres://app@db/app"
+port         = "8080"
+template     = "Hello $${name}"
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, exportTerraformJSON(&buf, envFile, false))
+	var values map[string]string
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &values))
+	assert.Equal(t, "Hello ${name}", values["TEMPLATE"])
+}
+
+func TestTerraformVariables_Errors(t *testing.T) {
+	_, _, err := terraformVariables(newEnvFile(map[string]string{"API_KEY": "a", "apiKey": "b"}), true)
+	assert.ErrorContains(t, err, "both become the Terraform variable api_key")
+
+	_, _, err = terraformVariables(newEnvFile(map[string]string{"1PASSWORD_TOKEN": "a"}), false)
+	assert.ErrorContains(t, err, "not a valid Terraform variable name")
+}
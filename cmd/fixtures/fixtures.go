@@ -0,0 +1,237 @@
+package fixtures
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/drapon/envy/internal/validator"
+)
+
+var (
+	environment string
+	output      string
+	format      string
+	source      string
+	rulesFile   string
+	anonymize   bool
+	seed        string
+)
+
+// fixturesCmd represents the fixtures command
+var fixturesCmd = &cobra.Command{
+	Use:   "fixtures",
+	Short: "Write an environment as test fixtures",
+	Long: `Write an environment's variables to a file for seeding application test
+suites.
+
+With --anonymize, sensitive values are replaced by realistic fakes, so the
+fixtures can be committed without leaking real secrets. Fakes follow the
+validation rules in .envy-rules.yaml: a value from the enum, a string
+matching the pattern, a number within min and max, or a value of the
+declared type (url, email, uuid, port, ...). Sensitive variables without a
+rule are scrambled, keeping their length and punctuation.
+
+Fakes are derived from the variable name and --seed, so regenerating the
+fixtures doesn't change them.`,
+	Example: `  # Export the test environment with fake secrets
+  envy fixtures --env test -o testdata/env.json --anonymize
+
+  # As a .env file, from AWS
+  envy fixtures --env test --source aws --format env -o testdata/.env --anonymize
+
+  # Different fakes for another suite
+  envy fixtures --env test -o testdata/e2e.json --anonymize --seed e2e`,
+	Args: cobra.NoArgs,
+	RunE: runFixtures,
+}
+
+// GetFixturesCmd returns the fixtures command.
+func GetFixturesCmd() *cobra.Command {
+	return fixturesCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(fixturesCmd)
+
+	fixturesCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to export")
+	fixturesCmd.Flags().StringVarP(&output, "output", "o", "", "Output file (stdout if not specified)")
+	fixturesCmd.Flags().StringVarP(&format, "format", "f", "json", "Output format (json/yaml/env)")
+	fixturesCmd.Flags().StringVarP(&source, "source", "s", "local", "Source (local/aws)")
+	fixturesCmd.Flags().StringVarP(&rulesFile, "rules", "r", ".envy-rules.yaml", "Validation rules file the fakes follow")
+	fixturesCmd.Flags().BoolVar(&anonymize, "anonymize", false, "Replace sensitive values with realistic fakes")
+	fixturesCmd.Flags().StringVar(&seed, "seed", "", "Seed for the fakes (same seed, same fakes)")
+}
+
+func runFixtures(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	// Load configuration
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+
+	switch format {
+	case "json", "yaml", "env":
+	default:
+		return fmt.Errorf("unsupported format: %s (use json, yaml or env)", format)
+	}
+
+	var envFile *env.File
+	switch source {
+	case "aws":
+		envFile, err = pullFromAWS(ctx, cfg, environment)
+		if err != nil {
+			return fmt.Errorf("failed to pull from AWS: %w", err)
+		}
+	case "local":
+		envFile, err = loadLocalFiles(cfg, environment)
+		if err != nil {
+			return fmt.Errorf("failed to load local files: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported source: %s", source)
+	}
+
+	isSensitive, err := sensitive.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	sensitive := 0
+	for _, key := range envFile.Keys() {
+		if isSensitive(key) {
+			sensitive++
+		}
+	}
+
+	if anonymize {
+		rules, err := validator.LoadRulesForEnvironment(rulesFile, environment)
+		if errors.Is(err, fs.ErrNotExist) {
+			rules = &validator.Rules{}
+		} else if err != nil {
+			return fmt.Errorf("failed to load validation rules: %w", err)
+		}
+		if envFile, err = anonymizeFile(envFile, rules, isSensitive, seed); err != nil {
+			return err
+		}
+	}
+
+	var writer io.Writer = os.Stdout
+	if output != "" {
+		if dir := filepath.Dir(output); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
+			}
+		}
+
+		// Real secrets stay private to the user
+		perm := os.FileMode(0600)
+		if anonymize || sensitive == 0 {
+			perm = 0644
+		}
+		file, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	if err := writeFixtures(writer, envFile, format); err != nil {
+		return fmt.Errorf("failed to write fixtures: %w", err)
+	}
+
+	if output == "" {
+		return nil
+	}
+	switch {
+	case anonymize:
+		color.PrintSuccessf("Wrote %d variable(s) to %s, %d sensitive value(s) replaced by fakes", len(envFile.Keys()), output, sensitive)
+	case sensitive > 0:
+		color.PrintSuccessf("Wrote %d variable(s) to %s", len(envFile.Keys()), output)
+		color.PrintWarningf("%d sensitive value(s) were written as-is; use --anonymize before committing fixtures", sensitive)
+	default:
+		color.PrintSuccessf("Wrote %d variable(s) to %s", len(envFile.Keys()), output)
+	}
+	return nil
+}
+
+// anonymizeFile returns a copy of envFile with every sensitive value
+// replaced by a fake that passes the variable's rule
+func anonymizeFile(envFile *env.File, rules *validator.Rules, isSensitive func(string) bool, seed string) (*env.File, error) {
+	faker := validator.NewFaker(rules, seed)
+
+	result := env.NewFile()
+	for _, key := range envFile.SortedKeys() {
+		value, _ := envFile.Get(key)
+		if isSensitive(key) {
+			var err error
+			if value, err = faker.Fake(key, value); err != nil {
+				return nil, err
+			}
+		}
+		result.Set(key, value)
+	}
+	return result, nil
+}
+
+func writeFixtures(w io.Writer, envFile *env.File, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(envFile.ToMap())
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		return encoder.Encode(envFile.ToMap())
+	case "env":
+		return envFile.Write(w)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+func pullFromAWS(ctx context.Context, cfg *config.Config, envName string) (*env.File, error) {
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	return awsManager.PullEnvironment(ctx, envName)
+}
+
+func loadLocalFiles(cfg *config.Config, envName string) (*env.File, error) {
+	envConfig, err := cfg.GetEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(envConfig.Files) == 0 {
+		return nil, fmt.Errorf("no files configured for environment %s", envName)
+	}
+
+	manager := env.NewManager(".")
+	return manager.LoadFiles(envConfig.Files)
+}
@@ -0,0 +1,75 @@
+package fixtures
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFixturesCmd(t *testing.T) {
+	cmd := GetFixturesCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "fixtures", cmd.Use)
+	assert.NotNil(t, cmd.RunE)
+
+	for _, name := range []string{"env", "output", "format", "source", "rules", "anonymize", "seed"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestAnonymizeFile(t *testing.T) {
+	envFile := env.NewFile()
+	envFile.Set("APP_NAME", "shop")
+	envFile.Set("API_KEY", "sk_live_abcdef123456")
+	envFile.Set("DATABASE_PASSWORD", "hunter2")
+
+	rules := &validator.Rules{
+		Variables: map[string]*validator.VariableRule{
+			"API_KEY": {Pattern: `^sk_(live|test)_[a-z0-9]{12}$`},
+		},
+	}
+	isSensitive := func(key string) bool { return key != "APP_NAME" }
+
+	result, err := anonymizeFile(envFile, rules, isSensitive, "")
+	require.NoError(t, err)
+
+	name, _ := result.Get("APP_NAME")
+	assert.Equal(t, "shop", name)
+
+	apiKey, _ := result.Get("API_KEY")
+	assert.Regexp(t, `^sk_(live|test)_[a-z0-9]{12}$`, apiKey)
+	assert.NotEqual(t, "sk_live_abcdef123456", apiKey)
+
+	password, _ := result.Get("DATABASE_PASSWORD")
+	assert.Len(t, password, len("hunter2"))
+	assert.NotEqual(t, "hunter2", password)
+
+	// Stable across runs, different per seed
+	again, err := anonymizeFile(envFile, rules, isSensitive, "")
+	require.NoError(t, err)
+	assert.Equal(t, result.ToMap(), again.ToMap())
+
+	seeded, err := anonymizeFile(envFile, rules, isSensitive, "e2e")
+	require.NoError(t, err)
+	assert.NotEqual(t, result.ToMap(), seeded.ToMap())
+}
+
+func TestWriteFixtures(t *testing.T) {
+	envFile := env.NewFile()
+	envFile.Set("PORT", "8080")
+
+	var buf bytes.Buffer
+	require.NoError(t, writeFixtures(&buf, envFile, "json"))
+	assert.JSONEq(t, `{"PORT": "8080"}`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, writeFixtures(&buf, envFile, "env"))
+	assert.True(t, strings.Contains(buf.String(), "PORT=8080"))
+
+	assert.Error(t, writeFixtures(&buf, envFile, "toml"))
+}
@@ -0,0 +1,116 @@
+package get
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/fuzzy"
+	"github.com/drapon/envy/internal/remote"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	environment string
+	pick        bool
+)
+
+// getCmd represents the get command
+var getCmd = &cobra.Command{
+	Use:   "get [KEY]",
+	Short: "Print the value of one variable",
+	Long: `Print the value of one variable of an environment, as stored in AWS or
+Azure, to standard output.
+
+With --pick the key is chosen with a built-in fuzzy finder over the keys of
+the environment: type a few characters of the key, move with the arrows or
+Tab and press Enter. KEY, if given, starts the search. The finder is drawn
+on standard error, so the value can still be piped.`,
+	Example: `  # Print one value
+  envy get DATABASE_URL --env prod
+
+  # Find the key by typing part of it
+  envy get --pick --env prod
+
+  # Start the search at "redis" and copy the value
+  envy get redis --pick | pbcopy`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runGet,
+}
+
+// GetGetCmd returns the get command.
+func GetGetCmd() *cobra.Command {
+	return getCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(getCmd)
+
+	getCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to read from (default: default_environment)")
+	getCmd.Flags().BoolVarP(&pick, "pick", "p", false, "Choose the key with a fuzzy finder")
+}
+
+func runGet(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+	if _, err := cfg.GetEnvironment(environment); err != nil {
+		return err
+	}
+
+	key, err := keyArg(args)
+	if err != nil {
+		return err
+	}
+
+	backend, err := remote.New(cfg)
+	if err != nil {
+		return err
+	}
+	vars, err := backend.ListEnvironmentVariables(ctx, environment)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", environment, err)
+	}
+
+	if pick {
+		if key, err = fuzzy.Pick(environment, key, sortedKeys(vars)); err != nil {
+			return err
+		}
+	}
+	value, ok := vars[key]
+	if !ok {
+		return fmt.Errorf("%s is not set in %s", key, environment)
+	}
+	fmt.Println(value)
+	return nil
+}
+
+// keyArg returns the key given on the command line, which is only the
+// start of the search with --pick
+func keyArg(args []string) (string, error) {
+	if len(args) == 1 {
+		return args[0], nil
+	}
+	if !pick {
+		return "", fmt.Errorf("give a KEY or --pick to choose one")
+	}
+	return "", nil
+}
+
+func sortedKeys(vars map[string]string) []string {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,36 @@
+package get
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFlags(t *testing.T) {
+	cmd := GetGetCmd()
+	assert.Equal(t, "get [KEY]", cmd.Use)
+	for _, name := range []string{"env", "pick"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestKeyArg(t *testing.T) {
+	defer func() { pick = false }()
+
+	key, err := keyArg([]string{"DB_HOST"})
+	require.NoError(t, err)
+	assert.Equal(t, "DB_HOST", key)
+
+	_, err = keyArg(nil)
+	assert.ErrorContains(t, err, "--pick")
+
+	pick = true
+	key, err = keyArg(nil)
+	require.NoError(t, err)
+	assert.Empty(t, key)
+}
+
+func TestSortedKeys(t *testing.T) {
+	assert.Equal(t, []string{"A", "B", "C"}, sortedKeys(map[string]string{"C": "3", "A": "1", "B": "2"}))
+}
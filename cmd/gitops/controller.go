@@ -0,0 +1,236 @@
+package gitops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/drapon/envy/internal/aws"
+	awserrors "github.com/drapon/envy/internal/aws/errors"
+	parameter_store "github.com/drapon/envy/internal/aws/parameter_store"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/gitops"
+	"github.com/drapon/envy/internal/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// DefaultLeaseParameter is the Parameter Store lease used for leader election
+const DefaultLeaseParameter = "/envy/gitops/{project}/controller-lease"
+
+var (
+	gitRemote      string
+	branch         string
+	interval       time.Duration
+	prune          bool
+	leaderElect    bool
+	leaseParameter string
+	leaseDuration  time.Duration
+	identity       string
+	metricsAddr    string
+)
+
+// controllerCmd represents the gitops controller command
+var controllerCmd = &cobra.Command{
+	Use:   "controller",
+	Short: "Continuously reconcile AWS with the env files in git",
+	Long: `Run a long-lived controller (e.g. in Kubernetes or ECS) that watches a git
+repository and keeps AWS in sync with the env files committed on a branch.
+
+Each interval the controller fetches the remote, reads every environment's
+files at the branch head and compares them with the variables in AWS. Any
+difference is corrected, whether it came from a new commit or from a manual
+change in the console (drift). Remote variables that are not in git are
+reported as unmanaged and left alone unless --prune is given. Corrections
+go through the same checks as 'envy push' (validate_on_push), and one that
+fails them is logged and not written.
+
+Run several replicas with --leader-elect for availability: they share a
+lease stored in Parameter Store and only the holder reconciles. The holder
+renews the lease before every write and stops writing as soon as it finds
+the lease expired or held by another replica.
+
+Prometheus metrics are served on --metrics-addr at /metrics, with a liveness
+probe at /healthz.`,
+	Example: `  # Reconcile all environments to origin/main every minute
+  envy gitops controller --repo /srv/config
+
+  # Highly available deployment with drift pruning
+  envy gitops controller --repo /srv/config --leader-elect --prune
+
+  # Report drift without correcting it
+  envy gitops controller --dry-run --interval 5m`,
+	Args: cobra.NoArgs,
+	RunE: runController,
+}
+
+func init() {
+	gitopsCmd.AddCommand(controllerCmd)
+
+	flags := controllerCmd.Flags()
+	flags.StringVarP(&environment, "env", "e", "", "Environment to reconcile (default: all environments)")
+	flags.StringVar(&repoDir, "repo", ".", "Directory of the git clone containing .envyrc's files")
+	flags.StringVar(&gitRemote, "remote", "origin", "Git remote to fetch before each reconcile (empty to skip fetching)")
+	flags.StringVar(&branch, "branch", "main", "Branch to reconcile to")
+	flags.DurationVar(&interval, "interval", time.Minute, "Time between reconciles")
+	flags.BoolVar(&prune, "prune", false, "Delete remote variables that are not in git")
+	flags.BoolVar(&dryRun, "dry-run", false, "Report drift without correcting it")
+	flags.StringVar(&stateParameter, "state-parameter", DefaultStateParameter, "Parameter recording the last applied commit")
+	flags.BoolVar(&leaderElect, "leader-elect", false, "Only reconcile while holding the controller lease")
+	flags.StringVar(&leaseParameter, "lease-parameter", DefaultLeaseParameter, "Parameter used as the leader election lease")
+	flags.DurationVar(&leaseDuration, "lease-duration", 0, "How long the lease is valid without renewal (default: 3x interval)")
+	flags.StringVar(&identity, "identity", "", "Replica identity for leader election (default: hostname-pid)")
+	flags.StringVar(&metricsAddr, "metrics-addr", ":9090", "Address for /metrics and /healthz (empty to disable)")
+}
+
+func runController(cmd *cobra.Command, args []string) error {
+	logger := log.WithContext(zap.String("command", "gitops controller"))
+
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	if leaseDuration == 0 {
+		leaseDuration = 3 * interval
+	}
+	if leaderElect && leaseDuration <= interval {
+		return fmt.Errorf("--lease-duration (%s) must be longer than --interval (%s)", leaseDuration, interval)
+	}
+
+	// Load configuration
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	envNames, err := resolveEnvironments(cfg, environment)
+	if err != nil {
+		return err
+	}
+	environments := make(map[string][]string)
+	for _, name := range envNames {
+		envConfig, err := cfg.GetEnvironment(name)
+		if err != nil {
+			return err
+		}
+		if len(envConfig.Files) > 0 {
+			environments[name] = envConfig.Files
+		}
+	}
+	if len(environments) == 0 {
+		return fmt.Errorf("no environments with files to reconcile")
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	ref := branch
+	if gitRemote != "" {
+		ref = gitRemote + "/" + branch
+	}
+
+	metrics := gitops.NewMetrics()
+	controller := &gitops.Controller{
+		Repo:         gitops.NewRepo(repoDir),
+		Remote:       awsManager,
+		Environments: environments,
+		FetchRemote:  gitRemote,
+		Ref:          ref,
+		Interval:     interval,
+		Prune:        prune,
+		DryRun:       dryRun,
+		Metrics:      metrics,
+		Logger:       logger,
+		Check: func(ctx context.Context, envName string, delta *gitops.Delta) error {
+			return checkDelta(ctx, cfg, awsManager, envName, delta, !prune)
+		},
+		OnApplied: func(ctx context.Context, envName, sha string) error {
+			return writeAppliedCommit(ctx, awsManager, stateParameterName(stateParameter, cfg.Project, envName), sha)
+		},
+	}
+
+	if leaderElect {
+		id := identity
+		if id == "" {
+			id = defaultIdentity()
+		}
+		store := &parameterLeaseStore{store: awsManager.GetParameterStore()}
+		name := stateParameterName(leaseParameter, cfg.Project, "")
+		controller.Lease = gitops.NewLease(store, name, id, leaseDuration)
+		logger.Info("Leader election enabled", zap.String("lease", name), zap.String("identity", id))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if metricsAddr != "" {
+		server := newMetricsServer(metricsAddr, metrics, logger)
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("Metrics server failed", zap.Error(err))
+			}
+		}()
+		defer server.Close()
+	}
+
+	color.PrintInfof("Reconciling %d environment(s) to %s every %s (Ctrl+C to stop)", len(environments), ref, interval)
+	return controller.Run(ctx)
+}
+
+func newMetricsServer(addr string, metrics *gitops.Metrics, logger *zap.Logger) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler(logger))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte("ok\n")); err != nil {
+			logger.Debug("Failed to write health check response", zap.Error(err))
+		}
+	})
+	return &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+}
+
+func defaultIdentity() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "envy"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// parameterLeaseStore stores the leader election lease in Parameter Store
+type parameterLeaseStore struct {
+	store *parameter_store.Store
+}
+
+func (s *parameterLeaseStore) Get(ctx context.Context, name string) (string, bool, error) {
+	param, err := s.store.GetParameter(ctx, name, false)
+	if err != nil {
+		if awserrors.IsNotFoundError(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return param.Value, true, nil
+}
+
+func (s *parameterLeaseStore) Create(ctx context.Context, name, value string) (bool, error) {
+	err := s.store.PutParameter(ctx, name, value, "envy gitops controller lease", "String", false)
+	if err != nil {
+		if awserrors.IsAlreadyExistsError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *parameterLeaseStore) Put(ctx context.Context, name, value string) error {
+	return s.store.PutParameter(ctx, name, value, "envy gitops controller lease", "String", true)
+}
@@ -0,0 +1,315 @@
+package gitops
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	awserrors "github.com/drapon/envy/internal/aws/errors"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/gitops"
+	"github.com/drapon/envy/internal/precheck"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// DefaultStateParameter is where the last applied commit is recorded.
+// {project} and {env} are replaced with the project and environment names.
+const DefaultStateParameter = "/envy/gitops/{project}/{env}/applied-commit"
+
+var (
+	environment    string
+	repoDir        string
+	fromRef        string
+	toRef          string
+	dryRun         bool
+	stateParameter string
+)
+
+// gitopsCmd represents the gitops command
+var gitopsCmd = &cobra.Command{
+	Use:   "gitops",
+	Short: "Drive AWS configuration from git commits",
+	Long: `Commands for a GitOps flow where committed .env files are the source of
+truth and merges, rather than manual pushes, update AWS.`,
+}
+
+// applyCmd represents the gitops apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply [FROM..TO]",
+	Short: "Push the env file changes between two commits to AWS",
+	Long: `Compute the changes to each environment's files between two commits and
+push only those deltas to AWS: added and modified variables are written and
+deleted variables are removed. Variables that did not change in the range are
+not touched, so values managed outside git are left alone. Each delta goes
+through the same checks as 'envy push' (validate_on_push) before it is
+written.
+
+After a successful apply the target commit SHA is recorded in Parameter Store
+(default: ` + DefaultStateParameter + `). When FROM is omitted the
+recorded commit is used, so running 'envy gitops apply' after every merge
+applies exactly the commits that have not been applied yet.
+
+The range may be given as FROM..TO, FROM.. (TO defaults to HEAD) or a single
+TO ref. Environments default to every configured environment whose files
+changed in the range.`,
+	Example: `  # Apply everything merged since the last apply (typical CI step)
+  envy gitops apply
+
+  # Apply the changes introduced by the last commit
+  envy gitops apply HEAD~1..HEAD
+
+  # First run: choose the starting commit explicitly
+  envy gitops apply --env production --from v1.4.0
+
+  # Show what would be pushed
+  envy gitops apply origin/main~3..origin/main --dry-run`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runApply,
+}
+
+// GetGitopsCmd returns the gitops command.
+func GetGitopsCmd() *cobra.Command {
+	return gitopsCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(gitopsCmd)
+	gitopsCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to apply (default: all environments changed in the range)")
+	applyCmd.Flags().StringVar(&repoDir, "repo", ".", "Directory of the git working tree containing .envyrc's files")
+	applyCmd.Flags().StringVar(&fromRef, "from", "", "Commit to diff from (default: last applied commit)")
+	applyCmd.Flags().StringVar(&toRef, "to", "", "Commit to apply (default: HEAD)")
+	applyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the changes without applying them")
+	applyCmd.Flags().StringVar(&stateParameter, "state-parameter", DefaultStateParameter, "Parameter recording the last applied commit")
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	from, to := fromRef, toRef
+	if len(args) == 1 {
+		if from != "" || to != "" {
+			return fmt.Errorf("a range argument cannot be combined with --from or --to")
+		}
+		var err error
+		from, to, err = parseRange(args[0])
+		if err != nil {
+			return err
+		}
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+
+	// Load configuration
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	envNames, err := resolveEnvironments(cfg, environment)
+	if err != nil {
+		return err
+	}
+
+	repo := gitops.NewRepo(repoDir)
+	toSHA, err := repo.ResolveRef(ctx, to)
+	if err != nil {
+		return err
+	}
+	fromSHA := ""
+	if from != "" {
+		if fromSHA, err = repo.ResolveRef(ctx, from); err != nil {
+			return err
+		}
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	applied := 0
+	for _, envName := range envNames {
+		ok, err := applyEnvironment(ctx, cfg, awsManager, repo, envName, fromSHA, toSHA)
+		if err != nil {
+			return fmt.Errorf("%s: %w", envName, err)
+		}
+		if ok {
+			applied++
+		}
+	}
+
+	if dryRun {
+		color.PrintInfof("\nDry run: no changes were made")
+	} else if applied == 0 {
+		color.PrintInfof("Nothing to apply at %s", gitops.ShortSHA(toSHA))
+	}
+	return nil
+}
+
+// applyEnvironment applies one environment's delta and records toSHA. It
+// reports whether anything was (or, in a dry run, would be) pushed.
+func applyEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Manager, repo *gitops.Repo, envName, fromSHA, toSHA string) (bool, error) {
+	envConfig, err := cfg.GetEnvironment(envName)
+	if err != nil {
+		return false, err
+	}
+	if len(envConfig.Files) == 0 {
+		return false, nil
+	}
+
+	stateName := stateParameterName(stateParameter, cfg.Project, envName)
+	recorded, err := readAppliedCommit(ctx, awsManager, stateName)
+	if err != nil {
+		return false, err
+	}
+
+	if fromSHA == "" {
+		if recorded == "" {
+			if environment == "" {
+				color.PrintWarningf("%s: no applied commit recorded, skipping (pass --from for the first apply)", envName)
+				return false, nil
+			}
+			return false, fmt.Errorf("no applied commit recorded in %s; pass --from or a FROM..TO range for the first apply", stateName)
+		}
+		fromSHA = recorded
+	} else if recorded != "" && recorded != fromSHA {
+		color.PrintWarningf("%s: last applied commit is %s but applying from %s; changes in between may be skipped or re-applied",
+			envName, gitops.ShortSHA(recorded), gitops.ShortSHA(fromSHA))
+	}
+
+	if fromSHA == toSHA {
+		return false, nil
+	}
+
+	delta, err := gitops.ComputeDelta(ctx, repo, envName, fromSHA, toSHA, envConfig.Files)
+	if err != nil {
+		return false, err
+	}
+
+	if delta.Empty() {
+		if !dryRun {
+			// Advance the state so the next apply starts from here
+			if err := writeAppliedCommit(ctx, awsManager, stateName, toSHA); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+
+	showDelta(delta, fromSHA, toSHA)
+
+	if dryRun {
+		return true, nil
+	}
+
+	if err := checkDelta(ctx, cfg, awsManager, envName, delta, true); err != nil {
+		return false, err
+	}
+	if err := awsManager.ApplyChanges(ctx, envName, delta.Set(), delta.Deleted); err != nil {
+		return false, fmt.Errorf("failed to apply changes: %w", err)
+	}
+	if err := writeAppliedCommit(ctx, awsManager, stateName, toSHA); err != nil {
+		return false, err
+	}
+
+	color.PrintSuccessf("Applied %s to %s (%d added, %d modified, %d deleted)",
+		gitops.ShortSHA(toSHA), envName, len(delta.Added), len(delta.Modified), len(delta.Deleted))
+	return true, nil
+}
+
+// checkDelta runs the checks push makes before a delta is written. partial
+// is set when remote variables not in the files are left in place.
+func checkDelta(ctx context.Context, cfg *config.Config, identity precheck.CallerIdentity, envName string, delta *gitops.Delta, partial bool) error {
+	envConfig, err := cfg.GetEnvironment(envName)
+	if err != nil {
+		return err
+	}
+	if err := precheck.Placeholders(cfg, envName, envConfig, delta.Set(), false); err != nil {
+		return err
+	}
+	if err := precheck.Writers(ctx, cfg, identity, precheck.Keys(delta.Set(), delta.Deleted)); err != nil {
+		return err
+	}
+	if !cfg.ValidateOnPush {
+		return nil
+	}
+	return precheck.Validate(ctx, envName, delta.Current, envConfig.Files, partial)
+}
+
+// parseRange splits FROM..TO. Either side may be empty; a spec without ".."
+// is taken as TO.
+func parseRange(spec string) (string, string, error) {
+	if strings.Contains(spec, "...") {
+		return "", "", fmt.Errorf("invalid range %q: use FROM..TO (symmetric differences are not supported)", spec)
+	}
+	from, to, found := strings.Cut(spec, "..")
+	if !found {
+		return "", spec, nil
+	}
+	return from, to, nil
+}
+
+func resolveEnvironments(cfg *config.Config, envName string) ([]string, error) {
+	if envName == "" || envName == "all" {
+		var names []string
+		for name := range cfg.Environments {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+
+	if _, err := cfg.GetEnvironment(envName); err != nil {
+		return nil, err
+	}
+	return []string{envName}, nil
+}
+
+// stateParameterName expands the {project} and {env} placeholders
+func stateParameterName(template, project, envName string) string {
+	name := strings.ReplaceAll(template, "{project}", project)
+	return strings.ReplaceAll(name, "{env}", envName)
+}
+
+func readAppliedCommit(ctx context.Context, awsManager *aws.Manager, name string) (string, error) {
+	param, err := awsManager.GetParameterStore().GetParameter(ctx, name, false)
+	if err != nil {
+		if awserrors.IsNotFoundError(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read applied commit from %s: %w", name, err)
+	}
+	return strings.TrimSpace(param.Value), nil
+}
+
+func writeAppliedCommit(ctx context.Context, awsManager *aws.Manager, name, sha string) error {
+	err := awsManager.GetParameterStore().PutParameter(ctx, name, sha, "Last commit applied by envy gitops", "String", true)
+	if err != nil {
+		return fmt.Errorf("failed to record applied commit in %s: %w", name, err)
+	}
+	return nil
+}
+
+// showDelta prints the keys that will change. Values are never shown since
+// the output typically ends up in CI logs.
+func showDelta(delta *gitops.Delta, fromSHA, toSHA string) {
+	color.PrintBoldf("\n%s: %s..%s (%s)", delta.Environment,
+		gitops.ShortSHA(fromSHA), gitops.ShortSHA(toSHA), strings.Join(delta.Files, ", "))
+	for _, key := range delta.Added {
+		fmt.Println(color.FormatSuccess("  + " + key))
+	}
+	for _, key := range delta.Modified {
+		fmt.Println(color.FormatWarning("  ~ " + key))
+	}
+	for _, key := range delta.Deleted {
+		fmt.Println(color.FormatError("  - " + key))
+	}
+}
@@ -0,0 +1,127 @@
+package gitops
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/gitops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGetGitopsCmd(t *testing.T) {
+	cmd := GetGitopsCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "gitops", cmd.Use)
+
+	apply, _, err := cmd.Find([]string{"apply"})
+	require.NoError(t, err)
+	assert.Equal(t, "apply [FROM..TO]", apply.Use)
+	assert.NotNil(t, apply.RunE)
+}
+
+func TestApplyCommandFlags(t *testing.T) {
+	flags := applyCmd.Flags()
+
+	assert.NotNil(t, flags.Lookup("env"))
+	assert.NotNil(t, flags.Lookup("repo"))
+	assert.NotNil(t, flags.Lookup("from"))
+	assert.NotNil(t, flags.Lookup("to"))
+	assert.NotNil(t, flags.Lookup("dry-run"))
+	assert.NotNil(t, flags.Lookup("state-parameter"))
+
+	assert.Equal(t, ".", flags.Lookup("repo").DefValue)
+	assert.Equal(t, DefaultStateParameter, flags.Lookup("state-parameter").DefValue)
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		spec     string
+		wantFrom string
+		wantTo   string
+		wantErr  bool
+	}{
+		{spec: "abc..def", wantFrom: "abc", wantTo: "def"},
+		{spec: "HEAD~1..", wantFrom: "HEAD~1"},
+		{spec: "..main", wantTo: "main"},
+		{spec: "main", wantTo: "main"},
+		{spec: "a...b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			from, to, err := parseRange(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFrom, from)
+			assert.Equal(t, tt.wantTo, to)
+		})
+	}
+}
+
+func TestStateParameterName(t *testing.T) {
+	assert.Equal(t, "/envy/gitops/myapp/prod/applied-commit", stateParameterName(DefaultStateParameter, "myapp", "prod"))
+	assert.Equal(t, "/custom/state", stateParameterName("/custom/state", "myapp", "prod"))
+}
+
+func TestResolveEnvironments(t *testing.T) {
+	cfg := &config.Config{
+		Environments: map[string]config.Environment{
+			"prod": {Files: []string{".env.prod"}},
+			"dev":  {Files: []string{".env.dev"}},
+		},
+	}
+
+	names, err := resolveEnvironments(cfg, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev", "prod"}, names)
+
+	names, err = resolveEnvironments(cfg, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod"}, names)
+
+	_, err = resolveEnvironments(cfg, "missing")
+	assert.Error(t, err)
+}
+
+func TestControllerCommandFlags(t *testing.T) {
+	controller, _, err := GetGitopsCmd().Find([]string{"controller"})
+	require.NoError(t, err)
+	assert.Equal(t, "controller", controller.Use)
+
+	flags := controllerCmd.Flags()
+	for _, name := range []string{"env", "repo", "remote", "branch", "interval", "prune", "dry-run",
+		"state-parameter", "leader-elect", "lease-parameter", "lease-duration", "identity", "metrics-addr"} {
+		assert.NotNil(t, flags.Lookup(name), name)
+	}
+
+	assert.Equal(t, "origin", flags.Lookup("remote").DefValue)
+	assert.Equal(t, "main", flags.Lookup("branch").DefValue)
+	assert.Equal(t, time.Minute.String(), flags.Lookup("interval").DefValue)
+	assert.Equal(t, DefaultLeaseParameter, flags.Lookup("lease-parameter").DefValue)
+}
+
+func TestMetricsServer(t *testing.T) {
+	server := newMetricsServer(":0", gitops.NewMetrics(), zap.NewNop())
+
+	rec := httptest.NewRecorder()
+	server.Handler.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, 200, rec.Code)
+	assert.Contains(t, rec.Body.String(), "envy_gitops_leader")
+
+	rec = httptest.NewRecorder()
+	server.Handler.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	assert.Equal(t, 200, rec.Code)
+}
+
+func TestDefaultIdentity(t *testing.T) {
+	assert.Contains(t, defaultIdentity(), fmt.Sprintf("-%d", os.Getpid()))
+}
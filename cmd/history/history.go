@@ -0,0 +1,174 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/aws/parameter_store"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/ownership"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	environment string
+	showValues  bool
+	format      string
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history VARIABLE",
+	Short: "List the previous versions of a variable in Parameter Store",
+	Long: `List the versions of a variable that Parameter Store keeps (the last 100),
+newest first, with when and by whom each was written.
+
+Sensitive values are masked unless --show-values is given. Restore an
+earlier version with 'envy rollback VARIABLE --version N'.`,
+	Example: `  # Versions of DATABASE_URL in prod
+  envy history DATABASE_URL --env prod
+
+  # Include the full values
+  envy history API_KEY --env staging --show-values`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistory,
+}
+
+// GetHistoryCmd returns the history command.
+func GetHistoryCmd() *cobra.Command {
+	return historyCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(historyCmd)
+
+	historyCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment (default: default_environment)")
+	historyCmd.Flags().BoolVar(&showValues, "show-values", false, "Show sensitive values instead of masking them")
+	historyCmd.Flags().StringVar(&format, "format", "text", "Output format (text/json)")
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	key := args[0]
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unsupported format %q (use text or json)", format)
+	}
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.UsesAzure() {
+		return fmt.Errorf("envy history is only supported with AWS")
+	}
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+
+	isSensitive, err := sensitive.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	versions, err := awsManager.VariableHistory(context.Background(), environment, key)
+	if err != nil {
+		return err
+	}
+
+	mask := isSensitive(key) && !showValues
+	if format == "json" {
+		return printJSON(versions, mask)
+	}
+	printVersions(key, environment, versions, mask)
+	return nil
+}
+
+// printVersions prints a table of the versions, newest first
+func printVersions(key, envName string, versions []*parameter_store.ParameterVersion, mask bool) {
+	color.PrintBoldf("Versions of %s in %s:", key, envName)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tMODIFIED\tBY\tVALUE")
+	for i := len(versions) - 1; i >= 0; i-- {
+		v := versions[i]
+		value := displayValue(v, mask)
+		var notes []string
+		if i == len(versions)-1 {
+			notes = append(notes, "current")
+		}
+		if len(v.Labels) > 0 {
+			notes = append(notes, "labels: "+strings.Join(v.Labels, ", "))
+		}
+		if len(notes) > 0 {
+			value += " (" + strings.Join(notes, "; ") + ")"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", v.Version, v.LastModified.Local().Format("2006-01-02 15:04:05"), modifiedBy(v), value)
+	}
+	w.Flush()
+}
+
+func printJSON(versions []*parameter_store.ParameterVersion, mask bool) error {
+	type version struct {
+		Version    int64    `json:"version"`
+		Modified   string   `json:"modified"`
+		ModifiedBy string   `json:"modified_by,omitempty"`
+		Value      string   `json:"value"`
+		Labels     []string `json:"labels,omitempty"`
+		LargeValue bool     `json:"large_value,omitempty"`
+	}
+
+	out := make([]version, 0, len(versions))
+	for i := len(versions) - 1; i >= 0; i-- {
+		v := versions[i]
+		out = append(out, version{
+			Version:    v.Version,
+			Modified:   v.LastModified.UTC().Format("2006-01-02T15:04:05Z"),
+			ModifiedBy: v.LastModifiedUser,
+			Value:      displayValue(v, mask),
+			Labels:     v.Labels,
+			LargeValue: v.LargeValue,
+		})
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// displayValue returns the value to show for a version. Sensitive values keep
+// their first and last character so versions can be told apart.
+func displayValue(v *parameter_store.ParameterVersion, mask bool) string {
+	switch {
+	case v.LargeValue:
+		return "[large value in Secrets Manager]"
+	case !mask:
+		return v.Value
+	case len(v.Value) <= 4:
+		return "***"
+	default:
+		return v.Value[:1] + "***" + v.Value[len(v.Value)-1:]
+	}
+}
+
+func modifiedBy(v *parameter_store.ParameterVersion) string {
+	if v.LastModifiedUser == "" {
+		return "-"
+	}
+	return ownership.PrincipalName(v.LastModifiedUser)
+}
@@ -0,0 +1,21 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/aws/parameter_store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisplayValue(t *testing.T) {
+	v := &parameter_store.ParameterVersion{Value: "s3cret-value"}
+	assert.Equal(t, "s3cret-value", displayValue(v, false))
+	assert.Equal(t, "s***e", displayValue(v, true))
+	assert.Equal(t, "***", displayValue(&parameter_store.ParameterVersion{Value: "abc"}, true))
+	assert.Equal(t, "[large value in Secrets Manager]", displayValue(&parameter_store.ParameterVersion{Value: "ref", LargeValue: true}, false))
+}
+
+func TestModifiedBy(t *testing.T) {
+	assert.Equal(t, "-", modifiedBy(&parameter_store.ParameterVersion{}))
+	assert.Equal(t, "alice", modifiedBy(&parameter_store.ParameterVersion{LastModifiedUser: "arn:aws:iam::123456789012:user/alice"}))
+}
@@ -0,0 +1,366 @@
+// Package importcmd implements the import command. It is not named after its
+// directory because "import" is a Go keyword.
+package importcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/format"
+	"github.com/drapon/envy/internal/precheck"
+	"github.com/drapon/envy/internal/prompt"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/drapon/envy/internal/validator"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	environment  string
+	from         string
+	target       string
+	dryRun       bool
+	force        bool
+	allowSecrets bool
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import FILE",
+	Short: "Import variables from a CSV file",
+	Long: `Import variables from a file edited outside of envy, such as a CSV sheet
+produced by 'envy export --format csv'.
+
+The file is validated (required key/value columns, valid and unique keys),
+then compared with the target environment and a preview of additions and
+modifications is shown before anything is written. Keys missing from the
+file are left unchanged.
+
+Values of variables with a type in .envy-rules.yaml are normalized first,
+e.g. surrounding spaces are trimmed and hostnames and UUIDs lowercased, so
+cells retyped in a spreadsheet do not show up as changes.
+
+Changes to variables classified as sensitive, by .envyrc or by a true in
+the file's sensitive column, are rejected unless --allow-secrets is given,
+so spreadsheets can be used for plain config without risking secrets.
+Variables marked sensitive are stored as SecureString.
+
+A leading ' in a value is removed: 'envy export --format csv' adds it to
+values starting with =, +, - or @ so spreadsheets do not run them as
+formulas.`,
+	Example: `  # Round-trip non-secret config through a spreadsheet
+  envy export --env staging --format csv --no-secrets --output staging.csv
+  envy import --from csv staging.csv --env staging
+
+  # Preview only
+  envy import --from csv staging.csv --env staging --dry-run
+
+  # Apply to the local .env file instead of AWS
+  envy import --from csv staging.csv --env staging --target local`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+// GetImportCmd returns the import command.
+func GetImportCmd() *cobra.Command {
+	return importCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(importCmd)
+
+	importCmd.Flags().StringVarP(&environment, "env", "e", "", "Target environment")
+	importCmd.Flags().StringVar(&from, "from", "csv", "Input format (csv)")
+	importCmd.Flags().StringVarP(&target, "target", "t", "aws", "Where to apply changes (aws/local)")
+	importCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the changes without applying them")
+	importCmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation prompt")
+	importCmd.Flags().BoolVar(&allowSecrets, "allow-secrets", false, "Allow changes to sensitive variables")
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if from != "csv" {
+		return fmt.Errorf("unsupported import format: %s", from)
+	}
+	if target != "aws" && target != "local" {
+		return fmt.Errorf("invalid target %q (expected aws or local)", target)
+	}
+
+	// Load configuration
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+	envConfig, err := cfg.GetEnvironment(environment)
+	if err != nil {
+		return err
+	}
+
+	imported, marked, err := readCSV(args[0])
+	if err != nil {
+		return err
+	}
+
+	rules, err := validator.LoadRulesForEnvironment(".envy-rules.yaml", environment)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to load validation rules: %w", err)
+	}
+	if rules != nil {
+		if keys := normalizeValues(imported, rules); len(keys) > 0 {
+			color.PrintInfof("Normalized %s to their rule types", strings.Join(keys, ", "))
+		}
+	}
+
+	// Load the current state of the target
+	var current *env.File
+	var awsManager *aws.Manager
+	if target == "aws" {
+		awsManager, err = aws.NewManager(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create AWS manager: %w", err)
+		}
+		current, err = awsManager.PullEnvironment(ctx, environment)
+		if err != nil {
+			return fmt.Errorf("failed to pull current variables: %w", err)
+		}
+	} else {
+		if len(envConfig.Files) == 0 {
+			return fmt.Errorf("no files configured for environment %s", environment)
+		}
+		current, err = env.NewManager(".").LoadFiles(envConfig.Files)
+		if err != nil {
+			return fmt.Errorf("failed to load local files: %w", err)
+		}
+	}
+
+	changes := changedVariables(current, imported)
+	if len(changes.Keys()) == 0 {
+		color.PrintSuccessf("No changes: %s matches %s", args[0], describeTarget(environment))
+		return nil
+	}
+
+	configured, err := sensitive.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	classifier := markedSensitive(configured, marked)
+
+	showPreview(current, changes, classifier)
+
+	if sensitive := sensitiveKeys(changes, classifier); len(sensitive) > 0 && !allowSecrets {
+		return fmt.Errorf("refusing to change sensitive variables %s (use --allow-secrets)", strings.Join(sensitive, ", "))
+	}
+
+	if target == "aws" {
+		if err := precheck.Placeholders(cfg, environment, envConfig, changes, false); err != nil {
+			return err
+		}
+		if err := precheck.Writers(ctx, cfg, awsManager, changes.Keys()); err != nil {
+			return err
+		}
+	}
+	if target == "aws" && cfg.ValidateOnPush {
+		result := env.NewFile()
+		result.Merge(current)
+		result.Merge(changes)
+		if err := precheck.Validate(ctx, environment, result, nil, false); err != nil {
+			return err
+		}
+	}
+
+	if dryRun {
+		color.PrintInfof("\nDry run: no changes were made")
+		return nil
+	}
+
+	if !force && !confirmImport(len(changes.Keys()), describeTarget(environment)) {
+		color.PrintWarningf("Import cancelled")
+		return nil
+	}
+
+	if target == "aws" {
+		awsManager.MarkSensitive(marked...)
+		err = applyToAWS(ctx, cfg, awsManager, current, changes)
+	} else {
+		err = applyToLocal(envConfig.Files[0], changes)
+	}
+	if err != nil {
+		return err
+	}
+
+	color.PrintSuccessf("Imported %d change(s) into %s", len(changes.Keys()), describeTarget(environment))
+	return nil
+}
+
+// readCSV parses the import and returns the keys its sensitive column marks
+func readCSV(path string) (*env.File, []string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	file, marked, err := env.ParseCSV(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV %s:\n%w", path, err)
+	}
+	return file, marked, nil
+}
+
+// markedSensitive extends a classifier with the keys the CSV marks
+// sensitive. The column can only add to the configured sensitivity, so
+// clearing it in a spreadsheet never exposes a secret.
+func markedSensitive(isSensitive func(string) bool, marked []string) func(string) bool {
+	if len(marked) == 0 {
+		return isSensitive
+	}
+
+	set := make(map[string]bool, len(marked))
+	for _, key := range marked {
+		set[key] = true
+	}
+	return func(key string) bool {
+		return set[key] || isSensitive(key)
+	}
+}
+
+// normalizeValues rewrites imported values in the canonical spelling of
+// their rule's type and returns the keys that changed
+func normalizeValues(imported *env.File, rules *validator.Rules) []string {
+	var keys []string
+	for _, key := range imported.Keys() {
+		rule, ok := rules.Variables[key]
+		if !ok || rule == nil {
+			continue
+		}
+		f, ok := format.Lookup(rule.Type)
+		if !ok {
+			continue
+		}
+
+		value, _ := imported.Get(key)
+		if normalized := f.Normalize(value); normalized != value {
+			imported.Set(key, normalized)
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// changedVariables returns the imported variables that are new or differ
+// from current, in the order they appear in the import
+func changedVariables(current, imported *env.File) *env.File {
+	changes := env.NewFile()
+	for _, key := range imported.Keys() {
+		value, _ := imported.Get(key)
+		if existing, ok := current.Get(key); ok && existing == value {
+			continue
+		}
+		changes.Set(key, value)
+	}
+	return changes
+}
+
+func showPreview(current, changes *env.File, isSensitive func(string) bool) {
+	var added, modified []string
+	for _, key := range changes.Keys() {
+		if _, ok := current.Get(key); ok {
+			modified = append(modified, key)
+		} else {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(modified)
+
+	display := func(key, value string) string {
+		if isSensitive(key) {
+			return "***HIDDEN***"
+		}
+		return value
+	}
+
+	color.PrintBoldf("\nChanges to apply:")
+	for _, key := range added {
+		value, _ := changes.Get(key)
+		fmt.Println(color.FormatSuccess(fmt.Sprintf("  + %s = %s", key, display(key, value))))
+	}
+	for _, key := range modified {
+		oldValue, _ := current.Get(key)
+		newValue, _ := changes.Get(key)
+		fmt.Println(color.FormatWarning(fmt.Sprintf("  ~ %s: %s → %s", key, display(key, oldValue), display(key, newValue))))
+	}
+	color.PrintInfof("\n%d added, %d modified", len(added), len(modified))
+}
+
+func sensitiveKeys(changes *env.File, isSensitive func(string) bool) []string {
+	var keys []string
+	for _, key := range changes.SortedKeys() {
+		if isSensitive(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func applyToAWS(ctx context.Context, cfg *config.Config, awsManager *aws.Manager, current, changes *env.File) error {
+	toPush := changes
+	// A Secrets Manager environment is stored as a single secret, so the
+	// full set of variables must be written back
+	if cfg.GetAWSService(environment) == "secrets_manager" {
+		toPush = env.NewFile()
+		toPush.Merge(current)
+		toPush.Merge(changes)
+	}
+
+	err := awsManager.PushReplicated(ctx, environment, toPush.Keys(), func() error {
+		return awsManager.PushEnvironment(ctx, environment, toPush, true)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to push changes: %w", err)
+	}
+	return nil
+}
+
+func applyToLocal(filename string, changes *env.File) error {
+	file := env.NewFile()
+	if _, err := os.Stat(filename); err == nil {
+		existing, err := env.ParseFile(filename)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", filename, err)
+		}
+		file = existing
+	}
+
+	file.Merge(changes)
+	if err := file.WriteFile(filename); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filename, err)
+	}
+	return nil
+}
+
+func describeTarget(envName string) string {
+	if target == "local" {
+		return fmt.Sprintf("local files (%s)", envName)
+	}
+	return fmt.Sprintf("AWS (%s)", envName)
+}
+
+func confirmImport(count int, description string) bool {
+	return prompt.Confirm(color.FormatWarning(fmt.Sprintf("About to apply %d change(s) to %s.", count, description)) + " Continue?")
+}
@@ -0,0 +1,97 @@
+package importcmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetImportCmd(t *testing.T) {
+	cmd := GetImportCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "import FILE", cmd.Use)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.NotNil(t, cmd.Flags().Lookup("env"))
+	assert.NotNil(t, cmd.Flags().Lookup("from"))
+	assert.NotNil(t, cmd.Flags().Lookup("target"))
+	assert.NotNil(t, cmd.Flags().Lookup("dry-run"))
+	assert.NotNil(t, cmd.Flags().Lookup("allow-secrets"))
+}
+
+func TestChangedVariables(t *testing.T) {
+	current := env.NewFile()
+	current.Set("SAME", "1")
+	current.Set("CHANGED", "old")
+	current.Set("NOT_IN_CSV", "x")
+
+	imported := env.NewFile()
+	imported.Set("SAME", "1")
+	imported.Set("CHANGED", "new")
+	imported.Set("ADDED", "a")
+
+	changes := changedVariables(current, imported)
+	assert.Equal(t, []string{"CHANGED", "ADDED"}, changes.Keys())
+
+	value, _ := changes.Get("CHANGED")
+	assert.Equal(t, "new", value)
+}
+
+func TestSensitiveKeys(t *testing.T) {
+	changes := env.NewFile()
+	changes.Set("TIMEOUT", "30")
+	changes.Set("DB_PASSWORD", "x")
+
+	keys := sensitiveKeys(changes, func(key string) bool { return key == "DB_PASSWORD" })
+	assert.Equal(t, []string{"DB_PASSWORD"}, keys)
+
+	// Keys marked sensitive in the CSV count even if the config disagrees
+	isSensitive := markedSensitive(func(key string) bool { return key == "DB_PASSWORD" }, []string{"TIMEOUT"})
+	assert.Equal(t, []string{"DB_PASSWORD", "TIMEOUT"}, sensitiveKeys(changes, isSensitive))
+}
+
+func TestApplyToLocal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env.staging")
+	require.NoError(t, os.WriteFile(path, []byte("A=1\nB=2\n"), 0600))
+
+	changes := env.NewFile()
+	changes.Set("B", "20")
+	changes.Set("C", "3")
+
+	require.NoError(t, applyToLocal(path, changes))
+
+	file, err := env.ParseFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "1", "B": "20", "C": "3"}, file.ToMap())
+}
+
+func TestNormalizeValues(t *testing.T) {
+	imported := env.NewFile()
+	imported.Set("DB_HOST", " DB.Example.com ")
+	imported.Set("PORT", "8080")
+	imported.Set("NAME", " padded ")
+	imported.Set("BAD_PORT", "http")
+
+	rules := &validator.Rules{
+		Variables: map[string]*validator.VariableRule{
+			"DB_HOST":  {Type: "hostname"},
+			"PORT":     {Type: "port"},
+			"NAME":     {Type: "string"},
+			"BAD_PORT": {Type: "port"},
+		},
+	}
+
+	assert.Equal(t, []string{"DB_HOST"}, normalizeValues(imported, rules))
+
+	value, _ := imported.Get("DB_HOST")
+	assert.Equal(t, "db.example.com", value)
+	value, _ = imported.Get("NAME")
+	assert.Equal(t, " padded ", value)
+	value, _ = imported.Get("BAD_PORT")
+	assert.Equal(t, "http", value)
+}
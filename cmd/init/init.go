@@ -1,13 +1,24 @@
 package init
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/drapon/envy/cmd/root"
 	"github.com/drapon/envy/internal/color"
 	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/framework"
+	"github.com/drapon/envy/internal/prompt"
+	"github.com/drapon/envy/internal/validator"
 	"github.com/drapon/envy/internal/wizard"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +29,7 @@ var (
 	awsRegion   string
 	awsProfile  string
 	interactive bool
+	catalogURL  string
 )
 
 // initCmd represents the init command
@@ -27,7 +39,22 @@ var initCmd = &cobra.Command{
 	Long: `Initialize a new envy project in the current directory.
 
 This command creates a .envyrc configuration file with default settings
-that you can customize for your project.`,
+that you can customize for your project.
+
+When no .env files exist yet, an example one is created. Variables with a
+generator in .envy-rules.yaml (e.g. generate: random_hex(32)) get a freshly
+generated value instead of a placeholder.
+
+With --catalog, the team's catalog of standard variables is fetched from a
+URL, an s3:// location (read with the AWS CLI) or a file. The env file is
+generated from it, with each variable's description and default, and its
+types and checks are written to .envy-rules.yaml.
+
+Next.js, Vite, Rails and Django projects are detected. Their browser-exposed
+variables (NEXT_PUBLIC_*, VITE_*) and framework settings such as RAILS_ENV
+are classified as plain, their secrets such as RAILS_MASTER_KEY and
+DJANGO_SECRET_KEY as sensitive, and 'envy export' defaults to the KEY=VALUE
+format their .env loaders read.`,
 	Example: `  # Initialize with default settings
   envy init
   
@@ -35,7 +62,10 @@ that you can customize for your project.`,
   envy init --project myapp
   
   # Initialize with AWS settings
-  envy init --project myapp --aws-region us-west-2 --aws-profile prod`,
+  envy init --project myapp --aws-region us-west-2 --aws-profile prod
+
+  # Start from the team's variable catalog
+  envy init --catalog s3://acme-platform/envy/catalog.yaml`,
 	RunE: runInit,
 }
 
@@ -48,11 +78,15 @@ func init() {
 	initCmd.Flags().StringVar(&awsRegion, "aws-region", "us-east-1", "AWS region")
 	initCmd.Flags().StringVar(&awsProfile, "aws-profile", "default", "AWS profile")
 	initCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Run in interactive mode")
+	initCmd.Flags().StringVar(&catalogURL, "catalog", "", "Variable catalog to generate the env file and rules from (URL, s3:// or file)")
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
 	// Check if running in interactive mode
 	if interactive {
+		if prompt.NonInteractive() {
+			return fmt.Errorf("--interactive needs prompts, which are disabled (--non-interactive, --yes or CI=true)")
+		}
 		return wizard.InteractiveInit(projectName)
 	}
 
@@ -61,6 +95,19 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf(".envyrc file already exists in current directory")
 	}
 
+	// Fetch the catalog first so a bad source leaves nothing behind
+	var catalog *validator.Catalog
+	if catalogURL != "" {
+		data, err := fetchCatalog(context.Background(), catalogURL)
+		if err != nil {
+			return fmt.Errorf("failed to fetch catalog: %w", err)
+		}
+		catalog, err = validator.ParseCatalog(data)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Get project name from flag or current directory
 	if projectName == "" {
 		cwd, err := os.Getwd()
@@ -111,6 +158,18 @@ func runInit(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Classify the variables of the frameworks in use
+	frameworks := framework.Detect(".")
+	if len(frameworks) > 0 {
+		color.PrintInfof("Detected %s", strings.Join(framework.Names(frameworks), ", "))
+		framework.Apply(cfg, frameworks)
+		for _, fw := range frameworks {
+			for _, note := range fw.Notes {
+				color.PrintInfof("%s: %s", fw.Name, note)
+			}
+		}
+	}
+
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		return fmt.Errorf("invalid configuration: %w", err)
@@ -121,20 +180,31 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to save configuration: %w", err)
 	}
 
+	if catalog != nil {
+		writeCatalogRules(catalog)
+	}
+
 	// Only create example .env file if no existing env files were found
 	if len(existingEnvFiles) == 0 {
 		envFile := fmt.Sprintf(".env.%s", envName)
 		if _, err := os.Stat(envFile); os.IsNotExist(err) {
-			content := `# Example environment variables
-DATABASE_URL=postgresql://localhost/myapp_dev
-REDIS_URL=redis://localhost:6379
-API_KEY=your-api-key-here
-DEBUG=true
-`
+			var content string
+			var generated []string
+			if catalog != nil {
+				content, generated, err = catalog.EnvFile()
+				if err != nil {
+					return err
+				}
+			} else {
+				content, generated = exampleEnvFile(loadRules())
+			}
 			if err := os.WriteFile(envFile, []byte(content), 0600); err != nil {
 				color.PrintWarningf("Failed to create example %s file: %v", envFile, err)
 			} else {
 				color.PrintSuccessf("Created example %s file", envFile)
+				if len(generated) > 0 {
+					color.PrintInfof("Generated values for %s", strings.Join(generated, ", "))
+				}
 			}
 		}
 	}
@@ -153,6 +223,125 @@ DEBUG=true
 	return nil
 }
 
+// exampleVariables are written to the example env file
+var exampleVariables = [][2]string{
+	{"DATABASE_URL", "postgresql://localhost/myapp_dev"},
+	{"REDIS_URL", "redis://localhost:6379"},
+	{"API_KEY", "your-api-key-here"},
+	{"DEBUG", "true"},
+}
+
+// loadRules returns the validation rules in the current directory, or nil
+// if there are none
+func loadRules() *validator.Rules {
+	if _, err := os.Stat(".envy-rules.yaml"); err != nil {
+		return nil
+	}
+	rules, err := validator.LoadRulesFromFile(".envy-rules.yaml")
+	if err != nil {
+		color.PrintWarningf("Ignoring .envy-rules.yaml: %v", err)
+		return nil
+	}
+	return rules
+}
+
+// exampleEnvFile returns the content of the example env file. Variables
+// with a generator in rules get a generated value, and the names of those
+// are returned.
+func exampleEnvFile(rules *validator.Rules) (string, []string) {
+	var b strings.Builder
+	b.WriteString("# Example environment variables\n")
+
+	var generated []string
+	write := func(key, value string) {
+		if rules != nil {
+			if rule, ok := rules.Variables[key]; ok && rule.Generate != "" {
+				if v, err := validator.GenerateValue(key, rule); err == nil {
+					value = v
+					generated = append(generated, key)
+				}
+			}
+		}
+		fmt.Fprintf(&b, "%s=%s\n", key, value)
+	}
+
+	written := make(map[string]bool)
+	for _, kv := range exampleVariables {
+		write(kv[0], kv[1])
+		written[kv[0]] = true
+	}
+
+	if rules != nil {
+		var keys []string
+		for key, rule := range rules.Variables {
+			if rule != nil && rule.Generate != "" && !written[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			write(key, "")
+		}
+	}
+
+	return b.String(), generated
+}
+
+// fetchCatalog reads a catalog from an http(s) URL, an s3:// location or a
+// local file. S3 objects are read with the AWS CLI, so the usual profile
+// and credentials apply.
+func fetchCatalog(ctx context.Context, source string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	switch {
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("GET %s: %s", source, resp.Status)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	case strings.HasPrefix(source, "s3://"):
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "aws", "s3", "cp", source, "-")
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("aws s3 cp %s: %s", source, msg)
+			}
+			return nil, fmt.Errorf("aws s3 cp %s: %w", source, err)
+		}
+		return out, nil
+
+	default:
+		return os.ReadFile(source)
+	}
+}
+
+// writeCatalogRules writes the catalog's rules to .envy-rules.yaml, unless
+// the project already has rules
+func writeCatalogRules(catalog *validator.Catalog) {
+	if _, err := os.Stat(".envy-rules.yaml"); err == nil {
+		color.PrintWarningf("Keeping the existing .envy-rules.yaml; the catalog rules were not written")
+		return
+	}
+	if err := validator.SaveRulesToFile(catalog.Rules(), ".envy-rules.yaml"); err != nil {
+		color.PrintWarningf("Failed to create .envy-rules.yaml: %v", err)
+		return
+	}
+	color.PrintSuccessf("Created .envy-rules.yaml from the catalog (%d variables)", len(catalog.Variables))
+}
+
 // detectEnvFiles scans the current directory for .env files
 func detectEnvFiles() []string {
 	var envFiles []string
@@ -2,7 +2,10 @@ package init
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -10,6 +13,7 @@ import (
 	"github.com/drapon/envy/cmd/root"
 	"github.com/drapon/envy/internal/config"
 	"github.com/drapon/envy/internal/testutil"
+	"github.com/drapon/envy/internal/validator"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -547,3 +551,47 @@ func BenchmarkRunInit(b *testing.B) {
 		os.RemoveAll(tempDir)
 	}
 }
+
+func TestExampleEnvFile(t *testing.T) {
+	content, generated := exampleEnvFile(nil)
+	assert.Contains(t, content, "API_KEY=your-api-key-here\n")
+	assert.Empty(t, generated)
+
+	rules := &validator.Rules{
+		Variables: map[string]*validator.VariableRule{
+			"API_KEY":        {Type: "string", Generate: "random_hex(16)"},
+			"SESSION_SECRET": {Type: "string", Generate: "random_alnum(24)"},
+			"DEBUG":          {Type: "bool"},
+		},
+	}
+	content, generated = exampleEnvFile(rules)
+	assert.Equal(t, []string{"API_KEY", "SESSION_SECRET"}, generated)
+	assert.Regexp(t, `(?m)^API_KEY=[0-9a-f]{32}$`, content)
+	assert.Regexp(t, `(?m)^SESSION_SECRET=[A-Za-z0-9]{24}$`, content)
+	assert.Contains(t, content, "DEBUG=true\n")
+}
+
+func TestFetchCatalog(t *testing.T) {
+	catalog := "variables:\n  - name: DATABASE_URL\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/catalog.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(catalog))
+	}))
+	defer server.Close()
+
+	data, err := fetchCatalog(context.Background(), server.URL+"/catalog.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, catalog, string(data))
+
+	_, err = fetchCatalog(context.Background(), server.URL+"/missing.yaml")
+	assert.ErrorContains(t, err, "404")
+
+	path := filepath.Join(t.TempDir(), "catalog.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(catalog), 0644))
+	data, err = fetchCatalog(context.Background(), path)
+	require.NoError(t, err)
+	assert.Equal(t, catalog, string(data))
+}
@@ -2,8 +2,9 @@ package list
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"sort"
 	"strings"
 
@@ -12,6 +13,11 @@ import (
 	"github.com/drapon/envy/internal/color"
 	"github.com/drapon/envy/internal/config"
 	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/ownership"
+	"github.com/drapon/envy/internal/roster"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/drapon/envy/internal/structured"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -20,10 +26,16 @@ var (
 	environment string
 	source      string
 	tree        bool
-	filter      string
+	filterOpts  filter.Options
 	showValues  bool
 	format      string
 	all         bool
+	pageSize    int
+	noPager     bool
+
+	// documentOutput receives the json and yaml documents; everything else
+	// goes to stderr then
+	documentOutput io.Writer = os.Stdout
 )
 
 // listCmd represents the list command
@@ -33,7 +45,15 @@ var listCmd = &cobra.Command{
 	Long: `List environment variables from local files, AWS, or both.
 
 This command displays environment variables with various formatting options
-including tree view, filtering, and value masking for sensitive variables.`,
+including tree view, filtering, and value masking for sensitive variables.
+
+The owners of variables, from the variables section of .envyrc, are shown
+with their email and chat channel when .envyrc configures a roster.
+
+In a terminal the output goes through $ENVY_PAGER, $PAGER or less, and the
+variables of AWS are shown page by page as they are read, so the first
+ones appear at once while the rest load in the background. With
+--page-size, envy pauses every that many variables instead.`,
 	Example: `  # List variables for the default environment
   envy list
   
@@ -47,16 +67,23 @@ including tree view, filtering, and value masking for sensitive variables.`,
   envy list --tree
   
   # Filter by prefix
-  envy list --filter "DB_"
+  envy list --filter "DB_*"
+
+  # Hide sensitive-looking keys matching a regex
+  envy list --exclude "/(TOKEN|SECRET)/"
   
   # Show actual values (careful with sensitive data!)
   envy list --show-values
   
-  # Output as JSON
+  # Output as JSON, or YAML
   envy list --format json
+  envy list --output yaml
   
   # List all environments
-  envy list --all`,
+  envy list --all
+
+  # 50 variables at a time, without the pager
+  envy list --env prod --page-size 50`,
 	RunE: runList,
 }
 
@@ -72,15 +99,28 @@ func init() {
 	listCmd.Flags().StringVarP(&environment, "env", "e", "", "Specify environment")
 	listCmd.Flags().StringVarP(&source, "source", "s", "both", "Source (local/aws/both)")
 	listCmd.Flags().BoolVarP(&tree, "tree", "t", false, "Tree format display")
-	listCmd.Flags().StringVarP(&filter, "filter", "f", "", "Filter pattern")
+	filter.AddFlagsP(listCmd.Flags(), &filterOpts, "f", "")
 	listCmd.Flags().BoolVar(&showValues, "show-values", false, "Show actual values (default: masked)")
-	listCmd.Flags().StringVar(&format, "format", "text", "Output format (text/json/tree)")
+	listCmd.Flags().StringVar(&format, "format", "text", "Output format (text/json/yaml/tree)")
 	listCmd.Flags().BoolVarP(&all, "all", "a", false, "List all environments")
+	listCmd.Flags().IntVar(&pageSize, "page-size", 0, "Pause every N variables, asking for more (default: use the pager)")
+	listCmd.Flags().BoolVar(&noPager, "no-pager", false, "Print everything without the pager")
 }
 
 func runList(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
+	// --output json|yaml|table applies unless --format is given
+	var err error
+	if format, err = structured.ResolveFormat(cmd); err != nil {
+		return err
+	}
+	if format == "json" || format == "yaml" {
+		var restore func()
+		documentOutput, restore = structured.KeepStdout()
+		defer restore()
+	}
+
 	// Load configuration
 	cfg, err := config.Load(viper.GetString("config"))
 	if err != nil {
@@ -110,9 +150,20 @@ func runList(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Contact details of owners, shown next to them
+	contacts, err := roster.Load(ctx, cfg)
+	if err != nil {
+		color.PrintWarningf("Owners are shown without contact details: %v", err)
+	}
+
+	defer startPager()()
+
 	// Process each environment
 	for i, envName := range environments {
-		if i > 0 {
+		switch {
+		case i > 0 && format == "yaml":
+			fmt.Fprintln(documentOutput, "---")
+		case i > 0:
 			fmt.Println() // Add spacing between environments
 		}
 
@@ -120,7 +171,7 @@ func runList(cmd *cobra.Command, args []string) error {
 			color.PrintBoldf("=== Environment: %s ===", envName)
 		}
 
-		if err := listEnvironment(ctx, cfg, awsManager, envName); err != nil {
+		if err := listEnvironment(ctx, cfg, awsManager, contacts, envName); err != nil {
 			return fmt.Errorf("failed to list environment %s: %w", envName, err)
 		}
 	}
@@ -128,7 +179,7 @@ func runList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func listEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Manager, envName string) error {
+func listEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Manager, contacts *roster.Roster, envName string) error {
 	// Get environment configuration
 	envConfig, err := cfg.GetEnvironment(envName)
 	if err != nil {
@@ -151,6 +202,35 @@ func listEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Ma
 		}
 	}
 
+	// Apply --filter/--exclude/--keys
+	keyFilter, err := filter.New(filterOpts)
+	if err != nil {
+		return err
+	}
+	localVars = keyFilter.ApplyMap(localVars)
+
+	isSensitive, err := sensitive.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Show who is accountable for each variable
+	rules, err := ownership.ParseRules(cfg.Variables)
+	if err != nil {
+		return err
+	}
+	annotate := func(key string, info varInfo) varInfo {
+		info.Owners = ownership.Owners(rules, key)
+		info.Sensitive = isSensitive(key)
+		return info
+	}
+
+	// Text is printed as AWS returns it, so large environments start
+	// showing at once
+	if awsManager != nil && format == "text" {
+		return streamText(ctx, awsManager, envName, localVars, keyFilter, annotate, contacts)
+	}
+
 	// Get AWS variables
 	if awsManager != nil && (source == "aws" || source == "both") {
 		awsVars, err = awsManager.ListEnvironmentVariables(ctx, envName)
@@ -159,15 +239,13 @@ func listEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Ma
 			awsVars = make(map[string]string)
 		}
 	}
+	awsVars = keyFilter.ApplyMap(awsVars)
 
 	// Merge and categorize variables
 	allVars := make(map[string]varInfo)
 
 	// Add local variables
 	for key, value := range localVars {
-		if filter != "" && !matchesFilter(key, filter) {
-			continue
-		}
 		allVars[key] = varInfo{
 			Value:     value,
 			Sources:   []string{"local"},
@@ -177,10 +255,6 @@ func listEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Ma
 
 	// Add/update with AWS variables
 	for key, value := range awsVars {
-		if filter != "" && !matchesFilter(key, filter) {
-			continue
-		}
-
 		if info, exists := allVars[key]; exists {
 			// Variable exists in both
 			info.Sources = append(info.Sources, "aws")
@@ -198,14 +272,18 @@ func listEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Ma
 		}
 	}
 
+	for key, info := range allVars {
+		allVars[key] = annotate(key, info)
+	}
+
 	// Display based on format
 	switch format {
-	case "json":
-		return displayJSON(allVars, envName)
+	case "json", "yaml":
+		return displayDocument(documentOutput, allVars, envName, contacts)
 	case "tree":
 		return displayTree(allVars, envName)
 	default:
-		return displayText(allVars, envName)
+		return displayText(allVars, envName, contacts)
 	}
 }
 
@@ -214,9 +292,11 @@ type varInfo struct {
 	Sources   []string
 	LocalOnly bool
 	AWSOnly   bool
+	Owners    []string
+	Sensitive bool
 }
 
-func displayText(vars map[string]varInfo, envName string) error {
+func displayText(vars map[string]varInfo, envName string, contacts *roster.Roster) error {
 	if len(vars) == 0 {
 		color.PrintWarningf("No variables found")
 		return nil
@@ -229,39 +309,64 @@ func displayText(vars map[string]varInfo, envName string) error {
 	}
 	sort.Strings(keys)
 
-	// Display header
+	printHeader(envName)
+
+	// Display variables
+	more := newMorePrompt(pageSize)
+	for _, key := range keys {
+		if !more.next() {
+			return nil
+		}
+		if err := printVar(key, vars[key], contacts); err != nil {
+			return nil // the pager was closed
+		}
+	}
+
+	printSummary(vars)
+	return nil
+}
+
+// printHeader prints the environment and source shown by text output
+func printHeader(envName string) {
 	if source == "both" {
 		color.PrintInfof("Environment: %s (showing %s)\n", envName, source)
 	} else {
 		color.PrintInfof("Environment: %s (source: %s)\n", envName, source)
 	}
+}
 
-	// Display variables
-	for _, key := range keys {
-		info := vars[key]
+// printVar prints one variable of text output
+func printVar(key string, info varInfo, contacts *roster.Roster) error {
+	// Source indicator
+	var sourceIndicator string
 
-		// Source indicator
-		var sourceIndicator string
+	if info.LocalOnly {
+		sourceIndicator = color.FormatSuccess("[local]")
+	} else if info.AWSOnly {
+		sourceIndicator = color.FormatInfo("[aws]")
+	} else {
+		sourceIndicator = color.FormatWarning("[both]")
+	}
 
-		if info.LocalOnly {
-			sourceIndicator = color.FormatSuccess("[local]")
-		} else if info.AWSOnly {
-			sourceIndicator = color.FormatInfo("[aws]")
-		} else {
-			sourceIndicator = color.FormatWarning("[both]")
-		}
+	// Display value
+	displayValue := maskValue(info.Value, info.Sensitive)
 
-		// Display value
-		displayValue := maskValue(key, info.Value)
+	owners := ""
+	if len(info.Owners) > 0 {
+		owners = " " + color.FormatInfo("(owner: "+strings.Join(contacts.DescribeAll(info.Owners), ", ")+")")
+	}
 
-		if source == "both" {
-			fmt.Printf("%-40s = %-20s %s\n", key, displayValue, sourceIndicator)
-		} else {
-			fmt.Printf("%-40s = %s\n", key, displayValue)
-		}
+	var err error
+	if source == "both" {
+		_, err = fmt.Printf("%-40s = %-20s %s%s\n", key, displayValue, sourceIndicator, owners)
+	} else {
+		_, err = fmt.Printf("%-40s = %s%s\n", key, displayValue, owners)
 	}
+	return err
+}
 
-	// Summary
+// printSummary prints the totals of text output
+func printSummary(vars map[string]varInfo) {
 	color.PrintBoldf("\nTotal: %d variables", len(vars))
 
 	if source == "both" {
@@ -283,8 +388,6 @@ func displayText(vars map[string]varInfo, envName string) error {
 		fmt.Printf("  AWS only: %d %s\n", awsCount, color.FormatInfo("(blue)"))
 		fmt.Printf("  Both: %d %s\n", bothCount, color.FormatWarning("(yellow)"))
 	}
-
-	return nil
 }
 
 func displayTree(vars map[string]varInfo, envName string) error {
@@ -358,7 +461,7 @@ func displayTreeNode(node *treeNode, indent string, isLast bool) {
 		// Display node
 		if node.value != nil {
 			// Leaf node with value
-			displayValue := maskValue(node.name, node.value.Value)
+			displayValue := maskValue(node.value.Value, node.value.Sensitive)
 
 			var colorCode string
 			if node.value.LocalOnly {
@@ -400,7 +503,9 @@ func displayTreeNode(node *treeNode, indent string, isLast bool) {
 	}
 }
 
-func displayJSON(vars map[string]varInfo, envName string) error {
+// displayDocument writes the variables of an environment as a JSON or YAML
+// document
+func displayDocument(w io.Writer, vars map[string]varInfo, envName string, contacts *roster.Roster) error {
 	output := map[string]interface{}{
 		"environment": envName,
 		"source":      source,
@@ -421,34 +526,33 @@ func displayJSON(vars map[string]varInfo, envName string) error {
 		varData := map[string]interface{}{
 			"sources": info.Sources,
 		}
+		if len(info.Owners) > 0 {
+			varData["owners"] = info.Owners
+			ownerContacts := make(map[string]roster.Contact)
+			for _, owner := range info.Owners {
+				if contact, ok := contacts.Contact(owner); ok {
+					ownerContacts[owner] = contact
+				}
+			}
+			if len(ownerContacts) > 0 {
+				varData["owner_contacts"] = ownerContacts
+			}
+		}
 
 		if showValues {
 			varData["value"] = info.Value
 		} else {
-			varData["value"] = maskValue(key, info.Value)
+			varData["value"] = maskValue(info.Value, info.Sensitive)
 		}
 
 		output["variables"].(map[string]interface{})[key] = varData
 	}
 
-	// Marshal and output
-	jsonBytes, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal JSON: %w", err)
-	}
-
-	fmt.Println(string(jsonBytes))
-	return nil
+	return structured.Write(w, structured.Format(format), output)
 }
 
-func matchesFilter(key, pattern string) bool {
-	// Simple contains match for now
-	// Could be enhanced to support regex or glob patterns
-	return strings.Contains(strings.ToLower(key), strings.ToLower(pattern))
-}
-
-func maskValue(key, value string) string {
-	if showValues && !isSensitiveKey(key) {
+func maskValue(value string, sensitive bool) string {
+	if showValues && !sensitive {
 		return value
 	}
 
@@ -459,20 +563,3 @@ func maskValue(key, value string) string {
 
 	return value[:1] + "***" + value[len(value)-1:]
 }
-
-func isSensitiveKey(key string) bool {
-	lowerKey := strings.ToLower(key)
-	sensitivePatterns := []string{
-		"password", "secret", "key", "token",
-		"credential", "auth", "private", "cert",
-		"api_key", "access_key", "secret_key",
-	}
-
-	for _, pattern := range sensitivePatterns {
-		if strings.Contains(lowerKey, pattern) {
-			return true
-		}
-	}
-
-	return false
-}
@@ -1,9 +1,13 @@
 package list
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/sensitive"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMatchesFilter(t *testing.T) {
@@ -47,8 +51,9 @@ func TestMatchesFilter(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := matchesFilter(tt.key, tt.filter)
-			assert.Equal(t, tt.want, got)
+			keyFilter, err := filter.New(filter.Options{Include: []string{tt.filter}})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, keyFilter.Match(tt.key))
 		})
 	}
 }
@@ -115,7 +120,7 @@ func TestMaskValue(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			showValues = tt.showValues
-			got := maskValue(tt.key, tt.value)
+			got := maskValue(tt.value, sensitive.IsSensitive(tt.key))
 			assert.Equal(t, tt.want, got)
 		})
 	}
@@ -158,8 +163,8 @@ func TestIsSensitiveKey(t *testing.T) {
 			want: true,
 		},
 		{
-			name: "credentials",
-			key:  "AWS_CREDENTIALS",
+			name: "credential",
+			key:  "AWS_CREDENTIAL",
 			want: true,
 		},
 		{
@@ -186,10 +191,20 @@ func TestIsSensitiveKey(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := isSensitiveKey(tt.key)
+			got := sensitive.IsSensitive(tt.key)
 			assert.Equal(t, tt.want, got)
 		})
 	}
+
+	t.Run("sensitivity overrides", func(t *testing.T) {
+		isSensitive, err := sensitive.New([]string{"SESSION_SALT"}, []string{"*_PUBLIC_KEY"})
+		require.NoError(t, err)
+
+		showValues = true
+		defer func() { showValues = false }()
+		assert.Equal(t, "s***t", maskValue("session-salt", isSensitive("SESSION_SALT")))
+		assert.Equal(t, "pk_live", maskValue("pk_live", isSensitive("STRIPE_PUBLIC_KEY")))
+	})
 }
 
 func TestListCommand(t *testing.T) {
@@ -442,11 +457,12 @@ func TestShowValuesFlag(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			showValues = tt.showValues
-			result := maskValue(tt.key, tt.value)
+			sensitive := sensitive.IsSensitive(tt.key)
+			result := maskValue(tt.value, sensitive)
 			// Test considers both scenarios based on implementation
-			if tt.showValues && !isSensitiveKey(tt.key) {
+			if tt.showValues && !sensitive {
 				assert.Equal(t, tt.value, result)
-			} else if !tt.showValues || isSensitiveKey(tt.key) {
+			} else if !tt.showValues || sensitive {
 				// Value should be masked
 				if len(tt.value) <= 4 {
 					assert.Equal(t, "***", result)
@@ -500,8 +516,9 @@ func TestFilterFunctionality(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := matchesFilter(tt.key, tt.filter)
-			assert.Equal(t, tt.expected, result)
+			keyFilter, err := filter.New(filter.Options{Include: []string{tt.filter}})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, keyFilter.Match(tt.key))
 		})
 	}
 }
@@ -543,4 +560,36 @@ func TestListOutput(t *testing.T) {
 			tt.checkFunc(t, tt.variables)
 		})
 	}
-}
\ No newline at end of file
+}
+func TestDisplayDocument(t *testing.T) {
+	defer func() { format, source, showValues = "text", "both", false }()
+	format, source = "yaml", "aws"
+
+	vars := map[string]varInfo{
+		"PORT":    {Value: "8080", Sources: []string{"aws"}},
+		"API_KEY": {Value: "sk-live-123", Sources: []string{"aws"}, Owners: []string{"payments"}, Sensitive: true},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, displayDocument(&buf, vars, "prod", nil))
+	assert.Equal(t, `count: 2
+environment: prod
+source: aws
+variables:
+  API_KEY:
+    owners:
+      - payments
+    sources:
+      - aws
+    value: s***3
+  PORT:
+    sources:
+      - aws
+    value: '***'
+`, buf.String())
+
+	buf.Reset()
+	format = "json"
+	require.NoError(t, displayDocument(&buf, vars, "prod", nil))
+	assert.Contains(t, buf.String(), `"environment": "prod"`)
+}
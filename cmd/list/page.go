@@ -0,0 +1,209 @@
+package list
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/roster"
+	"golang.org/x/term"
+)
+
+const (
+	// pageInterval spaces the requests for the pages of an environment,
+	// so listing thousands of variables leaves GetParametersByPath
+	// throughput for the applications sharing the account
+	pageInterval = 50 * time.Millisecond
+
+	// defaultPager shows the output a screen at a time, exits at once if it
+	// fits on one, and keeps the colors
+	defaultPager = "less -FRX"
+)
+
+// streamText prints the variables of an environment as the pages of AWS
+// arrive, then those only in local files. Pages are read in the background
+// while the first ones are shown, and the listing stops when the user
+// quits the pager.
+func streamText(ctx context.Context, awsManager *aws.Manager, envName string, localVars map[string]string, keyFilter *filter.Filter, annotate func(string, varInfo) varInfo, contacts *roster.Roster) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pages := make(chan map[string]string, 1000)
+	done := make(chan error, 1)
+	go func() {
+		defer close(pages)
+		done <- fetchPages(ctx, pageInterval, pages, func(fn func(map[string]string) error) error {
+			return awsManager.ListEnvironmentVariablesPages(ctx, envName, fn)
+		})
+	}()
+
+	printHeader(envName)
+	shown, err := printPages(pages, localVars, keyFilter, annotate, contacts, newMorePrompt(pageSize))
+	if err != nil {
+		return nil // the user quit the pager
+	}
+
+	if err := <-done; err != nil {
+		color.PrintWarningf("Failed to load AWS variables: %v", err)
+	}
+	if len(shown) == 0 {
+		color.PrintWarningf("No variables found")
+		return nil
+	}
+	printSummary(shown)
+	return nil
+}
+
+// fetchPages sends the pages of list to pages, waiting interval between
+// requests
+func fetchPages(ctx context.Context, interval time.Duration, pages chan<- map[string]string, list func(fn func(map[string]string) error) error) error {
+	limiter := time.NewTicker(interval)
+	defer limiter.Stop()
+
+	return list(func(page map[string]string) error {
+		select {
+		case pages <- page:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		select {
+		case <-limiter.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return nil
+	})
+}
+
+// printPages prints the variables of each page, sorted within the page,
+// then the local ones AWS did not have. It returns the variables shown, or
+// an error once the user stops the output.
+func printPages(pages <-chan map[string]string, localVars map[string]string, keyFilter *filter.Filter, annotate func(string, varInfo) varInfo, contacts *roster.Roster, more *morePrompt) (map[string]varInfo, error) {
+	shown := make(map[string]varInfo)
+	show := func(key string, info varInfo) error {
+		if !more.next() {
+			return io.EOF
+		}
+		info = annotate(key, info)
+		shown[key] = info
+		return printVar(key, info, contacts)
+	}
+
+	for page := range pages {
+		page = keyFilter.ApplyMap(page)
+		for _, key := range sortedKeys(page) {
+			info := varInfo{Value: page[key], Sources: []string{"aws"}, AWSOnly: true}
+			if _, ok := localVars[key]; ok {
+				info = varInfo{Value: page[key], Sources: []string{"local", "aws"}}
+			}
+			if err := show(key, info); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, key := range sortedKeys(localVars) {
+		if _, ok := shown[key]; ok {
+			continue
+		}
+		if err := show(key, varInfo{Value: localVars[key], Sources: []string{"local"}, LocalOnly: true}); err != nil {
+			return nil, err
+		}
+	}
+	return shown, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// morePrompt pauses the output every size variables until the user asks
+// for more, like more(1). With size 0 it never pauses.
+type morePrompt struct {
+	size  int
+	shown int
+	in    *bufio.Reader
+	out   io.Writer
+}
+
+// newMorePrompt pauses every size variables when both standard input and
+// output are a terminal
+func newMorePrompt(size int) *morePrompt {
+	if size <= 0 || !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return &morePrompt{}
+	}
+	return &morePrompt{size: size, in: bufio.NewReader(os.Stdin), out: os.Stdout}
+}
+
+// next reports whether to print another variable, asking first at the end
+// of a page
+func (p *morePrompt) next() bool {
+	if p.size > 0 && p.shown > 0 && p.shown%p.size == 0 {
+		fmt.Fprint(p.out, color.FormatInfo(fmt.Sprintf("-- %d shown; Enter for more, q to quit -- ", p.shown)))
+		answer, err := p.in.ReadString('\n')
+		if err != nil || strings.EqualFold(strings.TrimSpace(answer), "q") {
+			return false
+		}
+	}
+	p.shown++
+	return true
+}
+
+// startPager sends standard output through $ENVY_PAGER, $PAGER or less
+// when it is a terminal. The returned function closes the pager and waits
+// for the user to quit it. Without a usable pager the output is printed
+// as is.
+func startPager() func() {
+	if noPager || pageSize > 0 || format == "json" || format == "yaml" || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return func() {}
+	}
+
+	command := os.Getenv("ENVY_PAGER")
+	if command == "" {
+		command = os.Getenv("PAGER")
+	}
+	if command == "" {
+		command = defaultPager
+	}
+	fields := strings.Fields(command)
+	if len(fields) == 0 || fields[0] == "cat" {
+		return func() {}
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return func() {}
+	}
+	pager := exec.Command(fields[0], fields[1:]...)
+	pager.Stdin = r
+	pager.Stdout = os.Stdout
+	pager.Stderr = os.Stderr
+	if err := pager.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return func() {}
+	}
+	r.Close()
+
+	stdout := os.Stdout
+	os.Stdout = w
+	return func() {
+		os.Stdout = stdout
+		w.Close()
+		_ = pager.Wait()
+	}
+}
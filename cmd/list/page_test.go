@@ -0,0 +1,84 @@
+package list
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drapon/envy/internal/filter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchPages(t *testing.T) {
+	list := func(fn func(map[string]string) error) error {
+		for _, page := range []map[string]string{{"A": "1"}, {"B": "2"}, {"C": "3"}} {
+			if err := fn(page); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	pages := make(chan map[string]string, 10)
+	start := time.Now()
+	require.NoError(t, fetchPages(context.Background(), 20*time.Millisecond, pages, list))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond, "requests are spaced")
+	assert.Len(t, pages, 3)
+
+	// Stops once nobody reads the pages any more
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := fetchPages(ctx, time.Millisecond, make(chan map[string]string), list)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestPrintPages(t *testing.T) {
+	defer func() { source = "both" }()
+	source = "both"
+
+	keyFilter, err := filter.New(filter.Options{Exclude: []string{"SKIP_*"}})
+	require.NoError(t, err)
+	annotate := func(key string, info varInfo) varInfo { return info }
+
+	pages := make(chan map[string]string, 2)
+	pages <- map[string]string{"B": "aws-b", "A": "aws-a", "SKIP_ME": "x"}
+	pages <- map[string]string{"C": "aws-c"}
+	close(pages)
+
+	shown, err := printPages(pages, map[string]string{"A": "local-a", "D": "local-d"}, keyFilter, annotate, nil, &morePrompt{})
+	require.NoError(t, err)
+	assert.Len(t, shown, 4)
+	assert.Equal(t, []string{"local", "aws"}, shown["A"].Sources)
+	assert.Equal(t, "aws-a", shown["A"].Value)
+	assert.True(t, shown["B"].AWSOnly)
+	assert.True(t, shown["D"].LocalOnly)
+	assert.NotContains(t, shown, "SKIP_ME")
+}
+
+func TestMorePrompt(t *testing.T) {
+	var out strings.Builder
+	more := &morePrompt{size: 2, in: bufio.NewReader(strings.NewReader("\nq\n")), out: &out}
+
+	var printed int
+	for more.next() {
+		printed++
+	}
+	assert.Equal(t, 4, printed, "one page, Enter for the next, then q")
+	assert.Contains(t, out.String(), "-- 2 shown; Enter for more, q to quit --")
+	assert.Contains(t, out.String(), "-- 4 shown;")
+
+	// Without paging everything is shown
+	more = &morePrompt{}
+	for i := 0; i < 100; i++ {
+		require.True(t, more.next())
+	}
+
+	// The output stops at the end of input
+	more = &morePrompt{size: 1, in: bufio.NewReader(strings.NewReader("")), out: io.Discard}
+	assert.True(t, more.next())
+	assert.False(t, more.next())
+}
@@ -0,0 +1,68 @@
+package login
+
+import (
+	"context"
+	"os"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	profile   string
+	noBrowser bool
+)
+
+// loginCmd represents the login command
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in to AWS SSO (IAM Identity Center)",
+	Long: `Log in to AWS SSO with the device authorization flow, without the AWS CLI.
+
+envy shows a code and opens the AWS sign-in page, where you confirm it. The
+token is cached in ~/.aws/sso/cache like 'aws sso login' does, so envy, the
+AWS CLI and SDKs all use it until it expires. Profiles with an sso_session
+can refresh it without logging in again.
+
+The profile is --profile, else aws.profile of .envyrc, else AWS_PROFILE,
+else default. Other commands offer this login when the credentials of an
+SSO profile have expired.`,
+	Example: `  # Log in with the profile of .envyrc
+  envy login
+
+  # Log in with another profile, printing the URL instead of opening it
+  envy login --profile prod-admin --no-browser`,
+	Args: cobra.NoArgs,
+	RunE: runLogin,
+}
+
+// GetLoginCmd returns the login command.
+func GetLoginCmd() *cobra.Command {
+	return loginCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(loginCmd)
+
+	loginCmd.Flags().StringVarP(&profile, "profile", "p", "", "AWS profile to log in with")
+	loginCmd.Flags().BoolVar(&noBrowser, "no-browser", false, "Print the verification URL instead of opening a browser")
+}
+
+func runLogin(cmd *cobra.Command, args []string) error {
+	return aws.LoginSSO(context.Background(), loginProfile(), !noBrowser)
+}
+
+// loginProfile returns --profile, aws.profile of .envyrc or AWS_PROFILE.
+// Like the AWS clients, it leaves a "default" aws.profile to AWS_PROFILE.
+func loginProfile() string {
+	if profile != "" {
+		return profile
+	}
+	if cfg, err := config.Load(viper.GetString("config")); err == nil && cfg.AWS.Profile != "" && cfg.AWS.Profile != "default" {
+		return cfg.AWS.Profile
+	}
+	return os.Getenv("AWS_PROFILE")
+}
@@ -0,0 +1,30 @@
+package login
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLoginCmd(t *testing.T) {
+	cmd := GetLoginCmd()
+	assert.Equal(t, "login", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("profile"))
+	assert.NotNil(t, cmd.Flags().Lookup("no-browser"))
+}
+
+func TestLoginProfile(t *testing.T) {
+	defer func() { profile = "" }()
+	t.Setenv("AWS_PROFILE", "from-env")
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(wd)
+	require.NoError(t, os.Chdir(t.TempDir()))
+
+	assert.Equal(t, "from-env", loginProfile(), "without .envyrc")
+
+	profile = "prod-admin"
+	assert.Equal(t, "prod-admin", loginProfile())
+}
@@ -0,0 +1,291 @@
+package migratevars
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/precheck"
+	"github.com/drapon/envy/internal/prompt"
+	"github.com/drapon/envy/internal/validator"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	environment string
+	rulesFile   string
+	dryRun      bool
+	force       bool
+)
+
+// migrateVarsCmd represents the migrate-vars command
+var migrateVarsCmd = &cobra.Command{
+	Use:   "migrate-vars",
+	Short: "Move deprecated variables to their replacements in AWS",
+	Long: `Move every deprecated variable that has a replacement in the validation
+rules to its new name in AWS. The value is copied to the replacement (keeping
+the parameter type), read back to verify it, and only then is the deprecated
+key deleted.
+
+When the replacement already holds the same value, the deprecated key is
+just deleted. When it holds a different value, the variable is left alone and
+reported as a conflict to resolve by hand.
+
+Deprecations are declared in .envy-rules.yaml:
+
+  warnings:
+    - name: DB_HOST
+      replacement: DATABASE_HOST
+      sunset: 2026-06-30
+
+'envy validate' warns about DB_HOST until the sunset date and fails from
+then on.`,
+	Example: `  # Preview the migration in every environment
+  envy migrate-vars --env all --dry-run
+
+  # Migrate production without prompting
+  envy migrate-vars --env prod --force`,
+	Args: cobra.NoArgs,
+	RunE: runMigrateVars,
+}
+
+// GetMigrateVarsCmd returns the migrate-vars command.
+func GetMigrateVarsCmd() *cobra.Command {
+	return migrateVarsCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(migrateVarsCmd)
+
+	migrateVarsCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to migrate ('all' for every environment)")
+	migrateVarsCmd.Flags().StringVarP(&rulesFile, "rules", "r", ".envy-rules.yaml", "Validation rules file declaring the deprecations")
+	migrateVarsCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be migrated without making changes")
+	migrateVarsCmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation prompt")
+}
+
+// remoteStore is the part of the AWS manager a migration uses
+type remoteStore interface {
+	GetVariables(ctx context.Context, envName string, keys []string) (map[string]string, error)
+	RenameVariable(ctx context.Context, envName, oldKey, newKey string) error
+	ApplyChanges(ctx context.Context, envName string, set *env.File, deleted []string) error
+}
+
+// outcome is what migrating one variable in one environment did, or would do
+type outcome int
+
+const (
+	outcomeAbsent   outcome = iota // the deprecated key is not set
+	outcomeMoved                   // copied to the replacement and deleted
+	outcomeRemoved                 // deleted, the replacement already had its value
+	outcomeConflict                // both are set with different values
+)
+
+func runMigrateVars(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	// Load configuration
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	environments, err := resolveEnvironments(cfg, environment)
+	if err != nil {
+		return err
+	}
+
+	plan := make(map[string][]validator.WarningRule, len(environments))
+	total := 0
+	for _, envName := range environments {
+		rules, err := validator.LoadRulesForEnvironment(rulesFile, envName)
+		if err != nil {
+			return fmt.Errorf("failed to load validation rules: %w", err)
+		}
+		plan[envName] = rules.Deprecations()
+		total += len(plan[envName])
+	}
+	if total == 0 {
+		color.PrintInfof("No deprecated variables with a replacement in %s", rulesFile)
+		return nil
+	}
+
+	if !dryRun && !force && !confirmMigration(environments) {
+		color.PrintWarningf("Migration cancelled")
+		return nil
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	// A migration writes both the deprecated key and its replacement
+	if !dryRun {
+		var keys []string
+		for _, envName := range environments {
+			for _, deprecation := range plan[envName] {
+				keys = append(keys, deprecation.Name, deprecation.Replacement)
+			}
+		}
+		if err := precheck.Writers(ctx, cfg, awsManager, keys); err != nil {
+			return err
+		}
+	}
+
+	migrated, conflicts := 0, 0
+	for _, envName := range environments {
+		color.PrintInfof("Environment: %s", envName)
+
+		for _, deprecation := range plan[envName] {
+			result, err := migrate(ctx, awsManager, envName, deprecation)
+			if err != nil {
+				return fmt.Errorf("failed to migrate %s in %s: %w", deprecation.Name, envName, err)
+			}
+			report(result, deprecation)
+
+			switch result {
+			case outcomeMoved, outcomeRemoved:
+				migrated++
+			case outcomeConflict:
+				conflicts++
+			}
+
+			reportLocal(cfg, envName, deprecation)
+		}
+	}
+
+	if dryRun {
+		color.PrintInfof("Dry run: no changes were made")
+	} else if migrated > 0 {
+		color.PrintSuccessf("Migrated %d variable(s)", migrated)
+	} else {
+		color.PrintInfof("Nothing to migrate")
+	}
+
+	if conflicts > 0 {
+		return fmt.Errorf("%d variable(s) could not be migrated because the replacement holds a different value", conflicts)
+	}
+	return nil
+}
+
+// migrate moves a deprecated variable to its replacement in one
+// environment. With --dry-run it only reports what it would do.
+func migrate(ctx context.Context, store remoteStore, envName string, deprecation validator.WarningRule) (outcome, error) {
+	oldKey, newKey := deprecation.Name, deprecation.Replacement
+
+	vars, err := store.GetVariables(ctx, envName, []string{oldKey, newKey})
+	if err != nil {
+		return outcomeAbsent, err
+	}
+
+	oldValue, ok := vars[oldKey]
+	if !ok {
+		return outcomeAbsent, nil
+	}
+
+	if newValue, exists := vars[newKey]; exists {
+		if newValue != oldValue {
+			return outcomeConflict, nil
+		}
+		if !dryRun {
+			if err := store.ApplyChanges(ctx, envName, nil, []string{oldKey}); err != nil {
+				return outcomeAbsent, err
+			}
+		}
+		return outcomeRemoved, nil
+	}
+
+	if dryRun {
+		return outcomeMoved, nil
+	}
+
+	err = store.RenameVariable(ctx, envName, oldKey, newKey)
+	switch {
+	case errors.Is(err, aws.ErrKeyNotFound):
+		// Migrated by someone else in the meantime
+		return outcomeAbsent, nil
+	case errors.Is(err, aws.ErrKeyExists):
+		return outcomeConflict, nil
+	case err != nil:
+		return outcomeAbsent, err
+	}
+	return outcomeMoved, nil
+}
+
+func report(result outcome, deprecation validator.WarningRule) {
+	oldKey, newKey := deprecation.Name, deprecation.Replacement
+
+	switch result {
+	case outcomeAbsent:
+		color.PrintInfof("  %s: not set, skipping", oldKey)
+	case outcomeMoved:
+		if dryRun {
+			color.PrintInfof("  would move %s → %s", oldKey, newKey)
+		} else {
+			color.PrintSuccessf("  moved %s → %s (verified)", oldKey, newKey)
+		}
+	case outcomeRemoved:
+		if dryRun {
+			color.PrintInfof("  would delete %s, %s already has its value", oldKey, newKey)
+		} else {
+			color.PrintSuccessf("  deleted %s, %s already has its value", oldKey, newKey)
+		}
+	case outcomeConflict:
+		color.PrintWarningf("  %s and %s are both set with different values, skipping", oldKey, newKey)
+	}
+}
+
+// reportLocal points out local env files that still set the deprecated
+// variable. They are not rewritten; envy rename --local-only does that.
+func reportLocal(cfg *config.Config, envName string, deprecation validator.WarningRule) {
+	envConfig, err := cfg.GetEnvironment(envName)
+	if err != nil {
+		return
+	}
+
+	for _, filename := range envConfig.Files {
+		if _, err := os.Stat(filename); err != nil {
+			continue
+		}
+		file, err := env.ParseFile(filename)
+		if err != nil {
+			continue
+		}
+		if _, ok := file.Get(deprecation.Name); ok {
+			color.PrintWarningf("  %s still sets %s; run 'envy rename %s %s --env %s --local-only'",
+				filename, deprecation.Name, deprecation.Name, deprecation.Replacement, envName)
+		}
+	}
+}
+
+func resolveEnvironments(cfg *config.Config, envName string) ([]string, error) {
+	if envName == "all" {
+		var names []string
+		for name := range cfg.Environments {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+
+	if envName == "" {
+		envName = cfg.DefaultEnvironment
+	}
+	if _, err := cfg.GetEnvironment(envName); err != nil {
+		return nil, err
+	}
+	return []string{envName}, nil
+}
+
+func confirmMigration(environments []string) bool {
+	return prompt.Confirm(color.FormatWarning(fmt.Sprintf("About to move deprecated variables to their replacements in %s.", strings.Join(environments, ", "))) + " Continue?")
+}
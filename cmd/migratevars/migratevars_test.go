@@ -0,0 +1,120 @@
+package migratevars
+
+import (
+	"context"
+	"testing"
+
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore keeps one environment's variables in memory
+type fakeStore struct {
+	vars    map[string]string
+	renamed []string
+	deleted []string
+}
+
+func (f *fakeStore) GetVariables(ctx context.Context, envName string, keys []string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, key := range keys {
+		if value, ok := f.vars[key]; ok {
+			vars[key] = value
+		}
+	}
+	return vars, nil
+}
+
+func (f *fakeStore) RenameVariable(ctx context.Context, envName, oldKey, newKey string) error {
+	value, ok := f.vars[oldKey]
+	if !ok {
+		return aws.ErrKeyNotFound
+	}
+	if _, exists := f.vars[newKey]; exists {
+		return aws.ErrKeyExists
+	}
+	f.vars[newKey] = value
+	delete(f.vars, oldKey)
+	f.renamed = append(f.renamed, oldKey)
+	return nil
+}
+
+func (f *fakeStore) ApplyChanges(ctx context.Context, envName string, set *env.File, deleted []string) error {
+	for _, key := range deleted {
+		delete(f.vars, key)
+	}
+	f.deleted = append(f.deleted, deleted...)
+	return nil
+}
+
+func TestGetMigrateVarsCmd(t *testing.T) {
+	cmd := GetMigrateVarsCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "migrate-vars", cmd.Use)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.NotNil(t, cmd.Flags().Lookup("env"))
+	assert.NotNil(t, cmd.Flags().Lookup("rules"))
+	assert.NotNil(t, cmd.Flags().Lookup("dry-run"))
+	assert.NotNil(t, cmd.Flags().Lookup("force"))
+}
+
+func TestMigrate(t *testing.T) {
+	deprecation := validator.WarningRule{Name: "DB_HOST", Replacement: "DATABASE_HOST"}
+
+	tests := []struct {
+		name     string
+		vars     map[string]string
+		dryRun   bool
+		want     outcome
+		wantVars map[string]string
+	}{
+		{
+			name:     "moved",
+			vars:     map[string]string{"DB_HOST": "db"},
+			want:     outcomeMoved,
+			wantVars: map[string]string{"DATABASE_HOST": "db"},
+		},
+		{
+			name:     "replacement already set",
+			vars:     map[string]string{"DB_HOST": "db", "DATABASE_HOST": "db"},
+			want:     outcomeRemoved,
+			wantVars: map[string]string{"DATABASE_HOST": "db"},
+		},
+		{
+			name:     "conflict",
+			vars:     map[string]string{"DB_HOST": "old", "DATABASE_HOST": "new"},
+			want:     outcomeConflict,
+			wantVars: map[string]string{"DB_HOST": "old", "DATABASE_HOST": "new"},
+		},
+		{
+			name:     "absent",
+			vars:     map[string]string{"DATABASE_HOST": "db"},
+			want:     outcomeAbsent,
+			wantVars: map[string]string{"DATABASE_HOST": "db"},
+		},
+		{
+			name:     "dry run",
+			vars:     map[string]string{"DB_HOST": "db"},
+			dryRun:   true,
+			want:     outcomeMoved,
+			wantVars: map[string]string{"DB_HOST": "db"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dryRun = tt.dryRun
+			defer func() { dryRun = false }()
+
+			store := &fakeStore{vars: tt.vars}
+			got, err := migrate(context.Background(), store, "dev", deprecation)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantVars, store.vars)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package mv
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/precheck"
+	"github.com/drapon/envy/internal/prompt"
+)
+
+var (
+	environment string
+	deleteOld   bool
+	noConfig    bool
+	dryRun      bool
+	force       bool
+)
+
+// mvCmd represents the mv command
+var mvCmd = &cobra.Command{
+	Use:   "mv OLD_PREFIX NEW_PREFIX",
+	Short: "Copy or move every parameter under a path prefix to another prefix",
+	Long: `Copy every Parameter Store parameter under OLD_PREFIX, recursively, to
+the same name under NEW_PREFIX, e.g. after a project rename.
+
+Each copy keeps the parameter's type, description, KMS key, tier and tags
+and is read back to verify it. Nothing is written if any of the new names
+already exists. With --delete, the old parameters are deleted once every
+copy has been verified; without it they are left in place.
+
+Environments in .envyrc whose path is under OLD_PREFIX are updated to the
+new prefix, keeping the file's comments (--no-config leaves it alone). With
+--env only that environment is updated. Environments stored in Secrets
+Manager are not moved.
+
+Replica regions set up with 'envy replicate' are not moved; run 'envy
+replicate setup' again for the new path.`,
+	Example: `  # Move prod to the new project prefix, deleting the old parameters
+  envy mv /myapp/old-path/ /myapp/new-path/ --env prod --delete
+
+  # See what would be copied
+  envy mv /oldapp/ /newapp/ --dry-run`,
+	Args: cobra.ExactArgs(2),
+	RunE: runMove,
+}
+
+// GetMvCmd returns the mv command.
+func GetMvCmd() *cobra.Command {
+	return mvCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(mvCmd)
+
+	mvCmd.Flags().StringVarP(&environment, "env", "e", "", "Only update the path of this environment in .envyrc")
+	mvCmd.Flags().BoolVar(&deleteOld, "delete", false, "Delete the old parameters once every copy is verified")
+	mvCmd.Flags().BoolVar(&noConfig, "no-config", false, "Do not update environment paths in .envyrc")
+	mvCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be copied without making changes")
+	mvCmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation prompt")
+}
+
+func runMove(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	oldPrefix, err := aws.NormalizePrefix(args[0])
+	if err != nil {
+		return err
+	}
+	newPrefix, err := aws.NormalizePrefix(args[1])
+	if err != nil {
+		return err
+	}
+
+	// Load configuration
+	configFile := viper.GetString("config")
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	paths, err := movedPaths(cfg, environment, oldPrefix, newPrefix)
+	if err != nil {
+		return err
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	return move(ctx, cfg, awsManager, configFile, oldPrefix, newPrefix, paths)
+}
+
+// move copies the parameters under oldPrefix to newPrefix, points the
+// environments in paths at their new path in configFile, and with --delete
+// removes the originals
+func move(ctx context.Context, cfg *config.Config, awsManager *aws.Manager, configFile, oldPrefix, newPrefix string, paths map[string]string) error {
+	plan, err := awsManager.PlanMove(ctx, oldPrefix, newPrefix)
+	if err != nil {
+		return err
+	}
+	if len(plan.Moves) == 0 {
+		color.PrintInfof("No parameters under %s", oldPrefix)
+	} else {
+		color.PrintBoldf("Parameters to copy (%d):", len(plan.Moves))
+		for _, move := range plan.Moves {
+			fmt.Printf("  %s → %s\n", move.From, move.To)
+		}
+	}
+	if !noConfig {
+		for _, name := range sortedKeys(paths) {
+			color.PrintInfof("Environment %s: path → %s", name, paths[name])
+		}
+	}
+	if len(plan.Moves) == 0 && (noConfig || len(paths) == 0) {
+		return nil
+	}
+
+	if err := precheck.Writers(ctx, cfg, awsManager, plan.Keys()); err != nil {
+		return err
+	}
+
+	if dryRun {
+		color.PrintWarningf("\n[DRY RUN] No changes will be made")
+		return nil
+	}
+
+	if !force && !confirmMove(oldPrefix, newPrefix, len(plan.Moves)) {
+		color.PrintWarningf("Move cancelled")
+		return nil
+	}
+
+	copied, err := awsManager.CopyPrefix(ctx, plan)
+	if err != nil {
+		if len(copied) > 0 {
+			return fmt.Errorf("%w (copied so far, originals kept: %s)", err, strings.Join(copied, ", "))
+		}
+		return err
+	}
+	if len(copied) > 0 {
+		color.PrintSuccessf("Copied and verified %d parameter(s) to %s", len(copied), newPrefix)
+	}
+
+	if !noConfig && len(paths) > 0 {
+		values := make(map[string]string, len(paths))
+		for name, path := range paths {
+			values["environments."+name+".path"] = path
+		}
+		if err := config.SetValues(configFile, values); err != nil {
+			return fmt.Errorf("parameters were copied but .envyrc was not updated: %w", err)
+		}
+		color.PrintSuccessf("Updated the path of %s in .envyrc", strings.Join(sortedKeys(paths), ", "))
+	}
+
+	if deleteOld && len(plan.Moves) > 0 {
+		if err := awsManager.DeleteMoved(ctx, plan); err != nil {
+			return fmt.Errorf("failed to delete the old parameters: %w", err)
+		}
+		color.PrintSuccessf("Deleted %d parameter(s) under %s", len(plan.Moves), oldPrefix)
+	} else if len(plan.Moves) > 0 {
+		color.PrintInfof("The parameters under %s were kept; run again with --delete to remove them", oldPrefix)
+	}
+
+	for _, name := range sortedKeys(paths) {
+		if replicas, err := awsManager.ReplicationRegions(ctx, name); err == nil && len(replicas) > 0 {
+			color.PrintWarningf("%s is replicated to %s; run 'envy replicate setup --env %s --regions %s' to copy the new path",
+				name, strings.Join(replicas, ", "), name, strings.Join(replicas, ","))
+		}
+	}
+	return nil
+}
+
+// movedPaths returns the new path of every environment whose path is under
+// oldPrefix, or only of envName when given
+func movedPaths(cfg *config.Config, envName, oldPrefix, newPrefix string) (map[string]string, error) {
+	names := make([]string, 0, len(cfg.Environments))
+	if envName != "" {
+		if _, err := cfg.GetEnvironment(envName); err != nil {
+			return nil, err
+		}
+		names = append(names, envName)
+	} else {
+		for name := range cfg.Environments {
+			names = append(names, name)
+		}
+	}
+
+	paths := make(map[string]string)
+	for _, name := range names {
+		path := cfg.GetParameterPath(name)
+		if !strings.HasSuffix(path, "/") {
+			path += "/"
+		}
+		if !strings.HasPrefix(path, oldPrefix) {
+			if envName != "" {
+				return nil, fmt.Errorf("the path of %s (%s) is not under %s", name, path, oldPrefix)
+			}
+			continue
+		}
+		if cfg.GetAWSService(name) == "secrets_manager" {
+			if envName != "" {
+				return nil, fmt.Errorf("%s is stored in Secrets Manager, which mv does not support", name)
+			}
+			color.PrintWarningf("Skipping %s: it is stored in Secrets Manager", name)
+			continue
+		}
+		paths[name] = newPrefix + strings.TrimPrefix(path, oldPrefix)
+	}
+	return paths, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func confirmMove(oldPrefix, newPrefix string, count int) bool {
+	return prompt.Confirm(color.FormatWarning(fmt.Sprintf("About to copy %d parameter(s) from %s to %s.", count, oldPrefix, newPrefix)) + " Continue?")
+}
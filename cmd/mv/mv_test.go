@@ -0,0 +1,43 @@
+package mv
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMvCmd(t *testing.T) {
+	cmd := GetMvCmd()
+	assert.Equal(t, "mv OLD_PREFIX NEW_PREFIX", cmd.Use)
+	for _, name := range []string{"env", "delete", "no-config", "dry-run", "force"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestMovedPaths(t *testing.T) {
+	cfg := &config.Config{
+		Project: "myapp",
+		AWS:     config.AWSConfig{Service: "parameter_store"},
+		Environments: map[string]config.Environment{
+			"dev":     {},
+			"prod":    {Path: "/myapp/old-path/prod"},
+			"staging": {Path: "/other/staging/"},
+			"secrets": {Path: "/myapp/old-path/secrets/", UseSecretsManager: true},
+		},
+	}
+
+	paths, err := movedPaths(cfg, "", "/myapp/old-path/", "/myapp/new-path/")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"prod": "/myapp/new-path/prod/"}, paths)
+
+	paths, err = movedPaths(cfg, "", "/myapp/", "/newapp/")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"dev": "/newapp/dev/", "prod": "/newapp/old-path/prod/"}, paths)
+
+	_, err = movedPaths(cfg, "staging", "/myapp/", "/newapp/")
+	assert.ErrorContains(t, err, "not under /myapp/")
+	_, err = movedPaths(cfg, "secrets", "/myapp/", "/newapp/")
+	assert.ErrorContains(t, err, "Secrets Manager")
+}
@@ -0,0 +1,32 @@
+//go:build !slim
+
+package pull
+
+import (
+	"fmt"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// newProgressBar creates the terminal progress bar shown during pull
+func newProgressBar(total int) progressBar {
+	return progressbar.NewOptions(total,
+		progressbar.OptionSetDescription("Fetching variables from AWS"),
+		progressbar.OptionSetWidth(50),
+		progressbar.OptionShowCount(),
+		progressbar.OptionShowIts(),
+		progressbar.OptionSetItsString("vars"),
+		progressbar.OptionOnCompletion(func() {
+			fmt.Println()
+		}),
+		progressbar.OptionEnableColorCodes(true),
+		progressbar.OptionSetTheme(progressbar.Theme{
+			Saucer:        "[green]█[reset]",
+			SaucerHead:    "[green]>[reset]",
+			SaucerPadding: " ",
+			BarStart:      "[",
+			BarEnd:        "]",
+		}),
+		progressbar.OptionShowElapsedTimeOnFinish(),
+	)
+}
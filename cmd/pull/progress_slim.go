@@ -0,0 +1,14 @@
+//go:build slim
+
+package pull
+
+// silentBar discards progress updates in the slim build.
+type silentBar struct{}
+
+func (silentBar) Add(int) error { return nil }
+func (silentBar) Finish() error { return nil }
+
+// newProgressBar returns a no-op progress bar
+func newProgressBar(total int) progressBar {
+	return silentBar{}
+}
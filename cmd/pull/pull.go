@@ -2,20 +2,33 @@ package pull
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/drapon/envy/cmd/root"
 	"github.com/drapon/envy/internal/aws"
+	secrets_manager "github.com/drapon/envy/internal/aws/secrets_manager"
+	"github.com/drapon/envy/internal/azure"
 	"github.com/drapon/envy/internal/cache"
 	"github.com/drapon/envy/internal/color"
 	"github.com/drapon/envy/internal/config"
 	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/inline"
 	"github.com/drapon/envy/internal/log"
-	"github.com/schollz/progressbar/v3"
+	"github.com/drapon/envy/internal/remote"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/drapon/envy/internal/structured"
+	"github.com/drapon/envy/internal/validator"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
@@ -31,8 +44,33 @@ var (
 	backup      bool
 	merge       bool
 	noProgress  bool
+	validate    bool
+	stage       string
+	versionID   string
+	resume      bool
+	keyOrder    string
+	filterOpts  filter.Options
+
+	// documentOutput receives the json and yaml summary of --output;
+	// everything else goes to stderr then
+	documentOutput io.Writer = os.Stdout
 )
 
+// pullResult is the json and yaml output of pull. Values are not included;
+// they are in the files written.
+type pullResult struct {
+	Environments []pulledEnvironment `json:"environments"`
+}
+
+// pulledEnvironment describes the pull of one environment
+type pulledEnvironment struct {
+	Name      string   `json:"name"`
+	File      string   `json:"file"`
+	Variables int      `json:"variables"`
+	Keys      []string `json:"keys"`
+	Backup    string   `json:"backup,omitempty"`
+}
+
 // pullCmd represents the pull command
 var pullCmd = &cobra.Command{
 	Use:   "pull",
@@ -40,7 +78,31 @@ var pullCmd = &cobra.Command{
 	Long: `Pull environment variables from AWS Parameter Store or Secrets Manager.
 
 This command downloads variables from AWS and saves them to local .env files
-based on your configuration in .envyrc.`,
+based on your configuration in .envyrc.
+
+With --validate, the pulled values are checked against .envy-rules.yaml and
+any rule violations in the remote environment are reported as warnings.
+
+Environments in Secrets Manager can be pulled at an earlier or staged
+version with --stage (e.g. AWSPREVIOUS, or a label pushed with
+'envy push --stage') or --version-id.
+
+The output file is written to a temporary file and renamed over the old
+one, so an interrupted pull leaves it untouched. Parameter Store pages read
+so far are recorded, encrypted, in the user cache directory; after Ctrl-C
+or a network error, --resume fetches only the remaining pages instead of
+starting over.
+
+Remote stores keep no order, so the variables of a new file are sorted by
+--key-order: alphabetical, path (keys ending at each "_" level before the
+groups below it, e.g. PORT before DB_HOST), or rules (the order of
+.envy-rules.yaml, then the rest alphabetically). Set it for the team in
+.envyrc under commands: { pull: { key_order: rules } }. With --merge,
+existing variables keep their place and new ones follow in this order.
+
+With --output json or yaml, the files are written as usual and stdout gets
+a summary of each environment pulled: the file, and the keys written to it.
+Other values of --output are the file to write.`,
 	Example: `  # Pull variables for the default environment
   envy pull
   
@@ -57,7 +119,29 @@ based on your configuration in .envyrc.`,
   envy pull --all
   
   # Pull with backup of existing files
-  envy pull --backup`,
+  envy pull --backup
+
+  # Pull only some variables into the existing file
+  envy pull --filter "FEATURE_*" --merge
+
+  # Pull only non-sensitive variables
+  envy pull --no-secrets --output .env.shared
+
+  # Warn about remote values that break the validation rules
+  envy pull --env prod --validate
+
+  # Pull the values staged for a blue/green rollout, or the previous ones
+  envy pull --env prod --stage green --output .env.green
+  envy pull --env prod --stage AWSPREVIOUS --output .env.previous
+
+  # Finish a pull that was interrupted
+  envy pull --env prod --resume
+
+  # Write variables in the order of .envy-rules.yaml
+  envy pull --env dev --key-order rules
+
+  # Pull every environment and report the files written as JSON
+  envy pull --all --output json`,
 	RunE: runPull,
 }
 
@@ -67,29 +151,61 @@ func init() {
 	// Add flags specific to pull command
 	pullCmd.Flags().StringVarP(&environment, "env", "e", "", "Source environment")
 	pullCmd.Flags().StringVarP(&prefix, "prefix", "p", "", "AWS parameter prefix (overrides config)")
-	pullCmd.Flags().StringVarP(&output, "output", "o", "", "Output file path (overrides config)")
+	pullCmd.Flags().StringVarP(&output, "output", "o", "", "Output file path (overrides config); json, yaml or table select the format")
 	pullCmd.Flags().BoolVarP(&export, "export", "x", false, "Export variables to shell")
 	pullCmd.Flags().BoolVarP(&overwrite, "overwrite", "w", false, "Overwrite existing file without backup")
 	pullCmd.Flags().BoolVarP(&all, "all", "a", false, "Pull all environments")
 	pullCmd.Flags().BoolVar(&backup, "backup", false, "Create backup of existing files")
 	pullCmd.Flags().BoolVarP(&merge, "merge", "m", false, "Merge with existing local variables")
 	pullCmd.Flags().BoolVar(&noProgress, "no-progress", false, "Disable progress bar")
+	pullCmd.Flags().BoolVar(&validate, "validate", false, "Warn about pulled values that break the validation rules")
+	pullCmd.Flags().StringVar(&stage, "stage", "", "Pull the Secrets Manager version with this staging label, e.g. AWSPREVIOUS")
+	pullCmd.Flags().StringVar(&versionID, "version-id", "", "Pull this Secrets Manager version")
+	pullCmd.Flags().BoolVar(&resume, "resume", false, "Continue an interrupted pull, fetching only the pages it had not read")
+	pullCmd.Flags().StringVar(&keyOrder, "key-order", env.OrderAlphabetical, "Order of the variables written (alphabetical/path/rules)")
+	pullCmd.MarkFlagsMutuallyExclusive("stage", "version-id")
+	pullCmd.MarkFlagsMutuallyExclusive("resume", "stage")
+	pullCmd.MarkFlagsMutuallyExclusive("resume", "version-id")
+	filter.AddFlags(pullCmd.Flags(), &filterOpts)
+	filter.AddSensitivityFlags(pullCmd.Flags(), &filterOpts)
 }
 
 func runPull(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	// Ctrl-C stops the requests in flight; the pages read are kept for --resume
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	logger := log.WithContext(zap.String("command", "pull"))
 
+	// --output json|yaml prints a summary; other values name the file
+	format, err := structured.FromCommand(cmd)
+	if err != nil {
+		return err
+	}
+	if _, err := env.ParseOrder(keyOrder); err != nil {
+		return err
+	}
+	if structured.IsFormat(output) {
+		output = ""
+	}
+	if format.IsDocument() {
+		if export {
+			return fmt.Errorf("--export cannot be used with --output %s", format)
+		}
+		var restore func()
+		documentOutput, restore = structured.KeepStdout()
+		defer restore()
+	}
+
 	// Load configuration with caching
 	cfg, err := loadConfigWithCache()
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Create AWS manager
-	awsManager, err := aws.NewManager(cfg)
+	// Create the AWS or Azure Key Vault backend
+	backend, err := remote.New(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create AWS manager: %w", err)
+		return err
 	}
 
 	// Determine which environments to pull
@@ -106,54 +222,123 @@ func runPull(cmd *cobra.Command, args []string) error {
 	}
 
 	// Process each environment
+	sort.Strings(environments)
+	result := pullResult{Environments: []pulledEnvironment{}}
 	for _, envName := range environments {
-		if err := pullEnvironment(ctx, cfg, awsManager, envName, logger); err != nil {
+		pulled, err := pullEnvironment(ctx, cfg, backend, envName, logger)
+		if err != nil {
 			return fmt.Errorf("failed to pull environment %s: %w", envName, err)
 		}
+		result.Environments = append(result.Environments, pulled)
 	}
 
+	if format.IsDocument() {
+		return structured.Write(documentOutput, format, result)
+	}
 	return nil
 }
 
-func pullEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Manager, envName string, logger *zap.Logger) error {
+func pullEnvironment(ctx context.Context, cfg *config.Config, backend remote.Backend, envName string, logger *zap.Logger) (pulledEnvironment, error) {
+	pulled := pulledEnvironment{Name: envName, Keys: []string{}}
 	color.PrintInfof("Pulling environment: %s", envName)
 
 	// Get environment configuration
 	envConfig, err := cfg.GetEnvironment(envName)
 	if err != nil {
-		return err
+		return pulled, err
 	}
 
-	// Pull from AWS with caching
+	// Pull from AWS or Azure Key Vault
 	if !viper.GetBool("quiet") && !export && !noProgress {
 		color.PrintInfof("Connecting to %s...", getSourceDescription(cfg, envName))
 	}
 
-	envFile, err := pullEnvironmentWithCache(ctx, awsManager, envName, logger)
+	// Determine output file
+	outputFile := output
+	if outputFile == "" && len(envConfig.Files) > 0 {
+		outputFile = envConfig.Files[0]
+	}
+	if outputFile == "" {
+		outputFile = fmt.Sprintf(".env.%s", envName)
+	}
+
+	// Progress is recorded for pulls that write a file
+	progress := ""
+	if !export {
+		if progress, err = checkpointFile(envName, outputFile); err != nil {
+			logger.Debug("Pull progress will not be recorded", zap.Error(err))
+		}
+	}
+
+	// The version-keyed cache reads AWS metadata; Key Vault is read directly
+	var envFile *env.File
+	awsManager, onAWS := backend.(*aws.Manager)
+	switch {
+	case stage != "" || versionID != "":
+		if !onAWS {
+			return pulled, fmt.Errorf("--stage and --version-id are only supported with AWS Secrets Manager")
+		}
+		// Pinned versions are read directly, as the cache holds AWSCURRENT
+		envFile, err = awsManager.PullEnvironmentVersion(ctx, envName, secrets_manager.VersionRef{Stage: stage, VersionID: versionID})
+	case resume:
+		if !onAWS {
+			return pulled, fmt.Errorf("--resume is only supported with AWS")
+		}
+		envFile, err = resumePull(ctx, awsManager, envName, progress)
+	case onAWS:
+		envFile, err = pullEnvironmentWithCache(ctx, awsManager, envName, progress, logger)
+	default:
+		envFile, err = backend.PullEnvironment(ctx, envName)
+	}
+	if err != nil {
+		if hasProgress(progress) {
+			color.PrintWarningf("The pull of %s was interrupted; %s is unchanged. Fetch the rest with: envy pull --env %s --resume", envName, outputFile, envName)
+		}
+		return pulled, fmt.Errorf("pull failed: %w", err)
+	}
+	// Every page was read, so the progress is no longer needed whether the
+	// file gets written or not
+	defer removeCheckpoint(progress)
+
+	// Apply --filter/--exclude/--keys and --only-secrets/--no-secrets
+	keyFilter, err := filter.New(filterOpts)
+	if err != nil {
+		return pulled, err
+	}
+	classifier, err := sensitive.FromConfig(cfg)
 	if err != nil {
-		return fmt.Errorf("pull failed: %w", err)
+		return pulled, err
+	}
+	envFile = keyFilter.WithClassifier(classifier).ApplyFile(envFile)
+
+	// Every teammate's first pull writes the same file
+	if err := orderKeys(envFile, envName); err != nil {
+		return pulled, err
 	}
 
 	variableCount := len(envFile.Keys())
 	if variableCount == 0 {
 		color.PrintWarningf("No variables found")
-		return nil
+		return pulled, nil
 	}
 
 	color.PrintInfof("Fetched %d variables", variableCount)
 
+	if validate {
+		if err := warnInvalid(ctx, envName, envFile, keyFilter.Active()); err != nil {
+			return pulled, err
+		}
+	}
+
 	// Handle export mode
 	if export {
-		return exportVariables(envFile)
+		return pulled, exportVariables(envFile)
 	}
 
-	// Determine output file
-	outputFile := output
-	if outputFile == "" && len(envConfig.Files) > 0 {
-		outputFile = envConfig.Files[0]
-	}
-	if outputFile == "" {
-		outputFile = fmt.Sprintf(".env.%s", envName)
+	// A filtered pull only has some of the variables, so it must not
+	// silently replace the whole file
+	if keyFilter.Active() && !merge && !overwrite && fileExists(outputFile) {
+		return pulled, fmt.Errorf("%s exists and the filter selects only some variables; use --merge to update them in it, or --overwrite to replace the file", outputFile)
 	}
 
 	// Handle merge mode
@@ -168,12 +353,18 @@ func pullEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Ma
 		}
 	}
 
+	// A file sealed with envy seal stays sealed
+	if err := reseal(ctx, cfg, envFile, outputFile, classifier); err != nil {
+		return pulled, err
+	}
+
 	// Create backup if file exists
 	if backup && !overwrite && fileExists(outputFile) {
 		backupFile := createBackupFilename(outputFile)
 		color.PrintInfof("Creating backup: %s", backupFile)
+		pulled.Backup = backupFile
 		if err := copyFile(outputFile, backupFile); err != nil {
-			return fmt.Errorf("failed to create backup: %w", err)
+			return pulled, fmt.Errorf("failed to create backup: %w", err)
 		}
 	}
 
@@ -184,8 +375,12 @@ func pullEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Ma
 
 	// Write the file
 	if err := envFile.WriteFile(outputFile); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+		return pulled, fmt.Errorf("failed to write file: %w", err)
 	}
+	pulled.File = outputFile
+	pulled.Keys = envFile.Keys()
+	sort.Strings(pulled.Keys)
+	pulled.Variables = len(pulled.Keys)
 
 	if !viper.GetBool("quiet") && !export {
 		color.PrintSuccessf("✓ File written successfully")
@@ -199,6 +394,88 @@ func pullEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Ma
 	if !viper.GetBool("quiet") {
 		color.PrintSuccessf("Successfully pulled %d variables to %s", variableCount, outputFile)
 	}
+	return pulled, nil
+}
+
+// reseal encrypts the pulled values that were encrypted in the file they
+// replace, along with the other sensitive ones, when that file holds
+// ENC[...] values
+func reseal(ctx context.Context, cfg *config.Config, envFile *env.File, outputFile string, classifier func(string) bool) error {
+	if !fileExists(outputFile) {
+		return nil
+	}
+	existing, err := env.ParseFile(outputFile)
+	if err != nil {
+		return nil
+	}
+	wasSealed := make(map[string]bool)
+	for _, key := range inline.Encrypted(existing) {
+		wasSealed[key] = true
+	}
+	if len(wasSealed) == 0 {
+		return nil
+	}
+
+	sealed, err := inline.FromConfig(ctx, cfg).SealFile(envFile, func(key string) bool {
+		return wasSealed[key] || classifier(key)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to keep %s sealed: %w", outputFile, err)
+	}
+	if len(sealed) > 0 {
+		color.PrintInfof("Encrypted %d value(s), as %s is sealed", len(sealed), outputFile)
+	}
+	return nil
+}
+
+// orderKeys sorts the pulled variables by --key-order
+func orderKeys(envFile *env.File, envName string) error {
+	order, err := env.ParseOrder(keyOrder)
+	if err != nil {
+		return err
+	}
+
+	var named []string
+	if order == env.OrderRules {
+		rules, err := validator.LoadRulesForEnvironment(".envy-rules.yaml", envName)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			color.PrintWarningf("No .envy-rules.yaml found, sorting variables alphabetically")
+		case err != nil:
+			return fmt.Errorf("failed to load validation rules: %w", err)
+		default:
+			named = rules.Order()
+		}
+	}
+
+	envFile.Reorder(env.SortKeys(envFile.Keys(), order, named))
+	return nil
+}
+
+// warnInvalid reports pulled variables that break the environment's
+// validation rules. The pull itself goes ahead.
+func warnInvalid(ctx context.Context, envName string, envFile *env.File, partial bool) error {
+	rules, err := validator.LoadRulesForEnvironment(".envy-rules.yaml", envName)
+	if errors.Is(err, fs.ErrNotExist) {
+		color.PrintWarningf("No .envy-rules.yaml found, skipping validation")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load validation rules: %w", err)
+	}
+
+	result := validator.New(rules).Validate(ctx, envFile.ToMap())
+	if partial {
+		result = result.Without("missing_required")
+	}
+	if len(result.Errors) == 0 && len(result.Warnings) == 0 {
+		return nil
+	}
+
+	color.PrintWarningf("Remote values of %s break the validation rules:", envName)
+	for _, issue := range append(result.Errors, result.Warnings...) {
+		fmt.Printf("  %s\n", color.FormatWarning(issue.Message))
+	}
 	return nil
 }
 
@@ -218,9 +495,13 @@ func exportVariables(envFile *env.File) error {
 }
 
 func getSourceDescription(cfg *config.Config, envName string) string {
+	if cfg.UsesAzure() {
+		return azure.Target(cfg, envName)
+	}
+
 	service := cfg.GetAWSService(envName)
 	path := cfg.GetParameterPath(envName)
-	region := cfg.AWS.Region
+	region := cfg.GetRegion(envName)
 
 	if service == "secrets_manager" {
 		return fmt.Sprintf("AWS Secrets Manager (%s)", region)
@@ -235,10 +516,7 @@ func fileExists(filename string) bool {
 }
 
 func createBackupFilename(original string) string {
-	ext := filepath.Ext(original)
-	base := strings.TrimSuffix(original, ext)
-	timestamp := time.Now().Format("20060102_150405.000")
-	return fmt.Sprintf("%s.backup_%s%s", base, timestamp, ext)
+	return env.BackupFilename(original, time.Now())
 }
 
 func copyFile(src, dst string) error {
@@ -284,37 +562,69 @@ func loadConfigWithCache() (*config.Config, error) {
 }
 
 // pullEnvironmentWithCache retrieves environment variables from AWS with cache support
-func pullEnvironmentWithCache(ctx context.Context, awsManager *aws.Manager, envName string, logger *zap.Logger) (*env.File, error) {
-	// Generate cache key
+func pullEnvironmentWithCache(ctx context.Context, awsManager *aws.Manager, envName, progress string, logger *zap.Logger) (*env.File, error) {
+	// With a disk cache, fetch only the parameters whose version changed,
+	// or serve cached values within the environment's cache_soft_ttl
+	if store := cache.Versioned(); store != nil {
+		result, err := awsManager.PullEnvironmentStale(ctx, envName, store, func() {
+			path := awsManager.GetConfig().GetParameterPath(envName)
+			if err := cache.RefreshInBackground(store, envName, path, viper.GetString("config")); err != nil {
+				logger.Debug("Failed to start background refresh", zap.Error(err))
+			}
+		})
+		if err == nil {
+			logger.Debug("Retrieved AWS environment variables",
+				zap.String("environment", envName),
+				zap.Int("variable_count", len(result.File.Keys())),
+				zap.Int("fetched_count", result.Fetched),
+				zap.Duration("cache_age", result.Age),
+				zap.Bool("stale", result.Stale))
+			return result.File, nil
+		}
+		logger.Debug("Versioned pull failed, pulling the whole environment",
+			zap.String("environment", envName),
+			zap.Error(err))
+	}
+
+	// Prefer a key tied to the remote version so changes are picked up
+	// immediately; fall back to a short time-based cache if the version
+	// cannot be determined (e.g. missing DescribeParameters permission)
 	cacheKey := cache.NewCacheKeyBuilder("aws_env").
 		Add(envName).
-		Add(awsManager.GetConfig().AWS.Region).
+		Add(awsManager.GetConfig().GetRegion(envName)).
 		Add(awsManager.GetConfig().GetParameterPath(envName)).
 		Build()
+	cacheTTL := 15 * time.Minute // AWS environment variables cache TTL
+	remoteVersion := ""
+
+	if cache.IsEnabled() {
+		if version, err := awsManager.RemoteVersion(ctx, envName); err == nil {
+			remoteVersion = version
+			cacheKey = awsManager.PullCacheKey(envName, remoteVersion)
+			cacheTTL = aws.VersionedCacheTTL
+		} else {
+			logger.Debug("Could not determine remote version",
+				zap.String("environment", envName),
+				zap.Error(err))
+		}
+	}
 
 	// Get or generate environment variables from cache
 	result, err := cache.CachedOperationWithMetadata(
 		cacheKey,
-		15*time.Minute, // AWS environment variables cache TTL
+		cacheTTL,
 		map[string]interface{}{
-			"type":        "aws_environment",
-			"environment": envName,
-			"sensitive":   true, // AWS data is subject to encryption
+			"type":           "aws_environment",
+			"environment":    envName,
+			"remote_version": remoteVersion,
+			"sensitive":      true, // AWS data is subject to encryption
 		},
 		func() (interface{}, error) {
 			logger.Debug("Fetching AWS environment variables (cache miss)",
 				zap.String("environment", envName))
 
-			// Check if we should show progress
-			showProgress := !viper.GetBool("quiet") && !export && !noProgress
-
-			if showProgress {
-				// Use our custom progress function with English messages
-				return pullWithProgress(ctx, awsManager, envName)
-			}
-
-			// When progress is disabled, use regular pull
-			return awsManager.PullEnvironment(ctx, envName)
+			// Record the pages read, so an interrupted pull can resume
+			return fetchRecorded(ctx, awsManager, envName, &aws.PullCheckpoint{}, progress)
 		},
 	)
 
@@ -334,8 +644,15 @@ func pullEnvironmentWithCache(ctx context.Context, awsManager *aws.Manager, envN
 	return envFile, nil
 }
 
-// pullWithProgress pulls environment variables with a progress bar
-func pullWithProgress(ctx context.Context, awsManager *aws.Manager, envName string) (*env.File, error) {
+// progressBar is the subset of the progress bar API used while pulling.
+type progressBar interface {
+	Add(num int) error
+	Finish() error
+}
+
+// pullWithProgress pulls environment variables from checkpoint on with a
+// progress bar, passing every page read to save
+func pullWithProgress(ctx context.Context, awsManager *aws.Manager, envName string, checkpoint *aws.PullCheckpoint, save func(*aws.PullCheckpoint) error) (*env.File, error) {
 	cfg := awsManager.GetConfig()
 	service := cfg.GetAWSService(envName)
 	path := cfg.GetParameterPath(envName)
@@ -349,8 +666,8 @@ func pullWithProgress(ctx context.Context, awsManager *aws.Manager, envName stri
 	paramStore := awsManager.GetParameterStore()
 	parameters, err := paramStore.GetParametersByPath(ctx, path, false, false)
 	if err != nil {
-		// If we can't get the count, fall back to regular pull
-		return awsManager.PullEnvironment(ctx, envName)
+		// If we can't get the count, pull without a progress bar
+		return awsManager.PullEnvironmentResumable(ctx, envName, checkpoint, save)
 	}
 
 	if len(parameters) == 0 {
@@ -358,45 +675,20 @@ func pullWithProgress(ctx context.Context, awsManager *aws.Manager, envName stri
 		return env.NewFile(), nil
 	}
 
-	// Create progress bar
-	bar := progressbar.NewOptions(len(parameters),
-		progressbar.OptionSetDescription("Fetching variables from AWS"),
-		progressbar.OptionSetWidth(50),
-		progressbar.OptionShowCount(),
-		progressbar.OptionShowIts(),
-		progressbar.OptionSetItsString("vars"),
-		progressbar.OptionOnCompletion(func() {
-			fmt.Println()
-		}),
-		progressbar.OptionEnableColorCodes(true),
-		progressbar.OptionSetTheme(progressbar.Theme{
-			Saucer:        "[green]█[reset]",
-			SaucerHead:    "[green]>[reset]",
-			SaucerPadding: " ",
-			BarStart:      "[",
-			BarEnd:        "]",
-		}),
-		progressbar.OptionShowElapsedTimeOnFinish(),
-	)
-
-	// Pull each parameter
-	envFile := env.NewFile()
-	for _, param := range parameters {
-		// Get parameter value with decryption
-		fullParam, err := paramStore.GetParameter(ctx, param.Name, true)
-		if err != nil {
-			bar.Add(1)
-			continue // Skip failed parameters
-		}
+	// Create progress bar, counting the variables of a resumed pull
+	bar := newProgressBar(len(parameters))
+	read := len(checkpoint.Vars)
+	bar.Add(read)
 
-		// Extract key from path
-		key := strings.TrimPrefix(fullParam.Name, path)
-		key = strings.TrimPrefix(key, "/")
-
-		envFile.Set(key, fullParam.Value)
-		bar.Add(1)
+	envFile, err := awsManager.PullEnvironmentResumable(ctx, envName, checkpoint, func(checkpoint *aws.PullCheckpoint) error {
+		bar.Add(len(checkpoint.Vars) - read)
+		read = len(checkpoint.Vars)
+		return save(checkpoint)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	bar.Finish()
+	_ = bar.Finish()
 	return envFile, nil
 }
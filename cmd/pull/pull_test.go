@@ -1,6 +1,8 @@
 package pull
 
 import (
+	"bytes"
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,6 +11,9 @@ import (
 
 	"github.com/drapon/envy/internal/config"
 	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/envelope"
+	"github.com/drapon/envy/internal/inline"
+	"github.com/drapon/envy/internal/structured"
 	"github.com/drapon/envy/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -29,6 +34,7 @@ func TestPullCmd_Flags(t *testing.T) {
 	assert.NotNil(t, cmd.Flags().Lookup("all"))
 	assert.NotNil(t, cmd.Flags().Lookup("backup"))
 	assert.NotNil(t, cmd.Flags().Lookup("merge"))
+	assert.NotNil(t, cmd.Flags().Lookup("validate"))
 
 	// Test flag shortcuts
 	envFlag := cmd.Flags().Lookup("env")
@@ -540,6 +546,7 @@ func resetFlags() {
 	all = false
 	backup = false
 	merge = false
+	keyOrder = env.OrderAlphabetical
 }
 
 // Test helper to setup test environment
@@ -790,3 +797,90 @@ func TestEscapeQuotesInExport(t *testing.T) {
 		})
 	}
 }
+
+func TestPullResultDocument(t *testing.T) {
+	var buf bytes.Buffer
+	result := pullResult{Environments: []pulledEnvironment{
+		{Name: "prod", File: ".env.prod", Variables: 2, Keys: []string{"API_URL", "PORT"}},
+	}}
+	require.NoError(t, structured.Write(&buf, structured.YAML, result))
+	assert.Equal(t, `environments:
+  - name: prod
+    file: .env.prod
+    variables: 2
+    keys:
+      - API_URL
+      - PORT
+`, buf.String())
+}
+
+func TestOrderKeys(t *testing.T) {
+	defer resetFlags()
+	tempDir := testutil.TempDir(t)
+	testutil.ChangeDir(t, tempDir)
+
+	newFile := func() *env.File {
+		file := env.NewFile()
+		for _, key := range []string{"PORT", "DB_HOST", "API_KEY", "DEBUG"} {
+			file.Set(key, "x")
+		}
+		return file
+	}
+
+	file := newFile()
+	require.NoError(t, orderKeys(file, "dev"))
+	assert.Equal(t, []string{"API_KEY", "DB_HOST", "DEBUG", "PORT"}, file.Keys())
+
+	keyOrder = env.OrderPath
+	file = newFile()
+	require.NoError(t, orderKeys(file, "dev"))
+	assert.Equal(t, []string{"DEBUG", "PORT", "API_KEY", "DB_HOST"}, file.Keys())
+
+	// Without a rules file, rules falls back to alphabetical
+	keyOrder = env.OrderRules
+	file = newFile()
+	require.NoError(t, orderKeys(file, "dev"))
+	assert.Equal(t, []string{"API_KEY", "DB_HOST", "DEBUG", "PORT"}, file.Keys())
+
+	testutil.WriteFile(t, tempDir, ".envy-rules.yaml", "variables:\n  PORT:\n    type: int\n  DB_HOST:\n    type: string\n")
+	file = newFile()
+	require.NoError(t, orderKeys(file, "dev"))
+	assert.Equal(t, []string{"PORT", "DB_HOST", "API_KEY", "DEBUG"}, file.Keys())
+
+	keyOrder = "random"
+	assert.Error(t, orderKeys(newFile(), "dev"))
+}
+
+func TestReseal(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	keyFile := filepath.Join(tempDir, "inline.key")
+	require.NoError(t, envelope.GenerateKeyFile(keyFile))
+	cfg := &config.Config{InlineEncryption: config.InlineEncryptionConfig{KeyFile: keyFile}}
+	isSensitive := func(key string) bool { return key == "API_KEY" }
+
+	pulled := func() *env.File {
+		file := env.NewFile()
+		file.Set("PORT", "8080")
+		file.Set("API_KEY", "abc123")
+		file.Set("WEBHOOK_ID", "wh_1")
+		return file
+	}
+
+	// Plain files stay plain
+	plain := testutil.WriteFile(t, tempDir, ".env.plain", "PORT=80\nAPI_KEY=old\n")
+	file := pulled()
+	require.NoError(t, reseal(context.Background(), cfg, file, plain, isSensitive))
+	assert.Empty(t, inline.Encrypted(file))
+
+	// Sealed files keep their encrypted keys encrypted, and the sensitive ones
+	codec := inline.FromConfig(context.Background(), cfg)
+	value, err := codec.Encrypt("wh_0")
+	require.NoError(t, err)
+	sealed := testutil.WriteFile(t, tempDir, ".env.sealed", "PORT=80\nWEBHOOK_ID="+value+"\n")
+	file = pulled()
+	require.NoError(t, reseal(context.Background(), cfg, file, sealed, isSensitive))
+	assert.Equal(t, []string{"API_KEY", "WEBHOOK_ID"}, inline.Encrypted(file))
+
+	require.NoError(t, inline.Decrypt(context.Background(), cfg, file))
+	assert.Equal(t, pulled().ToMap(), file.ToMap())
+}
@@ -0,0 +1,150 @@
+package pull
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/envelope"
+	"github.com/spf13/viper"
+)
+
+// checkpointFile returns the file recording the progress of pulling
+// envName into outputFile. It lives in the user cache directory, outside
+// the project, and holds the values read so far, so it is sealed with
+// checkpointKey, only readable by the user and removed once the fetch
+// succeeds.
+func checkpointFile(envName, outputFile string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the user cache directory: %w", err)
+	}
+	abs, err := filepath.Abs(outputFile)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(envName + "\x00" + abs))
+	return filepath.Join(dir, "envy", "pull", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// checkpointKey returns the key sealing pull checkpoints, creating it on
+// first use. It is kept in the user config directory rather than next to
+// the checkpoints, so a copied or backed up cache holds no readable values.
+func checkpointKey() ([]byte, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the user config directory: %w", err)
+	}
+	path := filepath.Join(dir, "envy", "pull.key")
+	if err := envelope.GenerateKeyFile(path); err != nil && !errors.Is(err, fs.ErrExist) {
+		return nil, err
+	}
+	return envelope.LoadKeyFile(path)
+}
+
+// loadCheckpoint reads a checkpoint, returning an empty one if there is none
+func loadCheckpoint(path string) (*aws.PullCheckpoint, error) {
+	checkpoint := &aws.PullCheckpoint{}
+	if path == "" {
+		return checkpoint, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pull progress: %w", err)
+	}
+	key, err := checkpointKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pull progress: %w", err)
+	}
+	// A checkpoint cut short by a crash, or sealed with another key, is
+	// started over
+	data, err = envelope.Open(key, data)
+	if err != nil {
+		return &aws.PullCheckpoint{}, nil
+	}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return &aws.PullCheckpoint{}, nil
+	}
+	return checkpoint, nil
+}
+
+// saveCheckpoint returns a function recording a checkpoint at path, or
+// doing nothing without a path
+func saveCheckpoint(path string) func(*aws.PullCheckpoint) error {
+	return func(checkpoint *aws.PullCheckpoint) error {
+		if path == "" {
+			return nil
+		}
+		data, err := json.Marshal(checkpoint)
+		if err != nil {
+			return err
+		}
+		key, err := checkpointKey()
+		if err != nil {
+			return fmt.Errorf("failed to record pull progress: %w", err)
+		}
+		if data, err = envelope.Seal(key, data); err != nil {
+			return fmt.Errorf("failed to record pull progress: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return fmt.Errorf("failed to record pull progress: %w", err)
+		}
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, data, 0600); err != nil {
+			return fmt.Errorf("failed to record pull progress: %w", err)
+		}
+		return os.Rename(tmp, path)
+	}
+}
+
+// removeCheckpoint forgets the progress of a pull whose fetch succeeded
+func removeCheckpoint(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		color.PrintWarningf("Failed to remove pull progress %s: %v", path, err)
+	}
+}
+
+// hasProgress reports whether an interrupted pull left pages to resume from
+func hasProgress(path string) bool {
+	checkpoint, err := loadCheckpoint(path)
+	return err == nil && checkpoint.Pages > 0
+}
+
+// resumePull continues the interrupted pull recorded at path, fetching
+// only the pages it had not read yet
+func resumePull(ctx context.Context, awsManager *aws.Manager, envName, path string) (*env.File, error) {
+	checkpoint, err := loadCheckpoint(path)
+	if err != nil {
+		return nil, err
+	}
+	if checkpoint.Pages == 0 || checkpoint.Environment != envName {
+		color.PrintInfof("No interrupted pull of %s to resume; pulling all of it", envName)
+	} else {
+		color.PrintInfof("Resuming the pull of %s after %d page(s), %d variable(s)", envName, checkpoint.Pages, len(checkpoint.Vars))
+	}
+	return fetchRecorded(ctx, awsManager, envName, checkpoint, path)
+}
+
+// fetchRecorded pulls an environment from checkpoint on, recording its
+// progress at path after every page
+func fetchRecorded(ctx context.Context, awsManager *aws.Manager, envName string, checkpoint *aws.PullCheckpoint, path string) (*env.File, error) {
+	if viper.GetBool("quiet") || export || noProgress {
+		return awsManager.PullEnvironmentResumable(ctx, envName, checkpoint, saveCheckpoint(path))
+	}
+	return pullWithProgress(ctx, awsManager, envName, checkpoint, saveCheckpoint(path))
+}
@@ -0,0 +1,87 @@
+package pull
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drapon/envy/internal/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointFile(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	prod, err := checkpointFile("prod", ".env.prod")
+	require.NoError(t, err)
+	again, err := checkpointFile("prod", ".env.prod")
+	require.NoError(t, err)
+	other, err := checkpointFile("prod", ".env.green")
+	require.NoError(t, err)
+
+	assert.Equal(t, prod, again)
+	assert.NotEqual(t, prod, other)
+	assert.Equal(t, filepath.Join("envy", "pull"), filepath.Join(filepath.Base(filepath.Dir(filepath.Dir(prod))), filepath.Base(filepath.Dir(prod))))
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "envy", "pull", "prod.json")
+
+	checkpoint, err := loadCheckpoint(path)
+	require.NoError(t, err)
+	assert.Zero(t, checkpoint.Pages, "no checkpoint starts from the first page")
+	assert.False(t, hasProgress(path))
+
+	saved := &aws.PullCheckpoint{
+		Environment: "prod",
+		Region:      "us-east-1",
+		Path:        "/myapp/prod/",
+		NextToken:   "token-3",
+		Pages:       2,
+		Vars:        map[string]string{"API_KEY": "secret", "PORT": "443"},
+	}
+	require.NoError(t, saveCheckpoint(path)(saved))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret", "values are sealed")
+	assert.NotContains(t, string(data), "API_KEY")
+
+	loaded, err := loadCheckpoint(path)
+	require.NoError(t, err)
+	assert.Equal(t, saved, loaded)
+	assert.True(t, hasProgress(path))
+
+	removeCheckpoint(path)
+	assert.NoFileExists(t, path)
+	assert.False(t, hasProgress(path))
+}
+
+func TestLoadCheckpoint_Corrupt(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "prod.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"environment": "prod", "pages": 2, "vars": {"A"`), 0600))
+
+	checkpoint, err := loadCheckpoint(path)
+	require.NoError(t, err)
+	assert.Zero(t, checkpoint.Pages, "a truncated checkpoint is started over")
+
+	// Sealed with the key of another user or a deleted key
+	require.NoError(t, saveCheckpoint(path)(&aws.PullCheckpoint{Environment: "prod", Pages: 2}))
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	checkpoint, err = loadCheckpoint(path)
+	require.NoError(t, err)
+	assert.Zero(t, checkpoint.Pages, "a checkpoint sealed with another key is started over")
+}
+
+func TestSaveCheckpoint_WithoutPath(t *testing.T) {
+	assert.NoError(t, saveCheckpoint("")(&aws.PullCheckpoint{Pages: 1}))
+	checkpoint, err := loadCheckpoint("")
+	require.NoError(t, err)
+	assert.Zero(t, checkpoint.Pages)
+}
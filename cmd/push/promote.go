@@ -0,0 +1,155 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/drapon/envy/internal/aws"
+	secrets_manager "github.com/drapon/envy/internal/aws/secrets_manager"
+	"github.com/drapon/envy/internal/changeplan"
+	"github.com/drapon/envy/internal/changeticket"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/history"
+	"github.com/drapon/envy/internal/policy"
+	"github.com/drapon/envy/internal/precheck"
+)
+
+// checkStageFlags rejects --stage and --promote where they do not apply
+func checkStageFlags(onAWS bool) error {
+	if stage == "" && promote == "" {
+		return nil
+	}
+	if !onAWS {
+		return fmt.Errorf("--stage and --promote are only supported with AWS Secrets Manager")
+	}
+	if atomic || parallelMode || savePlan != "" {
+		return fmt.Errorf("--stage and --promote cannot be used with --atomic, --parallel or --save-plan")
+	}
+	if stage == secrets_manager.StageCurrent || promote == secrets_manager.StageCurrent {
+		return fmt.Errorf("%s is what a push without --stage writes", secrets_manager.StageCurrent)
+	}
+	return nil
+}
+
+// promoteEnvironment moves AWSCURRENT of an environment to the version
+// labelled with --promote, after showing what changes for readers
+func promoteEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Manager, envName string, tickets *changeticket.Client) error {
+	staged, err := awsManager.PullEnvironmentVersion(ctx, envName, secrets_manager.VersionRef{Stage: promote})
+	if err != nil {
+		return fmt.Errorf("failed to read stage %s: %w", promote, err)
+	}
+	current, err := awsManager.ListEnvironmentVariables(ctx, envName)
+	if err != nil {
+		return fmt.Errorf("failed to read current values: %w", err)
+	}
+	// Variables without the stage keep their current value
+	if cfg.SecretPerVariable() {
+		for key, value := range current {
+			if _, ok := staged.Get(key); !ok {
+				staged.Set(key, value)
+			}
+		}
+	}
+
+	keys := staged.Keys()
+	for key := range current {
+		if _, ok := staged.Get(key); !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if err := precheck.Writers(ctx, cfg, awsManager, keys); err != nil {
+		return err
+	}
+	// Secrets Manager encrypts every value, so only the rules on the push
+	// itself apply
+	if err := checkPolicies(cfg, policy.Push{Environment: envName, Vars: staged, Force: force}); err != nil {
+		return err
+	}
+
+	changed := showPromotion(staged.ToMap(), current)
+	if dryRun {
+		color.PrintWarningf("\n[DRY RUN] No changes will be made")
+		color.PrintInfof("Would move %s of %s to stage %s", secrets_manager.StageCurrent, envName, promote)
+		return nil
+	}
+	if changed == 0 {
+		color.PrintInfof("Stage %s holds the current values of %s", promote, envName)
+	}
+	if !force && !confirmPush(changed, envName) {
+		color.PrintWarningf("Promotion cancelled")
+		return nil
+	}
+
+	var change *changeticket.Change
+	if tickets != nil {
+		plan := changeplan.New(envName, changeplan.NewTarget(cfg, envName), staged.ToMap(), current, true, awsManager.IsSensitive)
+		mentions, err := ownerMentions(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		change = changeticket.NewChange(cfg.Project, getTargetDescription(cfg, envName), ticket, plan, mentions)
+	}
+
+	pending, err := history.Begin(ctx, cfg, awsManager, envName, "promote", keys)
+	if err != nil {
+		color.PrintWarningf("This promotion will not be recorded for envy undo: %v", err)
+	}
+
+	promoted, err := awsManager.PromoteStage(ctx, envName, promote)
+	if err != nil {
+		return fmt.Errorf("promotion failed after %d secret(s): %w", promoted, err)
+	}
+	if err := pending.CommitApplied(ctx); err != nil {
+		color.PrintWarningf("Failed to record the promotion for envy undo: %v", err)
+	}
+
+	color.PrintSuccessf("Promoted stage %s of %s (%d secret(s)); readers now get its values", promote, envName, promoted)
+	fmt.Printf("Roll back with: envy push --env %s --promote %s\n", envName, secrets_manager.StagePrevious)
+	recordChange(ctx, tickets, change)
+	return nil
+}
+
+// showPromotion lists how the values readers get change when staged
+// becomes current, and returns the number of changed variables
+func showPromotion(staged, current map[string]string) int {
+	color.PrintBoldf("\nChanges when stage %s becomes current:", promote)
+	changed := 0
+	for _, key := range sortedKeys(staged, current) {
+		value, inStaged := staged[key]
+		old, inCurrent := current[key]
+		switch {
+		case !inCurrent:
+			fmt.Printf("  %s %s\n", color.FormatSuccess("+"), key)
+		case !inStaged:
+			fmt.Printf("  %s %s\n", color.FormatError("-"), key)
+		case value != old:
+			fmt.Printf("  %s %s\n", color.FormatWarning("~"), key)
+		default:
+			continue
+		}
+		changed++
+	}
+	if changed == 0 {
+		color.PrintInfof("  No changes detected")
+	}
+	return changed
+}
+
+// sortedKeys returns the keys of every map, sorted
+func sortedKeys(maps ...map[string]string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,46 @@
+package push
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckStageFlags(t *testing.T) {
+	defer resetFlags()
+	resetFlags()
+
+	assert.NoError(t, checkStageFlags(false), "without --stage or --promote anything goes")
+
+	stage = "green"
+	assert.NoError(t, checkStageFlags(true))
+	assert.ErrorContains(t, checkStageFlags(false), "only supported with AWS")
+
+	atomic = true
+	assert.ErrorContains(t, checkStageFlags(true), "--atomic")
+
+	resetFlags()
+	promote = "AWSCURRENT"
+	assert.ErrorContains(t, checkStageFlags(true), "without --stage")
+}
+
+func TestShowPromotion(t *testing.T) {
+	defer resetFlags()
+	resetFlags()
+	promote = "green"
+
+	var changed int
+	stdout, _ := testutil.CaptureOutput(t, func() {
+		changed = showPromotion(
+			map[string]string{"API_KEY": "new", "LOG_LEVEL": "info", "FEATURE_X": "on"},
+			map[string]string{"API_KEY": "old", "LOG_LEVEL": "info", "LEGACY_URL": "x"},
+		)
+	})
+
+	assert.Equal(t, 3, changed)
+	assert.Contains(t, stdout, "+ FEATURE_X")
+	assert.Contains(t, stdout, "~ API_KEY")
+	assert.Contains(t, stdout, "- LEGACY_URL")
+	assert.NotContains(t, stdout, "LOG_LEVEL")
+}
@@ -3,17 +3,35 @@ package push
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/drapon/envy/cmd/root"
 	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/aws/parameter_store"
+	"github.com/drapon/envy/internal/aws/quota"
+	"github.com/drapon/envy/internal/azure"
+	"github.com/drapon/envy/internal/changeplan"
+	"github.com/drapon/envy/internal/changeticket"
 	"github.com/drapon/envy/internal/color"
 	"github.com/drapon/envy/internal/config"
 	"github.com/drapon/envy/internal/env"
 	"github.com/drapon/envy/internal/errors"
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/history"
+	"github.com/drapon/envy/internal/inline"
 	"github.com/drapon/envy/internal/log"
+	"github.com/drapon/envy/internal/ownership"
 	"github.com/drapon/envy/internal/parallel"
+	"github.com/drapon/envy/internal/policy"
+	"github.com/drapon/envy/internal/precheck"
+	"github.com/drapon/envy/internal/prompt"
+	"github.com/drapon/envy/internal/remote"
+	"github.com/drapon/envy/internal/roster"
+	"github.com/drapon/envy/internal/schedule"
+	"github.com/drapon/envy/internal/structured"
 	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -21,19 +39,31 @@ import (
 )
 
 var (
-	environment    string
-	prefix         string
-	variables      string
-	force          bool
-	dryRun         bool
-	all            bool
-	showDiff       bool
-	parallelMode   bool
-	maxWorkers     int
-	batchSize      int
-	skipEmpty      bool
-	allowDuplicate bool
-	noProgress     bool
+	environment       string
+	prefix            string
+	filterOpts        filter.Options
+	force             bool
+	dryRun            bool
+	all               bool
+	showDiff          bool
+	parallelMode      bool
+	maxWorkers        int
+	batchSize         int
+	skipEmpty         bool
+	allowDuplicate    bool
+	noProgress        bool
+	validate          bool
+	allowPlaceholders bool
+	savePlan          string
+	atomic            bool
+	output            string
+	ticket            string
+	stage             string
+	promote           string
+
+	// planOutput receives the change plan of --output json; everything
+	// else goes to stderr then
+	planOutput io.Writer = os.Stdout
 )
 
 // pushCmd represents the push command
@@ -43,7 +73,57 @@ var pushCmd = &cobra.Command{
 	Long: `Push environment variables to AWS Parameter Store or Secrets Manager.
 
 This command reads your local .env files and uploads the variables to AWS
-based on your configuration in .envyrc.`,
+based on your configuration in .envyrc.
+
+With --validate, or validate_on_push: true in .envyrc, the variables are
+first checked against .envy-rules.yaml and nothing is pushed if any rule
+fails with an error. When only some variables are pushed (--keys, --filter),
+missing required variables are not reported.
+
+Values that look like placeholders (changeme, TODO, your-api-key-here,
+example.com addresses, ...) are reported before pushing. Pushing them to an
+environment marked protected: true fails unless the keys are listed under
+placeholders.allow in .envyrc or --allow-placeholders is given.
+
+Variables with allowed_writers in the variables section of .envyrc may only
+be pushed by those AWS principals. Others get a warning, or an error with
+enforce_writers: true.
+
+Pushes of 100 or more variables first check the Parameter Store quotas of
+the region ('envy quota status') and warn when they would exceed the
+standard parameter limit or be throttled.
+
+With --atomic the current values are read first, and if the push fails
+halfway the variables already written are restored to them, so the
+environment is never left half-updated. Replicated environments are always
+pushed this way.
+
+With --save-plan the variables are written to a plan file instead of being
+pushed, to be applied later with 'envy apply'.
+
+Pushes to the environments of change_tickets in .envyrc are recorded in a
+change ticket: the change, with sensitive values masked, is added to the
+ticket given with --ticket, or a new ticket is opened. With require_ticket,
+they fail without --ticket.
+
+Pushes are checked against the policies of .envyrc before anything is
+written: a policy may require --force or variables such as CHANGE_TICKET
+in the shell, or forbid storing values matching password patterns as
+String parameters. Broken policies refuse the push, or only warn with
+action: warn; --dry-run reports them without failing.
+
+With --output json, --dry-run and --save-plan print a change plan to stdout
+(schema envy.change-plan, version 1) listing each variable as create, update
+or unchanged, for policy engines or scripts to check before the change is
+applied; --output yaml prints the same plan in YAML. Values of sensitive
+variables are left out; all other output goes to stderr.
+
+Environments in Secrets Manager can be rolled out blue/green: --stage LABEL
+writes the values as a new version carrying only that label, so readers
+keep the current values while consumers pinned to the label (envy pull
+--stage) try them. --promote LABEL then moves AWSCURRENT to that version in
+one request, without reading local files; --promote AWSPREVIOUS rolls it
+back. Staged pushes and promotions are not replicated.`,
 	Example: `  # Push variables for the default environment
   envy push
   
@@ -54,13 +134,41 @@ based on your configuration in .envyrc.`,
   envy push --prefix "/myapp/prod/"
   
   # Push specific variables only
-  envy push --vars "API_KEY,DATABASE_URL"
+  envy push --keys "API_KEY,DATABASE_URL"
+
+  # Push only database settings
+  envy push --filter "DB_*"
   
   # Force overwrite existing parameters
   envy push --force
   
   # Dry run to see what would be pushed
-  envy push --dry-run`,
+  envy push --dry-run
+
+  # Restore the previous values if any variable fails to push
+  envy push --env prod --atomic
+
+  # Refuse to push values that break the validation rules
+  envy push --env prod --validate
+
+  # Apply the change to prod in 30 minutes, unless cancelled
+  envy push --env prod --save-plan plan.json
+  envy apply --after 30m plan.json
+
+  # Push to prod under an approved change ticket
+  envy push --env prod --ticket CHG0031337
+
+  # Push to prod under the prod policies of .envyrc
+  CHANGE_TICKET=CHG0031337 envy push --env prod --force
+
+  # Stage new values, check them, then switch every reader to them
+  envy push --env prod --stage green
+  envy pull --env prod --stage green --output .env.green
+  envy push --env prod --promote green
+
+  # Check the change with OPA before applying it
+  envy push --env prod --save-plan plan.json --output json > change.json
+  opa eval -d policy.rego -i change.json "data.envy.deny"`,
 	RunE: runPush,
 }
 
@@ -70,7 +178,8 @@ func init() {
 	// Add flags specific to push command
 	pushCmd.Flags().StringVarP(&environment, "env", "e", "", "Target environment")
 	pushCmd.Flags().StringVarP(&prefix, "prefix", "p", "", "AWS parameter prefix (overrides config)")
-	pushCmd.Flags().StringVarP(&variables, "vars", "v", "", "Comma-separated list of variables to push")
+	filter.AddFlags(pushCmd.Flags(), &filterOpts)
+	filter.AddDeprecatedAlias(pushCmd.Flags(), "vars", "v", "keys", &filterOpts.Keys)
 	pushCmd.Flags().BoolVarP(&force, "force", "f", false, "Force overwrite existing parameters")
 	pushCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be pushed without making changes")
 	pushCmd.Flags().BoolVarP(&all, "all", "a", false, "Push all environments")
@@ -81,21 +190,72 @@ func init() {
 	pushCmd.Flags().BoolVar(&skipEmpty, "skip-empty", true, "Skip variables with empty values")
 	pushCmd.Flags().BoolVar(&allowDuplicate, "allow-duplicate", false, "Allow duplicate variable names (use last value)")
 	pushCmd.Flags().BoolVar(&noProgress, "no-progress", false, "Disable progress bar")
+	pushCmd.Flags().BoolVar(&validate, "validate", false, "Check the variables against the validation rules before pushing (default: validate_on_push)")
+	pushCmd.Flags().BoolVar(&allowPlaceholders, "allow-placeholders", false, "Push placeholder values to protected environments")
+	pushCmd.Flags().BoolVar(&atomic, "atomic", false, "Roll back the variables already written if the push fails")
+	pushCmd.Flags().StringVar(&savePlan, "save-plan", "", "Write the variables to a plan file for 'envy apply' instead of pushing")
+	pushCmd.Flags().StringVarP(&output, "output", "o", "text", "Output format of --dry-run and --save-plan (text/json/yaml)")
+	pushCmd.Flags().StringVar(&ticket, "ticket", "", "Change ticket to record the push in (see change_tickets in .envyrc)")
+	pushCmd.Flags().StringVar(&stage, "stage", "", "Write a Secrets Manager version with this staging label, leaving AWSCURRENT unchanged")
+	pushCmd.Flags().StringVar(&promote, "promote", "", "Move AWSCURRENT to the Secrets Manager version with this staging label instead of pushing files")
+	pushCmd.MarkFlagsMutuallyExclusive("stage", "promote")
 }
 
 func runPush(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
+	// The global --output, or ENVY_OUTPUT, selects the format of plans
+	if !cmd.Flags().Changed("output") && (dryRun || savePlan != "") {
+		selected, err := structured.FromCommand(cmd)
+		if err != nil {
+			return err
+		}
+		if selected != structured.Default {
+			output = string(selected)
+		}
+	}
+
+	switch output {
+	case "text", "table":
+	case "json", "yaml":
+		if !dryRun && savePlan == "" {
+			return fmt.Errorf("--output %s requires --dry-run or --save-plan", output)
+		}
+		if all {
+			return fmt.Errorf("--output %s cannot be used with --all", output)
+		}
+		// Keep stdout for the change plan
+		var restore func()
+		planOutput, restore = structured.KeepStdout()
+		defer restore()
+	default:
+		return fmt.Errorf("unsupported output format: %s (use text, json or yaml)", output)
+	}
+
 	// Load configuration
 	cfg, err := config.Load(viper.GetString("config"))
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
+	if !cmd.Flags().Changed("validate") {
+		validate = cfg.ValidateOnPush
+	}
 
-	// Create AWS manager
-	awsManager, err := aws.NewManager(cfg)
+	// Create the AWS or Azure Key Vault backend
+	backend, err := remote.New(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create AWS manager: %w", err)
+		return err
+	}
+	_, onAWS := backend.(*aws.Manager)
+	if !onAWS && (atomic || savePlan != "") {
+		return fmt.Errorf("--atomic and --save-plan are only supported with AWS")
+	}
+	if err := checkStageFlags(onAWS); err != nil {
+		return err
+	}
+
+	if savePlan != "" && all {
+		return fmt.Errorf("--save-plan cannot be used with --all")
 	}
 
 	// Determine which environments to push
@@ -113,7 +273,7 @@ func runPush(cmd *cobra.Command, args []string) error {
 
 	// Process each environment
 	for _, envName := range environments {
-		if err := pushEnvironment(ctx, cfg, awsManager, envName); err != nil {
+		if err := pushEnvironment(ctx, cfg, backend, envName); err != nil {
 			return fmt.Errorf("failed to push environment %s: %w", envName, err)
 		}
 	}
@@ -121,15 +281,34 @@ func runPush(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func pushEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Manager, envName string) error {
+func pushEnvironment(ctx context.Context, cfg *config.Config, backend remote.Backend, envName string) error {
 	color.PrintInfof("Pushing environment: %s", envName)
 
+	// Replication, undo history and allowed writers are AWS features
+	awsManager, onAWS := backend.(*aws.Manager)
+
 	// Get environment configuration
 	envConfig, err := cfg.GetEnvironment(envName)
 	if err != nil {
 		return err
 	}
 
+	// Pushes to ticketed environments are checked for a ticket before
+	// anything else, and recorded in it once done
+	var tickets *changeticket.Client
+	if cfg.ChangeTicketed(envName) && !dryRun && savePlan == "" {
+		if cfg.ChangeTickets.RequireTicket && ticket == "" {
+			return fmt.Errorf("pushes to %s need a change ticket: push with --ticket ID", envName)
+		}
+		if tickets, err = changeticket.New(cfg.ChangeTickets); err != nil {
+			return err
+		}
+	}
+
+	if promote != "" {
+		return promoteEnvironment(ctx, cfg, awsManager, envName, tickets)
+	}
+
 	// Create environment manager
 	envManager := env.NewManager(".")
 
@@ -139,21 +318,19 @@ func pushEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Ma
 		return fmt.Errorf("failed to load environment files: %w", err)
 	}
 
-	// Filter variables if specified
-	if variables != "" {
-		varsToKeep := strings.Split(variables, ",")
-		filteredFile := env.NewFile()
-
-		for _, varName := range varsToKeep {
-			varName = strings.TrimSpace(varName)
-			if value, exists := envFile.Get(varName); exists {
-				filteredFile.Set(varName, value)
-			} else {
-				color.PrintWarningf("Variable %s not found in local files", varName)
-			}
-		}
+	// Apply --filter/--exclude/--keys
+	keyFilter, err := filter.New(filterOpts)
+	if err != nil {
+		return err
+	}
+	for _, key := range keyFilter.MissingKeys(envFile) {
+		color.PrintWarningf("Variable %s not found in local files", key)
+	}
+	envFile = keyFilter.ApplyFile(envFile)
 
-		envFile = filteredFile
+	// Decrypt the ENC[...] values of sealed files
+	if err := inline.Decrypt(ctx, cfg, envFile); err != nil {
+		return err
 	}
 
 	// Check for duplicate keys
@@ -176,13 +353,42 @@ func pushEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Ma
 		envFile = filteredFile
 	}
 
+	if err := precheck.Placeholders(cfg, envName, envConfig, envFile, allowPlaceholders); err != nil {
+		return fmt.Errorf("%w; --allow-placeholders pushes them anyway", err)
+	}
+	if onAWS {
+		if err := precheck.Writers(ctx, cfg, awsManager, envFile.Keys()); err != nil {
+			return err
+		}
+	}
+
+	if onAWS && cfg.GetAWSService(envName) != "secrets_manager" && !envConfig.UseSecretsManager && !cfg.AWS.LargeValuesToSecretsManager {
+		warnLargeValues(envFile)
+	}
+
+	// Large pushes can run into the parameter limit or get throttled
+	if onAWS && len(envFile.Keys()) >= quota.LargePush {
+		checkQuotas(ctx, awsManager, envName, envFile.Keys())
+	}
+
+	if validate {
+		if err := precheck.Validate(ctx, envName, envFile, envConfig.Files, keyFilter.Active()); err != nil {
+			return err
+		}
+	}
+
+	push := policy.Push{Environment: envName, Vars: envFile, Force: force, Plaintext: plaintext(cfg, backend, envName)}
+	if err := checkPolicies(cfg, push); err != nil {
+		return err
+	}
+
 	// Show what will be pushed
 	color.PrintBoldf("\nVariables to push:")
 	skippedEmpty := 0
 	for _, key := range envFile.SortedKeys() {
 		value, _ := envFile.Get(key)
 		displayValue := value
-		if isSensitive(key) {
+		if backend.IsSensitive(key) {
 			displayValue = "***HIDDEN***"
 		}
 		if value == "" && skipEmpty {
@@ -198,7 +404,7 @@ func pushEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Ma
 	// Get current remote variables if showing diff
 	if showDiff && !dryRun {
 		color.PrintInfof("\nFetching current remote values...")
-		remoteVars, err := awsManager.ListEnvironmentVariables(ctx, envName)
+		remoteVars, err := backend.ListEnvironmentVariables(ctx, envName)
 		if err != nil {
 			color.PrintWarningf("Could not fetch remote values: %v", err)
 		} else {
@@ -206,6 +412,21 @@ func pushEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Ma
 		}
 	}
 
+	if output == "json" || output == "yaml" {
+		if err := writeChangePlan(ctx, cfg, backend, envName, envFile); err != nil {
+			return err
+		}
+	}
+
+	if savePlan != "" {
+		if err := savePushPlan(savePlan, envName, envFile, force); err != nil {
+			return err
+		}
+		color.PrintSuccessf("\nWrote plan for %d variables to %s", len(envFile.Keys()), savePlan)
+		fmt.Printf("Apply it with: envy apply --after 30m %s\n", savePlan)
+		return nil
+	}
+
 	// Dry run mode
 	if dryRun {
 		color.PrintWarningf("\n[DRY RUN] No changes will be made")
@@ -219,25 +440,207 @@ func pushEnvironment(ctx context.Context, cfg *config.Config, awsManager *aws.Ma
 		return nil
 	}
 
-	// Push to AWS
+	// The ticket describes the change against the values before the push
+	var change *changeticket.Change
+	if tickets != nil {
+		remoteVars, err := backend.ListEnvironmentVariables(ctx, envName)
+		if err != nil {
+			return fmt.Errorf("failed to read current values for the change ticket: %w", err)
+		}
+		plan := changeplan.New(envName, changeplan.NewTarget(cfg, envName), envFile.ToMap(), remoteVars, force, backend.IsSensitive)
+		mentions, err := ownerMentions(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		change = changeticket.NewChange(cfg.Project, getTargetDescription(cfg, envName), ticket, plan, mentions)
+	}
+
 	color.PrintInfof("\nPushing to %s...", getTargetDescription(cfg, envName))
 
-	if parallelMode {
-		// Use parallel push
-		if err := pushParallel(ctx, awsManager, envName, envFile, force); err != nil {
-			return fmt.Errorf("parallel push failed: %w", err)
+	if !onAWS {
+		if err := backend.PushEnvironment(ctx, envName, envFile, force); err != nil {
+			return fmt.Errorf("push failed: %w", err)
+		}
+		color.PrintSuccessf("Successfully pushed %d variables to %s", len(envFile.Keys()), envName)
+		recordChange(ctx, tickets, change)
+		return nil
+	}
+
+	// A staged version is not current, so there is nothing to undo yet
+	if stage != "" {
+		if err := awsManager.PushEnvironmentStaged(ctx, envName, envFile, stage); err != nil {
+			return fmt.Errorf("push failed: %w", err)
+		}
+		color.PrintSuccessf("Staged %d variables in %s as %s; readers keep the current values", len(envFile.Keys()), envName, stage)
+		fmt.Printf("Promote them with: envy push --env %s --promote %s\n", envName, stage)
+		recordChange(ctx, tickets, change)
+		return nil
+	}
+
+	// Remember the current values so the push can be undone with envy undo
+	pending, err := history.Begin(ctx, cfg, awsManager, envName, "push", envFile.Keys())
+	if err != nil {
+		color.PrintWarningf("This push will not be recorded for envy undo: %v", err)
+	}
+
+	// Replica regions set up with 'envy replicate' are written after the
+	// primary, with rollback if any region fails. --atomic rolls back a
+	// failed push without replicas too.
+	pushFn := awsManager.PushReplicated
+	if atomic {
+		pushFn = awsManager.PushAtomic
+	}
+	err = pushFn(ctx, envName, envFile.Keys(), func() error {
+		if parallelMode {
+			// Use parallel push
+			if err := pushParallel(ctx, awsManager, envName, envFile, force); err != nil {
+				return fmt.Errorf("parallel push failed: %w", err)
+			}
+			return nil
 		}
-	} else {
 		// Use sequential push with progress
 		if err := pushWithProgress(ctx, awsManager, envName, envFile, force); err != nil {
 			return fmt.Errorf("push failed: %w", err)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Existing keys are skipped without --force, so record what changed
+	if err := pending.CommitApplied(ctx); err != nil {
+		color.PrintWarningf("Failed to record the push for envy undo: %v", err)
 	}
 
 	color.PrintSuccessf("Successfully pushed %d variables to %s", len(envFile.Keys()), envName)
+	recordChange(ctx, tickets, change)
 	return nil
 }
 
+// ownerMentions returns how to mention the owners of a key in a change
+// ticket, using the roster when there is one
+func ownerMentions(ctx context.Context, cfg *config.Config) (func(key string) []string, error) {
+	rules, err := ownership.ParseRules(cfg.Variables)
+	if err != nil {
+		return nil, err
+	}
+	contacts, err := roster.Load(ctx, cfg)
+	if err != nil {
+		color.PrintWarningf("Owners are mentioned by name in the change ticket: %v", err)
+	}
+	return func(key string) []string {
+		return contacts.Mentions(ownership.Owners(rules, key))
+	}, nil
+}
+
+// plaintext returns what a push to envName stores unencrypted, for the
+// policies: the String parameters of Parameter Store
+func plaintext(cfg *config.Config, backend remote.Backend, envName string) func(key string) bool {
+	if _, onAWS := backend.(*aws.Manager); !onAWS || cfg.GetAWSService(envName) == "secrets_manager" {
+		return nil
+	}
+	return func(key string) bool { return !backend.IsSensitive(key) }
+}
+
+// checkPolicies checks a push against the policies of .envyrc. A dry run
+// only reports what they would refuse.
+func checkPolicies(cfg *config.Config, push policy.Push) error {
+	err := policy.Check(cfg, push)
+	if err != nil && dryRun {
+		color.PrintWarningf("[DRY RUN] %v", err)
+		return nil
+	}
+	return err
+}
+
+// recordChange records a finished push in its change ticket. The push
+// cannot be taken back at this point, so failures are only reported.
+func recordChange(ctx context.Context, tickets *changeticket.Client, change *changeticket.Change) {
+	if tickets == nil {
+		return
+	}
+	id, err := tickets.Record(ctx, change)
+	switch {
+	case err != nil:
+		color.PrintErrorf("The push was not recorded in a change ticket: %v", err)
+	case id != "" && change.Ticket != "":
+		color.PrintInfof("Recorded the change in ticket %s", id)
+	case id != "":
+		color.PrintInfof("Opened change ticket %s", id)
+	}
+}
+
+// savePushPlan writes the variables about to be pushed to a plan file
+func savePushPlan(filename, envName string, envFile *env.File, force bool) error {
+	if len(envFile.Keys()) == 0 {
+		return fmt.Errorf("no variables to write to the plan")
+	}
+	plan := &schedule.Plan{Environment: envName, Set: envFile.ToMap(), Force: force}
+	return plan.Save(filename)
+}
+
+// warnLargeValues warns about values over the Parameter Store size limit
+// before the push fails on them. Multiline values such as certificate
+// chains are measured as stored, with their newlines.
+func warnLargeValues(envFile *env.File) {
+	for _, key := range largeValues(envFile) {
+		value, _ := envFile.Get(key)
+		lines := ""
+		if n := strings.Count(value, "\n") + 1; n > 1 {
+			lines = fmt.Sprintf(", %d lines", n)
+		}
+		color.PrintWarningf("%s is %d bytes%s, over the Parameter Store limit of %d bytes", key, len(value), lines, parameter_store.MaxValueSize)
+		fmt.Println("  Set aws.large_values_to_secrets_manager in .envyrc to store it in Secrets Manager")
+	}
+}
+
+// largeValues returns the variables too large for a standard parameter,
+// sorted
+func largeValues(envFile *env.File) []string {
+	var keys []string
+	for _, key := range envFile.SortedKeys() {
+		if value, _ := envFile.Get(key); len(value) > parameter_store.MaxValueSize {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// checkQuotas warns when a push would exceed the parameter limit of the
+// region or be throttled. It never stops the push.
+func checkQuotas(ctx context.Context, awsManager *aws.Manager, envName string, keys []string) {
+	workers := 1
+	if parallelMode {
+		workers = maxWorkers
+	}
+
+	warnings, err := awsManager.PushPreflight(ctx, envName, keys, workers)
+	if err != nil {
+		color.PrintWarningf("Cannot check quotas: %v", err)
+		return
+	}
+	for _, warning := range warnings {
+		color.PrintWarningf("%s", warning.Message)
+		fmt.Printf("  %s\n", warning.Suggestion)
+	}
+}
+
+// writeChangePlan compares the variables about to be pushed with the remote
+// ones and writes the change plan to planOutput
+func writeChangePlan(ctx context.Context, cfg *config.Config, backend remote.Backend, envName string, envFile *env.File) error {
+	remoteVars, err := backend.ListEnvironmentVariables(ctx, envName)
+	if err != nil {
+		return fmt.Errorf("failed to read current values: %w", err)
+	}
+
+	plan := changeplan.New(envName, changeplan.NewTarget(cfg, envName), envFile.ToMap(), remoteVars, force, backend.IsSensitive)
+	if output == "yaml" {
+		return structured.Write(planOutput, structured.YAML, plan)
+	}
+	return plan.Write(planOutput)
+}
+
 func showDifferences(local, remote map[string]string) {
 	color.PrintBoldf("\nDifferences:")
 
@@ -292,19 +695,17 @@ func showDifferences(local, remote map[string]string) {
 }
 
 func confirmPush(count int, envName string) bool {
-	fmt.Printf("\n%s Continue? [y/N]: ", color.FormatWarning(fmt.Sprintf("About to push %d variables to %s.", count, envName)))
-
-	var response string
-	fmt.Scanln(&response)
-
-	response = strings.ToLower(strings.TrimSpace(response))
-	return response == "y" || response == "yes"
+	return prompt.Confirm(color.FormatWarning(fmt.Sprintf("About to push %d variables to %s.", count, envName)) + " Continue?")
 }
 
 func getTargetDescription(cfg *config.Config, envName string) string {
+	if cfg.UsesAzure() {
+		return azure.Target(cfg, envName)
+	}
+
 	service := cfg.GetAWSService(envName)
 	path := cfg.GetParameterPath(envName)
-	region := cfg.AWS.Region
+	region := cfg.GetRegion(envName)
 
 	if service == "secrets_manager" {
 		return fmt.Sprintf("AWS Secrets Manager (%s)", region)
@@ -404,11 +805,11 @@ func createParameterStoreTask(key, value, path string, overwrite bool, manager *
 	if !strings.HasSuffix(paramName, "/") {
 		paramName = paramName + "/"
 	}
-	paramName = paramName + key
+	paramName = paramName + manager.RemoteKey(key)
 
 	// Determine parameter type
 	paramType := "String"
-	if isSensitive(key) {
+	if manager.IsSensitive(key) {
 		paramType = "SecureString"
 	}
 
@@ -426,63 +827,18 @@ func createSecretsManagerTask(key, value, path string, overwrite bool, manager *
 	// For Secrets Manager, we typically batch all variables into one secret
 	// This is a simplified version for individual variables
 	secretName := strings.Trim(path, "/")
-	secretName = strings.ReplaceAll(secretName, "/", "-") + "-" + key
+	remoteKey := manager.RemoteKey(key)
+	secretName = strings.ReplaceAll(secretName, "/", "-") + "-" + remoteKey
 
 	return parallel.NewTaskFunc(
 		key,
 		func(ctx context.Context) error {
-			return manager.PutSecret(ctx, secretName, map[string]string{key: value}, overwrite)
+			return manager.PutSecret(ctx, secretName, map[string]string{remoteKey: value}, overwrite)
 		},
 		true, // Retriable
 	)
 }
 
-// isSensitive checks if the key represents a sensitive value
-func isSensitive(key string) bool {
-	// Exact matches (case-insensitive)
-	exactMatches := []string{
-		"password", "passwd", "pwd", "secret", "token",
-		"api_key", "apikey", "access_key", "accesskey",
-		"private_key", "privatekey", "auth_token", "authtoken",
-	}
-
-	// Suffix patterns (must end with these)
-	suffixPatterns := []string{
-		"_password", "_passwd", "_pwd", "_secret", "_token",
-		"_key", "_auth", "_credential", "_private",
-	}
-
-	// Prefix patterns (must start with these)
-	prefixPatterns := []string{
-		"secret_", "private_", "auth_",
-	}
-
-	keyLower := strings.ToLower(key)
-
-	// Check exact matches
-	for _, pattern := range exactMatches {
-		if keyLower == pattern {
-			return true
-		}
-	}
-
-	// Check suffix patterns
-	for _, pattern := range suffixPatterns {
-		if strings.HasSuffix(keyLower, pattern) {
-			return true
-		}
-	}
-
-	// Check prefix patterns
-	for _, pattern := range prefixPatterns {
-		if strings.HasPrefix(keyLower, pattern) {
-			return true
-		}
-	}
-
-	return false
-}
-
 // pushWithProgress pushes environment variables with a progress bar
 func pushWithProgress(ctx context.Context, awsManager *aws.Manager, envName string, envFile *env.File, overwrite bool) error {
 	cfg := awsManager.GetConfig()
@@ -550,11 +906,11 @@ func pushWithProgress(ctx context.Context, awsManager *aws.Manager, envName stri
 			continue
 		}
 
-		paramName := path + key
+		paramName := path + awsManager.RemoteKey(key)
 
 		// Determine parameter type
 		paramType := "String"
-		if isSensitive(key) {
+		if awsManager.IsSensitive(key) {
 			paramType = "SecureString"
 		}
 
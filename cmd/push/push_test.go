@@ -1,15 +1,22 @@
 package push
 
 import (
+	"context"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/schedule"
+	"github.com/drapon/envy/internal/sensitive"
 	"github.com/drapon/envy/internal/testutil"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPushCmd_Flags(t *testing.T) {
@@ -26,6 +33,10 @@ func TestPushCmd_Flags(t *testing.T) {
 	assert.NotNil(t, cmd.Flags().Lookup("dry-run"))
 	assert.NotNil(t, cmd.Flags().Lookup("all"))
 	assert.NotNil(t, cmd.Flags().Lookup("diff"))
+	assert.NotNil(t, cmd.Flags().Lookup("validate"))
+	assert.NotNil(t, cmd.Flags().Lookup("atomic"))
+	assert.NotNil(t, cmd.Flags().Lookup("output"))
+	assert.NotNil(t, cmd.Flags().Lookup("ticket"))
 	assert.NotNil(t, cmd.Flags().Lookup("parallel"))
 	assert.NotNil(t, cmd.Flags().Lookup("max-workers"))
 	assert.NotNil(t, cmd.Flags().Lookup("batch-size"))
@@ -118,7 +129,7 @@ func TestIsSensitive(t *testing.T) {
 		key := tc.Input.(string)
 		expected := tc.Expected.(bool)
 
-		actual := isSensitive(key)
+		actual := sensitive.IsSensitive(key)
 		assert.Equal(t, expected, actual)
 	})
 }
@@ -299,7 +310,7 @@ func TestRunPush_FlagParsing(t *testing.T) {
 				assert.Equal(t, tc.env, environment)
 			}
 			if tc.vars != "" {
-				assert.Equal(t, tc.vars, variables)
+				assert.Equal(t, []string{tc.vars}, filterOpts.Keys)
 			}
 			assert.Equal(t, tc.force, force)
 			assert.Equal(t, tc.dryRun, dryRun)
@@ -446,7 +457,7 @@ func TestCreateParameterStoreTask(t *testing.T) {
 	// Test parameter type determination
 	t.Run("string_parameter", func(t *testing.T) {
 		paramType := "String"
-		if isSensitive(key) {
+		if sensitive.IsSensitive(key) {
 			paramType = "SecureString"
 		}
 		assert.Equal(t, "String", paramType, "TEST_VAR should not be sensitive")
@@ -455,7 +466,7 @@ func TestCreateParameterStoreTask(t *testing.T) {
 	t.Run("secure_parameter", func(t *testing.T) {
 		sensitiveKey := "API_SECRET"
 		paramType := "String"
-		if isSensitive(sensitiveKey) {
+		if sensitive.IsSensitive(sensitiveKey) {
 			paramType = "SecureString"
 		}
 		assert.Equal(t, "SecureString", paramType)
@@ -484,7 +495,7 @@ func BenchmarkIsSensitive(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		key := keys[i%len(keys)]
-		_ = isSensitive(key)
+		_ = sensitive.IsSensitive(key)
 	}
 }
 
@@ -505,7 +516,7 @@ func BenchmarkGetTargetDescription(b *testing.B) {
 func resetFlags() {
 	environment = ""
 	prefix = ""
-	variables = ""
+	filterOpts = filter.Options{}
 	force = false
 	dryRun = false
 	all = false
@@ -513,6 +524,11 @@ func resetFlags() {
 	parallelMode = false
 	maxWorkers = 10
 	batchSize = 10
+	atomic = false
+	output = "text"
+	ticket = ""
+	stage = ""
+	promote = ""
 }
 
 // Test helper to setup test environment
@@ -636,7 +652,7 @@ func TestPushCmd_ConcurrentOperations(t *testing.T) {
 		cfg.GetEnvironment("test")
 		cfg.GetAWSService("test")
 		cfg.GetParameterPath("test")
-		isSensitive("TEST_KEY")
+		sensitive.IsSensitive("TEST_KEY")
 		getTargetDescription(cfg, "test")
 	}, 10, 100)
 }
@@ -656,7 +672,7 @@ func TestPushCmd_MemoryUsage(t *testing.T) {
 			cfg.GetAWSService("test")
 
 			for key := range vars {
-				isSensitive(key)
+				sensitive.IsSensitive(key)
 			}
 		}
 	}, 100) // 100MB limit
@@ -673,7 +689,7 @@ func TestPushCmd_Performance(t *testing.T) {
 
 		// Simulate processing variables
 		for key := range vars {
-			isSensitive(key)
+			sensitive.IsSensitive(key)
 		}
 
 		getTargetDescription(cfg, "test")
@@ -681,3 +697,114 @@ func TestPushCmd_Performance(t *testing.T) {
 		cfg.GetParameterPath("test")
 	}, 500*time.Millisecond, "processing 1000 variables")
 }
+
+func TestSavePushPlan(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "plan.json")
+
+	assert.Error(t, savePushPlan(filename, "prod", env.NewFile(), false))
+
+	file := env.NewFile()
+	file.Set("API_URL", "https://api.example.org")
+	file.Set("PORT", "8080")
+	require.NoError(t, savePushPlan(filename, "prod", file, true))
+
+	plan, err := schedule.LoadPlan(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "prod", plan.Environment)
+	assert.Equal(t, map[string]string{"API_URL": "https://api.example.org", "PORT": "8080"}, plan.Set)
+	assert.Empty(t, plan.Delete)
+	assert.True(t, plan.Force)
+}
+
+func TestRunPush_OutputFormat(t *testing.T) {
+	defer resetFlags()
+
+	resetFlags()
+	output = "json"
+	assert.ErrorContains(t, runPush(pushCmd, nil), "requires --dry-run or --save-plan")
+
+	dryRun = true
+	all = true
+	assert.ErrorContains(t, runPush(pushCmd, nil), "cannot be used with --all")
+
+	resetFlags()
+	output = "yaml"
+	assert.ErrorContains(t, runPush(pushCmd, nil), "--output yaml requires --dry-run or --save-plan")
+
+	resetFlags()
+	output = "xml"
+	assert.ErrorContains(t, runPush(pushCmd, nil), "unsupported output format")
+}
+
+func TestLargeValues(t *testing.T) {
+	envFile := env.NewFile()
+	envFile.Set("SMALL", "value")
+	envFile.Set("CHAIN", strings.Repeat("MIIBxTCCAWugAwIBAgIU\n", 250))
+	envFile.Set("EXACT", strings.Repeat("x", 4096))
+
+	assert.Equal(t, []string{"CHAIN"}, largeValues(envFile))
+}
+
+func TestPushEnvironment_RequiresTicket(t *testing.T) {
+	defer resetFlags()
+	resetFlags()
+
+	cfg := testutil.CreateTestConfig()
+	cfg.ChangeTickets = &config.ChangeTicketConfig{Environments: []string{"prod"}, RequireTicket: true}
+
+	err := pushEnvironment(context.Background(), cfg, nil, "prod")
+	assert.ErrorContains(t, err, "--ticket")
+}
+
+// fakeBackend records pushes, for tests that must not reach AWS
+type fakeBackend struct {
+	pushed *env.File
+}
+
+func (f *fakeBackend) PullEnvironment(ctx context.Context, envName string) (*env.File, error) {
+	return env.NewFile(), nil
+}
+
+func (f *fakeBackend) PushEnvironment(ctx context.Context, envName string, file *env.File, overwrite bool) error {
+	f.pushed = file
+	return nil
+}
+
+func (f *fakeBackend) ListEnvironmentVariables(ctx context.Context, envName string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (f *fakeBackend) IsSensitive(key string) bool {
+	return false
+}
+
+func TestPushEnvironment_Policies(t *testing.T) {
+	defer resetFlags()
+	resetFlags()
+
+	tempDir := testutil.TempDir(t)
+	testutil.ChangeDir(t, tempDir)
+	testutil.WriteFile(t, tempDir, ".env.prod", "PORT=8080\n")
+
+	cfg := testutil.CreateTestConfig()
+	cfg.Environments = map[string]config.Environment{"prod": {Files: []string{".env.prod"}}}
+	cfg.Policies = []config.PolicyConfig{{Name: "prod-force", Environments: []string{"prod"}, RequireForce: true}}
+	backend := &fakeBackend{}
+
+	err := pushEnvironment(context.Background(), cfg, backend, "prod")
+	assert.ErrorContains(t, err, "refused by policy prod-force")
+	assert.Nil(t, backend.pushed, "nothing is written")
+
+	dryRun = true
+	require.NoError(t, pushEnvironment(context.Background(), cfg, backend, "prod"))
+	assert.Nil(t, backend.pushed)
+
+	dryRun = false
+	force = true
+	require.NoError(t, pushEnvironment(context.Background(), cfg, backend, "prod"))
+	require.NotNil(t, backend.pushed)
+	assert.Equal(t, []string{"PORT"}, backend.pushed.Keys())
+
+	// Key Vault encrypts every value
+	assert.Nil(t, plaintext(cfg, backend, "prod"))
+}
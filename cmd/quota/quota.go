@@ -0,0 +1,119 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/aws/quota"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// quotaCmd represents the quota command
+var quotaCmd = &cobra.Command{
+	Use:   "quota",
+	Short: "Check Parameter Store and Secrets Manager quotas",
+	Long: `Check the service quotas envy depends on: the number of parameters and
+secrets per account and region, and the request rates of Parameter Store and
+Secrets Manager.
+
+Pushes of 100 or more variables run the same check first and warn when they
+would exceed the standard parameter limit or be throttled.`,
+}
+
+// statusCmd represents the quota status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show quota usage for the configured region",
+	Long: `Show how many parameters and secrets the account has in the configured
+region against their limits, and the request rate limits.
+
+Limits come from Service Quotas (servicequotas:ListServiceQuotas). Without
+access to it the defaults documented by AWS are shown, marked as default.`,
+	Example: `  envy quota status`,
+	Args:    cobra.NoArgs,
+	RunE:    runStatus,
+}
+
+// GetQuotaCmd returns the quota command.
+func GetQuotaCmd() *cobra.Command {
+	return quotaCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(quotaCmd)
+	quotaCmd.AddCommand(statusCmd)
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.UsesAzure() {
+		return fmt.Errorf("envy quota is only supported with AWS")
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	limits, err := awsManager.QuotaLimits(ctx)
+	if err != nil {
+		color.PrintWarningf("Cannot read Service Quotas, showing default limits: %v", err)
+	}
+	usage, err := awsManager.QuotaUsage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count parameters and secrets: %w", err)
+	}
+
+	color.PrintBoldf("Quotas in %s:\n", cfg.AWS.Region)
+	near := printStatus(limits, usage)
+	if near > 0 {
+		color.PrintWarningf("\n%d quota(s) at %.0f%% or more of the limit", near, quota.NearLimit*100)
+	}
+	return nil
+}
+
+// printStatus prints the limits with their usage and returns how many are
+// near the limit
+func printStatus(limits quota.Limits, usage quota.Usage) int {
+	used := map[*quota.Limit]int{
+		&limits.StandardParameters: usage.StandardParameters,
+		&limits.AdvancedParameters: usage.AdvancedParameters,
+		&limits.Secrets:            usage.Secrets,
+	}
+
+	near := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "QUOTA\tUSED\tLIMIT\tSOURCE\t")
+	for _, limit := range limits.All() {
+		source := "default"
+		if limit.Applied {
+			source = "service quotas"
+		}
+
+		// Request rates are not counted
+		count, counted := used[limit]
+		usedText, note := "-", ""
+		if counted {
+			usedText = fmt.Sprintf("%d (%d%%)", count, quota.Percent(count, *limit))
+			if quota.Near(count, *limit) {
+				note = color.FormatWarning("near limit")
+				near++
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", limit.Name, usedText, limit.FormatValue(), source, note)
+	}
+	w.Flush()
+	return near
+}
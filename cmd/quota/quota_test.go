@@ -0,0 +1,25 @@
+package quota
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/aws/quota"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetQuotaCmd(t *testing.T) {
+	cmd := GetQuotaCmd()
+	assert.Equal(t, "quota", cmd.Use)
+
+	names := []string{}
+	for _, sub := range cmd.Commands() {
+		names = append(names, sub.Name())
+	}
+	assert.Contains(t, names, "status")
+}
+
+func TestPrintStatus(t *testing.T) {
+	limits := quota.DefaultLimits()
+	assert.Equal(t, 0, printStatus(limits, quota.Usage{StandardParameters: 120, Secrets: 3}))
+	assert.Equal(t, 1, printStatus(limits, quota.Usage{StandardParameters: 9000}))
+}
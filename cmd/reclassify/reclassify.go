@@ -0,0 +1,148 @@
+package reclassify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/precheck"
+	"github.com/drapon/envy/internal/prompt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	environment string
+	keys        []string
+	toType      string
+	kmsKey      string
+	dryRun      bool
+	force       bool
+)
+
+// reclassifyCmd represents the reclassify command
+var reclassifyCmd = &cobra.Command{
+	Use:   "reclassify",
+	Short: "Change the type of existing parameters between String and SecureString",
+	Long: `Change the type of the parameters matching --key to --type.
+
+Parameter Store cannot change the type of a parameter in place, so each one
+is deleted and created again with the same value, description, tier and
+tags. Its version history starts over: the tag envy:reclassified-from
+records the type and version it had. Readers may briefly find a parameter
+missing while it is recreated, and replicas in other regions are not
+changed.
+
+Pushes choose the type from the sensitivity rules, so add the keys to
+sensitivity.plain or sensitivity.sensitive in .envyrc to keep the new type.`,
+	Example: `  # Store the public variables of prod as plain String parameters
+  envy reclassify --env prod --key 'PUBLIC_*' --type String --dry-run
+  envy reclassify --env prod --key 'PUBLIC_*' --type String
+
+  # Encrypt two variables with a customer managed key
+  envy reclassify --env prod --key WEBHOOK_URL --key SENTRY_DSN --type SecureString --kms-key alias/envy`,
+	Args: cobra.NoArgs,
+	RunE: runReclassify,
+}
+
+// GetReclassifyCmd returns the reclassify command.
+func GetReclassifyCmd() *cobra.Command {
+	return reclassifyCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(reclassifyCmd)
+
+	reclassifyCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to change (default: default_environment)")
+	reclassifyCmd.Flags().StringSliceVar(&keys, "key", nil, "Variables or patterns to reclassify (repeatable)")
+	reclassifyCmd.Flags().StringVar(&toType, "type", "", "New type: String or SecureString")
+	reclassifyCmd.Flags().StringVar(&kmsKey, "kms-key", "", "KMS key of new SecureString parameters (default: the environment's kms_key_id, or the account's key)")
+	reclassifyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be reclassified without making changes")
+	reclassifyCmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation")
+	_ = reclassifyCmd.MarkFlagRequired("key")
+	_ = reclassifyCmd.MarkFlagRequired("type")
+}
+
+func runReclassify(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if kmsKey != "" && toType != "SecureString" {
+		return fmt.Errorf("--kms-key only applies with --type SecureString")
+	}
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.UsesAzure() {
+		return fmt.Errorf("envy reclassify is only supported with AWS")
+	}
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+	if _, err := cfg.GetEnvironment(environment); err != nil {
+		return err
+	}
+
+	match, err := filter.New(filter.Options{Include: keys})
+	if err != nil {
+		return err
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+	plan, err := awsManager.PlanReclassify(ctx, environment, match.Match, toType)
+	if err != nil {
+		return err
+	}
+	if len(plan) == 0 {
+		color.PrintInfof("No parameters of %s matching %s need to become %s", environment, strings.Join(keys, ", "), toType)
+		return nil
+	}
+
+	color.PrintBoldf("Reclassifying %d parameter(s) in %s:", len(plan), environment)
+	planned := make([]string, 0, len(plan))
+	for _, r := range plan {
+		fmt.Printf("  %s %s: %s → %s (history ends at version %d)\n", color.FormatWarning("~"), r.Key, r.From, r.To, r.Version)
+		planned = append(planned, r.Key)
+	}
+
+	if err := precheck.Writers(ctx, cfg, awsManager, planned); err != nil {
+		return err
+	}
+
+	if dryRun {
+		color.PrintWarningf("\n[DRY RUN] No changes will be made")
+		return nil
+	}
+
+	if !force && !confirm(len(plan), environment) {
+		color.PrintWarningf("Reclassification cancelled")
+		return nil
+	}
+
+	now := time.Now()
+	for i, r := range plan {
+		if err := awsManager.Reclassify(ctx, r, kmsKey, now); err != nil {
+			if i > 0 {
+				color.PrintWarningf("Reclassified %s before the failure", strings.Join(planned[:i], ", "))
+			}
+			return err
+		}
+		fmt.Printf("  %s %s\n", color.FormatSuccess("✓"), r.Key)
+	}
+	color.PrintSuccessf("Reclassified %d parameter(s) in %s as %s", len(plan), environment, toType)
+	return nil
+}
+
+func confirm(count int, envName string) bool {
+	return prompt.Confirm(color.FormatWarning(fmt.Sprintf("About to delete and recreate %d parameters in %s, resetting their version history.", count, envName)) + " Continue?")
+}
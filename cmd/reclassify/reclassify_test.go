@@ -0,0 +1,21 @@
+package reclassify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetReclassifyCmd(t *testing.T) {
+	cmd := GetReclassifyCmd()
+	assert.Equal(t, "reclassify", cmd.Use)
+	for _, name := range []string{"env", "key", "type", "kms-key", "dry-run", "force"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestRunReclassify_KMSKeyNeedsSecureString(t *testing.T) {
+	defer func() { kmsKey, toType = "", "" }()
+	kmsKey, toType = "alias/envy", "String"
+	assert.ErrorContains(t, runReclassify(reclassifyCmd, nil), "--kms-key")
+}
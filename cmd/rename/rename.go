@@ -0,0 +1,553 @@
+package rename
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/precheck"
+	"github.com/drapon/envy/internal/prompt"
+	"github.com/drapon/envy/internal/validator"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	environment string
+	rulesFile   string
+	localOnly   bool
+	remoteOnly  bool
+	dryRun      bool
+	force       bool
+	grepUsages  bool
+	grepRoot    string
+)
+
+// renameCmd represents the rename command
+var renameCmd = &cobra.Command{
+	Use:   "rename OLD_KEY NEW_KEY",
+	Short: "Rename a variable locally and in AWS",
+	Long: `Rename an environment variable in local .env files, in AWS, and in the
+validation rules file.
+
+Remote renames copy the value to the new key (keeping the parameter type,
+description, KMS key, tier and tags), read it back to verify it, and only
+then delete the old key. Environments where the old key does not exist are
+skipped.
+
+Every selected environment and rules file is checked for conflicts before
+anything is renamed. If a rename still fails part way, the error lists the
+locations already renamed. --remote-only leaves local files and rules alone.
+
+With --grep, the codebase is searched for references to the old key so the
+code changes needed to complete the migration can be reported.`,
+	Example: `  # Rename in the default environment
+  envy rename DB_HOST DATABASE_HOST
+
+  # Rename in every configured environment and report code usages
+  envy rename DB_HOST DATABASE_HOST --env all --grep
+
+  # Preview the changes without applying them
+  envy rename DB_HOST DATABASE_HOST --env all --dry-run
+
+  # Rename only in local files
+  envy rename DB_HOST DATABASE_HOST --local-only`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRename,
+}
+
+// GetRenameCmd returns the rename command.
+func GetRenameCmd() *cobra.Command {
+	return renameCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(renameCmd)
+
+	renameCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to rename in ('all' for every environment)")
+	renameCmd.Flags().StringVarP(&rulesFile, "rules", "r", "", "Validation rules file to update (default: .envy-rules.yaml if present)")
+	renameCmd.Flags().BoolVar(&localOnly, "local-only", false, "Only rename in local files")
+	renameCmd.Flags().BoolVar(&remoteOnly, "remote-only", false, "Only rename in AWS")
+	renameCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be renamed without making changes")
+	renameCmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation prompt")
+	renameCmd.Flags().BoolVar(&grepUsages, "grep", false, "Search the codebase for usages of the old key")
+	renameCmd.Flags().StringVar(&grepRoot, "grep-dir", ".", "Directory to search with --grep")
+}
+
+func runRename(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	oldKey, newKey := args[0], args[1]
+
+	if err := validateKeys(oldKey, newKey); err != nil {
+		return err
+	}
+	if localOnly && remoteOnly {
+		return fmt.Errorf("--local-only and --remote-only cannot be used together")
+	}
+
+	// Load configuration
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	environments, err := resolveEnvironments(cfg, environment)
+	if err != nil {
+		return err
+	}
+
+	if !dryRun && !force && !confirmRename(oldKey, newKey, environments) {
+		color.PrintWarningf("Rename cancelled")
+		return nil
+	}
+
+	var awsManager *aws.Manager
+	if !localOnly {
+		awsManager, err = aws.NewManager(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create AWS manager: %w", err)
+		}
+	}
+
+	// Check every environment before changing any, so a conflict in one
+	// does not leave the others half renamed
+	if err := preflight(ctx, cfg, awsManager, environments, oldKey, newKey); err != nil {
+		return err
+	}
+	if !localOnly && !dryRun {
+		if err := precheck.Writers(ctx, cfg, awsManager, []string{oldKey, newKey}); err != nil {
+			return err
+		}
+	}
+
+	renamed := 0
+	var applied []string
+	for _, envName := range environments {
+		color.PrintInfof("Environment: %s", envName)
+
+		// Rename remotely first so local files are only touched once the
+		// authoritative copy has been migrated
+		if !localOnly {
+			ok, err := renameRemote(ctx, awsManager, envName, oldKey, newKey)
+			if err != nil {
+				return withApplied(fmt.Errorf("failed to rename in AWS (%s): %w", envName, err), applied)
+			}
+			if ok {
+				renamed++
+				applied = append(applied, "AWS "+envName)
+			}
+		}
+
+		if !remoteOnly {
+			files, err := renameLocal(cfg, envName, oldKey, newKey)
+			renamed += len(files)
+			applied = append(applied, files...)
+			if err != nil {
+				return withApplied(fmt.Errorf("failed to rename in local files (%s): %w", envName, err), applied)
+			}
+		}
+	}
+
+	// Rules files are local, so --remote-only leaves them alone
+	if !remoteOnly {
+		if err := renameInRules(oldKey, newKey); err != nil {
+			return withApplied(err, applied)
+		}
+	}
+
+	reportOverrides(cfg, oldKey)
+
+	if grepUsages {
+		if err := reportUsages(grepRoot, oldKey, newKey); err != nil {
+			return err
+		}
+	}
+
+	if dryRun {
+		color.PrintInfof("Dry run: no changes were made")
+		return nil
+	}
+
+	if renamed == 0 {
+		color.PrintWarningf("%s was not found in any selected environment", oldKey)
+		return nil
+	}
+
+	color.PrintSuccessf("Renamed %s to %s in %d location(s)", oldKey, newKey, renamed)
+	return nil
+}
+
+// preflight reports every conflict the rename would run into in the selected
+// environments and rules files
+func preflight(ctx context.Context, cfg *config.Config, awsManager *aws.Manager, environments []string, oldKey, newKey string) error {
+	var conflicts []string
+	for _, envName := range environments {
+		if !localOnly {
+			vars, err := awsManager.ListEnvironmentVariables(ctx, envName)
+			if err != nil {
+				return fmt.Errorf("failed to read AWS variables (%s): %w", envName, err)
+			}
+			if _, ok := vars[oldKey]; ok {
+				if _, exists := vars[newKey]; exists {
+					conflicts = append(conflicts, fmt.Sprintf("AWS (%s) already contains %s", envName, newKey))
+				}
+			}
+		}
+
+		if !remoteOnly {
+			envConfig, err := cfg.GetEnvironment(envName)
+			if err != nil {
+				return err
+			}
+			for _, filename := range envConfig.Files {
+				file, err := env.ParseFile(filename)
+				if err != nil {
+					continue // Missing files are skipped by the rename too
+				}
+				if _, ok := file.Get(oldKey); !ok {
+					continue
+				}
+				if _, exists := file.Get(newKey); exists {
+					conflicts = append(conflicts, fmt.Sprintf("%s already contains %s", filename, newKey))
+				}
+			}
+		}
+	}
+
+	if !remoteOnly {
+		names, err := rulesFiles()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			rules, err := validator.LoadRulesFromFile(name)
+			if err != nil {
+				return fmt.Errorf("failed to load rules file: %w", err)
+			}
+			if _, err := rules.RenameVariable(oldKey, newKey); err != nil {
+				conflicts = append(conflicts, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("cannot rename %s to %s, nothing was changed:\n  %s", oldKey, newKey, strings.Join(conflicts, "\n  "))
+	}
+	return nil
+}
+
+// withApplied adds the locations already renamed to an error, so a failed
+// rename can be finished or reverted by hand
+func withApplied(err error, applied []string) error {
+	if len(applied) == 0 {
+		return fmt.Errorf("%w (nothing was renamed)", err)
+	}
+	return fmt.Errorf("%w (already renamed in: %s)", err, strings.Join(applied, ", "))
+}
+
+func renameRemote(ctx context.Context, awsManager *aws.Manager, envName, oldKey, newKey string) (bool, error) {
+	if dryRun {
+		vars, err := awsManager.ListEnvironmentVariables(ctx, envName)
+		if err != nil {
+			return false, err
+		}
+		if _, ok := vars[oldKey]; !ok {
+			color.PrintInfof("  AWS: %s not found, skipping", oldKey)
+			return false, nil
+		}
+		if _, ok := vars[newKey]; ok {
+			return false, fmt.Errorf("%s already exists", newKey)
+		}
+		color.PrintInfof("  AWS: would rename %s → %s", oldKey, newKey)
+		return true, nil
+	}
+
+	err := awsManager.RenameVariable(ctx, envName, oldKey, newKey)
+	if errors.Is(err, aws.ErrKeyNotFound) {
+		color.PrintInfof("  AWS: %s not found, skipping", oldKey)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	color.PrintSuccessf("  AWS: renamed %s → %s (verified)", oldKey, newKey)
+	return true, nil
+}
+
+// renameLocal renames the key in the environment's files and returns the
+// files renamed (or that would be, in a dry run)
+func renameLocal(cfg *config.Config, envName, oldKey, newKey string) ([]string, error) {
+	envConfig, err := cfg.GetEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	var renamed []string
+	for _, filename := range envConfig.Files {
+		if _, err := os.Stat(filename); err != nil {
+			continue
+		}
+
+		file, err := env.ParseFile(filename)
+		if err != nil {
+			return renamed, err
+		}
+
+		if _, ok := file.Get(oldKey); !ok {
+			continue
+		}
+		if _, ok := file.Get(newKey); ok {
+			return renamed, fmt.Errorf("%s already contains %s", filename, newKey)
+		}
+
+		if dryRun {
+			color.PrintInfof("  %s: would rename %s → %s", filename, oldKey, newKey)
+			renamed = append(renamed, filename)
+			continue
+		}
+
+		file.Rename(oldKey, newKey)
+		if err := file.WriteFile(filename); err != nil {
+			return renamed, err
+		}
+		color.PrintSuccessf("  %s: renamed %s → %s", filename, oldKey, newKey)
+		renamed = append(renamed, filename)
+	}
+
+	return renamed, nil
+}
+
+func renameInRules(oldKey, newKey string) error {
+	names, err := rulesFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err := renameInRulesFile(name, oldKey, newKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rulesFiles returns the existing rules files: the shared one and the
+// environment-specific ones next to it
+func rulesFiles() ([]string, error) {
+	filename := rulesFile
+	if filename == "" {
+		filename = ".envy-rules.yaml"
+	}
+
+	envFiles, err := validator.EnvironmentRulesFiles(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, name := range append([]string{filename}, envFiles...) {
+		if _, err := os.Stat(name); err != nil {
+			if rulesFile != "" && name == rulesFile {
+				return nil, fmt.Errorf("failed to load rules file: %w", err)
+			}
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func renameInRulesFile(filename, oldKey, newKey string) error {
+	rules, err := validator.LoadRulesFromFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to load rules file: %w", err)
+	}
+
+	changed, err := rules.RenameVariable(oldKey, newKey)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	if !changed {
+		return nil
+	}
+
+	if dryRun {
+		color.PrintInfof("Rules: would rename %s → %s in %s", oldKey, newKey, filename)
+		return nil
+	}
+
+	if err := validator.SaveRulesToFile(rules, filename); err != nil {
+		return err
+	}
+	color.PrintSuccessf("Rules: renamed %s → %s in %s", oldKey, newKey, filename)
+	return nil
+}
+
+// reportOverrides points out .envyrc entries that mention the old key. The
+// configuration is not rewritten automatically to preserve its formatting.
+func reportOverrides(cfg *config.Config, oldKey string) {
+	for _, list := range [][]string{cfg.SensitivePatterns, cfg.Sensitivity.Sensitive, cfg.Sensitivity.Plain} {
+		for _, entry := range list {
+			if entry == oldKey {
+				color.PrintWarningf("Update the sensitivity override for %s in .envyrc", oldKey)
+				return
+			}
+		}
+	}
+}
+
+func reportUsages(dir, oldKey, newKey string) error {
+	usages, err := findUsages(dir, oldKey)
+	if err != nil {
+		return fmt.Errorf("failed to search for usages: %w", err)
+	}
+
+	if len(usages) == 0 {
+		color.PrintInfof("No code references to %s found", oldKey)
+		return nil
+	}
+
+	color.PrintWarningf("\n%d code reference(s) to %s need to be updated to %s:", len(usages), oldKey, newKey)
+	for _, u := range usages {
+		fmt.Printf("  %s:%d: %s\n", u.File, u.Line, u.Text)
+	}
+	return nil
+}
+
+// usage is a line of source code referencing a key
+type usage struct {
+	File string
+	Line int
+	Text string
+}
+
+// skippedDirs are never searched for usages
+var skippedDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+	"dist":         true,
+	"build":        true,
+	".next":        true,
+	"__pycache__":  true,
+}
+
+// maxUsageFileSize limits the size of files searched for usages
+const maxUsageFileSize = 1 << 20
+
+// findUsages returns lines under dir that reference key as a whole word.
+// Env files, binary files and common dependency directories are skipped.
+func findUsages(dir, key string) ([]usage, error) {
+	pattern, err := regexp.Compile(`(^|[^A-Za-z0-9_])` + regexp.QuoteMeta(key) + `($|[^A-Za-z0-9_])`)
+	if err != nil {
+		return nil, err
+	}
+
+	var usages []usage
+	err = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip unreadable entries
+		}
+
+		if d.IsDir() {
+			if path != dir && skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if isEnvFile(d.Name()) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil || info.Size() > maxUsageFileSize {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil || bytes.IndexByte(data, 0) != -1 {
+			return nil // Unreadable or binary
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, 64*1024), maxUsageFileSize)
+		line := 0
+		for scanner.Scan() {
+			line++
+			text := scanner.Text()
+			if pattern.MatchString(text) {
+				usages = append(usages, usage{
+					File: path,
+					Line: line,
+					Text: strings.TrimSpace(text),
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(usages, func(i, j int) bool {
+		return usages[i].File < usages[j].File
+	})
+	return usages, nil
+}
+
+// isEnvFile reports whether name looks like a .env file, which the local
+// rename already handles
+func isEnvFile(name string) bool {
+	return name == ".env" || strings.HasPrefix(name, ".env.") || strings.HasSuffix(name, ".env")
+}
+
+func resolveEnvironments(cfg *config.Config, envName string) ([]string, error) {
+	if envName == "all" {
+		var names []string
+		for name := range cfg.Environments {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+
+	if envName == "" {
+		envName = cfg.DefaultEnvironment
+	}
+	if _, err := cfg.GetEnvironment(envName); err != nil {
+		return nil, err
+	}
+	return []string{envName}, nil
+}
+
+var validKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func validateKeys(oldKey, newKey string) error {
+	if oldKey == newKey {
+		return fmt.Errorf("old and new key are the same")
+	}
+	for _, key := range []string{oldKey, newKey} {
+		if !validKey.MatchString(key) {
+			return fmt.Errorf("invalid variable name: %s", key)
+		}
+	}
+	return nil
+}
+
+func confirmRename(oldKey, newKey string, environments []string) bool {
+	return prompt.Confirm(color.FormatWarning(fmt.Sprintf("About to rename %s to %s in %s.", oldKey, newKey, strings.Join(environments, ", "))) + " Continue?")
+}
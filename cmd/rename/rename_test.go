@@ -0,0 +1,129 @@
+package rename
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRenameCmd(t *testing.T) {
+	cmd := GetRenameCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "rename OLD_KEY NEW_KEY", cmd.Use)
+	assert.NotNil(t, cmd.RunE)
+
+	assert.NotNil(t, cmd.Flags().Lookup("env"))
+	assert.NotNil(t, cmd.Flags().Lookup("dry-run"))
+	assert.NotNil(t, cmd.Flags().Lookup("grep"))
+	assert.NotNil(t, cmd.Flags().Lookup("local-only"))
+	assert.NotNil(t, cmd.Flags().Lookup("remote-only"))
+}
+
+func TestValidateKeys(t *testing.T) {
+	assert.NoError(t, validateKeys("DB_HOST", "DATABASE_HOST"))
+	assert.Error(t, validateKeys("DB_HOST", "DB_HOST"))
+	assert.Error(t, validateKeys("DB_HOST", "DATABASE-HOST"))
+	assert.Error(t, validateKeys("1DB", "DB"))
+}
+
+func TestResolveEnvironments(t *testing.T) {
+	cfg := &config.Config{
+		DefaultEnvironment: "dev",
+		Environments: map[string]config.Environment{
+			"prod":    {},
+			"dev":     {},
+			"staging": {},
+		},
+	}
+
+	envs, err := resolveEnvironments(cfg, "all")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev", "prod", "staging"}, envs)
+
+	envs, err = resolveEnvironments(cfg, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev"}, envs)
+
+	_, err = resolveEnvironments(cfg, "missing")
+	assert.Error(t, err)
+}
+
+func TestPreflight(t *testing.T) {
+	dir := t.TempDir()
+	dev := filepath.Join(dir, ".env.dev")
+	prod := filepath.Join(dir, ".env.prod")
+	require.NoError(t, os.WriteFile(dev, []byte("DB_HOST=a\n"), 0600))
+	require.NoError(t, os.WriteFile(prod, []byte("DB_HOST=b\nDATABASE_HOST=c\n"), 0600))
+
+	cfg := &config.Config{
+		Environments: map[string]config.Environment{
+			"dev":  {Files: []string{dev}},
+			"prod": {Files: []string{prod}},
+		},
+	}
+
+	localOnly, rulesFile = true, filepath.Join(dir, "rules.yaml")
+	defer func() { localOnly, remoteOnly, rulesFile = false, false, "" }()
+	require.NoError(t, os.WriteFile(rulesFile, []byte("variables:\n  DB_HOST:\n    type: string\n  DATABASE_HOST:\n    type: string\n"), 0600))
+
+	err := preflight(context.Background(), cfg, nil, []string{"dev", "prod"}, "DB_HOST", "DATABASE_HOST")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nothing was changed")
+	assert.Contains(t, err.Error(), prod+" already contains DATABASE_HOST")
+	assert.Contains(t, err.Error(), rulesFile)
+	assert.NotContains(t, err.Error(), dev)
+
+	// Only the dev file is checked
+	assert.NoError(t, preflight(context.Background(), cfg, nil, []string{"dev"}, "DB_HOST", "DB_HOSTNAME"))
+
+	// --remote-only skips local files and rules
+	localOnly, remoteOnly = false, true
+	cfg.Environments = map[string]config.Environment{}
+	assert.NoError(t, preflight(context.Background(), cfg, nil, nil, "DB_HOST", "DATABASE_HOST"))
+}
+
+func TestWithApplied(t *testing.T) {
+	err := withApplied(errors.New("boom"), nil)
+	assert.EqualError(t, err, "boom (nothing was renamed)")
+
+	err = withApplied(errors.New("boom"), []string{"AWS dev", ".env.dev"})
+	assert.EqualError(t, err, "boom (already renamed in: AWS dev, .env.dev)")
+}
+
+func TestFindUsages(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	write("main.go", "package main\n\nvar host = os.Getenv(\"DB_HOST\")\nvar other = os.Getenv(\"DB_HOST_RO\")\n")
+	write("config/app.js", "const h = process.env.DB_HOST;\n")
+	write(".env.dev", "DB_HOST=localhost\n")
+	write("node_modules/lib/index.js", "process.env.DB_HOST\n")
+	write("image.bin", "DB_HOST\x00\x01")
+
+	usages, err := findUsages(dir, "DB_HOST")
+	require.NoError(t, err)
+	require.Len(t, usages, 2)
+
+	assert.Equal(t, filepath.Join(dir, "config/app.js"), usages[0].File)
+	assert.Equal(t, 1, usages[0].Line)
+	assert.Equal(t, filepath.Join(dir, "main.go"), usages[1].File)
+	assert.Equal(t, 3, usages[1].Line)
+}
+
+func TestIsEnvFile(t *testing.T) {
+	assert.True(t, isEnvFile(".env"))
+	assert.True(t, isEnvFile(".env.production"))
+	assert.True(t, isEnvFile("docker.env"))
+	assert.False(t, isEnvFile("environment.go"))
+}
@@ -0,0 +1,324 @@
+package renameproject
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/precheck"
+	"github.com/drapon/envy/internal/prompt"
+)
+
+// metadataPrefixes are where envy keeps its own per-project parameters
+// (replica regions and gitops state); %s is the project
+var metadataPrefixes = []string{
+	"/envy/replication/%s/",
+	"/envy/gitops/%s/",
+}
+
+// scheduleParameter holds the project's scheduled plans
+const scheduleParameter = "/envy/scheduled/%s"
+
+var validProject = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+var (
+	migrate   bool
+	localOnly bool
+	deleteOld bool
+	dryRun    bool
+	force     bool
+)
+
+// renameProjectCmd represents the rename-project command
+var renameProjectCmd = &cobra.Command{
+	Use:   "rename-project NEW_NAME",
+	Short: "Rename the project in .envyrc and move its parameters to the new prefix",
+	Long: `Rename the project: set project in .envyrc to NEW_NAME and rewrite every
+environment path that starts with the old project, e.g. /myapp/prod/ becomes
+/newapp/prod/. Environments without an explicit path follow the project.
+
+The remote parameters can be moved to the new paths at the same time, as
+'envy mv' does: each parameter is copied with its type, description, KMS key,
+tier and tags, and verified. envy's own settings for the project (replica
+regions, scheduled plans, gitops state) are moved too. They are moved with
+--migrate or once you confirm the question; --force skips the question
+without moving anything, like --local-only. The old parameters are kept
+unless --delete is given.
+
+Environments stored in Secrets Manager are not moved: their secrets keep
+their old names, and .envyrc keeps pointing them at the old path.`,
+	Example: `  # Rename and move the parameters, asking first
+  envy rename-project newapp
+
+  # Rename and move without asking, deleting the old parameters
+  envy rename-project newapp --migrate --delete --force
+
+  # Only update .envyrc
+  envy rename-project newapp --local-only`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRenameProject,
+}
+
+// GetRenameProjectCmd returns the rename-project command.
+func GetRenameProjectCmd() *cobra.Command {
+	return renameProjectCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(renameProjectCmd)
+
+	renameProjectCmd.Flags().BoolVar(&migrate, "migrate", false, "Move the remote parameters without asking")
+	renameProjectCmd.Flags().BoolVar(&localOnly, "local-only", false, "Only update .envyrc")
+	renameProjectCmd.Flags().BoolVar(&deleteOld, "delete", false, "Delete the old parameters once every copy is verified")
+	renameProjectCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would change without making changes")
+	renameProjectCmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation prompts")
+}
+
+// projectMove is one prefix, or with Single one parameter, to move for the
+// rename. Metadata moves hold envy's settings rather than variables.
+// Environment names the environment of a variable prefix; Implicit ones
+// have no path in .envyrc and follow the project.
+type projectMove struct {
+	Environment string
+	OldPrefix   string
+	NewPrefix   string
+	Single      bool
+	Metadata    bool
+	Implicit    bool
+}
+
+func runRenameProject(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	newName := args[0]
+
+	if !validProject.MatchString(newName) {
+		return fmt.Errorf("invalid project name %q (letters, digits, '.', '_' and '-')", newName)
+	}
+	if migrate && localOnly {
+		return fmt.Errorf("--migrate and --local-only cannot be used together")
+	}
+
+	// Load configuration
+	configFile := viper.GetString("config")
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	oldName := cfg.Project
+	if oldName == newName {
+		return fmt.Errorf("the project is already named %s", newName)
+	}
+
+	paths, moves, skipped := plan(cfg, newName)
+	color.PrintBoldf("Renaming project %s → %s", oldName, newName)
+	for _, name := range sortedKeys(paths) {
+		fmt.Printf("  environments.%s.path: %s\n", name, paths[name])
+	}
+	for _, name := range skipped {
+		if cfg.GetAWSService(name) == "secrets_manager" {
+			color.PrintWarningf("%s is stored in Secrets Manager; its secrets are not moved and it keeps its path", name)
+			continue
+		}
+		color.PrintWarningf("%s: path %s does not start with /%s/ and is left alone", name, cfg.GetParameterPath(name), oldName)
+	}
+
+	// The parameters are only moved when asked to; --force skips the
+	// question without answering it. A dry run shows the moves.
+	doMigrate := false
+	switch {
+	case localOnly:
+	case dryRun:
+		if err := moveRemote(ctx, cfg, moves); err != nil {
+			return err
+		}
+	case migrate || (!force && confirm("Move the remote parameters to the new prefixes?")):
+		doMigrate = true
+		if err := moveRemote(ctx, cfg, moves); err != nil {
+			return err
+		}
+	}
+	if !doMigrate {
+		warnImplicit(moves)
+	}
+
+	if dryRun {
+		color.PrintWarningf("\n[DRY RUN] No changes will be made")
+		return nil
+	}
+
+	values := map[string]string{"project": newName}
+	for name, path := range paths {
+		values["environments."+name+".path"] = path
+	}
+	if err := config.SetValues(configFile, values); err != nil {
+		if doMigrate {
+			return fmt.Errorf("parameters were copied but .envyrc was not updated: %w", err)
+		}
+		return err
+	}
+	color.PrintSuccessf("Renamed the project to %s in .envyrc", newName)
+	if !doMigrate {
+		color.PrintInfof("Remote parameters were not moved; use 'envy mv /%s/ /%s/' to move them later", oldName, newName)
+	}
+	return nil
+}
+
+// plan returns the new explicit path of each environment, what to move
+// remotely, and the environments that are not moved: Secrets Manager ones
+// and those whose custom path does not follow the project
+func plan(cfg *config.Config, newName string) (map[string]string, []projectMove, []string) {
+	oldRoot := "/" + cfg.Project + "/"
+	newRoot := "/" + newName + "/"
+
+	paths := make(map[string]string)
+	var moves []projectMove
+	var skipped []string
+	for _, name := range sortedEnvironments(cfg) {
+		envConfig := cfg.Environments[name]
+		oldPath := cfg.GetParameterPath(name)
+		if !strings.HasSuffix(oldPath, "/") {
+			oldPath += "/"
+		}
+		if !strings.HasPrefix(oldPath, oldRoot) {
+			skipped = append(skipped, name)
+			continue
+		}
+		if cfg.GetAWSService(name) == "secrets_manager" {
+			// The secrets keep their names, so pin the old path
+			if envConfig.Path == "" {
+				paths[name] = oldPath
+			}
+			skipped = append(skipped, name)
+			continue
+		}
+		newPath := newRoot + strings.TrimPrefix(oldPath, oldRoot)
+		if envConfig.Path != "" {
+			paths[name] = newPath
+		}
+		moves = append(moves, projectMove{Environment: name, OldPrefix: oldPath, NewPrefix: newPath, Implicit: envConfig.Path == ""})
+	}
+
+	for _, format := range metadataPrefixes {
+		moves = append(moves, projectMove{
+			OldPrefix: fmt.Sprintf(format, cfg.Project),
+			NewPrefix: fmt.Sprintf(format, newName),
+			Metadata:  true,
+		})
+	}
+	moves = append(moves, projectMove{
+		OldPrefix: fmt.Sprintf(scheduleParameter, cfg.Project),
+		NewPrefix: fmt.Sprintf(scheduleParameter, newName),
+		Single:    true,
+		Metadata:  true,
+	})
+	return paths, moves, skipped
+}
+
+// warnImplicit warns that environments without a path in .envyrc read the
+// new prefix once the project is renamed, although their parameters were not
+// moved there
+func warnImplicit(moves []projectMove) {
+	for _, move := range moves {
+		if move.Implicit {
+			color.PrintWarningf("%s has no path in .envyrc and will read %s, but its parameters stay under %s until moved", move.Environment, move.NewPrefix, move.OldPrefix)
+		}
+	}
+}
+
+// moveRemote copies every prefix, and with --delete removes the originals
+// once all of them have been copied
+func moveRemote(ctx context.Context, cfg *config.Config, moves []projectMove) error {
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	// Plan every prefix first so a conflict stops the rename before
+	// anything is written
+	var plans []*aws.MovePlan
+	var keys []string
+	for _, move := range moves {
+		var movePlan *aws.MovePlan
+		if move.Single {
+			movePlan, err = awsManager.PlanMoveParameter(ctx, move.OldPrefix, move.NewPrefix)
+		} else {
+			movePlan, err = awsManager.PlanMove(ctx, move.OldPrefix, move.NewPrefix)
+		}
+		if err != nil {
+			return err
+		}
+		if len(movePlan.Moves) == 0 {
+			continue
+		}
+		color.PrintInfof("%s → %s: %d parameter(s)", move.OldPrefix, move.NewPrefix, len(movePlan.Moves))
+		plans = append(plans, movePlan)
+		// Only variables are subject to allowed_writers
+		if !move.Metadata {
+			keys = append(keys, movePlan.Keys()...)
+		}
+	}
+	if len(plans) == 0 {
+		color.PrintInfof("No remote parameters to move")
+		return nil
+	}
+	if dryRun {
+		return nil
+	}
+	if err := precheck.Writers(ctx, cfg, awsManager, keys); err != nil {
+		return err
+	}
+
+	for _, movePlan := range plans {
+		copied, err := awsManager.CopyPrefix(ctx, movePlan)
+		if err != nil {
+			if len(copied) > 0 {
+				return fmt.Errorf("%w (copied so far, originals kept: %s)", err, strings.Join(copied, ", "))
+			}
+			return err
+		}
+	}
+	color.PrintSuccessf("Copied and verified the parameters of %d prefix(es)", len(plans))
+
+	if !deleteOld {
+		color.PrintInfof("The old parameters were kept; rerun with --delete or remove them once nothing uses them")
+		return nil
+	}
+	for _, movePlan := range plans {
+		if err := awsManager.DeleteMoved(ctx, movePlan); err != nil {
+			return fmt.Errorf("failed to delete the old parameters: %w", err)
+		}
+	}
+	color.PrintSuccessf("Deleted the old parameters")
+	return nil
+}
+
+func sortedEnvironments(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Environments))
+	for name := range cfg.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func confirm(question string) bool {
+	return prompt.Confirm(color.FormatWarning(question))
+}
@@ -0,0 +1,52 @@
+package renameproject
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRenameProjectCmd(t *testing.T) {
+	cmd := GetRenameProjectCmd()
+	assert.Equal(t, "rename-project NEW_NAME", cmd.Use)
+	for _, name := range []string{"migrate", "local-only", "delete", "dry-run", "force"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	cfg := &config.Config{
+		Project: "myapp",
+		AWS:     config.AWSConfig{Service: "parameter_store"},
+		Environments: map[string]config.Environment{
+			"dev":     {},
+			"prod":    {Path: "/myapp/production"},
+			"shared":  {Path: "/shared/config/"},
+			"secrets": {UseSecretsManager: true},
+		},
+	}
+
+	paths, moves, skipped := plan(cfg, "newapp")
+	assert.Equal(t, map[string]string{
+		"prod":    "/newapp/production/",
+		"secrets": "/myapp/secrets/",
+	}, paths)
+	assert.Equal(t, []string{"secrets", "shared"}, skipped)
+	assert.Equal(t, []projectMove{
+		{Environment: "dev", OldPrefix: "/myapp/dev/", NewPrefix: "/newapp/dev/", Implicit: true},
+		{Environment: "prod", OldPrefix: "/myapp/production/", NewPrefix: "/newapp/production/"},
+		{OldPrefix: "/envy/replication/myapp/", NewPrefix: "/envy/replication/newapp/", Metadata: true},
+		{OldPrefix: "/envy/gitops/myapp/", NewPrefix: "/envy/gitops/newapp/", Metadata: true},
+		{OldPrefix: "/envy/scheduled/myapp", NewPrefix: "/envy/scheduled/newapp", Single: true, Metadata: true},
+	}, moves)
+}
+
+func TestValidProject(t *testing.T) {
+	for _, name := range []string{"newapp", "new-app", "app_2", "app.v2"} {
+		assert.True(t, validProject.MatchString(name), name)
+	}
+	for _, name := range []string{"", "new/app", "-app", "new app", ".."} {
+		assert.False(t, validProject.MatchString(name), name)
+	}
+}
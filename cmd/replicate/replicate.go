@@ -0,0 +1,232 @@
+package replicate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/ghactions"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	environment string
+	regions     []string
+	prune       bool
+	dryRun      bool
+)
+
+// replicateCmd represents the replicate command
+var replicateCmd = &cobra.Command{
+	Use:   "replicate",
+	Short: "Keep an environment's variables synchronized across AWS regions",
+	Long: `Replicate an environment from the primary region (aws.region) to other
+regions. Once set up, every write to the environment ('envy push', 'import',
+'apply', 'undo', 'ci push', 'gitops apply' and the rest) is also made in
+the replica regions, deletions included. If any region fails,
+the regions already written are rolled back to their previous values.
+
+The replica regions are recorded in the primary region's Parameter Store, so
+everyone pushing the environment replicates it the same way. Pair it with
+fallback_regions in .envyrc to read from a replica when the primary is down.`,
+}
+
+// setupCmd represents the replicate setup command
+var setupCmd = &cobra.Command{
+	Use:   "setup",
+	Short: "Record replica regions and copy the environment to them",
+	Example: `  # Replicate production to eu-west-1 (us-east-1 being aws.region)
+  envy replicate setup --env prod --regions us-east-1,eu-west-1
+
+  # Preview what would be copied, removing keys that only exist in replicas
+  envy replicate setup --env prod --regions eu-west-1 --prune --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: runSetup,
+}
+
+// statusCmd represents the replicate status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Check replica regions for drift from the primary region",
+	Long: `Compare every replica region with the primary region and list the keys
+that are missing, different or extra. Values are never shown. The command
+fails when a replica is out of sync, so it can be used as a CI check.`,
+	Example: `  envy replicate status --env prod`,
+	Args:    cobra.NoArgs,
+	RunE:    runStatus,
+}
+
+// GetReplicateCmd returns the replicate command.
+func GetReplicateCmd() *cobra.Command {
+	return replicateCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(replicateCmd)
+	replicateCmd.AddCommand(setupCmd)
+	replicateCmd.AddCommand(statusCmd)
+
+	replicateCmd.PersistentFlags().StringVarP(&environment, "env", "e", "", "Environment to replicate (default: default_environment)")
+
+	setupCmd.Flags().StringSliceVar(&regions, "regions", nil, "Regions to replicate to; the primary region may be included")
+	setupCmd.Flags().BoolVar(&prune, "prune", false, "Remove keys that only exist in a replica")
+	setupCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be copied without changing anything")
+	_ = setupCmd.MarkFlagRequired("regions")
+}
+
+func runSetup(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	cfg, awsManager, envName, err := load()
+	if err != nil {
+		return err
+	}
+
+	replicas, err := aws.ParseReplicaRegions(regions, cfg.GetRegion(envName))
+	if err != nil {
+		return err
+	}
+	if len(replicas) == 0 {
+		return fmt.Errorf("no replica regions other than the primary region %s", cfg.GetRegion(envName))
+	}
+
+	drifts, err := awsManager.ReplicationStatus(ctx, envName, replicas)
+	if err != nil {
+		return fmt.Errorf("failed to read %s from %s: %w", envName, cfg.GetRegion(envName), err)
+	}
+	showDrifts(drifts, prune)
+
+	if dryRun {
+		color.PrintWarningf("\n[DRY RUN] No changes will be made")
+		return nil
+	}
+
+	if _, err := awsManager.SetReplicationRegions(ctx, envName, replicas); err != nil {
+		return fmt.Errorf("failed to record replica regions: %w", err)
+	}
+	if err := awsManager.SyncReplicas(ctx, envName, replicas, prune); err != nil {
+		return fmt.Errorf("failed to copy %s to replicas: %w", envName, err)
+	}
+
+	color.PrintSuccessf("\n%s is replicated from %s to %s", envName, cfg.GetRegion(envName), strings.Join(replicas, ", "))
+	return nil
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	cfg, awsManager, envName, err := load()
+	if err != nil {
+		return err
+	}
+
+	replicas, err := awsManager.ReplicationRegions(ctx, envName)
+	if err != nil {
+		return err
+	}
+	if len(replicas) == 0 {
+		color.PrintWarningf("%s is not replicated; run 'envy replicate setup --env %s --regions ...'", envName, envName)
+		return nil
+	}
+
+	drifts, err := awsManager.ReplicationStatus(ctx, envName, replicas)
+	if err != nil {
+		return fmt.Errorf("failed to read %s from %s: %w", envName, cfg.GetRegion(envName), err)
+	}
+	color.PrintBoldf("%s: primary %s", envName, cfg.GetRegion(envName))
+	showDrifts(drifts, true)
+	ghactions.Emit(driftAnnotations(envName, drifts)...)
+
+	outOfSync := 0
+	for _, drift := range drifts {
+		if !drift.InSync() {
+			outOfSync++
+		}
+	}
+	if outOfSync > 0 {
+		return fmt.Errorf("%d of %d replica region(s) out of sync; run 'envy replicate setup' to resynchronize", outOfSync, len(drifts))
+	}
+	return nil
+}
+
+func load() (*config.Config, *aws.Manager, string, error) {
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	envName := environment
+	if envName == "" {
+		envName = cfg.DefaultEnvironment
+	}
+	if _, err := cfg.GetEnvironment(envName); err != nil {
+		return nil, nil, "", err
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+	return cfg, awsManager, envName, nil
+}
+
+// showDrifts prints the keys that differ in each replica. Extra keys are
+// listed only when they matter, i.e. they would be pruned or reported.
+func showDrifts(drifts []aws.RegionDrift, showExtra bool) {
+	for _, drift := range drifts {
+		switch {
+		case drift.Err != nil:
+			fmt.Printf("  %s: %s\n", drift.Region, color.FormatError(drift.Err.Error()))
+			continue
+		case drift.InSync():
+			fmt.Printf("  %s: %s\n", drift.Region, color.FormatSuccess("in sync"))
+			continue
+		}
+
+		fmt.Printf("  %s:\n", drift.Region)
+		for _, key := range drift.Missing {
+			fmt.Println(color.FormatSuccess("    + " + key))
+		}
+		for _, key := range drift.Different {
+			fmt.Println(color.FormatWarning("    ~ " + key))
+		}
+		if showExtra {
+			for _, key := range drift.Extra {
+				fmt.Println(color.FormatError("    - " + key))
+			}
+		} else if len(drift.Extra) > 0 {
+			fmt.Printf("    %d key(s) only in this region are kept (use --prune to remove them)\n", len(drift.Extra))
+		}
+	}
+}
+
+// driftAnnotations reports each replica that is out of sync as an error
+func driftAnnotations(envName string, drifts []aws.RegionDrift) []ghactions.Annotation {
+	var annotations []ghactions.Annotation
+	for _, drift := range drifts {
+		var message string
+		switch {
+		case drift.Err != nil:
+			message = fmt.Sprintf("%s could not be read in %s: %v", envName, drift.Region, drift.Err)
+		case drift.InSync():
+			continue
+		default:
+			message = fmt.Sprintf("%s in %s is out of sync: %d missing, %d different, %d extra",
+				envName, drift.Region, len(drift.Missing), len(drift.Different), len(drift.Extra))
+			if keys := append(append(append([]string{}, drift.Missing...), drift.Different...), drift.Extra...); len(keys) > 0 {
+				message += "\n" + strings.Join(keys, ", ")
+			}
+		}
+		annotations = append(annotations, ghactions.Annotation{
+			Level:   ghactions.LevelError,
+			Title:   "Replication drift",
+			Message: message,
+		})
+	}
+	return annotations
+}
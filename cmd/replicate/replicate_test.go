@@ -0,0 +1,49 @@
+package replicate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/ghactions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetReplicateCmd(t *testing.T) {
+	cmd := GetReplicateCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "replicate", cmd.Use)
+
+	for _, name := range []string{"setup", "status"} {
+		sub, _, err := cmd.Find([]string{name})
+		require.NoError(t, err)
+		assert.Equal(t, name, sub.Use)
+		assert.NotNil(t, sub.RunE)
+	}
+}
+
+func TestReplicateCommandFlags(t *testing.T) {
+	assert.NotNil(t, replicateCmd.PersistentFlags().Lookup("env"))
+	assert.Equal(t, "e", replicateCmd.PersistentFlags().Lookup("env").Shorthand)
+
+	flags := setupCmd.Flags()
+	assert.NotNil(t, flags.Lookup("regions"))
+	assert.NotNil(t, flags.Lookup("prune"))
+	assert.NotNil(t, flags.Lookup("dry-run"))
+	assert.Equal(t, "false", flags.Lookup("prune").DefValue)
+}
+
+func TestDriftAnnotations(t *testing.T) {
+	drifts := []aws.RegionDrift{
+		{Region: "us-west-2"},
+		{Region: "eu-west-1", Missing: []string{"A"}, Different: []string{"B"}},
+		{Region: "ap-south-1", Err: errors.New("access denied")},
+	}
+
+	annotations := driftAnnotations("prod", drifts)
+	require.Len(t, annotations, 2)
+	assert.Equal(t, ghactions.LevelError, annotations[0].Level)
+	assert.Equal(t, "prod in eu-west-1 is out of sync: 1 missing, 1 different, 0 extra\nA, B", annotations[0].Message)
+	assert.Contains(t, annotations[1].Message, "access denied")
+}
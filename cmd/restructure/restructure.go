@@ -0,0 +1,197 @@
+package restructure
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/precheck"
+	"github.com/drapon/envy/internal/prompt"
+)
+
+var (
+	environment string
+	to          string
+	deleteOld   bool
+	dryRun      bool
+	force       bool
+)
+
+// restructureCmd represents the restructure command
+var restructureCmd = &cobra.Command{
+	Use:   "restructure",
+	Short: "Move an environment between one JSON secret and per-key parameters",
+	Long: `Migrate an environment between the two ways envy stores it:
+
+  single-secret        every variable in one Secrets Manager JSON secret
+  per-key-parameters   one Parameter Store parameter per variable
+
+The variables are read from the current layout and written to the new one,
+which must be empty, then read back and compared key by key. Only when both
+hold the same variables is .envyrc updated (use_secrets_manager, keeping the
+file's comments), so a failed migration leaves the environment as it was.
+The old layout is kept unless --delete is given; a deleted secret can be
+restored during its 30 day recovery window.
+
+When aws.service is secrets_manager for every environment, moving one to
+per-key parameters sets aws.service to parameter_store and pins the other
+environments to Secrets Manager with use_secrets_manager.
+
+Replica regions set up with 'envy replicate' are not migrated; run 'envy
+replicate setup' again once the environment is restructured.`,
+	Example: `  # Consolidate the parameters of staging into one secret
+  envy restructure --env staging --to single-secret
+
+  # Split the production secret into parameters, removing the secret
+  envy restructure --env prod --to per-key-parameters --delete
+
+  # See what would be migrated
+  envy restructure --env prod --to single-secret --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: runRestructure,
+}
+
+// GetRestructureCmd returns the restructure command.
+func GetRestructureCmd() *cobra.Command {
+	return restructureCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(restructureCmd)
+
+	restructureCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to restructure")
+	restructureCmd.Flags().StringVar(&to, "to", "", "Layout to migrate to (single-secret/per-key-parameters)")
+	restructureCmd.Flags().BoolVar(&deleteOld, "delete", false, "Delete the old layout once the new one is verified")
+	restructureCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be migrated without making changes")
+	restructureCmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation prompt")
+	restructureCmd.MarkFlagRequired("to")
+}
+
+func runRestructure(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if to != aws.LayoutSingleSecret && to != aws.LayoutPerKeyParameters {
+		return fmt.Errorf("invalid --to %q (use %s or %s)", to, aws.LayoutSingleSecret, aws.LayoutPerKeyParameters)
+	}
+
+	// Load configuration
+	configFile := viper.GetString("config")
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.UsesAzure() {
+		return fmt.Errorf("envy restructure is only supported with AWS")
+	}
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+	if _, err := cfg.GetEnvironment(environment); err != nil {
+		return err
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	plan, err := awsManager.PlanRestructure(ctx, environment, to)
+	if err != nil {
+		return err
+	}
+	settings := layoutSettings(cfg, environment, to)
+
+	color.PrintBoldf("Restructuring %s: %s → %s", environment, plan.From, plan.To)
+	if plan.To == aws.LayoutSingleSecret {
+		fmt.Printf("  %d parameter(s) under %s → secret %s\n", len(plan.Values), plan.Path, plan.SecretName)
+	} else {
+		fmt.Printf("  secret %s → %d parameter(s) under %s\n", plan.SecretName, len(plan.Values), plan.Path)
+	}
+	for _, key := range sortedKeys(settings) {
+		fmt.Printf("  .envyrc %s: %v\n", key, settings[key])
+	}
+
+	if err := precheck.Writers(ctx, cfg, awsManager, plan.Keys()); err != nil {
+		return err
+	}
+
+	if dryRun {
+		color.PrintWarningf("\n[DRY RUN] No changes will be made")
+		return nil
+	}
+
+	if !force && !confirmRestructure(environment, plan.To) {
+		color.PrintWarningf("Restructure cancelled")
+		return nil
+	}
+
+	if err := awsManager.Restructure(ctx, plan); err != nil {
+		return fmt.Errorf("%w (the old layout was kept and .envyrc was not changed)", err)
+	}
+	color.PrintSuccessf("Wrote and verified %d variable(s) as %s", len(plan.Values), plan.To)
+
+	if err := config.SetSettings(configFile, settings); err != nil {
+		return fmt.Errorf("the new layout was written but .envyrc was not updated: %w", err)
+	}
+	color.PrintSuccessf("Updated .envyrc: %s is stored as %s", environment, plan.To)
+
+	if deleteOld {
+		if err := awsManager.DeleteOldLayout(ctx, plan); err != nil {
+			return fmt.Errorf("failed to delete the old layout: %w", err)
+		}
+		color.PrintSuccessf("Deleted the old %s layout", plan.From)
+	} else {
+		color.PrintInfof("The old %s layout was kept; delete it once nothing reads it", plan.From)
+	}
+
+	if replicas, err := awsManager.ReplicationRegions(ctx, environment); err == nil && len(replicas) > 0 {
+		color.PrintWarningf("%s is replicated to %s; run 'envy replicate setup --env %s --regions %s' to copy the new layout",
+			environment, strings.Join(replicas, ", "), environment, strings.Join(replicas, ","))
+	}
+	return nil
+}
+
+// layoutSettings returns the .envyrc settings that store envName as to.
+// Environments following aws.service are pinned to Secrets Manager when
+// the service changes under them.
+func layoutSettings(cfg *config.Config, envName, to string) map[string]interface{} {
+	settings := make(map[string]interface{})
+	if to == aws.LayoutSingleSecret {
+		settings["environments."+envName+".use_secrets_manager"] = true
+		return settings
+	}
+
+	if cfg.Environments[envName].UseSecretsManager {
+		settings["environments."+envName+".use_secrets_manager"] = false
+	}
+	if cfg.AWS.Service == "secrets_manager" {
+		settings["aws.service"] = "parameter_store"
+		for name, envConfig := range cfg.Environments {
+			if name != envName && !envConfig.UseSecretsManager {
+				settings["environments."+name+".use_secrets_manager"] = true
+			}
+		}
+	}
+	return settings
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func confirmRestructure(envName, layout string) bool {
+	return prompt.Confirm(color.FormatWarning(fmt.Sprintf("Migrate %s to %s?", envName, layout)))
+}
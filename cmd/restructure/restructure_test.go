@@ -0,0 +1,45 @@
+package restructure
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRestructureCmd(t *testing.T) {
+	cmd := GetRestructureCmd()
+	assert.Equal(t, "restructure", cmd.Use)
+	for _, name := range []string{"env", "to", "delete", "dry-run", "force"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestLayoutSettings(t *testing.T) {
+	cfg := &config.Config{
+		AWS: config.AWSConfig{Service: "parameter_store"},
+		Environments: map[string]config.Environment{
+			"dev":  {},
+			"prod": {UseSecretsManager: true},
+		},
+	}
+	assert.Equal(t, map[string]interface{}{"environments.dev.use_secrets_manager": true},
+		layoutSettings(cfg, "dev", "single-secret"))
+	assert.Equal(t, map[string]interface{}{"environments.prod.use_secrets_manager": false},
+		layoutSettings(cfg, "prod", "per-key-parameters"))
+}
+
+func TestLayoutSettings_PinsOtherEnvironments(t *testing.T) {
+	cfg := &config.Config{
+		AWS: config.AWSConfig{Service: "secrets_manager"},
+		Environments: map[string]config.Environment{
+			"dev":     {},
+			"staging": {},
+			"prod":    {UseSecretsManager: true},
+		},
+	}
+	assert.Equal(t, map[string]interface{}{
+		"aws.service": "parameter_store",
+		"environments.staging.use_secrets_manager": true,
+	}, layoutSettings(cfg, "dev", "per-key-parameters"))
+}
@@ -0,0 +1,137 @@
+// Package rolecmd implements the role command. It is not named after its
+// directory to avoid clashing with the internal role package it uses.
+package rolecmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/role"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// roleCmd represents the role command
+var roleCmd = &cobra.Command{
+	Use:   "role",
+	Short: "Show the role of this machine and the commands it runs",
+	Long: `Roles are named command sets defined in .envyrc, e.g. developers run
+pull, run and diff while operators also push. The role of this machine
+hides the commands of other roles from 'envy help' and refuses to run
+them, so a production push cannot happen from the wrong laptop by
+accident.
+
+The role is ENVY_ROLE when set, else the one saved with 'envy role use',
+else default_role from .envyrc. Roles are a local guard rail: what a
+principal may change in AWS is still decided by IAM.`,
+	Example: `  # Show the current role and the roles of the project
+  envy role
+
+  # Make this machine an operator
+  envy role use operator
+
+  # Go back to default_role
+  envy role clear`,
+	Args: cobra.NoArgs,
+	RunE: runRole,
+}
+
+// useCmd represents the role use command
+var useCmd = &cobra.Command{
+	Use:   "use ROLE",
+	Short: "Make ROLE the role of this machine",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runUse,
+}
+
+// clearCmd represents the role clear command
+var clearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Forget the saved role and use default_role",
+	Args:  cobra.NoArgs,
+	RunE:  runClear,
+}
+
+// GetRoleCmd returns the role command
+func GetRoleCmd() *cobra.Command {
+	return roleCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(roleCmd)
+	roleCmd.AddCommand(useCmd)
+	roleCmd.AddCommand(clearCmd)
+}
+
+func runRole(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	active, err := role.Current(cfg.Roles, cfg.DefaultRole)
+	if err != nil {
+		return err
+	}
+	return printRoles(cmd.OutOrStdout(), cfg, active)
+}
+
+// printRoles shows the active role and every role of the project
+func printRoles(w io.Writer, cfg *config.Config, active *role.Active) error {
+	if len(cfg.Roles) == 0 {
+		fmt.Fprintln(w, "No roles are defined in .envyrc; every command is available.")
+		return nil
+	}
+	if active == nil {
+		fmt.Fprintln(w, "No role is set; every command is available.")
+	} else {
+		fmt.Fprintf(w, "Role: %s (from %s)\n", color.FormatBold(active.Name), active.Source)
+	}
+
+	fmt.Fprintln(w)
+	for _, name := range role.Names(cfg.Roles) {
+		r := cfg.Roles[name]
+		marker := "  "
+		if active != nil && active.Name == name {
+			marker = "* "
+		}
+		fmt.Fprintf(w, "%s%s", marker, name)
+		if r.Description != "" {
+			fmt.Fprintf(w, " - %s", r.Description)
+		}
+		fmt.Fprintf(w, "\n    %s\n", strings.Join(r.Commands, ", "))
+	}
+	return nil
+}
+
+func runUse(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	name := args[0]
+	if _, ok := cfg.Roles[name]; !ok {
+		return fmt.Errorf("role '%s' is not defined; roles: %s", name, strings.Join(role.Names(cfg.Roles), ", "))
+	}
+
+	if err := role.Save(name); err != nil {
+		return fmt.Errorf("failed to save role: %w", err)
+	}
+	color.PrintSuccessf("This machine now has the %s role", name)
+	if env := os.Getenv(role.EnvVar); env != "" && env != name {
+		color.PrintWarningf("%s=%s overrides it in this shell", role.EnvVar, env)
+	}
+	return nil
+}
+
+func runClear(cmd *cobra.Command, args []string) error {
+	if err := role.Save(""); err != nil {
+		return fmt.Errorf("failed to remove the saved role: %w", err)
+	}
+	color.PrintSuccessf("Removed the saved role")
+	return nil
+}
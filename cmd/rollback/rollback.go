@@ -0,0 +1,137 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/history"
+	"github.com/drapon/envy/internal/precheck"
+	"github.com/drapon/envy/internal/prompt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	environment string
+	version     int64
+	dryRun      bool
+	force       bool
+)
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback VARIABLE",
+	Short: "Restore an earlier Parameter Store version of a variable",
+	Long: `Restore the value of an earlier version of a variable, as listed by
+'envy history'. The value is written as a new version, so the rollback
+itself shows up in the history and can be rolled back, and it is recorded
+for 'envy undo'.
+
+Versions whose value was stored in Secrets Manager (over 4 KB) cannot be
+restored, since the secret only holds the latest large value.`,
+	Example: `  # List the versions, then restore version 4
+  envy history DATABASE_URL --env prod
+  envy rollback DATABASE_URL --version 4 --env prod`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRollback,
+}
+
+// GetRollbackCmd returns the rollback command.
+func GetRollbackCmd() *cobra.Command {
+	return rollbackCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(rollbackCmd)
+
+	rollbackCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment (default: default_environment)")
+	rollbackCmd.Flags().Int64Var(&version, "version", 0, "Version to restore (see envy history)")
+	rollbackCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be restored without making changes")
+	rollbackCmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation")
+	_ = rollbackCmd.MarkFlagRequired("version")
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	key := args[0]
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.UsesAzure() {
+		return fmt.Errorf("envy rollback is only supported with AWS")
+	}
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	// Check the version before asking, RollbackVariable checks it again
+	versions, err := awsManager.VariableHistory(ctx, environment, key)
+	if err != nil {
+		return err
+	}
+	target, err := aws.RollbackTarget(versions, version)
+	if err != nil {
+		return err
+	}
+	current := versions[len(versions)-1]
+
+	color.PrintBoldf("Rolling back %s in %s from version %d to version %d (%s)", key, environment, current.Version, target.Version, target.LastModified.Local().Format("2006-01-02 15:04:05"))
+	if current.Value == target.Value {
+		color.PrintInfof("Version %d has the current value; nothing to do", target.Version)
+		return nil
+	}
+
+	if err := precheck.Writers(ctx, cfg, awsManager, []string{key}); err != nil {
+		return err
+	}
+	// The old value may break rules added since it was current
+	if cfg.ValidateOnPush {
+		restored := env.NewFile()
+		restored.Set(key, target.Value)
+		if err := precheck.Validate(ctx, environment, restored, nil, true); err != nil {
+			return err
+		}
+	}
+
+	if dryRun {
+		color.PrintWarningf("\n[DRY RUN] No changes will be made")
+		return nil
+	}
+
+	if !force && !confirmRollback(key, environment) {
+		color.PrintWarningf("Rollback cancelled")
+		return nil
+	}
+
+	// Remember the current value so the rollback can be undone with envy undo
+	pending, err := history.Begin(ctx, cfg, awsManager, environment, "rollback", []string{key})
+	if err != nil {
+		color.PrintWarningf("This rollback will not be recorded for envy undo: %v", err)
+	}
+
+	if _, err := awsManager.RollbackVariable(ctx, environment, key, version); err != nil {
+		return fmt.Errorf("failed to roll back %s: %w", key, err)
+	}
+	if err := pending.Commit(map[string]string{key: target.Value}, nil); err != nil {
+		color.PrintWarningf("Failed to record the rollback for envy undo: %v", err)
+	}
+
+	color.PrintSuccessf("Restored version %d of %s in %s", target.Version, key, environment)
+	return nil
+}
+
+func confirmRollback(key, envName string) bool {
+	return prompt.Confirm(color.FormatWarning(fmt.Sprintf("About to change %s in %s.", key, envName)) + " Continue?")
+}
@@ -0,0 +1,94 @@
+package root
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/role"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// configLoaded is set once initConfig has read .envyrc. Help requested with
+// --help is shown before cobra initializes, so the help function loads it.
+var configLoaded bool
+
+// activeRole returns the role of this machine from the roles of .envyrc,
+// or nil when every command is available
+func activeRole() (*role.Active, error) {
+	var roles map[string]config.RoleConfig
+	if err := viper.UnmarshalKey("roles", &roles); err != nil {
+		return nil, fmt.Errorf("invalid roles: %w", err)
+	}
+	return role.Current(roles, viper.GetString("default_role"))
+}
+
+// commandPath returns the path of cmd below the root, e.g. "replicate status"
+func commandPath(cmd *cobra.Command) string {
+	return strings.Join(strings.Fields(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name())), " ")
+}
+
+// enforceRole refuses commands outside the role of this machine
+func enforceRole(cmd *cobra.Command) error {
+	active, err := activeRole()
+	if err != nil {
+		return err
+	}
+	if active == nil {
+		return nil
+	}
+	// Shell completion offers the role's commands only
+	hideCommands(cmd.Root(), active)
+
+	path := commandPath(cmd)
+	if active.Allows(path) {
+		return nil
+	}
+	return fmt.Errorf("'envy %s' is not available to the %s role (from %s), which runs: %s; switch roles with 'envy role use <role>' or %s",
+		path, active.Name, active.Source, strings.Join(active.Role.Commands, ", "), role.EnvVar)
+}
+
+// hideCommands hides the commands of other roles from help
+func hideCommands(cmd *cobra.Command, active *role.Active) {
+	for _, child := range cmd.Commands() {
+		if !active.Shows(commandPath(child)) {
+			child.Hidden = true
+			continue
+		}
+		hideCommands(child, active)
+	}
+}
+
+// roleHelp wraps the help function to list only the commands of the role
+// of this machine and to say which role that is
+func roleHelp(help func(*cobra.Command, []string)) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		if !configLoaded {
+			initConfig()
+		}
+		active, err := activeRole()
+		if err != nil {
+			help(cmd, args)
+			fmt.Fprintln(cmd.ErrOrStderr(), color.FormatWarning(err.Error()))
+			return
+		}
+		if active != nil {
+			hideCommands(cmd.Root(), active)
+		}
+		help(cmd, args)
+		if active == nil {
+			return
+		}
+
+		out := cmd.OutOrStdout()
+		if path := commandPath(cmd); !active.Allows(path) {
+			fmt.Fprintln(out, color.FormatWarning(fmt.Sprintf("\n'envy %s' is locked for the %s role.", path, active.Name)))
+			return
+		}
+		if !cmd.HasParent() {
+			fmt.Fprintf(out, "\nRole %s (from %s): commands of other roles are hidden and locked. See 'envy role'.\n", active.Name, active.Source)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package root
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/role"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHideCommands(t *testing.T) {
+	envy := &cobra.Command{Use: "envy"}
+	pull := &cobra.Command{Use: "pull", Run: func(*cobra.Command, []string) {}}
+	push := &cobra.Command{Use: "push", Run: func(*cobra.Command, []string) {}}
+	replicate := &cobra.Command{Use: "replicate"}
+	status := &cobra.Command{Use: "status", Run: func(*cobra.Command, []string) {}}
+	setup := &cobra.Command{Use: "setup", Run: func(*cobra.Command, []string) {}}
+	replicate.AddCommand(status, setup)
+	envy.AddCommand(pull, push, replicate)
+
+	active := &role.Active{Name: "developer", Role: config.RoleConfig{Commands: []string{"pull", "replicate status"}}}
+	hideCommands(envy, active)
+
+	assert.False(t, pull.Hidden)
+	assert.True(t, push.Hidden)
+	assert.False(t, replicate.Hidden)
+	assert.False(t, status.Hidden)
+	assert.True(t, setup.Hidden)
+	assert.Equal(t, "replicate status", commandPath(status))
+}
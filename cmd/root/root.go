@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/drapon/envy/internal/cache"
+	"github.com/drapon/envy/internal/chaos"
 	"github.com/drapon/envy/internal/color"
 	"github.com/drapon/envy/internal/log"
+	"github.com/drapon/envy/internal/structured"
 	"github.com/drapon/envy/internal/updater"
 	"github.com/drapon/envy/internal/version"
 	"github.com/spf13/cobra"
@@ -34,7 +38,10 @@ by syncing between local .env files and AWS Parameter Store/Secrets Manager.
 It provides seamless bidirectional synchronization, multi-environment support,
 and secure storage for your application's configuration.`,
 	Version: version.GetInfo().Version,
-	Example: `  # Initialize a new project
+	Example: `  # New to envy? Take the guided tour against LocalStack
+  envy tour
+
+  # Initialize a new project
   envy init
 
   # Push environment variables to AWS
@@ -47,6 +54,15 @@ and secure storage for your application's configuration.`,
   envy list --env staging`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := enforceRole(cmd); err != nil {
+			return err
+		}
+		if _, err := structured.FromCommand(cmd); err != nil {
+			return err
+		}
+		return applyCommandDefaults(cmd, viper.GetStringMap("commands"))
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -78,18 +94,32 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "enable debug logging")
 	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "enable verbose output")
 	rootCmd.PersistentFlags().BoolVar(&quiet, "quiet", false, "suppress non-error output")
+	rootCmd.PersistentFlags().String("log-level", "", "log level, optionally per subsystem (aws, cache, env, parallel, updater), e.g. warn,aws=debug")
 	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output")
 	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "disable cache usage")
 	rootCmd.PersistentFlags().BoolVar(&clearCache, "clear-cache", false, "clear cache before executing command")
 	rootCmd.PersistentFlags().Bool("no-update-check", false, "disable automatic update check")
+	rootCmd.PersistentFlags().String("output", "", "output format for scripts: json, yaml or table (default: each command's own)")
+	rootCmd.PersistentFlags().BoolP("yes", "y", false, "answer yes to every confirmation, without prompting")
+	rootCmd.PersistentFlags().Bool("non-interactive", false, "never prompt, taking the default answer (also when CI=true); confirmations are declined unless --yes")
+	rootCmd.PersistentFlags().String("chaos", "", "inject synthetic AWS failures, e.g. throttling=0.2,timeout=0.05")
+	_ = rootCmd.PersistentFlags().MarkHidden("chaos")
 
 	// Bind flags to viper
 	_ = viper.BindPFlag("debug", rootCmd.PersistentFlags().Lookup("debug"))
 	_ = viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
 	_ = viper.BindPFlag("quiet", rootCmd.PersistentFlags().Lookup("quiet"))
+	_ = viper.BindPFlag("log_level", rootCmd.PersistentFlags().Lookup("log-level"))
 	_ = viper.BindPFlag("no_color", rootCmd.PersistentFlags().Lookup("no-color"))
 	_ = viper.BindPFlag("no_cache", rootCmd.PersistentFlags().Lookup("no-cache"))
 	_ = viper.BindPFlag("clear_cache", rootCmd.PersistentFlags().Lookup("clear-cache"))
+	_ = viper.BindPFlag("chaos", rootCmd.PersistentFlags().Lookup("chaos"))
+	_ = viper.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output"))
+	_ = viper.BindPFlag("yes", rootCmd.PersistentFlags().Lookup("yes"))
+	_ = viper.BindPFlag("non_interactive", rootCmd.PersistentFlags().Lookup("non-interactive"))
+
+	// List only the commands of the machine's role
+	rootCmd.SetHelpFunc(roleHelp(rootCmd.HelpFunc()))
 
 	// Set custom version template
 	rootCmd.SetVersionTemplate(version.GetInfo().DetailedString())
@@ -97,6 +127,7 @@ func init() {
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
+	configLoaded = true
 	if cfgFile != "" {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
@@ -124,6 +155,9 @@ func initConfig() {
 	// Set environment variable prefix
 	viper.SetEnvPrefix("ENVY")
 	viper.AutomaticEnv() // read in environment variables that match
+	// Point CI jobs at a cache restored by 'envy cache warm --output-dir'
+	_ = viper.BindEnv("cache.dir", "ENVY_CACHE_DIR")
+	_ = viper.BindEnv("cache.encryption_key", "ENVY_CACHE_ENCRYPTION_KEY")
 
 	// Set defaults
 	viper.SetDefault("project", "myapp")
@@ -163,6 +197,109 @@ func initConfig() {
 		log.Warn("Failed to initialize cache system", log.ErrorField(err))
 		// Continue program even if cache initialization fails (will work without cache)
 	}
+
+	// Enable synthetic AWS failures (--chaos or ENVY_CHAOS)
+	injector, err := chaos.Parse(viper.GetString("chaos"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, color.FormatError(err.Error()))
+		os.Exit(1)
+	}
+	if injector != nil {
+		chaos.Enable(injector)
+		fmt.Fprintln(os.Stderr, color.FormatWarning("Chaos mode enabled, injecting AWS failures: "+injector.String()))
+	}
+}
+
+// applyCommandDefaults sets the flags that were not given on the command
+// line from the commands section of .envyrc, e.g.
+//
+//	commands:
+//	  push: { parallel: true, max_workers: 20 }
+//	  replicate setup: { regions: [us-west-2] }
+//
+// Subcommands may also be nested under their parent. Flag names may use
+// underscores in place of dashes.
+func applyCommandDefaults(cmd *cobra.Command, commands map[string]interface{}) error {
+	path := strings.Fields(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()))
+	if len(path) == 0 {
+		return nil
+	}
+	defaults := commandDefaults(commands, path)
+	section := "commands." + strings.Join(path, " ")
+
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := defaults[name]
+		if _, ok := asMap(value); ok {
+			continue // a nested subcommand section
+		}
+
+		flagName := strings.ReplaceAll(name, "_", "-")
+		flag := cmd.Flags().Lookup(flagName)
+		if flag == nil {
+			return fmt.Errorf("%s: unknown flag --%s", section, flagName)
+		}
+		if flag.Changed {
+			continue
+		}
+
+		var err error
+		if list, ok := value.([]interface{}); ok {
+			items := make([]string, len(list))
+			for i, item := range list {
+				items[i] = fmt.Sprint(item)
+			}
+			if slice, ok := flag.Value.(interface{ Replace([]string) error }); ok {
+				err = slice.Replace(items)
+			} else {
+				err = flag.Value.Set(strings.Join(items, ","))
+			}
+		} else {
+			err = flag.Value.Set(fmt.Sprint(value))
+		}
+		if err != nil {
+			return fmt.Errorf("%s: invalid value %v for --%s: %w", section, value, flagName, err)
+		}
+	}
+	return nil
+}
+
+// commandDefaults finds the defaults for a command path, keyed either by the
+// full path ("replicate status") or nested under each parent
+func commandDefaults(commands map[string]interface{}, path []string) map[string]interface{} {
+	if defaults, ok := asMap(commands[strings.Join(path, " ")]); ok {
+		return defaults
+	}
+
+	current := commands
+	for _, name := range path {
+		next, ok := asMap(current[name])
+		if !ok {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+// asMap converts a YAML mapping as decoded by viper
+func asMap(value interface{}) (map[string]interface{}, bool) {
+	switch m := value.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			converted[fmt.Sprint(k)] = v
+		}
+		return converted, true
+	}
+	return nil, false
 }
 
 // GetRootCmd returns the root command
@@ -52,6 +52,14 @@ func TestRootCommand(t *testing.T) {
 				assert.False(t, IsClearCache())
 			},
 		},
+		{
+			name: "chaos flag is hidden",
+			testFunc: func(t *testing.T) {
+				flag := GetRootCmd().PersistentFlags().Lookup("chaos")
+				assert.NotNil(t, flag)
+				assert.True(t, flag.Hidden)
+			},
+		},
 		{
 			name: "AddCommand adds command successfully",
 			testFunc: func(t *testing.T) {
@@ -87,4 +95,72 @@ func TestRootCommand(t *testing.T) {
 func TestExecute(t *testing.T) {
 	// Skip this test as it requires full initialization
 	t.Skip("Execute requires full application initialization")
-}
\ No newline at end of file
+}
+func TestApplyCommandDefaults(t *testing.T) {
+	newTree := func() (*cobra.Command, *cobra.Command, *cobra.Command) {
+		app := &cobra.Command{Use: "envy"}
+		push := &cobra.Command{Use: "push"}
+		push.Flags().Bool("parallel", false, "")
+		push.Flags().Int("max-workers", 10, "")
+		push.Flags().StringSlice("exclude", nil, "")
+		replicate := &cobra.Command{Use: "replicate"}
+		status := &cobra.Command{Use: "status"}
+		status.Flags().String("interval", "", "")
+		replicate.AddCommand(status)
+		app.AddCommand(push, replicate)
+		return push, replicate, status
+	}
+
+	t.Run("applies defaults", func(t *testing.T) {
+		push, _, _ := newTree()
+		err := applyCommandDefaults(push, map[string]interface{}{
+			"push": map[string]interface{}{"parallel": true, "max_workers": 20, "exclude": []interface{}{"A", "B"}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "true", push.Flags().Lookup("parallel").Value.String())
+		assert.Equal(t, "20", push.Flags().Lookup("max-workers").Value.String())
+		assert.Equal(t, "[A,B]", push.Flags().Lookup("exclude").Value.String())
+	})
+
+	t.Run("command line wins", func(t *testing.T) {
+		push, _, _ := newTree()
+		assert.NoError(t, push.Flags().Set("max-workers", "5"))
+		err := applyCommandDefaults(push, map[string]interface{}{
+			"push": map[string]interface{}{"max_workers": 20},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "5", push.Flags().Lookup("max-workers").Value.String())
+	})
+
+	t.Run("subcommands", func(t *testing.T) {
+		_, replicate, status := newTree()
+		assert.NoError(t, applyCommandDefaults(status, map[string]interface{}{
+			"replicate status": map[string]interface{}{"interval": "5m"},
+		}))
+		assert.Equal(t, "5m", status.Flags().Lookup("interval").Value.String())
+
+		_, replicate, status = newTree()
+		assert.NoError(t, applyCommandDefaults(status, map[string]interface{}{
+			"replicate": map[string]interface{}{"status": map[string]interface{}{"interval": "1m"}},
+		}))
+		assert.Equal(t, "1m", status.Flags().Lookup("interval").Value.String())
+
+		// The parent's section only holds the nested one
+		assert.NoError(t, applyCommandDefaults(replicate, map[string]interface{}{
+			"replicate": map[string]interface{}{"status": map[string]interface{}{"interval": "1m"}},
+		}))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		push, _, _ := newTree()
+		err := applyCommandDefaults(push, map[string]interface{}{
+			"push": map[string]interface{}{"paralel": true},
+		})
+		assert.ErrorContains(t, err, "commands.push: unknown flag --paralel")
+
+		err = applyCommandDefaults(push, map[string]interface{}{
+			"push": map[string]interface{}{"max_workers": "many"},
+		})
+		assert.ErrorContains(t, err, "invalid value many for --max-workers")
+	})
+}
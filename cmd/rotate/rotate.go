@@ -0,0 +1,283 @@
+package rotate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/history"
+	"github.com/drapon/envy/internal/precheck"
+	"github.com/drapon/envy/internal/prompt"
+	"github.com/drapon/envy/internal/rotate"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	environment string
+	vars        []string
+	dryRun      bool
+	force       bool
+	updateLocal bool
+)
+
+// rotateCmd represents the rotate command
+var rotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Replace secrets with new random values",
+	Long: `Generate new random values for the secrets of an environment and push them
+to AWS.
+
+Without --vars every variable classified as sensitive is rotated. --vars
+selects variables by name or pattern instead, sensitive or not. New values
+are never printed; use --update-local to write them to the environment's
+.env files, and 'envy undo' to restore the previous values.
+
+Values are 32 random alphanumeric characters unless .envyrc says otherwise:
+
+  variables:
+    - key: "*_API_KEY"
+      rotate:
+        length: 48
+        charset: base64url   # alnum, hex, base64url, digits or ascii
+    - key: STRIPE_WEBHOOK_SECRET
+      rotate:
+        pattern: "whsec_[A-Za-z0-9]{32}"`,
+	Example: `  # Show which secrets of prod would be rotated
+  envy rotate --env prod --dry-run
+
+  # Rotate two variables and update .env.dev with the new values
+  envy rotate --env dev --vars SESSION_SECRET,JWT_SECRET --update-local
+
+  # Rotate every signing key without confirmation
+  envy rotate --env staging --vars "*_SIGNING_KEY" --force`,
+	Args: cobra.NoArgs,
+	RunE: runRotate,
+}
+
+// GetRotateCmd returns the rotate command.
+func GetRotateCmd() *cobra.Command {
+	return rotateCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(rotateCmd)
+
+	rotateCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to rotate (default: default_environment)")
+	rotateCmd.Flags().StringSliceVar(&vars, "vars", nil, "Variables or patterns to rotate (default: all sensitive variables)")
+	rotateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be rotated without making changes")
+	rotateCmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation")
+	rotateCmd.Flags().BoolVar(&updateLocal, "update-local", false, "Also write the new values to the environment's .env files")
+}
+
+func runRotate(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.UsesAzure() {
+		return fmt.Errorf("envy rotate is only supported with AWS")
+	}
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+	envConfig, err := cfg.GetEnvironment(environment)
+	if err != nil {
+		return err
+	}
+
+	rules, err := rotate.ParseRules(cfg.Variables)
+	if err != nil {
+		return err
+	}
+	isSensitive, err := sensitive.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	current, err := awsManager.ListEnvironmentVariables(ctx, environment)
+	if err != nil {
+		return fmt.Errorf("failed to read current values: %w", err)
+	}
+
+	keys, err := selectKeys(current, vars, isSensitive)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		color.PrintInfof("No sensitive variables to rotate in %s; select them with --vars", environment)
+		return nil
+	}
+
+	set, err := generate(rules, keys)
+	if err != nil {
+		return err
+	}
+
+	color.PrintBoldf("Rotating %d variable(s) in %s:", len(keys), environment)
+	for _, key := range keys {
+		fmt.Printf("  %s %s (%s)\n", color.FormatWarning("~"), key, rotate.Find(rules, key))
+	}
+
+	if err := precheck.Placeholders(cfg, environment, envConfig, set, false); err != nil {
+		return err
+	}
+	if err := precheck.Writers(ctx, cfg, awsManager, keys); err != nil {
+		return err
+	}
+	// Generated values must still satisfy the rules, e.g. a minimum length
+	if cfg.ValidateOnPush {
+		result := env.NewFile()
+		for key, value := range current {
+			result.Set(key, value)
+		}
+		result.Merge(set)
+		if err := precheck.Validate(ctx, environment, result, nil, true); err != nil {
+			return err
+		}
+	}
+
+	if dryRun {
+		color.PrintWarningf("\n[DRY RUN] No changes will be made")
+		return nil
+	}
+
+	if !force && !confirmRotate(len(keys), environment) {
+		color.PrintWarningf("Rotation cancelled")
+		return nil
+	}
+
+	// Remember the current values so the rotation can be undone with envy undo
+	pending, err := history.Begin(ctx, cfg, awsManager, environment, "rotate", keys)
+	if err != nil {
+		color.PrintWarningf("This rotation will not be recorded for envy undo: %v", err)
+	}
+
+	if err := awsManager.ApplyChanges(ctx, environment, set, nil); err != nil {
+		return fmt.Errorf("failed to push the new values: %w", err)
+	}
+	if err := pending.Commit(set.ToMap(), nil); err != nil {
+		color.PrintWarningf("Failed to record the rotation for envy undo: %v", err)
+	}
+	color.PrintSuccessf("Rotated %d variable(s) in %s", len(keys), environment)
+
+	if updateLocal {
+		return writeLocal(cfg, environment, set)
+	}
+	return nil
+}
+
+// selectKeys returns the variables to rotate, sorted: those matching the
+// --vars names and patterns, or the sensitive ones
+func selectKeys(current map[string]string, patterns []string, isSensitive func(string) bool) ([]string, error) {
+	var keys []string
+	if len(patterns) == 0 {
+		for key := range current {
+			if isSensitive(key) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		return keys, nil
+	}
+
+	for _, pattern := range patterns {
+		match, err := filter.New(filter.Options{Include: []string{pattern}})
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for key := range current {
+			if match.Match(key) {
+				keys = append(keys, key)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s matches no variable in AWS", pattern)
+		}
+	}
+
+	// Patterns may overlap
+	sort.Strings(keys)
+	unique := keys[:0]
+	for i, key := range keys {
+		if i == 0 || key != keys[i-1] {
+			unique = append(unique, key)
+		}
+	}
+	return unique, nil
+}
+
+// generate creates a new value for each key
+func generate(rules []rotate.Rule, keys []string) (*env.File, error) {
+	set := env.NewFile()
+	for _, key := range keys {
+		value, err := rotate.Find(rules, key).Generate()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		set.Set(key, value)
+	}
+	return set, nil
+}
+
+// writeLocal updates the rotated variables in the environment's .env files
+// that define them
+func writeLocal(cfg *config.Config, envName string, set *env.File) error {
+	envConfig, err := cfg.GetEnvironment(envName)
+	if err != nil {
+		return err
+	}
+
+	for _, filename := range envConfig.Files {
+		if _, err := os.Stat(filename); err != nil {
+			continue
+		}
+		file, err := env.ParseFile(filename)
+		if err != nil {
+			return err
+		}
+
+		var updated []string
+		for _, key := range set.SortedKeys() {
+			if _, ok := file.Get(key); ok {
+				value, _ := set.Get(key)
+				file.Set(key, value)
+				updated = append(updated, key)
+			}
+		}
+		if len(updated) == 0 {
+			continue
+		}
+
+		if err := file.WriteFile(filename); err != nil {
+			return fmt.Errorf("failed to update %s: %w", filename, err)
+		}
+		if err := os.Chmod(filename, 0600); err != nil {
+			color.PrintWarningf("Failed to set permissions on %s: %v", filename, err)
+		}
+		color.PrintSuccessf("  %s: updated %s", filename, strings.Join(updated, ", "))
+	}
+	return nil
+}
+
+func confirmRotate(count int, envName string) bool {
+	return prompt.Confirm(color.FormatWarning(fmt.Sprintf("About to replace %d secrets in %s; applications using the old values will stop working.", count, envName)) + " Continue?")
+}
@@ -0,0 +1,63 @@
+package rotate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectKeys(t *testing.T) {
+	current := map[string]string{
+		"API_KEY": "a", "DB_PASSWORD": "b", "LOG_LEVEL": "info", "STRIPE_SIGNING_KEY": "c", "GITHUB_SIGNING_KEY": "d",
+	}
+
+	keys, err := selectKeys(current, nil, sensitive.IsSensitive)
+	require.NoError(t, err)
+	assert.Contains(t, keys, "DB_PASSWORD")
+	assert.NotContains(t, keys, "LOG_LEVEL")
+
+	keys, err = selectKeys(current, []string{"LOG_LEVEL", "*_SIGNING_KEY", "STRIPE_SIGNING_KEY"}, sensitive.IsSensitive)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GITHUB_SIGNING_KEY", "LOG_LEVEL", "STRIPE_SIGNING_KEY"}, keys)
+
+	_, err = selectKeys(current, []string{"MISSING"}, sensitive.IsSensitive)
+	assert.ErrorContains(t, err, "MISSING matches no variable")
+}
+
+func TestWriteLocal(t *testing.T) {
+	dir := t.TempDir()
+	defer os.Chdir(mustGetwd(t))
+	require.NoError(t, os.Chdir(dir))
+
+	require.NoError(t, os.WriteFile(".env.dev", []byte("API_KEY=old\nLOG_LEVEL=info\n"), 0644))
+	cfg := &config.Config{
+		Environments: map[string]config.Environment{
+			"dev": {Files: []string{".env.dev", ".env.missing"}},
+		},
+	}
+
+	set := env.NewFile()
+	set.Set("API_KEY", "new")
+	set.Set("NOT_LOCAL", "x")
+	require.NoError(t, writeLocal(cfg, "dev", set))
+
+	file, err := env.ParseFile(filepath.Join(dir, ".env.dev"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "new", "LOG_LEVEL": "info"}, file.ToMap())
+
+	info, err := os.Stat(".env.dev")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func mustGetwd(t *testing.T) string {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	return wd
+}
@@ -0,0 +1,77 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/viper"
+
+	"github.com/drapon/envy/internal/agent"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+)
+
+// shellOverrides change where or how AWS is read in this shell. The system
+// agent reads .envyrc with its own environment, so it is bypassed when any
+// of them is set.
+var shellOverrides = []string{"AWS_ACCESS_KEY_ID", "ENVY_PROJECT", "ENVY_AWS_REGION", "ENVY_AWS_SERVICE"}
+
+// pullFromSystemAgent asks the user's system agent (envy agent --system)
+// for the environment, so new shells share its cache and credentials instead
+// of each calling AWS. It reports false when no agent runs or it cannot
+// serve the environment, and the caller pulls from AWS itself.
+func pullFromSystemAgent(ctx context.Context, cfg *config.Config, envName string) (*env.File, bool) {
+	socket := agent.SystemSocketPath()
+	if _, err := os.Stat(socket); err != nil || systemAgentBypassed(cfg) {
+		return nil, false
+	}
+	configPath, err := config.FindFile(viper.GetString("config"))
+	if err != nil {
+		return nil, false
+	}
+
+	vars, err := agent.NewClient(socket).Environment(ctx, configPath, profile(cfg), envName)
+	if err != nil {
+		if verbose {
+			fmt.Printf("System agent unavailable, pulling from AWS: %v\n", err)
+		}
+		return nil, false
+	}
+	if verbose {
+		fmt.Printf("Loaded environment '%s' from the system agent\n", envName)
+	}
+
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	file := env.NewFile()
+	for _, key := range keys {
+		file.Set(key, vars[key])
+	}
+	return file, true
+}
+
+// systemAgentBypassed reports whether this shell reads AWS differently than
+// the system agent would
+func systemAgentBypassed(cfg *config.Config) bool {
+	for _, name := range shellOverrides {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	// Without a region in .envyrc the SDK takes it from the environment
+	return cfg.AWS.Region == "" && (os.Getenv("AWS_REGION") != "" || os.Getenv("AWS_DEFAULT_REGION") != "")
+}
+
+// profile returns the AWS profile this shell would use, which the system
+// agent uses in its place
+func profile(cfg *config.Config) string {
+	if cfg.AWS.Profile != "" {
+		return cfg.AWS.Profile
+	}
+	return os.Getenv("AWS_PROFILE")
+}
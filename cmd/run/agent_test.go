@@ -0,0 +1,41 @@
+package run
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/drapon/envy/internal/config"
+)
+
+func TestSystemAgentBypassed(t *testing.T) {
+	for _, name := range append(shellOverrides, "AWS_REGION", "AWS_DEFAULT_REGION") {
+		t.Setenv(name, "")
+	}
+	cfg := &config.Config{}
+	assert.False(t, systemAgentBypassed(cfg))
+
+	t.Setenv("AWS_REGION", "eu-west-1")
+	assert.True(t, systemAgentBypassed(cfg), "the agent would not see the region")
+	cfg.AWS.Region = "us-east-1"
+	assert.False(t, systemAgentBypassed(cfg))
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAEXAMPLE")
+	assert.True(t, systemAgentBypassed(cfg), "the shell's own credentials win")
+}
+
+func TestProfile(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "dev")
+	assert.Equal(t, "dev", profile(&config.Config{}))
+
+	cfg := &config.Config{}
+	cfg.AWS.Profile = "admin"
+	assert.Equal(t, "admin", profile(cfg))
+}
+
+func TestPullFromSystemAgent_NotRunning(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	_, ok := pullFromSystemAgent(context.Background(), &config.Config{}, "dev")
+	assert.False(t, ok)
+}
@@ -0,0 +1,85 @@
+package run
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/drapon/envy/internal/bundle"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+)
+
+// checkBundleFlags rejects bundle flags without --bundle, and sources that
+// cannot be combined with it
+func checkBundleFlags() error {
+	if bundlePath == "" {
+		switch {
+		case bundlePublicKey != "":
+			return fmt.Errorf("--public-key requires --bundle")
+		case bundleKeyFile != "":
+			return fmt.Errorf("--bundle-key-file requires --bundle")
+		case bundleMaxAge != 0:
+			return fmt.Errorf("--max-age requires --bundle")
+		}
+		return nil
+	}
+
+	if from == "aws" {
+		return fmt.Errorf("--bundle cannot be combined with --from aws")
+	}
+	if bundlePublicKey == "" {
+		return fmt.Errorf("--bundle requires --public-key to verify the bundle")
+	}
+	return nil
+}
+
+// loadFromBundle loads the variables of a bundle after checking its
+// signature and age. AWS is never contacted.
+func loadFromBundle(cfg *config.Config, loaded *loadedEnvironment) error {
+	pub, err := bundle.LoadPublicKey(bundlePublicKey)
+	if err != nil {
+		return err
+	}
+	b, err := bundle.Read(bundlePath)
+	if err != nil {
+		return err
+	}
+	if err := b.Verify(pub); err != nil {
+		return fmt.Errorf("%s failed verification: %w", bundlePath, err)
+	}
+	if err := b.CheckAge(bundleMaxAge, time.Now()); err != nil {
+		return err
+	}
+	if environment != "" && environment != b.Metadata.Environment {
+		return fmt.Errorf("%s holds environment %s, not %s", bundlePath, b.Metadata.Environment, environment)
+	}
+
+	key, err := bundle.ResolveKey(bundleKeyFile)
+	if err != nil {
+		return err
+	}
+	envFile, err := b.OpenFile(key)
+	if err != nil {
+		return err
+	}
+
+	// Annotations live in the local files, if this project defines the
+	// bundled environment
+	var localFiles []*env.File
+	if cfg != nil {
+		if envConfig, err := cfg.GetEnvironment(b.Metadata.Environment); err == nil {
+			localFiles = parseLocalFiles(envConfig.Files)
+		}
+	}
+	if err := loaded.addMounts(localFiles...); err != nil {
+		return err
+	}
+	envFile = forProcess(envFile, consumerTags(localFiles...))
+
+	applyEnvFile(envFile, loaded.vars)
+	if verbose {
+		fmt.Printf("Loaded %d variables of %s from bundle created %s ago\n",
+			len(envFile.Variables), b.Metadata.Environment, b.Age(time.Now()).Round(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package run
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/drapon/envy/internal/bundle"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setBundleFlags(t *testing.T, path, keyFile, publicKey string, maxAge time.Duration) {
+	t.Cleanup(func() {
+		bundlePath, bundleKeyFile, bundlePublicKey, bundleMaxAge = "", "", "", 0
+		environment = ""
+	})
+	bundlePath, bundleKeyFile, bundlePublicKey, bundleMaxAge = path, keyFile, publicKey, maxAge
+}
+
+func TestLoadFromBundle(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := bundle.GenerateKeys(dir)
+	require.NoError(t, err)
+	key, err := bundle.LoadKey(paths[0])
+	require.NoError(t, err)
+	signer, err := bundle.LoadSigningKey(paths[1])
+	require.NoError(t, err)
+
+	created := time.Now().Add(-2 * time.Hour)
+	b, err := bundle.Create(bundle.Metadata{Environment: "prod", CreatedAt: created}, map[string]string{"PORT": "8080"}, nil, key, signer)
+	require.NoError(t, err)
+	path := filepath.Join(dir, "prod.envybundle")
+	require.NoError(t, b.Write(path))
+
+	newLoaded := func() *loadedEnvironment {
+		return &loadedEnvironment{vars: map[string]string{}, isSensitive: sensitive.IsSensitive}
+	}
+
+	setBundleFlags(t, path, paths[0], paths[2], 3*time.Hour)
+	loaded := newLoaded()
+	require.NoError(t, loadFromBundle(nil, loaded))
+	assert.Equal(t, map[string]string{"PORT": "8080"}, loaded.vars)
+
+	setBundleFlags(t, path, paths[0], paths[2], time.Hour)
+	assert.ErrorIs(t, loadFromBundle(nil, newLoaded()), bundle.ErrExpired)
+
+	setBundleFlags(t, path, paths[0], paths[2], 0)
+	environment = "staging"
+	assert.ErrorContains(t, loadFromBundle(nil, newLoaded()), "holds environment prod")
+}
+
+func TestCheckBundleFlags(t *testing.T) {
+	t.Cleanup(func() { from = "local" })
+
+	setBundleFlags(t, "", "", "signing.pub", 0)
+	assert.ErrorContains(t, checkBundleFlags(), "--public-key requires --bundle")
+
+	setBundleFlags(t, "prod.envybundle", "", "", 0)
+	assert.ErrorContains(t, checkBundleFlags(), "requires --public-key")
+
+	setBundleFlags(t, "prod.envybundle", "", "signing.pub", 0)
+	from = "aws"
+	assert.Error(t, checkBundleFlags())
+	from = "local"
+	assert.NoError(t, checkBundleFlags())
+}
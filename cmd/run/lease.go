@@ -0,0 +1,73 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/aws/client"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+)
+
+// leaseCredentials replaces the AWS credentials in the command's environment
+// with short-lived ones for --lease-role, scoped by --lease-policy
+func leaseCredentials(ctx context.Context, loaded *loadedEnvironment) error {
+	policy, err := readSessionPolicy(leasePolicy)
+	if err != nil {
+		return err
+	}
+
+	manager := loaded.manager
+	cfg := loaded.config
+	if manager == nil {
+		if cfg, err = config.Load(viper.GetString("config")); err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		if manager, err = aws.NewManager(cfg); err != nil {
+			return fmt.Errorf("failed to create AWS manager: %w", err)
+		}
+	}
+
+	lease, err := manager.LeaseCredentials(ctx, client.LeaseOptions{
+		RoleARN:  leaseRole,
+		Policy:   policy,
+		Duration: leaseDuration,
+	})
+	if err != nil {
+		return err
+	}
+
+	loaded.lease = lease.Environ(cfg.AWS.Region)
+	applyLease(loaded.vars, loaded.lease)
+	color.PrintInfof("envy: leased credentials for %s, expiring at %s", leaseRole, lease.Expires.Local().Format("15:04:05"))
+	return nil
+}
+
+// applyLease sets the leased credentials, dropping the profile settings
+// that could otherwise point SDKs back at the operator's credentials
+func applyLease(vars map[string]string, lease map[string]string) {
+	for _, key := range []string{"AWS_PROFILE", "AWS_DEFAULT_PROFILE", "AWS_SECURITY_TOKEN"} {
+		delete(vars, key)
+	}
+	for key, value := range lease {
+		vars[key] = value
+	}
+}
+
+// readSessionPolicy returns an inline session policy given either as JSON
+// or as the path of a JSON file
+func readSessionPolicy(policy string) (string, error) {
+	if policy == "" || strings.HasPrefix(strings.TrimSpace(policy), "{") {
+		return policy, nil
+	}
+	data, err := os.ReadFile(policy)
+	if err != nil {
+		return "", fmt.Errorf("failed to read session policy: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,51 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyLease(t *testing.T) {
+	vars := map[string]string{
+		"AWS_PROFILE":           "admin",
+		"AWS_ACCESS_KEY_ID":     "AKIAOPERATOR",
+		"AWS_SECRET_ACCESS_KEY": "operator-secret",
+		"APP_ENV":               "prod",
+	}
+	applyLease(vars, map[string]string{
+		"AWS_ACCESS_KEY_ID":     "ASIALEASED",
+		"AWS_SECRET_ACCESS_KEY": "leased-secret",
+		"AWS_SESSION_TOKEN":     "token",
+	})
+
+	assert.Equal(t, map[string]string{
+		"AWS_ACCESS_KEY_ID":     "ASIALEASED",
+		"AWS_SECRET_ACCESS_KEY": "leased-secret",
+		"AWS_SESSION_TOKEN":     "token",
+		"APP_ENV":               "prod",
+	}, vars)
+}
+
+func TestReadSessionPolicy(t *testing.T) {
+	policy, err := readSessionPolicy("")
+	require.NoError(t, err)
+	assert.Empty(t, policy)
+
+	inline := `{"Version":"2012-10-17","Statement":[]}`
+	policy, err = readSessionPolicy(inline)
+	require.NoError(t, err)
+	assert.Equal(t, inline, policy)
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(inline), 0644))
+	policy, err = readSessionPolicy(path)
+	require.NoError(t, err)
+	assert.Equal(t, inline, policy)
+
+	_, err = readSessionPolicy(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
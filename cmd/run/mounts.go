@@ -0,0 +1,189 @@
+package run
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/env"
+)
+
+// mountedFiles are the secret files written for the command, removed when
+// it exits
+type mountedFiles struct {
+	dir   string // private directory for relative mount paths, if created
+	paths []string
+}
+
+// mountedKeys returns the variables, sorted, that would be written to files
+func mountedKeys(loaded *loadedEnvironment) []string {
+	var keys []string
+	for key := range loaded.mounts {
+		if _, ok := loaded.vars[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mountTags returns the mount of each variable annotated with mount= in
+// files. Later files override earlier ones.
+func mountTags(files ...*env.File) (map[string]env.Mount, error) {
+	mounts := make(map[string]env.Mount)
+	for _, file := range files {
+		for key := range file.Variables {
+			mount, ok, err := file.Mount(key)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				mounts[key] = mount
+			}
+		}
+	}
+	return mounts, nil
+}
+
+// mountSecrets writes each mounted variable's value to its file and
+// replaces the value in vars with the file's path. Variables that are not
+// set are skipped. Relative paths may not leave the private directory and
+// existing files are never replaced.
+func mountSecrets(vars map[string]string, mounts map[string]env.Mount) (*mountedFiles, error) {
+	mounted := &mountedFiles{}
+
+	keys := make([]string, 0, len(mounts))
+	for key := range mounts {
+		if _, ok := vars[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		mount := mounts[key]
+		path := mount.Path
+		if !filepath.IsAbs(path) {
+			if escapes(path) {
+				mounted.cleanup()
+				return nil, fmt.Errorf("failed to mount %s: relative path %s may not contain '..'", key, path)
+			}
+			if mounted.dir == "" {
+				dir, err := os.MkdirTemp(secretsTempDir(), "envy-secrets-")
+				if err != nil {
+					mounted.cleanup()
+					return nil, fmt.Errorf("failed to create secrets directory: %w", err)
+				}
+				mounted.dir = dir
+			}
+			path = filepath.Join(mounted.dir, path)
+		}
+
+		if err := writeSecretFile(path, vars[key], mount.Mode); err != nil {
+			mounted.cleanup()
+			return nil, fmt.Errorf("failed to mount %s: %w", key, err)
+		}
+		mounted.paths = append(mounted.paths, path)
+		vars[key] = path
+		if verbose {
+			fmt.Printf("Mounted %s at %s\n", key, path)
+		}
+	}
+	return mounted, nil
+}
+
+// escapes reports whether a relative mount path has a ".." component
+func escapes(path string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// writeSecretFile creates path with value and mode. It fails if path
+// already exists, so a file envy did not create is never replaced (or
+// later shredded).
+func writeSecretFile(path, value string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if os.IsExist(err) {
+		return fmt.Errorf("%s already exists", path)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(value); err != nil {
+		f.Close()
+		_ = os.Remove(path)
+		return err
+	}
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		_ = os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// cleanup shreds the files created for this run and removes the private
+// directory
+func (m *mountedFiles) cleanup() {
+	if m == nil {
+		return
+	}
+	for _, path := range m.paths {
+		if err := shred(path); err != nil {
+			color.PrintWarningf("envy: failed to remove %s: %v", path, err)
+		}
+	}
+	m.paths = nil
+	if m.dir != "" {
+		_ = os.RemoveAll(m.dir)
+		m.dir = ""
+	}
+}
+
+// shred overwrites a file with zeros before removing it, so the secret does
+// not linger on disk-backed temp directories. Anything but a regular file,
+// e.g. a symlink swapped in while the command ran, is only removed.
+func shred(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return os.Remove(path)
+	}
+	if err := os.Chmod(path, 0600); err == nil {
+		if f, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+			_, _ = f.Write(make([]byte, info.Size()))
+			_ = f.Sync()
+			f.Close()
+		}
+	}
+	return os.Remove(path)
+}
+
+// secretsTempDir prefers /dev/shm, which is memory-backed on Linux, over
+// the default temp directory
+func secretsTempDir() string {
+	if runtime.GOOS == "linux" {
+		if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+			return "/dev/shm"
+		}
+	}
+	return os.TempDir()
+}
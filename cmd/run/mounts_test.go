@@ -0,0 +1,112 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/drapon/envy/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMountTags(t *testing.T) {
+	file, err := env.Parse(strings.NewReader("CERT=pem # envy: mount=tls.pem\nPLAIN=1\n"))
+	require.NoError(t, err)
+
+	mounts, err := mountTags(file)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]env.Mount{"CERT": {Path: "tls.pem", Mode: env.DefaultMountMode}}, mounts)
+
+	bad, err := env.Parse(strings.NewReader("CERT=pem # envy: mount=tls.pem mode=rw\n"))
+	require.NoError(t, err)
+	_, err = mountTags(bad)
+	assert.Error(t, err)
+}
+
+func TestMountSecrets(t *testing.T) {
+	absolute := filepath.Join(t.TempDir(), "nested", "gcp.json")
+	vars := map[string]string{
+		"TLS_CERT":        "-----BEGIN CERTIFICATE-----",
+		"GCP_CREDENTIALS": `{"type":"service_account"}`,
+		"PLAIN":           "1",
+	}
+	mounts := map[string]env.Mount{
+		"TLS_CERT":        {Path: "tls.pem", Mode: 0400},
+		"GCP_CREDENTIALS": {Path: absolute, Mode: 0440},
+		"NOT_SET":         {Path: "missing.pem", Mode: 0400},
+	}
+
+	mounted, err := mountSecrets(vars, mounts)
+	require.NoError(t, err)
+
+	assert.Equal(t, absolute, vars["GCP_CREDENTIALS"])
+	assert.True(t, strings.HasSuffix(vars["TLS_CERT"], "tls.pem"))
+	assert.Equal(t, "1", vars["PLAIN"])
+	assert.NotContains(t, vars, "NOT_SET")
+
+	data, err := os.ReadFile(vars["TLS_CERT"])
+	require.NoError(t, err)
+	assert.Equal(t, "-----BEGIN CERTIFICATE-----", string(data))
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(absolute)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0440), info.Mode().Perm())
+	}
+
+	mounted.cleanup()
+	_, err = os.Stat(vars["TLS_CERT"])
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(absolute)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Dir(vars["TLS_CERT"]))
+	assert.True(t, os.IsNotExist(err), "private directory is removed")
+}
+
+func TestMountSecrets_Unsafe(t *testing.T) {
+	vars := map[string]string{"CERT": "pem"}
+	_, err := mountSecrets(vars, map[string]env.Mount{"CERT": {Path: "../escape.pem", Mode: 0400}})
+	assert.ErrorContains(t, err, "..")
+	_, err = mountSecrets(vars, map[string]env.Mount{"CERT": {Path: "certs/../../escape.pem", Mode: 0400}})
+	assert.ErrorContains(t, err, "..")
+
+	// An existing file is neither replaced nor shredded
+	existing := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(existing, []byte("keep"), 0600))
+	vars = map[string]string{"A": "first", "B": "secret"}
+	_, err = mountSecrets(vars, map[string]env.Mount{
+		"A": {Path: "a.pem", Mode: 0400},
+		"B": {Path: existing, Mode: 0400},
+	})
+	assert.ErrorContains(t, err, "already exists")
+
+	data, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	assert.Equal(t, "keep", string(data))
+}
+
+func TestShred(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte("secret"), 0400))
+
+	require.NoError(t, shred(path))
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, shred(path), "missing files are ignored")
+}
+
+func TestMountedKeys(t *testing.T) {
+	loaded := &loadedEnvironment{
+		vars: map[string]string{"TLS_CERT": "-----BEGIN", "GCP_CREDENTIALS": "{}", "PORT": "8080"},
+		mounts: map[string]env.Mount{
+			"TLS_CERT":        {Path: "tls.pem"},
+			"GCP_CREDENTIALS": {Path: "gcp.json"},
+			"UNSET":           {Path: "unset"},
+		},
+	}
+	assert.Equal(t, []string{"GCP_CREDENTIALS", "TLS_CERT"}, mountedKeys(loaded))
+}
@@ -8,11 +8,16 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/drapon/envy/cmd/root"
 	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/cache"
+	"github.com/drapon/envy/internal/color"
 	"github.com/drapon/envy/internal/config"
 	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/inline"
+	"github.com/drapon/envy/internal/sensitive"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -26,6 +31,21 @@ var (
 	dryRun      bool
 	verbose     bool
 	from        string
+	noDisk      bool
+	watch       bool
+	poll        time.Duration
+	onChange    string
+	signalName  string
+	process     string
+
+	leaseRole     string
+	leasePolicy   string
+	leaseDuration time.Duration
+
+	bundlePath      string
+	bundleKeyFile   string
+	bundlePublicKey string
+	bundleMaxAge    time.Duration
 )
 
 // runCmd represents the run command
@@ -35,7 +55,49 @@ var runCmd = &cobra.Command{
 	Long: `Run a command with environment variables loaded from envy.
 
 This command loads environment variables from your configured sources
-and executes the specified command with those variables available.`,
+and executes the specified command with those variables available.
+
+With --process, only the variables tagged for that process are injected.
+Tag variables in the env files with a comment on the line above or inline:
+
+  # envy: consumers=web,worker
+  DATABASE_URL=...
+  STRIPE_KEY=... # envy: consumers=web
+
+consumers=* tags a variable for every process.
+
+Secrets that applications expect as files, such as TLS certificates or GCP
+credentials, can be mounted instead of passed in the environment:
+
+  TLS_CERT=... # envy: mount=/run/secrets/tls.pem mode=0400
+
+The value is written to the file (relative paths go to a private directory
+in /dev/shm when available and may not contain '..'), the variable is set
+to the file's path, and the file is overwritten and removed when the
+command exits. envy refuses to mount over a file that already exists. Mounted
+variables are not refreshed by --watch.
+
+With --from aws (or --source aws), the annotations are read from the
+environment's local files. Add --no-disk to stream the variables from
+Parameter Store or Secrets Manager straight into the command's environment:
+the pull cache is bypassed and mount annotations are refused, so no value is
+ever written to disk.
+
+With --watch, envy keeps running alongside the command and restarts it
+(SIGTERM, then SIGKILL after 10 seconds) or sends it --signal when its
+variables change: when the env files it was loaded from are edited, when
+variables with a refresh interval change in AWS, or, with --poll, when a
+full re-pull of the environment differs.
+
+With --lease-role, the command gets short-lived credentials for that role
+instead of yours, minted with AssumeRole and optionally narrowed by an
+inline session policy. They expire after --lease-duration.
+
+With --bundle, the variables come from a bundle made with 'envy bundle
+create' instead, without any access to AWS. The bundle's signature is
+checked against --public-key and, with --max-age, its age; the key comes
+from --bundle-key-file or ENVY_BUNDLE_KEY. Annotations are read from the
+bundled environment's local files when .envyrc defines it.`,
 	Example: `  # Run a command with loaded env vars
   envy run -- npm start
   
@@ -50,7 +112,34 @@ and executes the specified command with those variables available.`,
   
   # Run with AWS parameters
   envy run --env production --from aws -- ./deploy.sh
+
+  # Inject AWS parameters without writing any file, not even the cache
+  envy run --env production --source aws --no-disk -- ./server
   
+  # Restart the command when the .env files change
+  envy run --watch -- npm start
+
+  # Re-fetch variables with a refresh interval (see 'variables' in .envyrc)
+  # and restart the command when they change
+  envy run --env production --from aws --watch -- ./server
+
+  # Also re-pull the whole environment every minute
+  envy run --env production --from aws --watch --poll 1m -- ./server
+
+  # Send SIGHUP instead of restarting
+  envy run --env production --from aws --watch --on-change signal -- ./server
+
+  # Only inject the variables tagged for the worker process
+  envy run --env production --from aws --process worker -- ./worker
+
+  # Give the command 15-minute credentials limited to one bucket
+  envy run --lease-role arn:aws:iam::123456789012:role/app \
+    --lease-policy policy.json --lease-duration 15m -- ./import.sh
+
+  # Run from a bundle at a site without AWS access, refusing bundles over a day old
+  ENVY_BUNDLE_KEY=$KEY envy run --bundle prod.envybundle \
+    --public-key signing.pub --max-age 24h -- ./app
+
   # Dry run to see what would be executed
   envy run --dry-run -- npm start`,
 	Args: cobra.MinimumNArgs(1),
@@ -74,32 +163,120 @@ func init() {
 	runCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show command and environment without executing")
 	runCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show verbose output")
 	runCmd.Flags().StringVar(&from, "from", "local", "Source of variables (local/aws)")
+	runCmd.Flags().StringVar(&from, "source", "local", "Alias of --from")
+	runCmd.Flags().BoolVar(&noDisk, "no-disk", false, "Pull variables from AWS into memory only, bypassing the pull cache (requires --from aws)")
+	runCmd.Flags().BoolVar(&watch, "watch", false, "Restart or signal the command when its env files or, with --from aws, variables with a refresh interval change")
+	runCmd.Flags().DurationVar(&poll, "poll", 0, "With --watch and --from aws, also re-pull the whole environment at this interval")
+	runCmd.Flags().StringVar(&onChange, "on-change", "restart", "What to do when a watched variable changes (restart/signal)")
+	runCmd.Flags().StringVar(&signalName, "signal", "HUP", "Signal sent with --on-change signal")
+	runCmd.Flags().StringVar(&leaseRole, "lease-role", "", "Inject short-lived credentials for this role instead of your own")
+	runCmd.Flags().StringVar(&leasePolicy, "lease-policy", "", "Inline session policy for the leased credentials (JSON or a file)")
+	runCmd.Flags().DurationVar(&leaseDuration, "lease-duration", time.Hour, "Lifetime of the leased credentials (15m to 12h)")
+	runCmd.Flags().StringVar(&process, "process", "", "Only inject variables tagged for this consumer (# envy: consumers=...)")
+	runCmd.Flags().StringVar(&bundlePath, "bundle", "", "Load variables from a bundle instead of local files or AWS")
+	runCmd.Flags().StringVar(&bundleKeyFile, "bundle-key-file", "", "Shared key of the bundle (default: $ENVY_BUNDLE_KEY)")
+	runCmd.Flags().StringVar(&bundlePublicKey, "public-key", "", "Public key the bundle must be signed with (required with --bundle)")
+	runCmd.Flags().DurationVar(&bundleMaxAge, "max-age", 0, "Refuse bundles older than this (default: any age)")
+}
+
+// loadedEnvironment is the result of building the command's environment
+type loadedEnvironment struct {
+	vars map[string]string
+	// mounts are the variables written to files, from mount= annotations
+	mounts map[string]env.Mount
+	// isSensitive decides which values a dry run masks
+	isSensitive func(string) bool
+
+	// files are the env files the variables were read from, watched by
+	// --watch
+	files []string
+	// lease holds the credentials of --lease-role, kept across reloads
+	lease map[string]string
+
+	// Set when variables were loaded from AWS
+	config  *config.Config
+	manager *aws.Manager
+	envName string
+	remote  *env.File
+}
+
+// list returns the variables in KEY=VALUE form
+func (l *loadedEnvironment) list() []string {
+	var envVars []string
+	for k, v := range l.vars {
+		envVars = append(envVars, fmt.Sprintf("%s=%s", k, v))
+	}
+	return envVars
 }
 
 func runCommand(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
+	if noDisk && from != "aws" {
+		return fmt.Errorf("--no-disk requires --from aws")
+	}
+	if poll > 0 && (!watch || from != "aws") {
+		return fmt.Errorf("--poll requires --watch and --from aws")
+	}
+	if watch {
+		if onChange != "restart" && onChange != "signal" {
+			return fmt.Errorf("invalid --on-change %q (expected restart or signal)", onChange)
+		}
+	}
+
+	if leasePolicy != "" && leaseRole == "" {
+		return fmt.Errorf("--lease-policy requires --lease-role")
+	}
+	if err := checkBundleFlags(); err != nil {
+		return err
+	}
+
 	// Build environment variables
-	envVars, err := buildEnvironment(ctx)
+	loaded, err := buildEnvironment(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to build environment: %w", err)
 	}
 
 	// Handle dry run
 	if dryRun {
-		return showDryRun(args, envVars)
+		return showDryRun(args, loaded)
+	}
+
+	if leaseRole != "" {
+		if err := leaseCredentials(ctx, loaded); err != nil {
+			return err
+		}
+	}
+
+	if noDisk {
+		if keys := mountedKeys(loaded); len(keys) > 0 {
+			return fmt.Errorf("--no-disk refuses to write %v to files; remove their mount annotations or drop --no-disk", keys)
+		}
+	}
+
+	// Write mounted secrets, removing them however the command exits
+	mounted, err := mountSecrets(loaded.vars, loaded.mounts)
+	if err != nil {
+		return err
+	}
+	defer mounted.cleanup()
+	beforeExit = append(beforeExit, mounted.cleanup)
+
+	if watch {
+		return executeWatched(ctx, args, loaded)
 	}
 
 	// Execute command
-	return executeCommand(args, envVars)
+	return executeCommand(args, loaded.list())
 }
 
-func buildEnvironment(ctx context.Context) ([]string, error) {
+func buildEnvironment(ctx context.Context) (*loadedEnvironment, error) {
 	// Create environment manager
 	envManager := env.NewManager(".")
 
 	// Start with current environment if inherit is true
 	envMap := make(map[string]string)
+	loaded := &loadedEnvironment{vars: envMap, mounts: make(map[string]env.Mount), isSensitive: sensitive.IsSensitive}
 	if inherit {
 		for _, e := range os.Environ() {
 			parts := strings.SplitN(e, "=", 2)
@@ -122,15 +299,23 @@ func buildEnvironment(ctx context.Context) ([]string, error) {
 		if from == "aws" {
 			return nil, fmt.Errorf("configuration required for AWS mode: %w", err)
 		}
+	} else {
+		if loaded.isSensitive, err = sensitive.FromConfig(cfg); err != nil {
+			return nil, err
+		}
 	}
 
 	// Load environment variables based on source
-	if from == "aws" && cfg != nil {
-		if err := loadFromAWS(ctx, cfg, envMap); err != nil {
+	if bundlePath != "" {
+		if err := loadFromBundle(cfg, loaded); err != nil {
+			return nil, err
+		}
+	} else if from == "aws" && cfg != nil {
+		if err := loadFromAWS(ctx, cfg, loaded); err != nil {
 			return nil, err
 		}
 	} else {
-		if err := loadFromLocal(cfg, envManager, envMap); err != nil {
+		if err := loadFromLocal(ctx, cfg, envManager, loaded); err != nil {
 			return nil, err
 		}
 	}
@@ -140,11 +325,15 @@ func buildEnvironment(ctx context.Context) ([]string, error) {
 		if verbose {
 			fmt.Printf("Loading additional file: %s\n", file)
 		}
-		envFile, err := env.ParseFile(file)
+		loaded.files = append(loaded.files, file)
+		envFile, err := parseEnvFile(ctx, cfg, file)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load file %s: %w", file, err)
 		}
-		applyEnvFile(envFile, envMap)
+		if err := loaded.addMounts(envFile); err != nil {
+			return nil, err
+		}
+		applyEnvFile(forProcess(envFile, consumerTags(envFile)), envMap)
 	}
 
 	// Apply command-line set variables
@@ -166,51 +355,168 @@ func buildEnvironment(ctx context.Context) ([]string, error) {
 		}
 	}
 
-	// Convert map to slice
-	var envVars []string
-	for k, v := range envMap {
-		envVars = append(envVars, fmt.Sprintf("%s=%s", k, v))
-	}
-
 	if verbose {
-		fmt.Printf("Total environment variables: %d\n", len(envVars))
+		fmt.Printf("Total environment variables: %d\n", len(envMap))
 	}
 
-	return envVars, nil
+	return loaded, nil
 }
 
-func loadFromAWS(ctx context.Context, cfg *config.Config, envMap map[string]string) error {
-	// Create AWS manager
-	awsManager, err := aws.NewManager(cfg)
-	if err != nil {
-		return fmt.Errorf("failed to create AWS manager: %w", err)
-	}
-
+func loadFromAWS(ctx context.Context, cfg *config.Config, loaded *loadedEnvironment) error {
 	// Use environment from flag or default
 	envName := environment
 	if envName == "" {
 		envName = cfg.DefaultEnvironment
 	}
 
-	if verbose {
-		fmt.Printf("Loading environment '%s' from AWS...\n", envName)
+	// The system agent serves the environment from memory with its own
+	// credentials. --watch needs an AWS manager to re-fetch variables.
+	var envFile *env.File
+	fromAgent := false
+	if !watch {
+		envFile, fromAgent = pullFromSystemAgent(ctx, cfg, envName)
+	}
+
+	var awsManager *aws.Manager
+	if !fromAgent {
+		var err error
+		if awsManager, err = aws.NewManager(cfg); err != nil {
+			return fmt.Errorf("failed to create AWS manager: %w", err)
+		}
+
+		if verbose {
+			fmt.Printf("Loading environment '%s' from AWS...\n", envName)
+		}
+
+		// Pull environment from AWS, reusing the cache while the remote
+		// version is unchanged unless nothing may be written to disk
+		if noDisk {
+			envFile, err = pullInMemory(ctx, awsManager, envName)
+		} else {
+			envFile, err = pullWithVersionCache(ctx, awsManager, envName)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to pull from AWS: %w", err)
+		}
 	}
 
-	// Pull environment from AWS
-	envFile, err := awsManager.PullEnvironment(ctx, envName)
+	// Annotations live in the local files. Keep only the variables tagged
+	// for --process, so --watch does not inject the others either.
+	envConfig, err := cfg.GetEnvironment(envName)
 	if err != nil {
-		return fmt.Errorf("failed to pull from AWS: %w", err)
+		return err
 	}
+	localFiles := parseLocalFiles(envConfig.Files)
+	if err := loaded.addMounts(localFiles...); err != nil {
+		return err
+	}
+	envFile = forProcess(envFile, consumerTags(localFiles...))
 
-	applyEnvFile(envFile, envMap)
+	applyEnvFile(envFile, loaded.vars)
 	if verbose {
 		fmt.Printf("Loaded %d variables from AWS\n", len(envFile.Variables))
 	}
 
+	loaded.config = cfg
+	loaded.manager = awsManager
+	loaded.envName = envName
+	loaded.remote = envFile
+
 	return nil
 }
 
-func loadFromLocal(cfg *config.Config, envManager *env.Manager, envMap map[string]string) error {
+// pullInMemory streams an environment from AWS into memory, bypassing the
+// pull cache, which keeps values on disk
+func pullInMemory(ctx context.Context, awsManager *aws.Manager, envName string) (*env.File, error) {
+	envFile := env.NewFile()
+	err := awsManager.PullEnvironmentWithStreaming(ctx, envName, func(variable *env.Variable) error {
+		envFile.Set(variable.Key, variable.Value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return envFile, nil
+}
+
+// pullWithVersionCache pulls an environment from AWS. When caching is enabled
+// it first reads the remote versions, which only reads metadata. A disk
+// cache then fetches just the changed parameters; a memory cache serves the
+// cached pull for the environment's version fingerprint if there is one.
+func pullWithVersionCache(ctx context.Context, awsManager *aws.Manager, envName string) (*env.File, error) {
+	if !cache.IsEnabled() {
+		return awsManager.PullEnvironment(ctx, envName)
+	}
+
+	// With a disk cache, fetch only the parameters whose version changed,
+	// or serve cached values within the environment's cache_soft_ttl
+	if store := cache.Versioned(); store != nil {
+		result, err := awsManager.PullEnvironmentStale(ctx, envName, store, func() {
+			path := awsManager.GetConfig().GetParameterPath(envName)
+			if err := cache.RefreshInBackground(store, envName, path, viper.GetString("config")); err != nil && verbose {
+				fmt.Printf("Could not start background refresh: %v\n", err)
+			}
+		})
+		if err == nil {
+			if verbose {
+				switch {
+				case result.Stale:
+					fmt.Printf("Using variables cached %s ago, refreshing in the background\n", result.Age.Round(time.Second))
+				case result.Age > 0:
+					fmt.Printf("Using variables cached %s ago\n", result.Age.Round(time.Second))
+				default:
+					fmt.Printf("Fetched %d of %d variables, the rest from cache\n", result.Fetched, len(result.File.Keys()))
+				}
+			}
+			return result.File, nil
+		}
+		if verbose {
+			fmt.Printf("Could not check parameter versions, pulling without cache: %v\n", err)
+		}
+		return awsManager.PullEnvironment(ctx, envName)
+	}
+
+	remoteVersion, err := awsManager.RemoteVersion(ctx, envName)
+	if err != nil {
+		// e.g. missing ssm:DescribeParameters permission; fall back to a full pull
+		if verbose {
+			fmt.Printf("Could not check remote version, pulling without cache: %v\n", err)
+		}
+		return awsManager.PullEnvironment(ctx, envName)
+	}
+
+	fetched := false
+	result, err := cache.CachedOperationWithMetadata(
+		awsManager.PullCacheKey(envName, remoteVersion),
+		aws.VersionedCacheTTL,
+		map[string]interface{}{
+			"type":           "aws_environment",
+			"environment":    envName,
+			"remote_version": remoteVersion,
+			"sensitive":      true,
+		},
+		func() (interface{}, error) {
+			fetched = true
+			return awsManager.PullEnvironment(ctx, envName)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	envFile, ok := result.(*env.File)
+	if !ok {
+		return nil, fmt.Errorf("invalid cached environment file type")
+	}
+
+	if verbose && !fetched {
+		fmt.Printf("Remote version %s unchanged, using cached variables\n", remoteVersion)
+	}
+
+	return envFile, nil
+}
+
+func loadFromLocal(ctx context.Context, cfg *config.Config, envManager *env.Manager, loaded *loadedEnvironment) error {
 	var filesToLoad []string
 
 	// If additional files are specified via --file flag, skip config-based loading
@@ -238,7 +544,8 @@ func loadFromLocal(cfg *config.Config, envManager *env.Manager, envMap map[strin
 			if verbose {
 				fmt.Printf("Loading file: %s\n", file)
 			}
-			envFile, err := env.ParseFile(file)
+			loaded.files = append(loaded.files, file)
+			envFile, err := parseEnvFile(ctx, cfg, file)
 			if err != nil {
 				if os.IsNotExist(err) {
 					if verbose {
@@ -248,7 +555,10 @@ func loadFromLocal(cfg *config.Config, envManager *env.Manager, envMap map[strin
 				}
 				return fmt.Errorf("failed to load file %s: %w", file, err)
 			}
-			applyEnvFile(envFile, envMap)
+			if err := loaded.addMounts(envFile); err != nil {
+				return err
+			}
+			applyEnvFile(forProcess(envFile, consumerTags(envFile)), loaded.vars)
 		}
 	}
 
@@ -267,7 +577,85 @@ func applyEnvFile(envFile *env.File, envMap map[string]string) {
 	}
 }
 
-func showDryRun(args []string, envVars []string) error {
+// consumerTags returns the consumers of each variable tagged in files.
+// Later files override earlier ones.
+func consumerTags(files ...*env.File) map[string][]string {
+	tags := make(map[string][]string)
+	for _, file := range files {
+		for key := range file.Variables {
+			if consumers := file.Consumers(key); consumers != nil {
+				tags[key] = consumers
+			}
+		}
+	}
+	return tags
+}
+
+// addMounts records the mount annotations in files
+func (l *loadedEnvironment) addMounts(files ...*env.File) error {
+	mounts, err := mountTags(files...)
+	if err != nil {
+		return err
+	}
+	for key, mount := range mounts {
+		l.mounts[key] = mount
+	}
+	return nil
+}
+
+// parseEnvFile reads an env file and decrypts its ENC[...] values
+func parseEnvFile(ctx context.Context, cfg *config.Config, filename string) (*env.File, error) {
+	envFile, err := env.ParseFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := inline.Decrypt(ctx, cfg, envFile); err != nil {
+		return nil, err
+	}
+	return envFile, nil
+}
+
+// parseLocalFiles reads the local env files for their annotations, for
+// variables loaded from AWS. Missing files are skipped.
+func parseLocalFiles(filenames []string) []*env.File {
+	var files []*env.File
+	for _, filename := range filenames {
+		file, err := env.ParseFile(filename)
+		if err != nil {
+			if verbose {
+				fmt.Printf("Could not read annotations from %s: %v\n", filename, err)
+			}
+			continue
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// forProcess returns the variables in envFile tagged for --process. Without
+// --process every variable is returned.
+func forProcess(envFile *env.File, tags map[string][]string) *env.File {
+	if process == "" {
+		return envFile
+	}
+	if len(tags) == 0 {
+		color.PrintWarningf("No variables are tagged with consumers; none are injected for process %q", process)
+	}
+
+	filtered := env.NewFile()
+	for _, key := range envFile.Keys() {
+		if env.ConsumedBy(tags[key], process) {
+			value, _ := envFile.Get(key)
+			filtered.Set(key, value)
+		} else if verbose {
+			fmt.Printf("Skipping %s (not tagged for %s)\n", key, process)
+		}
+	}
+	return filtered
+}
+
+func showDryRun(args []string, loaded *loadedEnvironment) error {
+	envVars := loaded.list()
 	fmt.Println("DRY RUN MODE - Command will not be executed")
 	fmt.Println()
 	fmt.Printf("Command: %s\n", strings.Join(args, " "))
@@ -278,9 +666,12 @@ func showDryRun(args []string, envVars []string) error {
 		if len(parts) == 2 {
 			key, value := parts[0], parts[1]
 			// Mask sensitive values in dry run
-			if isSensitive(key) {
+			if loaded.isSensitive(key) {
 				value = maskValue(value)
 			}
+			if mount, ok := loaded.mounts[key]; ok {
+				value = fmt.Sprintf("<mounted at %s, mode %04o>", mount.Path, mount.Mode)
+			}
 			fmt.Printf("  %s=%s\n", key, value)
 		}
 	}
@@ -319,13 +710,21 @@ func executeCommand(args []string, envVars []string) error {
 	}
 
 	// Wait for command to complete
-	err := cmd.Wait()
+	return handleExit(cmd.Wait())
+}
 
-	// Handle exit code
+// beforeExit runs before handleExit exits, since deferred calls are skipped
+var beforeExit []func()
+
+// handleExit exits with the command's status when it failed
+func handleExit(err error) error {
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			// Command exited with non-zero status
 			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+				for _, fn := range beforeExit {
+					fn()
+				}
 				os.Exit(status.ExitStatus())
 			}
 		}
@@ -335,25 +734,6 @@ func executeCommand(args []string, envVars []string) error {
 	return nil
 }
 
-func isSensitive(key string) bool {
-	lowerKey := strings.ToLower(key)
-	sensitivePatterns := []string{
-		"password", "passwd", "pwd",
-		"secret",
-		"key", "api_key", "apikey",
-		"token",
-		"auth",
-		"credential",
-	}
-
-	for _, pattern := range sensitivePatterns {
-		if strings.Contains(lowerKey, pattern) {
-			return true
-		}
-	}
-	return false
-}
-
 func maskValue(value string) string {
 	if len(value) <= 4 {
 		return "****"
@@ -1,9 +1,18 @@
 package run
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/envelope"
+	"github.com/drapon/envy/internal/inline"
+	"github.com/drapon/envy/internal/sensitive"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsSensitive(t *testing.T) {
@@ -33,8 +42,8 @@ func TestIsSensitive(t *testing.T) {
 			want: true,
 		},
 		{
-			name: "credentials",
-			key:  "AWS_CREDENTIALS",
+			name: "credential",
+			key:  "AWS_CREDENTIAL",
 			want: true,
 		},
 		{
@@ -66,7 +75,7 @@ func TestIsSensitive(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := isSensitive(tt.key)
+			got := sensitive.IsSensitive(tt.key)
 			assert.Equal(t, tt.want, got)
 		})
 	}
@@ -139,6 +148,17 @@ func TestRunCommandFlags(t *testing.T) {
 	assert.NotNil(t, cmd.Flags().Lookup("dry-run"))
 	assert.NotNil(t, cmd.Flags().Lookup("verbose"))
 	assert.NotNil(t, cmd.Flags().Lookup("from"))
+	assert.NotNil(t, cmd.Flags().Lookup("watch"))
+	assert.NotNil(t, cmd.Flags().Lookup("on-change"))
+	assert.NotNil(t, cmd.Flags().Lookup("signal"))
+	assert.NotNil(t, cmd.Flags().Lookup("process"))
+	assert.NotNil(t, cmd.Flags().Lookup("bundle"))
+	assert.NotNil(t, cmd.Flags().Lookup("public-key"))
+	assert.NotNil(t, cmd.Flags().Lookup("max-age"))
+	assert.NotNil(t, cmd.Flags().Lookup("no-disk"))
+
+	assert.Equal(t, "restart", cmd.Flags().Lookup("on-change").DefValue)
+	assert.Equal(t, "HUP", cmd.Flags().Lookup("signal").DefValue)
 
 	// Check flag shortcuts
 	envFlag := cmd.Flags().Lookup("env")
@@ -160,6 +180,14 @@ func TestRunCommandFlags(t *testing.T) {
 	assert.Equal(t, "v", verboseFlag.Shorthand)
 }
 
+func TestSourceAlias(t *testing.T) {
+	cmd := GetRunCmd()
+	t.Cleanup(func() { from = "local" })
+
+	require.NoError(t, cmd.Flags().Set("source", "aws"))
+	assert.Equal(t, "aws", from)
+}
+
 func TestRunCommandUsage(t *testing.T) {
 	cmd := GetRunCmd()
 
@@ -354,4 +382,56 @@ func TestTimeout(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestForProcess(t *testing.T) {
+	dir := t.TempDir()
+	tagged := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(tagged, []byte(`LOG_LEVEL=info # envy: consumers=*
+# envy: consumers=web,worker
+DATABASE_URL=postgresql://localhost/app
+STRIPE_KEY=sk_test_123 # envy: consumers=web
+UNTAGGED=1
+`), 0644))
+
+	file, err := env.ParseFile(tagged)
+	require.NoError(t, err)
+
+	defer func() { process = "" }()
+
+	process = ""
+	assert.Same(t, file, forProcess(file, consumerTags(file)))
+
+	process = "worker"
+	filtered := forProcess(file, consumerTags(file))
+	assert.Equal(t, []string{"LOG_LEVEL", "DATABASE_URL"}, filtered.Keys())
+
+	process = "web"
+	filtered = forProcess(file, consumerTags(file))
+	assert.Equal(t, []string{"LOG_LEVEL", "DATABASE_URL", "STRIPE_KEY"}, filtered.Keys())
+
+	// Variables from AWS are filtered with the tags in the local files
+	remote := env.NewFile()
+	remote.Set("STRIPE_KEY", "sk_live_456")
+	remote.Set("DATABASE_URL", "postgresql://prod/app")
+	process = "worker"
+	filtered = forProcess(remote, consumerTags(parseLocalFiles([]string{tagged, filepath.Join(dir, "missing")})...))
+	assert.Equal(t, []string{"DATABASE_URL"}, filtered.Keys())
+}
+
+func TestParseEnvFileDecrypts(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv(inline.KeyEnv, strings.Repeat("07", envelope.KeySize))
+
+	value, err := inline.FromConfig(context.Background(), nil).Encrypt("s3cret")
+	require.NoError(t, err)
+	filename := filepath.Join(tempDir, ".env")
+	require.NoError(t, os.WriteFile(filename, []byte("PORT=8080\nAPI_KEY="+value+"\n"), 0600))
+
+	envFile, err := parseEnvFile(context.Background(), nil, filename)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"PORT": "8080", "API_KEY": "s3cret"}, envFile.ToMap())
+
+	t.Setenv(inline.KeyEnv, "")
+	_, err = parseEnvFile(context.Background(), nil, filename)
+	assert.ErrorContains(t, err, "failed to decrypt API_KEY")
+}
@@ -0,0 +1,258 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/filewatch"
+	"github.com/drapon/envy/internal/refresh"
+)
+
+// stopTimeout is how long a restarted command may take to exit before it
+// is killed
+const stopTimeout = 10 * time.Second
+
+// watchEvent asks the watch loop to update the command's environment
+type watchEvent struct {
+	// changed holds the new values of refreshed variables. When nil the
+	// whole environment is rebuilt.
+	changed map[string]string
+	reason  string
+}
+
+// executeWatched runs the command and restarts or signals it when its
+// variables change: when the env files they came from are edited, when
+// variables with a refresh interval change in AWS, or when a --poll re-pull
+// differs
+func executeWatched(ctx context.Context, args []string, loaded *loadedEnvironment) error {
+	var reloadSignal os.Signal
+	if onChange == "signal" {
+		var err error
+		if reloadSignal, err = refresh.ParseSignal(signalName); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan watchEvent)
+	send := func(event watchEvent) {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	}
+
+	sources := 0
+	if loaded.manager != nil {
+		started, err := watchRefreshed(ctx, loaded, send)
+		if err != nil {
+			return err
+		}
+		if started {
+			sources++
+		}
+	}
+	if len(loaded.files) > 0 {
+		if verbose {
+			for _, file := range loaded.files {
+				fmt.Printf("Watching %s\n", file)
+			}
+		}
+		watcher := filewatch.New(loaded.files, filewatch.DefaultInterval)
+		go watcher.Run(ctx, func(changed []string) {
+			send(watchEvent{reason: fmt.Sprintf("%v changed", changed)})
+		})
+		sources++
+	}
+	if poll > 0 && loaded.manager != nil {
+		go func() {
+			ticker := time.NewTicker(poll)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					send(watchEvent{reason: "re-pull"})
+				}
+			}
+		}()
+		sources++
+	}
+	if sources == 0 {
+		color.PrintWarningf("Nothing to watch: no env files and no variables with a refresh interval in %s; running without --watch", loaded.envName)
+		return executeCommand(args, loaded.list())
+	}
+
+	// Forward termination to the command
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	cmd, exited, err := startCommand(args, loaded.list())
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case sig := <-sigChan:
+			cmd.Process.Signal(sig)
+
+		case err := <-exited:
+			return handleExit(err)
+
+		case event := <-events:
+			changed := event.changed
+			if changed == nil {
+				rebuilt, err := buildEnvironment(ctx)
+				if err != nil {
+					color.PrintWarningf("envy: %s, but reloading failed: %v", event.reason, err)
+					continue
+				}
+				if loaded.lease != nil {
+					applyLease(rebuilt.vars, loaded.lease)
+				}
+				changed = rebuilt.vars
+			}
+			keys := applyChanges(loaded, changed, event.changed == nil)
+			if len(keys) == 0 {
+				continue
+			}
+
+			if onChange == "signal" {
+				color.PrintInfof("envy: %v changed, sending %v", keys, reloadSignal)
+				if err := cmd.Process.Signal(reloadSignal); err != nil {
+					color.PrintWarningf("envy: failed to signal command: %v", err)
+				}
+				continue
+			}
+
+			color.PrintInfof("envy: %v changed, restarting command", keys)
+			stopCommand(cmd, exited)
+			if cmd, exited, err = startCommand(args, loaded.list()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// watchRefreshed starts re-fetching the variables that have a refresh
+// interval. It returns false when there are none.
+func watchRefreshed(ctx context.Context, loaded *loadedEnvironment, send func(watchEvent)) (bool, error) {
+	rules, err := refresh.ParseRules(loaded.config.Variables)
+	if err != nil {
+		return false, err
+	}
+	intervals := refresh.Intervals(rules, watchableKeys(loaded))
+	if len(intervals) == 0 {
+		return false, nil
+	}
+
+	if verbose {
+		keys := make([]string, 0, len(intervals))
+		for key := range intervals {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("Watching %s (every %s)\n", key, intervals[key])
+		}
+	}
+
+	refresher := refresh.NewRefresher(loaded.manager, loaded.envName, intervals, loaded.vars)
+	refresher.OnError = func(err error) {
+		color.PrintWarningf("envy: %v", err)
+	}
+	go refresher.Run(ctx, func(changed map[string]string) {
+		send(watchEvent{changed: changed, reason: "refresh"})
+	})
+	return true, nil
+}
+
+// applyChanges updates the command's environment and returns the keys,
+// sorted, whose value changed. With complete, vars is the whole new
+// environment and keys missing from it are removed. Mounted variables keep
+// the path of their file.
+func applyChanges(loaded *loadedEnvironment, vars map[string]string, complete bool) []string {
+	var keys []string
+	for key, value := range vars {
+		if _, mounted := loaded.mounts[key]; mounted {
+			continue
+		}
+		if old, ok := loaded.vars[key]; !ok || old != value {
+			loaded.vars[key] = value
+			keys = append(keys, key)
+		}
+	}
+	if complete {
+		for key := range loaded.vars {
+			if _, mounted := loaded.mounts[key]; mounted {
+				continue
+			}
+			if _, ok := vars[key]; !ok {
+				delete(loaded.vars, key)
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// watchableKeys returns the keys whose value in the command's environment
+// came from AWS, so inherited or --set values are never replaced
+func watchableKeys(loaded *loadedEnvironment) []string {
+	var keys []string
+	for _, key := range loaded.remote.Keys() {
+		remoteValue, _ := loaded.remote.Get(key)
+		if value, ok := loaded.vars[key]; ok && value == remoteValue {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// startCommand starts the command and returns a channel that receives its
+// exit error
+func startCommand(args []string, envVars []string) (*exec.Cmd, <-chan error, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = envVars
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("failed to start command: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	return cmd, exited, nil
+}
+
+// stopCommand asks the command to exit and kills it after stopTimeout
+func stopCommand(cmd *exec.Cmd, exited <-chan error) {
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		// e.g. Windows, which cannot deliver SIGTERM
+		cmd.Process.Kill()
+	}
+
+	select {
+	case <-exited:
+	case <-time.After(stopTimeout):
+		cmd.Process.Kill()
+		<-exited
+	}
+}
@@ -0,0 +1,57 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/env"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchableKeys(t *testing.T) {
+	remote := env.NewFile()
+	remote.Set("DB_TOKEN", "from-aws")
+	remote.Set("API_TOKEN", "from-aws")
+	remote.Set("SET_TOKEN", "from-aws")
+
+	loaded := &loadedEnvironment{
+		vars: map[string]string{
+			"DB_TOKEN":  "from-aws",
+			"API_TOKEN": "inherited", // kept because --override was not given
+			"SET_TOKEN": "from --set",
+			"HOME":      "/root",
+		},
+		remote: remote,
+	}
+
+	assert.Equal(t, []string{"DB_TOKEN"}, watchableKeys(loaded))
+}
+
+func TestLoadedEnvironmentList(t *testing.T) {
+	loaded := &loadedEnvironment{vars: map[string]string{"A": "1", "B": "x=y"}}
+	assert.ElementsMatch(t, []string{"A=1", "B=x=y"}, loaded.list())
+}
+
+func TestApplyChanges(t *testing.T) {
+	loaded := &loadedEnvironment{
+		vars: map[string]string{
+			"PORT":     "8080",
+			"DEBUG":    "true",
+			"TLS_CERT": "/dev/shm/envy-secrets-1/tls.pem",
+		},
+		mounts: map[string]env.Mount{"TLS_CERT": {Path: "tls.pem"}},
+	}
+
+	// A refresh only sets the keys it fetched
+	assert.Empty(t, applyChanges(loaded, map[string]string{"PORT": "8080"}, false))
+	assert.Equal(t, []string{"PORT"}, applyChanges(loaded, map[string]string{"PORT": "9090"}, false))
+	assert.Equal(t, "true", loaded.vars["DEBUG"])
+
+	// A rebuild removes keys that are gone, but leaves mounted files alone
+	keys := applyChanges(loaded, map[string]string{"PORT": "9090", "LOG_LEVEL": "info", "TLS_CERT": "-----BEGIN"}, true)
+	assert.Equal(t, []string{"DEBUG", "LOG_LEVEL"}, keys)
+	assert.Equal(t, map[string]string{
+		"PORT":      "9090",
+		"LOG_LEVEL": "info",
+		"TLS_CERT":  "/dev/shm/envy-secrets-1/tls.pem",
+	}, loaded.vars)
+}
@@ -0,0 +1,148 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/scan"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/drapon/envy/internal/version"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	format   string
+	output   string
+	exclude  []string
+	exitZero bool
+)
+
+// scanCmd represents the scan command
+var scanCmd = &cobra.Command{
+	Use:   "scan [path]",
+	Short: "Scan the repository for hard-coded secrets and unignored .env files",
+	Long: `Scan a source tree, the current directory by default, for secrets that
+should be in AWS instead:
+
+  aws-access-key-id       AWS access key IDs
+  aws-secret-access-key   AWS secret access keys
+  private-key             PEM and OpenSSH private keys
+  sensitive-assignment    values assigned to variables whose names say they
+                          are secret (the sensitivity rules of .envyrc, also
+                          matching names like dbPassword in code)
+  high-entropy-string     random-looking string literals (warnings)
+  env-file-not-ignored    .env files that git does not ignore
+
+In a git work tree, tracked files and untracked files that are not ignored
+are scanned. Placeholders, references such as ${DB_PASSWORD}, and lines
+containing envy:allow are not reported. Matches are shown redacted.
+
+The command fails when anything is found, unless --exit-zero is given.
+The sarif format can be uploaded to GitHub code scanning.`,
+	Example: `  # Scan the current repository
+  envy scan
+
+  # Skip test fixtures and write a SARIF report for code scanning
+  envy scan --exclude testdata --exclude "*.snap" --format sarif -o envy.sarif`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runScan,
+}
+
+// GetScanCmd returns the scan command.
+func GetScanCmd() *cobra.Command {
+	return scanCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(scanCmd)
+
+	scanCmd.Flags().StringVarP(&format, "format", "f", "text", "Output format (text/json/sarif)")
+	scanCmd.Flags().StringVarP(&output, "output", "o", "", "File to write the report to (default: standard output)")
+	scanCmd.Flags().StringSliceVar(&exclude, "exclude", nil, "Glob patterns of paths to skip (repeatable)")
+	scanCmd.Flags().BoolVar(&exitZero, "exit-zero", false, "Succeed even when secrets are found")
+}
+
+func runScan(cmd *cobra.Command, args []string) error {
+	if format != "text" && format != "json" && format != "sarif" {
+		return fmt.Errorf("unsupported format: %s (use text, json or sarif)", format)
+	}
+	dir := "."
+	if len(args) == 1 {
+		dir = args[0]
+	}
+
+	// The sensitivity rules of .envyrc apply when there is one
+	isSensitive := sensitive.IsSensitive
+	if cfg, err := config.Load(viper.GetString("config")); err == nil {
+		if isSensitive, err = sensitive.FromConfig(cfg); err != nil {
+			return err
+		}
+	}
+
+	result, err := scan.Dir(dir, scan.Options{IsSensitive: isSensitive, Exclude: exclude})
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", output, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(result)
+	case "sarif":
+		err = scan.WriteSARIF(w, result, version.Version)
+	default:
+		err = writeText(w, result)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(result.Findings) > 0 && !exitZero {
+		return fmt.Errorf("found %d possible secret(s)", len(result.Findings))
+	}
+	return nil
+}
+
+func writeText(w io.Writer, result *scan.Result) error {
+	if !result.Git {
+		color.PrintWarningf("Not a git work tree: .env files were not checked against .gitignore")
+	}
+	for _, f := range result.Findings {
+		location := f.File
+		if f.Line > 0 {
+			location = fmt.Sprintf("%s:%d:%d", f.File, f.Line, f.Column)
+		}
+		level := color.FormatError(string(f.Severity))
+		if f.Severity == scan.SeverityWarning {
+			level = color.FormatWarning(string(f.Severity))
+		}
+		match := ""
+		if f.Match != "" {
+			match = " (" + f.Match + ")"
+		}
+		if _, err := fmt.Fprintf(w, "%s %s %s: %s%s\n", location, level, f.Rule, f.Message, match); err != nil {
+			return err
+		}
+	}
+	if len(result.Findings) == 0 {
+		color.PrintSuccessf("No secrets found in %d file(s)", result.Files)
+	}
+	return nil
+}
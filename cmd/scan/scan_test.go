@@ -0,0 +1,29 @@
+package scan
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/drapon/envy/internal/scan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteText(t *testing.T) {
+	result := &scan.Result{Git: true, Findings: []scan.Finding{
+		{Rule: scan.RuleAWSAccessKey, Severity: scan.SeverityError, File: "main.go", Line: 3, Column: 9, Message: "AWS access key ID", Match: "AKIA****"},
+		{Rule: scan.RuleEnvFile, Severity: scan.SeverityError, File: ".env", Message: ".env is not ignored by git"},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeText(&buf, result))
+	assert.Contains(t, buf.String(), "main.go:3:9")
+	assert.Contains(t, buf.String(), "aws-access-key-id: AWS access key ID (AKIA****)")
+	assert.Contains(t, buf.String(), ".env ")
+}
+
+func TestRunScan_Format(t *testing.T) {
+	defer func() { format = "text" }()
+	format = "xml"
+	assert.ErrorContains(t, runScan(scanCmd, nil), "unsupported format")
+}
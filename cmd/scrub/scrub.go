@@ -0,0 +1,154 @@
+package scrub
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/drapon/envy/internal/validator"
+)
+
+var (
+	output      string
+	environment string
+	rulesFile   string
+	redact      bool
+	all         bool
+	seed        string
+)
+
+// scrubCmd represents the scrub command
+var scrubCmd = &cobra.Command{
+	Use:   "scrub <file>",
+	Short: "Make a copy of an env file that is safe to share",
+	Long: `Make a copy of an env file with its sensitive values replaced, to share in
+bug reports or with vendors without leaking secrets.
+
+Variable order, comments and non-sensitive values are kept. Sensitive values
+are replaced by fakes that follow the validation rules in .envy-rules.yaml
+(type, pattern, enum, min and max), or, without a rule, by random characters
+of the same kind, keeping the length and punctuation of the original.
+
+With --redact, letters and digits are masked with '*' instead, so the value's
+length and shape remain visible but nothing realistic is put in its place.`,
+	Example: `  # Share production config with a vendor
+  envy scrub .env.prod -o .env.prod.shareable
+
+  # Mask values instead of faking them, following production's rules
+  envy scrub .env.prod --env prod --redact
+
+  # Scrub every value, not only sensitive ones
+  envy scrub .env.prod --all -o .env.prod.shareable`,
+	Args: cobra.ExactArgs(1),
+	RunE: runScrub,
+}
+
+// GetScrubCmd returns the scrub command.
+func GetScrubCmd() *cobra.Command {
+	return scrubCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(scrubCmd)
+
+	scrubCmd.Flags().StringVarP(&output, "output", "o", "", "Output file (stdout if not specified)")
+	scrubCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment whose validation rules the fakes follow")
+	scrubCmd.Flags().StringVarP(&rulesFile, "rules", "r", ".envy-rules.yaml", "Validation rules file the fakes follow")
+	scrubCmd.Flags().BoolVar(&redact, "redact", false, "Mask values with '*' instead of replacing them with fakes")
+	scrubCmd.Flags().BoolVar(&all, "all", false, "Scrub every value, not only sensitive ones")
+	scrubCmd.Flags().StringVar(&seed, "seed", "", "Seed for the fakes (same seed, same fakes)")
+}
+
+func runScrub(cmd *cobra.Command, args []string) error {
+	input := args[0]
+	if output != "" && filepath.Clean(output) == filepath.Clean(input) {
+		return fmt.Errorf("output would overwrite %s; choose another file", input)
+	}
+
+	// Load configuration
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	envFile, err := env.ParseFile(input)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", input, err)
+	}
+
+	isSensitive := func(string) bool { return true }
+	if !all {
+		if isSensitive, err = sensitive.FromConfig(cfg); err != nil {
+			return err
+		}
+	}
+
+	replace := func(key, value string) (string, error) { return mask(value), nil }
+	if !redact {
+		rules, err := validator.LoadRulesForEnvironment(rulesFile, environment)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to load validation rules: %w", err)
+		}
+		replace = validator.NewFaker(rules, seed).Fake
+	}
+
+	scrubbed, err := scrubFile(envFile, isSensitive, replace)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		return envFile.Write(os.Stdout)
+	}
+	if err := envFile.WriteFile(output); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	color.PrintSuccessf("Wrote %s, %d of %d value(s) scrubbed", output, scrubbed, len(envFile.Keys()))
+	return nil
+}
+
+// scrubFile replaces the values of the selected variables in place and
+// returns how many were replaced
+func scrubFile(envFile *env.File, selected func(string) bool, replace func(key, value string) (string, error)) (int, error) {
+	scrubbed := 0
+	for _, key := range envFile.Keys() {
+		if !selected(key) {
+			continue
+		}
+		value, _ := envFile.Get(key)
+		if value == "" {
+			continue
+		}
+
+		replacement, err := replace(key, value)
+		if err != nil {
+			return 0, err
+		}
+		envFile.Set(key, replacement)
+		scrubbed++
+	}
+	return scrubbed, nil
+}
+
+// mask replaces letters and digits with '*', keeping punctuation such as
+// the separators of a URL
+func mask(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return '*'
+		}
+		return r
+	}, value)
+}
@@ -0,0 +1,56 @@
+package scrub
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetScrubCmd(t *testing.T) {
+	cmd := GetScrubCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "scrub <file>", cmd.Use)
+	assert.NotNil(t, cmd.RunE)
+
+	for _, name := range []string{"output", "env", "rules", "redact", "all", "seed"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestScrubFile(t *testing.T) {
+	envFile, err := env.Parse(strings.NewReader("# Production\nAPP_NAME=shop\nDATABASE_URL=postgres://app:s3cret@db:5432/shop\nADMIN_EMAIL=ops@shop.io\nEMPTY_SECRET=\n"))
+	require.NoError(t, err)
+
+	rules := &validator.Rules{
+		Variables: map[string]*validator.VariableRule{
+			"ADMIN_EMAIL": {Type: "email"},
+		},
+	}
+	isSensitive := func(key string) bool { return key != "APP_NAME" }
+
+	scrubbed, err := scrubFile(envFile, isSensitive, validator.NewFaker(rules, "").Fake)
+	require.NoError(t, err)
+	assert.Equal(t, 2, scrubbed)
+
+	name, _ := envFile.Get("APP_NAME")
+	assert.Equal(t, "shop", name)
+
+	url, _ := envFile.Get("DATABASE_URL")
+	assert.Regexp(t, `^[a-z]{8}://[a-z]{3}:[a-z][0-9][a-z]{4}@[a-z]{2}:[0-9]{4}/[a-z]{4}$`, url)
+	assert.NotContains(t, url, "s3cret")
+
+	email, _ := envFile.Get("ADMIN_EMAIL")
+	assert.Regexp(t, `^[a-z.]+@example\.com$`, email)
+
+	assert.Equal(t, []string{"APP_NAME", "DATABASE_URL", "ADMIN_EMAIL", "EMPTY_SECRET"}, envFile.Keys())
+	assert.Len(t, envFile.Comments, 1)
+}
+
+func TestMask(t *testing.T) {
+	assert.Equal(t, "********://***:******@**:****/****", mask("postgres://app:s3cret@db:5432/shop"))
+	assert.Equal(t, "", mask(""))
+}
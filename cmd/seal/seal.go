@@ -0,0 +1,193 @@
+package seal
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/envelope"
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/inline"
+	"github.com/drapon/envy/internal/sensitive"
+)
+
+var (
+	environment string
+	check       bool
+	generateKey string
+	filterOpts  filter.Options
+
+	unsealEnvironment string
+)
+
+// sealCmd represents the seal command
+var sealCmd = &cobra.Command{
+	Use:   "seal [file...]",
+	Short: "Encrypt the secret values of env files so they can be committed",
+	Long: `Encrypt the sensitive values of env files in place as ENC[...] values, so
+the files can be committed to git with their plain settings readable and
+their secrets encrypted.
+
+run, export, push, diff, drift, assert and validate decrypt ENC[...] values
+transparently; pull keeps pulled files sealed when they were sealed before. Use envy unseal to edit
+the plaintext.
+
+Values are encrypted with the KMS key set as inline_encryption.kms_key_id in
+.envyrc, else for the age public keys of inline_encryption.age_recipients
+(decrypted with the identities of age_identity_file or ENVY_AGE_IDENTITY),
+or else with a local key file (inline_encryption.key_file, or a hex key in
+ENVY_INLINE_KEY) created with --generate-key. Keep key and identity files
+out of git.
+
+Without files, the files of the environment are sealed. Sensitive keys are
+selected as by list and push (sensitive_patterns and sensitivity in
+.envyrc); --filter, --exclude and --keys select keys explicitly instead.
+
+With --check nothing is changed: the command fails if a selected value is
+still plaintext, for pre-commit hooks and CI.`,
+	Example: `  # Encrypt with KMS (inline_encryption.kms_key_id: alias/app in .envyrc)
+  envy seal --env dev
+
+  # Encrypt with a local key instead
+  envy seal --generate-key ~/.config/envy/inline.key
+  envy seal .env
+
+  # Also encrypt a key not classified as sensitive
+  envy seal .env --keys STRIPE_WEBHOOK_ID
+
+  # Fail a pre-commit hook if a secret is committed in plaintext
+  envy seal --check .env .env.dev`,
+	RunE: runSeal,
+}
+
+// GetSealCmd returns the seal command.
+func GetSealCmd() *cobra.Command {
+	return sealCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(sealCmd)
+
+	sealCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment whose files are sealed (default: default environment)")
+	sealCmd.Flags().BoolVar(&check, "check", false, "Fail if a selected value is not encrypted, without changing files")
+	sealCmd.Flags().StringVar(&generateKey, "generate-key", "", "Create a local key file at this path and exit")
+	filter.AddFlags(sealCmd.Flags(), &filterOpts)
+}
+
+func runSeal(cmd *cobra.Command, args []string) error {
+	if generateKey != "" {
+		if err := envelope.GenerateKeyFile(generateKey); err != nil {
+			return err
+		}
+		color.PrintSuccessf("Created local key %s", generateKey)
+		color.PrintInfof("Set inline_encryption.key_file to it in .envyrc and keep it out of git")
+		return nil
+	}
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	files, err := envFiles(cfg, environment, args)
+	if err != nil {
+		return err
+	}
+
+	selected, err := selector(cfg, filterOpts)
+	if err != nil {
+		return err
+	}
+
+	if check {
+		plaintext := 0
+		for _, filename := range files {
+			envFile, err := env.ParseFile(filename)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", filename, err)
+			}
+			for _, key := range unsealed(envFile, selected) {
+				color.PrintErrorf("%s: %s is not encrypted", filename, key)
+				plaintext++
+			}
+		}
+		if plaintext > 0 {
+			color.PrintInfof("Run envy seal to encrypt them")
+			os.Exit(1)
+		}
+		color.PrintSuccessf("All secret values are encrypted")
+		return nil
+	}
+
+	codec := inline.FromConfig(context.Background(), cfg)
+	for _, filename := range files {
+		envFile, err := env.ParseFile(filename)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", filename, err)
+		}
+		sealed, err := codec.SealFile(envFile, selected)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+		if len(sealed) == 0 {
+			color.PrintInfof("%s: nothing to encrypt", filename)
+			continue
+		}
+		if err := envFile.WriteFile(filename); err != nil {
+			return fmt.Errorf("failed to write %s: %w", filename, err)
+		}
+		color.PrintSuccessf("%s: encrypted %d value(s)", filename, len(sealed))
+	}
+	return nil
+}
+
+// envFiles returns the files named on the command line, else those of the
+// environment
+func envFiles(cfg *config.Config, environment string, args []string) ([]string, error) {
+	if len(args) > 0 {
+		return args, nil
+	}
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+	envConfig, err := cfg.GetEnvironment(environment)
+	if err != nil {
+		return nil, err
+	}
+	if len(envConfig.Files) == 0 {
+		return nil, fmt.Errorf("no files configured for environment %s", environment)
+	}
+	return envConfig.Files, nil
+}
+
+// unsealed returns the selected keys of envFile with plaintext values
+func unsealed(envFile *env.File, selected func(string) bool) []string {
+	var keys []string
+	for _, key := range envFile.Keys() {
+		value, _ := envFile.Get(key)
+		if value != "" && !inline.IsEncrypted(value) && selected(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// selector returns the keys to encrypt: those the filter flags select,
+// else the sensitive ones
+func selector(cfg *config.Config, opts filter.Options) (func(string) bool, error) {
+	keyFilter, err := filter.New(opts)
+	if err != nil {
+		return nil, err
+	}
+	if keyFilter.Active() {
+		return keyFilter.Match, nil
+	}
+	return sensitive.FromConfig(cfg)
+}
@@ -0,0 +1,93 @@
+package seal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/inline"
+	"github.com/drapon/envy/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSealCmds(t *testing.T) {
+	cmd := GetSealCmd()
+	assert.Equal(t, "seal [file...]", cmd.Use)
+	for _, name := range []string{"env", "check", "generate-key", "filter", "exclude", "keys"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+
+	cmd = GetUnsealCmd()
+	assert.Equal(t, "unseal [file...]", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("env"))
+}
+
+func TestEnvFiles(t *testing.T) {
+	cfg := &config.Config{
+		DefaultEnvironment: "dev",
+		Environments: map[string]config.Environment{
+			"dev":  {Files: []string{".env", ".env.dev"}},
+			"prod": {},
+		},
+	}
+
+	files, err := envFiles(cfg, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{".env", ".env.dev"}, files)
+
+	files, err = envFiles(cfg, "dev", []string{"other.env"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other.env"}, files)
+
+	_, err = envFiles(cfg, "prod", nil)
+	assert.ErrorContains(t, err, "no files configured for environment prod")
+}
+
+func TestSelectorAndUnsealed(t *testing.T) {
+	envFile, err := env.Parse(strings.NewReader("PORT=8080\nAPI_KEY=abc\nDB_PASSWORD=ENC[v1,file:00,AA,AA]\nEMPTY_TOKEN=\nWEBHOOK_ID=wh_1\n"))
+	require.NoError(t, err)
+
+	selected, err := selector(&config.Config{}, filter.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"API_KEY"}, unsealed(envFile, selected))
+
+	selected, err = selector(&config.Config{}, filter.Options{Keys: []string{"WEBHOOK_ID,DB_PASSWORD"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"WEBHOOK_ID"}, unsealed(envFile, selected))
+}
+
+func TestSealAndUnseal(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	testutil.ChangeDir(t, tempDir)
+	defer func() { generateKey = "" }()
+
+	generateKey = filepath.Join(tempDir, "inline.key")
+	require.NoError(t, runSeal(sealCmd, nil))
+	generateKey = ""
+
+	testutil.WriteFile(t, tempDir, ".envyrc", "project: app\ndefault_environment: dev\ninline_encryption:\n  key_file: inline.key\nenvironments:\n  dev:\n    files: [.env]\n")
+	testutil.WriteFile(t, tempDir, ".env", "# App settings\nPORT=8080\nAPI_KEY=abc123\n")
+
+	require.NoError(t, runSeal(sealCmd, nil))
+	data, err := os.ReadFile(".env")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# App settings\nPORT=8080\nAPI_KEY=ENC[v1,file:")
+	assert.NotContains(t, string(data), "abc123")
+
+	require.NoError(t, runUnseal(unsealCmd, nil))
+	data, err = os.ReadFile(".env")
+	require.NoError(t, err)
+	assert.Equal(t, "# App settings\nPORT=8080\nAPI_KEY=abc123\n", string(data))
+}
+
+func TestSealedFileIsCommittable(t *testing.T) {
+	envFile, err := env.Parse(strings.NewReader("API_KEY=ENC[v1,file:0011223344556677,QUJD+/=,REVG+/=]\n"))
+	require.NoError(t, err)
+	value, _ := envFile.Get("API_KEY")
+	assert.True(t, inline.IsEncrypted(value), "base64 survives the parser unquoted")
+}
@@ -0,0 +1,79 @@
+package seal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/inline"
+)
+
+// unsealCmd represents the unseal command
+var unsealCmd = &cobra.Command{
+	Use:   "unseal [file...]",
+	Short: "Decrypt the ENC[...] values of env files in place for editing",
+	Long: `Decrypt the ENC[...] values written by envy seal in place, so the secrets can
+be edited. Run envy seal again before committing the files.
+
+Without files, the files of the environment are decrypted.`,
+	Example: `  # Edit a secret, then encrypt it again
+  envy unseal .env
+  $EDITOR .env
+  envy seal .env`,
+	RunE: runUnseal,
+}
+
+// GetUnsealCmd returns the unseal command.
+func GetUnsealCmd() *cobra.Command {
+	return unsealCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(unsealCmd)
+
+	unsealCmd.Flags().StringVarP(&unsealEnvironment, "env", "e", "", "Environment whose files are decrypted (default: default environment)")
+}
+
+func runUnseal(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	files, err := envFiles(cfg, unsealEnvironment, args)
+	if err != nil {
+		return err
+	}
+
+	codec := inline.FromConfig(context.Background(), cfg)
+	opened := 0
+	for _, filename := range files {
+		envFile, err := env.ParseFile(filename)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", filename, err)
+		}
+		keys, err := codec.OpenFile(envFile)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+		if len(keys) == 0 {
+			color.PrintInfof("%s: nothing to decrypt", filename)
+			continue
+		}
+		if err := envFile.WriteFile(filename); err != nil {
+			return fmt.Errorf("failed to write %s: %w", filename, err)
+		}
+		color.PrintSuccessf("%s: decrypted %d value(s)", filename, len(keys))
+		opened++
+	}
+	if opened > 0 {
+		color.PrintWarningf("The files now hold plaintext secrets: run envy seal before committing them")
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package set
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/history"
+	"github.com/drapon/envy/internal/precheck"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+var (
+	environment string
+	promptValue bool
+	fromStdin   bool
+	fromFile    string
+	allowEmpty  bool
+)
+
+// setCmd represents the set command
+var setCmd = &cobra.Command{
+	Use:   "set <KEY>",
+	Short: "Write one variable to AWS without putting its value on the command line",
+	Long: `Write a single variable to AWS, reading its value so that it never appears
+in shell history or in the arguments of a process:
+
+  --prompt          ask for the value on the terminal, without echo, twice
+  --stdin           read the value from standard input
+  --from-file PATH  read the value from a file
+
+One trailing newline is removed from values read from standard input or a
+file; other whitespace and inner newlines are kept. The value is never
+printed, and the previous value can be restored with 'envy undo'.`,
+	Example: `  # Type the new password, which is not echoed
+  envy set DB_PASSWORD --env prod --prompt
+
+  # Pipe a value from another tool
+  op read op://prod/db/password | envy set DB_PASSWORD --env prod --stdin
+
+  # Upload a certificate
+  envy set TLS_CERT --env prod --from-file cert.pem`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSet,
+}
+
+// GetSetCmd returns the set command.
+func GetSetCmd() *cobra.Command {
+	return setCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(setCmd)
+
+	setCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to write to (default: default_environment)")
+	setCmd.Flags().BoolVar(&promptValue, "prompt", false, "Ask for the value on the terminal without echo")
+	setCmd.Flags().BoolVar(&fromStdin, "stdin", false, "Read the value from standard input")
+	setCmd.Flags().StringVar(&fromFile, "from-file", "", "Read the value from a file")
+	setCmd.Flags().BoolVar(&allowEmpty, "allow-empty", false, "Accept an empty value")
+	setCmd.MarkFlagsMutuallyExclusive("prompt", "stdin", "from-file")
+	setCmd.MarkFlagsOneRequired("prompt", "stdin", "from-file")
+}
+
+var validKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func runSet(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	key := args[0]
+	if !validKey.MatchString(key) {
+		return fmt.Errorf("%q is not a valid variable name", key)
+	}
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.UsesAzure() {
+		return fmt.Errorf("envy set is only supported with AWS")
+	}
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+	envConfig, err := cfg.GetEnvironment(environment)
+	if err != nil {
+		return err
+	}
+
+	value, err := readValue(key)
+	if err != nil {
+		return err
+	}
+	if value == "" && !allowEmpty {
+		return fmt.Errorf("the value of %s is empty (use --allow-empty to set it anyway)", key)
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+	set := env.NewFile()
+	set.Set(key, value)
+	keys := []string{key}
+	if err := precheck.Placeholders(cfg, environment, envConfig, set, false); err != nil {
+		return err
+	}
+	if err := precheck.Writers(ctx, cfg, awsManager, keys); err != nil {
+		return err
+	}
+	if cfg.ValidateOnPush {
+		if err := precheck.Validate(ctx, environment, set, nil, true); err != nil {
+			return err
+		}
+	}
+
+	pending, err := history.Begin(ctx, cfg, awsManager, environment, "set", keys)
+	if err != nil {
+		color.PrintWarningf("This change will not be recorded for envy undo: %v", err)
+	}
+
+	if err := awsManager.ApplyChanges(ctx, environment, set, nil); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := pending.Commit(set.ToMap(), nil); err != nil {
+		color.PrintWarningf("Failed to record the change for envy undo: %v", err)
+	}
+	color.PrintSuccessf("Set %s in %s", key, environment)
+	return nil
+}
+
+// readValue reads the value of key from the source selected by the flags
+func readValue(key string) (string, error) {
+	switch {
+	case promptValue:
+		return promptSecret(key)
+	case fromStdin:
+		if term.IsTerminal(int(os.Stdin.Fd())) {
+			color.PrintInfof("Reading the value of %s from standard input; end it with Ctrl-D", key)
+		}
+		return readAll(os.Stdin)
+	default:
+		f, err := os.Open(fromFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the value: %w", err)
+		}
+		defer f.Close()
+		return readAll(f)
+	}
+}
+
+// readAll reads a value, removing one trailing newline
+func readAll(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the value: %w", err)
+	}
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	data = bytes.TrimSuffix(data, []byte("\r"))
+	return string(data), nil
+}
+
+// promptSecret asks for the value twice on the terminal, without echo
+func promptSecret(key string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("--prompt needs a terminal; use --stdin or --from-file in scripts")
+	}
+
+	fmt.Fprintf(os.Stderr, "Value for %s: ", key)
+	first, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the value: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Repeat the value: ")
+	second, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the value: %w", err)
+	}
+	if !bytes.Equal(first, second) {
+		return "", fmt.Errorf("the values do not match")
+	}
+	return strings.TrimSuffix(string(first), "\r"), nil
+}
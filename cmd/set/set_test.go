@@ -0,0 +1,52 @@
+package set
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAll(t *testing.T) {
+	tests := map[string]string{
+		"hunter2\n":      "hunter2",
+		"hunter2\r\n":    "hunter2",
+		"hunter2":        "hunter2",
+		"  spaced  \n\n": "  spaced  \n",
+		"line1\nline2\n": "line1\nline2",
+		"":               "",
+	}
+	for input, want := range tests {
+		got, err := readAll(strings.NewReader(input))
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "input %q", input)
+	}
+}
+
+func TestReadValueFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "value.txt")
+	require.NoError(t, os.WriteFile(path, []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"), 0600))
+
+	defer func() { fromFile = "" }()
+	fromFile = path
+	value, err := readValue("TLS_CERT")
+	require.NoError(t, err)
+	assert.Equal(t, "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----", value)
+
+	fromFile = filepath.Join(t.TempDir(), "missing.txt")
+	_, err = readValue("TLS_CERT")
+	assert.Error(t, err)
+}
+
+func TestSetFlags(t *testing.T) {
+	cmd := GetSetCmd()
+	assert.Equal(t, "set <KEY>", cmd.Use)
+	for _, name := range []string{"env", "prompt", "stdin", "from-file", "allow-empty"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+	assert.True(t, validKey.MatchString("DB_PASSWORD"))
+	assert.False(t, validKey.MatchString("DB-PASSWORD"))
+}
@@ -0,0 +1,268 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/aws/client"
+	awserrors "github.com/drapon/envy/internal/aws/errors"
+	"github.com/drapon/envy/internal/aws/secrets_manager"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/share"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+var (
+	environment string
+	ttl         time.Duration
+	output      string
+)
+
+// shareCmd represents the share command
+var shareCmd = &cobra.Command{
+	Use:   "share <KEY>",
+	Short: "Hand one secret to a teammate through a one-time, expiring share",
+	Long: `Share the value of a variable with a teammate without pasting it into chat.
+
+The value is encrypted with a new random key and stored in a Secrets Manager
+secret under envy/share/. envy prints a command with a token holding the
+secret's location and the key; the receiver runs it to read the value once,
+which deletes the secret. The token is useless after the first read or
+after --ttl, so a leaked token or chat log exposes nothing later.
+
+Secrets Manager has no expiry of its own: expired shares that were never
+received stay until 'envy share prune' deletes them.`,
+	Example: `  # Share the staging API key for ten minutes
+  envy share API_KEY --env staging --ttl 10m
+
+  # The receiver reads it once, into a file
+  envy share receive eu-west-1.3f9c...  -o api_key.txt
+
+  # Delete the shares that expired unread
+  envy share prune`,
+	Args: cobra.ExactArgs(1),
+	RunE: runShare,
+}
+
+// receiveCmd represents the share receive command
+var receiveCmd = &cobra.Command{
+	Use:   "receive <token>",
+	Short: "Read a shared secret once and delete it",
+	Long: `Read the value of a share and delete it, so it cannot be read again. The
+value is written to standard output, or to a file readable only by you
+with --output.
+
+No .envyrc is needed: the token names the share's region, and the AWS
+profile of an .envyrc in the current directory is used if there is one.`,
+	Example: `  envy share receive eu-west-1.3f9c... | pbcopy`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runReceive,
+}
+
+// pruneCmd represents the share prune command
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete expired shares that were never received",
+	Args:  cobra.NoArgs,
+	RunE:  runPrune,
+}
+
+// GetShareCmd returns the share command.
+func GetShareCmd() *cobra.Command {
+	return shareCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(shareCmd)
+	shareCmd.AddCommand(receiveCmd)
+	shareCmd.AddCommand(pruneCmd)
+
+	shareCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to share the variable from (default: default_environment)")
+	shareCmd.Flags().DurationVar(&ttl, "ttl", 10*time.Minute, "How long the share can be received")
+
+	receiveCmd.Flags().StringVarP(&output, "output", "o", "", "File to write the value to (default: standard output)")
+}
+
+func runShare(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+	key := args[0]
+
+	if ttl <= 0 || ttl > share.MaxTTL {
+		return fmt.Errorf("--ttl must be between 1s and %s", share.MaxTTL)
+	}
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.UsesAzure() {
+		return fmt.Errorf("envy share is only supported with AWS")
+	}
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+	if _, err := cfg.GetEnvironment(environment); err != nil {
+		return err
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+	vars, err := awsManager.ListEnvironmentVariables(ctx, environment)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", environment, err)
+	}
+	value, ok := vars[key]
+	if !ok {
+		return fmt.Errorf("%s is not set in %s", key, environment)
+	}
+
+	meta := share.Metadata{
+		Variable:    key,
+		Environment: environment,
+		ExpiresAt:   time.Now().Add(ttl).UTC().Truncate(time.Second),
+	}
+	if arn, err := awsManager.CallerARN(ctx); err == nil {
+		meta.SharedBy = arn
+	}
+
+	region := awsManager.GetClient().Region()
+	envelope, token, err := share.Seal(region, meta, value)
+	if err != nil {
+		return err
+	}
+	stored, err := envelope.Marshal()
+	if err != nil {
+		return err
+	}
+	tags := map[string]string{share.ExpiresTag: meta.ExpiresAt.Format(time.RFC3339)}
+	description := fmt.Sprintf("envy share of %s from %s", key, environment)
+	if err := awsManager.GetSecretsManager().CreateTaggedSecret(ctx, token.SecretName(), description, stored, tags); err != nil {
+		return err
+	}
+
+	color.PrintSuccessf("Shared %s from %s until %s", key, environment, meta.ExpiresAt.Local().Format("15:04 MST"))
+	fmt.Println("Send this command to the receiver; it works once:")
+	fmt.Printf("\n  envy share receive %s\n\n", token)
+	color.PrintWarningf("Anyone holding the token can read the value until it is received")
+	return nil
+}
+
+func runReceive(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	token, err := share.ParseToken(args[0])
+	if err != nil {
+		return err
+	}
+
+	opts := client.Options{Region: token.Region}
+	if cfg, err := config.Load(viper.GetString("config")); err == nil && !cfg.UsesAzure() {
+		opts.Profile = cfg.AWS.Profile
+	}
+	awsClient, err := client.NewClient(ctx, opts)
+	if err != nil {
+		return err
+	}
+	value, envelope, err := receive(ctx, secrets_manager.NewManager(awsClient), token, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if output != "" {
+		if err := os.WriteFile(output, []byte(value), 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", output, err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s from %s to %s\n", envelope.Metadata.Variable, envelope.Metadata.Environment, output)
+		return nil
+	}
+	fmt.Print(value)
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Println()
+	}
+	return nil
+}
+
+// shareStore is the part of Secrets Manager shares are received from
+type shareStore interface {
+	GetSecretString(ctx context.Context, name string) (string, error)
+	DeleteSecret(ctx context.Context, name string, forceDelete bool) error
+}
+
+// receive reads the share of token and deletes it. An expired share is
+// deleted without being opened, and the value is only returned once the
+// share can no longer be read.
+func receive(ctx context.Context, secrets shareStore, token share.Token, now time.Time) (string, *share.Envelope, error) {
+	stored, err := secrets.GetSecretString(ctx, token.SecretName())
+	if err != nil {
+		if awserrors.IsNotFoundError(err) {
+			return "", nil, fmt.Errorf("share not found: it was already received, revoked or pruned")
+		}
+		return "", nil, err
+	}
+	envelope, err := share.ParseEnvelope(stored)
+	if err != nil {
+		return "", nil, err
+	}
+
+	value, err := envelope.Open(token, now)
+	if errors.Is(err, share.ErrExpired) {
+		if delErr := secrets.DeleteSecret(ctx, token.SecretName(), true); delErr != nil {
+			color.PrintWarningf("Failed to delete the expired share: %v", delErr)
+		}
+		return "", nil, err
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The value is only handed out once the share can no longer be read
+	if err := secrets.DeleteSecret(ctx, token.SecretName(), true); err != nil {
+		return "", nil, fmt.Errorf("refusing to read a share that cannot be deleted: %w", err)
+	}
+	return value, envelope, nil
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if cfg.UsesAzure() {
+		return fmt.Errorf("envy share is only supported with AWS")
+	}
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	secrets := awsManager.GetSecretsManager()
+	list, err := secrets.ListSecrets(ctx, share.Prefix)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	deleted := 0
+	for _, secret := range list {
+		if !share.ExpiredTags(secret.Tags, now) {
+			continue
+		}
+		if err := secrets.DeleteSecret(ctx, secret.Name, true); err != nil {
+			return err
+		}
+		deleted++
+	}
+	color.PrintSuccessf("Deleted %d expired share(s), %d pending", deleted, len(list)-deleted)
+	return nil
+}
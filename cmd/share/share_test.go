@@ -0,0 +1,97 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	awserrors "github.com/drapon/envy/internal/aws/errors"
+	"github.com/drapon/envy/internal/share"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetShareCmd(t *testing.T) {
+	cmd := GetShareCmd()
+	assert.Equal(t, "share <KEY>", cmd.Use)
+	assert.Equal(t, "10m0s", cmd.Flags().Lookup("ttl").DefValue)
+
+	names := make([]string, 0)
+	for _, sub := range cmd.Commands() {
+		names = append(names, sub.Name())
+	}
+	assert.ElementsMatch(t, []string{"receive", "prune"}, names)
+}
+
+// fakeSecrets holds share secrets in memory; deleting fails with deleteErr
+type fakeSecrets struct {
+	secrets   map[string]string
+	deleted   []string
+	deleteErr error
+}
+
+func (f *fakeSecrets) GetSecretString(ctx context.Context, name string) (string, error) {
+	value, ok := f.secrets[name]
+	if !ok {
+		return "", awserrors.ErrSecretNotFound
+	}
+	return value, nil
+}
+
+func (f *fakeSecrets) DeleteSecret(ctx context.Context, name string, forceDelete bool) error {
+	if f.deleteErr != nil {
+		return f.deleteErr
+	}
+	delete(f.secrets, name)
+	f.deleted = append(f.deleted, name)
+	return nil
+}
+
+// newShare stores a share of API_KEY expiring at expires
+func newShare(t *testing.T, expires time.Time) (*fakeSecrets, share.Token) {
+	meta := share.Metadata{Variable: "API_KEY", Environment: "staging", ExpiresAt: expires}
+	envelope, token, err := share.Seal("eu-west-1", meta, "sk_live_123")
+	require.NoError(t, err)
+	stored, err := envelope.Marshal()
+	require.NoError(t, err)
+	return &fakeSecrets{secrets: map[string]string{token.SecretName(): stored}}, token
+}
+
+func TestReceive(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	secrets, token := newShare(t, now.Add(10*time.Minute))
+
+	value, envelope, err := receive(ctx, secrets, token, now)
+	require.NoError(t, err)
+	assert.Equal(t, "sk_live_123", value)
+	assert.Equal(t, "API_KEY", envelope.Metadata.Variable)
+	assert.Equal(t, []string{token.SecretName()}, secrets.deleted)
+
+	_, _, err = receive(ctx, secrets, token, now)
+	assert.ErrorContains(t, err, "share not found")
+}
+
+func TestReceive_Expired(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	secrets, token := newShare(t, now.Add(-time.Minute))
+
+	value, _, err := receive(context.Background(), secrets, token, now)
+	assert.ErrorIs(t, err, share.ErrExpired)
+	assert.Empty(t, value)
+	assert.Equal(t, []string{token.SecretName()}, secrets.deleted, "an expired share is deleted")
+}
+
+func TestReceive_DeleteFails(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	secrets, token := newShare(t, now.Add(10*time.Minute))
+	secrets.deleteErr = errors.New("AccessDeniedException")
+
+	// A share that could be read again is never revealed
+	value, envelope, err := receive(context.Background(), secrets, token, now)
+	assert.ErrorContains(t, err, "refusing to read a share that cannot be deleted")
+	assert.Empty(t, value)
+	assert.Nil(t, envelope)
+	assert.Contains(t, secrets.secrets, token.SecretName())
+}
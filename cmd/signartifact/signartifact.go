@@ -0,0 +1,70 @@
+package signartifact
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/artifact"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var key string
+
+// signArtifactCmd represents the sign-artifact command
+var signArtifactCmd = &cobra.Command{
+	Use:   "sign-artifact <file>",
+	Short: "Sign a configuration artifact with a detached signature",
+	Long: `Sign a file, such as an export or a bundle, and write the signature to
+<file>.sig. The signature covers the file's name, SHA-256 digest and size,
+and when and by whom it was signed; 'envy verify-artifact' checks it.
+
+--key is one of:
+
+  kms:<key>       an asymmetric AWS KMS signing key, by ID, ARN or alias
+  cosign:<ref>    any key reference cosign accepts (requires the cosign CLI;
+                  set COSIGN_PASSWORD for encrypted keys)
+  <path>          an Ed25519 private key, e.g. from 'envy bundle keygen'
+
+'envy export --sign' and 'envy bundle create --sign' sign their output the
+same way.`,
+	Example: `  # Sign with a KMS key
+  envy sign-artifact config.json --key kms:alias/envy-signing
+
+  # Sign with a local Ed25519 key
+  envy sign-artifact prod.envybundle --key keys/signing.pem`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSignArtifact,
+}
+
+// GetSignArtifactCmd returns the sign-artifact command.
+func GetSignArtifactCmd() *cobra.Command {
+	return signArtifactCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(signArtifactCmd)
+
+	signArtifactCmd.Flags().StringVar(&key, "key", "", "Signing key: kms:<key>, cosign:<ref> or an Ed25519 private key file")
+	_ = signArtifactCmd.MarkFlagRequired("key")
+}
+
+func runSignArtifact(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	// KMS keys use the credentials of .envyrc when there is one
+	var cfg *config.Config
+	if loaded, err := config.Load(viper.GetString("config")); err == nil && !loaded.UsesAzure() {
+		cfg = loaded
+	}
+
+	sigPath, err := artifact.SignFile(ctx, args[0], key, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to sign %s: %w", args[0], err)
+	}
+	color.PrintSuccessf("Signed %s, signature written to %s", args[0], sigPath)
+	return nil
+}
@@ -0,0 +1,13 @@
+package signartifact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSignArtifactCmd(t *testing.T) {
+	cmd := GetSignArtifactCmd()
+	assert.Equal(t, "sign-artifact <file>", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("key"))
+}
@@ -0,0 +1,92 @@
+package tour
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandboxEnv(t *testing.T) {
+	env := sandboxEnv("", "http://localhost:4566", "eu-west-1")
+	assert.Equal(t, "http://localhost:4566", env["AWS_ENDPOINT_URL"])
+	assert.Equal(t, "test", env["AWS_ACCESS_KEY_ID"])
+	assert.Equal(t, "eu-west-1", env["AWS_REGION"])
+
+	env = sandboxEnv("sandbox", "http://localhost:4566", "eu-west-1")
+	assert.NotContains(t, env, "AWS_ENDPOINT_URL")
+	assert.NotContains(t, env, "AWS_ACCESS_KEY_ID")
+}
+
+func TestSteps(t *testing.T) {
+	defer func() { profile = "" }()
+
+	profile = "sandbox"
+	all := steps()
+	require.Len(t, all, 4)
+	assert.Equal(t, []string{"init", "--project", tourProject, "--env", tourEnv, "--aws-region", region, "--aws-profile", "sandbox"}, all[0].args)
+	assert.Equal(t, "pull", all[1].args[0])
+	assert.Equal(t, "diff", all[2].args[0])
+	assert.Contains(t, all[3].args, "--dry-run")
+}
+
+func TestCheckLocalStack(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_localstack/health", r.URL.Path)
+	}))
+	assert.NoError(t, checkLocalStack(context.Background(), server.URL+"/"))
+
+	server.Close()
+	assert.ErrorContains(t, checkLocalStack(context.Background(), server.URL), "docker run")
+}
+
+func TestMakeWorkspace(t *testing.T) {
+	workspace, cleanup, err := makeWorkspace("")
+	require.NoError(t, err)
+	assert.DirExists(t, workspace)
+	cleanup()
+	assert.NoDirExists(t, workspace)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".envyrc"), []byte("project: app\n"), 0600))
+	_, _, err = makeWorkspace(dir)
+	assert.ErrorContains(t, err, "already has an .envyrc")
+}
+
+func TestTourRun(t *testing.T) {
+	echo, err := exec.LookPath("echo")
+	if err != nil {
+		t.Skip("echo is not available")
+	}
+	workspace := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, tourFile), []byte("LOG_LEVEL=info\n"), 0600))
+
+	var out strings.Builder
+	tr := &tour{
+		workspace:  workspace,
+		executable: echo,
+		in:         bufio.NewReader(strings.NewReader("\nq\n")),
+		out:        &out,
+		pause:      true,
+	}
+	err = tr.run([]step{
+		{title: "Change a value", prepare: edit, args: []string{"diff", "--env", "dev"}, show: tourFile},
+		{title: "Never reached", args: []string{"push"}},
+	})
+	assert.ErrorIs(t, err, errQuit)
+
+	output := out.String()
+	assert.Contains(t, output, "$ envy diff --env dev")
+	assert.Contains(t, output, "diff --env dev\n")
+	assert.Contains(t, output, "  LOG_LEVEL=debug\n  FEATURE_CHECKOUT=true\n")
+	assert.Contains(t, output, "$ envy push")
+	assert.True(t, strings.HasSuffix(output, "$ envy push\nPress Enter to run it (q to quit) "), "quitting skips the command")
+}
@@ -0,0 +1,218 @@
+package undo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/history"
+	"github.com/drapon/envy/internal/precheck"
+	"github.com/drapon/envy/internal/prompt"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	environment string
+	list        bool
+	dryRun      bool
+	force       bool
+)
+
+// undoCmd represents the undo command
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Revert the last push to an environment",
+	Long: `Revert the last push to an environment using the local history.
+
+Every push and 'envy apply' records the values it changed, before and after,
+in .envy/history, encrypted with a key kept in the user config directory.
+'envy undo' restores the previous values of the last recorded push, and
+deletes the variables it created, independent of AWS version history. The
+undone push is removed from the history, so running 'envy undo' again
+reverts the push before it.
+
+If a variable was changed again since the push, e.g. by someone else, undo
+stops rather than overwrite that change, unless --force is given.
+
+Configure the history in .envyrc:
+
+  history:
+    keep: 50                  # records kept per environment
+    dir: .envy/history
+    key_file: ~/.config/envy/history.key
+    disabled: false`,
+	Example: `  # Revert the last push to prod
+  envy undo --env prod
+
+  # Show what would be reverted
+  envy undo --env prod --dry-run
+
+  # List the recorded pushes
+  envy undo --env prod --list`,
+	RunE: runUndo,
+}
+
+// GetUndoCmd returns the undo command.
+func GetUndoCmd() *cobra.Command {
+	return undoCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(undoCmd)
+
+	undoCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to revert")
+	undoCmd.Flags().BoolVar(&list, "list", false, "List the recorded pushes instead of reverting")
+	undoCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be reverted without making changes")
+	undoCmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation and revert variables changed since the push")
+}
+
+func runUndo(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	// Load configuration
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+	if _, err := cfg.GetEnvironment(environment); err != nil {
+		return err
+	}
+
+	journal, err := history.OpenConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	if journal == nil {
+		return fmt.Errorf("history is disabled in .envyrc")
+	}
+
+	if list {
+		records, err := journal.List(environment)
+		if err != nil {
+			return err
+		}
+		printRecords(environment, records)
+		return nil
+	}
+
+	last, ok, err := journal.Last(environment)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		color.PrintInfof("No recorded pushes to %s", environment)
+		return nil
+	}
+
+	isSensitive, err := sensitive.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	color.PrintBoldf("Reverting %s of %s (%s):", last.Command, formatTime(last), last.ID)
+	printChanges(last, isSensitive)
+
+	// Create AWS manager
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	current, err := awsManager.ListEnvironmentVariables(ctx, environment)
+	if err != nil {
+		return fmt.Errorf("failed to read current values: %w", err)
+	}
+	if drifted := last.Drifted(current); len(drifted) > 0 {
+		if !force {
+			return fmt.Errorf("%d variable(s) changed since the push: %s (use --force to revert them anyway)", len(drifted), strings.Join(drifted, ", "))
+		}
+		color.PrintWarningf("Reverting variables changed since the push: %s", strings.Join(drifted, ", "))
+	}
+
+	restore, deleted := last.Revert()
+	set := env.NewFile()
+	for key, value := range restore {
+		set.Set(key, value)
+	}
+	if err := precheck.Writers(ctx, cfg, awsManager, precheck.Keys(set, deleted)); err != nil {
+		return err
+	}
+
+	if dryRun {
+		color.PrintWarningf("\n[DRY RUN] No changes will be made")
+		return nil
+	}
+
+	if !force && !confirmUndo(len(last.Changes), environment) {
+		color.PrintWarningf("Undo cancelled")
+		return nil
+	}
+	if err := awsManager.ApplyChanges(ctx, environment, set, deleted); err != nil {
+		return fmt.Errorf("failed to revert: %w", err)
+	}
+	if err := journal.Remove(environment, last.ID); err != nil {
+		return err
+	}
+
+	color.PrintSuccessf("Reverted %d variable(s) in %s", len(last.Changes), environment)
+	return nil
+}
+
+// printRecords prints the recorded pushes, newest first
+func printRecords(envName string, records []*history.Record) {
+	if len(records) == 0 {
+		color.PrintInfof("No recorded pushes to %s", envName)
+		return
+	}
+	for _, rec := range records {
+		keys := make([]string, 0, len(rec.Changes))
+		for _, change := range rec.Changes {
+			keys = append(keys, change.Key)
+		}
+		by := ""
+		if rec.By != "" {
+			by = " by " + rec.By
+		}
+		fmt.Printf("%s  %s  %-6s %s%s\n", rec.ID, formatTime(rec), rec.Command, strings.Join(keys, ", "), by)
+	}
+}
+
+// printChanges shows what undoing the record does, hiding sensitive values
+func printChanges(rec *history.Record, isSensitive func(string) bool) {
+	for _, change := range rec.Changes {
+		sensitive := isSensitive(change.Key)
+		switch {
+		case change.Before == nil:
+			fmt.Printf("  %s %s\n", color.FormatError("-"), change.Key)
+		case change.After == nil:
+			fmt.Printf("  %s %s = %s\n", color.FormatSuccess("+"), change.Key, displayValue(*change.Before, sensitive))
+		default:
+			fmt.Printf("  %s %s = %s (was %s)\n", color.FormatWarning("~"), change.Key, displayValue(*change.Before, sensitive), displayValue(*change.After, sensitive))
+		}
+	}
+}
+
+func displayValue(value string, sensitive bool) string {
+	if sensitive {
+		return "***HIDDEN***"
+	}
+	return value
+}
+
+func formatTime(rec *history.Record) string {
+	return rec.Time.Local().Format("2006-01-02 15:04:05")
+}
+
+func confirmUndo(count int, envName string) bool {
+	return prompt.Confirm(color.FormatWarning(fmt.Sprintf("About to revert %d variables in %s.", count, envName)) + " Continue?")
+}
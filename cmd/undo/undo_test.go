@@ -0,0 +1,36 @@
+package undo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drapon/envy/internal/history"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUndoCmd(t *testing.T) {
+	cmd := GetUndoCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "undo", cmd.Use)
+
+	for _, name := range []string{"env", "list", "dry-run", "force"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestDisplayValue(t *testing.T) {
+	assert.Equal(t, "***HIDDEN***", displayValue("s3cr3t", true))
+	assert.Equal(t, "8080", displayValue("8080", false))
+}
+
+func TestPrintRecords(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	rec := history.NewRecord("prod", "push", map[string]string{"PORT": "80"}, map[string]string{"PORT": "8080", "API_SECRET": "x"}, nil, now)
+
+	assert.NotPanics(t, func() {
+		printRecords("prod", nil)
+		printRecords("prod", []*history.Record{rec})
+		printChanges(rec, sensitive.IsSensitive)
+	})
+}
@@ -0,0 +1,91 @@
+package validate
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/report"
+	"github.com/drapon/envy/internal/validator"
+)
+
+// writeReport renders the validation result to --output, or stdout. The
+// file is closed before runValidate exits with a failure status.
+func writeReport(renderer report.Renderer, doc *report.Document) error {
+	if outputFile == "" {
+		return renderer.Render(os.Stdout, doc)
+	}
+
+	file, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := renderer.Render(file, doc); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	color.PrintSuccessf("Report written to %s", outputFile)
+	return nil
+}
+
+// validationDocument describes a validation result for the renderers of
+// the report package. Issues are sections of their own; fixes start
+// collapsed.
+func validationDocument(result *validator.ValidationResult, envName string, failed bool) *report.Document {
+	doc := &report.Document{
+		Title: "Validation report: " + envName,
+		Summary: []string{fmt.Sprintf("%d errors, %d warnings, %d info",
+			len(result.Errors), len(result.Warnings), len(result.Infos))},
+		Status: report.StatusPass,
+	}
+	switch {
+	case failed:
+		doc.Status = report.StatusFail
+	case len(result.Errors) > 0 || len(result.Warnings) > 0:
+		doc.Status = report.StatusWarn
+	}
+
+	issues := []struct {
+		title  string
+		status report.Status
+		issues []validator.ValidationError
+	}{
+		{"Errors", report.StatusFail, result.Errors},
+		{"Warnings", report.StatusWarn, result.Warnings},
+		{"Info", report.StatusInfo, result.Infos},
+	}
+	for _, group := range issues {
+		if len(group.issues) == 0 {
+			continue
+		}
+		section := report.Section{
+			Title:   fmt.Sprintf("%s (%d)", group.title, len(group.issues)),
+			Status:  group.status,
+			Columns: []string{"Variable", "Message", "Details"},
+		}
+		for _, issue := range group.issues {
+			section.Rows = append(section.Rows, []string{issue.Variable, issue.Message, issue.Details})
+		}
+		doc.Sections = append(doc.Sections, section)
+	}
+
+	// Like the text output, fixes --fix applied are not offered again
+	if len(result.Fixes) > 0 && !fix {
+		doc.Sections = append(doc.Sections, fixSection("Available fixes", result.Fixes))
+	}
+	if len(result.AppliedFixes) > 0 {
+		doc.Sections = append(doc.Sections, fixSection("Applied fixes", result.AppliedFixes))
+	}
+	return doc
+}
+
+func fixSection(title string, fixes []validator.Fix) report.Section {
+	section := report.Section{Title: fmt.Sprintf("%s (%d)", title, len(fixes)), Collapsed: true}
+	for _, f := range fixes {
+		section.Items = append(section.Items, f.Variable+": "+f.Description)
+	}
+	return section
+}
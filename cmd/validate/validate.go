@@ -2,14 +2,21 @@ package validate
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 
 	"github.com/drapon/envy/cmd/root"
 	"github.com/drapon/envy/internal/color"
 	"github.com/drapon/envy/internal/config"
 	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/ghactions"
+	"github.com/drapon/envy/internal/inline"
+	"github.com/drapon/envy/internal/report"
+	"github.com/drapon/envy/internal/sensitive"
+	"github.com/drapon/envy/internal/structured"
 	"github.com/drapon/envy/internal/validator"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -20,9 +27,15 @@ var (
 	file        string
 	rules       string
 	strict      bool
+	maxSeverity string
 	format      string
+	outputFile  string
 	fix         bool
 	verbose     bool
+
+	// documentOutput receives the json and yaml documents; everything else
+	// goes to stderr then
+	documentOutput io.Writer = os.Stdout
 )
 
 // validateCmd represents the validate command
@@ -32,7 +45,16 @@ var validateCmd = &cobra.Command{
 	Long: `Validate environment variables against defined rules and schemas.
 
 This command checks that all required environment variables are present,
-properly formatted, and meet any defined validation criteria.`,
+properly formatted, and meet any defined validation criteria.
+
+Stricter rules for one environment can be given in an environments section
+of the rules file, or in a rules file named after it such as
+.envy-rules.prod.yaml. They are applied on top of the shared rules when
+validating that environment with --env.
+
+Each rule can set a severity of error, warning or info, so new rules can
+be introduced as warnings before they are enforced. Validation fails when
+any issue is more severe than --max-severity, which defaults to warning.`,
 	Example: `  # Validate current environment
   envy validate
   
@@ -44,15 +66,27 @@ properly formatted, and meet any defined validation criteria.`,
   
   # Validate with custom rules
   envy validate --rules .envy-rules.yaml
+
+  # Validate production with the rules in the environments.production
+  # section of the rules file and .envy-rules.production.yaml, if present
+  envy validate --env production
   
   # Strict validation (fail on warnings)
   envy validate --strict
+
+  # Fail on any issue, including info, e.g. in CI
+  envy validate --max-severity info
   
-  # Auto-fix issues where possible
+  # Auto-fix issues where possible, filling in defaults and generating
+  # missing values for rules with a generator
   envy validate --fix
   
-  # Output as JSON
-  envy validate --format json`,
+  # Output as JSON, or YAML
+  envy validate --format json
+  envy validate --output yaml
+
+  # HTML report to attach to a change ticket
+  envy validate --env production --format html -o validation.html`,
 	RunE: runValidate,
 }
 
@@ -68,8 +102,10 @@ func init() {
 	validateCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to validate")
 	validateCmd.Flags().StringVarP(&file, "file", "f", "", "Environment file to validate")
 	validateCmd.Flags().StringVarP(&rules, "rules", "r", "", "Custom validation rules file (.envy-rules.yaml)")
-	validateCmd.Flags().BoolVar(&strict, "strict", false, "Treat warnings as errors")
-	validateCmd.Flags().StringVar(&format, "format", "text", "Output format (text/json)")
+	validateCmd.Flags().BoolVar(&strict, "strict", false, "Fail on any issue (same as --max-severity info)")
+	validateCmd.Flags().StringVar(&maxSeverity, "max-severity", string(validator.SeverityWarning), "Highest severity tolerated before failing (error/warning/info)")
+	validateCmd.Flags().StringVar(&format, "format", "text", "Output format (text/json/yaml/html)")
+	validateCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Write the html report to a file instead of stdout (json, yaml or table select the format)")
 	validateCmd.Flags().BoolVar(&fix, "fix", false, "Auto-fix issues where possible")
 	validateCmd.Flags().BoolVar(&verbose, "verbose", false, "Show detailed information about all variables")
 }
@@ -77,6 +113,27 @@ func init() {
 func runValidate(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
+	threshold, err := failureThreshold()
+	if err != nil {
+		return err
+	}
+
+	// --output json|yaml|table applies unless --format is given
+	if format, err = structured.ResolveFormat(cmd); err != nil {
+		return err
+	}
+	if structured.IsFormat(outputFile) {
+		outputFile = ""
+	}
+	if format == "json" || format == "yaml" {
+		var restore func()
+		documentOutput, restore = structured.KeepStdout()
+		defer restore()
+	}
+	if _, isReport := report.Lookup(format); outputFile != "" && !isReport {
+		return fmt.Errorf("--output applies to the html format")
+	}
+
 	// Load configuration
 	cfg, err := config.Load(viper.GetString("config"))
 	if err != nil {
@@ -105,26 +162,10 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		envFiles = envConfig.Files
 	}
 
-	// Load validation rules
-	var validationRules *validator.Rules
-	if rules != "" {
-		// Load custom rules file
-		validationRules, err = validator.LoadRulesFromFile(rules)
-		if err != nil {
-			return fmt.Errorf("failed to load rules file: %w", err)
-		}
-	} else {
-		// Check for default rules file
-		defaultRulesFile := ".envy-rules.yaml"
-		if _, err := os.Stat(defaultRulesFile); err == nil {
-			validationRules, err = validator.LoadRulesFromFile(defaultRulesFile)
-			if err != nil {
-				return fmt.Errorf("failed to load default rules file: %w", err)
-			}
-		} else {
-			// Use built-in default rules
-			validationRules = validator.DefaultRules()
-		}
+	// Load validation rules, including those specific to the environment
+	validationRules, err := loadRules(rules, environment)
+	if err != nil {
+		return err
 	}
 
 	// Load environment variables
@@ -133,16 +174,24 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load environment files: %w", err)
 	}
+	if err := inline.Decrypt(ctx, cfg, envFile); err != nil {
+		return err
+	}
 
-	// Create validator
-	v := validator.New(validationRules)
+	// Create validator, with the sensitivity settings for client-exposed
+	// secrets
+	isSensitive, err := sensitive.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	v := validator.New(validationRules).WithSensitive(isSensitive)
 
 	// Validate
 	result := v.Validate(ctx, envFile.ToMap())
 
 	// Apply fixes if requested
 	if fix && len(result.Fixes) > 0 {
-		fixes := applyFixes(envFile, result.Fixes)
+		fixes := applyFixes(envFile, result.Fixes, validationRules)
 		if len(fixes) > 0 {
 			// Save the fixed file
 			for _, filePath := range envFiles {
@@ -155,32 +204,65 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	}
 
 	// Check if validation failed
-	hasErrors := len(result.Errors) > 0
-	hasWarnings := len(result.Warnings) > 0
-
-	if strict && hasWarnings {
-		hasErrors = true
-	}
+	failed := result.Exceeds(threshold)
 
 	// Output results
-	switch format {
-	case "json":
-		if err := outputJSON(result, envName); err != nil {
+	renderer, isReport := report.Lookup(format)
+	switch {
+	case isReport:
+		if err := writeReport(renderer, validationDocument(result, envName, failed)); err != nil {
+			return err
+		}
+	case format == "json" || format == "yaml":
+		if err := outputDocument(documentOutput, result, envName, failed); err != nil {
 			return err
 		}
 	default:
-		outputText(result, envName)
+		outputText(result, envName, failed)
+		ghactions.Emit(ghactions.ValidationAnnotations(result, envFiles)...)
 	}
 
 	// Exit with error code if validation failed
-	if hasErrors {
+	if failed {
 		os.Exit(1)
 	}
 
 	return nil
 }
 
-func applyFixes(envFile *env.File, fixes []validator.Fix) []validator.Fix {
+// failureThreshold returns the highest severity tolerated, from
+// --max-severity or --strict
+func failureThreshold() (validator.Severity, error) {
+	if strict {
+		return validator.SeverityInfo, nil
+	}
+	threshold, err := validator.ParseSeverity(maxSeverity)
+	if err != nil {
+		return "", fmt.Errorf("invalid --max-severity: %w", err)
+	}
+	return threshold, nil
+}
+
+// loadRules loads the rules file, or .envy-rules.yaml when none is given,
+// with the rules for envName merged in. The built-in rules are used when no
+// default rules file exists.
+func loadRules(filename, envName string) (*validator.Rules, error) {
+	explicit := filename != ""
+	if !explicit {
+		filename = ".envy-rules.yaml"
+	}
+
+	validationRules, err := validator.LoadRulesForEnvironment(filename, envName)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return validator.DefaultRules(), nil
+		}
+		return nil, fmt.Errorf("failed to load rules file: %w", err)
+	}
+	return validationRules, nil
+}
+
+func applyFixes(envFile *env.File, fixes []validator.Fix, rules *validator.Rules) []validator.Fix {
 	applied := []validator.Fix{}
 
 	for _, fix := range fixes {
@@ -201,20 +283,32 @@ func applyFixes(envFile *env.File, fixes []validator.Fix) []validator.Fix {
 		case validator.FixTypeRemoveVariable:
 			delete(envFile.Variables, fix.Variable)
 			applied = append(applied, fix)
+		case validator.FixTypeGenerate:
+			if _, exists := envFile.Variables[fix.Variable]; exists {
+				continue
+			}
+			value, err := validator.GenerateValue(fix.Variable, rules.Variables[fix.Variable])
+			if err != nil {
+				color.PrintWarningf("Could not generate %s: %v", fix.Variable, err)
+				continue
+			}
+			envFile.Set(fix.Variable, value)
+			applied = append(applied, fix)
 		}
 	}
 
 	return applied
 }
 
-func outputText(result *validator.ValidationResult, envName string) {
+func outputText(result *validator.ValidationResult, envName string, failed bool) {
 	color.PrintInfof("Validating environment: %s\n", envName)
 
 	// Summary
 	errorCount := len(result.Errors)
 	warningCount := len(result.Warnings)
+	infoCount := len(result.Infos)
 
-	if errorCount == 0 && warningCount == 0 {
+	if errorCount == 0 && warningCount == 0 && infoCount == 0 {
 		color.PrintSuccessf("✅ All validation checks passed!")
 		return
 	}
@@ -243,6 +337,18 @@ func outputText(result *validator.ValidationResult, envName string) {
 		fmt.Println()
 	}
 
+	// Display infos
+	if infoCount > 0 {
+		color.PrintInfof("ℹ️  Info (%d):", infoCount)
+		for _, info := range result.Infos {
+			fmt.Printf("  - %s\n", color.FormatInfo(info.Message))
+			if info.Details != "" {
+				fmt.Printf("    %s\n", info.Details)
+			}
+		}
+		fmt.Println()
+	}
+
 	// Display available fixes
 	if len(result.Fixes) > 0 && !fix {
 		color.PrintInfof("💡 Available fixes (%d):", len(result.Fixes))
@@ -263,48 +369,38 @@ func outputText(result *validator.ValidationResult, envName string) {
 	}
 
 	// Summary line
-	status := "FAILED"
-	if errorCount == 0 {
-		if warningCount > 0 && strict {
-			status = "FAILED (strict mode)"
-		} else {
-			status = "PASSED with warnings"
-		}
-	}
-
-	if errorCount == 0 && warningCount == 0 {
-		color.PrintSuccessf("Validation %s: %d errors, %d warnings", status, errorCount, warningCount)
-	} else if errorCount > 0 {
-		color.PrintErrorf("Validation %s: %d errors, %d warnings", status, errorCount, warningCount)
-	} else {
-		color.PrintWarningf("Validation %s: %d errors, %d warnings", status, errorCount, warningCount)
+	summary := fmt.Sprintf("%d errors, %d warnings, %d info", errorCount, warningCount, infoCount)
+	switch {
+	case failed:
+		color.PrintErrorf("Validation FAILED: %s", summary)
+	case errorCount > 0 || warningCount > 0:
+		color.PrintWarningf("Validation PASSED with issues below --max-severity: %s", summary)
+	default:
+		color.PrintInfof("Validation PASSED: %s", summary)
 	}
 }
 
-func outputJSON(result *validator.ValidationResult, envName string) error {
+// outputDocument writes the validation result as a JSON or YAML document
+func outputDocument(w io.Writer, result *validator.ValidationResult, envName string, failed bool) error {
 	output := map[string]interface{}{
 		"environment":   envName,
 		"status":        "passed",
 		"errors":        result.Errors,
 		"warnings":      result.Warnings,
+		"infos":         result.Infos,
 		"fixes":         result.Fixes,
 		"applied_fixes": result.AppliedFixes,
 		"summary": map[string]int{
 			"errors":   len(result.Errors),
 			"warnings": len(result.Warnings),
+			"infos":    len(result.Infos),
 			"fixes":    len(result.Fixes),
 		},
 	}
 
-	if len(result.Errors) > 0 || (strict && len(result.Warnings) > 0) {
+	if failed {
 		output["status"] = "failed"
 	}
 
-	jsonBytes, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal JSON output: %w", err)
-	}
-
-	fmt.Println(string(jsonBytes))
-	return nil
+	return structured.Write(w, structured.Format(format), output)
 }
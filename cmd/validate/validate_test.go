@@ -1,10 +1,13 @@
 package validate
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/drapon/envy/internal/report"
 	"github.com/drapon/envy/internal/validator"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestValidateCommand(t *testing.T) {
@@ -19,9 +22,16 @@ func TestOutputText(t *testing.T) {
 	t.Skip("outputText is an internal function")
 }
 
-func TestOutputJSON(t *testing.T) {
-	// Skip this test as outputJSON is an internal function
-	t.Skip("outputJSON is an internal function")
+func TestOutputDocument(t *testing.T) {
+	defer func() { format = "text" }()
+	format = "yaml"
+
+	result := &validator.ValidationResult{}
+	var buf bytes.Buffer
+	require.NoError(t, outputDocument(&buf, result, "prod", true))
+	assert.Contains(t, buf.String(), "environment: prod\n")
+	assert.Contains(t, buf.String(), "status: failed\n")
+	assert.Contains(t, buf.String(), "summary:\n  errors: 0\n")
 }
 
 func TestApplyFixes(t *testing.T) {
@@ -138,3 +148,24 @@ func TestValidateNonExistentFile(t *testing.T) {
 		t.Skip("Skipping integration test in short mode")
 	}
 }
+
+func TestValidationDocument(t *testing.T) {
+	result := &validator.ValidationResult{
+		Errors:   []validator.ValidationError{{Variable: "PORT", Message: "PORT must be an integer", Details: "Expected type: int"}},
+		Warnings: []validator.ValidationError{{Variable: "DEBUG", Message: "DEBUG is not set"}},
+		Fixes:    []validator.Fix{{Variable: "DEBUG", Description: "Set default value"}},
+	}
+
+	doc := validationDocument(result, "prod", true)
+	assert.Equal(t, "Validation report: prod", doc.Title)
+	assert.Equal(t, report.StatusFail, doc.Status)
+	require.Len(t, doc.Sections, 3)
+	assert.Equal(t, "Errors (1)", doc.Sections[0].Title)
+	assert.Equal(t, []string{"PORT", "PORT must be an integer", "Expected type: int"}, doc.Sections[0].Rows[0])
+	assert.Equal(t, "Available fixes (1)", doc.Sections[2].Title)
+	assert.True(t, doc.Sections[2].Collapsed)
+
+	doc = validationDocument(&validator.ValidationResult{}, "dev", false)
+	assert.Equal(t, report.StatusPass, doc.Status)
+	assert.Empty(t, doc.Sections)
+}
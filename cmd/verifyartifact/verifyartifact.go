@@ -0,0 +1,91 @@
+package verifyartifact
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/artifact"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	key          string
+	artifactPath string
+)
+
+// verifyArtifactCmd represents the verify-artifact command
+var verifyArtifactCmd = &cobra.Command{
+	Use:   "verify-artifact <file.sig>",
+	Short: "Verify the signature of a configuration artifact",
+	Long: `Check that an artifact is the one a signature was made for and that the
+signature was made with the expected key. The artifact is the signature's
+path without .sig unless --artifact is given. The command fails unless both
+checks pass, so it can gate a deploy.
+
+--key is the key the artifact must be signed with:
+
+  kms:<key>       verify with AWS KMS (kms:Verify on the key)
+  cosign:<ref>    verify with the cosign CLI
+  <path>          a PEM public key, verified offline: the signing.pub of
+                  'envy bundle keygen', or the public key of the KMS key
+                  ('aws kms get-public-key')`,
+	Example: `  # Verify with KMS
+  envy verify-artifact config.json.sig --key kms:alias/envy-signing
+
+  # Verify offline with the KMS key's public key
+  envy verify-artifact config.json.sig --key envy-signing.pub`,
+	Args: cobra.ExactArgs(1),
+	RunE: runVerifyArtifact,
+}
+
+// GetVerifyArtifactCmd returns the verify-artifact command.
+func GetVerifyArtifactCmd() *cobra.Command {
+	return verifyArtifactCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(verifyArtifactCmd)
+
+	verifyArtifactCmd.Flags().StringVar(&key, "key", "", "Key the artifact must be signed with: kms:<key>, cosign:<ref> or a PEM public key file")
+	verifyArtifactCmd.Flags().StringVar(&artifactPath, "artifact", "", "Artifact to verify (default: the signature's path without .sig)")
+	_ = verifyArtifactCmd.MarkFlagRequired("key")
+}
+
+func runVerifyArtifact(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	sig, err := artifact.ReadSignature(args[0])
+	if err != nil {
+		return err
+	}
+	path := artifactPath
+	if path == "" {
+		path = artifact.ArtifactPath(args[0], sig)
+	}
+
+	// Downstream systems may verify without an .envyrc
+	var cfg *config.Config
+	if loaded, err := config.Load(viper.GetString("config")); err == nil && !loaded.UsesAzure() {
+		cfg = loaded
+	}
+	verifier, err := artifact.ParseVerifier(key, artifact.ConfigClient(ctx, cfg))
+	if err != nil {
+		return err
+	}
+	if err := artifact.Verify(ctx, sig, path, verifier); err != nil {
+		return fmt.Errorf("verification of %s failed: %w", path, err)
+	}
+
+	color.PrintSuccessf("%s is signed by %s (%s)", path, sig.KeyID, sig.Method)
+	fmt.Printf("  Digest:    %s\n", sig.Statement.Digest)
+	fmt.Printf("  Signed at: %s (%s ago)\n", sig.Statement.SignedAt.Format(time.RFC3339), time.Since(sig.Statement.SignedAt).Round(time.Second))
+	if sig.Statement.SignedBy != "" {
+		fmt.Printf("  Signed by: %s\n", sig.Statement.SignedBy)
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package verifyartifact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetVerifyArtifactCmd(t *testing.T) {
+	cmd := GetVerifyArtifactCmd()
+	assert.Equal(t, "verify-artifact <file.sig>", cmd.Use)
+	for _, name := range []string{"key", "artifact"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+}
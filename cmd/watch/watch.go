@@ -0,0 +1,352 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/drapon/envy/cmd/root"
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/aws/events"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+var (
+	environment string
+	output      string
+	interval    time.Duration
+	useEvents   bool
+	setupEvents bool
+	queueName   string
+)
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Keep a local .env file in sync with AWS",
+	Long: `Watch an environment in AWS and rewrite the local .env file when it changes.
+
+By default the remote version is polled at a fixed interval. With --events,
+envy instead subscribes to Parameter Store change events delivered through
+EventBridge and SNS, reacting to remote changes within seconds while making
+only long-poll SQS calls. Create the rule and topic once with --setup-events.
+Each watcher then subscribes a queue of its own, named after the topic with
+a random suffix, so any number of watchers see every change. The queue is
+deleted when the watcher stops; one left behind by a killed watcher keeps
+messages for an hour at most and can be deleted safely.`,
+	Example: `  # Poll the default environment every 30 seconds
+  envy watch
+
+  # Poll a specific environment every 5 seconds
+  envy watch --env production --interval 5s
+
+  # Create the EventBridge rule and SNS topic for an environment
+  envy watch --env production --setup-events
+
+  # React to change events instead of polling
+  envy watch --env production --events`,
+	RunE: runWatch,
+}
+
+// GetWatchCmd returns the watch command.
+func GetWatchCmd() *cobra.Command {
+	return watchCmd
+}
+
+func init() {
+	root.GetRootCmd().AddCommand(watchCmd)
+
+	watchCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to watch")
+	watchCmd.Flags().StringVarP(&output, "output", "o", "", "Output file path (overrides config)")
+	watchCmd.Flags().DurationVarP(&interval, "interval", "i", 30*time.Second, "Polling interval when not using events")
+	watchCmd.Flags().BoolVar(&useEvents, "events", false, "Subscribe to Parameter Store change events via EventBridge and SQS")
+	watchCmd.Flags().BoolVar(&setupEvents, "setup-events", false, "Create the EventBridge rule and SNS topic, then exit")
+	watchCmd.Flags().StringVar(&queueName, "queue", "", "Rule and topic name (default: envy-<project>-<env>-changes)")
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	logger := log.WithContext(zap.String("command", "watch"))
+
+	cfg, err := config.Load(viper.GetString("config"))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if environment == "" {
+		environment = cfg.DefaultEnvironment
+	}
+	if _, err := cfg.GetEnvironment(environment); err != nil {
+		return err
+	}
+
+	awsManager, err := aws.NewManager(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+
+	if (useEvents || setupEvents) && cfg.GetAWSService(environment) == "secrets_manager" {
+		return fmt.Errorf("event-based watch is only supported for Parameter Store environments")
+	}
+
+	name := queueName
+	if name == "" {
+		name = defaultTopicName(cfg.Project, environment)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if setupEvents {
+		return runSetupEvents(ctx, cfg, awsManager, name)
+	}
+
+	outputFile := resolveOutputFile(cfg, environment)
+
+	// Always start from the current remote state
+	if err := syncEnvironment(ctx, awsManager, environment, outputFile); err != nil {
+		return err
+	}
+
+	if useEvents {
+		err = watchEvents(ctx, awsManager, name, outputFile, logger)
+	} else {
+		err = watchPolling(ctx, awsManager, outputFile, logger)
+	}
+
+	if errors.Is(err, context.Canceled) {
+		color.PrintInfof("Stopped watching %s", environment)
+		return nil
+	}
+	return err
+}
+
+func runSetupEvents(ctx context.Context, cfg *config.Config, awsManager *aws.Manager, name string) error {
+	path := cfg.GetParameterPath(environment)
+	color.PrintInfof("Setting up change events for %s...", path)
+
+	client := events.NewClient(awsManager.GetClient())
+	topic, err := client.SetupParameterStoreEvents(ctx, name, path)
+	if err != nil {
+		return fmt.Errorf("failed to set up change events: %w", err)
+	}
+
+	color.PrintSuccessf("✓ EventBridge rule: %s", topic.RuleArn)
+	color.PrintSuccessf("✓ SNS topic: %s", topic.TopicArn)
+	color.PrintInfof("Run 'envy watch --env %s --events' to start watching", environment)
+	return nil
+}
+
+// watchPolling checks the remote version at a fixed interval
+func watchPolling(ctx context.Context, awsManager *aws.Manager, outputFile string, logger *zap.Logger) error {
+	if interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+
+	lastVersion, err := awsManager.RemoteVersion(ctx, environment)
+	if err != nil {
+		return fmt.Errorf("failed to determine remote version: %w", err)
+	}
+
+	color.PrintInfof("Watching %s (polling every %s, Ctrl+C to stop)", environment, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		version, err := awsManager.RemoteVersion(ctx, environment)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.Warn("Failed to check remote version", zap.Error(err))
+			continue
+		}
+
+		if version == lastVersion {
+			continue
+		}
+
+		logger.Debug("Remote version changed",
+			zap.String("from", lastVersion),
+			zap.String("to", version))
+
+		if err := syncEnvironment(ctx, awsManager, environment, outputFile); err != nil {
+			color.PrintWarningf("Sync failed: %v", err)
+			continue
+		}
+		lastVersion = version
+	}
+}
+
+// watchEvents subscribes a queue of its own to the environment's change
+// topic and long-polls it until ctx is cancelled, then removes the queue
+func watchEvents(ctx context.Context, awsManager *aws.Manager, name, outputFile string, logger *zap.Logger) error {
+	client := events.NewClient(awsManager.GetClient())
+
+	principal, err := awsManager.CallerARN(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to determine AWS account: %w", err)
+	}
+	accountID, err := events.AccountID(principal)
+	if err != nil {
+		return err
+	}
+
+	sub, err := client.Subscribe(ctx, name, accountID)
+	if errors.Is(err, events.ErrNotSetUp) {
+		return fmt.Errorf("change events for %s are not set up, run 'envy watch --env %s --setup-events' first", environment, environment)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", name, err)
+	}
+	defer func() {
+		// ctx is cancelled by now, but the queue must still be removed
+		cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		if err := client.Unsubscribe(cleanupCtx, sub); err != nil {
+			logger.Warn("Failed to remove watch queue", zap.String("queue", sub.QueueURL), zap.Error(err))
+		}
+	}()
+
+	color.PrintInfof("Watching %s via %s (Ctrl+C to stop)", environment, name)
+
+	return consumeEvents(ctx, client, sub.QueueURL, func(ctx context.Context) error {
+		return syncEnvironment(ctx, awsManager, environment, outputFile)
+	}, logger)
+}
+
+// changeQueue receives change events; satisfied by *events.Client
+type changeQueue interface {
+	ReceiveMessages(ctx context.Context, queueURL string, waitTime time.Duration) ([]events.Message, error)
+	DeleteMessage(ctx context.Context, queueURL, receiptHandle string) error
+}
+
+// retryDelay is how long consumeEvents waits after a failed receive
+var retryDelay = 5 * time.Second
+
+// consumeEvents syncs once per batch of change events and deletes the
+// batch afterwards. If the sync fails the messages stay on the queue and
+// are redelivered.
+func consumeEvents(ctx context.Context, queue changeQueue, queueURL string, sync func(ctx context.Context) error, logger *zap.Logger) error {
+	for {
+		messages, err := queue.ReceiveMessages(ctx, queueURL, events.MaxWaitTime)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.Warn("Failed to receive change events", zap.Error(err))
+			// Avoid a tight loop on persistent errors
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+
+		if len(messages) == 0 {
+			continue
+		}
+
+		// A single pull covers every change in the batch
+		for _, msg := range messages {
+			change, err := events.ParseParameterChange(msg.Body)
+			if err != nil {
+				logger.Debug("Ignoring unexpected message", zap.String("id", msg.MessageID), zap.Error(err))
+				continue
+			}
+			if !viper.GetBool("quiet") {
+				color.PrintInfof("%s: %s", change.Operation, change.Name)
+			}
+		}
+
+		if err := sync(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// Leave the messages on the queue so they are redelivered
+			color.PrintWarningf("Sync failed: %v", err)
+			continue
+		}
+
+		for _, msg := range messages {
+			if err := queue.DeleteMessage(ctx, queueURL, msg.ReceiptHandle); err != nil {
+				logger.Debug("Failed to delete message", zap.String("id", msg.MessageID), zap.Error(err))
+			}
+		}
+	}
+}
+
+// syncEnvironment pulls an environment and writes it to outputFile
+func syncEnvironment(ctx context.Context, awsManager *aws.Manager, envName, outputFile string) error {
+	envFile, err := awsManager.PullEnvironment(ctx, envName)
+	if err != nil {
+		return fmt.Errorf("pull failed: %w", err)
+	}
+
+	if err := envFile.WriteFile(outputFile); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	if err := os.Chmod(outputFile, 0600); err != nil {
+		color.PrintWarningf("Could not set file permissions: %v", err)
+	}
+
+	if !viper.GetBool("quiet") {
+		color.PrintSuccessf("[%s] Synced %d variables to %s",
+			time.Now().Format("15:04:05"), len(envFile.Keys()), outputFile)
+	}
+	return nil
+}
+
+func resolveOutputFile(cfg *config.Config, envName string) string {
+	if output != "" {
+		return output
+	}
+	if envConfig, err := cfg.GetEnvironment(envName); err == nil && len(envConfig.Files) > 0 {
+		return envConfig.Files[0]
+	}
+	return fmt.Sprintf(".env.%s", envName)
+}
+
+// defaultTopicName builds a topic name valid for SNS and EventBridge, which
+// leaves room for the suffix of each watcher's queue
+func defaultTopicName(project, envName string) string {
+	name := fmt.Sprintf("envy-%s-%s-changes", project, envName)
+	if project == "" {
+		name = fmt.Sprintf("envy-%s-changes", envName)
+	}
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+
+	// EventBridge limits rule names to 64 characters
+	result := b.String()
+	if len(result) > 64 {
+		result = result[:64]
+	}
+	return result
+}
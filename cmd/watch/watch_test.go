@@ -0,0 +1,104 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/drapon/envy/internal/aws/events"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestGetWatchCmd(t *testing.T) {
+	cmd := GetWatchCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "watch", cmd.Use)
+	assert.NotNil(t, cmd.RunE)
+}
+
+func TestWatchCommandFlags(t *testing.T) {
+	cmd := GetWatchCmd()
+
+	assert.NotNil(t, cmd.Flags().Lookup("env"))
+	assert.NotNil(t, cmd.Flags().Lookup("output"))
+	assert.NotNil(t, cmd.Flags().Lookup("interval"))
+	assert.NotNil(t, cmd.Flags().Lookup("events"))
+	assert.NotNil(t, cmd.Flags().Lookup("setup-events"))
+	assert.NotNil(t, cmd.Flags().Lookup("queue"))
+
+	intervalFlag := cmd.Flags().Lookup("interval")
+	assert.Equal(t, (30 * time.Second).String(), intervalFlag.DefValue)
+}
+
+func TestDefaultTopicName(t *testing.T) {
+	assert.Equal(t, "envy-myapp-dev-changes", defaultTopicName("myapp", "dev"))
+	assert.Equal(t, "envy-dev-changes", defaultTopicName("", "dev"))
+	assert.Equal(t, "envy-my-app-prod-changes", defaultTopicName("my.app", "prod"))
+	assert.LessOrEqual(t, len(defaultTopicName(string(make([]byte, 100)), "dev")), 64)
+}
+
+const changeEvent = `{"source":"aws.ssm","detail-type":"Parameter Store Change","detail":{"name":"/myapp/dev/API_KEY","operation":"Update"}}`
+
+// fakeQueue replays batches of messages, then blocks until cancelled
+type fakeQueue struct {
+	batches  [][]events.Message
+	errs     []error
+	deleted  []string
+	receives int
+	cancel   context.CancelFunc
+}
+
+func (q *fakeQueue) ReceiveMessages(ctx context.Context, queueURL string, waitTime time.Duration) ([]events.Message, error) {
+	q.receives++
+	if len(q.errs) > 0 {
+		err := q.errs[0]
+		q.errs = q.errs[1:]
+		return nil, err
+	}
+	if len(q.batches) == 0 {
+		q.cancel()
+		return nil, ctx.Err()
+	}
+	batch := q.batches[0]
+	q.batches = q.batches[1:]
+	return batch, nil
+}
+
+func (q *fakeQueue) DeleteMessage(ctx context.Context, queueURL, receiptHandle string) error {
+	q.deleted = append(q.deleted, receiptHandle)
+	return nil
+}
+
+func TestConsumeEvents(t *testing.T) {
+	retryDelay = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queue := &fakeQueue{
+		errs: []error{errors.New("throttled")},
+		batches: [][]events.Message{
+			{{MessageID: "1", ReceiptHandle: "r1", Body: changeEvent}, {MessageID: "2", ReceiptHandle: "r2", Body: "not an event"}},
+			{{MessageID: "3", ReceiptHandle: "r3", Body: changeEvent}},
+			{},
+			{{MessageID: "4", ReceiptHandle: "r4", Body: changeEvent}},
+		},
+		cancel: cancel,
+	}
+
+	syncs := 0
+	err := consumeEvents(ctx, queue, "https://sqs/queue", func(ctx context.Context) error {
+		syncs++
+		// The second batch fails to sync and must stay on the queue
+		if syncs == 2 {
+			return errors.New("pull failed")
+		}
+		return nil
+	}, zap.NewNop())
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 3, syncs)
+	assert.Equal(t, []string{"r1", "r2", "r4"}, queue.deleted)
+	assert.Equal(t, 6, queue.receives)
+}
@@ -0,0 +1,146 @@
+package agent
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"path/filepath"
+
+	"github.com/drapon/envy/internal/filter"
+)
+
+// Caller identifies the process making a request
+type Caller struct {
+	// HasCredentials is true when PID and UID come from the kernel (Unix
+	// socket peer credentials) rather than being unknown
+	HasCredentials bool
+	PID            int
+	UID            int
+	// Exe is the caller's executable path, if it could be resolved
+	Exe string
+	// Token is the bearer token sent with the request, if any
+	Token string
+}
+
+// Rule grants matching callers access to variables. Every criterion that
+// is set must match the caller.
+type Rule struct {
+	Process      string // executable path or glob
+	UIDs         []int
+	Token        string
+	Environments []string
+	Keys         []string
+
+	keys *filter.Filter
+}
+
+// ACL decides which variables a caller may read
+type ACL struct {
+	rules []*Rule
+	// DefaultUID is allowed everything when no rules are configured
+	DefaultUID int
+}
+
+// NewACL compiles rules. With no rules only callers running as defaultUID
+// are allowed, which matches the protection of a 0600 .env file.
+func NewACL(rules []Rule, defaultUID int) (*ACL, error) {
+	if err := checkIdentifiable(rules, peerCredentialsSupported); err != nil {
+		return nil, err
+	}
+
+	acl := &ACL{DefaultUID: defaultUID}
+	for i := range rules {
+		rule := rules[i]
+		if rule.Process == "" && len(rule.UIDs) == 0 && rule.Token == "" {
+			return nil, fmt.Errorf("acl rule %d: at least one of process, uids or token is required", i+1)
+		}
+		if rule.Process != "" {
+			if _, err := filepath.Match(rule.Process, ""); err != nil {
+				return nil, fmt.Errorf("acl rule %d: invalid process pattern %q: %w", i+1, rule.Process, err)
+			}
+		}
+
+		keys, err := filter.New(filter.Options{Include: rule.Keys})
+		if err != nil {
+			return nil, fmt.Errorf("acl rule %d: %w", i+1, err)
+		}
+		rule.keys = keys
+		acl.rules = append(acl.rules, &rule)
+	}
+	return acl, nil
+}
+
+// checkIdentifiable fails when no caller could ever be allowed: without peer
+// credentials, neither the default rule nor process or UID rules match, so
+// at least one token rule is needed
+func checkIdentifiable(rules []Rule, peerCredentials bool) error {
+	if peerCredentials {
+		return nil
+	}
+	for _, rule := range rules {
+		if rule.Token != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("this platform cannot identify callers by process or uid; configure agent.acl rules with a token")
+}
+
+// Allowed reports whether caller may read key in envName
+func (a *ACL) Allowed(caller Caller, envName, key string) bool {
+	if len(a.rules) == 0 {
+		return caller.HasCredentials && caller.UID == a.DefaultUID
+	}
+
+	for _, rule := range a.rules {
+		if rule.matchesCaller(caller) && rule.allowsEnvironment(envName) && rule.keys.Match(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesCaller(caller Caller) bool {
+	if r.Process != "" {
+		if !caller.HasCredentials || caller.Exe == "" {
+			return false
+		}
+		if ok, _ := filepath.Match(r.Process, caller.Exe); !ok {
+			return false
+		}
+	}
+
+	if len(r.UIDs) > 0 {
+		if !caller.HasCredentials {
+			return false
+		}
+		found := false
+		for _, uid := range r.UIDs {
+			if uid == caller.UID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if r.Token != "" {
+		if subtle.ConstantTimeCompare([]byte(r.Token), []byte(caller.Token)) != 1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *Rule) allowsEnvironment(envName string) bool {
+	if len(r.Environments) == 0 {
+		return true
+	}
+	for _, name := range r.Environments {
+		if name == envName {
+			return true
+		}
+	}
+	return false
+}
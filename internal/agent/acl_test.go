@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestACL_Default(t *testing.T) {
+	acl, err := NewACL(nil, 1000)
+	require.NoError(t, err)
+
+	assert.True(t, acl.Allowed(Caller{HasCredentials: true, UID: 1000}, "prod", "DB_PASSWORD"))
+	assert.False(t, acl.Allowed(Caller{HasCredentials: true, UID: 1001}, "prod", "DB_PASSWORD"))
+	assert.False(t, acl.Allowed(Caller{UID: 1000}, "prod", "DB_PASSWORD"), "unidentified callers are denied")
+}
+
+func TestACL_Rules(t *testing.T) {
+	acl, err := NewACL([]Rule{
+		{Process: "/usr/local/bin/api", Environments: []string{"prod"}, Keys: []string{"DB_*"}},
+		{UIDs: []int{2000}, Keys: []string{"FEATURE_FLAGS"}},
+		{Token: "s3cret", Environments: []string{"dev"}},
+	}, 1000)
+	require.NoError(t, err)
+
+	api := Caller{HasCredentials: true, PID: 10, UID: 1000, Exe: "/usr/local/bin/api"}
+	assert.True(t, acl.Allowed(api, "prod", "DB_PASSWORD"))
+	assert.False(t, acl.Allowed(api, "prod", "STRIPE_KEY"), "key not granted")
+	assert.False(t, acl.Allowed(api, "staging", "DB_PASSWORD"), "environment not granted")
+
+	other := Caller{HasCredentials: true, PID: 11, UID: 1000, Exe: "/usr/bin/python3"}
+	assert.False(t, acl.Allowed(other, "prod", "DB_PASSWORD"), "rules replace the default UID grant")
+
+	worker := Caller{HasCredentials: true, UID: 2000, Exe: "/opt/worker"}
+	assert.True(t, acl.Allowed(worker, "prod", "FEATURE_FLAGS"))
+	assert.False(t, acl.Allowed(worker, "prod", "DB_PASSWORD"))
+
+	assert.True(t, acl.Allowed(Caller{Token: "s3cret"}, "dev", "ANYTHING"))
+	assert.False(t, acl.Allowed(Caller{Token: "wrong"}, "dev", "ANYTHING"))
+	assert.False(t, acl.Allowed(Caller{Token: "s3cret"}, "prod", "ANYTHING"))
+}
+
+func TestACL_ProcessGlob(t *testing.T) {
+	acl, err := NewACL([]Rule{{Process: "/srv/app/bin/*"}}, 0)
+	require.NoError(t, err)
+
+	assert.True(t, acl.Allowed(Caller{HasCredentials: true, Exe: "/srv/app/bin/web"}, "prod", "KEY"))
+	assert.False(t, acl.Allowed(Caller{HasCredentials: true, Exe: "/srv/other/web"}, "prod", "KEY"))
+	assert.False(t, acl.Allowed(Caller{HasCredentials: true}, "prod", "KEY"), "unknown executable")
+}
+
+func TestNewACL_Invalid(t *testing.T) {
+	_, err := NewACL([]Rule{{Keys: []string{"DB_*"}}}, 0)
+	assert.Error(t, err, "rule must identify callers")
+
+	_, err = NewACL([]Rule{{Process: "[", Keys: []string{"A"}}}, 0)
+	assert.Error(t, err)
+
+	_, err = NewACL([]Rule{{UIDs: []int{1}, Keys: []string{"/[/"}}}, 0)
+	assert.Error(t, err)
+}
+
+func TestCheckIdentifiable(t *testing.T) {
+	assert.NoError(t, checkIdentifiable(nil, true))
+	assert.Error(t, checkIdentifiable(nil, false), "default rule needs credentials")
+	assert.Error(t, checkIdentifiable([]Rule{{UIDs: []int{1}, Keys: []string{"A"}}}, false))
+	assert.NoError(t, checkIdentifiable([]Rule{{Token: "secret", Keys: []string{"A"}}}, false))
+}
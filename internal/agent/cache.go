@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/drapon/envy/internal/env"
+)
+
+// Source loads an environment's variables. It is satisfied by *aws.Manager.
+type Source interface {
+	PullEnvironment(ctx context.Context, envName string) (*env.File, error)
+}
+
+// Cache keeps environments in memory and refreshes them after TTL. When a
+// refresh fails the previous values keep being served, so a brief AWS
+// outage does not take applications down.
+type Cache struct {
+	source Source
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	mu      sync.Mutex // serialises loads of one environment
+	file    *env.File
+	fetched time.Time
+}
+
+// NewCache returns a cache over source
+func NewCache(source Source, ttl time.Duration) *Cache {
+	return &Cache{
+		source:  source,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+// Get returns an environment's variables, loading them if they are missing
+// or older than the TTL
+func (c *Cache) Get(ctx context.Context, envName string) (*env.File, error) {
+	entry := c.entry(envName)
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.file != nil && c.now().Sub(entry.fetched) < c.ttl {
+		return entry.file, nil
+	}
+
+	file, err := c.source.PullEnvironment(ctx, envName)
+	if err != nil {
+		if entry.file != nil {
+			return entry.file, nil
+		}
+		return nil, err
+	}
+
+	entry.file = file
+	entry.fetched = c.now()
+	return file, nil
+}
+
+// Update replaces the values of individual keys in a loaded environment,
+// e.g. after a per-key refresh. Environments not loaded yet are ignored.
+func (c *Cache) Update(envName string, values map[string]string) {
+	c.mu.Lock()
+	entry, ok := c.entries[envName]
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.file == nil {
+		return
+	}
+
+	// Copy so readers holding the previous file are unaffected
+	updated := env.NewFile()
+	updated.Merge(entry.file)
+	for key, value := range values {
+		updated.Set(key, value)
+	}
+	entry.file = updated
+}
+
+// Refresh reloads every environment that has been loaded so far, returning
+// the first error encountered
+func (c *Cache) Refresh(ctx context.Context) error {
+	c.mu.Lock()
+	names := make([]string, 0, len(c.entries))
+	for name := range c.entries {
+		names = append(names, name)
+	}
+	c.mu.Unlock()
+
+	var firstErr error
+	for _, name := range names {
+		file, err := c.source.PullEnvironment(ctx, name)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		entry := c.entry(name)
+		entry.mu.Lock()
+		entry.file = file
+		entry.fetched = c.now()
+		entry.mu.Unlock()
+	}
+	return firstErr
+}
+
+// Run refreshes loaded environments every TTL until ctx is cancelled, so
+// requests are served from memory instead of waiting on AWS. onError is
+// called for failed refreshes and may be nil.
+func (c *Cache) Run(ctx context.Context, onError func(error)) {
+	ticker := time.NewTicker(c.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Refresh(ctx); err != nil && onError != nil && ctx.Err() == nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+func (c *Cache) entry(envName string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[envName]
+	if !ok {
+		entry = &cacheEntry{}
+		c.entries[envName] = entry
+	}
+	return entry
+}
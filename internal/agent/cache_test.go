@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/drapon/envy/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSource serves fixed values and counts pulls
+type fakeSource struct {
+	mu     sync.Mutex
+	values map[string]string
+	pulls  int
+	err    error
+}
+
+func (s *fakeSource) PullEnvironment(ctx context.Context, envName string) (*env.File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pulls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	file := env.NewFile()
+	for k, v := range s.values {
+		file.Set(k, v)
+	}
+	return file, nil
+}
+
+func (s *fakeSource) set(key, value string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	s.err = err
+}
+
+func (s *fakeSource) pullCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pulls
+}
+
+func TestCache_Get(t *testing.T) {
+	ctx := context.Background()
+	source := &fakeSource{values: map[string]string{"A": "1"}}
+	cache := NewCache(source, time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	file, err := cache.Get(ctx, "prod")
+	require.NoError(t, err)
+	value, _ := file.Get("A")
+	assert.Equal(t, "1", value)
+
+	// Served from memory within the TTL
+	source.set("A", "2", nil)
+	file, err = cache.Get(ctx, "prod")
+	require.NoError(t, err)
+	value, _ = file.Get("A")
+	assert.Equal(t, "1", value)
+	assert.Equal(t, 1, source.pullCount())
+
+	// Reloaded after the TTL
+	now = now.Add(2 * time.Minute)
+	file, err = cache.Get(ctx, "prod")
+	require.NoError(t, err)
+	value, _ = file.Get("A")
+	assert.Equal(t, "2", value)
+}
+
+func TestCache_ServesStaleOnError(t *testing.T) {
+	ctx := context.Background()
+	source := &fakeSource{values: map[string]string{"A": "1"}}
+	cache := NewCache(source, time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	_, err := cache.Get(ctx, "prod")
+	require.NoError(t, err)
+
+	source.set("A", "2", errors.New("throttled"))
+	now = now.Add(2 * time.Minute)
+	file, err := cache.Get(ctx, "prod")
+	require.NoError(t, err)
+	value, _ := file.Get("A")
+	assert.Equal(t, "1", value)
+
+	assert.Error(t, cache.Refresh(ctx))
+
+	_, err = cache.Get(ctx, "never-loaded")
+	assert.Error(t, err)
+}
+
+func TestCache_Update(t *testing.T) {
+	ctx := context.Background()
+	source := &fakeSource{values: map[string]string{"A": "1", "TOKEN": "old"}}
+	cache := NewCache(source, time.Minute)
+
+	before, err := cache.Get(ctx, "prod")
+	require.NoError(t, err)
+
+	cache.Update("prod", map[string]string{"TOKEN": "new"})
+	cache.Update("staging", map[string]string{"TOKEN": "ignored"})
+
+	after, err := cache.Get(ctx, "prod")
+	require.NoError(t, err)
+	value, _ := after.Get("TOKEN")
+	assert.Equal(t, "new", value)
+	value, _ = after.Get("A")
+	assert.Equal(t, "1", value)
+
+	value, _ = before.Get("TOKEN")
+	assert.Equal(t, "old", value, "previously returned file is not modified")
+
+	require.NoError(t, cache.Refresh(ctx))
+	assert.Equal(t, 2, source.pullCount(), "updating an unloaded environment does not start loading it")
+}
+
+func TestCache_Run(t *testing.T) {
+	source := &fakeSource{values: map[string]string{"A": "1"}}
+	cache := NewCache(source, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := cache.Get(ctx, "prod")
+	require.NoError(t, err)
+
+	go cache.Run(ctx, nil)
+	require.Eventually(t, func() bool { return source.pullCount() >= 3 }, 5*time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when the variable or environment does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrAccessDenied is returned when the ACL does not allow the request
+	ErrAccessDenied = errors.New("access denied")
+)
+
+// Client queries a running agent
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	// Token is sent as a bearer token when set
+	Token string
+}
+
+// NewClient returns a client for the agent at address, which is either a
+// Unix socket path or an http:// URL
+func NewClient(address string) *Client {
+	if strings.HasPrefix(address, "http://") {
+		return &Client{
+			httpClient: &http.Client{Timeout: 30 * time.Second},
+			baseURL:    strings.TrimSuffix(address, "/"),
+		}
+	}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", address)
+		},
+	}
+	return &Client{
+		httpClient: &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		baseURL:    "http://envy-agent",
+	}
+}
+
+// Get returns the value of key in envName
+func (c *Client) Get(ctx context.Context, envName, key string) (string, error) {
+	body, err := c.get(ctx, "/v1/secrets/"+url.PathEscape(envName)+"/"+url.PathEscape(key))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// Keys returns the keys in envName the caller may read
+func (c *Client) Keys(ctx context.Context, envName string) ([]string, error) {
+	body, err := c.get(ctx, "/v1/secrets/"+url.PathEscape(envName))
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Keys []string `json:"keys"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("invalid response from agent: %w", err)
+	}
+	return result.Keys, nil
+}
+
+func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to agent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, nil
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%s: %w", strings.TrimSpace(string(body)), ErrNotFound)
+	case http.StatusForbidden:
+		return nil, ErrAccessDenied
+	default:
+		return nil, fmt.Errorf("agent returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+}
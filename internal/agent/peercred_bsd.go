@@ -0,0 +1,51 @@
+//go:build darwin || freebsd
+
+package agent
+
+import (
+	"fmt"
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// peerCredentialsSupported reports whether callers can be identified by
+// process and UID on this platform
+const peerCredentialsSupported = true
+
+// peerCredentials returns the UID, and where the platform reports it the
+// PID, of the process on the other end of a Unix socket connection. The
+// executable is not resolved, so process rules do not match on these
+// platforms.
+func peerCredentials(conn net.Conn) (Caller, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return Caller{}, fmt.Errorf("peer credentials require a Unix socket")
+	}
+
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return Caller{}, err
+	}
+
+	var cred *unix.Xucred
+	var pid int
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptXucred(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+		if credErr == nil {
+			pid = peerPID(int(fd))
+		}
+	}); err != nil {
+		return Caller{}, err
+	}
+	if credErr != nil {
+		return Caller{}, credErr
+	}
+
+	return Caller{
+		HasCredentials: true,
+		PID:            pid,
+		UID:            int(cred.Uid),
+	}, nil
+}
@@ -0,0 +1,12 @@
+package agent
+
+import "golang.org/x/sys/unix"
+
+// peerPID returns the PID of the socket's peer, or 0 if it is unknown
+func peerPID(fd int) int {
+	pid, err := unix.GetsockoptInt(fd, unix.SOL_LOCAL, unix.LOCAL_PEERPID)
+	if err != nil {
+		return 0
+	}
+	return pid
+}
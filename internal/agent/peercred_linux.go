@@ -0,0 +1,52 @@
+//go:build linux
+
+package agent
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// peerCredentialsSupported reports whether callers can be identified by
+// process and UID on this platform
+const peerCredentialsSupported = true
+
+// peerCredentials returns the PID, UID and executable of the process on
+// the other end of a Unix socket connection
+func peerCredentials(conn net.Conn) (Caller, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return Caller{}, fmt.Errorf("peer credentials require a Unix socket")
+	}
+
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return Caller{}, err
+	}
+
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return Caller{}, err
+	}
+	if credErr != nil {
+		return Caller{}, credErr
+	}
+
+	caller := Caller{
+		HasCredentials: true,
+		PID:            int(cred.Pid),
+		UID:            int(cred.Uid),
+	}
+	// The executable is best effort: it is unreadable for processes of
+	// other users unless the agent runs with sufficient privileges
+	if exe, err := os.Readlink("/proc/" + strconv.Itoa(caller.PID) + "/exe"); err == nil {
+		caller.Exe = exe
+	}
+	return caller, nil
+}
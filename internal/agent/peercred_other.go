@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !freebsd
+
+package agent
+
+import (
+	"fmt"
+	"net"
+)
+
+// peerCredentialsSupported reports whether callers can be identified by
+// process and UID on this platform
+const peerCredentialsSupported = false
+
+// peerCredentials is not implemented on this platform. Callers are
+// unidentified and only token rules can match them.
+func peerCredentials(conn net.Conn) (Caller, error) {
+	return Caller{}, fmt.Errorf("peer credentials are not supported on this platform")
+}
@@ -0,0 +1,219 @@
+// Package agent implements a local read-through proxy that lets
+// applications fetch individual variables at runtime over a Unix socket
+// instead of receiving every secret in their process environment.
+//
+// API (all responses are plain text unless noted):
+//
+//	GET /v1/secrets/{env}/{key}   value of one variable
+//	GET /v1/secrets/{env}         JSON {"keys": [...]} of readable keys
+//	GET /healthz                  "ok"
+//
+// A system agent, shared by every shell of the user, also serves whole
+// environments of any project (see Projects).
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Server answers variable requests from the cache, checking each against
+// the ACL
+type Server struct {
+	Cache *Cache
+	ACL   *ACL
+	// Environments are the environments that may be requested
+	Environments []string
+	// Projects, when set, serves the environments of every project of the
+	// user, identified by the path of their .envyrc
+	Projects *Projects
+	Logger   *zap.Logger
+}
+
+type connContextKey struct{}
+
+// Handler returns the HTTP handler for the agent API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/secrets/{env}/{key}", s.handleGet)
+	mux.HandleFunc("GET /v1/secrets/{env}", s.handleKeys)
+	if s.Projects != nil {
+		mux.HandleFunc("GET "+systemRoute+"{env}", s.handleProject)
+	}
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	return mux
+}
+
+// Serve serves the API on listener until ctx is cancelled
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ConnContext: func(ctx context.Context, conn net.Conn) context.Context {
+			return context.WithValue(ctx, connContextKey{}, conn)
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	envName, key := r.PathValue("env"), r.PathValue("key")
+	caller := callerFromRequest(r)
+
+	if !s.servesEnvironment(envName) {
+		http.Error(w, "environment not found", http.StatusNotFound)
+		return
+	}
+
+	allowed := s.ACL.Allowed(caller, envName, key)
+	s.logger().Info("Variable requested",
+		zap.String("environment", envName),
+		zap.String("key", key),
+		zap.Int("pid", caller.PID),
+		zap.Int("uid", caller.UID),
+		zap.String("exe", caller.Exe),
+		zap.Bool("allowed", allowed))
+	if !allowed {
+		http.Error(w, "access denied", http.StatusForbidden)
+		return
+	}
+
+	file, err := s.Cache.Get(r.Context(), envName)
+	if err != nil {
+		s.logger().Error("Failed to load environment", zap.String("environment", envName), zap.Error(err))
+		http.Error(w, "failed to load environment", http.StatusBadGateway)
+		return
+	}
+
+	value, ok := file.Get(key)
+	if !ok {
+		http.Error(w, "variable not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write([]byte(value))
+}
+
+func (s *Server) handleKeys(w http.ResponseWriter, r *http.Request) {
+	envName := r.PathValue("env")
+	caller := callerFromRequest(r)
+
+	if !s.servesEnvironment(envName) {
+		http.Error(w, "environment not found", http.StatusNotFound)
+		return
+	}
+
+	file, err := s.Cache.Get(r.Context(), envName)
+	if err != nil {
+		s.logger().Error("Failed to load environment", zap.String("environment", envName), zap.Error(err))
+		http.Error(w, "failed to load environment", http.StatusBadGateway)
+		return
+	}
+
+	keys := []string{}
+	for _, key := range file.SortedKeys() {
+		if s.ACL.Allowed(caller, envName, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{"keys": keys})
+}
+
+func (s *Server) servesEnvironment(envName string) bool {
+	for _, name := range s.Environments {
+		if name == envName {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) logger() *zap.Logger {
+	if s.Logger == nil {
+		return zap.NewNop()
+	}
+	return s.Logger
+}
+
+// callerFromRequest identifies the requesting process from the connection
+// and the Authorization header
+func callerFromRequest(r *http.Request) Caller {
+	var caller Caller
+	if conn, ok := r.Context().Value(connContextKey{}).(net.Conn); ok {
+		if c, err := peerCredentials(conn); err == nil {
+			caller = c
+		}
+	}
+
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		caller.Token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return caller
+}
+
+// DefaultSocketPath returns the per-user socket path used when none is
+// configured
+func DefaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "envy", "agent.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("envy-%d", os.Getuid()), "agent.sock")
+}
+
+// ListenUnix creates a Unix socket at path with the given permissions. A
+// stale socket left by a crashed agent is replaced; a live one is an error.
+func ListenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("an agent is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return listener, nil
+}
@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startAgent serves the API on a temporary Unix socket
+func startAgent(t *testing.T, rules []Rule) string {
+	t.Helper()
+
+	source := &fakeSource{values: map[string]string{"DB_PASSWORD": "hunter2", "LOG_LEVEL": "debug"}}
+	acl, err := NewACL(rules, os.Getuid())
+	require.NoError(t, err)
+
+	server := &Server{
+		Cache:        NewCache(source, time.Minute),
+		ACL:          acl,
+		Environments: []string{"prod"},
+	}
+
+	// Keep the path short: Unix socket paths are limited to ~100 bytes
+	dir, err := os.MkdirTemp("", "envy")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "agent.sock")
+
+	listener, err := ListenUnix(socket, 0600)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx, listener) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	return socket
+}
+
+func TestServer_DefaultACL(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("peer credentials are only available on Linux")
+	}
+	ctx := context.Background()
+	client := NewClient(startAgent(t, nil))
+
+	value, err := client.Get(ctx, "prod", "DB_PASSWORD")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+
+	_, err = client.Get(ctx, "prod", "MISSING")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	_, err = client.Get(ctx, "staging", "DB_PASSWORD")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	keys, err := client.Keys(ctx, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DB_PASSWORD", "LOG_LEVEL"}, keys)
+}
+
+func TestServer_TokenRule(t *testing.T) {
+	ctx := context.Background()
+	socket := startAgent(t, []Rule{{Token: "t0ken", Keys: []string{"LOG_*"}}})
+
+	client := NewClient(socket)
+	_, err := client.Get(ctx, "prod", "LOG_LEVEL")
+	assert.True(t, errors.Is(err, ErrAccessDenied), "no token")
+
+	client.Token = "t0ken"
+	value, err := client.Get(ctx, "prod", "LOG_LEVEL")
+	require.NoError(t, err)
+	assert.Equal(t, "debug", value)
+
+	_, err = client.Get(ctx, "prod", "DB_PASSWORD")
+	assert.True(t, errors.Is(err, ErrAccessDenied))
+
+	keys, err := client.Keys(ctx, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"LOG_LEVEL"}, keys)
+}
+
+func TestListenUnix(t *testing.T) {
+	dir, err := os.MkdirTemp("", "envy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "agent.sock")
+
+	// Leave a stale socket behind, as a crashed agent would
+	listener, err := ListenUnix(socket, 0600)
+	require.NoError(t, err)
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+
+	listener, err = ListenUnix(socket, 0600)
+	require.NoError(t, err, "stale socket is replaced")
+	defer listener.Close()
+
+	info, err := os.Stat(socket)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	_, err = ListenUnix(socket, 0600)
+	assert.Error(t, err, "live socket is not replaced")
+
+	plain := filepath.Join(dir, "plain")
+	require.NoError(t, os.WriteFile(plain, nil, 0600))
+	_, err = ListenUnix(plain, 0600)
+	assert.Error(t, err, "regular files are never removed")
+}
+
+func TestDefaultSocketPath(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	assert.Equal(t, "/run/user/1000/envy/agent.sock", DefaultSocketPath())
+
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	assert.Contains(t, DefaultSocketPath(), "agent.sock")
+}
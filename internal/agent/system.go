@@ -0,0 +1,195 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// systemRoute is where the system agent serves whole environments of any
+// project of the user:
+//
+//	GET /v1/projects/environments/{env}?config=PATH&profile=NAME
+//
+// returns JSON {"variables": {...}}
+const systemRoute = "/v1/projects/environments/"
+
+// Opener returns the variable source of the project configured by the
+// .envyrc at configPath, reading AWS with profile in place of the one the
+// file names when it is set, and the names of the project's environments
+type Opener func(configPath, profile string) (Source, []string, error)
+
+// Projects keeps one cache per project, so every shell of the user shares
+// the values and AWS credentials of a single daemon. A project is opened on
+// first use and again when its .envyrc changes.
+type Projects struct {
+	open Opener
+	ttl  time.Duration
+
+	mu       sync.Mutex
+	projects map[string]*project
+}
+
+type project struct {
+	cache        *Cache
+	environments []string
+	modTime      time.Time
+}
+
+// NewProjects returns an empty set of projects whose caches refresh after
+// ttl
+func NewProjects(open Opener, ttl time.Duration) *Projects {
+	return &Projects{open: open, ttl: ttl, projects: make(map[string]*project)}
+}
+
+// Get returns the variables of envName in the project configured at
+// configPath
+func (p *Projects) Get(ctx context.Context, configPath, profile, envName string) (map[string]string, error) {
+	proj, err := p.project(configPath, profile)
+	if err != nil {
+		return nil, err
+	}
+	if !contains(proj.environments, envName) {
+		return nil, fmt.Errorf("environment %s: %w", envName, ErrNotFound)
+	}
+	file, err := proj.cache.Get(ctx, envName)
+	if err != nil {
+		return nil, err
+	}
+	return file.ToMap(), nil
+}
+
+// project returns the open project for a configuration file, reopening it
+// when the file was modified
+func (p *Projects) project(configPath, profile string) (*project, error) {
+	if !filepath.IsAbs(configPath) {
+		return nil, fmt.Errorf("configuration path %q is not absolute", configPath)
+	}
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := configPath + "\x00" + profile
+	if proj, ok := p.projects[id]; ok && proj.modTime.Equal(info.ModTime()) {
+		return proj, nil
+	}
+	source, environments, err := p.open(configPath, profile)
+	if err != nil {
+		return nil, err
+	}
+	proj := &project{cache: NewCache(source, p.ttl), environments: environments, modTime: info.ModTime()}
+	p.projects[id] = proj
+	return proj, nil
+}
+
+// Run refreshes the loaded environments of every project every TTL until
+// ctx is cancelled. onError is called for failed refreshes and may be nil.
+func (p *Projects) Run(ctx context.Context, onError func(error)) {
+	ticker := time.NewTicker(p.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			caches := make([]*Cache, 0, len(p.projects))
+			for _, proj := range p.projects {
+				caches = append(caches, proj.cache)
+			}
+			p.mu.Unlock()
+
+			for _, cache := range caches {
+				if err := cache.Refresh(ctx); err != nil && onError != nil && ctx.Err() == nil {
+					onError(err)
+				}
+			}
+		}
+	}
+}
+
+// handleProject serves a whole environment of a project to the callers the
+// ACL allows every key, by default the agent's own user
+func (s *Server) handleProject(w http.ResponseWriter, r *http.Request) {
+	envName := r.PathValue("env")
+	configPath := r.URL.Query().Get("config")
+	caller := callerFromRequest(r)
+
+	allowed := s.ACL.Allowed(caller, envName, "*")
+	s.logger().Info("Environment requested",
+		zap.String("config", configPath),
+		zap.String("environment", envName),
+		zap.Int("pid", caller.PID),
+		zap.Int("uid", caller.UID),
+		zap.String("exe", caller.Exe),
+		zap.Bool("allowed", allowed))
+	if !allowed {
+		http.Error(w, "access denied", http.StatusForbidden)
+		return
+	}
+
+	vars, err := s.Projects.Get(r.Context(), configPath, r.URL.Query().Get("profile"), envName)
+	if errors.Is(err, ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.logger().Error("Failed to load environment", zap.String("config", configPath), zap.String("environment", envName), zap.Error(err))
+		http.Error(w, fmt.Sprintf("failed to load environment: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]map[string]string{"variables": vars})
+}
+
+// Environment returns every variable of envName in the project configured
+// at configPath from a system agent. profile is the AWS profile of the
+// caller, which the agent uses when set.
+func (c *Client) Environment(ctx context.Context, configPath, profile, envName string) (map[string]string, error) {
+	query := url.Values{"config": {configPath}}
+	if profile != "" {
+		query.Set("profile", profile)
+	}
+	body, err := c.get(ctx, systemRoute+url.PathEscape(envName)+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Variables map[string]string `json:"variables"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("invalid response from agent: %w", err)
+	}
+	return result.Variables, nil
+}
+
+// SystemSocketPath returns the socket of the user's system agent
+func SystemSocketPath() string {
+	return filepath.Join(filepath.Dir(DefaultSocketPath()), "system.sock")
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjects(t *testing.T) {
+	ctx := context.Background()
+	configPath := filepath.Join(t.TempDir(), ".envyrc")
+	require.NoError(t, os.WriteFile(configPath, []byte("project: myapp\n"), 0600))
+
+	source := &fakeSource{values: map[string]string{"API_URL": "https://api.example.org"}}
+	var opened []string
+	projects := NewProjects(func(path, profile string) (Source, []string, error) {
+		opened = append(opened, profile)
+		return source, []string{"dev", "prod"}, nil
+	}, time.Minute)
+
+	vars, err := projects.Get(ctx, configPath, "", "dev")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_URL": "https://api.example.org"}, vars)
+
+	_, err = projects.Get(ctx, configPath, "", "prod")
+	require.NoError(t, err)
+	assert.Equal(t, 2, source.pullCount(), "one pull per environment")
+	assert.Len(t, opened, 1, "the project is opened once")
+
+	_, err = projects.Get(ctx, configPath, "admin", "dev")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "admin"}, opened, "each profile has its own cache")
+
+	// Editing .envyrc reopens the project
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(configPath, later, later))
+	_, err = projects.Get(ctx, configPath, "", "dev")
+	require.NoError(t, err)
+	assert.Len(t, opened, 3)
+
+	_, err = projects.Get(ctx, configPath, "", "staging")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	_, err = projects.Get(ctx, ".envyrc", "", "dev")
+	assert.ErrorContains(t, err, "not absolute")
+	_, err = projects.Get(ctx, filepath.Join(t.TempDir(), ".envyrc"), "", "dev")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestServer_Projects(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("peer credentials are only available on Linux")
+	}
+	ctx := context.Background()
+	configPath := filepath.Join(t.TempDir(), ".envyrc")
+	require.NoError(t, os.WriteFile(configPath, []byte("project: myapp\n"), 0600))
+
+	source := &fakeSource{values: map[string]string{"DB_PASSWORD": "hunter2"}}
+	acl, err := NewACL(nil, os.Getuid())
+	require.NoError(t, err)
+	server := &Server{
+		ACL: acl,
+		Projects: NewProjects(func(path, profile string) (Source, []string, error) {
+			return source, []string{"prod"}, nil
+		}, time.Minute),
+	}
+
+	dir, err := os.MkdirTemp("", "envy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	listener, err := ListenUnix(filepath.Join(dir, "system.sock"), 0600)
+	require.NoError(t, err)
+
+	serveCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(serveCtx, listener) }()
+	defer func() {
+		cancel()
+		assert.NoError(t, <-done)
+	}()
+
+	client := NewClient(filepath.Join(dir, "system.sock"))
+	vars, err := client.Environment(ctx, configPath, "default", "prod")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DB_PASSWORD": "hunter2"}, vars)
+
+	_, err = client.Environment(ctx, configPath, "", "staging")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	_, err = client.Environment(ctx, filepath.Join(dir, "missing"), "", "prod")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestSystemSocketPath(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	assert.Equal(t, "/run/user/1000/envy/system.sock", SystemSocketPath())
+}
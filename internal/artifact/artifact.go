@@ -0,0 +1,180 @@
+// Package artifact signs the files envy writes, such as exports and
+// bundles, with detached signatures, so downstream systems can check that a
+// configuration artifact came from an authorized envy run before using it.
+//
+// A signature covers a statement of the artifact's name, SHA-256 digest and
+// size, when it was signed and by whom. Statements are signed with an
+// Ed25519 key file, an asymmetric AWS KMS key or cosign.
+package artifact
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SignatureExtension is appended to an artifact's name for its signature
+const SignatureExtension = ".sig"
+
+// Signing methods
+const (
+	MethodEd25519 = "ed25519"
+	MethodKMS     = "kms"
+	MethodCosign  = "cosign"
+)
+
+var (
+	// ErrSignature is returned when a signature does not verify
+	ErrSignature = errors.New("artifact signature is not valid")
+	// ErrDigest is returned when an artifact differs from the one signed
+	ErrDigest = errors.New("artifact does not match its signature")
+)
+
+// Statement describes a signed artifact
+type Statement struct {
+	Artifact string    `json:"artifact"`
+	Digest   string    `json:"digest"`
+	Size     int64     `json:"size"`
+	SignedAt time.Time `json:"signed_at"`
+	SignedBy string    `json:"signed_by,omitempty"`
+}
+
+// Signature is the content of a signature file
+type Signature struct {
+	Statement Statement `json:"statement"`
+	Method    string    `json:"method"`
+	// KeyID identifies the signing key: the ARN of a KMS key, the key ID of
+	// an Ed25519 key or the cosign key reference
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	Signature []byte `json:"signature"`
+}
+
+// Signer signs statements
+type Signer interface {
+	Sign(ctx context.Context, message []byte) (*Signature, error)
+}
+
+// identifier is implemented by signers that know who is signing
+type identifier interface {
+	SignedBy(ctx context.Context) string
+}
+
+// Verifier checks the signature of a statement
+type Verifier interface {
+	Verify(ctx context.Context, sig *Signature, message []byte) error
+}
+
+// Digest returns the SHA-256 digest and size of a file
+func Digest(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open artifact: %w", err)
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read artifact: %w", err)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// Sign signs the artifact at path. Signers that know who is signing, such
+// as KMS with the caller's ARN, record it in the statement.
+func Sign(ctx context.Context, path string, signer Signer, now time.Time) (*Signature, error) {
+	digest, size, err := Digest(path)
+	if err != nil {
+		return nil, err
+	}
+	statement := Statement{
+		Artifact: filepath.Base(path),
+		Digest:   digest,
+		Size:     size,
+		SignedAt: now.UTC(),
+	}
+	if id, ok := signer.(identifier); ok {
+		statement.SignedBy = id.SignedBy(ctx)
+	}
+	message, err := statement.bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := signer.Sign(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	sig.Statement = statement
+	return sig, nil
+}
+
+// Verify checks that the artifact at path is the one signed, and that the
+// signature verifies with verifier
+func Verify(ctx context.Context, sig *Signature, path string, verifier Verifier) error {
+	digest, size, err := Digest(path)
+	if err != nil {
+		return err
+	}
+	if digest != sig.Statement.Digest || size != sig.Statement.Size {
+		return fmt.Errorf("%s: %w", path, ErrDigest)
+	}
+
+	message, err := sig.Statement.bytes()
+	if err != nil {
+		return err
+	}
+	return verifier.Verify(ctx, sig, message)
+}
+
+// ArtifactPath returns the artifact a signature file belongs to: the file
+// without the signature extension, or else the signed name next to it
+func ArtifactPath(sigPath string, sig *Signature) string {
+	if strings.HasSuffix(sigPath, SignatureExtension) {
+		return strings.TrimSuffix(sigPath, SignatureExtension)
+	}
+	return filepath.Join(filepath.Dir(sigPath), filepath.Base(sig.Statement.Artifact))
+}
+
+// bytes returns the message a signature covers
+func (s Statement) bytes() ([]byte, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signature statement: %w", err)
+	}
+	return data, nil
+}
+
+// ReadSignature reads a signature file
+func ReadSignature(path string) (*Signature, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature: %w", err)
+	}
+	var sig Signature
+	if err := json.Unmarshal(data, &sig); err != nil {
+		return nil, fmt.Errorf("%s is not an envy signature: %w", path, err)
+	}
+	return &sig, nil
+}
+
+// Write writes a signature file
+func (s *Signature) Write(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode signature: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write signature: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package artifact
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/drapon/envy/internal/aws/client"
+	"github.com/drapon/envy/internal/bundle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func noAWS(keyID string) (*client.Client, error) {
+	panic("AWS must not be used")
+}
+
+func writeArtifact(t *testing.T, dir string) string {
+	path := filepath.Join(dir, "prod.env")
+	require.NoError(t, os.WriteFile(path, []byte("PORT=8080\n"), 0600))
+	return path
+}
+
+func TestSignAndVerify_Ed25519(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := bundle.GenerateKeys(dir)
+	require.NoError(t, err)
+	path := writeArtifact(t, dir)
+
+	signer, err := ParseSigner(paths[1], noAWS)
+	require.NoError(t, err)
+	sig, err := Sign(context.Background(), path, signer, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, MethodEd25519, sig.Method)
+	assert.Equal(t, "prod.env", sig.Statement.Artifact)
+
+	sigPath := path + SignatureExtension
+	require.NoError(t, sig.Write(sigPath))
+	read, err := ReadSignature(sigPath)
+	require.NoError(t, err)
+	assert.Equal(t, path, ArtifactPath(sigPath, read))
+
+	verifier, err := ParseVerifier(paths[2], noAWS)
+	require.NoError(t, err)
+	require.NoError(t, Verify(context.Background(), read, path, verifier))
+
+	// A backdated statement no longer verifies
+	backdated := *read
+	backdated.Statement.SignedAt = backdated.Statement.SignedAt.Add(-time.Hour)
+	assert.ErrorIs(t, Verify(context.Background(), &backdated, path, verifier), ErrSignature)
+
+	// Neither does a changed artifact
+	require.NoError(t, os.WriteFile(path, []byte("PORT=9090\n"), 0600))
+	assert.ErrorIs(t, Verify(context.Background(), read, path, verifier), ErrDigest)
+}
+
+func TestVerify_KMSOffline(t *testing.T) {
+	dir := t.TempDir()
+	path := writeArtifact(t, dir)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tests := []struct {
+		algorithm string
+		public    interface{}
+		sign      func(digest []byte) ([]byte, error)
+	}{
+		{"ECDSA_SHA_256", &ecKey.PublicKey, func(digest []byte) ([]byte, error) {
+			return ecdsa.SignASN1(rand.Reader, ecKey, digest)
+		}},
+		{"RSASSA_PSS_SHA_256", &rsaKey.PublicKey, func(digest []byte) ([]byte, error) {
+			return rsa.SignPSS(rand.Reader, rsaKey, crypto.SHA256, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}},
+		{"RSASSA_PKCS1_V1_5_SHA_256", &rsaKey.PublicKey, func(digest []byte) ([]byte, error) {
+			return rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			// Sign as KMS would, with MessageType DIGEST
+			digest, size, err := Digest(path)
+			require.NoError(t, err)
+			sig := &Signature{
+				Statement: Statement{Artifact: "prod.env", Digest: digest, Size: size, SignedAt: time.Now().UTC()},
+				Method:    MethodKMS,
+				Algorithm: tt.algorithm,
+			}
+			message, err := sig.Statement.bytes()
+			require.NoError(t, err)
+			sum := sha256.Sum256(message)
+			sig.Signature, err = tt.sign(sum[:])
+			require.NoError(t, err)
+
+			der, err := x509.MarshalPKIXPublicKey(tt.public)
+			require.NoError(t, err)
+			pubPath := filepath.Join(t.TempDir(), "kms.pub")
+			require.NoError(t, os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+
+			verifier, err := ParseVerifier(pubPath, noAWS)
+			require.NoError(t, err)
+			require.NoError(t, Verify(context.Background(), sig, path, verifier))
+
+			sig.Signature[len(sig.Signature)-1] ^= 0xff
+			assert.ErrorIs(t, Verify(context.Background(), sig, path, verifier), ErrSignature)
+		})
+	}
+}
+
+func TestCosign_NotInstalled(t *testing.T) {
+	original := cosignBinary
+	cosignBinary = "envy-test-missing-cosign"
+	t.Cleanup(func() { cosignBinary = original })
+
+	signer, err := ParseSigner("cosign:cosign.key", noAWS)
+	require.NoError(t, err)
+	_, err = Sign(context.Background(), writeArtifact(t, t.TempDir()), signer, time.Now())
+	assert.ErrorContains(t, err, "cosign is not installed")
+}
@@ -0,0 +1,59 @@
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/aws/client"
+	"github.com/drapon/envy/internal/aws/kms"
+	"github.com/drapon/envy/internal/config"
+)
+
+// ConfigClient returns an AWSClientFunc using the AWS settings of cfg. When
+// cfg is nil, e.g. on a system verifying artifacts without an .envyrc, the
+// default AWS configuration is used in the key's region: that of its ARN,
+// else AWS_REGION or AWS_DEFAULT_REGION.
+func ConfigClient(ctx context.Context, cfg *config.Config) AWSClientFunc {
+	return func(keyID string) (*client.Client, error) {
+		if cfg != nil {
+			manager, err := aws.NewManager(cfg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create AWS manager: %w", err)
+			}
+			return manager.GetClient(), nil
+		}
+
+		region := kms.RegionOf(keyID)
+		for _, name := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+			if region == "" {
+				region = os.Getenv(name)
+			}
+		}
+		if region == "" {
+			return nil, fmt.Errorf("no AWS region for KMS key %s: give the key by ARN or set AWS_REGION", keyID)
+		}
+		return client.NewClient(ctx, client.Options{Region: region})
+	}
+}
+
+// SignFile signs the artifact at path with the key reference ref (see
+// ParseSigner) and writes the signature next to it. It returns the path of
+// the signature.
+func SignFile(ctx context.Context, path, ref string, cfg *config.Config) (string, error) {
+	signer, err := ParseSigner(ref, ConfigClient(ctx, cfg))
+	if err != nil {
+		return "", err
+	}
+	sig, err := Sign(ctx, path, signer, time.Now())
+	if err != nil {
+		return "", err
+	}
+	sigPath := path + SignatureExtension
+	if err := sig.Write(sigPath); err != nil {
+		return "", err
+	}
+	return sigPath, nil
+}
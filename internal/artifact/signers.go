@@ -0,0 +1,281 @@
+package artifact
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/drapon/envy/internal/aws/client"
+	"github.com/drapon/envy/internal/aws/kms"
+	"github.com/drapon/envy/internal/bundle"
+)
+
+// Prefixes of key references that are not key files
+const (
+	kmsPrefix    = "kms:"
+	cosignPrefix = "cosign:"
+)
+
+// kmsAlgorithms are the KMS signing algorithms used, in order of preference.
+// All sign a SHA-256 digest.
+var kmsAlgorithms = []string{"ECDSA_SHA_256", "RSASSA_PSS_SHA_256", "RSASSA_PKCS1_V1_5_SHA_256"}
+
+// cosignBinary is the cosign executable
+var cosignBinary = "cosign"
+
+// AWSClientFunc returns the AWS client to use a KMS key with
+type AWSClientFunc func(keyID string) (*client.Client, error)
+
+// ParseSigner returns the signer of a key reference: kms:<key ID, ARN or
+// alias>, cosign:<cosign key reference> or the path of an Ed25519 private
+// key, such as the signing key of 'envy bundle keygen'
+func ParseSigner(ref string, awsClient AWSClientFunc) (Signer, error) {
+	switch {
+	case strings.HasPrefix(ref, kmsPrefix):
+		keyID := strings.TrimPrefix(ref, kmsPrefix)
+		c, err := awsClient(keyID)
+		if err != nil {
+			return nil, err
+		}
+		return &kmsSigner{client: kms.NewClient(c), identity: c, keyID: keyID}, nil
+	case strings.HasPrefix(ref, cosignPrefix):
+		return &cosignKey{ref: strings.TrimPrefix(ref, cosignPrefix)}, nil
+	case ref == "":
+		return nil, fmt.Errorf("no signing key given")
+	}
+
+	key, err := bundle.LoadSigningKey(ref)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519Signer(key), nil
+}
+
+// ParseVerifier returns the verifier of a key reference: kms:<key ID, ARN
+// or alias> to verify with KMS, cosign:<cosign key reference>, or the path
+// of a PEM public key (Ed25519, or the ECDSA or RSA public key of a KMS key)
+// to verify offline
+func ParseVerifier(ref string, awsClient AWSClientFunc) (Verifier, error) {
+	switch {
+	case strings.HasPrefix(ref, kmsPrefix):
+		keyID := strings.TrimPrefix(ref, kmsPrefix)
+		c, err := awsClient(keyID)
+		if err != nil {
+			return nil, err
+		}
+		return &kmsVerifier{client: kms.NewClient(c), keyID: keyID}, nil
+	case strings.HasPrefix(ref, cosignPrefix):
+		return &cosignKey{ref: strings.TrimPrefix(ref, cosignPrefix)}, nil
+	case ref == "":
+		return nil, fmt.Errorf("no verification key given")
+	}
+	return loadPublicKey(ref)
+}
+
+// ed25519Signer signs with an Ed25519 private key
+type ed25519Signer ed25519.PrivateKey
+
+func (s ed25519Signer) Sign(ctx context.Context, message []byte) (*Signature, error) {
+	key := ed25519.PrivateKey(s)
+	return &Signature{
+		Method:    MethodEd25519,
+		KeyID:     bundle.KeyID(key.Public().(ed25519.PublicKey)),
+		Algorithm: "Ed25519",
+		Signature: ed25519.Sign(key, message),
+	}, nil
+}
+
+// kmsSigner signs the SHA-256 digest of statements with a KMS key
+type kmsSigner struct {
+	client   *kms.Client
+	identity *client.Client
+	keyID    string
+}
+
+// SignedBy returns the caller the statement is signed by
+func (s *kmsSigner) SignedBy(ctx context.Context) string {
+	arn, err := s.identity.CallerARN(ctx)
+	if err != nil {
+		return ""
+	}
+	return arn
+}
+
+func (s *kmsSigner) Sign(ctx context.Context, message []byte) (*Signature, error) {
+	pub, err := s.client.GetPublicKey(ctx, s.keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read KMS key %s: %w", s.keyID, err)
+	}
+	algorithm := ""
+	for _, preferred := range kmsAlgorithms {
+		for _, supported := range pub.SigningAlgorithms {
+			if algorithm == "" && supported == preferred {
+				algorithm = preferred
+			}
+		}
+	}
+	if algorithm == "" {
+		return nil, fmt.Errorf("KMS key %s supports none of %s", s.keyID, strings.Join(kmsAlgorithms, ", "))
+	}
+
+	digest := sha256.Sum256(message)
+	signature, keyARN, err := s.client.Sign(ctx, s.keyID, algorithm, digest[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with KMS key %s: %w", s.keyID, err)
+	}
+	return &Signature{Method: MethodKMS, KeyID: keyARN, Algorithm: algorithm, Signature: signature}, nil
+}
+
+// kmsVerifier verifies KMS signatures with KMS
+type kmsVerifier struct {
+	client *kms.Client
+	keyID  string
+}
+
+func (v *kmsVerifier) Verify(ctx context.Context, sig *Signature, message []byte) error {
+	if sig.Method != MethodKMS {
+		return fmt.Errorf("signature was made with %s, not KMS: %w", sig.Method, ErrSignature)
+	}
+	digest := sha256.Sum256(message)
+	valid, err := v.client.Verify(ctx, v.keyID, sig.Algorithm, digest[:], sig.Signature)
+	if err != nil {
+		return fmt.Errorf("failed to verify with KMS key %s: %w", v.keyID, err)
+	}
+	if !valid {
+		return ErrSignature
+	}
+	return nil
+}
+
+// publicKey verifies Ed25519 signatures, and KMS signatures offline
+type publicKey struct {
+	key crypto.PublicKey
+}
+
+func loadPublicKey(path string) (*publicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("%s does not contain a PEM PUBLIC KEY", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+	}
+	return &publicKey{key: key}, nil
+}
+
+func (p *publicKey) Verify(ctx context.Context, sig *Signature, message []byte) error {
+	if key, ok := p.key.(ed25519.PublicKey); ok {
+		if sig.Method != MethodEd25519 || !ed25519.Verify(key, message, sig.Signature) {
+			return ErrSignature
+		}
+		return nil
+	}
+
+	if sig.Method != MethodKMS {
+		return fmt.Errorf("signature was made with %s, which this public key cannot verify: %w", sig.Method, ErrSignature)
+	}
+	digest := sha256.Sum256(message)
+	valid := false
+	switch key := p.key.(type) {
+	case *ecdsa.PublicKey:
+		valid = sig.Algorithm == "ECDSA_SHA_256" && ecdsa.VerifyASN1(key, digest[:], sig.Signature)
+	case *rsa.PublicKey:
+		switch sig.Algorithm {
+		case "RSASSA_PSS_SHA_256":
+			valid = rsa.VerifyPSS(key, crypto.SHA256, digest[:], sig.Signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		case "RSASSA_PKCS1_V1_5_SHA_256":
+			valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig.Signature) == nil
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", p.key)
+	}
+	if !valid {
+		return ErrSignature
+	}
+	return nil
+}
+
+// cosignKey signs and verifies statements with the cosign CLI, so any key
+// reference cosign supports can be used (key files, cloud KMS URIs, ...)
+type cosignKey struct {
+	ref string
+}
+
+func (c *cosignKey) Sign(ctx context.Context, message []byte) (*Signature, error) {
+	dir, err := os.MkdirTemp("", "envy-cosign-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	messageFile := filepath.Join(dir, "statement.json")
+	sigFile := filepath.Join(dir, "statement.sig")
+	if err := os.WriteFile(messageFile, message, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write statement: %w", err)
+	}
+	if err := c.run(ctx, "sign-blob", "--yes", "--key", c.ref, "--output-signature", sigFile, messageFile); err != nil {
+		return nil, err
+	}
+
+	encoded, err := os.ReadFile(sigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cosign signature: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cosign signature: %w", err)
+	}
+	return &Signature{Method: MethodCosign, KeyID: c.ref, Algorithm: "cosign", Signature: signature}, nil
+}
+
+func (c *cosignKey) Verify(ctx context.Context, sig *Signature, message []byte) error {
+	if sig.Method != MethodCosign {
+		return fmt.Errorf("signature was made with %s, not cosign: %w", sig.Method, ErrSignature)
+	}
+	dir, err := os.MkdirTemp("", "envy-cosign-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	messageFile := filepath.Join(dir, "statement.json")
+	sigFile := filepath.Join(dir, "statement.sig")
+	if err := os.WriteFile(messageFile, message, 0600); err != nil {
+		return fmt.Errorf("failed to write statement: %w", err)
+	}
+	if err := os.WriteFile(sigFile, []byte(base64.StdEncoding.EncodeToString(sig.Signature)), 0600); err != nil {
+		return fmt.Errorf("failed to write signature: %w", err)
+	}
+	if err := c.run(ctx, "verify-blob", "--key", c.ref, "--signature", sigFile, messageFile); err != nil {
+		return fmt.Errorf("%v: %w", err, ErrSignature)
+	}
+	return nil
+}
+
+func (c *cosignKey) run(ctx context.Context, args ...string) error {
+	if _, err := exec.LookPath(cosignBinary); err != nil {
+		return fmt.Errorf("cosign is not installed: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, cosignBinary, args...)
+	cmd.Stdin = os.Stdin // cosign may ask for the key's password
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("cosign %s failed: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
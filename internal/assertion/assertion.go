@@ -0,0 +1,260 @@
+// Package assertion evaluates simple expressions such as 'PORT == 443' or
+// 'len(API_KEY) >= 32' against the variables of an environment, so deploy
+// pipelines can smoke test a configuration without writing a rules file.
+//
+// An expression is a variable, optionally wrapped in len(), an operator and
+// a value:
+//
+//	==, !=      equal, comparing numbers as numbers and true/false as booleans
+//	<, <=, >, >= numeric comparison
+//	=~, !~      regular expression match
+//
+// A variable on its own asserts that it is set and not empty; !KEY asserts
+// the opposite. Values may be quoted with ' or " to keep spaces.
+package assertion
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// operators in the order they are matched, longest first
+var operators = []string{"==", "!=", "<=", ">=", "=~", "!~", "<", ">"}
+
+var (
+	keyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+	lenPattern = regexp.MustCompile(`^len\(\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\)$`)
+)
+
+// Assertion is a parsed expression
+type Assertion struct {
+	expression string
+	key        string
+	length     bool   // compare the length of the value
+	negate     bool   // !KEY
+	op         string // "" for KEY and !KEY
+	want       string
+	pattern    *regexp.Regexp
+}
+
+// Result is the outcome of an assertion
+type Result struct {
+	Expression string `json:"expression"`
+	Key        string `json:"key"`
+	Passed     bool   `json:"passed"`
+	// Detail says why an assertion failed, e.g. "PORT is 8080"
+	Detail string `json:"detail,omitempty"`
+}
+
+// Parse parses an expression
+func Parse(expression string) (*Assertion, error) {
+	a := &Assertion{expression: strings.TrimSpace(expression)}
+	if a.expression == "" {
+		return nil, fmt.Errorf("empty assertion")
+	}
+
+	index, op := findOperator(a.expression)
+	if op == "" {
+		subject := a.expression
+		if strings.HasPrefix(subject, "!") {
+			a.negate = true
+			subject = strings.TrimSpace(subject[1:])
+		}
+		if !keyPattern.MatchString(subject) {
+			return nil, fmt.Errorf("invalid assertion %q: expected KEY, !KEY or KEY <operator> VALUE", expression)
+		}
+		a.key = subject
+		return a, nil
+	}
+
+	subject := strings.TrimSpace(a.expression[:index])
+	if m := lenPattern.FindStringSubmatch(subject); m != nil {
+		a.key, a.length = m[1], true
+	} else if keyPattern.MatchString(subject) {
+		a.key = subject
+	} else {
+		return nil, fmt.Errorf("invalid assertion %q: %q is not a variable or len(VARIABLE)", expression, subject)
+	}
+	a.op = op
+
+	want, err := parseValue(strings.TrimSpace(a.expression[index+len(op):]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid assertion %q: %w", expression, err)
+	}
+	a.want = want
+
+	switch op {
+	case "=~", "!~":
+		if a.pattern, err = regexp.Compile(want); err != nil {
+			return nil, fmt.Errorf("invalid assertion %q: %w", expression, err)
+		}
+	case "<", "<=", ">", ">=":
+		if _, ok := number(want); !ok {
+			return nil, fmt.Errorf("invalid assertion %q: %s compares numbers, not %q", expression, op, want)
+		}
+	}
+	return a, nil
+}
+
+// ParseAll parses every expression, reporting the first that is invalid
+func ParseAll(expressions []string) ([]*Assertion, error) {
+	assertions := make([]*Assertion, 0, len(expressions))
+	for _, expression := range expressions {
+		a, err := Parse(expression)
+		if err != nil {
+			return nil, err
+		}
+		assertions = append(assertions, a)
+	}
+	return assertions, nil
+}
+
+// Key returns the variable the assertion is about
+func (a *Assertion) Key() string {
+	return a.key
+}
+
+// String returns the expression as given
+func (a *Assertion) String() string {
+	return a.expression
+}
+
+// Evaluate checks the assertion against vars. The values of keys for which
+// hide returns true are left out of the failure detail.
+func (a *Assertion) Evaluate(vars map[string]string, hide func(string) bool) Result {
+	result := Result{Expression: a.expression, Key: a.key}
+	value, set := vars[a.key]
+
+	if a.op == "" {
+		present := set && value != ""
+		result.Passed = present != a.negate
+		switch {
+		case result.Passed:
+		case a.negate:
+			result.Detail = a.key + " is set"
+		case set:
+			result.Detail = a.key + " is empty"
+		default:
+			result.Detail = a.key + " is not set"
+		}
+		return result
+	}
+	if !set {
+		result.Detail = a.key + " is not set"
+		return result
+	}
+
+	subject, actual := a.key, value
+	if a.length {
+		subject, actual = "len("+a.key+")", strconv.Itoa(utf8.RuneCountInString(value))
+	}
+
+	var err error
+	result.Passed, err = a.compare(actual)
+	switch {
+	case err != nil:
+		result.Detail = fmt.Sprintf("%s %v", subject, err)
+	case result.Passed:
+	case !a.length && hide != nil && hide(a.key):
+		result.Detail = subject + " has another value (sensitive, not shown)"
+	default:
+		result.Detail = fmt.Sprintf("%s is %s", subject, strconv.Quote(actual))
+	}
+	return result
+}
+
+// EvaluateAll checks every assertion against vars and reports whether all
+// of them passed
+func EvaluateAll(assertions []*Assertion, vars map[string]string, hide func(string) bool) ([]Result, bool) {
+	results := make([]Result, 0, len(assertions))
+	passed := true
+	for _, a := range assertions {
+		result := a.Evaluate(vars, hide)
+		passed = passed && result.Passed
+		results = append(results, result)
+	}
+	return results, passed
+}
+
+// compare applies the operator to actual and the expected value
+func (a *Assertion) compare(actual string) (bool, error) {
+	switch a.op {
+	case "==":
+		return equal(actual, a.want), nil
+	case "!=":
+		return !equal(actual, a.want), nil
+	case "=~":
+		return a.pattern.MatchString(actual), nil
+	case "!~":
+		return !a.pattern.MatchString(actual), nil
+	}
+
+	got, ok := number(actual)
+	if !ok {
+		return false, fmt.Errorf("is %s, not a number", strconv.Quote(actual))
+	}
+	want, _ := number(a.want)
+	switch a.op {
+	case "<":
+		return got < want, nil
+	case "<=":
+		return got <= want, nil
+	case ">":
+		return got > want, nil
+	default:
+		return got >= want, nil
+	}
+}
+
+// equal compares numbers as numbers, so 443 equals 443.0, and true/false
+// as booleans, so "False" and "0" equal false; anything else as strings
+func equal(actual, want string) bool {
+	if x, ok := number(actual); ok {
+		if y, ok := number(want); ok {
+			return x == y
+		}
+	}
+	if want == "true" || want == "false" {
+		if b, err := strconv.ParseBool(actual); err == nil {
+			return strconv.FormatBool(b) == want
+		}
+	}
+	return actual == want
+}
+
+func number(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f, err == nil
+}
+
+// findOperator returns the position and the first operator in expression
+func findOperator(expression string) (int, string) {
+	for i := range expression {
+		for _, op := range operators {
+			if strings.HasPrefix(expression[i:], op) {
+				return i, op
+			}
+		}
+	}
+	return -1, ""
+}
+
+// parseValue unquotes a quoted value; unquoted values are taken as written
+func parseValue(raw string) (string, error) {
+	if raw == "" {
+		return "", fmt.Errorf("missing value after the operator")
+	}
+	if len(raw) >= 2 && (raw[0] == '"' || raw[0] == '\'') {
+		if raw[len(raw)-1] != raw[0] {
+			return "", fmt.Errorf("unterminated quote in %s", raw)
+		}
+		if raw[0] == '"' {
+			return strconv.Unquote(raw)
+		}
+		return raw[1 : len(raw)-1], nil
+	}
+	return raw, nil
+}
@@ -0,0 +1,112 @@
+package aws
+
+import (
+	"context"
+	"strings"
+
+	"github.com/drapon/envy/internal/aws/errors"
+	"github.com/drapon/envy/internal/env"
+)
+
+// ApplyChanges writes a delta to an environment: variables in set are
+// created or overwritten and keys in deleted are removed. Variables not
+// mentioned are left untouched, which for Secrets Manager means the secret
+// is read, patched and written back as a whole. The delta is replicated to
+// the environment's replica regions like a push.
+func (m *Manager) ApplyChanges(ctx context.Context, envName string, set *env.File, deleted []string) error {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return err
+	}
+	var keys []string
+	if set != nil {
+		keys = append(keys, set.Keys()...)
+	}
+	keys = append(keys, deleted...)
+
+	return m.PushReplicated(ctx, envName, keys, func() error {
+		return m.applyChanges(ctx, envName, set, deleted)
+	})
+}
+
+// applyChanges writes a delta to the manager's region only
+func (m *Manager) applyChanges(ctx context.Context, envName string, set *env.File, deleted []string) error {
+	// Get environment configuration
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return err
+	}
+
+	// Determine which service to use
+	service := m.config.GetAWSService(envName)
+	path := m.config.GetParameterPath(envName)
+
+	if service == "secrets_manager" || envConfig.UseSecretsManager {
+		return m.applyToSecretsManager(ctx, path, set, deleted)
+	}
+
+	return m.applyToParameterStore(ctx, path, set, deleted)
+}
+
+func (m *Manager) applyToParameterStore(ctx context.Context, path string, set *env.File, deleted []string) error {
+	if set != nil && len(set.Keys()) > 0 {
+		if err := m.pushToParameterStore(ctx, path, set.ToMap(), true); err != nil {
+			return err
+		}
+	}
+
+	if !strings.HasSuffix(path, "/") {
+		path = path + "/"
+	}
+
+	for _, key := range deleted {
+		paramName := path + m.RemoteKey(key)
+		if err := m.paramStore.DeleteParameter(ctx, paramName); err != nil && !errors.IsNotFoundError(err) {
+			return errors.WrapAWSError(err, "delete parameter", paramName)
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) applyToSecretsManager(ctx context.Context, path string, set *env.File, deleted []string) error {
+	if m.config.SecretPerVariable() {
+		var vars map[string]string
+		if set != nil {
+			vars = set.ToMap()
+		}
+		return m.applyToVariableSecrets(ctx, path, vars, deleted)
+	}
+
+	// Clean path for secret name
+	secretName := strings.Trim(path, "/")
+	secretName = strings.ReplaceAll(secretName, "/", "-")
+
+	vars := make(map[string]string)
+	secret, err := m.secretsManager.GetSecret(ctx, secretName)
+	if err != nil && !errors.IsNotFoundError(err) {
+		return errors.WrapAWSError(err, "get secret", secretName)
+	}
+	if err == nil {
+		for k, v := range secret.KeyValue {
+			vars[k] = v
+		}
+	}
+
+	if set != nil {
+		for k, v := range set.ToMap() {
+			vars[m.RemoteKey(k)] = v
+		}
+	}
+	for _, key := range deleted {
+		delete(vars, m.RemoteKey(key))
+	}
+
+	err = m.secretsManager.CreateOrUpdateSecret(ctx, secretName,
+		"Environment variables for "+secretName, vars)
+	if err != nil {
+		return errors.WrapAWSError(err, "create/update secret", secretName)
+	}
+
+	return nil
+}
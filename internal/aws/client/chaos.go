@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/drapon/envy/internal/chaos"
+)
+
+// chaosMiddleware fails requests with synthetic errors before they are sent.
+// It runs after the SDK retry middleware, so injected failures are retried
+// exactly like real throttling and timeouts.
+func chaosMiddleware(injector *chaos.Injector) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("EnvyChaos",
+			func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+				operation := awsmiddleware.GetServiceID(ctx) + "." + awsmiddleware.GetOperationName(ctx)
+				if err := injector.Error(operation); err != nil {
+					return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+				}
+				return next.HandleFinalize(ctx, in)
+			}), middleware.After)
+	}
+}
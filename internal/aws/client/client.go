@@ -3,12 +3,21 @@ package client
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/drapon/envy/internal/chaos"
+	"github.com/drapon/envy/internal/prompt"
 )
 
 // Client represents an AWS client wrapper
@@ -16,8 +25,13 @@ type Client struct {
 	config        aws.Config
 	ssmClient     *ssm.Client
 	secretsClient *secretsmanager.Client
+	sqsClient     *sqs.Client
+	snsClient     *sns.Client
+	kmsClient     *kms.Client
 	region        string
 	profile       string
+	roleARN       string
+	oidc          *OIDCOptions
 	mu            sync.Mutex
 }
 
@@ -25,6 +39,25 @@ type Client struct {
 type Options struct {
 	Region  string
 	Profile string
+	// Chaos injects synthetic failures into every request when set
+	Chaos *chaos.Injector
+	// OIDC assumes a role with the CI provider's OIDC token when running in
+	// CI
+	OIDC *OIDCOptions
+	// RoleARN is a role assumed with the credentials of Profile (or OIDC),
+	// e.g. to reach an environment in another account
+	RoleARN string
+	// ExternalID is passed when assuming RoleARN, for roles whose trust
+	// policy requires one
+	ExternalID string
+	// MFASerial is the MFA device whose code assuming RoleARN needs. The
+	// code comes from TokenProvider, or is asked for in the terminal.
+	MFASerial     string
+	TokenProvider func() (string, error)
+	// Credentials replaces the credentials of Profile, OIDC and RoleARN,
+	// e.g. to share assumed role credentials between regions without
+	// asking for another MFA code
+	Credentials aws.CredentialsProvider
 }
 
 // NewClient creates a new AWS client
@@ -44,18 +77,74 @@ func NewClient(ctx context.Context, opts Options) (*Client, error) {
 		configOpts = append(configOpts, config.WithSharedConfigProfile(opts.Profile))
 	}
 
+	if opts.Chaos != nil {
+		configOpts = append(configOpts, config.WithAPIOptions([]func(*middleware.Stack) error{
+			chaosMiddleware(opts.Chaos),
+		}))
+	}
+
 	cfg, err := config.LoadDefaultConfig(ctx, configOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
+	if opts.Credentials != nil {
+		cfg.Credentials = opts.Credentials
+	} else {
+		if opts.OIDC != nil {
+			if err := applyOIDC(&cfg, *opts.OIDC); err != nil {
+				return nil, err
+			}
+		}
+		if opts.RoleARN != "" {
+			cfg.Credentials = aws.NewCredentialsCache(assumeRoleProvider(cfg, opts))
+		}
+	}
+
 	return &Client{
 		config:  cfg,
 		region:  opts.Region,
 		profile: opts.Profile,
+		roleARN: opts.RoleARN,
+		oidc:    opts.OIDC,
 	}, nil
 }
 
+// assumeRoleProvider assumes opts.RoleARN with the credentials of cfg
+func assumeRoleProvider(cfg aws.Config, opts Options) *stscreds.AssumeRoleProvider {
+	return stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), opts.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = "envy"
+		if opts.ExternalID != "" {
+			o.ExternalID = aws.String(opts.ExternalID)
+		}
+		if opts.MFASerial != "" {
+			o.SerialNumber = aws.String(opts.MFASerial)
+			o.TokenProvider = opts.TokenProvider
+			if o.TokenProvider == nil {
+				o.TokenProvider = promptMFAToken(opts.MFASerial)
+			}
+		}
+	})
+}
+
+// promptMFAToken asks for the current code of an MFA device in the terminal
+func promptMFAToken(serial string) func() (string, error) {
+	return func() (string, error) {
+		if !prompt.IsInteractive() {
+			return "", fmt.Errorf("assuming the role needs a code of MFA device %s, which can only be entered in a terminal", serial)
+		}
+		code, err := prompt.InteractiveInput(fmt.Sprintf("MFA code for %s:", serial))
+		if err != nil {
+			return "", err
+		}
+		code = strings.TrimSpace(code)
+		if code == "" {
+			return "", fmt.Errorf("no MFA code entered")
+		}
+		return code, nil
+	}
+}
+
 // SSM returns the SSM (Parameter Store) client
 func (c *Client) SSM() *ssm.Client {
 	c.mu.Lock()
@@ -78,6 +167,39 @@ func (c *Client) SecretsManager() *secretsmanager.Client {
 	return c.secretsClient
 }
 
+// SQS returns the SQS client
+func (c *Client) SQS() *sqs.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.sqsClient == nil {
+		c.sqsClient = sqs.NewFromConfig(c.config)
+	}
+	return c.sqsClient
+}
+
+// SNS returns the SNS client
+func (c *Client) SNS() *sns.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.snsClient == nil {
+		c.snsClient = sns.NewFromConfig(c.config)
+	}
+	return c.snsClient
+}
+
+// KMS returns the KMS client
+func (c *Client) KMS() *kms.Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.kmsClient == nil {
+		c.kmsClient = kms.NewFromConfig(c.config)
+	}
+	return c.kmsClient
+}
+
 // Region returns the configured AWS region
 func (c *Client) Region() string {
 	return c.region
@@ -88,6 +210,16 @@ func (c *Client) Profile() string {
 	return c.profile
 }
 
+// RoleARN returns the role the client assumes, if any
+func (c *Client) RoleARN() string {
+	return c.roleARN
+}
+
+// OIDC returns the OIDC options the client was created with, if any
+func (c *Client) OIDC() *OIDCOptions {
+	return c.oidc
+}
+
 // Config returns the underlying AWS config
 func (c *Client) Config() aws.Config {
 	return c.config
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -269,4 +270,24 @@ func TestMutexSafety(t *testing.T) {
 
 	// If we get here without deadlock or panic, the test passes
 	assert.True(t, true)
-}
\ No newline at end of file
+}
+func TestNewClientWithCredentials(t *testing.T) {
+	static := credentials.NewStaticCredentialsProvider("AKID", "SECRET", "")
+	client, err := NewClient(context.Background(), Options{
+		Region:      "eu-west-1",
+		RoleARN:     "arn:aws:iam::123456789012:role/envy",
+		MFASerial:   "arn:aws:iam::123456789012:mfa/alice",
+		Credentials: static,
+	})
+	assert.NoError(t, err)
+
+	creds, err := client.Config().Credentials.Retrieve(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "AKID", creds.AccessKeyID, "shared credentials are used instead of assuming the role again")
+	assert.Equal(t, "arn:aws:iam::123456789012:role/envy", client.RoleARN())
+}
+
+func TestPromptMFAToken_NotInteractive(t *testing.T) {
+	_, err := promptMFAToken("arn:aws:iam::123456789012:mfa/alice")()
+	assert.ErrorContains(t, err, "MFA device arn:aws:iam::123456789012:mfa/alice")
+}
@@ -0,0 +1,19 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// CallerARN returns the ARN of the principal the client's credentials
+// belong to
+func (c *Client) CallerARN(ctx context.Context) (string, error) {
+	out, err := sts.NewFromConfig(c.config).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get caller identity: %w", err)
+	}
+	return aws.ToString(out.Arn), nil
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// STS session duration limits for AssumeRole
+const (
+	MinLeaseDuration = 15 * time.Minute
+	MaxLeaseDuration = 12 * time.Hour
+)
+
+// LeaseOptions describes short-lived credentials minted for a child process
+type LeaseOptions struct {
+	RoleARN string
+	// Policy is an inline session policy (JSON). The credentials get the
+	// intersection of the role's permissions and this policy.
+	Policy      string
+	Duration    time.Duration
+	SessionName string
+}
+
+// Lease is a set of temporary credentials
+type Lease struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expires         time.Time
+}
+
+// Environ returns the variables that make AWS SDKs and the CLI use the
+// lease
+func (l *Lease) Environ(region string) map[string]string {
+	vars := map[string]string{
+		"AWS_ACCESS_KEY_ID":         l.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY":     l.SecretAccessKey,
+		"AWS_SESSION_TOKEN":         l.SessionToken,
+		"AWS_CREDENTIAL_EXPIRATION": l.Expires.UTC().Format(time.RFC3339),
+	}
+	if region != "" {
+		vars["AWS_REGION"] = region
+		vars["AWS_DEFAULT_REGION"] = region
+	}
+	return vars
+}
+
+// LeaseCredentials assumes a role with the client's credentials and
+// returns credentials that expire after opts.Duration
+func (c *Client) LeaseCredentials(ctx context.Context, opts LeaseOptions) (*Lease, error) {
+	if opts.RoleARN == "" {
+		return nil, fmt.Errorf("a role ARN is required to lease credentials")
+	}
+	if opts.Duration < MinLeaseDuration || opts.Duration > MaxLeaseDuration {
+		return nil, fmt.Errorf("lease duration must be between %s and %s", MinLeaseDuration, MaxLeaseDuration)
+	}
+	if opts.Policy != "" && !json.Valid([]byte(opts.Policy)) {
+		return nil, fmt.Errorf("session policy is not valid JSON")
+	}
+
+	sessionName := opts.SessionName
+	if sessionName == "" {
+		sessionName = "envy-run"
+	}
+
+	input := &sts.AssumeRoleInput{
+		RoleArn:         aws.String(opts.RoleARN),
+		RoleSessionName: aws.String(sessionName),
+		DurationSeconds: aws.Int32(int32(opts.Duration / time.Second)),
+	}
+	if opts.Policy != "" {
+		input.Policy = aws.String(opts.Policy)
+	}
+
+	out, err := sts.NewFromConfig(c.config).AssumeRole(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assume %s: %w", opts.RoleARN, err)
+	}
+	if out.Credentials == nil {
+		return nil, fmt.Errorf("assuming %s returned no credentials", opts.RoleARN)
+	}
+
+	return &Lease{
+		AccessKeyID:     aws.ToString(out.Credentials.AccessKeyId),
+		SecretAccessKey: aws.ToString(out.Credentials.SecretAccessKey),
+		SessionToken:    aws.ToString(out.Credentials.SessionToken),
+		Expires:         aws.ToTime(out.Credentials.Expiration),
+	}, nil
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLease_Environ(t *testing.T) {
+	lease := &Lease{
+		AccessKeyID:     "ASIAEXAMPLE",
+		SecretAccessKey: "secret",
+		SessionToken:    "token",
+		Expires:         time.Date(2024, 6, 3, 14, 5, 0, 0, time.UTC),
+	}
+
+	assert.Equal(t, map[string]string{
+		"AWS_ACCESS_KEY_ID":         "ASIAEXAMPLE",
+		"AWS_SECRET_ACCESS_KEY":     "secret",
+		"AWS_SESSION_TOKEN":         "token",
+		"AWS_CREDENTIAL_EXPIRATION": "2024-06-03T14:05:00Z",
+		"AWS_REGION":                "eu-west-1",
+		"AWS_DEFAULT_REGION":        "eu-west-1",
+	}, lease.Environ("eu-west-1"))
+	assert.NotContains(t, lease.Environ(""), "AWS_REGION")
+}
+
+func TestClient_LeaseCredentials_Invalid(t *testing.T) {
+	c := &Client{config: aws.Config{Region: "us-east-1"}}
+	ctx := context.Background()
+
+	_, err := c.LeaseCredentials(ctx, LeaseOptions{Duration: time.Hour})
+	assert.ErrorContains(t, err, "role ARN")
+
+	_, err = c.LeaseCredentials(ctx, LeaseOptions{RoleARN: "arn:aws:iam::123456789012:role/app", Duration: time.Minute})
+	assert.ErrorContains(t, err, "between")
+
+	_, err = c.LeaseCredentials(ctx, LeaseOptions{RoleARN: "arn:aws:iam::123456789012:role/app", Duration: time.Hour, Policy: "{not json"})
+	assert.ErrorContains(t, err, "not valid JSON")
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// defaultOIDCAudience is the audience AWS expects in web identity tokens
+const defaultOIDCAudience = "sts.amazonaws.com"
+
+// OIDCOptions configures keyless authentication from CI: the CI provider's
+// OIDC token is exchanged for role credentials with AssumeRoleWithWebIdentity
+type OIDCOptions struct {
+	RoleARN string
+	// Provider is github, gitlab or circleci. Empty or "auto" detects the
+	// provider from the environment.
+	Provider string
+	// Audience is requested for GitHub tokens. GitLab and CircleCI set it in
+	// the pipeline configuration.
+	Audience string
+	// TokenEnv is the variable holding the token, overriding the provider's
+	// default
+	TokenEnv    string
+	SessionName string
+	Duration    time.Duration
+}
+
+// oidcProviders are the supported CI providers, in detection order, with
+// the variable each sets to "true" in its jobs
+var oidcProviders = []struct {
+	name   string
+	marker string
+}{
+	{"github", "GITHUB_ACTIONS"},
+	{"gitlab", "GITLAB_CI"},
+	{"circleci", "CIRCLECI"},
+}
+
+// DetectOIDCProvider returns the CI provider envy runs under, restricted to
+// want unless it is empty or "auto". It returns "" outside CI, so the same
+// .envyrc keeps working on developer machines with their usual credentials.
+func DetectOIDCProvider(want string) string {
+	for _, p := range oidcProviders {
+		if want != "" && want != "auto" && want != p.name {
+			continue
+		}
+		if os.Getenv(p.marker) == "true" {
+			return p.name
+		}
+	}
+	return ""
+}
+
+// applyOIDC replaces cfg's credentials with role credentials obtained with
+// the CI provider's OIDC token. Outside CI cfg is left unchanged.
+func applyOIDC(cfg *aws.Config, opts OIDCOptions) error {
+	provider := DetectOIDCProvider(opts.Provider)
+	if provider == "" {
+		return nil
+	}
+
+	retriever, err := oidcTokenRetriever(provider, opts)
+	if err != nil {
+		return err
+	}
+
+	sessionName := opts.SessionName
+	if sessionName == "" {
+		sessionName = "envy-" + provider
+	}
+
+	roleProvider := stscreds.NewWebIdentityRoleProvider(sts.NewFromConfig(*cfg), opts.RoleARN, retriever, func(o *stscreds.WebIdentityRoleOptions) {
+		o.RoleSessionName = sessionName
+		if opts.Duration > 0 {
+			o.Duration = opts.Duration
+		}
+	})
+	cfg.Credentials = aws.NewCredentialsCache(roleProvider)
+	return nil
+}
+
+// oidcTokenRetriever returns where to read the provider's token from
+func oidcTokenRetriever(provider string, opts OIDCOptions) (stscreds.IdentityTokenRetriever, error) {
+	if opts.TokenEnv != "" {
+		return envToken{names: []string{opts.TokenEnv}}, nil
+	}
+
+	switch provider {
+	case "github":
+		requestURL := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+		requestToken := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+		if requestURL == "" || requestToken == "" {
+			return nil, fmt.Errorf("GitHub OIDC token is not available: grant the workflow 'id-token: write' permission")
+		}
+		audience := opts.Audience
+		if audience == "" {
+			audience = defaultOIDCAudience
+		}
+		return &githubToken{
+			requestURL:   requestURL,
+			requestToken: requestToken,
+			audience:     audience,
+			http:         &http.Client{Timeout: 30 * time.Second},
+		}, nil
+
+	case "gitlab":
+		// GitLab exposes tokens declared under id_tokens in .gitlab-ci.yml
+		return envToken{names: []string{"GITLAB_OIDC_TOKEN"}, hint: "declare GITLAB_OIDC_TOKEN under id_tokens in .gitlab-ci.yml"}, nil
+
+	case "circleci":
+		return envToken{names: []string{"CIRCLE_OIDC_TOKEN_V2", "CIRCLE_OIDC_TOKEN"}, hint: "OIDC tokens require a CircleCI context"}, nil
+	}
+	return nil, fmt.Errorf("unsupported OIDC provider %q", provider)
+}
+
+// envToken reads a token from the first set variable in names
+type envToken struct {
+	names []string
+	hint  string
+}
+
+// GetIdentityToken implements stscreds.IdentityTokenRetriever
+func (t envToken) GetIdentityToken() ([]byte, error) {
+	for _, name := range t.names {
+		if token := strings.TrimSpace(os.Getenv(name)); token != "" {
+			return []byte(token), nil
+		}
+	}
+
+	err := fmt.Errorf("OIDC token is not set (%s)", strings.Join(t.names, ", "))
+	if t.hint != "" {
+		err = fmt.Errorf("%w: %s", err, t.hint)
+	}
+	return nil, err
+}
+
+// githubToken requests a token from the GitHub Actions OIDC endpoint. Tokens
+// are short-lived, so a new one is requested each time credentials are
+// refreshed.
+type githubToken struct {
+	requestURL   string
+	requestToken string
+	audience     string
+	http         *http.Client
+}
+
+// GetIdentityToken implements stscreds.IdentityTokenRetriever
+func (t *githubToken) GetIdentityToken() ([]byte, error) {
+	u, err := url.Parse(t.requestURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ACTIONS_ID_TOKEN_REQUEST_URL: %w", err)
+	}
+	query := u.Query()
+	query.Set("audience", t.audience)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+t.requestToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := t.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request GitHub OIDC token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("failed to request GitHub OIDC token: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var body struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode GitHub OIDC token: %w", err)
+	}
+	if body.Value == "" {
+		return nil, fmt.Errorf("GitHub returned an empty OIDC token")
+	}
+	return []byte(body.Value), nil
+}
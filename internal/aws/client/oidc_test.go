@@ -0,0 +1,89 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func clearCIEnv(t *testing.T) {
+	for _, p := range oidcProviders {
+		t.Setenv(p.marker, "")
+	}
+}
+
+func TestDetectOIDCProvider(t *testing.T) {
+	clearCIEnv(t)
+	assert.Equal(t, "", DetectOIDCProvider("auto"))
+
+	t.Setenv("GITLAB_CI", "true")
+	assert.Equal(t, "gitlab", DetectOIDCProvider(""))
+	assert.Equal(t, "gitlab", DetectOIDCProvider("gitlab"))
+	assert.Equal(t, "", DetectOIDCProvider("github"))
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	assert.Equal(t, "github", DetectOIDCProvider("auto"))
+	assert.Equal(t, "gitlab", DetectOIDCProvider("gitlab"))
+}
+
+func TestEnvToken(t *testing.T) {
+	t.Setenv("CIRCLE_OIDC_TOKEN_V2", "")
+	t.Setenv("CIRCLE_OIDC_TOKEN", "v1-token")
+
+	retriever, err := oidcTokenRetriever("circleci", OIDCOptions{})
+	require.NoError(t, err)
+	token, err := retriever.GetIdentityToken()
+	require.NoError(t, err)
+	assert.Equal(t, "v1-token", string(token))
+
+	t.Setenv("CIRCLE_OIDC_TOKEN_V2", "v2-token")
+	token, err = retriever.GetIdentityToken()
+	require.NoError(t, err)
+	assert.Equal(t, "v2-token", string(token))
+
+	t.Setenv("GITLAB_OIDC_TOKEN", "")
+	retriever, err = oidcTokenRetriever("gitlab", OIDCOptions{})
+	require.NoError(t, err)
+	_, err = retriever.GetIdentityToken()
+	assert.ErrorContains(t, err, "id_tokens")
+
+	t.Setenv("MY_TOKEN", "custom")
+	retriever, err = oidcTokenRetriever("gitlab", OIDCOptions{TokenEnv: "MY_TOKEN"})
+	require.NoError(t, err)
+	token, err = retriever.GetIdentityToken()
+	require.NoError(t, err)
+	assert.Equal(t, "custom", string(token))
+}
+
+func TestGitHubToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer request-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"value":"jwt-for-` + r.URL.Query().Get("audience") + `"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", server.URL+"/token?api-version=2.0")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "request-token")
+
+	retriever, err := oidcTokenRetriever("github", OIDCOptions{})
+	require.NoError(t, err)
+	token, err := retriever.GetIdentityToken()
+	require.NoError(t, err)
+	assert.Equal(t, "jwt-for-sts.amazonaws.com", string(token))
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "wrong")
+	retriever, err = oidcTokenRetriever("github", OIDCOptions{Audience: "custom"})
+	require.NoError(t, err)
+	_, err = retriever.GetIdentityToken()
+	assert.ErrorContains(t, err, "403")
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", "")
+	_, err = oidcTokenRetriever("github", OIDCOptions{})
+	assert.ErrorContains(t, err, "id-token: write")
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	awserrors "github.com/drapon/envy/internal/aws/errors"
+)
+
+// Profile is a named profile from the shared AWS config files
+type Profile struct {
+	Name string
+	// SSO is true when the profile signs in with IAM Identity Center, so
+	// 'envy login' can renew its credentials
+	SSO bool
+}
+
+// LoadProfiles returns the profiles defined in ~/.aws/config and
+// ~/.aws/credentials (or AWS_CONFIG_FILE and AWS_SHARED_CREDENTIALS_FILE),
+// sorted by name
+func LoadProfiles() ([]Profile, error) {
+	profiles := make(map[string]*Profile)
+
+	for _, source := range []struct {
+		path       string
+		configFile bool
+	}{
+		{sharedFile("AWS_CONFIG_FILE", "config"), true},
+		{sharedFile("AWS_SHARED_CREDENTIALS_FILE", "credentials"), false},
+	} {
+		f, err := os.Open(source.path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read %s: %w", source.path, err)
+		}
+		parseProfiles(f, source.configFile, profiles)
+		f.Close()
+	}
+
+	result := make([]Profile, 0, len(profiles))
+	for _, profile := range profiles {
+		result = append(result, *profile)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// parseProfiles adds the profiles in an INI-style shared config file. In the
+// config file sections are named "profile NAME" (except "default"); in the
+// credentials file they are just "NAME".
+func parseProfiles(r io.Reader, configFile bool, profiles map[string]*Profile) {
+	var current *Profile
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = nil
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if configFile && name != "default" {
+				var ok bool
+				if name, ok = strings.CutPrefix(name, "profile "); !ok {
+					// e.g. [sso-session NAME] or [services NAME]
+					continue
+				}
+				name = strings.TrimSpace(name)
+			}
+			if name == "" {
+				continue
+			}
+
+			if profiles[name] == nil {
+				profiles[name] = &Profile{Name: name}
+			}
+			current = profiles[name]
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+		key, _, _ := strings.Cut(line, "=")
+		switch strings.TrimSpace(key) {
+		case "sso_start_url", "sso_session":
+			current.SSO = true
+		}
+	}
+}
+
+// sharedFile returns the path of a shared AWS file, honouring the
+// environment variable the SDK uses to override it
+func sharedFile(envVar, name string) string {
+	if path := os.Getenv(envVar); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".aws", name)
+}
+
+// CheckCredentials resolves the client's credentials, returning an error
+// wrapping errors.ErrCredentials when they are missing or expired
+func (c *Client) CheckCredentials(ctx context.Context) error {
+	if c.config.Credentials == nil {
+		return fmt.Errorf("no credential provider configured: %w", awserrors.ErrCredentials)
+	}
+
+	creds, err := c.config.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, awserrors.ErrCredentials)
+	}
+	if creds.Expired() {
+		return fmt.Errorf("credentials from %s expired at %s: %w", creds.Source, creds.Expires.Format("2006-01-02 15:04:05"), awserrors.ErrCredentials)
+	}
+	return nil
+}
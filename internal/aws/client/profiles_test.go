@@ -0,0 +1,64 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProfiles(t *testing.T) {
+	config := `
+# shared config
+[default]
+region = us-east-1
+
+[profile dev]
+sso_session = corp
+sso_account_id = 123456789012
+
+[profile  legacy-sso ]
+sso_start_url = https://corp.awsapps.com/start
+
+[profile ci]
+role_arn = arn:aws:iam::123456789012:role/ci
+
+[sso-session corp]
+sso_start_url = https://corp.awsapps.com/start
+`
+	credentials := `
+[default]
+aws_access_key_id = AKIA...
+
+[static]
+aws_access_key_id = AKIA...
+`
+
+	profiles := make(map[string]*Profile)
+	parseProfiles(strings.NewReader(config), true, profiles)
+	parseProfiles(strings.NewReader(credentials), false, profiles)
+
+	assert.Equal(t, map[string]*Profile{
+		"default":    {Name: "default"},
+		"dev":        {Name: "dev", SSO: true},
+		"legacy-sso": {Name: "legacy-sso", SSO: true},
+		"ci":         {Name: "ci"},
+		"static":     {Name: "static"},
+	}, profiles)
+}
+
+func TestLoadProfiles(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config")
+	require.NoError(t, os.WriteFile(configPath, []byte("[profile b]\nsso_session = x\n[default]\n"), 0600))
+
+	t.Setenv("AWS_CONFIG_FILE", configPath)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "missing"))
+
+	profiles, err := LoadProfiles()
+	require.NoError(t, err)
+	assert.Equal(t, []Profile{{Name: "b", SSO: true}, {Name: "default"}}, profiles)
+}
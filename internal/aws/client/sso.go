@@ -0,0 +1,293 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc/types"
+)
+
+// deviceCodeGrant is the OAuth grant type of the device authorization flow
+const deviceCodeGrant = "urn:ietf:params:oauth:grant-type:device_code"
+
+// SSOConfig is how a profile signs in with IAM Identity Center
+type SSOConfig struct {
+	Profile string
+	// Session is the profile's sso_session; legacy profiles set the start
+	// URL and region on the profile itself and have none
+	Session  string
+	StartURL string
+	Region   string
+	Scopes   []string
+}
+
+// CacheKey names the cached token as the SDK looks it up: by session, or
+// by start URL for legacy profiles
+func (c *SSOConfig) CacheKey() string {
+	if c.Session != "" {
+		return c.Session
+	}
+	return c.StartURL
+}
+
+// LoadSSOConfig reads the SSO settings of a profile from ~/.aws/config (or
+// AWS_CONFIG_FILE)
+func LoadSSOConfig(profile string) (*SSOConfig, error) {
+	path := sharedFile("AWS_CONFIG_FILE", "config")
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer f.Close()
+
+	return parseSSOConfig(f, profile)
+}
+
+// parseSSOConfig finds the SSO settings of profile in a shared config file,
+// following its sso_session to the [sso-session NAME] section
+func parseSSOConfig(r io.Reader, profile string) (*SSOConfig, error) {
+	sections := make(map[string]map[string]string)
+	var current map[string]string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.Join(strings.Fields(line[1:len(line)-1]), " ")
+			if sections[name] == nil {
+				sections[name] = make(map[string]string)
+			}
+			current = sections[name]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			current[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	section := sections["profile "+profile]
+	if profile == "default" && section == nil {
+		section = sections["default"]
+	}
+	if section == nil {
+		return nil, fmt.Errorf("profile %q is not defined in the AWS config file", profile)
+	}
+
+	cfg := &SSOConfig{
+		Profile:  profile,
+		Session:  section["sso_session"],
+		StartURL: section["sso_start_url"],
+		Region:   section["sso_region"],
+	}
+	if cfg.Session != "" {
+		session := sections["sso-session "+cfg.Session]
+		if session == nil {
+			return nil, fmt.Errorf("profile %q uses sso_session %q, which is not defined", profile, cfg.Session)
+		}
+		cfg.StartURL = session["sso_start_url"]
+		cfg.Region = session["sso_region"]
+		for _, scope := range strings.Split(session["sso_registration_scopes"], ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				cfg.Scopes = append(cfg.Scopes, scope)
+			}
+		}
+	}
+
+	if cfg.StartURL == "" {
+		return nil, fmt.Errorf("profile %q does not sign in with AWS SSO (no sso_start_url or sso_session)", profile)
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("profile %q has no sso_region", profile)
+	}
+	return cfg, nil
+}
+
+// DeviceAuthorization is what the user confirms in the browser to finish
+// an SSO login
+type DeviceAuthorization struct {
+	UserCode                string
+	VerificationURI         string
+	VerificationURIComplete string // with the code filled in
+	ExpiresAt               time.Time
+}
+
+// ssoOIDCAPI is the part of the SSO OIDC API that SSOLogin uses
+type ssoOIDCAPI interface {
+	RegisterClient(ctx context.Context, params *ssooidc.RegisterClientInput, optFns ...func(*ssooidc.Options)) (*ssooidc.RegisterClientOutput, error)
+	StartDeviceAuthorization(ctx context.Context, params *ssooidc.StartDeviceAuthorizationInput, optFns ...func(*ssooidc.Options)) (*ssooidc.StartDeviceAuthorizationOutput, error)
+	CreateToken(ctx context.Context, params *ssooidc.CreateTokenInput, optFns ...func(*ssooidc.Options)) (*ssooidc.CreateTokenOutput, error)
+}
+
+// SSOLoginOptions customize SSOLogin
+type SSOLoginOptions struct {
+	// Authorize is called once the login waits for the user, to show the
+	// code and open the verification URL
+	Authorize func(DeviceAuthorization)
+
+	api   ssoOIDCAPI                                       // SSO OIDC client, replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error // waits between polls
+}
+
+// cachedToken is the SSO token cache file format shared with the AWS CLI
+// and SDKs
+type cachedToken struct {
+	StartURL              string `json:"startUrl"`
+	Region                string `json:"region"`
+	AccessToken           string `json:"accessToken"`
+	ExpiresAt             string `json:"expiresAt"`
+	ClientID              string `json:"clientId,omitempty"`
+	ClientSecret          string `json:"clientSecret,omitempty"`
+	RegistrationExpiresAt string `json:"registrationExpiresAt,omitempty"`
+	RefreshToken          string `json:"refreshToken,omitempty"`
+}
+
+// SSOLogin signs in with the OAuth device authorization flow, like 'aws
+// sso login', and caches the token where the SDK looks for it. It returns
+// when the token expires.
+func SSOLogin(ctx context.Context, cfg *SSOConfig, opts SSOLoginOptions) (time.Time, error) {
+	api := opts.api
+	if api == nil {
+		api = ssooidc.NewFromConfig(aws.Config{Region: cfg.Region, Credentials: aws.AnonymousCredentials{}})
+	}
+	sleep := opts.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+
+	registration, err := api.RegisterClient(ctx, &ssooidc.RegisterClientInput{
+		ClientName: aws.String("envy"),
+		ClientType: aws.String("public"),
+		Scopes:     cfg.Scopes,
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to register with AWS SSO: %w", err)
+	}
+
+	auth, err := api.StartDeviceAuthorization(ctx, &ssooidc.StartDeviceAuthorizationInput{
+		ClientId:     registration.ClientId,
+		ClientSecret: registration.ClientSecret,
+		StartUrl:     aws.String(cfg.StartURL),
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to start AWS SSO login: %w", err)
+	}
+
+	deadline := time.Now().Add(time.Duration(auth.ExpiresIn) * time.Second)
+	if opts.Authorize != nil {
+		opts.Authorize(DeviceAuthorization{
+			UserCode:                aws.ToString(auth.UserCode),
+			VerificationURI:         aws.ToString(auth.VerificationUri),
+			VerificationURIComplete: aws.ToString(auth.VerificationUriComplete),
+			ExpiresAt:               deadline,
+		})
+	}
+
+	interval := time.Duration(auth.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	for {
+		if err := sleep(ctx, interval); err != nil {
+			return time.Time{}, err
+		}
+
+		token, err := api.CreateToken(ctx, &ssooidc.CreateTokenInput{
+			ClientId:     registration.ClientId,
+			ClientSecret: registration.ClientSecret,
+			DeviceCode:   auth.DeviceCode,
+			GrantType:    aws.String(deviceCodeGrant),
+		})
+		var pending *types.AuthorizationPendingException
+		var slowDown *types.SlowDownException
+		switch {
+		case errors.As(err, &pending):
+			if time.Now().After(deadline) {
+				return time.Time{}, fmt.Errorf("the AWS SSO login was not confirmed in time")
+			}
+			continue
+		case errors.As(err, &slowDown):
+			interval += 5 * time.Second
+			continue
+		case err != nil:
+			return time.Time{}, fmt.Errorf("AWS SSO login failed: %w", err)
+		}
+
+		expiresAt := time.Now().Add(time.Duration(token.ExpiresIn) * time.Second).UTC()
+		cached := cachedToken{
+			StartURL:    cfg.StartURL,
+			Region:      cfg.Region,
+			AccessToken: aws.ToString(token.AccessToken),
+			ExpiresAt:   expiresAt.Format(time.RFC3339),
+		}
+		if cfg.Session != "" {
+			// Sessions can refresh the token without another login
+			cached.ClientID = aws.ToString(registration.ClientId)
+			cached.ClientSecret = aws.ToString(registration.ClientSecret)
+			cached.RegistrationExpiresAt = time.Unix(registration.ClientSecretExpiresAt, 0).UTC().Format(time.RFC3339)
+			cached.RefreshToken = aws.ToString(token.RefreshToken)
+		}
+		if err := writeCachedToken(cfg.CacheKey(), cached); err != nil {
+			return time.Time{}, err
+		}
+		return expiresAt, nil
+	}
+}
+
+// writeCachedToken replaces the cache file of key, readable only by the
+// user
+func writeCachedToken(key string, token cachedToken) error {
+	path, err := ssocreds.StandardCachedTokenFilepath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create the SSO token cache: %w", err)
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp-" + strconv.Itoa(os.Getpid())
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to cache the SSO token: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to cache the SSO token: %w", err)
+	}
+	return nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ssoConfigFile = `[default]
+region = us-east-1
+
+[profile legacy]
+sso_start_url = https://legacy.awsapps.com/start
+sso_region = us-east-1
+sso_account_id = 123456789012
+sso_role_name = Developer
+
+[profile dev]
+sso_session = acme
+sso_account_id = 123456789012
+
+[sso-session acme]
+sso_start_url = https://acme.awsapps.com/start
+sso_region = eu-west-1
+sso_registration_scopes = sso:account:access
+`
+
+func TestParseSSOConfig(t *testing.T) {
+	cfg, err := parseSSOConfig(strings.NewReader(ssoConfigFile), "dev")
+	require.NoError(t, err)
+	assert.Equal(t, &SSOConfig{Profile: "dev", Session: "acme", StartURL: "https://acme.awsapps.com/start", Region: "eu-west-1", Scopes: []string{"sso:account:access"}}, cfg)
+	assert.Equal(t, "acme", cfg.CacheKey())
+
+	cfg, err = parseSSOConfig(strings.NewReader(ssoConfigFile), "legacy")
+	require.NoError(t, err)
+	assert.Equal(t, "https://legacy.awsapps.com/start", cfg.CacheKey())
+
+	_, err = parseSSOConfig(strings.NewReader(ssoConfigFile), "default")
+	assert.ErrorContains(t, err, "does not sign in with AWS SSO")
+
+	_, err = parseSSOConfig(strings.NewReader(ssoConfigFile), "missing")
+	assert.ErrorContains(t, err, "not defined")
+}
+
+// fakeSSOOIDC confirms the login after pending polls
+type fakeSSOOIDC struct {
+	pending int
+	polls   int
+}
+
+func (f *fakeSSOOIDC) RegisterClient(ctx context.Context, params *ssooidc.RegisterClientInput, optFns ...func(*ssooidc.Options)) (*ssooidc.RegisterClientOutput, error) {
+	return &ssooidc.RegisterClientOutput{ClientId: aws.String("client"), ClientSecret: aws.String("secret"), ClientSecretExpiresAt: time.Now().Add(90 * 24 * time.Hour).Unix()}, nil
+}
+
+func (f *fakeSSOOIDC) StartDeviceAuthorization(ctx context.Context, params *ssooidc.StartDeviceAuthorizationInput, optFns ...func(*ssooidc.Options)) (*ssooidc.StartDeviceAuthorizationOutput, error) {
+	return &ssooidc.StartDeviceAuthorizationOutput{
+		DeviceCode:              aws.String("device"),
+		UserCode:                aws.String("ABCD-EFGH"),
+		VerificationUri:         aws.String("https://device.sso.eu-west-1.amazonaws.com/"),
+		VerificationUriComplete: aws.String("https://device.sso.eu-west-1.amazonaws.com/?user_code=ABCD-EFGH"),
+		ExpiresIn:               600,
+		Interval:                1,
+	}, nil
+}
+
+func (f *fakeSSOOIDC) CreateToken(ctx context.Context, params *ssooidc.CreateTokenInput, optFns ...func(*ssooidc.Options)) (*ssooidc.CreateTokenOutput, error) {
+	f.polls++
+	if f.polls <= f.pending {
+		return nil, &types.AuthorizationPendingException{}
+	}
+	return &ssooidc.CreateTokenOutput{AccessToken: aws.String("token"), RefreshToken: aws.String("refresh"), ExpiresIn: 3600}, nil
+}
+
+func TestSSOLogin(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	api := &fakeSSOOIDC{pending: 2}
+	var auth DeviceAuthorization
+	cfg := &SSOConfig{Profile: "dev", Session: "acme", StartURL: "https://acme.awsapps.com/start", Region: "eu-west-1"}
+	expires, err := SSOLogin(context.Background(), cfg, SSOLoginOptions{
+		Authorize: func(a DeviceAuthorization) { auth = a },
+		api:       api,
+		sleep:     func(context.Context, time.Duration) error { return nil },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ABCD-EFGH", auth.UserCode)
+	assert.Equal(t, 3, api.polls)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expires, time.Minute)
+
+	path, err := ssocreds.StandardCachedTokenFilepath("acme")
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var cached cachedToken
+	require.NoError(t, json.Unmarshal(data, &cached))
+	assert.Equal(t, "token", cached.AccessToken)
+	assert.Equal(t, "refresh", cached.RefreshToken)
+	assert.Equal(t, "client", cached.ClientID)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
@@ -0,0 +1,110 @@
+package aws
+
+import (
+	"context"
+	"strings"
+
+	"github.com/drapon/envy/internal/aws/errors"
+	"github.com/drapon/envy/internal/aws/parameter_store"
+	"github.com/drapon/envy/internal/cost"
+)
+
+// CostInventory lists what an environment stores, with the size of each
+// value, for cost estimates. Large values are listed by the secret holding
+// them.
+func (m *Manager) CostInventory(ctx context.Context, envName string) (*cost.Inventory, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	path := m.config.GetParameterPath(envName)
+	inv := &cost.Inventory{Environment: envName, Service: "parameter_store"}
+
+	if m.config.GetAWSService(envName) == "secrets_manager" || envConfig.UseSecretsManager {
+		inv.Service = "secrets_manager"
+		inv.Secrets, err = m.environmentSecrets(ctx, path)
+		return inv, err
+	}
+
+	metadata, err := m.paramStore.DescribeParametersByPath(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	params, err := m.paramStore.GetParametersByPath(ctx, path, true, true)
+	if err != nil {
+		return nil, errors.WrapAWSError(err, "get parameters by path", path)
+	}
+	sizes := make(map[string]int, len(params))
+	for _, param := range params {
+		sizes[param.Name] = len(param.Value)
+	}
+
+	prefix := path
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	for _, meta := range metadata {
+		if _, ok := m.LocalKey(strings.TrimPrefix(meta.Name, prefix)); !ok {
+			continue
+		}
+		size := sizes[meta.Name]
+		if meta.Tier == "Advanced" {
+			inv.AdvancedParameters = append(inv.AdvancedParameters, cost.Item{Name: meta.Name, Size: size})
+		} else {
+			inv.StandardParameters++
+		}
+		// Values read back over the limit were stored in Secrets Manager
+		if size > parameter_store.MaxValueSize {
+			inv.LargeValues = append(inv.LargeValues, cost.Item{Name: parameter_store.LargeValueSecretName(meta.Name), Size: size})
+		}
+	}
+	return inv, nil
+}
+
+// environmentSecrets returns the secret of a Secrets Manager environment
+// and the per-variable secrets written next to it by parallel pushes, or the
+// secret of each variable in per_variable mode
+func (m *Manager) environmentSecrets(ctx context.Context, path string) ([]cost.Item, error) {
+	if m.config.SecretPerVariable() {
+		secrets, err := m.listVariableSecrets(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(secrets))
+		for _, secret := range secrets {
+			names = append(names, secret.Name)
+		}
+		values, err := m.fetchVariableSecrets(ctx, path, names)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]cost.Item, 0, len(values))
+		for _, name := range sortedKeys(values) {
+			items = append(items, cost.Item{Name: name, Size: len(values[name])})
+		}
+		return items, nil
+	}
+
+	secretName := strings.ReplaceAll(strings.Trim(path, "/"), "/", "-")
+	secrets, err := m.secretsManager.ListSecrets(ctx, secretName)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []cost.Item
+	for _, secret := range secrets {
+		if secret.Name != secretName && !strings.HasPrefix(secret.Name, secretName+"-") {
+			continue
+		}
+		value, err := m.secretsManager.GetSecretString(ctx, secret.Name)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, cost.Item{Name: secret.Name, Size: len(value)})
+	}
+	return items, nil
+}
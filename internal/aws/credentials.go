@@ -0,0 +1,144 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/drapon/envy/internal/aws/client"
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/prompt"
+)
+
+// credentialsDocsURL explains how to configure AWS profiles
+const credentialsDocsURL = "https://docs.aws.amazon.com/cli/latest/userguide/cli-configure-files.html"
+
+// credentialAction is one choice in the credential failure prompt
+type credentialAction struct {
+	label   string
+	profile string // switch to this profile
+	ssoFor  string // log in to AWS SSO for this profile
+	docs    bool
+}
+
+// resolveCredentials checks that awsClient can obtain credentials. When they
+// are missing or expired and a terminal is attached, it offers to switch to
+// another profile, log in to AWS SSO or open the docs, then retries.
+func resolveCredentials(ctx context.Context, cfg *config.Config, awsClient *client.Client, opts client.Options) (*client.Client, error) {
+	for {
+		err := awsClient.CheckCredentials(ctx)
+		if err == nil {
+			return awsClient, nil
+		}
+		if opts.OIDC != nil && client.DetectOIDCProvider(opts.OIDC.Provider) != "" {
+			return nil, fmt.Errorf("assuming %s with the OIDC token: %w (check the role's trust policy)", opts.OIDC.RoleARN, err)
+		}
+		if !prompt.IsInteractive() {
+			return nil, fmt.Errorf("profile %q: %w (run 'envy login --profile %s' or set aws.profile in .envyrc)", profileName(opts.Profile), err, profileName(opts.Profile))
+		}
+
+		profiles, loadErr := client.LoadProfiles()
+		if loadErr != nil {
+			color.PrintWarningf("Could not read AWS profiles: %v", loadErr)
+		}
+
+		color.PrintWarningf("Profile %q credentials are missing or expired: %v", profileName(opts.Profile), err)
+		actions := credentialActions(profileName(opts.Profile), profiles)
+		labels := make([]string, len(actions))
+		for i, action := range actions {
+			labels[i] = action.label
+		}
+
+		selected, selectErr := prompt.InteractiveSelect("What would you like to do?", labels, 0)
+		if selectErr != nil || selected < 0 || selected == len(actions)-1 {
+			return nil, err
+		}
+
+		action := actions[selected]
+		switch {
+		case action.docs:
+			if openErr := prompt.OpenURL(credentialsDocsURL); openErr != nil {
+				color.PrintInfof("See %s", credentialsDocsURL)
+			}
+
+		case action.ssoFor != "":
+			if loginErr := LoginSSO(ctx, action.ssoFor, true); loginErr != nil {
+				color.PrintWarningf("%v", loginErr)
+			}
+
+		case action.profile != "":
+			opts.Profile = action.profile
+			newClient, newErr := client.NewClient(ctx, opts)
+			if newErr != nil {
+				return nil, fmt.Errorf("failed to create AWS client: %w", newErr)
+			}
+			awsClient = newClient
+			cfg.AWS.Profile = action.profile
+			color.PrintInfof("Using profile %q for this run (set aws.profile in .envyrc to keep it)", action.profile)
+		}
+	}
+}
+
+// credentialActions lists the choices for a failed profile: SSO login when
+// it is an SSO profile, every other profile, the docs, and cancel last
+func credentialActions(current string, profiles []client.Profile) []credentialAction {
+	var actions []credentialAction
+
+	for _, profile := range profiles {
+		if profile.Name == current && profile.SSO {
+			actions = append(actions, credentialAction{
+				label:  fmt.Sprintf("Log in to AWS SSO (envy login --profile %s)", current),
+				ssoFor: current,
+			})
+		}
+	}
+	for _, profile := range profiles {
+		if profile.Name != current {
+			actions = append(actions, credentialAction{
+				label:   fmt.Sprintf("Use profile %s", profile.Name),
+				profile: profile.Name,
+			})
+		}
+	}
+
+	actions = append(actions,
+		credentialAction{label: "Open AWS credentials documentation", docs: true},
+		credentialAction{label: "Cancel"},
+	)
+	return actions
+}
+
+// LoginSSO signs profile in to AWS SSO with the device authorization flow:
+// it shows the code to confirm, opens the verification page when browser
+// is set, and caches the token for the SDK and the AWS CLI
+func LoginSSO(ctx context.Context, profile string, browser bool) error {
+	ssoConfig, err := client.LoadSSOConfig(profileName(profile))
+	if err != nil {
+		return err
+	}
+
+	expires, err := client.SSOLogin(ctx, ssoConfig, client.SSOLoginOptions{
+		Authorize: func(auth client.DeviceAuthorization) {
+			color.PrintInfof("Confirm the code %s at %s", auth.UserCode, auth.VerificationURI)
+			if browser {
+				if err := prompt.OpenURL(auth.VerificationURIComplete); err == nil {
+					color.PrintInfof("Opened the page in your browser; waiting for confirmation...")
+					return
+				}
+			}
+			color.PrintInfof("Open %s to confirm; waiting...", auth.VerificationURIComplete)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	color.PrintSuccessf("Logged in to %s until %s", ssoConfig.StartURL, expires.Local().Format("2006-01-02 15:04"))
+	return nil
+}
+
+func profileName(profile string) string {
+	if profile == "" {
+		return "default"
+	}
+	return profile
+}
@@ -0,0 +1,48 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/aws/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentialActions(t *testing.T) {
+	profiles := []client.Profile{
+		{Name: "default"},
+		{Name: "dev", SSO: true},
+		{Name: "prod", SSO: true},
+	}
+
+	t.Run("sso profile offers login first", func(t *testing.T) {
+		actions := credentialActions("dev", profiles)
+
+		labels := make([]string, len(actions))
+		for i, action := range actions {
+			labels[i] = action.label
+		}
+		assert.Equal(t, []string{
+			"Log in to AWS SSO (envy login --profile dev)",
+			"Use profile default",
+			"Use profile prod",
+			"Open AWS credentials documentation",
+			"Cancel",
+		}, labels)
+		assert.Equal(t, "dev", actions[0].ssoFor)
+		assert.Equal(t, "prod", actions[2].profile)
+		assert.True(t, actions[3].docs)
+	})
+
+	t.Run("unknown profile lists all others", func(t *testing.T) {
+		actions := credentialActions("missing", profiles)
+		assert.Len(t, actions, 5)
+		assert.Empty(t, actions[0].ssoFor)
+		assert.Equal(t, "default", actions[0].profile)
+	})
+
+	t.Run("no profiles", func(t *testing.T) {
+		actions := credentialActions("default", nil)
+		assert.Len(t, actions, 2)
+		assert.Equal(t, "Cancel", actions[len(actions)-1].label)
+	})
+}
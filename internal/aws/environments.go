@@ -0,0 +1,70 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/drapon/envy/internal/aws/client"
+	secrets_manager "github.com/drapon/envy/internal/aws/secrets_manager"
+	"github.com/drapon/envy/internal/chaos"
+)
+
+// forEnvironment returns the manager to use for an environment: m itself,
+// or, for an environment with its own region, profile or role_arn, a
+// manager with a client for them, created on first use. Methods taking an
+// environment call it first, so one manager serves environments spread
+// over several accounts and regions.
+func (m *Manager) forEnvironment(envName string) (*Manager, error) {
+	if m.environment != "" || m.config == nil {
+		return m, nil
+	}
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil || !envConfig.OwnAccount() {
+		// Unknown environments are reported by the caller
+		return m, nil
+	}
+
+	m.environmentsMu.Lock()
+	defer m.environmentsMu.Unlock()
+
+	if em, ok := m.environments[envName]; ok {
+		return em, nil
+	}
+
+	opts := client.Options{
+		Region:     m.config.GetRegion(envName),
+		Profile:    envConfig.Profile,
+		Chaos:      chaos.Global(),
+		OIDC:       oidcOptions(m.config),
+		RoleARN:    m.config.GetRoleARN(envName),
+		ExternalID: m.config.AWS.ExternalID,
+		MFASerial:  m.config.AWS.MFASerial,
+	}
+	if opts.Profile == "" {
+		opts.Profile = m.config.AWS.Profile
+		if envConfig.RoleARN == "" && m.client != nil {
+			// Only the region differs: the same credentials, and no
+			// second MFA code
+			opts.Credentials = m.client.Config().Credentials
+		}
+	}
+	awsClient, err := client.NewClient(context.Background(), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS client for environment %s: %w", envName, err)
+	}
+
+	secretsManager := secrets_manager.NewManager(awsClient)
+	em := &Manager{
+		client:         awsClient,
+		paramStore:     newParameterStore(m.config, awsClient, secretsManager),
+		secretsManager: secretsManager,
+		config:         m.config,
+		isSensitive:    m.IsSensitive, // follows MarkSensitive on m
+		environment:    envName,
+	}
+	if m.environments == nil {
+		m.environments = make(map[string]*Manager)
+	}
+	m.environments[envName] = em
+	return em, nil
+}
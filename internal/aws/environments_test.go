@@ -0,0 +1,42 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForEnvironment(t *testing.T) {
+	cfg := &config.Config{
+		AWS: config.AWSConfig{Region: "us-east-1", Service: "parameter_store"},
+		Environments: map[string]config.Environment{
+			"dev":  {Files: []string{".env.dev"}},
+			"prod": {Files: []string{".env.prod"}, Region: "eu-west-1", RoleARN: "arn:aws:iam::210987654321:role/envy"},
+		},
+	}
+	m := &Manager{config: cfg}
+
+	same, err := m.forEnvironment("dev")
+	require.NoError(t, err)
+	assert.Same(t, m, same, "environments without overrides use the default client")
+
+	same, err = m.forEnvironment("unknown")
+	require.NoError(t, err)
+	assert.Same(t, m, same)
+
+	prod, err := m.forEnvironment("prod")
+	require.NoError(t, err)
+	assert.NotSame(t, m, prod)
+	assert.Equal(t, "eu-west-1", prod.client.Region())
+	assert.Equal(t, "arn:aws:iam::210987654321:role/envy", prod.client.RoleARN())
+
+	again, err := m.forEnvironment("prod")
+	require.NoError(t, err)
+	assert.Same(t, prod, again, "the environment's manager is reused")
+
+	scoped, err := prod.forEnvironment("dev")
+	require.NoError(t, err)
+	assert.Same(t, prod, scoped, "an environment's manager is not redirected")
+}
@@ -20,6 +20,7 @@ var (
 	ErrSecretAlreadyExists    = errors.New("secret already exists")
 	ErrRateLimitExceeded      = errors.New("rate limit exceeded")
 	ErrInvalidRequest         = errors.New("invalid request")
+	ErrCredentials            = errors.New("AWS credentials are missing or expired")
 )
 
 // IsNotFoundError checks if the error is a not found error
@@ -80,6 +81,22 @@ func IsRateLimitError(err error) bool {
 		errors.Is(err, ErrRateLimitExceeded)
 }
 
+// IsCredentialError checks if the error is due to missing or expired credentials
+func IsCredentialError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	errStr := err.Error()
+	return strings.Contains(errStr, "ExpiredToken") ||
+		strings.Contains(errStr, "InvalidClientTokenId") ||
+		strings.Contains(errStr, "UnrecognizedClientException") ||
+		strings.Contains(errStr, "failed to retrieve credentials") ||
+		strings.Contains(errStr, "failed to refresh cached credentials") ||
+		strings.Contains(errStr, "SSO session") ||
+		errors.Is(err, ErrCredentials)
+}
+
 // WrapAWSError wraps AWS errors with more context
 func WrapAWSError(err error, operation string, resource string) error {
 	if err == nil {
@@ -115,6 +132,10 @@ func WrapAWSError(err error, operation string, resource string) error {
 		return fmt.Errorf("%s failed for %s: %w (please retry after a moment)", operation, resource, ErrRateLimitExceeded)
 	}
 
+	if IsCredentialError(err) {
+		return fmt.Errorf("%s failed for %s: %w (run 'envy login' or choose another profile)", operation, resource, ErrCredentials)
+	}
+
 	// Default wrapping
 	return fmt.Errorf("%s failed for %s: %w", operation, resource, err)
 }
@@ -149,6 +170,8 @@ func FormatError(err error) string {
 		return "Resource already exists. Use --force to overwrite."
 	case IsRateLimitError(err):
 		return "Rate limit exceeded. Please wait a moment and try again."
+	case IsCredentialError(err):
+		return "AWS credentials are missing or expired. Run 'envy login' or set aws.profile in .envyrc."
 	default:
 		return err.Error()
 	}
@@ -2,6 +2,7 @@ package errors
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
@@ -143,6 +144,58 @@ func TestIsRateLimitError(t *testing.T) {
 	}
 }
 
+func TestIsCredentialError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "expired token",
+			err: &smithy.GenericAPIError{
+				Code: "ExpiredTokenException",
+			},
+			want: true,
+		},
+		{
+			name: "invalid client token",
+			err: &smithy.GenericAPIError{
+				Code: "InvalidClientTokenId",
+			},
+			want: true,
+		},
+		{
+			name: "credential provider failure",
+			err:  errors.New("failed to retrieve credentials: the SSO session has expired or is invalid"),
+			want: true,
+		},
+		{
+			name: "wrapped sentinel",
+			err:  fmt.Errorf("profile dev: %w", ErrCredentials),
+			want: true,
+		},
+		{
+			name: "access denied",
+			err: &smithy.GenericAPIError{
+				Code: "AccessDeniedException",
+			},
+			want: false,
+		},
+		{
+			name: "nil error",
+			err:  nil,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := IsCredentialError(tt.err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestExtractAWSErrorCode(t *testing.T) {
 	tests := []struct {
 		name string
@@ -0,0 +1,236 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+const (
+	eventBridgeService     = "events"
+	eventBridgeContentType = "application/x-amz-json-1.1"
+)
+
+// APIError is returned when EventBridge rejects a request
+type APIError struct {
+	Service    string
+	Operation  string
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s %s failed (%d %s): %s", e.Service, e.Operation, e.StatusCode, e.Code, e.Message)
+}
+
+// ErrorCode lets the SDK retryer recognize throttling codes
+func (e *APIError) ErrorCode() string {
+	return e.Code
+}
+
+// HTTPStatusCode lets the SDK retryer recognize retryable status codes
+func (e *APIError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
+// ruleClient calls the two EventBridge operations needed by setup over the
+// JSON protocol, signed with the SDK's SigV4 signer. It uses the endpoint,
+// credentials and retryer of the AWS config.
+type ruleClient struct {
+	config     aws.Config
+	signer     *v4.Signer
+	httpClient *http.Client
+}
+
+func newRuleClient(cfg aws.Config) *ruleClient {
+	return &ruleClient{
+		config:     cfg,
+		signer:     v4.NewSigner(),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// PutRule creates or updates an EventBridge rule on the default event bus
+func (c *ruleClient) PutRule(ctx context.Context, name, eventPattern, description string) (string, error) {
+	input := map[string]string{
+		"Name":         name,
+		"EventPattern": eventPattern,
+		"Description":  description,
+		"State":        "ENABLED",
+	}
+
+	var output struct {
+		RuleArn string `json:"RuleArn"`
+	}
+	if err := c.call(ctx, "AWSEvents.PutRule", input, &output); err != nil {
+		return "", err
+	}
+
+	return output.RuleArn, nil
+}
+
+// PutTarget attaches a target to an EventBridge rule
+func (c *ruleClient) PutTarget(ctx context.Context, rule, targetID, targetArn string) error {
+	input := map[string]interface{}{
+		"Rule": rule,
+		"Targets": []map[string]string{
+			{"Id": targetID, "Arn": targetArn},
+		},
+	}
+
+	var output struct {
+		FailedEntryCount int `json:"FailedEntryCount"`
+		FailedEntries    []struct {
+			ErrorCode    string `json:"ErrorCode"`
+			ErrorMessage string `json:"ErrorMessage"`
+		} `json:"FailedEntries"`
+	}
+	if err := c.call(ctx, "AWSEvents.PutTargets", input, &output); err != nil {
+		return err
+	}
+
+	if output.FailedEntryCount > 0 && len(output.FailedEntries) > 0 {
+		entry := output.FailedEntries[0]
+		return fmt.Errorf("failed to attach target to rule %s: %s: %s", rule, entry.ErrorCode, entry.ErrorMessage)
+	}
+
+	return nil
+}
+
+// call performs a signed request, retrying as the config's retryer allows
+func (c *ruleClient) call(ctx context.Context, target string, input, output interface{}) error {
+	var retryer aws.Retryer = retry.NewStandard()
+	if c.config.Retryer != nil {
+		retryer = c.config.Retryer()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := c.do(ctx, target, input, output)
+		if err == nil || !retryer.IsErrorRetryable(err) || attempt >= retryer.MaxAttempts() {
+			return err
+		}
+		delay, derr := retryer.RetryDelay(attempt, err)
+		if derr != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *ruleClient) do(ctx context.Context, target string, input, output interface{}) error {
+	operation := target[strings.Index(target, ".")+1:]
+
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", operation, err)
+	}
+
+	region := c.config.Region
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint(), bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", operation, err)
+	}
+	req.Header.Set("Content-Type", eventBridgeContentType)
+	req.Header.Set("X-Amz-Target", target)
+
+	creds, err := c.config.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	hash := sha256.Sum256(payload)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), eventBridgeService, region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign %s request: %w", operation, err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", operation, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", operation, err)
+	}
+
+	if resp.StatusCode >= 300 {
+		return parseAPIError(eventBridgeService, operation, resp.StatusCode, body)
+	}
+
+	if output == nil || len(body) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(body, output); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", operation, err)
+	}
+
+	return nil
+}
+
+// endpoint honours AWS_ENDPOINT_URL_EVENTBRIDGE and the config's base
+// endpoint (AWS_ENDPOINT_URL) before the regional endpoint of the partition
+func (c *ruleClient) endpoint() string {
+	if url := os.Getenv("AWS_ENDPOINT_URL_EVENTBRIDGE"); url != "" {
+		return url
+	}
+	if c.config.BaseEndpoint != nil && *c.config.BaseEndpoint != "" {
+		return *c.config.BaseEndpoint
+	}
+
+	suffix := "amazonaws.com"
+	if Partition(c.config.Region) == "aws-cn" {
+		suffix = "amazonaws.com.cn"
+	}
+	return fmt.Sprintf("https://%s.%s.%s/", eventBridgeService, c.config.Region, suffix)
+}
+
+// parseAPIError converts a JSON protocol error body into an APIError
+func parseAPIError(service, operation string, statusCode int, body []byte) error {
+	var payload struct {
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+		MessageUpper string `json:"Message"`
+	}
+	_ = json.Unmarshal(body, &payload) // Fall back to the raw body below
+
+	code := payload.Type
+	// Error types may be namespaced, e.g. "com.amazonaws.events#ResourceNotFoundException"
+	if idx := strings.LastIndex(code, "#"); idx != -1 {
+		code = code[idx+1:]
+	}
+
+	message := payload.Message
+	if message == "" {
+		message = payload.MessageUpper
+	}
+	if message == "" {
+		message = strings.TrimSpace(string(body))
+	}
+
+	return &APIError{
+		Service:    service,
+		Operation:  operation,
+		StatusCode: statusCode,
+		Code:       code,
+		Message:    message,
+	}
+}
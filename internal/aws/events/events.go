@@ -0,0 +1,385 @@
+// Package events subscribes to Parameter Store change notifications.
+//
+// An EventBridge rule forwards the changes under an environment's path to an
+// SNS topic. Every watcher subscribes a queue of its own to the topic, so
+// concurrent watchers each receive every change, and removes it on exit.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/drapon/envy/internal/aws/client"
+)
+
+const (
+	// MaxWaitTime is the longest long-poll SQS allows for ReceiveMessage
+	MaxWaitTime = 20 * time.Second
+
+	// ruleTargetID identifies the topic among the rule's targets
+	ruleTargetID = "envy-watch-topic"
+)
+
+// ErrNotSetUp is returned when the topic of a subscription does not exist
+var ErrNotSetUp = stderrors.New("change events are not set up")
+
+// Client calls the SQS, SNS and EventBridge APIs used by watch mode
+type Client struct {
+	sqs   *sqs.Client
+	sns   *sns.Client
+	rules *ruleClient
+}
+
+// NewClient creates an events client sharing the configuration, retries and
+// failure injection of an AWS client
+func NewClient(c *client.Client) *Client {
+	return &Client{
+		sqs:   c.SQS(),
+		sns:   c.SNS(),
+		rules: newRuleClient(c.Config()),
+	}
+}
+
+// Topic describes the EventBridge rule and SNS topic of an environment
+type Topic struct {
+	RuleName string
+	RuleArn  string
+	TopicArn string
+}
+
+// Subscription is the queue of one watcher, subscribed to a topic
+type Subscription struct {
+	QueueURL        string
+	QueueArn        string
+	SubscriptionArn string
+}
+
+// Message represents a message received from SQS
+type Message struct {
+	MessageID     string
+	ReceiptHandle string
+	Body          string
+}
+
+// ParameterChange is a Parameter Store change parsed from an EventBridge event
+type ParameterChange struct {
+	Name      string
+	Operation string // Create, Update, Delete, LabelParameterVersion
+	Time      time.Time
+}
+
+// SetupParameterStoreEvents creates (or updates) an SNS topic and an
+// EventBridge rule forwarding Parameter Store changes under pathPrefix to it.
+// All calls are idempotent, so running setup twice is safe.
+func (c *Client) SetupParameterStoreEvents(ctx context.Context, name, pathPrefix string) (*Topic, error) {
+	topic, err := c.sns.CreateTopic(ctx, &sns.CreateTopicInput{Name: aws.String(name)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create topic %s: %w", name, err)
+	}
+	topicArn := aws.ToString(topic.TopicArn)
+
+	pattern, err := ParameterStorePattern(pathPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	ruleArn, err := c.rules.PutRule(ctx, name, pattern,
+		fmt.Sprintf("envy watch: Parameter Store changes under %s", pathPrefix))
+	if err != nil {
+		return nil, err
+	}
+
+	// Allow only this rule to publish to the topic
+	_, err = c.sns.SetTopicAttributes(ctx, &sns.SetTopicAttributesInput{
+		TopicArn:       aws.String(topicArn),
+		AttributeName:  aws.String("Policy"),
+		AttributeValue: aws.String(TopicPolicy(topicArn, ruleArn)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set policy of topic %s: %w", name, err)
+	}
+
+	if err := c.rules.PutTarget(ctx, name, ruleTargetID, topicArn); err != nil {
+		return nil, err
+	}
+
+	return &Topic{RuleName: name, RuleArn: ruleArn, TopicArn: topicArn}, nil
+}
+
+// Subscribe creates a queue for one watcher and subscribes it to the topic
+// set up under name. It returns ErrNotSetUp if the topic does not exist.
+// The queue must be removed with Unsubscribe.
+func (c *Client) Subscribe(ctx context.Context, name, accountID string) (*Subscription, error) {
+	topicArn, err := c.topicArn(ctx, name, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	queueName, err := QueueName(name)
+	if err != nil {
+		return nil, err
+	}
+	queue, err := c.sqs.CreateQueue(ctx, &sqs.CreateQueueInput{
+		QueueName: aws.String(queueName),
+		Attributes: map[string]string{
+			// Change notifications are only useful for a short while
+			string(sqstypes.QueueAttributeNameMessageRetentionPeriod): "3600",
+		},
+		Tags: map[string]string{"envy:watch": name},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create queue %s: %w", queueName, err)
+	}
+	sub := &Subscription{QueueURL: aws.ToString(queue.QueueUrl)}
+
+	if err := c.subscribe(ctx, sub, topicArn); err != nil {
+		_ = c.Unsubscribe(context.WithoutCancel(ctx), sub)
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (c *Client) subscribe(ctx context.Context, sub *Subscription, topicArn string) error {
+	attrs, err := c.sqs.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(sub.QueueURL),
+		AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameQueueArn},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read queue attributes: %w", err)
+	}
+	sub.QueueArn = attrs.Attributes[string(sqstypes.QueueAttributeNameQueueArn)]
+	if sub.QueueArn == "" {
+		return fmt.Errorf("queue %s has no QueueArn attribute", sub.QueueURL)
+	}
+
+	// Allow only the topic to deliver to the queue
+	_, err = c.sqs.SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl: aws.String(sub.QueueURL),
+		Attributes: map[string]string{
+			string(sqstypes.QueueAttributeNamePolicy): QueuePolicy(sub.QueueArn, topicArn),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set queue policy: %w", err)
+	}
+
+	// Raw delivery keeps the EventBridge event as the message body
+	out, err := c.sns.Subscribe(ctx, &sns.SubscribeInput{
+		TopicArn:              aws.String(topicArn),
+		Protocol:              aws.String("sqs"),
+		Endpoint:              aws.String(sub.QueueArn),
+		Attributes:            map[string]string{"RawMessageDelivery": "true"},
+		ReturnSubscriptionArn: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe queue to topic: %w", err)
+	}
+	sub.SubscriptionArn = aws.ToString(out.SubscriptionArn)
+	return nil
+}
+
+// Unsubscribe removes a watcher's subscription and queue. It is best
+// effort: a queue left behind only stops receiving messages.
+func (c *Client) Unsubscribe(ctx context.Context, sub *Subscription) error {
+	var errs []error
+	if sub.SubscriptionArn != "" {
+		_, err := c.sns.Unsubscribe(ctx, &sns.UnsubscribeInput{SubscriptionArn: aws.String(sub.SubscriptionArn)})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to unsubscribe: %w", err))
+		}
+	}
+	if sub.QueueURL != "" {
+		_, err := c.sqs.DeleteQueue(ctx, &sqs.DeleteQueueInput{QueueUrl: aws.String(sub.QueueURL)})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete queue %s: %w", sub.QueueURL, err))
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+// ReceiveMessages long-polls a queue for up to waitTime
+func (c *Client) ReceiveMessages(ctx context.Context, queueURL string, waitTime time.Duration) ([]Message, error) {
+	if waitTime > MaxWaitTime {
+		waitTime = MaxWaitTime
+	}
+
+	out, err := c.sqs.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(queueURL),
+		MaxNumberOfMessages: 10,
+		WaitTimeSeconds:     int32(waitTime.Seconds()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]Message, 0, len(out.Messages))
+	for _, msg := range out.Messages {
+		messages = append(messages, Message{
+			MessageID:     aws.ToString(msg.MessageId),
+			ReceiptHandle: aws.ToString(msg.ReceiptHandle),
+			Body:          aws.ToString(msg.Body),
+		})
+	}
+	return messages, nil
+}
+
+// DeleteMessage removes a processed message from a queue
+func (c *Client) DeleteMessage(ctx context.Context, queueURL, receiptHandle string) error {
+	_, err := c.sqs.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(queueURL),
+		ReceiptHandle: aws.String(receiptHandle),
+	})
+	return err
+}
+
+// topicArn returns the ARN of the topic set up under name, or ErrNotSetUp
+func (c *Client) topicArn(ctx context.Context, name, accountID string) (string, error) {
+	region := c.sns.Options().Region
+	arn := fmt.Sprintf("arn:%s:sns:%s:%s:%s", Partition(region), region, accountID, name)
+
+	_, err := c.sns.GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{TopicArn: aws.String(arn)})
+	var notFound *snstypes.NotFoundException
+	if stderrors.As(err, &notFound) {
+		return "", fmt.Errorf("%w: topic %s does not exist", ErrNotSetUp, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read topic %s: %w", name, err)
+	}
+	return arn, nil
+}
+
+// QueueName returns a unique queue name for one watcher of the topic name,
+// valid for SQS
+func QueueName(name string) (string, error) {
+	random := make([]byte, 4)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate queue name: %w", err)
+	}
+	// SQS limits queue names to 80 characters
+	if len(name) > 71 {
+		name = name[:71]
+	}
+	return name + "-" + hex.EncodeToString(random), nil
+}
+
+// AccountID extracts the account from an ARN
+func AccountID(arn string) (string, error) {
+	parts := strings.Split(arn, ":")
+	if len(parts) < 6 || parts[4] == "" {
+		return "", fmt.Errorf("invalid ARN %q", arn)
+	}
+	return parts[4], nil
+}
+
+// ParameterStorePattern returns an EventBridge event pattern matching
+// Parameter Store changes for parameters under pathPrefix
+func ParameterStorePattern(pathPrefix string) (string, error) {
+	if !strings.HasSuffix(pathPrefix, "/") {
+		pathPrefix = pathPrefix + "/"
+	}
+
+	pattern := map[string]interface{}{
+		"source":      []string{"aws.ssm"},
+		"detail-type": []string{"Parameter Store Change"},
+		"detail": map[string]interface{}{
+			"name": []map[string]string{
+				{"prefix": pathPrefix},
+			},
+		},
+	}
+
+	data, err := json.Marshal(pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to build event pattern: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// TopicPolicy returns an SNS access policy that lets a single EventBridge
+// rule publish to the topic
+func TopicPolicy(topicArn, ruleArn string) string {
+	return sendPolicy("AllowEnvyWatchRule", "events.amazonaws.com", "sns:Publish", topicArn, ruleArn)
+}
+
+// QueuePolicy returns an SQS access policy that lets a single SNS topic
+// send messages to the queue
+func QueuePolicy(queueArn, topicArn string) string {
+	return sendPolicy("AllowEnvyWatchTopic", "sns.amazonaws.com", "sqs:SendMessage", queueArn, topicArn)
+}
+
+func sendPolicy(sid, service, action, resource, sourceArn string) string {
+	policy := map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{
+				"Sid":       sid,
+				"Effect":    "Allow",
+				"Principal": map[string]string{"Service": service},
+				"Action":    action,
+				"Resource":  resource,
+				"Condition": map[string]interface{}{
+					"ArnEquals": map[string]string{"aws:SourceArn": sourceArn},
+				},
+			},
+		},
+	}
+
+	// Marshalling a map of plain values cannot fail
+	data, _ := json.Marshal(policy)
+	return string(data)
+}
+
+// ParseParameterChange parses the body of an EventBridge "Parameter Store
+// Change" event as delivered to SQS
+func ParseParameterChange(body string) (*ParameterChange, error) {
+	var event struct {
+		Source     string    `json:"source"`
+		DetailType string    `json:"detail-type"`
+		Time       time.Time `json:"time"`
+		Detail     struct {
+			Name      string `json:"name"`
+			Operation string `json:"operation"`
+		} `json:"detail"`
+	}
+
+	if err := json.Unmarshal([]byte(body), &event); err != nil {
+		return nil, fmt.Errorf("invalid event payload: %w", err)
+	}
+
+	if event.Source != "aws.ssm" || event.DetailType != "Parameter Store Change" {
+		return nil, fmt.Errorf("unexpected event %q from %q", event.DetailType, event.Source)
+	}
+
+	if event.Detail.Name == "" {
+		return nil, fmt.Errorf("event has no parameter name")
+	}
+
+	return &ParameterChange{
+		Name:      event.Detail.Name,
+		Operation: event.Detail.Operation,
+		Time:      event.Time,
+	}, nil
+}
+
+// Partition returns the ARN partition of a region
+func Partition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	}
+	return "aws"
+}
@@ -0,0 +1,131 @@
+package events
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseParameterChange(t *testing.T) {
+	body := `{
+		"version": "0",
+		"id": "6a7e8feb-b491-4cf7-a9f1-bf3703467718",
+		"detail-type": "Parameter Store Change",
+		"source": "aws.ssm",
+		"account": "123456789012",
+		"time": "2024-05-01T12:00:00Z",
+		"region": "us-east-1",
+		"resources": ["arn:aws:ssm:us-east-1:123456789012:parameter/myapp/dev/API_KEY"],
+		"detail": {
+			"operation": "Update",
+			"name": "/myapp/dev/API_KEY",
+			"type": "SecureString"
+		}
+	}`
+
+	change, err := ParseParameterChange(body)
+	require.NoError(t, err)
+	assert.Equal(t, "/myapp/dev/API_KEY", change.Name)
+	assert.Equal(t, "Update", change.Operation)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), change.Time)
+}
+
+func TestParseParameterChangeInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid json", "not json"},
+		{"other source", `{"source":"aws.ec2","detail-type":"Parameter Store Change","detail":{"name":"/a"}}`},
+		{"missing name", `{"source":"aws.ssm","detail-type":"Parameter Store Change","detail":{}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseParameterChange(tt.body)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParameterStorePattern(t *testing.T) {
+	pattern, err := ParameterStorePattern("/myapp/dev")
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(pattern), &decoded))
+	assert.Equal(t, []interface{}{"aws.ssm"}, decoded["source"])
+
+	detail := decoded["detail"].(map[string]interface{})
+	prefix := detail["name"].([]interface{})[0].(map[string]interface{})["prefix"]
+	assert.Equal(t, "/myapp/dev/", prefix)
+}
+
+func TestPolicies(t *testing.T) {
+	policy := QueuePolicy("arn:aws:sqs:us-east-1:123:q", "arn:aws:sns:us-east-1:123:t")
+	assert.Contains(t, policy, `"aws:SourceArn":"arn:aws:sns:us-east-1:123:t"`)
+	assert.Contains(t, policy, `"Resource":"arn:aws:sqs:us-east-1:123:q"`)
+	assert.Contains(t, policy, `"Service":"sns.amazonaws.com"`)
+
+	policy = TopicPolicy("arn:aws:sns:us-east-1:123:t", "arn:aws:events:us-east-1:123:rule/t")
+	assert.Contains(t, policy, `"aws:SourceArn":"arn:aws:events:us-east-1:123:rule/t"`)
+	assert.Contains(t, policy, `"Action":"sns:Publish"`)
+}
+
+func TestQueueName(t *testing.T) {
+	a, err := QueueName("envy-myapp-dev-changes")
+	require.NoError(t, err)
+	b, err := QueueName("envy-myapp-dev-changes")
+	require.NoError(t, err)
+
+	assert.Regexp(t, `^envy-myapp-dev-changes-[0-9a-f]{8}$`, a)
+	assert.NotEqual(t, a, b, "each watcher needs its own queue")
+
+	long, err := QueueName(strings.Repeat("x", 100))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(long), 80)
+}
+
+func TestAccountID(t *testing.T) {
+	id, err := AccountID("arn:aws:sts::123456789012:assumed-role/dev/alice")
+	require.NoError(t, err)
+	assert.Equal(t, "123456789012", id)
+
+	_, err = AccountID("not-an-arn")
+	assert.Error(t, err)
+}
+
+func TestPartition(t *testing.T) {
+	assert.Equal(t, "aws", Partition("us-east-1"))
+	assert.Equal(t, "aws-cn", Partition("cn-north-1"))
+	assert.Equal(t, "aws-us-gov", Partition("us-gov-west-1"))
+}
+
+func TestParseAPIError(t *testing.T) {
+	err := parseAPIError("events", "PutRule", 400,
+		[]byte(`{"__type":"com.amazonaws.events#ThrottlingException","message":"Rate exceeded"}`))
+
+	apiErr, ok := err.(*APIError)
+	require.True(t, ok)
+	assert.Equal(t, "ThrottlingException", apiErr.Code)
+	assert.Equal(t, "Rate exceeded", apiErr.Message)
+	assert.True(t, retry.NewStandard().IsErrorRetryable(err), "throttling should be retried")
+}
+
+func TestRuleClientEndpoint(t *testing.T) {
+	t.Setenv("AWS_ENDPOINT_URL_EVENTBRIDGE", "")
+	c := newRuleClient(aws.Config{Region: "cn-north-1"})
+	assert.Equal(t, "https://events.cn-north-1.amazonaws.com.cn/", c.endpoint())
+
+	c = newRuleClient(aws.Config{Region: "us-east-1", BaseEndpoint: aws.String("http://localhost:4566")})
+	assert.Equal(t, "http://localhost:4566", c.endpoint())
+
+	t.Setenv("AWS_ENDPOINT_URL_EVENTBRIDGE", "http://localhost:4010")
+	assert.Equal(t, "http://localhost:4010", c.endpoint())
+}
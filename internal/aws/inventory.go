@@ -0,0 +1,123 @@
+package aws
+
+import (
+	"context"
+	"strings"
+
+	"github.com/drapon/envy/internal/aws/errors"
+)
+
+// Resource is a parameter or secret holding an environment's variables,
+// described for audits without its value
+type Resource struct {
+	Name    string // parameter or secret name
+	Key     string // variable name; empty for a Secrets Manager secret holding them all
+	Service string // parameter_store or secrets_manager
+	Type    string // parameter type: String, StringList or SecureString
+
+	KMSKeyID        string // empty for the AWS managed key
+	Tags            map[string]string
+	RotationEnabled bool
+	RotationDays    int64
+}
+
+// DescribeEnvironment lists the resources holding an environment's
+// variables with their encryption, tags and rotation settings
+func (m *Manager) DescribeEnvironment(ctx context.Context, envName string) ([]*Resource, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	service := m.config.GetAWSService(envName)
+	path := m.config.GetParameterPath(envName)
+
+	if (service == "secrets_manager" || envConfig.UseSecretsManager) && m.config.SecretPerVariable() {
+		return m.describeVariableSecrets(ctx, path)
+	}
+	if service == "secrets_manager" || envConfig.UseSecretsManager {
+		secretName := strings.ReplaceAll(strings.Trim(path, "/"), "/", "-")
+		secret, err := m.secretsManager.DescribeSecret(ctx, secretName)
+		if err != nil {
+			if errors.IsNotFoundError(err) {
+				return nil, nil
+			}
+			return nil, errors.WrapAWSError(err, "describe secret", secretName)
+		}
+		return []*Resource{{
+			Name:            secret.Name,
+			Service:         "secrets_manager",
+			KMSKeyID:        secret.KMSKeyID,
+			Tags:            secret.Tags,
+			RotationEnabled: secret.RotationEnabled,
+			RotationDays:    secret.RotationDays,
+		}}, nil
+	}
+
+	metadata, err := m.paramStore.DescribeParametersByPath(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := path
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	resources := make([]*Resource, 0, len(metadata))
+	for _, meta := range metadata {
+		key, ok := m.LocalKey(strings.TrimPrefix(meta.Name, prefix))
+		if !ok {
+			continue
+		}
+		tags, err := m.paramStore.ListTags(ctx, meta.Name)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, &Resource{
+			Name:     meta.Name,
+			Key:      key,
+			Service:  "parameter_store",
+			Type:     meta.Type,
+			KMSKeyID: meta.KeyID,
+			Tags:     tags,
+		})
+	}
+	return resources, nil
+}
+
+// describeVariableSecrets describes each secret of an environment stored
+// one secret per variable
+func (m *Manager) describeVariableSecrets(ctx context.Context, path string) ([]*Resource, error) {
+	secrets, err := m.listVariableSecrets(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := VariableSecretPrefix(path)
+	resources := make([]*Resource, 0, len(secrets))
+	for _, entry := range secrets {
+		key, ok := m.LocalKey(strings.TrimPrefix(entry.Name, prefix))
+		if !ok {
+			continue
+		}
+		secret, err := m.secretsManager.DescribeSecret(ctx, entry.Name)
+		if err != nil {
+			return nil, errors.WrapAWSError(err, "describe secret", entry.Name)
+		}
+		resources = append(resources, &Resource{
+			Name:            secret.Name,
+			Key:             key,
+			Service:         "secrets_manager",
+			KMSKeyID:        secret.KMSKeyID,
+			Tags:            secret.Tags,
+			RotationEnabled: secret.RotationEnabled,
+			RotationDays:    secret.RotationDays,
+		})
+	}
+	return resources, nil
+}
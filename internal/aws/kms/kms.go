@@ -0,0 +1,139 @@
+// Package kms signs and verifies with asymmetric AWS KMS keys, for signing
+// the artifacts envy writes, and encrypts data keys with symmetric ones.
+package kms
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+
+	"github.com/drapon/envy/internal/aws/client"
+)
+
+// PublicKey is the public half of an asymmetric KMS key
+type PublicKey struct {
+	// KeyID is the ARN of the key
+	KeyID string
+	// DER is the key in DER-encoded SubjectPublicKeyInfo form
+	DER               []byte
+	KeySpec           string
+	SigningAlgorithms []string
+}
+
+// Client calls KMS with the configuration, retries and failure injection
+// of an AWS client. Keys given by ARN are used in the ARN's region.
+type Client struct {
+	api *kms.Client
+}
+
+// NewClient returns a KMS client using the credentials of c
+func NewClient(c *client.Client) *Client {
+	return &Client{api: c.KMS()}
+}
+
+// Sign signs a SHA-256 digest with a key, given by ID, ARN or alias. It
+// returns the signature and the ARN of the key.
+func (c *Client) Sign(ctx context.Context, keyID, algorithm string, digest []byte) ([]byte, string, error) {
+	out, err := c.api.Sign(ctx, &kms.SignInput{
+		KeyId:            aws.String(keyID),
+		Message:          digest,
+		MessageType:      types.MessageTypeDigest,
+		SigningAlgorithm: types.SigningAlgorithmSpec(algorithm),
+	}, inRegionOf(keyID))
+	if err != nil {
+		return nil, "", err
+	}
+	return out.Signature, aws.ToString(out.KeyId), nil
+}
+
+// Verify checks a signature of a SHA-256 digest. An invalid signature is
+// reported as false rather than an error.
+func (c *Client) Verify(ctx context.Context, keyID, algorithm string, digest, signature []byte) (bool, error) {
+	out, err := c.api.Verify(ctx, &kms.VerifyInput{
+		KeyId:            aws.String(keyID),
+		Message:          digest,
+		MessageType:      types.MessageTypeDigest,
+		Signature:        signature,
+		SigningAlgorithm: types.SigningAlgorithmSpec(algorithm),
+	}, inRegionOf(keyID))
+	var invalid *types.KMSInvalidSignatureException
+	if errors.As(err, &invalid) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return out.SignatureValid, nil
+}
+
+// GetPublicKey returns the public key of an asymmetric key
+func (c *Client) GetPublicKey(ctx context.Context, keyID string) (*PublicKey, error) {
+	out, err := c.api.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(keyID)}, inRegionOf(keyID))
+	if err != nil {
+		return nil, err
+	}
+	algorithms := make([]string, 0, len(out.SigningAlgorithms))
+	for _, algorithm := range out.SigningAlgorithms {
+		algorithms = append(algorithms, string(algorithm))
+	}
+	return &PublicKey{
+		KeyID:             aws.ToString(out.KeyId),
+		DER:               out.PublicKey,
+		KeySpec:           string(out.KeySpec),
+		SigningAlgorithms: algorithms,
+	}, nil
+}
+
+// Encrypt encrypts a small plaintext of up to 4 KB, such as a data key,
+// with a symmetric key given by ID, ARN or alias. The encryption context is
+// bound to the ciphertext and must be given again to decrypt it. It returns
+// the ciphertext and the ARN of the key.
+func (c *Client) Encrypt(ctx context.Context, keyID string, plaintext []byte, encryptionContext map[string]string) ([]byte, string, error) {
+	out, err := c.api.Encrypt(ctx, &kms.EncryptInput{
+		KeyId:             aws.String(keyID),
+		Plaintext:         plaintext,
+		EncryptionContext: encryptionContext,
+	}, inRegionOf(keyID))
+	if err != nil {
+		return nil, "", err
+	}
+	return out.CiphertextBlob, aws.ToString(out.KeyId), nil
+}
+
+// Decrypt decrypts a ciphertext of Encrypt. keyID, the key it was encrypted
+// with, picks the region of an ARN and makes KMS refuse ciphertexts of
+// other keys.
+func (c *Client) Decrypt(ctx context.Context, keyID string, ciphertext []byte, encryptionContext map[string]string) ([]byte, error) {
+	out, err := c.api.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:             aws.String(keyID),
+		CiphertextBlob:    ciphertext,
+		EncryptionContext: encryptionContext,
+	}, inRegionOf(keyID))
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
+
+// RegionOf returns the region of a key or alias ARN, or "" for key IDs and
+// alias names
+func RegionOf(keyID string) string {
+	parts := strings.SplitN(keyID, ":", 6)
+	if len(parts) == 6 && parts[0] == "arn" && parts[2] == "kms" {
+		return parts[3]
+	}
+	return ""
+}
+
+// inRegionOf sends a request for a key given by ARN to the key's region
+func inRegionOf(keyID string) func(*kms.Options) {
+	return func(o *kms.Options) {
+		if region := RegionOf(keyID); region != "" {
+			o.Region = region
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package kms
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const keyARN = "arn:aws:kms:eu-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+
+func testClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return &Client{api: kms.NewFromConfig(aws.Config{
+		Region:       "us-east-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		BaseEndpoint: aws.String(server.URL),
+	})}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	digest := sha256.Sum256([]byte("statement"))
+	client := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// Keys given by ARN are used in their region
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/kms/aws4_request")
+
+		var input struct {
+			KeyID            string `json:"KeyId"`
+			Message          []byte
+			MessageType      string
+			Signature        []byte
+			SigningAlgorithm string
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+		assert.Equal(t, digest[:], input.Message)
+		assert.Equal(t, "DIGEST", input.MessageType)
+		assert.Equal(t, "ECDSA_SHA_256", input.SigningAlgorithm)
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.Sign":
+			json.NewEncoder(w).Encode(map[string]interface{}{"KeyId": keyARN, "Signature": []byte("sig")})
+		case "TrentService.Verify":
+			if string(input.Signature) != "sig" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"__type":"KMSInvalidSignatureException"}`))
+				return
+			}
+			w.Write([]byte(`{"SignatureValid":true}`))
+		default:
+			t.Errorf("unexpected target %s", r.Header.Get("X-Amz-Target"))
+		}
+	})
+
+	sig, keyID, err := client.Sign(context.Background(), keyARN, "ECDSA_SHA_256", digest[:])
+	require.NoError(t, err)
+	assert.Equal(t, []byte("sig"), sig)
+	assert.Equal(t, keyARN, keyID)
+
+	valid, err := client.Verify(context.Background(), keyARN, "ECDSA_SHA_256", digest[:], sig)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = client.Verify(context.Background(), keyARN, "ECDSA_SHA_256", digest[:], []byte("forged"))
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestGetPublicKey_Error(t *testing.T) {
+	client := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"NotFoundException","message":"Alias alias/missing is not found."}`))
+	})
+
+	_, err := client.GetPublicKey(context.Background(), "alias/missing")
+	var notFound *types.NotFoundException
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, "Alias alias/missing is not found.", notFound.ErrorMessage())
+}
+
+func TestRegionOf(t *testing.T) {
+	assert.Equal(t, "eu-west-1", RegionOf(keyARN))
+	assert.Equal(t, "ap-northeast-1", RegionOf("arn:aws:kms:ap-northeast-1:123456789012:alias/envy"))
+	assert.Equal(t, "", RegionOf("alias/envy"))
+	assert.Equal(t, "", RegionOf("1234abcd-12ab-34cd-56ef-1234567890ab"))
+}
+
+func TestEncryptAndDecrypt(t *testing.T) {
+	encryptionContext := map[string]string{"purpose": "test"}
+	client := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			KeyID             string `json:"KeyId"`
+			Plaintext         []byte
+			CiphertextBlob    []byte
+			EncryptionContext map[string]string
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+		assert.Equal(t, keyARN, input.KeyID)
+		assert.Equal(t, encryptionContext, input.EncryptionContext)
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.Encrypt":
+			json.NewEncoder(w).Encode(map[string]interface{}{"KeyId": keyARN, "CiphertextBlob": append([]byte("wrapped:"), input.Plaintext...)})
+		case "TrentService.Decrypt":
+			json.NewEncoder(w).Encode(map[string]interface{}{"KeyId": keyARN, "Plaintext": input.CiphertextBlob[len("wrapped:"):]})
+		default:
+			t.Errorf("unexpected target %s", r.Header.Get("X-Amz-Target"))
+		}
+	})
+
+	ciphertext, keyID, err := client.Encrypt(context.Background(), keyARN, []byte("data key"), encryptionContext)
+	require.NoError(t, err)
+	assert.Equal(t, keyARN, keyID)
+
+	plaintext, err := client.Decrypt(context.Background(), keyARN, ciphertext, encryptionContext)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data key"), plaintext)
+}
@@ -0,0 +1,294 @@
+// Package lambda reads and replaces the environment variables of AWS Lambda
+// functions, for exporting an environment straight into a function's
+// configuration.
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+
+	"github.com/drapon/envy/internal/aws/client"
+)
+
+const (
+	lambdaService = "lambda"
+	apiVersion    = "2015-03-31"
+
+	// MaxEnvironmentSize is the total size, in bytes, of the keys and
+	// values a function's environment may hold
+	MaxEnvironmentSize = 4096
+)
+
+// validKey matches the variable names Lambda accepts
+var validKey = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]+$`)
+
+// reservedKeys are set by the Lambda runtime and cannot be configured
+var reservedKeys = map[string]bool{
+	"_HANDLER":                        true,
+	"_X_AMZN_TRACE_ID":                true,
+	"AWS_DEFAULT_REGION":              true,
+	"AWS_REGION":                      true,
+	"AWS_EXECUTION_ENV":               true,
+	"AWS_LAMBDA_FUNCTION_NAME":        true,
+	"AWS_LAMBDA_FUNCTION_MEMORY_SIZE": true,
+	"AWS_LAMBDA_FUNCTION_VERSION":     true,
+	"AWS_LAMBDA_INITIALIZATION_TYPE":  true,
+	"AWS_LAMBDA_LOG_GROUP_NAME":       true,
+	"AWS_LAMBDA_LOG_STREAM_NAME":      true,
+	"AWS_ACCESS_KEY":                  true,
+	"AWS_ACCESS_KEY_ID":               true,
+	"AWS_SECRET_ACCESS_KEY":           true,
+	"AWS_SESSION_TOKEN":               true,
+	"AWS_LAMBDA_RUNTIME_API":          true,
+	"LAMBDA_TASK_ROOT":                true,
+	"LAMBDA_RUNTIME_DIR":              true,
+}
+
+// Validate checks that vars can be set on a function: names Lambda accepts,
+// none reserved by the runtime, and within MaxEnvironmentSize
+func Validate(vars map[string]string) error {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	size := 0
+	for _, key := range keys {
+		if reservedKeys[key] {
+			return fmt.Errorf("%s is reserved by the Lambda runtime", key)
+		}
+		if !validKey.MatchString(key) {
+			return fmt.Errorf("%s is not a valid Lambda environment variable name", key)
+		}
+		size += len(key) + len(vars[key])
+	}
+	if size > MaxEnvironmentSize {
+		return fmt.Errorf("the variables take %d bytes, more than the %d bytes a Lambda function's environment holds", size, MaxEnvironmentSize)
+	}
+	return nil
+}
+
+// APIError is returned when Lambda rejects a request
+type APIError struct {
+	Operation  string
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("lambda %s failed (%d %s): %s", e.Operation, e.StatusCode, e.Code, e.Message)
+}
+
+// ErrorCode lets the SDK retryer recognize throttling codes
+func (e *APIError) ErrorCode() string {
+	return e.Code
+}
+
+// HTTPStatusCode lets the SDK retryer recognize retryable status codes
+func (e *APIError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
+// Environment is the environment of a function's configuration
+type Environment struct {
+	Variables map[string]string
+	// RevisionID identifies the configuration it was read from, so an
+	// update fails rather than overwrite a concurrent change
+	RevisionID string
+}
+
+// Client calls the function configuration operations of Lambda over its
+// REST protocol, signed with the SDK's SigV4 signer, since the Lambda SDK is
+// not a dependency. It uses the endpoint, credentials and retryer of the
+// AWS config.
+type Client struct {
+	config     aws.Config
+	signer     *v4.Signer
+	httpClient *http.Client
+}
+
+// NewClient returns a Lambda client using the credentials of c
+func NewClient(c *client.Client) *Client {
+	return newClient(c.Config())
+}
+
+func newClient(cfg aws.Config) *Client {
+	return &Client{
+		config:     cfg,
+		signer:     v4.NewSigner(),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// GetEnvironment reads the environment variables of a function, given by
+// name or ARN
+func (c *Client) GetEnvironment(ctx context.Context, function string) (*Environment, error) {
+	var output struct {
+		RevisionID  string `json:"RevisionId"`
+		Environment struct {
+			Variables map[string]string `json:"Variables"`
+		} `json:"Environment"`
+	}
+	if err := c.call(ctx, "GetFunctionConfiguration", http.MethodGet, function, nil, &output); err != nil {
+		return nil, err
+	}
+
+	vars := output.Environment.Variables
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	return &Environment{Variables: vars, RevisionID: output.RevisionID}, nil
+}
+
+// UpdateEnvironment replaces the environment variables of a function. With
+// a revision ID the update fails if the configuration changed since it was
+// read.
+func (c *Client) UpdateEnvironment(ctx context.Context, function string, vars map[string]string, revisionID string) error {
+	input := map[string]interface{}{
+		"Environment": map[string]interface{}{"Variables": vars},
+	}
+	if revisionID != "" {
+		input["RevisionId"] = revisionID
+	}
+	return c.call(ctx, "UpdateFunctionConfiguration", http.MethodPut, function, input, nil)
+}
+
+// call performs a signed request, retrying as the config's retryer allows
+func (c *Client) call(ctx context.Context, operation, method, function string, input, output interface{}) error {
+	var retryer aws.Retryer = retry.NewStandard()
+	if c.config.Retryer != nil {
+		retryer = c.config.Retryer()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := c.do(ctx, operation, method, function, input, output)
+		if err == nil || !retryer.IsErrorRetryable(err) || attempt >= retryer.MaxAttempts() {
+			return err
+		}
+		delay, derr := retryer.RetryDelay(attempt, err)
+		if derr != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, operation, method, function string, input, output interface{}) error {
+	var payload []byte
+	if input != nil {
+		var err error
+		if payload, err = json.Marshal(input); err != nil {
+			return fmt.Errorf("failed to encode %s request: %w", operation, err)
+		}
+	}
+
+	endpoint := strings.TrimSuffix(c.endpoint(), "/") + "/" + apiVersion + "/functions/" + url.PathEscape(function) + "/configuration"
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", operation, err)
+	}
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	creds, err := c.config.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	hash := sha256.Sum256(payload)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), lambdaService, c.config.Region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign %s request: %w", operation, err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", operation, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", operation, err)
+	}
+	if resp.StatusCode >= 300 {
+		return parseAPIError(operation, resp, body)
+	}
+
+	if output == nil || len(body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(body, output); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", operation, err)
+	}
+	return nil
+}
+
+// endpoint honours AWS_ENDPOINT_URL_LAMBDA and the config's base endpoint
+// (AWS_ENDPOINT_URL) before the regional endpoint
+func (c *Client) endpoint() string {
+	if url := os.Getenv("AWS_ENDPOINT_URL_LAMBDA"); url != "" {
+		return url
+	}
+	if c.config.BaseEndpoint != nil && *c.config.BaseEndpoint != "" {
+		return *c.config.BaseEndpoint
+	}
+
+	suffix := "amazonaws.com"
+	if strings.HasPrefix(c.config.Region, "cn-") {
+		suffix = "amazonaws.com.cn"
+	}
+	return fmt.Sprintf("https://%s.%s.%s/", lambdaService, c.config.Region, suffix)
+}
+
+// parseAPIError converts a REST protocol error into an APIError. The code is
+// in the X-Amzn-ErrorType header, e.g. "ResourceNotFoundException:http://...",
+// or else the body's Type.
+func parseAPIError(operation string, resp *http.Response, body []byte) error {
+	var payload struct {
+		Type         string `json:"Type"`
+		Message      string `json:"message"`
+		MessageUpper string `json:"Message"`
+	}
+	_ = json.Unmarshal(body, &payload) // Fall back to the raw body below
+
+	code := resp.Header.Get("X-Amzn-ErrorType")
+	if idx := strings.Index(code, ":"); idx != -1 {
+		code = code[:idx]
+	}
+	if code == "" {
+		code = payload.Type
+	}
+
+	message := payload.Message
+	if message == "" {
+		message = payload.MessageUpper
+	}
+	if message == "" {
+		message = strings.TrimSpace(string(body))
+	}
+
+	return &APIError{Operation: operation, StatusCode: resp.StatusCode, Code: code, Message: message}
+}
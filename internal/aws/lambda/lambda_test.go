@@ -0,0 +1,72 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	t.Setenv("AWS_ENDPOINT_URL_LAMBDA", server.URL)
+	return newClient(aws.Config{Region: "us-east-1", Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", "")})
+}
+
+func TestGetAndUpdateEnvironment(t *testing.T) {
+	var updated struct {
+		Environment struct{ Variables map[string]string }
+		RevisionId  string
+	}
+	client := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2015-03-31/functions/my-function/configuration", r.URL.Path)
+		assert.Contains(t, r.Header.Get("Authorization"), "/lambda/aws4_request")
+
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"FunctionName":"my-function","RevisionId":"rev-1","Environment":{"Variables":{"A":"1"}}}`))
+		case http.MethodPut:
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&updated))
+			w.Write([]byte(`{}`))
+		}
+	})
+
+	env, err := client.GetEnvironment(context.Background(), "my-function")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "1"}, env.Variables)
+	assert.Equal(t, "rev-1", env.RevisionID)
+
+	require.NoError(t, client.UpdateEnvironment(context.Background(), "my-function", map[string]string{"B": "2"}, env.RevisionID))
+	assert.Equal(t, map[string]string{"B": "2"}, updated.Environment.Variables)
+	assert.Equal(t, "rev-1", updated.RevisionId)
+}
+
+func TestGetEnvironment_NotFound(t *testing.T) {
+	client := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amzn-ErrorType", "ResourceNotFoundException:http://internal.amazon.com/coral/com.amazonaws.awslambda/")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"Type":"User","Message":"Function not found: missing"}`))
+	})
+
+	_, err := client.GetEnvironment(context.Background(), "missing")
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "ResourceNotFoundException", apiErr.Code)
+	assert.Equal(t, "Function not found: missing", apiErr.Message)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(map[string]string{"DB_HOST": "localhost"}))
+	assert.ErrorContains(t, Validate(map[string]string{"AWS_REGION": "eu-west-1"}), "reserved")
+	assert.ErrorContains(t, Validate(map[string]string{"app.port": "80"}), "not a valid")
+	assert.ErrorContains(t, Validate(map[string]string{"CERT": strings.Repeat("x", MaxEnvironmentSize)}), "4096 bytes")
+}
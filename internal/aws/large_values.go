@@ -0,0 +1,52 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/drapon/envy/internal/aws/client"
+	"github.com/drapon/envy/internal/aws/errors"
+	parameter_store "github.com/drapon/envy/internal/aws/parameter_store"
+	secrets_manager "github.com/drapon/envy/internal/aws/secrets_manager"
+	"github.com/drapon/envy/internal/config"
+)
+
+// secretsManagerValues stores parameter values too large for Parameter
+// Store as Secrets Manager secrets
+type secretsManagerValues struct {
+	secrets *secrets_manager.Manager
+}
+
+func (v secretsManagerValues) PutValue(ctx context.Context, name, value string) error {
+	return v.secrets.CreateOrUpdateSecret(ctx, name, "Large value of an envy parameter", value)
+}
+
+func (v secretsManagerValues) GetValue(ctx context.Context, name string) (string, error) {
+	return v.secrets.GetSecretString(ctx, name)
+}
+
+func (v secretsManagerValues) DeleteValue(ctx context.Context, name string) error {
+	// Delete immediately so the name can be reused if the value grows again
+	err := v.secrets.DeleteSecret(ctx, name, true)
+	if errors.IsNotFoundError(err) {
+		return nil
+	}
+	return err
+}
+
+// newParameterStore returns the Parameter Store client for a manager,
+// storing large values in Secrets Manager if the configuration allows it
+// and applying the KMS keys and tags of the environments
+func newParameterStore(cfg *config.Config, awsClient *client.Client, secrets *secrets_manager.Manager) *parameter_store.Store {
+	paramStore := parameter_store.NewStore(awsClient)
+	if cfg.AWS.LargeValuesToSecretsManager {
+		paramStore.SetLargeValueStore(secretsManagerValues{secrets: secrets})
+	}
+	for name, envConfig := range cfg.Environments {
+		// Malformed tags are reported when the configuration is loaded
+		tags, _ := envConfig.ParameterTags()
+		if envConfig.KMSKeyID != "" || len(tags) > 0 {
+			paramStore.SetPathDefaults(cfg.GetParameterPath(name), parameter_store.PathDefaults{KeyID: envConfig.KMSKeyID, Tags: tags})
+		}
+	}
+	return paramStore
+}
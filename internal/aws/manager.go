@@ -4,15 +4,18 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/drapon/envy/internal/aws/client"
 	"github.com/drapon/envy/internal/aws/errors"
 	parameter_store "github.com/drapon/envy/internal/aws/parameter_store"
 	secrets_manager "github.com/drapon/envy/internal/aws/secrets_manager"
+	"github.com/drapon/envy/internal/chaos"
 	"github.com/drapon/envy/internal/config"
 	"github.com/drapon/envy/internal/env"
 	"github.com/drapon/envy/internal/memory"
 	"github.com/drapon/envy/internal/prompt"
+	"github.com/drapon/envy/internal/sensitive"
 )
 
 // Manager manages AWS operations for envy
@@ -21,6 +24,14 @@ type Manager struct {
 	paramStore     *parameter_store.Store
 	secretsManager *secrets_manager.Manager
 	config         *config.Config
+	isSensitive    func(string) bool
+
+	regionsMu sync.Mutex
+	regions   map[string]*Manager // read-only managers for fallback regions
+
+	environment    string // set on the manager of an environment with its own account
+	environmentsMu sync.Mutex
+	environments   map[string]*Manager // managers of environments with their own account
 }
 
 // GetConfig returns the configuration
@@ -28,29 +39,109 @@ func (m *Manager) GetConfig() *config.Config {
 	return m.config
 }
 
+// IsSensitive reports whether a key should be stored encrypted, honouring
+// the sensitivity overrides of .envyrc
+func (m *Manager) IsSensitive(key string) bool {
+	if m.isSensitive == nil {
+		return sensitive.IsSensitive(key)
+	}
+	return m.isSensitive(key)
+}
+
+// MarkSensitive makes the manager treat keys as sensitive in addition to
+// the configured sensitivity, e.g. keys flagged in an imported file
+func (m *Manager) MarkSensitive(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	marked := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		marked[key] = true
+	}
+	classify := m.isSensitive
+	if classify == nil {
+		classify = sensitive.IsSensitive
+	}
+	m.isSensitive = func(key string) bool {
+		return marked[key] || classify(key)
+	}
+}
+
+// LeaseCredentials mints temporary credentials for a child process by
+// assuming a role with the manager's credentials
+func (m *Manager) LeaseCredentials(ctx context.Context, opts client.LeaseOptions) (*client.Lease, error) {
+	return m.client.LeaseCredentials(ctx, opts)
+}
+
+// CallerARN returns the ARN of the principal the manager acts as
+func (m *Manager) CallerARN(ctx context.Context) (string, error) {
+	return m.client.CallerARN(ctx)
+}
+
 // NewManager creates a new AWS manager
 func NewManager(cfg *config.Config) (*Manager, error) {
 	ctx := context.Background()
 
+	isSensitive, err := sensitive.FromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create AWS client
-	awsClient, err := client.NewClient(ctx, client.Options{
-		Region:  cfg.AWS.Region,
-		Profile: cfg.AWS.Profile,
-	})
+	opts := client.Options{
+		Region:     cfg.AWS.Region,
+		Profile:    cfg.AWS.Profile,
+		Chaos:      chaos.Global(),
+		OIDC:       oidcOptions(cfg),
+		RoleARN:    cfg.AWS.RoleARN,
+		ExternalID: cfg.AWS.ExternalID,
+		MFASerial:  cfg.AWS.MFASerial,
+	}
+	awsClient, err := client.NewClient(ctx, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create AWS client: %w", err)
 	}
 
+	// Fail early on missing or expired credentials, offering another profile
+	awsClient, err = resolveCredentials(ctx, cfg, awsClient, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	secretsManager := secrets_manager.NewManager(awsClient)
 	return &Manager{
 		client:         awsClient,
-		paramStore:     parameter_store.NewStore(awsClient),
-		secretsManager: secrets_manager.NewManager(awsClient),
+		paramStore:     newParameterStore(cfg, awsClient, secretsManager),
+		secretsManager: secretsManager,
 		config:         cfg,
+		isSensitive:    isSensitive,
 	}, nil
 }
 
+// oidcOptions converts aws.oidc from .envyrc, or returns nil when it is
+// not set
+func oidcOptions(cfg *config.Config) *client.OIDCOptions {
+	oidc := cfg.AWS.OIDC
+	if oidc == nil {
+		return nil
+	}
+	return &client.OIDCOptions{
+		RoleARN:     oidc.RoleARN,
+		Provider:    oidc.Provider,
+		Audience:    oidc.Audience,
+		TokenEnv:    oidc.TokenEnv,
+		SessionName: oidc.SessionName,
+		Duration:    oidc.SessionDuration(),
+	}
+}
+
 // PushEnvironment pushes environment variables to AWS
 func (m *Manager) PushEnvironment(ctx context.Context, envName string, file *env.File, overwrite bool) error {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return err
+	}
 	// Get environment configuration
 	envConfig, err := m.config.GetEnvironment(envName)
 	if err != nil {
@@ -74,8 +165,23 @@ func (m *Manager) PushEnvironment(ctx context.Context, envName string, file *env
 	return m.pushToParameterStore(ctx, path, vars, overwrite)
 }
 
-// PullEnvironment pulls environment variables from AWS
+// PullEnvironment pulls environment variables from AWS, reading from the
+// environment's fallback regions if the primary region fails
 func (m *Manager) PullEnvironment(ctx context.Context, envName string) (*env.File, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	var file *env.File
+	err = m.readWithFallback(ctx, envName, func(ctx context.Context, rm *Manager) error {
+		var err error
+		file, err = rm.pullEnvironment(ctx, envName)
+		return err
+	})
+	return file, err
+}
+
+func (m *Manager) pullEnvironment(ctx context.Context, envName string) (*env.File, error) {
 	// Get environment configuration
 	envConfig, err := m.config.GetEnvironment(envName)
 	if err != nil {
@@ -122,6 +228,10 @@ func (m *Manager) PullEnvironment(ctx context.Context, envName string) (*env.Fil
 
 // ListEnvironmentVariables lists variables for an environment
 func (m *Manager) ListEnvironmentVariables(ctx context.Context, envName string) (map[string]string, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
 	// Get environment configuration
 	envConfig, err := m.config.GetEnvironment(envName)
 	if err != nil {
@@ -139,8 +249,100 @@ func (m *Manager) ListEnvironmentVariables(ctx context.Context, envName string)
 	return m.pullFromParameterStore(ctx, path)
 }
 
+// ListEnvironmentVariablesPages calls fn with the variables of an
+// environment a page at a time as they are read, so the first ones can be
+// shown before the rest arrive. Secrets Manager environments are one page.
+// An error from fn stops the listing and is returned.
+func (m *Manager) ListEnvironmentVariablesPages(ctx context.Context, envName string, fn func(page map[string]string) error) error {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return err
+	}
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return err
+	}
+
+	service := m.config.GetAWSService(envName)
+	path := m.config.GetParameterPath(envName)
+
+	if service == "secrets_manager" || envConfig.UseSecretsManager {
+		vars, err := m.pullFromSecretsManager(ctx, path)
+		if err != nil {
+			return err
+		}
+		return fn(vars)
+	}
+
+	var fnErr error
+	err = m.paramStore.WalkParametersByPath(ctx, path, true, true, func(page []*parameter_store.Parameter) error {
+		fnErr = fn(m.localVars(m.paramStore.ConvertToEnvVars(page, path)))
+		return fnErr
+	})
+	if err != nil && fnErr == nil {
+		return errors.WrapAWSError(err, "get parameters by path", path)
+	}
+	return err
+}
+
+// RemoteVersion returns a cheap fingerprint of the remote state of an
+// environment. For Parameter Store it is derived from parameter metadata
+// (DescribeParameters); for Secrets Manager it is the AWSCURRENT VersionId.
+// Values are never fetched, so callers can use it to decide whether a cached
+// pull is still current.
+func (m *Manager) RemoteVersion(ctx context.Context, envName string) (string, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return "", err
+	}
+	var version string
+	err = m.readWithFallback(ctx, envName, func(ctx context.Context, rm *Manager) error {
+		var err error
+		version, err = rm.remoteVersion(ctx, envName)
+		return err
+	})
+	return version, err
+}
+
+func (m *Manager) remoteVersion(ctx context.Context, envName string) (string, error) {
+	// Get environment configuration
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return "", err
+	}
+
+	// Determine which service to use
+	service := m.config.GetAWSService(envName)
+	path := m.config.GetParameterPath(envName)
+
+	if service == "secrets_manager" || envConfig.UseSecretsManager {
+		if m.config.SecretPerVariable() {
+			return m.variableSecretsVersion(ctx, path)
+		}
+		secretName := strings.Trim(path, "/")
+		secretName = strings.ReplaceAll(secretName, "/", "-")
+
+		versionID, err := m.secretsManager.GetCurrentVersionID(ctx, secretName)
+		if err != nil {
+			return "", errors.WrapAWSError(err, "describe secret", secretName)
+		}
+		return "sm-" + versionID, nil
+	}
+
+	metadata, err := m.paramStore.DescribeParametersByPath(ctx, path)
+	if err != nil {
+		return "", errors.WrapAWSError(err, "describe parameters", path)
+	}
+
+	return "ps-" + parameter_store.Fingerprint(metadata), nil
+}
+
 // DeleteEnvironment deletes all variables for an environment
 func (m *Manager) DeleteEnvironment(ctx context.Context, envName string) error {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return err
+	}
 	// Get environment configuration
 	envConfig, err := m.config.GetEnvironment(envName)
 	if err != nil {
@@ -151,11 +353,27 @@ func (m *Manager) DeleteEnvironment(ctx context.Context, envName string) error {
 	service := m.config.GetAWSService(envName)
 	path := m.config.GetParameterPath(envName)
 
+	// Other applications share the path or secret of a namespace, so only
+	// the namespace's own variables are deleted
+	if m.namespacePrefix() != "" {
+		vars, err := m.pullEnvironment(ctx, envName)
+		if err != nil {
+			return err
+		}
+		return m.applyChanges(ctx, envName, nil, vars.Keys())
+	}
+
 	if service == "secrets_manager" || envConfig.UseSecretsManager {
 		// Delete from Secrets Manager
-		secrets, err := m.secretsManager.ListSecrets(ctx, path)
+		var secrets []*secrets_manager.Secret
+		if m.config.SecretPerVariable() {
+			secrets, err = m.listVariableSecrets(ctx, path)
+		} else {
+			secrets, err = m.secretsManager.ListSecrets(ctx, path)
+			err = errors.WrapAWSError(err, "list secrets", path)
+		}
 		if err != nil {
-			return errors.WrapAWSError(err, "list secrets", path)
+			return err
 		}
 
 		for _, secret := range secrets {
@@ -188,29 +406,13 @@ func (m *Manager) pushToParameterStore(ctx context.Context, path string, vars ma
 		}
 
 		if len(existing) > 0 {
-			// Show existing parameters and ask for action
-			action := m.promptBulkOverwrite(existing)
-			switch action {
-			case "all":
-				overwrite = true
-			case "none":
-				// Remove existing from vars
-				for _, key := range existing {
-					delete(vars, key)
-				}
-				if len(vars) == 0 {
-					fmt.Println("No new variables to push.")
-					return nil
-				}
-			case "select":
-				// Ask for each parameter
-				for _, key := range existing {
-					if !m.promptOverwriteSingle(key) {
-						delete(vars, key)
-					}
-				}
-			case "cancel":
-				return fmt.Errorf("push cancelled by user")
+			overwrite, err = m.confirmOverwrites(existing, vars)
+			if err != nil {
+				return err
+			}
+			if len(vars) == 0 {
+				fmt.Println("No new variables to push.")
+				return nil
 			}
 		}
 	}
@@ -228,14 +430,11 @@ func (m *Manager) pushToParameterStore(ctx context.Context, path string, vars ma
 		default:
 		}
 
-		paramName := path + key
+		paramName := path + m.RemoteKey(key)
 
 		// Determine parameter type based on key
 		paramType := "String"
-		if strings.Contains(strings.ToLower(key), "password") ||
-			strings.Contains(strings.ToLower(key), "secret") ||
-			strings.Contains(strings.ToLower(key), "key") ||
-			strings.Contains(strings.ToLower(key), "token") {
+		if m.IsSensitive(key) {
 			paramType = "SecureString"
 		}
 
@@ -269,6 +468,31 @@ func (m *Manager) checkExistingParameters(ctx context.Context, path string, vars
 	return existing, nil
 }
 
+// confirmOverwrites asks what to do with the variables of vars that exist
+// remotely and removes the ones to keep from vars. It reports whether the
+// existing ones may all be overwritten.
+func (m *Manager) confirmOverwrites(existing []string, vars map[string]string) (bool, error) {
+	switch m.promptBulkOverwrite(existing) {
+	case "all":
+		return true, nil
+	case "select":
+		// Ask for each parameter
+		for _, key := range existing {
+			if !m.promptOverwriteSingle(key) {
+				delete(vars, key)
+			}
+		}
+	case "cancel":
+		return false, fmt.Errorf("push cancelled by user")
+	default:
+		// Remove existing from vars
+		for _, key := range existing {
+			delete(vars, key)
+		}
+	}
+	return false, nil
+}
+
 // promptBulkOverwrite prompts for bulk overwrite action
 func (m *Manager) promptBulkOverwrite(existing []string) string {
 	fmt.Printf("\nFound %d existing parameters:\n", len(existing))
@@ -277,6 +501,16 @@ func (m *Manager) promptBulkOverwrite(existing []string) string {
 	}
 	fmt.Println()
 
+	// Without prompts the existing parameters are kept, unless --yes
+	if prompt.NonInteractive() {
+		if prompt.AssumeYes() {
+			fmt.Println("Overwriting them (--yes)")
+			return "all"
+		}
+		fmt.Println("Skipping them, as prompts are disabled (pass --yes or --force to overwrite them)")
+		return "none"
+	}
+
 	// Try interactive menu first
 	options := []string{
 		"Overwrite all",
@@ -324,14 +558,7 @@ func (m *Manager) promptOverwriteSingle(key string) bool {
 
 // promptOverwriteSecret asks the user if they want to overwrite an existing secret
 func (m *Manager) promptOverwriteSecret(secretName string) bool {
-	fmt.Printf("\nSecret already exists: %s\n", secretName)
-	fmt.Print("Overwrite? [y/N]: ")
-
-	var response string
-	fmt.Scanln(&response)
-
-	response = strings.ToLower(strings.TrimSpace(response))
-	return response == "y" || response == "yes"
+	return prompt.Confirm(fmt.Sprintf("Secret already exists: %s\nOverwrite?", secretName))
 }
 
 // pullFromParameterStore pulls variables from Parameter Store
@@ -343,15 +570,32 @@ func (m *Manager) pullFromParameterStore(ctx context.Context, path string) (map[
 	}
 
 	// Convert to env vars
-	return m.paramStore.ConvertToEnvVars(parameters, path), nil
+	return m.localVars(m.paramStore.ConvertToEnvVars(parameters, path)), nil
 }
 
 // pushToSecretsManager pushes variables to Secrets Manager
 func (m *Manager) pushToSecretsManager(ctx context.Context, path string, vars map[string]string, overwrite bool) error {
+	if m.config.SecretPerVariable() {
+		return m.pushToVariableSecrets(ctx, path, vars, overwrite)
+	}
+
 	// Clean path for secret name
 	secretName := strings.Trim(path, "/")
 	secretName = strings.ReplaceAll(secretName, "/", "-")
 
+	// A shared secret keeps the variables of the other namespaces
+	if m.namespacePrefix() != "" {
+		current := map[string]string{}
+		secret, err := m.secretsManager.GetSecret(ctx, secretName)
+		if err != nil && !errors.IsNotFoundError(err) {
+			return errors.WrapAWSError(err, "get secret", secretName)
+		}
+		if err == nil && secret.KeyValue != nil {
+			current = secret.KeyValue
+		}
+		vars = m.mergeNamespace(current, vars)
+	}
+
 	// Create or update secret
 	err := m.secretsManager.CreateOrUpdateSecret(ctx, secretName,
 		fmt.Sprintf("Environment variables for %s", secretName), vars)
@@ -379,6 +623,10 @@ func (m *Manager) pushToSecretsManager(ctx context.Context, path string, vars ma
 
 // pullFromSecretsManager pulls variables from Secrets Manager
 func (m *Manager) pullFromSecretsManager(ctx context.Context, path string) (map[string]string, error) {
+	if m.config.SecretPerVariable() {
+		return m.pullFromVariableSecrets(ctx, path)
+	}
+
 	// Clean path for secret name
 	secretName := strings.Trim(path, "/")
 	secretName = strings.ReplaceAll(secretName, "/", "-")
@@ -389,19 +637,7 @@ func (m *Manager) pullFromSecretsManager(ctx context.Context, path string) (map[
 		return nil, errors.WrapAWSError(err, "get secret", secretName)
 	}
 
-	// Return key-value pairs
-	if secret.KeyValue != nil {
-		return secret.KeyValue, nil
-	}
-
-	// If it's a string value, return as single key
-	if secret.Value != "" {
-		return map[string]string{
-			"SECRET_VALUE": secret.Value,
-		}, nil
-	}
-
-	return map[string]string{}, nil
+	return m.bundleVars(secret), nil
 }
 
 // GetClient returns the underlying AWS client
@@ -482,14 +718,11 @@ type pushParameterJob struct {
 }
 
 func (job *pushParameterJob) Process() error {
-	paramName := job.path + job.key
+	paramName := job.path + job.manager.RemoteKey(job.key)
 
 	// Determine parameter type based on key
 	paramType := "String"
-	if strings.Contains(strings.ToLower(job.key), "password") ||
-		strings.Contains(strings.ToLower(job.key), "secret") ||
-		strings.Contains(strings.ToLower(job.key), "key") ||
-		strings.Contains(strings.ToLower(job.key), "token") {
+	if job.manager.IsSensitive(job.key) {
 		paramType = "SecureString"
 	}
 
@@ -507,6 +740,10 @@ func (job *pushParameterJob) Process() error {
 
 // PushEnvironmentWithMemoryOptimization pushes environment variables with memory optimization
 func (m *Manager) PushEnvironmentWithMemoryOptimization(ctx context.Context, envName string, file *env.File, overwrite bool) error {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return err
+	}
 	// Monitor memory usage during operation
 	poolManager := memory.GetGlobalPoolManager()
 	if poolManager != nil {
@@ -521,6 +758,10 @@ func (m *Manager) PushEnvironmentWithMemoryOptimization(ctx context.Context, env
 
 // PullEnvironmentWithStreaming pulls environment variables using streaming approach
 func (m *Manager) PullEnvironmentWithStreaming(ctx context.Context, envName string, writer func(*env.Variable) error) error {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return err
+	}
 	// Get environment configuration
 	envConfig, err := m.config.GetEnvironment(envName)
 	if err != nil {
@@ -1,19 +1,25 @@
 package aws
 
 import (
-	"strings"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/drapon/envy/internal/aws/errors"
 	"github.com/drapon/envy/internal/config"
 	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/sensitive"
 	"github.com/drapon/envy/internal/testutil"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestNewManager(t *testing.T) {
 	t.Run("valid_config", func(t *testing.T) {
+		// NewManager resolves credentials up front
+		t.Setenv("AWS_ACCESS_KEY_ID", "AKIAEXAMPLE")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
 		cfg := testutil.CreateTestConfig()
 
 		manager, err := NewManager(cfg)
@@ -37,6 +43,23 @@ func TestNewManager(t *testing.T) {
 			assert.Contains(t, err.Error(), "AWS region is required")
 		}
 	})
+
+	t.Run("missing_credentials", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+		t.Setenv("AWS_PROFILE", "")
+		t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+		t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+		t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+		cfg := testutil.CreateTestConfig()
+		cfg.AWS.Profile = ""
+
+		manager, err := NewManager(cfg)
+		assert.Nil(t, manager)
+		assert.True(t, errors.IsCredentialError(err))
+	})
 }
 
 func TestManager_PushEnvironment_ParameterStore(t *testing.T) {
@@ -277,8 +300,7 @@ func TestPushParameterJob(t *testing.T) {
 	}
 
 	// Test sensitive key detection
-	isSensitive := isSensitiveKey(sensitiveJob.key)
-	assert.True(t, isSensitive)
+	assert.True(t, manager.IsSensitive(sensitiveJob.key))
 }
 
 func TestSetVariableJob(t *testing.T) {
@@ -396,23 +418,6 @@ func TestManager_ServiceSelection(t *testing.T) {
 	}
 }
 
-// Helper function to test sensitive key detection
-func testIsSensitiveKey(key string) bool {
-	sensitivePatterns := []string{
-		"password", "secret", "key", "token",
-		"credential", "auth", "private", "cert",
-	}
-
-	lowerKey := strings.ToLower(key)
-	for _, pattern := range sensitivePatterns {
-		if strings.Contains(lowerKey, pattern) {
-			return true
-		}
-	}
-
-	return false
-}
-
 func TestSensitiveKeyDetection(t *testing.T) {
 	sensitiveKeys := []string{
 		"PASSWORD",
@@ -421,8 +426,7 @@ func TestSensitiveKeyDetection(t *testing.T) {
 		"PRIVATE_KEY",
 		"DATABASE_PASSWORD",
 		"AUTH_TOKEN",
-		"CERTIFICATE",
-		"CREDENTIAL",
+		"DB_CREDENTIAL",
 	}
 
 	nonSensitiveKeys := []string{
@@ -436,17 +440,34 @@ func TestSensitiveKeyDetection(t *testing.T) {
 		"ENVIRONMENT",
 	}
 
+	manager := &Manager{}
+
 	for _, key := range sensitiveKeys {
 		t.Run("sensitive_"+key, func(t *testing.T) {
-			assert.True(t, isSensitiveKey(key), "key %s should be detected as sensitive", key)
+			assert.True(t, manager.IsSensitive(key), "key %s should be detected as sensitive", key)
 		})
 	}
 
 	for _, key := range nonSensitiveKeys {
 		t.Run("non_sensitive_"+key, func(t *testing.T) {
-			assert.False(t, isSensitiveKey(key), "key %s should not be detected as sensitive", key)
+			assert.False(t, manager.IsSensitive(key), "key %s should not be detected as sensitive", key)
 		})
 	}
+
+	t.Run("overrides", func(t *testing.T) {
+		isSensitive, err := sensitive.New([]string{"SESSION_SALT"}, []string{"*_PUBLIC_KEY"})
+		require.NoError(t, err)
+		manager := &Manager{isSensitive: isSensitive}
+
+		assert.True(t, manager.IsSensitive("SESSION_SALT"))
+		assert.False(t, manager.IsSensitive("STRIPE_PUBLIC_KEY"))
+		assert.True(t, manager.IsSensitive("STRIPE_SECRET_KEY"))
+
+		manager.MarkSensitive("STRIPE_PUBLIC_KEY")
+		assert.True(t, manager.IsSensitive("STRIPE_PUBLIC_KEY"))
+		assert.True(t, manager.IsSensitive("SESSION_SALT"))
+		assert.False(t, manager.IsSensitive("APP_NAME"))
+	})
 }
 
 // Benchmark tests
@@ -469,6 +490,7 @@ func BenchmarkManager_GetAWSService(b *testing.B) {
 }
 
 func BenchmarkSensitiveKeyDetection(b *testing.B) {
+	manager := &Manager{}
 	keys := []string{
 		"APP_NAME", "DEBUG", "API_SECRET", "PASSWORD",
 		"JWT_TOKEN", "DATABASE_URL", "PRIVATE_KEY", "PORT",
@@ -477,7 +499,7 @@ func BenchmarkSensitiveKeyDetection(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		key := keys[i%len(keys)]
-		_ = isSensitiveKey(key)
+		_ = manager.IsSensitive(key)
 	}
 }
 
@@ -620,3 +642,23 @@ func TestManager_ConfigurationValidation(t *testing.T) {
 		}
 	})
 }
+
+func TestConfirmOverwritesWithoutPrompts(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	t.Setenv("CI", "true")
+	m := &Manager{}
+
+	vars := map[string]string{"API_KEY": "new", "PORT": "80"}
+	all, err := m.confirmOverwrites([]string{"API_KEY"}, vars)
+	require.NoError(t, err)
+	assert.False(t, all)
+	assert.Equal(t, map[string]string{"PORT": "80"}, vars, "existing parameters are kept")
+
+	viper.Set("yes", true)
+	vars = map[string]string{"API_KEY": "new", "PORT": "80"}
+	all, err = m.confirmOverwrites([]string{"API_KEY"}, vars)
+	require.NoError(t, err)
+	assert.True(t, all)
+	assert.Len(t, vars, 2)
+	assert.True(t, m.promptOverwriteSecret("envy/app/dev"))
+}
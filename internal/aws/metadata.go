@@ -0,0 +1,173 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/drapon/envy/internal/aws/errors"
+	parameter_store "github.com/drapon/envy/internal/aws/parameter_store"
+)
+
+// Kinds of metadata drift
+const (
+	DriftType   = "type"    // a sensitive variable stored as a String
+	DriftKMSKey = "kms-key" // a SecureString encrypted with another key than kms_key_id
+	DriftTags   = "tags"    // tags of the environment missing or different
+)
+
+// defaultKMSKey is the key of SecureStrings created without one
+const defaultKMSKey = "alias/aws/ssm"
+
+// MetadataDrift is a parameter stored differently from how .envyrc says
+// it should be, whatever its value
+type MetadataDrift struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+
+	meta    *parameter_store.ParameterMetadata
+	keyID   string            // KMS key to use, for type and kms-key drift
+	tags    map[string]string // tags to set, for tag drift
+	manager *Manager          // of the environment the parameter is in
+}
+
+// MetadataDrift compares the parameters of an environment with its
+// configuration: variables classified as sensitive must be SecureStrings,
+// SecureStrings must be encrypted with kms_key_id when it is set, and
+// parameters must carry the environment's tags. Only keys accepted by
+// match are checked. Drift is sorted by key.
+func (m *Manager) MetadataDrift(ctx context.Context, envName string, match func(key string) bool) ([]MetadataDrift, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	if m.config.GetAWSService(envName) == "secrets_manager" || envConfig.UseSecretsManager {
+		return nil, fmt.Errorf("%s is stored in Secrets Manager, where variables have no type or key of their own", envName)
+	}
+	tags, err := envConfig.ParameterTags()
+	if err != nil {
+		return nil, err
+	}
+
+	path := m.config.GetParameterPath(envName)
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	metadata, err := m.paramStore.DescribeParametersByPath(ctx, path)
+	if err != nil {
+		return nil, errors.WrapAWSError(err, "describe parameters", path)
+	}
+
+	var drift []MetadataDrift
+	for _, meta := range metadata {
+		remote := strings.TrimPrefix(meta.Name, path)
+		if strings.Contains(remote, "/") {
+			continue
+		}
+		key, ok := m.LocalKey(remote)
+		if !ok || !match(key) {
+			continue
+		}
+
+		var actualTags map[string]string
+		if len(tags) > 0 {
+			if actualTags, err = m.paramStore.ListTags(ctx, meta.Name); err != nil {
+				return nil, errors.WrapAWSError(err, "list tags", meta.Name)
+			}
+		}
+		found := parameterDrift(meta, key, m.IsSensitive(key), envConfig.KMSKeyID, tags, actualTags)
+		// Large values live in Secrets Manager, encrypted, behind a String
+		if len(found) > 0 && found[0].Kind == DriftType && m.paramStore.IsLargeValue(ctx, meta.Name) {
+			found = found[1:]
+		}
+		for i := range found {
+			found[i].manager = m
+		}
+		drift = append(drift, found...)
+	}
+
+	sort.SliceStable(drift, func(i, j int) bool { return drift[i].Key < drift[j].Key })
+	return drift, nil
+}
+
+// parameterDrift compares one parameter with what is expected of it
+func parameterDrift(meta *parameter_store.ParameterMetadata, key string, sensitive bool, keyID string, tags, actualTags map[string]string) []MetadataDrift {
+	var drift []MetadataDrift
+	add := func(kind, expected, actual string) {
+		drift = append(drift, MetadataDrift{Key: key, Name: meta.Name, Kind: kind, Expected: expected, Actual: actual, meta: meta, keyID: keyID})
+	}
+
+	switch {
+	case sensitive && meta.Type != "SecureString":
+		add(DriftType, "SecureString", meta.Type)
+	case keyID != "" && meta.Type == "SecureString" && !sameKey(keyID, meta.KeyID):
+		actual := meta.KeyID
+		if actual == "" {
+			actual = defaultKMSKey
+		}
+		add(DriftKMSKey, keyID, actual)
+	}
+
+	missing := make(map[string]string)
+	var expected, actual []string
+	for name, value := range tags {
+		if current, ok := actualTags[name]; !ok || current != value {
+			missing[name] = value
+			expected = append(expected, name+"="+value)
+			if ok {
+				actual = append(actual, name+"="+current)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(expected)
+		sort.Strings(actual)
+		got := strings.Join(actual, ", ")
+		if got == "" {
+			got = "(missing)"
+		}
+		add(DriftTags, strings.Join(expected, ", "), got)
+		drift[len(drift)-1].tags = missing
+	}
+	return drift
+}
+
+// sameKey reports whether two references to a KMS key agree. A key ID
+// matches the key's ARN and an alias the alias's ARN; an alias and the ID
+// of the key it points to cannot be told apart without KMS and differ.
+func sameKey(a, b string) bool {
+	if a == b {
+		return true
+	}
+	return strings.HasSuffix(a, ":"+b) || strings.HasSuffix(b, ":"+a) ||
+		strings.HasSuffix(a, "/"+b) || strings.HasSuffix(b, "/"+a)
+}
+
+// FixMetadata reconciles a parameter with its configuration. A String is
+// recreated as a SecureString (see Reclassify), a SecureString is
+// re-encrypted with the configured key as a new version, and tags are
+// added or updated.
+func (m *Manager) FixMetadata(ctx context.Context, d MetadataDrift, now time.Time) error {
+	if d.manager != nil {
+		m = d.manager
+	}
+	switch d.Kind {
+	case DriftType:
+		r := Reclassification{Key: d.Key, Name: d.Name, From: d.meta.Type, To: "SecureString", Version: d.meta.Version, meta: d.meta, manager: m}
+		return m.Reclassify(ctx, r, d.keyID, now)
+	case DriftKMSKey:
+		return errors.WrapAWSError(m.paramStore.ChangeKey(ctx, d.Name, d.keyID), "re-encrypt parameter", d.Name)
+	case DriftTags:
+		return errors.WrapAWSError(m.paramStore.AddTags(ctx, d.Name, d.tags), "tag parameter", d.Name)
+	}
+	return fmt.Errorf("unknown metadata drift %q", d.Kind)
+}
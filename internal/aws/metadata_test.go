@@ -0,0 +1,46 @@
+package aws
+
+import (
+	"testing"
+
+	parameter_store "github.com/drapon/envy/internal/aws/parameter_store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParameterDrift(t *testing.T) {
+	plain := &parameter_store.ParameterMetadata{Name: "/app/prod/DB_PASSWORD", Type: "String"}
+	drift := parameterDrift(plain, "DB_PASSWORD", true, "alias/prod", nil, nil)
+	if assert.Len(t, drift, 1) {
+		assert.Equal(t, DriftType, drift[0].Kind)
+		assert.Equal(t, "SecureString", drift[0].Expected)
+		assert.Equal(t, "alias/prod", drift[0].keyID)
+	}
+	assert.Empty(t, parameterDrift(plain, "LOG_LEVEL", false, "alias/prod", nil, nil))
+
+	secure := &parameter_store.ParameterMetadata{Name: "/app/prod/API_TOKEN", Type: "SecureString", KeyID: "alias/aws/ssm"}
+	drift = parameterDrift(secure, "API_TOKEN", true, "alias/prod", nil, nil)
+	if assert.Len(t, drift, 1) {
+		assert.Equal(t, DriftKMSKey, drift[0].Kind)
+		assert.Equal(t, "alias/aws/ssm", drift[0].Actual)
+	}
+	assert.Empty(t, parameterDrift(secure, "API_TOKEN", true, "", nil, nil), "no kms_key_id, no key drift")
+
+	tags := map[string]string{"Team": "payments", "Env": "prod"}
+	drift = parameterDrift(secure, "API_TOKEN", true, "", tags, map[string]string{"Team": "platform", "Owner": "x"})
+	if assert.Len(t, drift, 1) {
+		assert.Equal(t, DriftTags, drift[0].Kind)
+		assert.Equal(t, "Env=prod, Team=payments", drift[0].Expected)
+		assert.Equal(t, "Team=platform", drift[0].Actual)
+		assert.Equal(t, tags, drift[0].tags)
+	}
+	assert.Empty(t, parameterDrift(secure, "API_TOKEN", true, "", tags, map[string]string{"Team": "payments", "Env": "prod"}))
+}
+
+func TestSameKey(t *testing.T) {
+	id := "1234abcd-12ab-34cd-56ef-1234567890ab"
+	assert.True(t, sameKey(id, "arn:aws:kms:us-east-1:111122223333:key/"+id))
+	assert.True(t, sameKey("alias/prod", "arn:aws:kms:us-east-1:111122223333:alias/prod"))
+	assert.True(t, sameKey("alias/prod", "alias/prod"))
+	assert.False(t, sameKey("alias/prod", "alias/aws/ssm"))
+	assert.False(t, sameKey("alias/prod", id))
+}
@@ -0,0 +1,153 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/drapon/envy/internal/aws/errors"
+	parameter_store "github.com/drapon/envy/internal/aws/parameter_store"
+)
+
+// Move is one parameter of a namespace move
+type Move struct {
+	From string
+	To   string
+
+	param *parameter_store.Parameter
+}
+
+// MovePlan lists the parameters a namespace move copies, sorted by name
+type MovePlan struct {
+	OldPrefix string
+	NewPrefix string
+	Moves     []Move
+}
+
+// Keys returns the variable names the move writes, without their prefixes
+func (p *MovePlan) Keys() []string {
+	keys := make([]string, 0, len(p.Moves))
+	for _, move := range p.Moves {
+		keys = append(keys, move.From[strings.LastIndex(move.From, "/")+1:])
+	}
+	return keys
+}
+
+// NormalizePrefix returns a parameter path prefix with leading and trailing
+// slashes
+func NormalizePrefix(prefix string) (string, error) {
+	if !strings.HasPrefix(prefix, "/") {
+		return "", fmt.Errorf("invalid prefix %q: must start with /", prefix)
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	if prefix == "/" || strings.Contains(prefix, "//") {
+		return "", fmt.Errorf("invalid prefix %q", prefix)
+	}
+	return prefix, nil
+}
+
+// PlanMove lists every parameter under oldPrefix, recursively, with its name
+// under newPrefix. It fails before anything is written if any of the new
+// names is already taken.
+func (m *Manager) PlanMove(ctx context.Context, oldPrefix, newPrefix string) (*MovePlan, error) {
+	oldPrefix, err := NormalizePrefix(oldPrefix)
+	if err != nil {
+		return nil, err
+	}
+	newPrefix, err = NormalizePrefix(newPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(newPrefix, oldPrefix) || strings.HasPrefix(oldPrefix, newPrefix) {
+		return nil, fmt.Errorf("%s and %s overlap", oldPrefix, newPrefix)
+	}
+
+	params, err := m.paramStore.GetParametersByPath(ctx, oldPrefix, true, true)
+	if err != nil {
+		return nil, errors.WrapAWSError(err, "get parameters by path", oldPrefix)
+	}
+	existing, err := m.paramStore.ListParameterNames(ctx, newPrefix, true)
+	if err != nil {
+		return nil, errors.WrapAWSError(err, "get parameters by path", newPrefix)
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		taken[name] = true
+	}
+
+	plan := &MovePlan{OldPrefix: oldPrefix, NewPrefix: newPrefix}
+	var conflicts []string
+	for _, param := range params {
+		to := newPrefix + strings.TrimPrefix(param.Name, oldPrefix)
+		if taken[to] {
+			conflicts = append(conflicts, to)
+			continue
+		}
+		plan.Moves = append(plan.Moves, Move{From: param.Name, To: to, param: param})
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return nil, fmt.Errorf("%d parameter(s) already exist under %s: %s: %w", len(conflicts), newPrefix, strings.Join(conflicts, ", "), ErrKeyExists)
+	}
+
+	sort.Slice(plan.Moves, func(i, j int) bool { return plan.Moves[i].From < plan.Moves[j].From })
+	return plan, nil
+}
+
+// PlanMoveParameter plans moving the single parameter oldName to newName.
+// The plan is empty when oldName does not exist, and it fails if newName is
+// already taken.
+func (m *Manager) PlanMoveParameter(ctx context.Context, oldName, newName string) (*MovePlan, error) {
+	plan := &MovePlan{OldPrefix: oldName, NewPrefix: newName}
+	param, err := m.paramStore.GetParameter(ctx, oldName, true)
+	if err != nil {
+		if errors.IsNotFoundError(err) {
+			return plan, nil
+		}
+		return nil, errors.WrapAWSError(err, "get parameter", oldName)
+	}
+	if _, err := m.paramStore.GetParameter(ctx, newName, true); err == nil {
+		return nil, fmt.Errorf("parameter %s already exists: %w", newName, ErrKeyExists)
+	} else if !errors.IsNotFoundError(err) {
+		return nil, errors.WrapAWSError(err, "get parameter", newName)
+	}
+
+	plan.Moves = []Move{{From: oldName, To: newName, param: param}}
+	return plan, nil
+}
+
+// CopyPrefix copies every parameter of the plan, keeping its type,
+// description, KMS key, tier and tags, and verifies each copy. It returns
+// the parameters copied, which are complete even when it fails part way.
+func (m *Manager) CopyPrefix(ctx context.Context, plan *MovePlan) ([]string, error) {
+	var copied []string
+	for _, move := range plan.Moves {
+		if err := m.copyParameter(ctx, move.param, move.To); err != nil {
+			return copied, err
+		}
+		copied = append(copied, move.To)
+	}
+	return copied, nil
+}
+
+// DeleteMoved deletes the original parameters of a plan once every copy
+// has been verified. Each copy is read again first, so an original is never
+// deleted without a matching copy.
+func (m *Manager) DeleteMoved(ctx context.Context, plan *MovePlan) error {
+	for _, move := range plan.Moves {
+		copied, err := m.paramStore.GetParameter(ctx, move.To, true)
+		if err != nil {
+			return errors.WrapAWSError(err, "verify parameter", move.To)
+		}
+		if copied.Value != move.param.Value {
+			return fmt.Errorf("verification failed for %s: value does not match %s, original kept", move.To, move.From)
+		}
+		if err := m.paramStore.DeleteParameter(ctx, move.From); err != nil && !errors.IsNotFoundError(err) {
+			return errors.WrapAWSError(err, "delete parameter", move.From)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePrefix(t *testing.T) {
+	prefix, err := NormalizePrefix("/myapp/prod")
+	require.NoError(t, err)
+	assert.Equal(t, "/myapp/prod/", prefix)
+
+	prefix, err = NormalizePrefix("/myapp/")
+	require.NoError(t, err)
+	assert.Equal(t, "/myapp/", prefix)
+
+	for _, invalid := range []string{"myapp/", "/", "/myapp//prod/"} {
+		_, err := NormalizePrefix(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestMovePlan_Keys(t *testing.T) {
+	plan := &MovePlan{Moves: []Move{
+		{From: "/old/prod/API_KEY", To: "/new/prod/API_KEY"},
+		{From: "/old/PORT", To: "/new/PORT"},
+	}}
+	assert.Equal(t, []string{"API_KEY", "PORT"}, plan.Keys())
+}
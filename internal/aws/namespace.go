@@ -0,0 +1,70 @@
+package aws
+
+import "strings"
+
+// namespacePrefix returns the prefix of the project's keys in AWS, empty
+// unless the configuration sets a namespace
+func (m *Manager) namespacePrefix() string {
+	if m.config == nil || m.config.Namespace == "" {
+		return ""
+	}
+	return m.config.Namespace + "."
+}
+
+// RemoteKey returns the name of a variable in AWS, prefixed with the
+// namespace if the configuration sets one
+func (m *Manager) RemoteKey(key string) string {
+	return m.namespacePrefix() + key
+}
+
+// LocalKey returns the variable a key read from AWS holds, and false when
+// the key belongs to another application sharing the path or secret
+func (m *Manager) LocalKey(remote string) (string, bool) {
+	prefix := m.namespacePrefix()
+	if prefix == "" {
+		return remote, true
+	}
+	if !strings.HasPrefix(remote, prefix) || len(remote) == len(prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(remote, prefix), true
+}
+
+// localVars returns the variables of the project's namespace among
+// variables read from AWS, without the prefix. Keys of other namespaces, and
+// keys without one, belong to other applications sharing the path or
+// secret and are dropped.
+func (m *Manager) localVars(remote map[string]string) map[string]string {
+	prefix := m.namespacePrefix()
+	if prefix == "" {
+		return remote
+	}
+
+	vars := make(map[string]string)
+	for name, value := range remote {
+		if key, ok := m.LocalKey(name); ok {
+			vars[key] = value
+		}
+	}
+	return vars
+}
+
+// mergeNamespace returns the content of a shared secret with the project's
+// variables replaced by vars, keeping the keys of other applications
+func (m *Manager) mergeNamespace(current, vars map[string]string) map[string]string {
+	prefix := m.namespacePrefix()
+	if prefix == "" {
+		return vars
+	}
+
+	merged := make(map[string]string, len(current)+len(vars))
+	for key, value := range current {
+		if !strings.HasPrefix(key, prefix) {
+			merged[key] = value
+		}
+	}
+	for key, value := range vars {
+		merged[prefix+key] = value
+	}
+	return merged
+}
@@ -0,0 +1,47 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespaceKeys(t *testing.T) {
+	m := &Manager{config: &config.Config{Namespace: "billing-service"}}
+
+	assert.Equal(t, "billing-service.DB_URL", m.RemoteKey("DB_URL"))
+
+	key, ok := m.LocalKey("billing-service.DB_URL")
+	assert.True(t, ok)
+	assert.Equal(t, "DB_URL", key)
+
+	for _, remote := range []string{"DB_URL", "orders.DB_URL", "billing-service."} {
+		_, ok := m.LocalKey(remote)
+		assert.False(t, ok, remote)
+	}
+
+	plain := &Manager{config: &config.Config{}}
+	assert.Equal(t, "DB_URL", plain.RemoteKey("DB_URL"))
+	key, ok = plain.LocalKey("orders.DB_URL")
+	assert.True(t, ok)
+	assert.Equal(t, "orders.DB_URL", key)
+}
+
+func TestNamespaceVars(t *testing.T) {
+	m := &Manager{config: &config.Config{Namespace: "billing-service"}}
+
+	shared := map[string]string{
+		"billing-service.DB_URL": "postgres://billing",
+		"orders.DB_URL":          "postgres://orders",
+		"LOG_LEVEL":              "info",
+	}
+	assert.Equal(t, map[string]string{"DB_URL": "postgres://billing"}, m.localVars(shared))
+
+	merged := m.mergeNamespace(shared, map[string]string{"API_KEY": "secret"})
+	assert.Equal(t, map[string]string{
+		"billing-service.API_KEY": "secret",
+		"orders.DB_URL":           "postgres://orders",
+		"LOG_LEVEL":               "info",
+	}, merged)
+}
@@ -85,7 +85,7 @@ func (m *ParallelManager) PushEnvironmentParallel(
 	// Convert to map
 	vars := file.ToMap()
 
-	log.Info("並列プッシュ開始",
+	log.Named("aws").Info("並列プッシュ開始",
 		zap.String("environment", envName),
 		zap.String("service", service),
 		zap.Int("variables", len(vars)),
@@ -134,7 +134,7 @@ func (m *ParallelManager) pushToParameterStoreParallel(
 		operations = append(operations, &paramOp{
 			Key:   key,
 			Value: value,
-			Path:  path + key,
+			Path:  path + m.RemoteKey(key),
 		})
 	}
 
@@ -159,7 +159,7 @@ func (m *ParallelManager) pushToParameterStoreParallel(
 
 				// Determine parameter type
 				paramType := "String"
-				if isSensitiveKey(op.Key) {
+				if m.IsSensitive(op.Key) {
 					paramType = "SecureString"
 				}
 
@@ -176,7 +176,7 @@ func (m *ParallelManager) pushToParameterStoreParallel(
 
 				// Determine parameter type
 				paramType := "String"
-				if isSensitiveKey(op.Key) {
+				if m.IsSensitive(op.Key) {
 					paramType = "SecureString"
 				}
 
@@ -208,7 +208,7 @@ func (m *ParallelManager) pushToParameterStoreParallel(
 	}
 
 	if errorCount > 0 {
-		log.Error("一部のパラメータのプッシュに失敗",
+		log.Named("aws").Error("一部のパラメータのプッシュに失敗",
 			zap.Int("failed", errorCount),
 			zap.Int("total", len(operations)),
 		)
@@ -219,7 +219,7 @@ func (m *ParallelManager) pushToParameterStoreParallel(
 		}
 	}
 
-	log.Info("並列プッシュ完了",
+	log.Named("aws").Info("並列プッシュ完了",
 		zap.Int("succeeded", len(operations)-errorCount),
 		zap.Int("failed", errorCount),
 	)
@@ -243,7 +243,7 @@ func (m *ParallelManager) PullEnvironmentParallel(
 	service := m.config.GetAWSService(envName)
 	path := m.config.GetParameterPath(envName)
 
-	log.Info("並列プル開始",
+	log.Named("aws").Info("並列プル開始",
 		zap.String("environment", envName),
 		zap.String("service", service),
 	)
@@ -355,7 +355,7 @@ func (m *ParallelManager) pullFromParameterStoreParallel(
 	for _, result := range processResults {
 		if result.Error != nil {
 			errorCount++
-			log.Error("パラメータ取得エラー",
+			log.Named("aws").Error("パラメータ取得エラー",
 				zap.String("parameter", result.Task.Name()),
 				zap.Error(result.Error),
 			)
@@ -363,13 +363,13 @@ func (m *ParallelManager) pullFromParameterStoreParallel(
 	}
 
 	if errorCount > 0 {
-		log.Warn("一部のパラメータの取得に失敗",
+		log.Named("aws").Warn("一部のパラメータの取得に失敗",
 			zap.Int("failed", errorCount),
 			zap.Int("total", len(operations)),
 		)
 	}
 
-	return results, nil
+	return m.localVars(results), nil
 }
 
 // ListEnvironmentsParallel lists variables for multiple environments in parallel
@@ -511,7 +511,7 @@ func (m *ParallelManager) ValidateEnvironmentsParallel(
 	// Check results
 	for _, result := range processResults {
 		if result.Error != nil {
-			log.Warn("環境検証エラー",
+			log.Named("aws").Warn("環境検証エラー",
 				zap.String("environment", result.Task.Name()),
 				zap.Error(result.Error),
 			)
@@ -521,23 +521,6 @@ func (m *ParallelManager) ValidateEnvironmentsParallel(
 	return validationErrors, nil
 }
 
-// isSensitiveKey checks if a key contains sensitive information
-func isSensitiveKey(key string) bool {
-	lowerKey := strings.ToLower(key)
-	sensitivePatterns := []string{
-		"password", "secret", "key", "token",
-		"credential", "auth", "private", "cert",
-	}
-
-	for _, pattern := range sensitivePatterns {
-		if strings.Contains(lowerKey, pattern) {
-			return true
-		}
-	}
-
-	return false
-}
-
 // PutParameter puts a single parameter to Parameter Store
 func (m *ParallelManager) PutParameter(ctx context.Context, name, value, paramType string, overwrite bool) error {
 	return m.paramStore.PutParameter(ctx, name, value, "", paramType, overwrite)
@@ -0,0 +1,133 @@
+package parameter_store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MaxValueSize is the largest value, in bytes, a standard tier parameter holds
+const MaxValueSize = 4096
+
+// referencePrefix marks a parameter whose value is stored in Secrets Manager.
+// The rest of the value is the secret name.
+const referencePrefix = "envy:secretsmanager:"
+
+// largeValueSecretPrefix namespaces the secrets holding large values so they
+// never collide with environments stored in Secrets Manager
+const largeValueSecretPrefix = "envy/large-values"
+
+// LargeValueStore stores values too large for Parameter Store
+type LargeValueStore interface {
+	PutValue(ctx context.Context, name, value string) error
+	GetValue(ctx context.Context, name string) (string, error)
+	DeleteValue(ctx context.Context, name string) error
+}
+
+// SetLargeValueStore makes values over MaxValueSize go to store, with a
+// reference parameter written in their place. Reads with decryption return
+// the stored value, so callers never see the reference.
+func (s *Store) SetLargeValueStore(store LargeValueStore) {
+	s.large = store
+}
+
+// LargeValueSecretName returns the secret holding the value of a parameter
+func LargeValueSecretName(paramName string) string {
+	return largeValueSecretPrefix + "/" + strings.TrimPrefix(paramName, "/")
+}
+
+// IsLargeValue reports whether a parameter refers to a large value in
+// Secrets Manager, which is encrypted whatever the parameter's type
+func (s *Store) IsLargeValue(ctx context.Context, name string) bool {
+	return strings.HasPrefix(s.rawValue(ctx, name), referencePrefix)
+}
+
+// referencedSecret returns the secret a parameter value refers to. Only the
+// parameter's own secret under largeValueSecretPrefix is accepted, so a
+// hand-written reference cannot make envy read or delete any other secret.
+func referencedSecret(paramName, value string) (string, bool, error) {
+	if !strings.HasPrefix(value, referencePrefix) {
+		return "", false, nil
+	}
+	secretName := strings.TrimPrefix(value, referencePrefix)
+	if secretName != LargeValueSecretName(paramName) {
+		return "", false, fmt.Errorf("parameter %s refers to secret %q instead of %q", paramName, secretName, LargeValueSecretName(paramName))
+	}
+	return secretName, true, nil
+}
+
+// putLargeValue stores an oversized value in the large value store and
+// writes a reference to it in the parameter. The reference is not sensitive
+// and is written as a String so it can be recognized without decryption.
+func (s *Store) putLargeValue(ctx context.Context, name, value, description string, overwrite bool) error {
+	if s.large == nil {
+		return fmt.Errorf("value of parameter %s is %d bytes, over the Parameter Store limit of %d bytes (set aws.large_values_to_secrets_manager in .envyrc to store it in Secrets Manager)",
+			name, len(value), MaxValueSize)
+	}
+
+	secretName := LargeValueSecretName(name)
+	ref := referencePrefix + secretName
+
+	// Without overwrite, create the parameter first so an existing one
+	// fails before the value it refers to is replaced
+	if !overwrite {
+		if err := s.PutParameter(ctx, name, ref, description, "String", false); err != nil {
+			return err
+		}
+	}
+
+	if err := s.large.PutValue(ctx, secretName, value); err != nil {
+		if !overwrite {
+			_ = s.DeleteParameter(ctx, name)
+		}
+		return fmt.Errorf("failed to store large value of parameter %s: %w", name, err)
+	}
+
+	if overwrite {
+		return s.PutParameter(ctx, name, ref, description, "String", true)
+	}
+	return nil
+}
+
+// resolveReferences replaces reference values with the values they refer to
+func (s *Store) resolveReferences(ctx context.Context, parameters []*Parameter) error {
+	if s.large == nil {
+		return nil
+	}
+
+	for _, param := range parameters {
+		secretName, ok, err := referencedSecret(param.Name, param.Value)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		value, err := s.large.GetValue(ctx, secretName)
+		if err != nil {
+			return fmt.Errorf("failed to read large value of parameter %s: %w", param.Name, err)
+		}
+		param.Value = value
+	}
+	return nil
+}
+
+// releaseLargeValue deletes the secret a parameter referred to, once the
+// parameter has been deleted or overwritten with a small value. A foreign
+// reference is reported and its secret left alone.
+func (s *Store) releaseLargeValue(ctx context.Context, name, previous string) error {
+	if s.large == nil {
+		return nil
+	}
+	secretName, ok, err := referencedSecret(name, previous)
+	if err != nil {
+		return fmt.Errorf("not deleting the secret: %w", err)
+	}
+	if !ok {
+		return nil
+	}
+	if err := s.large.DeleteValue(ctx, secretName); err != nil {
+		return fmt.Errorf("failed to delete large value of parameter %s: %w", name, err)
+	}
+	return nil
+}
@@ -2,8 +2,13 @@ package parameter_store
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
@@ -15,6 +20,38 @@ import (
 type Store struct {
 	client    *client.Client
 	ssmClient *ssm.Client
+	large     LargeValueStore
+	defaults  map[string]PathDefaults
+}
+
+// PathDefaults are the KMS key and tags of parameters written under a path
+type PathDefaults struct {
+	KeyID string            // KMS key of SecureStrings, the account default when empty
+	Tags  map[string]string // added to parameters when they are created
+}
+
+// SetPathDefaults makes writes under path encrypt SecureStrings with the
+// KMS key of defaults and tag the parameters they create
+func (s *Store) SetPathDefaults(path string, defaults PathDefaults) {
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	if s.defaults == nil {
+		s.defaults = make(map[string]PathDefaults)
+	}
+	s.defaults[path] = defaults
+}
+
+// Defaults returns the defaults of the most specific path containing name
+func (s *Store) Defaults(name string) PathDefaults {
+	var match string
+	var defaults PathDefaults
+	for path, d := range s.defaults {
+		if strings.HasPrefix(name, path) && len(path) > len(match) {
+			match, defaults = path, d
+		}
+	}
+	return defaults
 }
 
 // NewStore creates a new Parameter Store client
@@ -35,6 +72,40 @@ type Parameter struct {
 	Description  string
 }
 
+// ParameterMetadata represents parameter metadata without its value
+type ParameterMetadata struct {
+	Name         string
+	Type         string
+	KeyID        string // KMS key of a SecureString
+	Tier         string
+	Description  string
+	Version      int64
+	LastModified time.Time
+}
+
+// ParameterVersion is one version of a parameter from its history
+type ParameterVersion struct {
+	Version          int64
+	Value            string
+	Type             string
+	LastModified     time.Time
+	LastModifiedUser string // ARN of the user or role that wrote the version
+	Labels           []string
+
+	// LargeValue marks a value stored in Secrets Manager. Value is the
+	// reference, since the secret only holds the latest large value.
+	LargeValue bool
+}
+
+// CreateOptions are the attributes of a parameter created by CreateParameter
+type CreateOptions struct {
+	Type        string
+	Description string
+	KeyID       string // KMS key of a SecureString, the account default when empty
+	Tier        string // Standard tier when empty
+	Tags        map[string]string
+}
+
 // GetParameter retrieves a single parameter
 func (s *Store) GetParameter(ctx context.Context, name string, withDecryption bool) (*Parameter, error) {
 	input := &ssm.GetParameterInput{
@@ -51,19 +122,96 @@ func (s *Store) GetParameter(ctx context.Context, name string, withDecryption bo
 		return nil, fmt.Errorf("parameter %s not found", name)
 	}
 
-	return &Parameter{
+	param := &Parameter{
 		Name:         aws.ToString(result.Parameter.Name),
 		Value:        aws.ToString(result.Parameter.Value),
 		Type:         string(result.Parameter.Type),
 		Version:      result.Parameter.Version,
 		LastModified: result.Parameter.LastModifiedDate.Format("2006-01-02 15:04:05"),
-	}, nil
+	}
+
+	if withDecryption {
+		if err := s.resolveReferences(ctx, []*Parameter{param}); err != nil {
+			return nil, err
+		}
+	}
+
+	return param, nil
+}
+
+// GetParameterHistory returns every version of a parameter that Parameter
+// Store keeps (the last 100), oldest first
+func (s *Store) GetParameterHistory(ctx context.Context, name string, withDecryption bool) ([]*ParameterVersion, error) {
+	var versions []*ParameterVersion
+	var nextToken *string
+
+	for {
+		result, err := s.ssmClient.GetParameterHistory(ctx, &ssm.GetParameterHistoryInput{
+			Name:           aws.String(name),
+			WithDecryption: aws.Bool(withDecryption),
+			NextToken:      nextToken,
+			MaxResults:     aws.Int32(50),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get history of parameter %s: %w", name, err)
+		}
+
+		for _, param := range result.Parameters {
+			version := &ParameterVersion{
+				Version:          param.Version,
+				Value:            aws.ToString(param.Value),
+				Type:             string(param.Type),
+				LastModifiedUser: aws.ToString(param.LastModifiedUser),
+				Labels:           param.Labels,
+			}
+			if param.LastModifiedDate != nil {
+				version.LastModified = *param.LastModifiedDate
+			}
+			version.LargeValue = strings.HasPrefix(version.Value, referencePrefix)
+			versions = append(versions, version)
+		}
+
+		nextToken = result.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	return versions, nil
 }
 
 // GetParametersByPath retrieves all parameters under a specific path
 func (s *Store) GetParametersByPath(ctx context.Context, path string, recursive bool, withDecryption bool) ([]*Parameter, error) {
 	var parameters []*Parameter
+	err := s.WalkParametersByPath(ctx, path, recursive, withDecryption, func(page []*Parameter) error {
+		parameters = append(parameters, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parameters, nil
+}
+
+// WalkParametersByPath calls fn with each page of the parameters under a
+// path as it is read, so callers can show the first ones before the rest
+// arrive. An error from fn stops the walk and is returned.
+func (s *Store) WalkParametersByPath(ctx context.Context, path string, recursive bool, withDecryption bool, fn func(page []*Parameter) error) error {
+	return s.WalkParametersByPathFrom(ctx, path, recursive, withDecryption, "", func(page []*Parameter, _ string) error {
+		return fn(page)
+	})
+}
+
+// WalkParametersByPathFrom is WalkParametersByPath starting at the page of
+// token, a next token an earlier walk passed to fn, so an interrupted walk
+// can continue where it stopped. fn also gets the token of the page after
+// the current one, "" after the last page.
+func (s *Store) WalkParametersByPathFrom(ctx context.Context, path string, recursive bool, withDecryption bool, token string, fn func(page []*Parameter, nextToken string) error) error {
 	var nextToken *string
+	if token != "" {
+		nextToken = aws.String(token)
+	}
 
 	// Ensure path ends with /
 	if !strings.HasSuffix(path, "/") {
@@ -81,11 +229,12 @@ func (s *Store) GetParametersByPath(ctx context.Context, path string, recursive
 
 		result, err := s.ssmClient.GetParametersByPath(ctx, input)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get parameters by path %s: %w", path, err)
+			return fmt.Errorf("failed to get parameters by path %s: %w", path, err)
 		}
 
+		page := make([]*Parameter, 0, len(result.Parameters))
 		for _, param := range result.Parameters {
-			parameters = append(parameters, &Parameter{
+			page = append(page, &Parameter{
 				Name:         aws.ToString(param.Name),
 				Value:        aws.ToString(param.Value),
 				Type:         string(param.Type),
@@ -94,13 +243,28 @@ func (s *Store) GetParametersByPath(ctx context.Context, path string, recursive
 			})
 		}
 
+		if withDecryption {
+			if err := s.resolveReferences(ctx, page); err != nil {
+				return err
+			}
+		}
 		nextToken = result.NextToken
+		if len(page) > 0 {
+			if err := fn(page, aws.ToString(nextToken)); err != nil {
+				return err
+			}
+		}
 		if nextToken == nil {
-			break
+			return nil
 		}
 	}
+}
 
-	return parameters, nil
+// IsInvalidNextToken reports whether err is Parameter Store refusing a
+// next token, e.g. one that expired before an interrupted walk resumed
+func IsInvalidNextToken(err error) bool {
+	var invalid *types.InvalidNextToken
+	return errors.As(err, &invalid)
 }
 
 // PutParameter creates or updates a parameter
@@ -109,6 +273,16 @@ func (s *Store) PutParameter(ctx context.Context, name, value, description strin
 		paramType = "String"
 	}
 
+	if len(value) > MaxValueSize {
+		return s.putLargeValue(ctx, name, value, description, overwrite)
+	}
+
+	// Remember a previous large value so its secret can be released
+	var previous string
+	if s.large != nil && overwrite {
+		previous = s.rawValue(ctx, name)
+	}
+
 	// Convert string type to AWS type
 	var awsType types.ParameterType
 	switch paramType {
@@ -132,17 +306,113 @@ func (s *Store) PutParameter(ctx context.Context, name, value, description strin
 	if description != "" {
 		input.Description = aws.String(description)
 	}
+	defaults := s.Defaults(name)
+	if defaults.KeyID != "" && awsType == types.ParameterTypeSecureString {
+		input.KeyId = aws.String(defaults.KeyID)
+	}
 
-	_, err := s.ssmClient.PutParameter(ctx, input)
+	result, err := s.ssmClient.PutParameter(ctx, input)
 	if err != nil {
 		return fmt.Errorf("failed to put parameter %s: %w", name, err)
 	}
+	// Tags cannot be given with Overwrite, so new parameters are tagged after
+	if result.Version == 1 {
+		if err := s.AddTags(ctx, name, defaults.Tags); err != nil {
+			return err
+		}
+	}
+
+	if previous == value {
+		return nil
+	}
+	return s.releaseLargeValue(ctx, name, previous)
+}
+
+// CreateParameter creates a parameter that must not exist yet. Unlike
+// PutParameter it sets the KMS key, tier and tags of the new parameter.
+// The path defaults fill in the KMS key and tags opts leave out.
+func (s *Store) CreateParameter(ctx context.Context, name, value string, opts CreateOptions) error {
+	defaults := s.Defaults(name)
+	if opts.KeyID == "" {
+		opts.KeyID = defaults.KeyID
+	}
+	if len(defaults.Tags) > 0 {
+		tags := make(map[string]string, len(defaults.Tags)+len(opts.Tags))
+		for key, value := range defaults.Tags {
+			tags[key] = value
+		}
+		for key, value := range opts.Tags {
+			tags[key] = value
+		}
+		opts.Tags = tags
+	}
+
+	if len(value) > MaxValueSize {
+		if err := s.putLargeValue(ctx, name, value, opts.Description, false); err != nil {
+			return err
+		}
+		return s.AddTags(ctx, name, opts.Tags)
+	}
+
+	paramType := opts.Type
+	if paramType == "" {
+		paramType = "String"
+	}
 
+	input := &ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String(value),
+		Type:      types.ParameterType(paramType),
+		Overwrite: aws.Bool(false),
+	}
+	if opts.Description != "" {
+		input.Description = aws.String(opts.Description)
+	}
+	if opts.KeyID != "" && paramType == "SecureString" {
+		input.KeyId = aws.String(opts.KeyID)
+	}
+	if opts.Tier != "" {
+		input.Tier = types.ParameterTier(opts.Tier)
+	}
+	input.Tags = toTags(opts.Tags)
+
+	if _, err := s.ssmClient.PutParameter(ctx, input); err != nil {
+		return fmt.Errorf("failed to put parameter %s: %w", name, err)
+	}
+	return nil
+}
+
+// ChangeKey re-encrypts a SecureString with another KMS key by writing
+// its value again as a new version
+func (s *Store) ChangeKey(ctx context.Context, name, keyID string) error {
+	param, err := s.GetParameter(ctx, name, true)
+	if err != nil {
+		return err
+	}
+	if param.Type != "SecureString" {
+		return fmt.Errorf("parameter %s is a %s, not a SecureString", name, param.Type)
+	}
+
+	_, err = s.ssmClient.PutParameter(ctx, &ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String(param.Value),
+		Type:      types.ParameterTypeSecureString,
+		KeyId:     aws.String(keyID),
+		Overwrite: aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to re-encrypt parameter %s: %w", name, err)
+	}
 	return nil
 }
 
 // DeleteParameter deletes a parameter
 func (s *Store) DeleteParameter(ctx context.Context, name string) error {
+	var previous string
+	if s.large != nil {
+		previous = s.rawValue(ctx, name)
+	}
+
 	input := &ssm.DeleteParameterInput{
 		Name: aws.String(name),
 	}
@@ -152,7 +422,20 @@ func (s *Store) DeleteParameter(ctx context.Context, name string) error {
 		return fmt.Errorf("failed to delete parameter %s: %w", name, err)
 	}
 
-	return nil
+	return s.releaseLargeValue(ctx, name, previous)
+}
+
+// rawValue returns a parameter's stored value without decryption or
+// resolving references, or "" if it cannot be read
+func (s *Store) rawValue(ctx context.Context, name string) string {
+	result, err := s.ssmClient.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(false),
+	})
+	if err != nil || result.Parameter == nil {
+		return ""
+	}
+	return aws.ToString(result.Parameter.Value)
 }
 
 // DeleteParametersByPath deletes all parameters under a path
@@ -225,3 +508,174 @@ func (s *Store) ConvertToEnvVars(parameters []*Parameter, stripPrefix string) ma
 
 	return envVars
 }
+
+// DescribeParametersByPath lists parameter metadata under a path without
+// fetching or decrypting values. It is much cheaper than GetParametersByPath
+// and is used to detect remote changes.
+func (s *Store) DescribeParametersByPath(ctx context.Context, path string) ([]*ParameterMetadata, error) {
+	// The Path filter does not accept a trailing slash
+	filterPath := path
+	if filterPath != "/" {
+		filterPath = strings.TrimSuffix(filterPath, "/")
+	}
+
+	metadata, err := s.describeParameters(ctx, []types.ParameterStringFilter{
+		{
+			Key:    aws.String("Path"),
+			Option: aws.String("Recursive"),
+			Values: []string{filterPath},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe parameters under %s: %w", path, err)
+	}
+	return metadata, nil
+}
+
+// DescribeAllParameters lists the metadata of every parameter in the
+// region, including names outside a path hierarchy
+func (s *Store) DescribeAllParameters(ctx context.Context) ([]*ParameterMetadata, error) {
+	metadata, err := s.describeParameters(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe parameters: %w", err)
+	}
+	return metadata, nil
+}
+
+func (s *Store) describeParameters(ctx context.Context, filters []types.ParameterStringFilter) ([]*ParameterMetadata, error) {
+	var metadata []*ParameterMetadata
+	var nextToken *string
+
+	for {
+		result, err := s.ssmClient.DescribeParameters(ctx, &ssm.DescribeParametersInput{
+			ParameterFilters: filters,
+			NextToken:        nextToken,
+			MaxResults:       aws.Int32(50),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, param := range result.Parameters {
+			metadata = append(metadata, toMetadata(param))
+		}
+
+		nextToken = result.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+
+	return metadata, nil
+}
+
+// DescribeParameter returns the metadata of a single parameter
+func (s *Store) DescribeParameter(ctx context.Context, name string) (*ParameterMetadata, error) {
+	result, err := s.ssmClient.DescribeParameters(ctx, &ssm.DescribeParametersInput{
+		ParameterFilters: []types.ParameterStringFilter{
+			{
+				Key:    aws.String("Name"),
+				Option: aws.String("Equals"),
+				Values: []string{name},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe parameter %s: %w", name, err)
+	}
+	if len(result.Parameters) == 0 {
+		return nil, &types.ParameterNotFound{Message: aws.String("parameter " + name + " not found")}
+	}
+
+	return toMetadata(result.Parameters[0]), nil
+}
+
+func toMetadata(param types.ParameterMetadata) *ParameterMetadata {
+	meta := &ParameterMetadata{
+		Name:        aws.ToString(param.Name),
+		Type:        string(param.Type),
+		KeyID:       aws.ToString(param.KeyId),
+		Tier:        string(param.Tier),
+		Description: aws.ToString(param.Description),
+		Version:     param.Version,
+	}
+	if param.LastModifiedDate != nil {
+		meta.LastModified = *param.LastModifiedDate
+	}
+	return meta
+}
+
+// AddTags adds tags to a parameter
+func (s *Store) AddTags(ctx context.Context, name string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	_, err := s.ssmClient.AddTagsToResource(ctx, &ssm.AddTagsToResourceInput{
+		ResourceType: types.ResourceTypeForTaggingParameter,
+		ResourceId:   aws.String(name),
+		Tags:         toTags(tags),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag parameter %s: %w", name, err)
+	}
+	return nil
+}
+
+// toTags converts a tag map to SSM tags, sorted by key
+func toTags(tags map[string]string) []types.Tag {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]types.Tag, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, types.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	return result
+}
+
+// ListTags returns the tags of a parameter
+func (s *Store) ListTags(ctx context.Context, name string) (map[string]string, error) {
+	result, err := s.ssmClient.ListTagsForResource(ctx, &ssm.ListTagsForResourceInput{
+		ResourceType: types.ResourceTypeForTaggingParameter,
+		ResourceId:   aws.String(name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags of parameter %s: %w", name, err)
+	}
+
+	tags := make(map[string]string, len(result.TagList))
+	for _, tag := range result.TagList {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// Fingerprint returns a stable identifier for a set of parameter versions.
+// Any put, delete or new parameter under the path changes the fingerprint.
+func Fingerprint(metadata []*ParameterMetadata) string {
+	entries := make([]string, 0, len(metadata))
+	var latest time.Time
+	for _, meta := range metadata {
+		entries = append(entries, fmt.Sprintf("%s@%d", meta.Name, meta.Version))
+		if meta.LastModified.After(latest) {
+			latest = meta.LastModified
+		}
+	}
+	sort.Strings(entries)
+
+	hasher := sha256.New()
+	for _, entry := range entries {
+		hasher.Write([]byte(entry))
+		hasher.Write([]byte{'\n'})
+	}
+
+	return fmt.Sprintf("n%d-t%d-%s", len(entries), latest.Unix(), hex.EncodeToString(hasher.Sum(nil))[:16])
+}
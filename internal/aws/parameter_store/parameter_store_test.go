@@ -2,10 +2,18 @@ package parameter_store
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockSSMClient is a mock implementation of SSM client
@@ -53,4 +61,169 @@ func (m *MockSSMClient) DeleteParametersByPath(ctx context.Context, prefix strin
 func TestNewStore(t *testing.T) {
 	// Skip this test as it requires actual AWS client
 	t.Skip("Skipping test that requires AWS client")
-}
\ No newline at end of file
+}
+
+func TestFingerprint(t *testing.T) {
+	modified := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	base := []*ParameterMetadata{
+		{Name: "/myapp/dev/API_URL", Version: 3, LastModified: modified},
+		{Name: "/myapp/dev/DB_HOST", Version: 1, LastModified: modified.Add(-time.Hour)},
+	}
+
+	t.Run("stable regardless of order", func(t *testing.T) {
+		reordered := []*ParameterMetadata{base[1], base[0]}
+		assert.Equal(t, Fingerprint(base), Fingerprint(reordered))
+	})
+
+	t.Run("version bump changes fingerprint", func(t *testing.T) {
+		bumped := []*ParameterMetadata{
+			{Name: "/myapp/dev/API_URL", Version: 4, LastModified: modified},
+			base[1],
+		}
+		assert.NotEqual(t, Fingerprint(base), Fingerprint(bumped))
+	})
+
+	t.Run("replaced parameter changes fingerprint", func(t *testing.T) {
+		replaced := []*ParameterMetadata{
+			base[0],
+			{Name: "/myapp/dev/DB_PORT", Version: 1, LastModified: modified.Add(-time.Hour)},
+		}
+		assert.NotEqual(t, Fingerprint(base), Fingerprint(replaced))
+	})
+
+	t.Run("empty path", func(t *testing.T) {
+		assert.Contains(t, Fingerprint(nil), "n0-")
+	})
+}
+
+// fakeLargeValues is an in-memory LargeValueStore
+type fakeLargeValues map[string]string
+
+func (f fakeLargeValues) PutValue(ctx context.Context, name, value string) error {
+	f[name] = value
+	return nil
+}
+
+func (f fakeLargeValues) GetValue(ctx context.Context, name string) (string, error) {
+	value, ok := f[name]
+	if !ok {
+		return "", errors.New("secret not found")
+	}
+	return value, nil
+}
+
+func (f fakeLargeValues) DeleteValue(ctx context.Context, name string) error {
+	delete(f, name)
+	return nil
+}
+
+func TestLargeValueReferences(t *testing.T) {
+	secretName := LargeValueSecretName("/myapp/prod/TLS_CERT")
+	assert.Equal(t, "envy/large-values/myapp/prod/TLS_CERT", secretName)
+
+	name, ok, err := referencedSecret("/myapp/prod/TLS_CERT", referencePrefix+secretName)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, secretName, name)
+
+	_, ok, err = referencedSecret("/myapp/prod/TLS_CERT", "plain value")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = referencedSecret("/myapp/prod/TLS_CERT", referencePrefix+"prod/database")
+	assert.Error(t, err, "secret outside envy/large-values")
+
+	_, _, err = referencedSecret("/myapp/prod/TLS_CERT", referencePrefix+LargeValueSecretName("/myapp/prod/OTHER"))
+	assert.Error(t, err, "another parameter's secret")
+}
+
+func TestResolveReferences(t *testing.T) {
+	cert := strings.Repeat("x", MaxValueSize+1)
+	secretName := LargeValueSecretName("/myapp/prod/TLS_CERT")
+
+	t.Run("replaces references", func(t *testing.T) {
+		s := &Store{large: fakeLargeValues{secretName: cert}}
+		params := []*Parameter{
+			{Name: "/myapp/prod/TLS_CERT", Value: referencePrefix + secretName},
+			{Name: "/myapp/prod/API_URL", Value: "https://api.example.com"},
+		}
+
+		require.NoError(t, s.resolveReferences(context.Background(), params))
+		assert.Equal(t, cert, params[0].Value)
+		assert.Equal(t, "https://api.example.com", params[1].Value)
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		s := &Store{large: fakeLargeValues{}}
+		params := []*Parameter{{Name: "/myapp/prod/TLS_CERT", Value: referencePrefix + secretName}}
+
+		err := s.resolveReferences(context.Background(), params)
+		assert.ErrorContains(t, err, "/myapp/prod/TLS_CERT")
+	})
+
+	t.Run("foreign reference", func(t *testing.T) {
+		s := &Store{large: fakeLargeValues{"prod/database": "password"}}
+		params := []*Parameter{{Name: "/myapp/prod/TLS_CERT", Value: referencePrefix + "prod/database"}}
+
+		err := s.resolveReferences(context.Background(), params)
+		assert.ErrorContains(t, err, "prod/database")
+		assert.Equal(t, referencePrefix+"prod/database", params[0].Value)
+	})
+
+	t.Run("disabled leaves references", func(t *testing.T) {
+		s := &Store{}
+		params := []*Parameter{{Name: "/myapp/prod/TLS_CERT", Value: referencePrefix + secretName}}
+
+		require.NoError(t, s.resolveReferences(context.Background(), params))
+		assert.Equal(t, referencePrefix+secretName, params[0].Value)
+	})
+}
+
+func TestPutLargeValueDisabled(t *testing.T) {
+	s := &Store{}
+	err := s.PutParameter(context.Background(), "/myapp/prod/TLS_CERT", strings.Repeat("x", MaxValueSize+1), "", "String", true)
+	assert.ErrorContains(t, err, "large_values_to_secrets_manager")
+}
+
+func TestReleaseLargeValue(t *testing.T) {
+	secretName := LargeValueSecretName("/myapp/prod/TLS_CERT")
+	large := fakeLargeValues{secretName: "old", "other": "kept"}
+	s := &Store{large: large}
+
+	require.NoError(t, s.releaseLargeValue(context.Background(), "/myapp/prod/TLS_CERT", "small value"))
+	assert.Len(t, large, 2)
+
+	require.NoError(t, s.releaseLargeValue(context.Background(), "/myapp/prod/TLS_CERT", referencePrefix+secretName))
+	assert.Equal(t, fakeLargeValues{"other": "kept"}, large)
+
+	err := s.releaseLargeValue(context.Background(), "/myapp/prod/TLS_CERT", referencePrefix+"other")
+	assert.Error(t, err)
+	assert.Equal(t, fakeLargeValues{"other": "kept"}, large, "foreign secret is not deleted")
+}
+
+func TestToTags(t *testing.T) {
+	assert.Nil(t, toTags(nil))
+
+	tags := toTags(map[string]string{"team": "payments", "env": "prod"})
+	require.Len(t, tags, 2)
+	assert.Equal(t, "env", *tags[0].Key)
+	assert.Equal(t, "prod", *tags[0].Value)
+	assert.Equal(t, "team", *tags[1].Key)
+}
+
+func TestDefaults(t *testing.T) {
+	s := &Store{}
+	assert.Equal(t, PathDefaults{}, s.Defaults("/myapp/prod/DB_PASSWORD"))
+
+	s.SetPathDefaults("/myapp/prod", PathDefaults{KeyID: "alias/prod"})
+	s.SetPathDefaults("/myapp/prod/payments/", PathDefaults{KeyID: "alias/payments", Tags: map[string]string{"team": "payments"}})
+	assert.Equal(t, "alias/prod", s.Defaults("/myapp/prod/DB_PASSWORD").KeyID)
+	assert.Equal(t, "alias/payments", s.Defaults("/myapp/prod/payments/STRIPE_KEY").KeyID)
+	assert.Empty(t, s.Defaults("/myapp/production/DB_PASSWORD").KeyID)
+}
+
+func TestIsInvalidNextToken(t *testing.T) {
+	err := fmt.Errorf("get parameters by path: %w", &types.InvalidNextToken{Message: aws.String("The token has expired")})
+	assert.True(t, IsInvalidNextToken(err))
+	assert.False(t, IsInvalidNextToken(fmt.Errorf("throttled")))
+}
@@ -0,0 +1,339 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/drapon/envy/internal/aws/errors"
+	"github.com/drapon/envy/internal/aws/parameter_store"
+	"github.com/drapon/envy/internal/cache"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/log"
+	"go.uber.org/zap"
+)
+
+// VersionedCacheTTL bounds how long a version-keyed pull result is kept.
+// The key itself changes whenever a remote parameter or secret version
+// changes, so the TTL only limits how long unused entries linger.
+const VersionedCacheTTL = 24 * time.Hour
+
+// PullCacheKey returns the cache key for an environment pulled at the given
+// remote version (see RemoteVersion)
+func (m *Manager) PullCacheKey(envName, remoteVersion string) string {
+	return cache.NewCacheKeyBuilder("aws_env_version").
+		Add(envName).
+		Add(m.config.GetRegion(envName)).
+		Add(m.config.GetParameterPath(envName)).
+		Add(remoteVersion).
+		Build()
+}
+
+// VersionedCache caches remote values by parameter version, e.g.
+// cache.VersionedStore. Lookup returns the values whose cached version
+// matches and the names to fetch; Update records the fetched values and
+// forgets names that are no longer in versions. Snapshot returns every
+// cached value and when the versions were last checked, without checking
+// them.
+type VersionedCache interface {
+	Lookup(envName, path string, versions map[string]string) (map[string]string, []string, error)
+	Update(envName, path string, versions, fetched map[string]string) error
+	Snapshot(envName, path string) (map[string]string, time.Time, bool)
+}
+
+// PullEnvironmentCached pulls an environment, fetching only the parameters
+// whose version changed since they were cached. Versions are read from
+// metadata, so after a partial push only the pushed parameters are
+// fetched. It returns the number of values fetched from AWS.
+func (m *Manager) PullEnvironmentCached(ctx context.Context, envName string, store VersionedCache) (*env.File, int, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, 0, err
+	}
+	var file *env.File
+	var fetched int
+	err = m.readWithFallback(ctx, envName, func(ctx context.Context, rm *Manager) error {
+		var err error
+		file, fetched, err = rm.pullEnvironmentCached(ctx, envName, store)
+		return err
+	})
+	return file, fetched, err
+}
+
+func (m *Manager) pullEnvironmentCached(ctx context.Context, envName string, store VersionedCache) (*env.File, int, error) {
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	service := m.config.GetAWSService(envName)
+	path := m.config.GetParameterPath(envName)
+
+	var vars map[string]string
+	var fetched int
+	switch {
+	case (service == "secrets_manager" || envConfig.UseSecretsManager) && m.config.SecretPerVariable():
+		vars, fetched, err = m.pullVariableSecretsCached(ctx, envName, path, store)
+	case service == "secrets_manager" || envConfig.UseSecretsManager:
+		vars, fetched, err = m.pullSecretCached(ctx, envName, path, store)
+	default:
+		vars, fetched, err = m.pullParametersCached(ctx, envName, path, store)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return varsFile(vars), fetched, nil
+}
+
+// StalePull is the result of PullEnvironmentStale
+type StalePull struct {
+	File    *env.File
+	Fetched int           // values fetched from AWS
+	Age     time.Duration // age of cached values served without asking AWS
+	Stale   bool          // served past the soft TTL while being refreshed
+}
+
+// PullEnvironmentStale pulls an environment with stale-while-revalidate
+// semantics when it has cache_soft_ttl: cached values checked within the
+// soft TTL are served without asking AWS, and values checked within the
+// hard TTL are served at once while revalidate refreshes them in the
+// background. Otherwise, or when nothing is cached, it waits for
+// PullEnvironmentCached.
+func (m *Manager) PullEnvironmentStale(ctx context.Context, envName string, store VersionedCache, revalidate func()) (*StalePull, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	if soft, hard := envConfig.CacheTTLs(); soft > 0 {
+		if file, verifiedAt, ok := m.cachedEnvironment(envName, store); ok {
+			age := time.Since(verifiedAt)
+			switch {
+			case age < soft:
+				return &StalePull{File: file, Age: age}, nil
+			case age < hard:
+				revalidate()
+				return &StalePull{File: file, Age: age, Stale: true}, nil
+			}
+		}
+	}
+
+	file, fetched, err := m.PullEnvironmentCached(ctx, envName, store)
+	if err != nil {
+		return nil, err
+	}
+	return &StalePull{File: file, Fetched: fetched}, nil
+}
+
+// cachedEnvironment returns the cached variables of an environment and when
+// they were last checked against AWS
+func (m *Manager) cachedEnvironment(envName string, store VersionedCache) (*env.File, time.Time, bool) {
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	path := m.config.GetParameterPath(envName)
+
+	values, verifiedAt, ok := store.Snapshot(envName, path)
+	if !ok {
+		return nil, time.Time{}, false
+	}
+
+	if m.config.GetAWSService(envName) == "secrets_manager" || envConfig.UseSecretsManager {
+		if m.config.SecretPerVariable() {
+			return varsFile(m.variableSecretVars(values, path)), verifiedAt, true
+		}
+		vars, err := secretVars(values, SecretName(path))
+		if err != nil {
+			return nil, time.Time{}, false
+		}
+		return varsFile(vars), verifiedAt, true
+	}
+	return varsFile(m.parameterVars(values, path)), verifiedAt, true
+}
+
+// varsFile returns an env file holding vars
+func varsFile(vars map[string]string) *env.File {
+	file := env.NewFile()
+	for key, value := range vars {
+		file.Set(key, value)
+	}
+	return file
+}
+
+// pullParametersCached pulls a Parameter Store path parameter by parameter.
+// Versions are numbered per region, so the region is part of each version.
+func (m *Manager) pullParametersCached(ctx context.Context, envName, path string, store VersionedCache) (map[string]string, int, error) {
+	metadata, err := m.paramStore.DescribeParametersByPath(ctx, path)
+	if err != nil {
+		return nil, 0, errors.WrapAWSError(err, "describe parameters", path)
+	}
+
+	versions := make(map[string]string, len(metadata))
+	for _, meta := range metadata {
+		versions[meta.Name] = fmt.Sprintf("%s:%d", m.client.Region(), meta.Version)
+	}
+
+	values, fetched, err := pullVersioned(store, envName, path, versions, func(missing []string) (map[string]string, error) {
+		return m.fetchParameters(ctx, path, missing, len(versions))
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return m.parameterVars(values, path), fetched, nil
+}
+
+// parameterVars converts parameter values by name to variables
+func (m *Manager) parameterVars(values map[string]string, path string) map[string]string {
+	parameters := make([]*parameter_store.Parameter, 0, len(values))
+	for name, value := range values {
+		parameters = append(parameters, &parameter_store.Parameter{Name: name, Value: value})
+	}
+	return m.localVars(m.paramStore.ConvertToEnvVars(parameters, path))
+}
+
+// fetchParameters returns the values of the named parameters. When most of
+// the path is missing one paged read is cheaper than a request per name.
+func (m *Manager) fetchParameters(ctx context.Context, path string, names []string, total int) (map[string]string, error) {
+	values := make(map[string]string, len(names))
+	if len(names)*2 > total {
+		wanted := make(map[string]bool, len(names))
+		for _, name := range names {
+			wanted[name] = true
+		}
+		parameters, err := m.paramStore.GetParametersByPath(ctx, path, true, true)
+		if err != nil {
+			return nil, errors.WrapAWSError(err, "get parameters by path", path)
+		}
+		for _, parameter := range parameters {
+			if wanted[parameter.Name] {
+				values[parameter.Name] = parameter.Value
+			}
+		}
+		return values, nil
+	}
+
+	for _, name := range names {
+		parameter, err := m.paramStore.GetParameter(ctx, name, true)
+		if err != nil {
+			if errors.IsNotFoundError(err) {
+				continue // deleted since it was described
+			}
+			return nil, errors.WrapAWSError(err, "get parameter", name)
+		}
+		values[name] = parameter.Value
+	}
+	return values, nil
+}
+
+// pullSecretCached pulls a Secrets Manager environment. The whole secret has
+// one version, so it is cached as a single value.
+func (m *Manager) pullSecretCached(ctx context.Context, envName, path string, store VersionedCache) (map[string]string, int, error) {
+	secretName := SecretName(path)
+
+	versionID, err := m.secretsManager.GetCurrentVersionID(ctx, secretName)
+	if err != nil {
+		return nil, 0, errors.WrapAWSError(err, "describe secret", secretName)
+	}
+
+	versions := map[string]string{secretName: "sm-" + versionID}
+	values, fetched, err := pullVersioned(store, envName, path, versions, func([]string) (map[string]string, error) {
+		vars, err := m.pullFromSecretsManager(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(vars)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{secretName: string(data)}, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	vars, err := secretVars(values, secretName)
+	if err != nil {
+		return nil, 0, err
+	}
+	return vars, fetched, nil
+}
+
+// pullVariableSecretsCached pulls an environment stored one secret per
+// variable, fetching only the secrets whose version changed
+func (m *Manager) pullVariableSecretsCached(ctx context.Context, envName, path string, store VersionedCache) (map[string]string, int, error) {
+	versions, err := m.variableSecretVersions(ctx, path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	values, fetched, err := pullVersioned(store, envName, path, versions, func(missing []string) (map[string]string, error) {
+		return m.fetchVariableSecrets(ctx, path, missing)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return m.variableSecretVars(values, path), fetched, nil
+}
+
+// secretVars decodes the cached value of a secret
+func secretVars(values map[string]string, secretName string) (map[string]string, error) {
+	vars := map[string]string{}
+	if data, ok := values[secretName]; ok {
+		if err := json.Unmarshal([]byte(data), &vars); err != nil {
+			return nil, fmt.Errorf("invalid cached secret %s: %w", secretName, err)
+		}
+	}
+	return vars, nil
+}
+
+// SecretName returns the name of the secret holding an environment stored
+// in Secrets Manager under path
+func SecretName(path string) string {
+	return strings.ReplaceAll(strings.Trim(path, "/"), "/", "-")
+}
+
+// pullVersioned returns the values of versions, served from store where the
+// cached version matches and fetched otherwise. Cache errors only cost a
+// fetch; they never fail the pull.
+func pullVersioned(store VersionedCache, envName, path string, versions map[string]string, fetch func(missing []string) (map[string]string, error)) (map[string]string, int, error) {
+	values, missing, err := store.Lookup(envName, path, versions)
+	if err != nil {
+		log.Named("aws").Debug("Versioned cache lookup failed", zap.String("environment", envName), zap.Error(err))
+		values = make(map[string]string, len(versions))
+		missing = missing[:0]
+		for name := range versions {
+			missing = append(missing, name)
+		}
+	}
+	got := map[string]string{}
+	if len(missing) > 0 {
+		if got, err = fetch(missing); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	fetched := make(map[string]string, len(missing))
+	for _, name := range missing {
+		value, ok := got[name]
+		if !ok {
+			continue
+		}
+		values[name] = value
+		fetched[name] = value
+	}
+
+	// Recorded even when nothing was fetched: the versions were checked
+	if err := store.Update(envName, path, versions, fetched); err != nil {
+		log.Named("aws").Warn("Failed to update versioned cache", zap.String("environment", envName), zap.Error(err))
+	}
+	return values, len(fetched), nil
+}
@@ -0,0 +1,135 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/drapon/envy/internal/cache"
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullVersioned(t *testing.T) {
+	store, err := cache.NewVersionedStore(t.TempDir(), "")
+	require.NoError(t, err)
+
+	remote := map[string]string{"/app/dev/A": "a1", "/app/dev/B": "b1"}
+	versions := map[string]string{"/app/dev/A": "us-east-1:1", "/app/dev/B": "us-east-1:1"}
+
+	var requested []string
+	fetch := func(missing []string) (map[string]string, error) {
+		requested = append([]string{}, missing...)
+		sort.Strings(requested)
+		values := make(map[string]string)
+		for _, name := range missing {
+			if value, ok := remote[name]; ok {
+				values[name] = value
+			}
+		}
+		return values, nil
+	}
+
+	values, fetched, err := pullVersioned(store, "dev", "/app/dev/", versions, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fetched)
+	assert.Equal(t, remote, values)
+
+	// Nothing changed: served from the cache
+	requested = nil
+	values, fetched, err = pullVersioned(store, "dev", "/app/dev/", versions, fetch)
+	require.NoError(t, err)
+	assert.Zero(t, fetched)
+	assert.Nil(t, requested)
+	assert.Equal(t, remote, values)
+
+	// A partial push of B fetches only B
+	remote["/app/dev/B"] = "b2"
+	versions["/app/dev/B"] = "us-east-1:2"
+	values, fetched, err = pullVersioned(store, "dev", "/app/dev/", versions, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fetched)
+	assert.Equal(t, []string{"/app/dev/B"}, requested)
+	assert.Equal(t, "b2", values["/app/dev/B"])
+	assert.Equal(t, "a1", values["/app/dev/A"])
+
+	// A parameter deleted between describe and fetch is left out
+	versions["/app/dev/C"] = "us-east-1:1"
+	values, fetched, err = pullVersioned(store, "dev", "/app/dev/", versions, fetch)
+	require.NoError(t, err)
+	assert.Zero(t, fetched)
+	assert.NotContains(t, values, "/app/dev/C")
+
+	// Fetch errors fail the pull
+	delete(versions, "/app/dev/C")
+	versions["/app/dev/A"] = "us-east-1:2"
+	_, _, err = pullVersioned(store, "dev", "/app/dev/", versions, func([]string) (map[string]string, error) {
+		return nil, fmt.Errorf("throttled")
+	})
+	assert.Error(t, err)
+}
+
+// snapshotCache serves a fixed snapshot; Lookup and Update are not used by
+// the paths under test
+type snapshotCache struct {
+	values     map[string]string
+	verifiedAt time.Time
+}
+
+func (c *snapshotCache) Lookup(string, string, map[string]string) (map[string]string, []string, error) {
+	panic("unexpected lookup")
+}
+
+func (c *snapshotCache) Update(string, string, map[string]string, map[string]string) error {
+	panic("unexpected update")
+}
+
+func (c *snapshotCache) Snapshot(string, string) (map[string]string, time.Time, bool) {
+	return c.values, c.verifiedAt, c.values != nil
+}
+
+func TestPullEnvironmentStale(t *testing.T) {
+	cfg := &config.Config{
+		AWS: config.AWSConfig{Region: "us-east-1", Service: "parameter_store"},
+		Environments: map[string]config.Environment{
+			"dev":  {Files: []string{".env.dev"}, Path: "/app/dev/", CacheSoftTTL: "5m", CacheHardTTL: "1h"},
+			"prod": {Files: []string{".env.prod"}, Path: "/app/prod/", UseSecretsManager: true, CacheSoftTTL: "5m"},
+		},
+	}
+	m := &Manager{config: cfg}
+
+	t.Run("fresh values are served without AWS", func(t *testing.T) {
+		store := &snapshotCache{values: map[string]string{"/app/dev/API_URL": "https://api"}, verifiedAt: time.Now().Add(-time.Minute)}
+		revalidated := false
+
+		result, err := m.PullEnvironmentStale(context.Background(), "dev", store, func() { revalidated = true })
+		require.NoError(t, err)
+		assert.False(t, result.Stale)
+		assert.False(t, revalidated)
+		value, _ := result.File.Get("API_URL")
+		assert.Equal(t, "https://api", value)
+	})
+
+	t.Run("stale values are served while revalidating", func(t *testing.T) {
+		store := &snapshotCache{values: map[string]string{"/app/dev/API_URL": "https://api"}, verifiedAt: time.Now().Add(-10 * time.Minute)}
+		revalidated := false
+
+		result, err := m.PullEnvironmentStale(context.Background(), "dev", store, func() { revalidated = true })
+		require.NoError(t, err)
+		assert.True(t, result.Stale)
+		assert.True(t, revalidated)
+		assert.GreaterOrEqual(t, result.Age, 10*time.Minute)
+	})
+
+	t.Run("secrets are decoded", func(t *testing.T) {
+		store := &snapshotCache{values: map[string]string{"app-prod": `{"DB_PASSWORD":"hunter2"}`}, verifiedAt: time.Now()}
+
+		result, err := m.PullEnvironmentStale(context.Background(), "prod", store, func() {})
+		require.NoError(t, err)
+		value, _ := result.File.Get("DB_PASSWORD")
+		assert.Equal(t, "hunter2", value)
+	})
+}
@@ -0,0 +1,192 @@
+// Package quota checks Parameter Store and Secrets Manager against their
+// service quotas, so large pushes warn before they run into the parameter
+// limit of the account or get throttled.
+//
+// Limits are read from Service Quotas where the account has them, and
+// otherwise default to the values AWS documents.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+const (
+	// LargePush is the number of variables from which push checks the
+	// quotas before writing
+	LargePush = 100
+
+	// NearLimit is the share of a limit from which usage is reported
+	NearLimit = 0.8
+
+	// slowPush is how long a push may take before pacing is suggested
+	slowPush = 2 * time.Minute
+)
+
+// Limit is one quota
+type Limit struct {
+	Name  string
+	Value float64
+	// Applied is true when the value was read from Service Quotas, false
+	// for the documented default
+	Applied bool
+
+	// match selects the quota among those of the service by words in its
+	// name, since quota codes are not documented for all of them
+	service string
+	match   []string
+}
+
+// Limits are the quotas envy's operations depend on, per account and region
+type Limits struct {
+	StandardParameters Limit
+	AdvancedParameters Limit
+	GetParameterTPS    Limit
+	PutParameterTPS    Limit
+	Secrets            Limit
+	SecretWriteTPS     Limit
+}
+
+// DefaultLimits returns the default quotas documented by AWS
+func DefaultLimits() Limits {
+	return Limits{
+		StandardParameters: Limit{Name: "Standard parameters", Value: 10000, service: "ssm", match: []string{"standard", "parameters"}},
+		AdvancedParameters: Limit{Name: "Advanced parameters", Value: 100000, service: "ssm", match: []string{"advanced", "parameters"}},
+		GetParameterTPS:    Limit{Name: "GetParameter requests/s", Value: 40, service: "ssm", match: []string{"getparameter"}},
+		PutParameterTPS:    Limit{Name: "PutParameter requests/s", Value: 3, service: "ssm", match: []string{"putparameter"}},
+		Secrets:            Limit{Name: "Secrets", Value: 500000, service: "secretsmanager", match: []string{"secrets per region"}},
+		SecretWriteTPS:     Limit{Name: "Secret writes/s", Value: 50, service: "secretsmanager", match: []string{"putsecretvalue"}},
+	}
+}
+
+// All returns the limits in display order
+func (l *Limits) All() []*Limit {
+	return []*Limit{&l.StandardParameters, &l.AdvancedParameters, &l.GetParameterTPS, &l.PutParameterTPS, &l.Secrets, &l.SecretWriteTPS}
+}
+
+// Fetch reads the limits of the region from Service Quotas. Limits it cannot
+// read keep their defaults; the error reports why Service Quotas was not
+// available, e.g. a missing servicequotas:ListServiceQuotas permission.
+func Fetch(ctx context.Context, cfg aws.Config) (Limits, error) {
+	limits := DefaultLimits()
+	client := newQuotaClient(cfg)
+
+	for _, service := range []string{"ssm", "secretsmanager"} {
+		quotas, err := client.ListServiceQuotas(ctx, service)
+		if err != nil {
+			return limits, err
+		}
+		limits.apply(service, quotas)
+	}
+	return limits, nil
+}
+
+// apply sets the limits of a service from its quotas
+func (l *Limits) apply(service string, quotas []serviceQuota) {
+	for _, limit := range l.All() {
+		if limit.service != service {
+			continue
+		}
+		for _, q := range quotas {
+			if matches(q.QuotaName, limit.match) && q.Value > 0 {
+				limit.Value = q.Value
+				limit.Applied = true
+				break
+			}
+		}
+	}
+}
+
+func matches(name string, words []string) bool {
+	name = strings.ToLower(name)
+	for _, word := range words {
+		if !strings.Contains(name, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// Usage is what the account uses in the region
+type Usage struct {
+	StandardParameters int
+	AdvancedParameters int
+	Secrets            int
+}
+
+// Operation is a push about to write parameters
+type Operation struct {
+	// NewParameters are created, Writes counts every parameter written
+	NewParameters int
+	Writes        int
+	// Workers is the number of concurrent writes, 1 for a sequential push
+	Workers int
+}
+
+// Warning is a quota an operation approaches or exceeds
+type Warning struct {
+	Message    string
+	Suggestion string
+}
+
+// Check returns the quotas op approaches or exceeds
+func Check(limits Limits, usage Usage, op Operation) []Warning {
+	var warnings []Warning
+
+	standard := limits.StandardParameters.Value
+	after := usage.StandardParameters + op.NewParameters
+	switch {
+	case float64(after) > standard:
+		warnings = append(warnings, Warning{
+			Message:    fmt.Sprintf("The push creates %d parameters; with the %d standard parameters in the region that exceeds the limit of %s", op.NewParameters, usage.StandardParameters, limits.StandardParameters.FormatValue()),
+			Suggestion: fmt.Sprintf("Create the new parameters in the Advanced tier (up to %s per region, charged per parameter) or store the environment in Secrets Manager (use_secrets_manager: true)", limits.AdvancedParameters.FormatValue()),
+		})
+	case op.NewParameters > 0 && float64(after) >= standard*NearLimit:
+		warnings = append(warnings, Warning{
+			Message:    fmt.Sprintf("After the push the region has %d of %s standard parameters (%d%%)", after, limits.StandardParameters.FormatValue(), Percent(after, limits.StandardParameters)),
+			Suggestion: "Plan for the Advanced tier or Secrets Manager before the limit is reached",
+		})
+	}
+
+	tps := limits.PutParameterTPS.Value
+	if op.Workers > 1 && float64(op.Workers) > tps {
+		warnings = append(warnings, Warning{
+			Message:    fmt.Sprintf("%d parallel workers exceed the PutParameter limit of %s requests per second; requests will be throttled and retried", op.Workers, limits.PutParameterTPS.FormatValue()),
+			Suggestion: fmt.Sprintf("Use --max-workers %d", int(math.Max(1, math.Floor(tps)))),
+		})
+	}
+	if duration := time.Duration(float64(op.Writes) / tps * float64(time.Second)); duration >= slowPush {
+		warnings = append(warnings, Warning{
+			Message:    fmt.Sprintf("Writing %d parameters at %s per second takes at least %s", op.Writes, limits.PutParameterTPS.FormatValue(), duration.Round(time.Second)),
+			Suggestion: "Push in smaller batches with --filter, or request a higher PutParameter limit in Service Quotas",
+		})
+	}
+
+	return warnings
+}
+
+// Near reports whether used is close to or over the limit
+func Near(used int, limit Limit) bool {
+	return limit.Value > 0 && float64(used) >= limit.Value*NearLimit
+}
+
+// Percent returns used as a percentage of the limit
+func Percent(used int, limit Limit) int {
+	if limit.Value <= 0 {
+		return 0
+	}
+	return int(math.Round(float64(used) / limit.Value * 100))
+}
+
+// FormatValue prints the limit, without decimals when it is whole
+func (l Limit) FormatValue() string {
+	if l.Value == math.Trunc(l.Value) {
+		return fmt.Sprintf("%.0f", l.Value)
+	}
+	return fmt.Sprintf("%g", l.Value)
+}
@@ -0,0 +1,134 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck_ParameterLimit(t *testing.T) {
+	limits := DefaultLimits()
+
+	warnings := Check(limits, Usage{StandardParameters: 9950}, Operation{NewParameters: 100, Writes: 100, Workers: 1})
+	require.NotEmpty(t, warnings)
+	assert.Contains(t, warnings[0].Message, "exceeds the limit of 10000")
+	assert.Contains(t, warnings[0].Suggestion, "Advanced tier")
+
+	warnings = Check(limits, Usage{StandardParameters: 7950}, Operation{NewParameters: 100, Writes: 100, Workers: 1})
+	require.NotEmpty(t, warnings)
+	assert.Contains(t, warnings[0].Message, "8050 of 10000 standard parameters (81%)")
+
+	// Updating existing parameters adds none
+	assert.Empty(t, Check(limits, Usage{StandardParameters: 9000}, Operation{Writes: 100, Workers: 1}))
+}
+
+func TestCheck_Pacing(t *testing.T) {
+	limits := DefaultLimits()
+
+	warnings := Check(limits, Usage{}, Operation{Writes: 100, Workers: 10})
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0].Message, "10 parallel workers")
+	assert.Equal(t, "Use --max-workers 3", warnings[0].Suggestion)
+
+	warnings = Check(limits, Usage{}, Operation{Writes: 600, Workers: 1})
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0].Message, "takes at least 3m20s")
+
+	limits.PutParameterTPS.Value = 100
+	assert.Empty(t, Check(limits, Usage{}, Operation{Writes: 600, Workers: 10}))
+}
+
+func TestLimitsApply(t *testing.T) {
+	limits := DefaultLimits()
+	limits.apply("ssm", []serviceQuota{
+		{QuotaName: "Advanced parameters", Value: 200000},
+		{QuotaName: "PutParameter transactions per second", Value: 10},
+		{QuotaName: "Secrets per Region", Value: 1}, // another service's name
+	})
+
+	assert.Equal(t, float64(200000), limits.AdvancedParameters.Value)
+	assert.True(t, limits.AdvancedParameters.Applied)
+	assert.Equal(t, float64(10), limits.PutParameterTPS.Value)
+	assert.False(t, limits.StandardParameters.Applied)
+	assert.Equal(t, float64(500000), limits.Secrets.Value)
+}
+
+func TestPercentAndNear(t *testing.T) {
+	limit := Limit{Value: 10000}
+	assert.Equal(t, 80, Percent(8000, limit))
+	assert.True(t, Near(8000, limit))
+	assert.False(t, Near(7999, limit))
+	assert.Equal(t, "10000", limit.FormatValue())
+	assert.Equal(t, "2.5", Limit{Value: 2.5}.FormatValue())
+}
+
+func TestFetch(t *testing.T) {
+	var services []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, serviceQuotasTarget, r.Header.Get("X-Amz-Target"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/servicequotas/aws4_request")
+
+		var input struct {
+			ServiceCode string
+			NextToken   string
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+		services = append(services, input.ServiceCode+input.NextToken)
+
+		switch {
+		case input.ServiceCode == "ssm" && input.NextToken == "":
+			w.Write([]byte(`{"Quotas":[{"QuotaName":"Standard parameters","Value":10000}],"NextToken":"p2"}`))
+		case input.ServiceCode == "ssm":
+			w.Write([]byte(`{"Quotas":[{"QuotaName":"GetParameter throughput","Value":1000}]}`))
+		default:
+			w.Write([]byte(`{"Quotas":[]}`))
+		}
+	}))
+	defer server.Close()
+	t.Setenv("AWS_ENDPOINT_URL_SERVICE_QUOTAS", server.URL)
+
+	cfg := aws.Config{Region: "us-east-1", Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", "")}
+	limits, err := Fetch(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ssm", "ssmp2", "secretsmanager"}, services)
+	assert.True(t, limits.StandardParameters.Applied)
+	assert.Equal(t, float64(1000), limits.GetParameterTPS.Value)
+	assert.False(t, limits.Secrets.Applied)
+}
+
+func TestFetch_AccessDenied(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"AccessDeniedException","Message":"not authorized"}`))
+	}))
+	defer server.Close()
+	t.Setenv("AWS_ENDPOINT_URL_SERVICE_QUOTAS", server.URL)
+
+	cfg := aws.Config{Region: "us-east-1", Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", "")}
+	limits, err := Fetch(context.Background(), cfg)
+	assert.ErrorContains(t, err, "AccessDeniedException")
+	assert.Equal(t, DefaultLimits().StandardParameters.Value, limits.StandardParameters.Value)
+}
+
+func TestParseAPIError(t *testing.T) {
+	err := parseAPIError(400, []byte(`{"__type":"com.amazonaws.servicequotas#TooManyRequestsException","message":"Rate exceeded"}`))
+
+	apiErr, ok := err.(*APIError)
+	require.True(t, ok)
+	assert.Equal(t, "TooManyRequestsException", apiErr.Code)
+	assert.True(t, retry.NewStandard().IsErrorRetryable(err), "throttling should be retried")
+}
+
+func TestQuotaClientEndpoint(t *testing.T) {
+	t.Setenv("AWS_ENDPOINT_URL_SERVICE_QUOTAS", "")
+	assert.Equal(t, "https://servicequotas.cn-north-1.amazonaws.com.cn/", newQuotaClient(aws.Config{Region: "cn-north-1"}).endpoint())
+	assert.Equal(t, "https://servicequotas.eu-west-1.amazonaws.com/", newQuotaClient(aws.Config{Region: "eu-west-1"}).endpoint())
+}
@@ -0,0 +1,206 @@
+package quota
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+const (
+	serviceQuotasService     = "servicequotas"
+	serviceQuotasTarget      = "ServiceQuotasV20190624.ListServiceQuotas"
+	serviceQuotasContentType = "application/x-amz-json-1.1"
+)
+
+// APIError is returned when Service Quotas rejects a request
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("servicequotas ListServiceQuotas failed (%d %s): %s", e.StatusCode, e.Code, e.Message)
+}
+
+// ErrorCode lets the SDK retryer recognize throttling codes
+func (e *APIError) ErrorCode() string {
+	return e.Code
+}
+
+// HTTPStatusCode lets the SDK retryer recognize retryable status codes
+func (e *APIError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
+// serviceQuota is a quota as returned by ListServiceQuotas
+type serviceQuota struct {
+	QuotaName string  `json:"QuotaName"`
+	QuotaCode string  `json:"QuotaCode"`
+	Value     float64 `json:"Value"`
+}
+
+// quotaClient lists the quotas of a service over the JSON protocol, signed
+// with the SDK's SigV4 signer, since the Service Quotas SDK is not a
+// dependency. It uses the endpoint, credentials and retryer of the AWS
+// config.
+type quotaClient struct {
+	config     aws.Config
+	signer     *v4.Signer
+	httpClient *http.Client
+}
+
+func newQuotaClient(cfg aws.Config) *quotaClient {
+	return &quotaClient{
+		config:     cfg,
+		signer:     v4.NewSigner(),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ListServiceQuotas returns the quotas of a service, e.g. ssm
+func (c *quotaClient) ListServiceQuotas(ctx context.Context, serviceCode string) ([]serviceQuota, error) {
+	var quotas []serviceQuota
+	var nextToken string
+
+	for {
+		input := map[string]interface{}{"ServiceCode": serviceCode, "MaxResults": 100}
+		if nextToken != "" {
+			input["NextToken"] = nextToken
+		}
+
+		var output struct {
+			Quotas    []serviceQuota `json:"Quotas"`
+			NextToken string         `json:"NextToken"`
+		}
+		if err := c.call(ctx, input, &output); err != nil {
+			return nil, err
+		}
+
+		quotas = append(quotas, output.Quotas...)
+		nextToken = output.NextToken
+		if nextToken == "" {
+			return quotas, nil
+		}
+	}
+}
+
+// call performs a signed request, retrying as the config's retryer allows
+func (c *quotaClient) call(ctx context.Context, input, output interface{}) error {
+	var retryer aws.Retryer = retry.NewStandard()
+	if c.config.Retryer != nil {
+		retryer = c.config.Retryer()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := c.do(ctx, input, output)
+		if err == nil || !retryer.IsErrorRetryable(err) || attempt >= retryer.MaxAttempts() {
+			return err
+		}
+		delay, derr := retryer.RetryDelay(attempt, err)
+		if derr != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (c *quotaClient) do(ctx context.Context, input, output interface{}) error {
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to encode ListServiceQuotas request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint(), bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create ListServiceQuotas request: %w", err)
+	}
+	req.Header.Set("Content-Type", serviceQuotasContentType)
+	req.Header.Set("X-Amz-Target", serviceQuotasTarget)
+
+	creds, err := c.config.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	hash := sha256.Sum256(payload)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), serviceQuotasService, c.config.Region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign ListServiceQuotas request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ListServiceQuotas request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read ListServiceQuotas response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return parseAPIError(resp.StatusCode, body)
+	}
+
+	if err := json.Unmarshal(body, output); err != nil {
+		return fmt.Errorf("failed to decode ListServiceQuotas response: %w", err)
+	}
+	return nil
+}
+
+// endpoint honours AWS_ENDPOINT_URL_SERVICE_QUOTAS and the config's base
+// endpoint (AWS_ENDPOINT_URL) before the regional endpoint
+func (c *quotaClient) endpoint() string {
+	if url := os.Getenv("AWS_ENDPOINT_URL_SERVICE_QUOTAS"); url != "" {
+		return url
+	}
+	if c.config.BaseEndpoint != nil && *c.config.BaseEndpoint != "" {
+		return *c.config.BaseEndpoint
+	}
+
+	suffix := "amazonaws.com"
+	if strings.HasPrefix(c.config.Region, "cn-") {
+		suffix = "amazonaws.com.cn"
+	}
+	return fmt.Sprintf("https://%s.%s.%s/", serviceQuotasService, c.config.Region, suffix)
+}
+
+// parseAPIError converts a JSON protocol error body into an APIError
+func parseAPIError(statusCode int, body []byte) error {
+	var payload struct {
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+		MessageUpper string `json:"Message"`
+	}
+	_ = json.Unmarshal(body, &payload) // Fall back to the raw body below
+
+	code := payload.Type
+	if idx := strings.LastIndex(code, "#"); idx != -1 {
+		code = code[idx+1:]
+	}
+	message := payload.Message
+	if message == "" {
+		message = payload.MessageUpper
+	}
+	if message == "" {
+		message = strings.TrimSpace(string(body))
+	}
+
+	return &APIError{StatusCode: statusCode, Code: code, Message: message}
+}
@@ -0,0 +1,94 @@
+package aws
+
+import (
+	"context"
+	"strings"
+
+	"github.com/drapon/envy/internal/aws/parameter_store"
+	"github.com/drapon/envy/internal/aws/quota"
+	"github.com/drapon/envy/internal/log"
+	"go.uber.org/zap"
+)
+
+// QuotaLimits reads the Parameter Store and Secrets Manager quotas of the
+// region. When Service Quotas cannot be read the defaults are returned with
+// the error.
+func (m *Manager) QuotaLimits(ctx context.Context) (quota.Limits, error) {
+	return quota.Fetch(ctx, m.client.Config())
+}
+
+// QuotaUsage counts the parameters, by tier, and the secrets of the region
+func (m *Manager) QuotaUsage(ctx context.Context) (quota.Usage, error) {
+	var usage quota.Usage
+
+	params, err := m.paramStore.DescribeAllParameters(ctx)
+	if err != nil {
+		return usage, err
+	}
+	usage.StandardParameters, usage.AdvancedParameters = countTiers(params)
+
+	secrets, err := m.secretsManager.ListSecrets(ctx, "")
+	if err != nil {
+		return usage, err
+	}
+	usage.Secrets = len(secrets)
+	return usage, nil
+}
+
+// PushPreflight checks a push of keys to an environment against the
+// Parameter Store quotas of the region. workers is the number of concurrent
+// writes. Secrets Manager environments are a single secret and are not
+// checked.
+func (m *Manager) PushPreflight(ctx context.Context, envName string, keys []string, workers int) ([]quota.Warning, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	if m.config.GetAWSService(envName) == "secrets_manager" || envConfig.UseSecretsManager {
+		return nil, nil
+	}
+
+	limits, err := m.QuotaLimits(ctx)
+	if err != nil {
+		log.Named("aws").Debug("Service Quotas unavailable, using default limits", zap.Error(err))
+	}
+
+	params, err := m.paramStore.DescribeAllParameters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var usage quota.Usage
+	usage.StandardParameters, usage.AdvancedParameters = countTiers(params)
+
+	existing := make(map[string]bool, len(params))
+	for _, param := range params {
+		existing[param.Name] = true
+	}
+	path := m.config.GetParameterPath(envName)
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	op := quota.Operation{Writes: len(keys), Workers: workers}
+	for _, key := range keys {
+		if !existing[path+m.RemoteKey(key)] {
+			op.NewParameters++
+		}
+	}
+	return quota.Check(limits, usage, op), nil
+}
+
+func countTiers(params []*parameter_store.ParameterMetadata) (standard, advanced int) {
+	for _, param := range params {
+		if param.Tier == "Advanced" {
+			advanced++
+		} else {
+			standard++
+		}
+	}
+	return standard, advanced
+}
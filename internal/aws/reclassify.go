@@ -0,0 +1,149 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/drapon/envy/internal/aws/errors"
+	parameter_store "github.com/drapon/envy/internal/aws/parameter_store"
+)
+
+// ReclassifiedTag is the tag recording the type and version a parameter
+// had before envy reclassify recreated it
+const ReclassifiedTag = "envy:reclassified-from"
+
+// Reclassification is a parameter whose type changes
+type Reclassification struct {
+	Key     string
+	Name    string
+	From    string
+	To      string
+	Version int64
+
+	meta    *parameter_store.ParameterMetadata
+	manager *Manager // of the environment the parameter is in
+}
+
+// PlanReclassify lists the parameters of an environment whose keys match
+// and whose type is not toType, sorted by key. Types only exist in
+// Parameter Store.
+func (m *Manager) PlanReclassify(ctx context.Context, envName string, match func(key string) bool, toType string) ([]Reclassification, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	if toType != "String" && toType != "SecureString" {
+		return nil, fmt.Errorf("invalid type %q: use String or SecureString", toType)
+	}
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	if m.config.GetAWSService(envName) == "secrets_manager" || envConfig.UseSecretsManager {
+		return nil, fmt.Errorf("%s is stored in Secrets Manager, where variables have no type", envName)
+	}
+
+	path := m.config.GetParameterPath(envName)
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	metadata, err := m.paramStore.DescribeParametersByPath(ctx, path)
+	if err != nil {
+		return nil, errors.WrapAWSError(err, "describe parameters", path)
+	}
+	plan := planReclassify(metadata, path, toType, func(remote string) (string, bool) {
+		key, ok := m.LocalKey(remote)
+		return key, ok && match(key)
+	})
+	for i := range plan {
+		plan[i].manager = m
+	}
+	return plan, nil
+}
+
+// planReclassify selects the parameters directly under path whose local
+// key is accepted by match and whose type differs from toType
+func planReclassify(metadata []*parameter_store.ParameterMetadata, path, toType string, match func(remote string) (string, bool)) []Reclassification {
+	var plan []Reclassification
+	for _, meta := range metadata {
+		remote := strings.TrimPrefix(meta.Name, path)
+		if remote == meta.Name || strings.Contains(remote, "/") || meta.Type == toType {
+			continue
+		}
+		key, ok := match(remote)
+		if !ok {
+			continue
+		}
+		plan = append(plan, Reclassification{
+			Key:     key,
+			Name:    meta.Name,
+			From:    meta.Type,
+			To:      toType,
+			Version: meta.Version,
+			meta:    meta,
+		})
+	}
+	sort.Slice(plan, func(i, j int) bool { return plan[i].Key < plan[j].Key })
+	return plan
+}
+
+// Reclassify changes the type of a parameter. The type of a parameter
+// cannot be changed in place, so it is deleted and created again with the
+// same value, description, tier and tags, and the KMS key kmsKey when it
+// becomes a SecureString (the account default when empty). Its version
+// history starts over; ReclassifiedTag records the type and version it had.
+// If the new parameter cannot be created, the original is restored.
+func (m *Manager) Reclassify(ctx context.Context, r Reclassification, kmsKey string, now time.Time) error {
+	if r.manager != nil {
+		m = r.manager
+	}
+	param, err := m.paramStore.GetParameter(ctx, r.Name, true)
+	if err != nil {
+		return errors.WrapAWSError(err, "get parameter", r.Name)
+	}
+	if len(param.Value) > parameter_store.MaxValueSize {
+		return fmt.Errorf("%s is stored in Secrets Manager as a large value and cannot be reclassified", r.Key)
+	}
+	tags, err := m.paramStore.ListTags(ctx, r.Name)
+	if err != nil {
+		return errors.WrapAWSError(err, "list tags", r.Name)
+	}
+
+	original := parameter_store.CreateOptions{
+		Type:        param.Type,
+		Description: r.meta.Description,
+		KeyID:       r.meta.KeyID,
+		Tier:        r.meta.Tier,
+		Tags:        tags,
+	}
+	opts := original
+	opts.Type = r.To
+	opts.KeyID = kmsKey
+	opts.Tags = make(map[string]string, len(tags)+1)
+	for key, value := range tags {
+		opts.Tags[key] = value
+	}
+	opts.Tags[ReclassifiedTag] = fmt.Sprintf("%s v%d at %s", param.Type, param.Version, now.UTC().Format(time.RFC3339))
+
+	if err := m.paramStore.DeleteParameter(ctx, r.Name); err != nil {
+		return errors.WrapAWSError(err, "delete parameter", r.Name)
+	}
+	if err := m.paramStore.CreateParameter(ctx, r.Name, param.Value, opts); err != nil {
+		if restoreErr := m.paramStore.CreateParameter(ctx, r.Name, param.Value, original); restoreErr != nil {
+			return fmt.Errorf("failed to recreate %s (%v) and to restore it: %w", r.Name, err, restoreErr)
+		}
+		return errors.WrapAWSError(err, "put parameter", r.Name)
+	}
+
+	created, err := m.paramStore.GetParameter(ctx, r.Name, true)
+	if err != nil {
+		return errors.WrapAWSError(err, "verify parameter", r.Name)
+	}
+	if created.Value != param.Value || created.Type != r.To {
+		return fmt.Errorf("verification failed for %s after reclassification", r.Name)
+	}
+	return nil
+}
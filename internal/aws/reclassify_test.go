@@ -0,0 +1,37 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+
+	parameter_store "github.com/drapon/envy/internal/aws/parameter_store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanReclassify(t *testing.T) {
+	metadata := []*parameter_store.ParameterMetadata{
+		{Name: "/app/prod/PUBLIC_URL", Type: "SecureString", Version: 3},
+		{Name: "/app/prod/PUBLIC_NAME", Type: "String", Version: 1},
+		{Name: "/app/prod/PUBLIC_CDN", Type: "SecureString", Version: 7},
+		{Name: "/app/prod/DB_PASSWORD", Type: "SecureString", Version: 2},
+		{Name: "/app/prod/nested/PUBLIC_X", Type: "SecureString", Version: 1},
+		{Name: "/other/PUBLIC_Y", Type: "SecureString", Version: 1},
+	}
+	match := func(remote string) (string, bool) {
+		return remote, strings.HasPrefix(remote, "PUBLIC_")
+	}
+
+	plan := planReclassify(metadata, "/app/prod/", "String", match)
+	if assert.Len(t, plan, 2) {
+		assert.Equal(t, "PUBLIC_CDN", plan[0].Key)
+		assert.Equal(t, "SecureString", plan[0].From)
+		assert.Equal(t, "String", plan[0].To)
+		assert.Equal(t, int64(7), plan[0].Version)
+		assert.Equal(t, "/app/prod/PUBLIC_URL", plan[1].Name)
+	}
+
+	plan = planReclassify(metadata, "/app/prod/", "SecureString", match)
+	if assert.Len(t, plan, 1) {
+		assert.Equal(t, "PUBLIC_NAME", plan[0].Key)
+	}
+}
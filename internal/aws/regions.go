@@ -0,0 +1,120 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/drapon/envy/internal/aws/client"
+	"github.com/drapon/envy/internal/aws/errors"
+	secrets_manager "github.com/drapon/envy/internal/aws/secrets_manager"
+	"github.com/drapon/envy/internal/chaos"
+	"github.com/drapon/envy/internal/log"
+	"go.uber.org/zap"
+)
+
+// readWithFallback runs read against the primary region and, when it fails
+// or exceeds the environment's region_timeout, against each fallback region
+// in turn. Only reads fall back; parameters must be replicated to those
+// regions separately.
+func (m *Manager) readWithFallback(ctx context.Context, envName string, read func(ctx context.Context, rm *Manager) error) error {
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return err
+	}
+	if len(envConfig.FallbackRegions) == 0 {
+		return read(ctx, m)
+	}
+
+	timeout := envConfig.Timeout()
+	primaryErr := readRegion(ctx, timeout, m, read)
+	if !shouldFailover(ctx, primaryErr) {
+		return primaryErr
+	}
+
+	for _, region := range envConfig.FallbackRegions {
+		rm, err := m.regionManager(ctx, region)
+		if err != nil {
+			log.Named("aws").Warn("Failed to create client for fallback region",
+				zap.String("region", region), zap.Error(err))
+			continue
+		}
+
+		if err := readRegion(ctx, timeout, rm, read); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Named("aws").Warn("Fallback region failed",
+				zap.String("environment", envName),
+				zap.String("region", region),
+				zap.Error(err))
+			continue
+		}
+
+		log.Named("aws").Warn("Served from fallback region",
+			zap.String("environment", envName),
+			zap.String("region", region),
+			zap.String("primary_region", m.config.GetRegion(envName)),
+			zap.Error(primaryErr))
+		return nil
+	}
+
+	return fmt.Errorf("primary region %s and fallback regions failed: %w", m.config.GetRegion(envName), primaryErr)
+}
+
+// readRegion runs read against one region with a timeout
+func readRegion(ctx context.Context, timeout time.Duration, rm *Manager, read func(ctx context.Context, rm *Manager) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return read(ctx, rm)
+}
+
+// shouldFailover reports whether err suggests the region is unavailable.
+// Missing data, permission and credential errors would fail the same way in
+// another region, and a cancelled caller wants no more attempts.
+func shouldFailover(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	return !errors.IsNotFoundError(err) &&
+		!errors.IsAccessDeniedError(err) &&
+		!errors.IsCredentialError(err)
+}
+
+// regionManager returns a manager reading from region with the same
+// profile and configuration, creating it on first use
+func (m *Manager) regionManager(ctx context.Context, region string) (*Manager, error) {
+	m.regionsMu.Lock()
+	defer m.regionsMu.Unlock()
+
+	if rm, ok := m.regions[region]; ok {
+		return rm, nil
+	}
+
+	awsClient, err := client.NewClient(ctx, client.Options{
+		Region:  region,
+		Profile: m.client.Profile(),
+		Chaos:   chaos.Global(),
+		OIDC:    m.client.OIDC(),
+		RoleARN: m.client.RoleARN(),
+		// The primary's credentials, so an MFA code is not asked for again
+		Credentials: m.client.Config().Credentials,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	secretsManager := secrets_manager.NewManager(awsClient)
+	rm := &Manager{
+		client:         awsClient,
+		paramStore:     newParameterStore(m.config, awsClient, secretsManager),
+		secretsManager: secretsManager,
+		config:         m.config,
+		isSensitive:    m.IsSensitive, // follows MarkSensitive on the primary
+	}
+	if m.regions == nil {
+		m.regions = make(map[string]*Manager)
+	}
+	m.regions[region] = rm
+	return rm, nil
+}
@@ -0,0 +1,122 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	awserrors "github.com/drapon/envy/internal/aws/errors"
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFallbackManager returns a manager for "prod" whose fallback region
+// managers are already created, so no AWS clients are needed
+func newFallbackManager(fallbacks ...string) *Manager {
+	cfg := &config.Config{
+		AWS: config.AWSConfig{Region: "us-east-1", Service: "parameter_store"},
+		Environments: map[string]config.Environment{
+			"prod": {
+				Files:           []string{".env.prod"},
+				Path:            "/app/prod/",
+				FallbackRegions: fallbacks,
+				RegionTimeout:   "50ms",
+			},
+		},
+	}
+
+	m := &Manager{config: cfg, regions: make(map[string]*Manager)}
+	for _, region := range fallbacks {
+		m.regions[region] = &Manager{config: cfg}
+	}
+	return m
+}
+
+func TestReadWithFallback(t *testing.T) {
+	t.Run("primary succeeds", func(t *testing.T) {
+		m := newFallbackManager("us-west-2")
+
+		var served []*Manager
+		err := m.readWithFallback(context.Background(), "prod", func(ctx context.Context, rm *Manager) error {
+			served = append(served, rm)
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []*Manager{m}, served)
+	})
+
+	t.Run("primary error falls back", func(t *testing.T) {
+		m := newFallbackManager("us-west-2", "eu-west-1")
+
+		var served []*Manager
+		err := m.readWithFallback(context.Background(), "prod", func(ctx context.Context, rm *Manager) error {
+			served = append(served, rm)
+			if rm != m.regions["eu-west-1"] {
+				return errors.New("service unavailable")
+			}
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []*Manager{m, m.regions["us-west-2"], m.regions["eu-west-1"]}, served)
+	})
+
+	t.Run("slow primary falls back", func(t *testing.T) {
+		m := newFallbackManager("us-west-2")
+
+		var served *Manager
+		err := m.readWithFallback(context.Background(), "prod", func(ctx context.Context, rm *Manager) error {
+			if rm == m {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			served = rm
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Same(t, m.regions["us-west-2"], served)
+	})
+
+	t.Run("not found does not fall back", func(t *testing.T) {
+		m := newFallbackManager("us-west-2")
+
+		calls := 0
+		err := m.readWithFallback(context.Background(), "prod", func(ctx context.Context, rm *Manager) error {
+			calls++
+			return fmt.Errorf("get secret: %w", awserrors.ErrSecretNotFound)
+		})
+
+		assert.ErrorIs(t, err, awserrors.ErrSecretNotFound)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("all regions fail", func(t *testing.T) {
+		m := newFallbackManager("us-west-2")
+		primaryErr := errors.New("primary down")
+
+		err := m.readWithFallback(context.Background(), "prod", func(ctx context.Context, rm *Manager) error {
+			if rm == m {
+				return primaryErr
+			}
+			return errors.New("replica down")
+		})
+
+		assert.ErrorIs(t, err, primaryErr)
+		assert.Contains(t, err.Error(), "us-east-1")
+	})
+
+	t.Run("no fallback regions", func(t *testing.T) {
+		m := newFallbackManager()
+		primaryErr := errors.New("primary down")
+
+		err := m.readWithFallback(context.Background(), "prod", func(ctx context.Context, rm *Manager) error {
+			return primaryErr
+		})
+
+		assert.Equal(t, primaryErr, err)
+	})
+}
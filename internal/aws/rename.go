@@ -0,0 +1,161 @@
+package aws
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+
+	"github.com/drapon/envy/internal/aws/errors"
+	parameter_store "github.com/drapon/envy/internal/aws/parameter_store"
+)
+
+var (
+	// ErrKeyNotFound is returned by RenameVariable when the old key does not exist remotely
+	ErrKeyNotFound = stderrors.New("key not found")
+	// ErrKeyExists is returned by RenameVariable when the new key already exists remotely
+	ErrKeyExists = stderrors.New("key already exists")
+)
+
+// RenameVariable renames a variable in AWS. For Parameter Store the value is
+// copied to the new parameter (keeping its type, description, KMS key, tier
+// and tags), read back to verify it, and only then is the old parameter deleted. For Secrets Manager the key is
+// renamed inside the secret in a single update, which is verified the same way,
+// or its secret copied like a parameter when each variable is its own secret.
+func (m *Manager) RenameVariable(ctx context.Context, envName, oldKey, newKey string) error {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return err
+	}
+	// Get environment configuration
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return err
+	}
+
+	// Determine which service to use
+	service := m.config.GetAWSService(envName)
+	path := m.config.GetParameterPath(envName)
+	oldKey, newKey = m.RemoteKey(oldKey), m.RemoteKey(newKey)
+
+	if (service == "secrets_manager" || envConfig.UseSecretsManager) && m.config.SecretPerVariable() {
+		return m.renameVariableSecret(ctx, path, oldKey, newKey)
+	}
+	if service == "secrets_manager" || envConfig.UseSecretsManager {
+		return m.renameInSecretsManager(ctx, path, oldKey, newKey)
+	}
+
+	return m.renameInParameterStore(ctx, path, oldKey, newKey)
+}
+
+// renameInParameterStore copies a parameter to a new name and deletes the old one
+func (m *Manager) renameInParameterStore(ctx context.Context, path, oldKey, newKey string) error {
+	if !strings.HasSuffix(path, "/") {
+		path = path + "/"
+	}
+	oldName := path + oldKey
+	newName := path + newKey
+
+	oldParam, err := m.paramStore.GetParameter(ctx, oldName, true)
+	if err != nil {
+		if errors.IsNotFoundError(err) {
+			return fmt.Errorf("%s: %w", oldName, ErrKeyNotFound)
+		}
+		return errors.WrapAWSError(err, "get parameter", oldName)
+	}
+
+	if _, err := m.paramStore.GetParameter(ctx, newName, false); err == nil {
+		return fmt.Errorf("%s: %w", newName, ErrKeyExists)
+	} else if !errors.IsNotFoundError(err) {
+		return errors.WrapAWSError(err, "get parameter", newName)
+	}
+
+	if err := m.copyParameter(ctx, oldParam, newName); err != nil {
+		return err
+	}
+
+	if err := m.paramStore.DeleteParameter(ctx, oldName); err != nil {
+		return errors.WrapAWSError(err, "delete parameter", oldName)
+	}
+
+	return nil
+}
+
+// copyParameter creates newName with the value of param, keeping its type,
+// description, KMS key, tier and tags, and reads it back to verify it
+func (m *Manager) copyParameter(ctx context.Context, param *parameter_store.Parameter, newName string) error {
+	meta, err := m.paramStore.DescribeParameter(ctx, param.Name)
+	if err != nil {
+		return errors.WrapAWSError(err, "describe parameter", param.Name)
+	}
+	tags, err := m.paramStore.ListTags(ctx, param.Name)
+	if err != nil {
+		return errors.WrapAWSError(err, "list tags", param.Name)
+	}
+
+	opts := parameter_store.CreateOptions{
+		Type:        param.Type,
+		Description: meta.Description,
+		KeyID:       meta.KeyID,
+		Tier:        meta.Tier,
+		Tags:        tags,
+	}
+	if err := m.paramStore.CreateParameter(ctx, newName, param.Value, opts); err != nil {
+		return errors.WrapAWSError(err, "put parameter", newName)
+	}
+
+	// Verify the copy before the caller removes the original
+	copied, err := m.paramStore.GetParameter(ctx, newName, true)
+	if err != nil {
+		return errors.WrapAWSError(err, "verify parameter", newName)
+	}
+	if copied.Value != param.Value {
+		return fmt.Errorf("verification failed for %s: value does not match %s, original kept", newName, param.Name)
+	}
+	return nil
+}
+
+// renameInSecretsManager renames a key inside the environment's secret
+func (m *Manager) renameInSecretsManager(ctx context.Context, path, oldKey, newKey string) error {
+	// Clean path for secret name
+	secretName := strings.Trim(path, "/")
+	secretName = strings.ReplaceAll(secretName, "/", "-")
+
+	secret, err := m.secretsManager.GetSecret(ctx, secretName)
+	if err != nil {
+		return errors.WrapAWSError(err, "get secret", secretName)
+	}
+
+	value, ok := secret.KeyValue[oldKey]
+	if !ok {
+		return fmt.Errorf("%s in %s: %w", oldKey, secretName, ErrKeyNotFound)
+	}
+	if _, exists := secret.KeyValue[newKey]; exists {
+		return fmt.Errorf("%s in %s: %w", newKey, secretName, ErrKeyExists)
+	}
+
+	vars := make(map[string]string, len(secret.KeyValue))
+	for k, v := range secret.KeyValue {
+		vars[k] = v
+	}
+	vars[newKey] = value
+	delete(vars, oldKey)
+
+	if err := m.secretsManager.UpdateSecret(ctx, secretName, vars); err != nil {
+		return errors.WrapAWSError(err, "update secret", secretName)
+	}
+
+	// Verify the update
+	updated, err := m.secretsManager.GetSecret(ctx, secretName)
+	if err != nil {
+		return errors.WrapAWSError(err, "verify secret", secretName)
+	}
+	if got, ok := updated.KeyValue[newKey]; !ok || got != value {
+		return fmt.Errorf("verification failed for %s in %s", newKey, secretName)
+	}
+	if _, stillExists := updated.KeyValue[oldKey]; stillExists {
+		return fmt.Errorf("verification failed: %s still present in %s", oldKey, secretName)
+	}
+
+	return nil
+}
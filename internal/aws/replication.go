@@ -0,0 +1,378 @@
+package aws
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/drapon/envy/internal/aws/errors"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/log"
+	"go.uber.org/zap"
+)
+
+// replicationParameterFormat is where the replica regions of an environment
+// are recorded, in the primary region's Parameter Store, so every user and
+// CI job pushing the environment replicates it the same way
+const replicationParameterFormat = "/envy/replication/%s/%s/regions"
+
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+
+// RegionDrift describes how a replica region differs from the primary
+type RegionDrift struct {
+	Region    string
+	Missing   []string // in the primary but not the replica
+	Different []string // different values
+	Extra     []string // in the replica but not the primary
+	Err       error    // the replica could not be read
+}
+
+// InSync reports whether the replica matches the primary
+func (d RegionDrift) InSync() bool {
+	return d.Err == nil && len(d.Missing) == 0 && len(d.Different) == 0 && len(d.Extra) == 0
+}
+
+// ReplicationRegions returns the replica regions recorded for an
+// environment, or nil when it is not replicated
+func (m *Manager) ReplicationRegions(ctx context.Context, envName string) ([]string, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	name := m.replicationParameter(envName)
+	param, err := m.paramStore.GetParameter(ctx, name, false)
+	if err != nil {
+		if errors.IsNotFoundError(err) {
+			return nil, nil
+		}
+		if errors.IsAccessDeniedError(err) {
+			// Users without access to the setting push to the primary only
+			log.Named("aws").Debug("Cannot read replication settings", zap.String("parameter", name), zap.Error(err))
+			return nil, nil
+		}
+		return nil, errors.WrapAWSError(err, "get parameter", name)
+	}
+
+	var regions []string
+	for _, region := range strings.Split(param.Value, ",") {
+		if region = strings.TrimSpace(region); region != "" {
+			regions = append(regions, region)
+		}
+	}
+	return regions, nil
+}
+
+// SetReplicationRegions records the replica regions of an environment. The
+// primary region is dropped from the list if present.
+func (m *Manager) SetReplicationRegions(ctx context.Context, envName string, regions []string) ([]string, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	replicas, err := ParseReplicaRegions(regions, m.config.GetRegion(envName))
+	if err != nil {
+		return nil, err
+	}
+	if len(replicas) == 0 {
+		return nil, fmt.Errorf("no replica regions other than the primary region %s", m.config.GetRegion(envName))
+	}
+
+	name := m.replicationParameter(envName)
+	err = m.paramStore.PutParameter(ctx, name, strings.Join(replicas, ","),
+		"Replica regions for envy environment "+envName, "String", true)
+	if err != nil {
+		return nil, errors.WrapAWSError(err, "put parameter", name)
+	}
+	return replicas, nil
+}
+
+// ParseReplicaRegions validates region names and returns them without the
+// primary region and duplicates
+func ParseReplicaRegions(regions []string, primary string) ([]string, error) {
+	var replicas []string
+	seen := map[string]bool{primary: true}
+	for _, region := range regions {
+		region = strings.TrimSpace(region)
+		if region == "" {
+			continue
+		}
+		if !regionPattern.MatchString(region) {
+			return nil, fmt.Errorf("invalid region %q", region)
+		}
+		if !seen[region] {
+			seen[region] = true
+			replicas = append(replicas, region)
+		}
+	}
+	return replicas, nil
+}
+
+// PushReplicated runs push, which writes keys to the primary region, and
+// then copies the resulting values to the environment's replica regions.
+// If the push or any replica fails, every region written so far is rolled
+// back to its previous values for those keys.
+func (m *Manager) PushReplicated(ctx context.Context, envName string, keys []string, push func() error) error {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return err
+	}
+	return m.pushReplicated(ctx, envName, keys, push, false)
+}
+
+// PushAtomic is PushReplicated that also rolls the primary region back
+// when push fails and the environment has no replicas, so a push that
+// fails halfway never leaves some keys written and others not.
+func (m *Manager) PushAtomic(ctx context.Context, envName string, keys []string, push func() error) error {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return err
+	}
+	return m.pushReplicated(ctx, envName, keys, push, true)
+}
+
+func (m *Manager) pushReplicated(ctx context.Context, envName string, keys []string, push func() error, atomic bool) error {
+	replicas, err := m.ReplicationRegions(ctx, envName)
+	if err != nil {
+		return err
+	}
+	if len(replicas) == 0 && !atomic {
+		return push()
+	}
+
+	regions := make([]*Manager, 0, len(replicas)+1)
+	regions = append(regions, m)
+	for _, region := range replicas {
+		rm, err := m.regionManager(ctx, region)
+		if err != nil {
+			return fmt.Errorf("failed to create client for %s: %w", region, err)
+		}
+		regions = append(regions, rm)
+	}
+
+	// Snapshot every region first so any of them can be restored
+	snapshots := make([]map[string]string, len(regions))
+	for i, rm := range regions {
+		if snapshots[i], err = rm.snapshot(ctx, envName, keys); err != nil {
+			return fmt.Errorf("failed to read %s before push: %w", rm.client.Region(), err)
+		}
+	}
+
+	if err := push(); err != nil {
+		return m.rollback(ctx, envName, keys, regions[:1], snapshots, err)
+	}
+	if len(replicas) == 0 {
+		return nil
+	}
+
+	// Replicate what the primary ended up with, since the push may have
+	// skipped existing keys or empty values, or deleted keys
+	pushed, err := m.snapshot(ctx, envName, keys)
+	if err != nil {
+		return m.rollback(ctx, envName, keys, regions[:1], snapshots, err)
+	}
+	set := env.NewFile()
+	var deleted []string
+	for _, key := range keys {
+		if value, ok := pushed[key]; ok {
+			set.Set(key, value)
+		} else {
+			deleted = append(deleted, key)
+		}
+	}
+
+	for i, rm := range regions[1:] {
+		if err := rm.applyChanges(ctx, envName, set, deleted); err != nil {
+			err = fmt.Errorf("failed to replicate to %s: %w", rm.client.Region(), err)
+			return m.rollback(ctx, envName, keys, regions[:i+2], snapshots, err)
+		}
+		log.Named("aws").Info("Replicated environment",
+			zap.String("environment", envName),
+			zap.String("region", rm.client.Region()),
+			zap.Int("variables", len(pushed)))
+	}
+
+	return nil
+}
+
+// ReplicationStatus compares every replica region with the primary
+func (m *Manager) ReplicationStatus(ctx context.Context, envName string, replicas []string) ([]RegionDrift, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	_, drifts, err := m.compareReplicas(ctx, envName, replicas)
+	return drifts, err
+}
+
+// SyncReplicas copies every variable of the primary region to the replica
+// regions, overwriting differing values. Keys only present in a replica are
+// removed when prune is set.
+func (m *Manager) SyncReplicas(ctx context.Context, envName string, replicas []string, prune bool) error {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return err
+	}
+	primary, drifts, err := m.compareReplicas(ctx, envName, replicas)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, drift := range drifts {
+		if drift.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", drift.Region, drift.Err))
+			continue
+		}
+
+		set := env.NewFile()
+		for _, key := range append(drift.Missing, drift.Different...) {
+			value, _ := primary.Get(key)
+			set.Set(key, value)
+		}
+		var deleted []string
+		if prune {
+			deleted = drift.Extra
+		}
+		if len(set.Keys()) == 0 && len(deleted) == 0 {
+			continue
+		}
+
+		rm, err := m.regionManager(ctx, drift.Region)
+		if err == nil {
+			err = rm.applyChanges(ctx, envName, set, deleted)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", drift.Region, err))
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+// compareReplicas pulls the primary region and every replica and reports
+// their differences. A replica without the environment counts as empty.
+func (m *Manager) compareReplicas(ctx context.Context, envName string, replicas []string) (*env.File, []RegionDrift, error) {
+	primary, err := m.pullEnvironment(ctx, envName)
+	if err != nil {
+		return nil, nil, err
+	}
+	want := primary.ToMap()
+
+	drifts := make([]RegionDrift, 0, len(replicas))
+	for _, region := range replicas {
+		drift := RegionDrift{Region: region}
+
+		got, err := m.pullRegion(ctx, envName, region)
+		if err != nil {
+			drift.Err = err
+		} else {
+			drift.Missing, drift.Different, drift.Extra = compareVariables(want, got)
+		}
+		drifts = append(drifts, drift)
+	}
+	return primary, drifts, nil
+}
+
+func (m *Manager) pullRegion(ctx context.Context, envName, region string) (map[string]string, error) {
+	rm, err := m.regionManager(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := rm.pullEnvironment(ctx, envName)
+	if errors.IsNotFoundError(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return file.ToMap(), nil
+}
+
+// snapshot returns the current values of keys in the manager's region. The
+// whole environment is read by path, which takes far fewer calls than
+// reading the keys one by one.
+func (m *Manager) snapshot(ctx context.Context, envName string, keys []string) (map[string]string, error) {
+	file, err := m.pullEnvironment(ctx, envName)
+	if errors.IsNotFoundError(err) {
+		// e.g. a Secrets Manager environment not created yet
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if value, ok := file.Get(key); ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+// rollback restores keys in regions to their snapshots and returns cause,
+// annotated with any region that could not be restored
+func (m *Manager) rollback(ctx context.Context, envName string, keys []string, regions []*Manager, snapshots []map[string]string, cause error) error {
+	var failed []string
+	for i, rm := range regions {
+		set := env.NewFile()
+		var deleted []string
+		for _, key := range keys {
+			if value, ok := snapshots[i][key]; ok {
+				set.Set(key, value)
+			} else {
+				deleted = append(deleted, key)
+			}
+		}
+
+		if err := rm.applyChanges(ctx, envName, set, deleted); err != nil {
+			region := rm.client.Region()
+			log.Named("aws").Error("Failed to roll back region",
+				zap.String("environment", envName),
+				zap.String("region", region),
+				zap.Error(err))
+			failed = append(failed, region)
+		}
+	}
+
+	if len(failed) > 0 && len(regions) == 1 {
+		return fmt.Errorf("%w (rollback failed; run 'envy diff' to check the environment)", cause)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w (rollback failed in %s; run 'envy replicate status')", cause, strings.Join(failed, ", "))
+	}
+	if len(regions) == 1 {
+		return fmt.Errorf("%w (rolled back to the previous values)", cause)
+	}
+	return fmt.Errorf("%w (all regions rolled back)", cause)
+}
+
+func (m *Manager) replicationParameter(envName string) string {
+	return fmt.Sprintf(replicationParameterFormat, m.config.Project, envName)
+}
+
+// compareVariables returns the keys missing from, different in and extra
+// in got compared with want, sorted
+func compareVariables(want, got map[string]string) (missing, different, extra []string) {
+	for key, value := range want {
+		gotValue, ok := got[key]
+		switch {
+		case !ok:
+			missing = append(missing, key)
+		case gotValue != value:
+			different = append(different, key)
+		}
+	}
+	for key := range got {
+		if _, ok := want[key]; !ok {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(different)
+	sort.Strings(extra)
+	return missing, different, extra
+}
@@ -0,0 +1,211 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/drapon/envy/internal/aws/errors"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/cost"
+)
+
+// Storage layouts of an environment
+const (
+	// LayoutSingleSecret stores every variable in one JSON secret
+	LayoutSingleSecret = "single-secret"
+	// LayoutPerKeyParameters stores each variable as its own parameter
+	LayoutPerKeyParameters = "per-key-parameters"
+)
+
+// Layout returns how an environment is stored
+func Layout(cfg *config.Config, envName string) string {
+	if cfg.GetAWSService(envName) == "secrets_manager" {
+		return LayoutSingleSecret
+	}
+	return LayoutPerKeyParameters
+}
+
+// RestructurePlan moves an environment from one layout to the other
+type RestructurePlan struct {
+	Environment string
+	From        string
+	To          string
+	// Path is the parameter path, SecretName the secret of the environment
+	Path       string
+	SecretName string
+	// Values are the variables read from the current layout
+	Values map[string]string
+}
+
+// Keys returns the variables of the plan, sorted
+func (p *RestructurePlan) Keys() []string {
+	keys := make([]string, 0, len(p.Values))
+	for key := range p.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PlanRestructure reads the variables of envName and checks that they can be
+// written in the layout to: the target must be empty, and a single secret
+// must hold all of them. Nothing is written.
+func (m *Manager) PlanRestructure(ctx context.Context, envName, to string) (*RestructurePlan, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	if to != LayoutSingleSecret && to != LayoutPerKeyParameters {
+		return nil, fmt.Errorf("invalid layout %q (use %s or %s)", to, LayoutSingleSecret, LayoutPerKeyParameters)
+	}
+	if m.namespacePrefix() != "" {
+		return nil, fmt.Errorf("%s shares its path or secret with other applications (namespace %s) and cannot be restructured", envName, m.config.Namespace)
+	}
+	if m.config.SecretPerVariable() {
+		return nil, fmt.Errorf("secrets_manager.mode is %s, which already stores each variable on its own; restructure moves single-secret environments", config.SecretsManagerPerVariable)
+	}
+	from := Layout(m.config, envName)
+	if from == to {
+		return nil, fmt.Errorf("%s is already stored as %s", envName, to)
+	}
+
+	path := m.config.GetParameterPath(envName)
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	plan := &RestructurePlan{Environment: envName, From: from, To: to, Path: path, SecretName: SecretName(path)}
+
+	if from == LayoutSingleSecret {
+		plan.Values, err = m.pullFromSecretsManager(ctx, path)
+	} else {
+		plan.Values, err = m.pullFromParameterStore(ctx, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Values) == 0 {
+		return nil, fmt.Errorf("%s has no variables to restructure", envName)
+	}
+
+	if to == LayoutSingleSecret {
+		if err := m.checkSecretTarget(ctx, plan); err != nil {
+			return nil, err
+		}
+	} else if err := m.checkParameterTarget(ctx, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// checkSecretTarget fails if the secret exists or the values do not fit
+func (m *Manager) checkSecretTarget(ctx context.Context, plan *RestructurePlan) error {
+	data, err := json.Marshal(plan.Values)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", plan.Environment, err)
+	}
+	if len(data) > cost.SecretMaxSize {
+		return fmt.Errorf("the variables of %s take %d bytes as JSON, more than the %d a secret holds", plan.Environment, len(data), cost.SecretMaxSize)
+	}
+
+	_, err = m.secretsManager.GetSecret(ctx, plan.SecretName)
+	if err == nil {
+		return fmt.Errorf("secret %s already exists: %w", plan.SecretName, ErrKeyExists)
+	}
+	if !errors.IsNotFoundError(err) {
+		return errors.WrapAWSError(err, "get secret", plan.SecretName)
+	}
+	return nil
+}
+
+// checkParameterTarget fails if any parameter exists under the path
+func (m *Manager) checkParameterTarget(ctx context.Context, plan *RestructurePlan) error {
+	existing, err := m.paramStore.ListParameterNames(ctx, plan.Path, true)
+	if err != nil {
+		return errors.WrapAWSError(err, "get parameters by path", plan.Path)
+	}
+	if len(existing) > 0 {
+		sort.Strings(existing)
+		return fmt.Errorf("%d parameter(s) already exist under %s: %s: %w", len(existing), plan.Path, strings.Join(existing, ", "), ErrKeyExists)
+	}
+	return nil
+}
+
+// Restructure writes the variables of a plan in the new layout and reads
+// them back to verify that both layouts hold the same variables. The old
+// layout is left untouched.
+func (m *Manager) Restructure(ctx context.Context, plan *RestructurePlan) error {
+	m, err := m.forEnvironment(plan.Environment)
+	if err != nil {
+		return err
+	}
+	var written map[string]string
+	if plan.To == LayoutSingleSecret {
+		err = m.secretsManager.CreateSecret(ctx, plan.SecretName, "Environment variables for "+plan.SecretName, plan.Values)
+		if err != nil {
+			return errors.WrapAWSError(err, "create secret", plan.SecretName)
+		}
+		written, err = m.pullFromSecretsManager(ctx, plan.Path)
+	} else {
+		vars := make(map[string]string, len(plan.Values))
+		for key, value := range plan.Values {
+			vars[key] = value
+		}
+		if err := m.pushToParameterStore(ctx, plan.Path, vars, true); err != nil {
+			return err
+		}
+		written, err = m.pullFromParameterStore(ctx, plan.Path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to verify the new layout: %w", err)
+	}
+
+	if mismatched := Mismatched(plan.Values, written); len(mismatched) > 0 {
+		return fmt.Errorf("verification failed, the new layout differs in %s", strings.Join(mismatched, ", "))
+	}
+	return nil
+}
+
+// DeleteOldLayout removes the previous layout of a restructured
+// environment. A secret is scheduled for deletion with the default recovery
+// window rather than deleted immediately.
+func (m *Manager) DeleteOldLayout(ctx context.Context, plan *RestructurePlan) error {
+	m, err := m.forEnvironment(plan.Environment)
+	if err != nil {
+		return err
+	}
+	if plan.From == LayoutSingleSecret {
+		if err := m.secretsManager.DeleteSecret(ctx, plan.SecretName, false); err != nil && !errors.IsNotFoundError(err) {
+			return errors.WrapAWSError(err, "delete secret", plan.SecretName)
+		}
+		return nil
+	}
+
+	for _, key := range plan.Keys() {
+		paramName := plan.Path + key
+		if err := m.paramStore.DeleteParameter(ctx, paramName); err != nil && !errors.IsNotFoundError(err) {
+			return errors.WrapAWSError(err, "delete parameter", paramName)
+		}
+	}
+	return nil
+}
+
+// Mismatched returns the keys, sorted, that are missing from one of the maps
+// or hold different values
+func Mismatched(want, got map[string]string) []string {
+	var keys []string
+	for key, value := range want {
+		if other, ok := got[key]; !ok || other != value {
+			keys = append(keys, key)
+		}
+	}
+	for key := range got {
+		if _, ok := want[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,43 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayout(t *testing.T) {
+	cfg := &config.Config{
+		AWS: config.AWSConfig{Service: "parameter_store"},
+		Environments: map[string]config.Environment{
+			"dev":  {},
+			"prod": {UseSecretsManager: true},
+		},
+	}
+	assert.Equal(t, LayoutPerKeyParameters, Layout(cfg, "dev"))
+	assert.Equal(t, LayoutSingleSecret, Layout(cfg, "prod"))
+
+	cfg.AWS.Service = "secrets_manager"
+	assert.Equal(t, LayoutSingleSecret, Layout(cfg, "dev"))
+}
+
+func TestPlanRestructure_InvalidTarget(t *testing.T) {
+	m := &Manager{config: &config.Config{
+		AWS:          config.AWSConfig{Service: "parameter_store"},
+		Environments: map[string]config.Environment{"dev": {}},
+	}}
+
+	_, err := m.PlanRestructure(context.Background(), "dev", "many-secrets")
+	assert.ErrorContains(t, err, "invalid layout")
+
+	_, err = m.PlanRestructure(context.Background(), "dev", LayoutPerKeyParameters)
+	assert.ErrorContains(t, err, "already stored as per-key-parameters")
+}
+
+func TestMismatched(t *testing.T) {
+	want := map[string]string{"A": "1", "B": "2", "C": "3"}
+	assert.Empty(t, Mismatched(want, map[string]string{"A": "1", "B": "2", "C": "3"}))
+	assert.Equal(t, []string{"B", "C", "D"}, Mismatched(want, map[string]string{"A": "1", "B": "x", "D": "4"}))
+}
@@ -0,0 +1,76 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/drapon/envy/internal/aws/errors"
+	"github.com/drapon/envy/internal/aws/parameter_store"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/log"
+	"go.uber.org/zap"
+)
+
+// PullCheckpoint records how far a pull of a Parameter Store environment
+// got: the variables read so far and the token of the next page. A pull
+// interrupted by Ctrl-C or a network error continues from it instead of
+// reading every page again.
+type PullCheckpoint struct {
+	Environment string            `json:"environment"`
+	Region      string            `json:"region"`
+	Path        string            `json:"path"`
+	NextToken   string            `json:"next_token"`
+	Pages       int               `json:"pages"`
+	Vars        map[string]string `json:"vars"`
+}
+
+// PullEnvironmentResumable pulls an environment starting at checkpoint,
+// which may be empty, and calls save with the updated checkpoint after
+// every page. A checkpoint of another path or region, one whose token has
+// expired, or a complete one, whose values may have changed since, is
+// started over. Secrets Manager environments are read in one go and never
+// saved.
+func (m *Manager) PullEnvironmentResumable(ctx context.Context, envName string, checkpoint *PullCheckpoint, save func(*PullCheckpoint) error) (*env.File, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	if m.config.GetAWSService(envName) == "secrets_manager" || envConfig.UseSecretsManager {
+		return m.PullEnvironment(ctx, envName)
+	}
+
+	path := m.config.GetParameterPath(envName)
+	region := m.config.GetRegion(envName)
+	complete := checkpoint.Pages > 0 && checkpoint.NextToken == ""
+	if checkpoint.Environment != envName || checkpoint.Path != path || checkpoint.Region != region || checkpoint.Vars == nil || complete {
+		*checkpoint = PullCheckpoint{Environment: envName, Region: region, Path: path, Vars: map[string]string{}}
+	}
+
+	err = m.walkFrom(ctx, path, checkpoint, save)
+	if err != nil && checkpoint.NextToken != "" && parameter_store.IsInvalidNextToken(err) {
+		log.Debug("Checkpoint token expired, pulling the whole environment",
+			zap.String("environment", envName),
+			zap.Int("pages", checkpoint.Pages))
+		*checkpoint = PullCheckpoint{Environment: envName, Region: region, Path: path, Vars: map[string]string{}}
+		err = m.walkFrom(ctx, path, checkpoint, save)
+	}
+	if err != nil {
+		return nil, errors.WrapAWSError(err, "get parameters by path", path)
+	}
+	return varsFile(checkpoint.Vars), nil
+}
+
+// walkFrom reads the pages of path from the checkpoint's token on
+func (m *Manager) walkFrom(ctx context.Context, path string, checkpoint *PullCheckpoint, save func(*PullCheckpoint) error) error {
+	return m.paramStore.WalkParametersByPathFrom(ctx, path, true, true, checkpoint.NextToken, func(page []*parameter_store.Parameter, nextToken string) error {
+		for key, value := range m.localVars(m.paramStore.ConvertToEnvVars(page, path)) {
+			checkpoint.Vars[key] = value
+		}
+		checkpoint.NextToken = nextToken
+		checkpoint.Pages++
+		return save(checkpoint)
+	})
+}
@@ -0,0 +1,32 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullEnvironmentResumable_Complete(t *testing.T) {
+	m := newFakeManager(t, &fakeParameterStore{params: map[string]string{"/myapp/prod/PORT": "8080"}})
+
+	// Every page was read by an earlier pull, but the values may have
+	// changed since, so they are fetched again
+	checkpoint := &PullCheckpoint{
+		Environment: "prod",
+		Region:      "us-east-1",
+		Path:        "/myapp/prod/",
+		Pages:       3,
+		Vars:        map[string]string{"PORT": "443"},
+	}
+	saved := 0
+	file, err := m.PullEnvironmentResumable(context.Background(), "prod", checkpoint, func(*PullCheckpoint) error {
+		saved++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"PORT": "8080"}, file.ToMap())
+	assert.Equal(t, 1, saved)
+	assert.Equal(t, 1, checkpoint.Pages)
+}
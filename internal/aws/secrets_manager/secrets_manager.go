@@ -37,13 +37,25 @@ type Secret struct {
 	CreatedDate  string
 	LastModified string
 	VersionId    string
+	Tags         map[string]string // Only set by ListSecrets
 }
 
 // GetSecret retrieves a secret
 func (m *Manager) GetSecret(ctx context.Context, name string) (*Secret, error) {
+	return m.GetSecretVersion(ctx, name, VersionRef{})
+}
+
+// GetSecretVersion retrieves the version of a secret ref selects
+func (m *Manager) GetSecretVersion(ctx context.Context, name string, ref VersionRef) (*Secret, error) {
 	input := &secretsmanager.GetSecretValueInput{
 		SecretId: aws.String(name),
 	}
+	if ref.Stage != "" {
+		input.VersionStage = aws.String(ref.Stage)
+	}
+	if ref.VersionID != "" {
+		input.VersionId = aws.String(ref.VersionID)
+	}
 
 	result, err := m.secretsClient.GetSecretValue(ctx, input)
 	if err != nil {
@@ -76,6 +88,33 @@ func (m *Manager) GetSecret(ctx context.Context, name string) (*Secret, error) {
 	return secret, nil
 }
 
+// GetSecretString retrieves a secret's string value as stored, without
+// parsing JSON
+func (m *Manager) GetSecretString(ctx context.Context, name string) (string, error) {
+	return m.GetSecretStringVersion(ctx, name, VersionRef{})
+}
+
+// GetSecretStringVersion retrieves the string value of the version of a
+// secret ref selects, as stored
+func (m *Manager) GetSecretStringVersion(ctx context.Context, name string, ref VersionRef) (string, error) {
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(name),
+	}
+	if ref.Stage != "" {
+		input.VersionStage = aws.String(ref.Stage)
+	}
+	if ref.VersionID != "" {
+		input.VersionId = aws.String(ref.VersionID)
+	}
+
+	result, err := m.secretsClient.GetSecretValue(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to get secret %s: %w", name, err)
+	}
+
+	return aws.ToString(result.SecretString), nil
+}
+
 // CreateSecret creates a new secret
 func (m *Manager) CreateSecret(ctx context.Context, name, description string, value interface{}) error {
 	input := &secretsmanager.CreateSecretInput{
@@ -108,6 +147,25 @@ func (m *Manager) CreateSecret(ctx context.Context, name, description string, va
 	return nil
 }
 
+// CreateTaggedSecret creates a new string secret with tags
+func (m *Manager) CreateTaggedSecret(ctx context.Context, name, description, value string, tags map[string]string) error {
+	input := &secretsmanager.CreateSecretInput{
+		Name:         aws.String(name),
+		SecretString: aws.String(value),
+	}
+	if description != "" {
+		input.Description = aws.String(description)
+	}
+	for key, tagValue := range tags {
+		input.Tags = append(input.Tags, types.Tag{Key: aws.String(key), Value: aws.String(tagValue)})
+	}
+
+	if _, err := m.secretsClient.CreateSecret(ctx, input); err != nil {
+		return fmt.Errorf("failed to create secret %s: %w", name, err)
+	}
+	return nil
+}
+
 // UpdateSecret updates an existing secret
 func (m *Manager) UpdateSecret(ctx context.Context, name string, value interface{}) error {
 	input := &secretsmanager.UpdateSecretInput{
@@ -166,6 +224,11 @@ func (m *Manager) ListSecrets(ctx context.Context, namePrefix string) ([]*Secret
 			NextToken:  nextToken,
 			MaxResults: aws.Int32(100),
 		}
+		// The name filter matches prefixes regardless of case, so names
+		// are still checked below
+		if namePrefix != "" {
+			input.Filters = []types.Filter{{Key: types.FilterNameStringTypeName, Values: []string{namePrefix}}}
+		}
 
 		result, err := m.secretsClient.ListSecrets(ctx, input)
 		if err != nil {
@@ -192,6 +255,13 @@ func (m *Manager) ListSecrets(ctx context.Context, namePrefix string) ([]*Secret
 			if secretEntry.LastChangedDate != nil {
 				secret.LastModified = secretEntry.LastChangedDate.Format("2006-01-02 15:04:05")
 			}
+			secret.VersionId = currentVersion(secretEntry.SecretVersionsToStages)
+			if len(secretEntry.Tags) > 0 {
+				secret.Tags = make(map[string]string, len(secretEntry.Tags))
+				for _, tag := range secretEntry.Tags {
+					secret.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+				}
+			}
 
 			secrets = append(secrets, secret)
 		}
@@ -205,6 +275,142 @@ func (m *Manager) ListSecrets(ctx context.Context, namePrefix string) ([]*Secret
 	return secrets, nil
 }
 
+// GetCurrentVersionID returns the VersionId labelled AWSCURRENT without
+// retrieving the secret value
+func (m *Manager) GetCurrentVersionID(ctx context.Context, name string) (string, error) {
+	input := &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(name),
+	}
+
+	result, err := m.secretsClient.DescribeSecret(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to describe secret %s: %w", name, err)
+	}
+
+	if versionID := currentVersion(result.VersionIdsToStages); versionID != "" {
+		return versionID, nil
+	}
+	return "", fmt.Errorf("secret %s has no %s version", name, StageCurrent)
+}
+
+// currentVersion returns the version labelled AWSCURRENT, if any
+func currentVersion(versionsToStages map[string][]string) string {
+	return VersionWithStage(versionsToStages, StageCurrent)
+}
+
+// batchSize is the most secrets BatchGetSecretValue reads by name at once
+const batchSize = 20
+
+// GetSecretStrings reads the string values of the named secrets, 20 per
+// request. Secrets that do not exist are left out; secrets the caller may
+// not read are left out and returned in denied, so a principal allowed a
+// subset of an environment still gets that subset.
+func (m *Manager) GetSecretStrings(ctx context.Context, names []string) (values map[string]string, denied []string, err error) {
+	values = make(map[string]string, len(names))
+	for start := 0; start < len(names); start += batchSize {
+		end := start + batchSize
+		if end > len(names) {
+			end = len(names)
+		}
+		result, err := m.secretsClient.BatchGetSecretValue(ctx, &secretsmanager.BatchGetSecretValueInput{
+			SecretIdList: names[start:end],
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get secrets: %w", err)
+		}
+
+		for _, entry := range result.SecretValues {
+			values[aws.ToString(entry.Name)] = aws.ToString(entry.SecretString)
+		}
+		for _, failure := range result.Errors {
+			switch aws.ToString(failure.ErrorCode) {
+			case "ResourceNotFoundException":
+			case "AccessDeniedException":
+				denied = append(denied, aws.ToString(failure.SecretId))
+			default:
+				return nil, nil, fmt.Errorf("failed to get secret %s: %s: %s", aws.ToString(failure.SecretId), aws.ToString(failure.ErrorCode), aws.ToString(failure.Message))
+			}
+		}
+	}
+	return values, denied, nil
+}
+
+// SecretOptions are applied to a secret when PutSecretString creates it
+type SecretOptions struct {
+	Description string
+	KMSKeyID    string // empty for the AWS managed key
+	Tags        map[string]string
+}
+
+// PutSecretString sets the value of a string secret, creating it with opts
+// if it does not exist
+func (m *Manager) PutSecretString(ctx context.Context, name, value string, opts SecretOptions) error {
+	_, err := m.secretsClient.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(name),
+		SecretString: aws.String(value),
+	})
+	var notFound *types.ResourceNotFoundException
+	if !isAWSError(err, &notFound) {
+		if err != nil {
+			return fmt.Errorf("failed to update secret %s: %w", name, err)
+		}
+		return nil
+	}
+
+	input := &secretsmanager.CreateSecretInput{
+		Name:         aws.String(name),
+		SecretString: aws.String(value),
+	}
+	if opts.Description != "" {
+		input.Description = aws.String(opts.Description)
+	}
+	if opts.KMSKeyID != "" {
+		input.KmsKeyId = aws.String(opts.KMSKeyID)
+	}
+	for key, tagValue := range opts.Tags {
+		input.Tags = append(input.Tags, types.Tag{Key: aws.String(key), Value: aws.String(tagValue)})
+	}
+	if _, err := m.secretsClient.CreateSecret(ctx, input); err != nil {
+		return fmt.Errorf("failed to create secret %s: %w", name, err)
+	}
+	return nil
+}
+
+// SecretMetadata is the encryption, rotation and tagging of a secret
+type SecretMetadata struct {
+	Name            string
+	ARN             string
+	KMSKeyID        string // empty for the AWS managed key
+	RotationEnabled bool
+	RotationDays    int64 // rotation interval, if set in days
+	Tags            map[string]string
+}
+
+// DescribeSecret returns a secret's metadata without retrieving its value
+func (m *Manager) DescribeSecret(ctx context.Context, name string) (*SecretMetadata, error) {
+	result, err := m.secretsClient.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe secret %s: %w", name, err)
+	}
+
+	metadata := &SecretMetadata{
+		Name:            aws.ToString(result.Name),
+		ARN:             aws.ToString(result.ARN),
+		KMSKeyID:        aws.ToString(result.KmsKeyId),
+		RotationEnabled: aws.ToBool(result.RotationEnabled),
+		Tags:            make(map[string]string, len(result.Tags)),
+	}
+	if result.RotationRules != nil {
+		metadata.RotationDays = aws.ToInt64(result.RotationRules.AutomaticallyAfterDays)
+	}
+	for _, tag := range result.Tags {
+		metadata.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return metadata, nil
+}
+
 // CreateOrUpdateSecret creates a new secret or updates if it exists
 func (m *Manager) CreateOrUpdateSecret(ctx context.Context, name, description string, value interface{}) error {
 	// Try to update first
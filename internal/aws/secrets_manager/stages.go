@@ -0,0 +1,102 @@
+package secrets_manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// Staging labels Secrets Manager maintains itself
+const (
+	// StageCurrent labels the version readers get by default
+	StageCurrent = "AWSCURRENT"
+	// StagePrevious labels the version AWSCURRENT was last moved from
+	StagePrevious = "AWSPREVIOUS"
+)
+
+// VersionRef selects a version of a secret by staging label or version ID.
+// The zero value selects AWSCURRENT.
+type VersionRef struct {
+	Stage     string
+	VersionID string
+}
+
+// String describes the version for messages
+func (r VersionRef) String() string {
+	switch {
+	case r.VersionID != "":
+		return "version " + r.VersionID
+	case r.Stage != "":
+		return "stage " + r.Stage
+	}
+	return "stage " + StageCurrent
+}
+
+// PutSecretStaged writes value as a new version of an existing secret that
+// carries only stage, so readers of AWSCURRENT keep the current value until
+// the stage is promoted. It returns the ID of the new version.
+func (m *Manager) PutSecretStaged(ctx context.Context, name, value, stage string) (string, error) {
+	result, err := m.secretsClient.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:      aws.String(name),
+		SecretString:  aws.String(value),
+		VersionStages: []string{stage},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to stage secret %s as %s: %w", name, stage, err)
+	}
+	return aws.ToString(result.VersionId), nil
+}
+
+// VersionStages returns the staging labels of each version of a secret by
+// version ID
+func (m *Manager) VersionStages(ctx context.Context, name string) (map[string][]string, error) {
+	result, err := m.secretsClient.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe secret %s: %w", name, err)
+	}
+	return result.VersionIdsToStages, nil
+}
+
+// MoveStage attaches stage to versionID in one request, removing it from
+// the version that has it. Moving AWSCURRENT labels the version it leaves
+// AWSPREVIOUS, so readers switch from one complete value to the other.
+func (m *Manager) MoveStage(ctx context.Context, name, stage, versionID string) error {
+	versions, err := m.VersionStages(ctx, name)
+	if err != nil {
+		return err
+	}
+	from := VersionWithStage(versions, stage)
+	if from == versionID {
+		return nil
+	}
+
+	input := &secretsmanager.UpdateSecretVersionStageInput{
+		SecretId:        aws.String(name),
+		VersionStage:    aws.String(stage),
+		MoveToVersionId: aws.String(versionID),
+	}
+	if from != "" {
+		input.RemoveFromVersionId = aws.String(from)
+	}
+	if _, err := m.secretsClient.UpdateSecretVersionStage(ctx, input); err != nil {
+		return fmt.Errorf("failed to move %s of secret %s to version %s: %w", stage, name, versionID, err)
+	}
+	return nil
+}
+
+// VersionWithStage returns the version labelled stage, or "" when no
+// version has it
+func VersionWithStage(versionsToStages map[string][]string, stage string) string {
+	for versionID, stages := range versionsToStages {
+		for _, s := range stages {
+			if s == stage {
+				return versionID
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,26 @@
+package secrets_manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionWithStage(t *testing.T) {
+	versions := map[string][]string{
+		"v3": {"green"},
+		"v2": {StageCurrent},
+		"v1": {StagePrevious, "blue"},
+	}
+	assert.Equal(t, "v2", VersionWithStage(versions, StageCurrent))
+	assert.Equal(t, "v1", VersionWithStage(versions, "blue"))
+	assert.Equal(t, "v3", VersionWithStage(versions, "green"))
+	assert.Empty(t, VersionWithStage(versions, "canary"))
+	assert.Equal(t, "v2", currentVersion(versions))
+}
+
+func TestVersionRefString(t *testing.T) {
+	assert.Equal(t, "stage AWSCURRENT", VersionRef{}.String())
+	assert.Equal(t, "stage green", VersionRef{Stage: "green"}.String())
+	assert.Equal(t, "version 4f1c", VersionRef{VersionID: "4f1c"}.String())
+}
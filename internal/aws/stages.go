@@ -0,0 +1,182 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/drapon/envy/internal/aws/errors"
+	secrets_manager "github.com/drapon/envy/internal/aws/secrets_manager"
+	"github.com/drapon/envy/internal/env"
+)
+
+// Secrets Manager keeps earlier values of a secret as versions and points
+// staging labels at them; readers get the version labelled AWSCURRENT. A
+// blue/green rollout stages the new values under a label of its own, lets
+// pinned consumers try them, and then moves AWSCURRENT to them in one
+// request. Moving it back to AWSPREVIOUS rolls the change back.
+
+// stagedPath returns the path of envName, which must be stored in Secrets
+// Manager to have versions
+func (m *Manager) stagedPath(envName string) (string, error) {
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return "", err
+	}
+	if m.config.GetAWSService(envName) != "secrets_manager" && !envConfig.UseSecretsManager {
+		return "", fmt.Errorf("%s is stored in Parameter Store; version stages are only available for Secrets Manager", envName)
+	}
+	return m.config.GetParameterPath(envName), nil
+}
+
+// PullEnvironmentVersion pulls the version of an environment ref selects,
+// e.g. the one labelled AWSPREVIOUS. Environments stored one secret per
+// variable can only be selected by stage, since each secret has its own
+// version IDs; secrets without the stage are left out.
+func (m *Manager) PullEnvironmentVersion(ctx context.Context, envName string, ref secrets_manager.VersionRef) (*env.File, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	path, err := m.stagedPath(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !m.config.SecretPerVariable() {
+		secretName := SecretName(path)
+		secret, err := m.secretsManager.GetSecretVersion(ctx, secretName, ref)
+		if err != nil {
+			return nil, errors.WrapAWSError(err, "get secret "+ref.String(), secretName)
+		}
+		return varsFile(m.bundleVars(secret)), nil
+	}
+
+	if ref.VersionID != "" {
+		return nil, fmt.Errorf("each variable of %s is its own secret with its own versions; select them with a stage instead of a version ID", envName)
+	}
+	secrets, err := m.listVariableSecrets(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(secrets))
+	for _, entry := range secrets {
+		value, err := m.secretsManager.GetSecretStringVersion(ctx, entry.Name, ref)
+		if err != nil {
+			if errors.IsNotFoundError(err) {
+				continue // no version of this variable has the stage
+			}
+			return nil, errors.WrapAWSError(err, "get secret "+ref.String(), entry.Name)
+		}
+		values[entry.Name] = value
+	}
+	return varsFile(m.variableSecretVars(values, path)), nil
+}
+
+// PushEnvironmentStaged writes the variables of file as new versions
+// labelled stage, leaving AWSCURRENT and so every reader on the current
+// values. The environment's secrets must exist; push without a stage first.
+func (m *Manager) PushEnvironmentStaged(ctx context.Context, envName string, file *env.File, stage string) error {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return err
+	}
+	path, err := m.stagedPath(envName)
+	if err != nil {
+		return err
+	}
+	vars := file.ToMap()
+
+	if m.config.SecretPerVariable() {
+		for _, key := range file.SortedKeys() {
+			name := VariableSecretPrefix(path) + m.RemoteKey(key)
+			if _, err := m.secretsManager.PutSecretStaged(ctx, name, vars[key], stage); err != nil {
+				if errors.IsNotFoundError(err) {
+					return fmt.Errorf("%s has no secret yet; push it without --stage first", key)
+				}
+				return errors.WrapAWSError(err, "stage secret", name)
+			}
+		}
+		return nil
+	}
+
+	secretName := SecretName(path)
+	// A shared secret keeps the variables of the other namespaces
+	if m.namespacePrefix() != "" {
+		current, err := m.secretsManager.GetSecret(ctx, secretName)
+		if err != nil {
+			return errors.WrapAWSError(err, "get secret", secretName)
+		}
+		vars = m.mergeNamespace(current.KeyValue, vars)
+	}
+	data, err := json.Marshal(vars)
+	if err != nil {
+		return err
+	}
+	if _, err := m.secretsManager.PutSecretStaged(ctx, secretName, string(data), stage); err != nil {
+		if errors.IsNotFoundError(err) {
+			return fmt.Errorf("secret %s does not exist yet; push without --stage first", secretName)
+		}
+		return errors.WrapAWSError(err, "stage secret", secretName)
+	}
+	return nil
+}
+
+// PromoteStage moves AWSCURRENT to the version labelled stage, so readers
+// switch to the staged values at once, and returns how many secrets were
+// switched. With one secret per variable each secret switches on its own.
+func (m *Manager) PromoteStage(ctx context.Context, envName, stage string) (int, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return 0, err
+	}
+	path, err := m.stagedPath(envName)
+	if err != nil {
+		return 0, err
+	}
+
+	names := []string{SecretName(path)}
+	if m.config.SecretPerVariable() {
+		secrets, err := m.listVariableSecrets(ctx, path)
+		if err != nil {
+			return 0, err
+		}
+		names = names[:0]
+		for _, secret := range secrets {
+			names = append(names, secret.Name)
+		}
+	}
+
+	promoted := 0
+	for _, name := range names {
+		versions, err := m.secretsManager.VersionStages(ctx, name)
+		if err != nil {
+			return promoted, errors.WrapAWSError(err, "describe secret", name)
+		}
+		versionID := secrets_manager.VersionWithStage(versions, stage)
+		if versionID == "" {
+			continue
+		}
+		if err := m.secretsManager.MoveStage(ctx, name, secrets_manager.StageCurrent, versionID); err != nil {
+			return promoted, errors.WrapAWSError(err, "promote secret", name)
+		}
+		promoted++
+	}
+	if promoted == 0 {
+		return 0, fmt.Errorf("no version of %s is labelled %s", envName, stage)
+	}
+	return promoted, nil
+}
+
+// bundleVars returns the variables of the project in the secret of an
+// environment stored as one secret
+func (m *Manager) bundleVars(secret *secrets_manager.Secret) map[string]string {
+	if secret.KeyValue != nil {
+		return m.localVars(secret.KeyValue)
+	}
+	// A plain string secret is returned as a single key
+	if secret.Value != "" {
+		return map[string]string{"SECRET_VALUE": secret.Value}
+	}
+	return map[string]string{}
+}
@@ -0,0 +1,249 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/drapon/envy/internal/aws/errors"
+	secrets_manager "github.com/drapon/envy/internal/aws/secrets_manager"
+	"github.com/drapon/envy/internal/log"
+)
+
+// With secrets_manager.mode per_variable, each variable of a Secrets
+// Manager environment is its own string secret named after the environment
+// path and the key, e.g. myapp/prod/DB_PASSWORD, so IAM policies can grant
+// access to single variables or key patterns.
+
+// VariableSecretPrefix returns the prefix of the secrets of an environment
+// stored one secret per variable under path
+func VariableSecretPrefix(path string) string {
+	return strings.Trim(path, "/") + "/"
+}
+
+// variableSecretKey returns the remote key a per-variable secret holds, and
+// false for secrets of other paths or nested below path
+func variableSecretKey(name, prefix string) (string, bool) {
+	key := strings.TrimPrefix(name, prefix)
+	if key == name || key == "" || strings.Contains(key, "/") {
+		return "", false
+	}
+	return key, true
+}
+
+// listVariableSecrets returns the per-variable secrets of an environment
+func (m *Manager) listVariableSecrets(ctx context.Context, path string) ([]*secrets_manager.Secret, error) {
+	prefix := VariableSecretPrefix(path)
+	secrets, err := m.secretsManager.ListSecrets(ctx, prefix)
+	if err != nil {
+		return nil, errors.WrapAWSError(err, "list secrets", prefix)
+	}
+
+	own := secrets[:0]
+	for _, secret := range secrets {
+		if _, ok := variableSecretKey(secret.Name, prefix); ok {
+			own = append(own, secret)
+		}
+	}
+	return own, nil
+}
+
+// pullFromVariableSecrets reads every per-variable secret of an environment.
+// Secrets the caller may not read are skipped, so a principal granted part
+// of an environment pulls that part.
+func (m *Manager) pullFromVariableSecrets(ctx context.Context, path string) (map[string]string, error) {
+	secrets, err := m.listVariableSecrets(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		names = append(names, secret.Name)
+	}
+	values, err := m.fetchVariableSecrets(ctx, path, names)
+	if err != nil {
+		return nil, err
+	}
+	return m.variableSecretVars(values, path), nil
+}
+
+// fetchVariableSecrets returns the values of the named per-variable secrets
+// by name, leaving out the ones that do not exist or may not be read
+func (m *Manager) fetchVariableSecrets(ctx context.Context, path string, names []string) (map[string]string, error) {
+	values, denied, err := m.secretsManager.GetSecretStrings(ctx, names)
+	if err != nil {
+		return nil, errors.WrapAWSError(err, "get secrets", VariableSecretPrefix(path))
+	}
+	if len(denied) > 0 {
+		log.Named("aws").Warn("Skipped secrets the caller may not read", zap.Strings("secrets", denied))
+	}
+	return values, nil
+}
+
+// variableSecretVars returns the project's variables among values of
+// per-variable secrets, keyed by secret name
+func (m *Manager) variableSecretVars(values map[string]string, path string) map[string]string {
+	prefix := VariableSecretPrefix(path)
+	remote := make(map[string]string, len(values))
+	for name, value := range values {
+		if key, ok := variableSecretKey(name, prefix); ok {
+			remote[key] = value
+		}
+	}
+	return m.localVars(remote)
+}
+
+// pushToVariableSecrets writes each variable to its own secret. Like a
+// Parameter Store push, variables not in vars are left as they are.
+func (m *Manager) pushToVariableSecrets(ctx context.Context, path string, vars map[string]string, overwrite bool) error {
+	if !overwrite {
+		current, err := m.pullFromVariableSecrets(ctx, path)
+		if err != nil {
+			return err
+		}
+		var existing []string
+		for key := range vars {
+			if _, ok := current[key]; ok {
+				existing = append(existing, key)
+			}
+		}
+		sort.Strings(existing)
+		if len(existing) > 0 {
+			if _, err := m.confirmOverwrites(existing, vars); err != nil {
+				return err
+			}
+			if len(vars) == 0 {
+				fmt.Println("No new variables to push.")
+				return nil
+			}
+		}
+	}
+
+	opts := m.variableSecretOptions(path)
+	for _, key := range sortedKeys(vars) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		name := VariableSecretPrefix(path) + m.RemoteKey(key)
+		if err := m.secretsManager.PutSecretString(ctx, name, vars[key], opts); err != nil {
+			return errors.WrapAWSError(err, "put secret", name)
+		}
+	}
+	return nil
+}
+
+// applyToVariableSecrets writes and deletes single per-variable secrets.
+// Deleted secrets are removed without a recovery window, as parameters are,
+// so the key can be set again at once; envy undo restores them.
+func (m *Manager) applyToVariableSecrets(ctx context.Context, path string, set map[string]string, deleted []string) error {
+	if len(set) > 0 {
+		if err := m.pushToVariableSecrets(ctx, path, set, true); err != nil {
+			return err
+		}
+	}
+	for _, key := range deleted {
+		name := VariableSecretPrefix(path) + m.RemoteKey(key)
+		if err := m.secretsManager.DeleteSecret(ctx, name, true); err != nil && !errors.IsNotFoundError(err) {
+			return errors.WrapAWSError(err, "delete secret", name)
+		}
+	}
+	return nil
+}
+
+// renameVariableSecret copies the secret of oldKey to newKey, verifies the
+// copy and deletes the original. Keys are remote keys.
+func (m *Manager) renameVariableSecret(ctx context.Context, path, oldKey, newKey string) error {
+	prefix := VariableSecretPrefix(path)
+	oldName, newName := prefix+oldKey, prefix+newKey
+
+	values, denied, err := m.secretsManager.GetSecretStrings(ctx, []string{oldName, newName})
+	if err != nil {
+		return errors.WrapAWSError(err, "get secrets", prefix)
+	}
+	if len(denied) > 0 {
+		return fmt.Errorf("access denied to %s", strings.Join(denied, ", "))
+	}
+	value, ok := values[oldName]
+	if !ok {
+		return fmt.Errorf("%s: %w", oldName, ErrKeyNotFound)
+	}
+	if _, exists := values[newName]; exists {
+		return fmt.Errorf("%s: %w", newName, ErrKeyExists)
+	}
+
+	if err := m.secretsManager.PutSecretString(ctx, newName, value, m.variableSecretOptions(path)); err != nil {
+		return errors.WrapAWSError(err, "put secret", newName)
+	}
+	copied, err := m.secretsManager.GetSecretString(ctx, newName)
+	if err != nil {
+		return errors.WrapAWSError(err, "verify secret", newName)
+	}
+	if copied != value {
+		return fmt.Errorf("verification failed for %s: value does not match %s, original kept", newName, oldName)
+	}
+	if err := m.secretsManager.DeleteSecret(ctx, oldName, true); err != nil {
+		return errors.WrapAWSError(err, "delete secret", oldName)
+	}
+	return nil
+}
+
+// variableSecretsVersion fingerprints the current versions of the
+// per-variable secrets of an environment without reading their values
+func (m *Manager) variableSecretsVersion(ctx context.Context, path string) (string, error) {
+	versions, err := m.variableSecretVersions(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	hasher := sha256.New()
+	for _, name := range sortedKeys(versions) {
+		fmt.Fprintf(hasher, "%s@%s\n", name, versions[name])
+	}
+	return "smv-" + hex.EncodeToString(hasher.Sum(nil))[:16], nil
+}
+
+// variableSecretVersions returns the current version of each per-variable
+// secret of an environment by name
+func (m *Manager) variableSecretVersions(ctx context.Context, path string) (map[string]string, error) {
+	secrets, err := m.listVariableSecrets(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		versions[secret.Name] = "sm-" + secret.VersionId
+	}
+	return versions, nil
+}
+
+// variableSecretOptions returns the description, KMS key and tags new
+// secrets of the environment stored under path are created with
+func (m *Manager) variableSecretOptions(path string) secrets_manager.SecretOptions {
+	opts := secrets_manager.SecretOptions{Description: "Environment variable of " + strings.Trim(path, "/")}
+	for name, envConfig := range m.config.Environments {
+		if m.config.GetParameterPath(name) != path {
+			continue
+		}
+		// Malformed tags are reported when the configuration is loaded
+		tags, _ := envConfig.ParameterTags()
+		opts.KMSKeyID = envConfig.KMSKeyID
+		opts.Tags = tags
+		break
+	}
+	return opts
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,50 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariableSecretKey(t *testing.T) {
+	prefix := VariableSecretPrefix("/myapp/prod/")
+	assert.Equal(t, "myapp/prod/", prefix)
+	assert.Equal(t, prefix, VariableSecretPrefix("myapp/prod"))
+
+	key, ok := variableSecretKey("myapp/prod/DB_PASSWORD", prefix)
+	assert.True(t, ok)
+	assert.Equal(t, "DB_PASSWORD", key)
+
+	for _, name := range []string{"myapp/prod/", "myapp/prod/db/PASSWORD", "myapp/production/DB_PASSWORD", "myapp-prod"} {
+		_, ok := variableSecretKey(name, prefix)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestVariableSecretVars(t *testing.T) {
+	m := &Manager{config: &config.Config{Namespace: "billing"}}
+
+	values := map[string]string{
+		"myapp/prod/billing.DB_URL": "postgres://billing",
+		"myapp/prod/orders.DB_URL":  "postgres://orders",
+		"myapp/prod/nested/KEY":     "x",
+		"myapp-prod":                `{"KEY":"bundle"}`,
+	}
+	assert.Equal(t, map[string]string{"DB_URL": "postgres://billing"}, m.variableSecretVars(values, "/myapp/prod/"))
+}
+
+func TestVariableSecretOptions(t *testing.T) {
+	m := &Manager{config: &config.Config{
+		Environments: map[string]config.Environment{
+			"prod": {Path: "/myapp/prod/", KMSKeyID: "alias/prod", Tags: []string{"team=billing"}},
+		},
+	}}
+
+	opts := m.variableSecretOptions("/myapp/prod/")
+	assert.Equal(t, "alias/prod", opts.KMSKeyID)
+	assert.Equal(t, map[string]string{"team": "billing"}, opts.Tags)
+	assert.Equal(t, "Environment variable of myapp/prod", opts.Description)
+
+	assert.Empty(t, m.variableSecretOptions("/other/").KMSKeyID)
+}
@@ -0,0 +1,82 @@
+package aws
+
+import (
+	"context"
+	"strings"
+
+	"github.com/drapon/envy/internal/aws/errors"
+)
+
+// GetVariables fetches only the given keys of an environment. Keys that do
+// not exist remotely are omitted from the result. Parameter Store reads one
+// parameter per key; a Secrets Manager environment is a single secret, so it
+// is read whole and filtered.
+func (m *Manager) GetVariables(ctx context.Context, envName string, keys []string) (map[string]string, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	var vars map[string]string
+	err = m.readWithFallback(ctx, envName, func(ctx context.Context, rm *Manager) error {
+		var err error
+		vars, err = rm.getVariables(ctx, envName, keys)
+		return err
+	})
+	return vars, err
+}
+
+func (m *Manager) getVariables(ctx context.Context, envName string, keys []string) (map[string]string, error) {
+	// Get environment configuration
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Determine which service to use
+	service := m.config.GetAWSService(envName)
+	path := m.config.GetParameterPath(envName)
+
+	if (service == "secrets_manager" || envConfig.UseSecretsManager) && m.config.SecretPerVariable() {
+		names := make([]string, 0, len(keys))
+		for _, key := range keys {
+			names = append(names, VariableSecretPrefix(path)+m.RemoteKey(key))
+		}
+		values, err := m.fetchVariableSecrets(ctx, path, names)
+		if err != nil {
+			return nil, err
+		}
+		return m.variableSecretVars(values, path), nil
+	}
+
+	if service == "secrets_manager" || envConfig.UseSecretsManager {
+		all, err := m.pullFromSecretsManager(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		vars := make(map[string]string, len(keys))
+		for _, key := range keys {
+			if value, ok := all[key]; ok {
+				vars[key] = value
+			}
+		}
+		return vars, nil
+	}
+
+	if !strings.HasSuffix(path, "/") {
+		path = path + "/"
+	}
+
+	vars := make(map[string]string, len(keys))
+	for _, key := range keys {
+		paramName := path + m.RemoteKey(key)
+		param, err := m.paramStore.GetParameter(ctx, paramName, true)
+		if err != nil {
+			if errors.IsNotFoundError(err) {
+				continue
+			}
+			return nil, errors.WrapAWSError(err, "get parameter", paramName)
+		}
+		vars[key] = param.Value
+	}
+	return vars, nil
+}
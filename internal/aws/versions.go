@@ -0,0 +1,100 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/drapon/envy/internal/aws/errors"
+	"github.com/drapon/envy/internal/aws/parameter_store"
+	"github.com/drapon/envy/internal/env"
+)
+
+// VariableHistory returns the versions of a variable that Parameter Store
+// keeps, oldest first. Secrets Manager environments are one secret for all
+// variables, so they have no per-variable history.
+func (m *Manager) VariableHistory(ctx context.Context, envName, key string) ([]*parameter_store.ParameterVersion, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	paramName, err := m.parameterName(envName, key)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := m.paramStore.GetParameterHistory(ctx, paramName, true)
+	if err != nil {
+		if errors.IsNotFoundError(err) {
+			return nil, fmt.Errorf("%s does not exist in %s", key, envName)
+		}
+		return nil, errors.WrapAWSError(err, "get parameter history", paramName)
+	}
+	return versions, nil
+}
+
+// RollbackVariable writes the value of an earlier version of a variable as
+// its new version, replicated like a push, and returns the version restored
+func (m *Manager) RollbackVariable(ctx context.Context, envName, key string, version int64) (*parameter_store.ParameterVersion, error) {
+	m, err := m.forEnvironment(envName)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := m.VariableHistory(ctx, envName, key)
+	if err != nil {
+		return nil, err
+	}
+	target, err := RollbackTarget(versions, version)
+	if err != nil {
+		return nil, err
+	}
+
+	set := env.NewFile()
+	set.Set(key, target.Value)
+	if err := m.ApplyChanges(ctx, envName, set, nil); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// RollbackTarget returns the version to restore from a parameter's history.
+// The current version and large values cannot be restored: Secrets Manager
+// only holds the latest large value.
+func RollbackTarget(versions []*parameter_store.ParameterVersion, version int64) (*parameter_store.ParameterVersion, error) {
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("the parameter has no history")
+	}
+
+	latest := versions[len(versions)-1]
+	for _, v := range versions {
+		if v.Version != version {
+			continue
+		}
+		if v == latest {
+			return nil, fmt.Errorf("version %d is the current version", version)
+		}
+		if v.LargeValue {
+			return nil, fmt.Errorf("version %d was stored in Secrets Manager, which keeps only the latest large value", version)
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("version %d not found; Parameter Store keeps versions %d to %d", version, versions[0].Version, latest.Version)
+}
+
+// parameterName returns the parameter holding key in a Parameter Store
+// environment
+func (m *Manager) parameterName(envName, key string) (string, error) {
+	envConfig, err := m.config.GetEnvironment(envName)
+	if err != nil {
+		return "", err
+	}
+	if m.config.GetAWSService(envName) == "secrets_manager" || envConfig.UseSecretsManager {
+		return "", fmt.Errorf("%s is stored in Secrets Manager; version history is only available for Parameter Store", envName)
+	}
+
+	path := m.config.GetParameterPath(envName)
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	return path + m.RemoteKey(key), nil
+}
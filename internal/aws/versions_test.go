@@ -0,0 +1,33 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/aws/parameter_store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollbackTarget(t *testing.T) {
+	versions := []*parameter_store.ParameterVersion{
+		{Version: 3, Value: "a"},
+		{Version: 4, Value: "", LargeValue: true},
+		{Version: 5, Value: "b"},
+	}
+
+	target, err := RollbackTarget(versions, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "a", target.Value)
+
+	_, err = RollbackTarget(versions, 5)
+	assert.ErrorContains(t, err, "current version")
+
+	_, err = RollbackTarget(versions, 4)
+	assert.ErrorContains(t, err, "Secrets Manager")
+
+	_, err = RollbackTarget(versions, 1)
+	assert.ErrorContains(t, err, "keeps versions 3 to 5")
+
+	_, err = RollbackTarget(nil, 1)
+	assert.Error(t, err)
+}
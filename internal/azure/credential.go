@@ -0,0 +1,315 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// vaultResource is the resource Key Vault access tokens are issued for
+	vaultResource = "https://vault.azure.net"
+
+	defaultAuthorityHost = "https://login.microsoftonline.com/"
+
+	// imdsEndpoint is the Azure Instance Metadata Service token endpoint
+	imdsEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+	// tokenRefreshMargin renews tokens this long before they expire
+	tokenRefreshMargin = 2 * time.Minute
+)
+
+// token is an access token and when it expires
+type token struct {
+	value     string
+	expiresOn time.Time
+}
+
+// tokenSource obtains Key Vault access tokens from one kind of credential
+type tokenSource interface {
+	name() string
+	token(ctx context.Context) (token, error)
+}
+
+// Credential obtains and caches Key Vault access tokens. It uses, in order:
+// a client secret (AZURE_CLIENT_SECRET) or federated token
+// (AZURE_FEDERATED_TOKEN_FILE) with AZURE_TENANT_ID and AZURE_CLIENT_ID, the
+// Azure CLI login, or the managed identity of the machine.
+type Credential struct {
+	sources []tokenSource
+
+	mu     sync.Mutex
+	cached token
+	source tokenSource // the source that last succeeded
+}
+
+// NewCredential returns the credential for the tenant and client, which
+// override AZURE_TENANT_ID and AZURE_CLIENT_ID when set
+func NewCredential(tenantID, clientID string) *Credential {
+	if tenantID == "" {
+		tenantID = os.Getenv("AZURE_TENANT_ID")
+	}
+	if clientID == "" {
+		clientID = os.Getenv("AZURE_CLIENT_ID")
+	}
+	authority := os.Getenv("AZURE_AUTHORITY_HOST")
+	if authority == "" {
+		authority = defaultAuthorityHost
+	}
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+
+	// Explicitly configured service principals are not followed by other
+	// sources, so a mistake in them is reported rather than masked
+	if secret := os.Getenv("AZURE_CLIENT_SECRET"); secret != "" && tenantID != "" && clientID != "" {
+		return &Credential{sources: []tokenSource{&clientCredential{
+			authority: authority, tenantID: tenantID, clientID: clientID,
+			form: url.Values{"client_secret": {secret}},
+			kind: "client secret", http: httpClient,
+		}}}
+	}
+	if file := os.Getenv("AZURE_FEDERATED_TOKEN_FILE"); file != "" && tenantID != "" && clientID != "" {
+		return &Credential{sources: []tokenSource{&clientCredential{
+			authority: authority, tenantID: tenantID, clientID: clientID,
+			assertionFile: file,
+			kind:          "federated token", http: httpClient,
+		}}}
+	}
+
+	return &Credential{sources: []tokenSource{
+		&cliCredential{tenantID: tenantID},
+		&managedIdentity{clientID: clientID, http: &http.Client{}},
+	}}
+}
+
+// Token returns a valid access token for Key Vault
+func (c *Credential) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached.value != "" && time.Until(c.cached.expiresOn) > tokenRefreshMargin {
+		return c.cached.value, nil
+	}
+
+	// Stay with the source that worked, then try the others
+	sources := c.sources
+	if c.source != nil {
+		sources = append([]tokenSource{c.source}, sources...)
+	}
+
+	var failures []string
+	for i, source := range sources {
+		if i > 0 && source == c.source {
+			continue
+		}
+		t, err := source.token(ctx)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", source.name(), err))
+			continue
+		}
+		c.cached, c.source = t, source
+		return t.value, nil
+	}
+	return "", fmt.Errorf("no Azure credentials: set AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET, run 'az login', or use a managed identity (%s)", strings.Join(failures, "; "))
+}
+
+// clientCredential requests tokens for a service principal with a client
+// secret or a federated token from Microsoft Entra ID
+type clientCredential struct {
+	authority     string
+	tenantID      string
+	clientID      string
+	form          url.Values // extra form fields, the client secret
+	assertionFile string     // federated token, re-read for every request
+	kind          string
+	http          *http.Client
+}
+
+func (c *clientCredential) name() string { return c.kind }
+
+func (c *clientCredential) token(ctx context.Context) (token, error) {
+	form := url.Values{
+		"grant_type": {"client_credentials"},
+		"client_id":  {c.clientID},
+		"scope":      {vaultResource + "/.default"},
+	}
+	for key, values := range c.form {
+		form[key] = values
+	}
+	if c.assertionFile != "" {
+		// Federated tokens are short-lived and rotated on disk
+		assertion, err := os.ReadFile(c.assertionFile)
+		if err != nil {
+			return token{}, fmt.Errorf("failed to read federated token: %w", err)
+		}
+		form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+		form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+	}
+
+	endpoint := strings.TrimSuffix(c.authority, "/") + "/" + url.PathEscape(c.tenantID) + "/oauth2/v2.0/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return requestToken(c.http, req)
+}
+
+// cliCredential gets tokens from the Azure CLI login
+type cliCredential struct {
+	tenantID string
+}
+
+func (c *cliCredential) name() string { return "Azure CLI" }
+
+func (c *cliCredential) token(ctx context.Context) (token, error) {
+	if _, err := exec.LookPath("az"); err != nil {
+		return token{}, fmt.Errorf("az is not installed")
+	}
+
+	args := []string{"account", "get-access-token", "--resource", vaultResource, "--output", "json"}
+	if c.tenantID != "" {
+		args = append(args, "--tenant", c.tenantID)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "az", args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return token{}, fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return token{}, err
+	}
+	return parseCLIToken(out)
+}
+
+// parseCLIToken parses the output of az account get-access-token. Older
+// versions only report the expiry as local time in expiresOn.
+func parseCLIToken(out []byte) (token, error) {
+	var body struct {
+		AccessToken string `json:"accessToken"`
+		ExpiresOn   string `json:"expiresOn"`
+		ExpiresUnix int64  `json:"expires_on"`
+	}
+	if err := json.Unmarshal(out, &body); err != nil {
+		return token{}, fmt.Errorf("failed to decode az output: %w", err)
+	}
+	if body.AccessToken == "" {
+		return token{}, fmt.Errorf("az returned no access token")
+	}
+
+	t := token{value: body.AccessToken}
+	if body.ExpiresUnix > 0 {
+		t.expiresOn = time.Unix(body.ExpiresUnix, 0)
+	} else if expires, err := time.ParseInLocation("2006-01-02 15:04:05.999999", body.ExpiresOn, time.Local); err == nil {
+		t.expiresOn = expires
+	} else {
+		return token{}, fmt.Errorf("az returned an invalid expiry %q", body.ExpiresOn)
+	}
+	return t, nil
+}
+
+// managedIdentity gets tokens for the identity of the Azure resource envy
+// runs on: App Service and Functions set IDENTITY_ENDPOINT, virtual
+// machines and AKS nodes serve them from IMDS
+type managedIdentity struct {
+	clientID string
+	http     *http.Client
+}
+
+func (c *managedIdentity) name() string { return "managed identity" }
+
+func (c *managedIdentity) token(ctx context.Context) (token, error) {
+	query := url.Values{"resource": {vaultResource}}
+	if c.clientID != "" {
+		query.Set("client_id", c.clientID)
+	}
+
+	endpoint, header, value := imdsEndpoint, "Metadata", "true"
+	query.Set("api-version", "2018-02-01")
+	if identityEndpoint := os.Getenv("IDENTITY_ENDPOINT"); identityEndpoint != "" {
+		endpoint, header, value = identityEndpoint, "X-IDENTITY-HEADER", os.Getenv("IDENTITY_HEADER")
+		query.Set("api-version", "2019-08-01")
+	} else {
+		// IMDS is unreachable off Azure; don't wait for a connect timeout
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return token{}, err
+	}
+	req.Header.Set(header, value)
+	return requestToken(c.http, req)
+}
+
+// requestToken sends a token request and decodes the OAuth response.
+// Entra ID reports expires_in; managed identity endpoints report expires_on,
+// as a string.
+func requestToken(client *http.Client, req *http.Request) (token, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return token{}, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return token{}, err
+	}
+
+	var body struct {
+		AccessToken string          `json:"access_token"`
+		ExpiresIn   json.RawMessage `json:"expires_in"`
+		ExpiresOn   json.RawMessage `json:"expires_on"`
+		Error       string          `json:"error"`
+		Description string          `json:"error_description"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return token{}, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	if resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+		if body.Description != "" {
+			return token{}, fmt.Errorf("%s: %s", resp.Status, firstLine(body.Description))
+		}
+		return token{}, fmt.Errorf("%s: %s", resp.Status, body.Error)
+	}
+
+	t := token{value: body.AccessToken, expiresOn: time.Now().Add(time.Hour)}
+	if seconds, ok := jsonSeconds(body.ExpiresOn); ok {
+		t.expiresOn = time.Unix(seconds, 0)
+	} else if seconds, ok := jsonSeconds(body.ExpiresIn); ok {
+		t.expiresOn = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	return t, nil
+}
+
+// jsonSeconds reads a number of seconds given as a JSON number or string
+func jsonSeconds(raw json.RawMessage) (int64, bool) {
+	text := strings.Trim(string(raw), `"`)
+	if text == "" {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(text, 10, 64)
+	return seconds, err == nil
+}
+
+// firstLine trims Entra ID error descriptions, which append trace and
+// correlation IDs on further lines
+func firstLine(s string) string {
+	if i := strings.IndexAny(s, "\r\n"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
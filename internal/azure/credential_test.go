@@ -0,0 +1,65 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredential_ClientSecret(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/tenant/oauth2/v2.0/token", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client", r.PostForm.Get("client_id"))
+		assert.Equal(t, "secret", r.PostForm.Get("client_secret"))
+		assert.Equal(t, "https://vault.azure.net/.default", r.PostForm.Get("scope"))
+		w.Write([]byte(`{"access_token":"tok","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("AZURE_AUTHORITY_HOST", server.URL)
+	t.Setenv("AZURE_CLIENT_SECRET", "secret")
+	credential := NewCredential("tenant", "client")
+
+	for i := 0; i < 2; i++ {
+		value, err := credential.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "tok", value)
+	}
+	assert.Equal(t, 1, requests, "the token is cached")
+}
+
+func TestCredential_ClientSecretError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"invalid_client","error_description":"AADSTS7000215: Invalid client secret provided.\r\nTrace ID: 1"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("AZURE_AUTHORITY_HOST", server.URL)
+	t.Setenv("AZURE_CLIENT_SECRET", "wrong")
+	_, err := NewCredential("tenant", "client").Token(context.Background())
+	assert.ErrorContains(t, err, "AADSTS7000215: Invalid client secret provided.")
+	assert.NotContains(t, err.Error(), "Trace ID")
+}
+
+func TestParseCLIToken(t *testing.T) {
+	tok, err := parseCLIToken([]byte(`{"accessToken":"a","expiresOn":"2026-01-02 03:04:05.000000","expires_on":1767323045}`))
+	require.NoError(t, err)
+	assert.Equal(t, "a", tok.value)
+	assert.Equal(t, int64(1767323045), tok.expiresOn.Unix())
+
+	tok, err = parseCLIToken([]byte(`{"accessToken":"a","expiresOn":"2026-01-02 03:04:05.123456"}`))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 1, 2, 3, 4, 5, 123456000, time.Local), tok.expiresOn)
+
+	_, err = parseCLIToken([]byte(`{"accessToken":""}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,204 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// apiVersion is the Key Vault REST API version envy speaks
+const apiVersion = "7.4"
+
+// tokenProvider returns access tokens for requests; *Credential in
+// production
+type tokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// Client is a minimal Key Vault secrets client over the REST API
+type Client struct {
+	vaultURL string
+	tokens   tokenProvider
+	http     *http.Client
+}
+
+// SecretItem is a secret as listed, without its value
+type SecretItem struct {
+	Name    string
+	Enabled bool
+	Tags    map[string]string
+}
+
+// Secret is the current version of a secret
+type Secret struct {
+	Name  string
+	Value string
+	Tags  map[string]string
+}
+
+// Error is an error returned by Key Vault
+type Error struct {
+	StatusCode int
+	Code       string // e.g. SecretNotFound, Forbidden, Conflict
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("key vault: %s (%d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is Key Vault's answer for a missing secret
+func IsNotFound(err error) bool {
+	kvErr, ok := err.(*Error)
+	return ok && kvErr.StatusCode == http.StatusNotFound
+}
+
+// NewClient returns a client for the vault at vaultURL
+func NewClient(vaultURL string, tokens tokenProvider) *Client {
+	return &Client{
+		vaultURL: strings.TrimSuffix(vaultURL, "/"),
+		tokens:   tokens,
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// secretAttributes is the wire form of a secret and of a listed secret
+type secretAttributes struct {
+	ID         string            `json:"id"`
+	Value      string            `json:"value,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	Attributes struct {
+		Enabled *bool `json:"enabled,omitempty"`
+	} `json:"attributes"`
+}
+
+// ListSecrets returns every secret of the vault. Values are not included.
+func (c *Client) ListSecrets(ctx context.Context) ([]SecretItem, error) {
+	var items []SecretItem
+	next := c.vaultURL + "/secrets?maxresults=25&api-version=" + apiVersion
+	for next != "" {
+		var page struct {
+			Value    []secretAttributes `json:"value"`
+			NextLink string             `json:"nextLink"`
+		}
+		if err := c.do(ctx, http.MethodGet, next, nil, &page); err != nil {
+			return nil, err
+		}
+		for _, s := range page.Value {
+			items = append(items, SecretItem{
+				Name:    secretNameFromID(s.ID),
+				Enabled: s.Attributes.Enabled == nil || *s.Attributes.Enabled,
+				Tags:    s.Tags,
+			})
+		}
+		next = page.NextLink
+	}
+	return items, nil
+}
+
+// GetSecret returns the current version of a secret
+func (c *Client) GetSecret(ctx context.Context, name string) (*Secret, error) {
+	var s secretAttributes
+	if err := c.do(ctx, http.MethodGet, c.secretURL(name), nil, &s); err != nil {
+		return nil, err
+	}
+	return &Secret{Name: name, Value: s.Value, Tags: s.Tags}, nil
+}
+
+// SetSecret stores value as the new version of a secret, creating it if
+// needed
+func (c *Client) SetSecret(ctx context.Context, name, value string, tags map[string]string) error {
+	body := map[string]interface{}{
+		"value":       value,
+		"contentType": "text/plain",
+		"tags":        tags,
+	}
+	return c.do(ctx, http.MethodPut, c.secretURL(name), body, nil)
+}
+
+func (c *Client) secretURL(name string) string {
+	return c.vaultURL + "/secrets/" + url.PathEscape(name) + "?api-version=" + apiVersion
+}
+
+// do sends an authenticated request and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	accessToken, err := c.tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("key vault request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		kvErr := &Error{StatusCode: resp.StatusCode, Code: http.StatusText(resp.StatusCode)}
+		var errBody struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &errBody) == nil && errBody.Error.Code != "" {
+			kvErr.Code, kvErr.Message = errBody.Error.Code, errBody.Error.Message
+		} else {
+			kvErr.Message = strings.TrimSpace(string(data))
+		}
+		return kvErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode key vault response: %w", err)
+	}
+	return nil
+}
+
+// secretNameFromID returns the name in a secret ID such as
+// https://myvault.vault.azure.net/secrets/name or .../secrets/name/version
+func secretNameFromID(id string) string {
+	u, err := url.Parse(id)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(path.Clean(u.Path), "/"), "/")
+	if len(parts) >= 2 && parts[0] == "secrets" {
+		return parts[1]
+	}
+	return ""
+}
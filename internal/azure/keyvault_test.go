@@ -0,0 +1,80 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticToken string
+
+func (t staticToken) Token(ctx context.Context) (string, error) { return string(t), nil }
+
+func TestClient(t *testing.T) {
+	var stored map[string]interface{}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+		assert.Equal(t, apiVersion, r.URL.Query().Get("api-version"))
+
+		switch {
+		case r.URL.Path == "/secrets" && r.URL.Query().Get("page") == "":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"value": []map[string]interface{}{
+					{"id": server.URL + "/secrets/a", "tags": map[string]string{"envy-key": "A"}},
+				},
+				"nextLink": server.URL + "/secrets?page=2&api-version=" + apiVersion,
+			})
+		case r.URL.Path == "/secrets":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"value": []map[string]interface{}{
+					{"id": server.URL + "/secrets/b", "attributes": map[string]bool{"enabled": false}},
+				},
+			})
+		case r.URL.Path == "/secrets/a" && r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id": server.URL + "/secrets/a/0123", "value": "va", "tags": map[string]string{"envy-key": "A"},
+			})
+		case r.URL.Path == "/secrets/c" && r.Method == http.MethodPut:
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&stored))
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"SecretNotFound","message":"A secret with (name/id) x was not found in this key vault."}}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/", staticToken("tok"))
+	ctx := context.Background()
+
+	items, err := client.ListSecrets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []SecretItem{
+		{Name: "a", Enabled: true, Tags: map[string]string{"envy-key": "A"}},
+		{Name: "b", Enabled: false},
+	}, items)
+
+	secret, err := client.GetSecret(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "va", secret.Value)
+
+	require.NoError(t, client.SetSecret(ctx, "c", "vc", map[string]string{"envy-key": "C"}))
+	assert.Equal(t, "vc", stored["value"])
+	assert.Equal(t, map[string]interface{}{"envy-key": "C"}, stored["tags"])
+
+	_, err = client.GetSecret(ctx, "missing")
+	assert.True(t, IsNotFound(err))
+	assert.ErrorContains(t, err, "SecretNotFound")
+}
+
+func TestSecretNameFromID(t *testing.T) {
+	assert.Equal(t, "name", secretNameFromID("https://v.vault.azure.net/secrets/name"))
+	assert.Equal(t, "name", secretNameFromID("https://v.vault.azure.net/secrets/name/0123abcd"))
+	assert.Equal(t, "", secretNameFromID("https://v.vault.azure.net/keys/name"))
+}
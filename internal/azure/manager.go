@@ -0,0 +1,224 @@
+// Package azure stores envy environments in Azure Key Vault. Each variable
+// is one secret; tags record the environment path and the variable's exact
+// name, since secret names only allow letters, digits and hyphens.
+package azure
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/sensitive"
+)
+
+const (
+	// pathTag and keyTag are the tags identifying envy's secrets
+	pathTag = "envy-path"
+	keyTag  = "envy-key"
+
+	// maxNameLength is Key Vault's limit on secret names
+	maxNameLength = 127
+)
+
+// invalidNameChars are the characters Key Vault secret names cannot hold
+var invalidNameChars = regexp.MustCompile(`[^0-9A-Za-z-]+`)
+
+// vault is the part of Client the manager uses
+type vault interface {
+	ListSecrets(ctx context.Context) ([]SecretItem, error)
+	GetSecret(ctx context.Context, name string) (*Secret, error)
+	SetSecret(ctx context.Context, name, value string, tags map[string]string) error
+}
+
+// Manager pushes and pulls environments to and from Key Vault
+type Manager struct {
+	vault       vault
+	config      *config.Config
+	isSensitive func(string) bool
+}
+
+// NewManager returns a manager for the vault in cfg.Azure
+func NewManager(cfg *config.Config) (*Manager, error) {
+	if !cfg.UsesAzure() {
+		return nil, fmt.Errorf("azure.vault_url is not set")
+	}
+	isSensitive, err := sensitive.FromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	credential := NewCredential(cfg.Azure.TenantID, cfg.Azure.ClientID)
+	return &Manager{
+		vault:       NewClient(cfg.Azure.VaultURL, credential),
+		config:      cfg,
+		isSensitive: isSensitive,
+	}, nil
+}
+
+// IsSensitive reports whether a key holds a secret. Key Vault encrypts
+// every value, so it only affects how values are displayed.
+func (m *Manager) IsSensitive(key string) bool {
+	return m.isSensitive(key)
+}
+
+// Target describes where an environment is stored
+func Target(cfg *config.Config, envName string) string {
+	return fmt.Sprintf("Azure Key Vault %s (%s)", cfg.GetParameterPath(envName), strings.TrimPrefix(cfg.Azure.VaultURL, "https://"))
+}
+
+// SecretName returns the secret holding key in the environment at path,
+// e.g. myapp-dev--DATABASE-URL for DATABASE_URL under /myapp/dev/
+func SecretName(path, key string) string {
+	name := sanitize(path) + "--" + sanitize(key)
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
+	}
+	return name
+}
+
+func sanitize(s string) string {
+	return strings.Trim(invalidNameChars.ReplaceAllString(s, "-"), "-")
+}
+
+// PullEnvironment returns the variables of an environment
+func (m *Manager) PullEnvironment(ctx context.Context, envName string) (*env.File, error) {
+	vars, err := m.ListEnvironmentVariables(ctx, envName)
+	if err != nil {
+		return nil, err
+	}
+
+	file := env.NewFile()
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		file.Set(key, vars[key])
+	}
+	return file, nil
+}
+
+// ListEnvironmentVariables returns the variables of an environment as a map.
+// Disabled secrets are left out.
+func (m *Manager) ListEnvironmentVariables(ctx context.Context, envName string) (map[string]string, error) {
+	path, err := m.path(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := m.environmentSecrets(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]string, len(secrets))
+	for key, item := range secrets {
+		if !item.Enabled {
+			continue
+		}
+		secret, err := m.vault.GetSecret(ctx, item.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get secret %s: %w", item.Name, err)
+		}
+		vars[key] = secret.Value
+	}
+	return vars, nil
+}
+
+// PushEnvironment stores the variables of file as secrets. Unchanged
+// values are not written, so no new secret versions are created for them.
+// Without overwrite, secrets that already exist keep their values.
+func (m *Manager) PushEnvironment(ctx context.Context, envName string, file *env.File, overwrite bool) error {
+	path, err := m.path(envName)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]string, len(file.Keys()))
+	for _, key := range file.SortedKeys() {
+		name := SecretName(path, key)
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("%s and %s both map to the Key Vault secret %s; rename one of them", other, key, name)
+		}
+		names[name] = key
+	}
+
+	// One listing tells which secrets exist and whether they are envy's
+	items, err := m.vault.ListSecrets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list secrets: %w", err)
+	}
+	existing := make(map[string]SecretItem)
+	for _, item := range items {
+		key, ok := names[item.Name]
+		if !ok {
+			continue
+		}
+		if item.Tags[pathTag] != path || item.Tags[keyTag] != key {
+			return fmt.Errorf("the Key Vault secret %s for %s already exists and is not %s of %s; remove or rename it", item.Name, key, key, path)
+		}
+		existing[item.Name] = item
+	}
+
+	var kept []string
+	for _, key := range file.SortedKeys() {
+		value, _ := file.Get(key)
+		name := SecretName(path, key)
+
+		if item, ok := existing[name]; ok && item.Enabled {
+			current, err := m.vault.GetSecret(ctx, name)
+			if err != nil {
+				return fmt.Errorf("failed to get secret %s: %w", name, err)
+			}
+			if current.Value == value {
+				continue
+			}
+			if !overwrite {
+				kept = append(kept, key)
+				continue
+			}
+		} else if ok && !overwrite {
+			kept = append(kept, key)
+			continue
+		}
+
+		tags := map[string]string{pathTag: path, keyTag: key}
+		if err := m.vault.SetSecret(ctx, name, value, tags); err != nil {
+			return fmt.Errorf("failed to set secret %s for %s: %w", name, key, err)
+		}
+	}
+
+	if len(kept) > 0 {
+		fmt.Printf("Kept the existing values of %s; push with --force to overwrite them\n", strings.Join(kept, ", "))
+	}
+	return nil
+}
+
+// environmentSecrets returns envy's secrets of the environment at path by
+// variable name
+func (m *Manager) environmentSecrets(ctx context.Context, path string) (map[string]SecretItem, error) {
+	items, err := m.vault.ListSecrets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	secrets := make(map[string]SecretItem)
+	for _, item := range items {
+		if item.Tags[pathTag] == path && item.Tags[keyTag] != "" {
+			secrets[item.Tags[keyTag]] = item
+		}
+	}
+	return secrets, nil
+}
+
+func (m *Manager) path(envName string) (string, error) {
+	if _, err := m.config.GetEnvironment(envName); err != nil {
+		return "", err
+	}
+	return m.config.GetParameterPath(envName), nil
+}
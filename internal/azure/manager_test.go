@@ -0,0 +1,124 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVault keeps secrets in memory and counts writes
+type fakeVault struct {
+	secrets map[string]*Secret
+	enabled map[string]bool
+	sets    []string
+}
+
+func newFakeVault() *fakeVault {
+	return &fakeVault{secrets: make(map[string]*Secret), enabled: make(map[string]bool)}
+}
+
+func (v *fakeVault) ListSecrets(ctx context.Context) ([]SecretItem, error) {
+	var items []SecretItem
+	for name, s := range v.secrets {
+		items = append(items, SecretItem{Name: name, Enabled: v.enabled[name], Tags: s.Tags})
+	}
+	return items, nil
+}
+
+func (v *fakeVault) GetSecret(ctx context.Context, name string) (*Secret, error) {
+	s, ok := v.secrets[name]
+	if !ok {
+		return nil, &Error{StatusCode: 404, Code: "SecretNotFound"}
+	}
+	return s, nil
+}
+
+func (v *fakeVault) SetSecret(ctx context.Context, name, value string, tags map[string]string) error {
+	v.secrets[name] = &Secret{Name: name, Value: value, Tags: tags}
+	v.enabled[name] = true
+	v.sets = append(v.sets, name)
+	return nil
+}
+
+func newTestManager(vault vault) *Manager {
+	cfg := &config.Config{
+		Project: "myapp",
+		Azure:   &config.AzureConfig{VaultURL: "https://myvault.vault.azure.net"},
+		Environments: map[string]config.Environment{
+			"dev": {Path: "/myapp/dev/"},
+		},
+	}
+	return &Manager{vault: vault, config: cfg, isSensitive: func(string) bool { return false }}
+}
+
+func envFile(vars map[string]string) *env.File {
+	file := env.NewFile()
+	for key, value := range vars {
+		file.Set(key, value)
+	}
+	return file
+}
+
+func TestSecretName(t *testing.T) {
+	assert.Equal(t, "myapp-dev--DATABASE-URL", SecretName("/myapp/dev/", "DATABASE_URL"))
+	assert.Equal(t, "myapp--A-B", SecretName("/myapp/", "A.B"))
+	assert.Len(t, SecretName("/myapp/dev/", strings.Repeat("X", 200)), maxNameLength)
+}
+
+func TestManager_PushPull(t *testing.T) {
+	ctx := context.Background()
+	vault := newFakeVault()
+	m := newTestManager(vault)
+
+	require.NoError(t, m.PushEnvironment(ctx, "dev", envFile(map[string]string{"A": "1", "B_KEY": "2"}), false))
+	assert.ElementsMatch(t, []string{"myapp-dev--A", "myapp-dev--B-KEY"}, vault.sets)
+	assert.Equal(t, map[string]string{pathTag: "/myapp/dev/", keyTag: "B_KEY"}, vault.secrets["myapp-dev--B-KEY"].Tags)
+
+	file, err := m.PullEnvironment(ctx, "dev")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "1", "B_KEY": "2"}, file.ToMap())
+
+	// Unchanged values are not written; changed ones are kept without overwrite
+	vault.sets = nil
+	require.NoError(t, m.PushEnvironment(ctx, "dev", envFile(map[string]string{"A": "1", "B_KEY": "3"}), false))
+	assert.Empty(t, vault.sets)
+	assert.Equal(t, "2", vault.secrets["myapp-dev--B-KEY"].Value)
+
+	require.NoError(t, m.PushEnvironment(ctx, "dev", envFile(map[string]string{"A": "1", "B_KEY": "3"}), true))
+	assert.Equal(t, []string{"myapp-dev--B-KEY"}, vault.sets)
+	assert.Equal(t, "3", vault.secrets["myapp-dev--B-KEY"].Value)
+
+	// Disabled secrets are not pulled
+	vault.enabled["myapp-dev--A"] = false
+	vars, err := m.ListEnvironmentVariables(ctx, "dev")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"B_KEY": "3"}, vars)
+
+	_, err = m.PullEnvironment(ctx, "prod")
+	assert.Error(t, err)
+}
+
+func TestManager_PushConflicts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("colliding names", func(t *testing.T) {
+		vault := newFakeVault()
+		err := newTestManager(vault).PushEnvironment(ctx, "dev", envFile(map[string]string{"A_B": "1", "A.B": "2"}), true)
+		assert.ErrorContains(t, err, "both map to the Key Vault secret myapp-dev--A-B")
+		assert.Empty(t, vault.sets)
+	})
+
+	t.Run("foreign secret", func(t *testing.T) {
+		vault := newFakeVault()
+		vault.secrets["myapp-dev--A"] = &Secret{Name: "myapp-dev--A", Value: "x"}
+		vault.enabled["myapp-dev--A"] = true
+		err := newTestManager(vault).PushEnvironment(ctx, "dev", envFile(map[string]string{"A": "1"}), true)
+		assert.ErrorContains(t, err, "already exists")
+		assert.Empty(t, vault.sets)
+	})
+}
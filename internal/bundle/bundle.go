@@ -0,0 +1,263 @@
+// Package bundle creates and opens envy bundles: read-only snapshots of an
+// environment for deploys that cannot reach AWS. The variables are encrypted
+// with a shared key, and the bundle is signed so consumers can check who
+// created it before trusting its values.
+package bundle
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/drapon/envy/internal/env"
+)
+
+const (
+	// FormatVersion is the version of the bundle file format
+	FormatVersion = 1
+	// Extension is the conventional extension of bundle files
+	Extension = ".envybundle"
+)
+
+var (
+	// ErrSignature is returned when a bundle's signature does not verify
+	ErrSignature = errors.New("bundle signature does not match")
+	// ErrExpired is returned for bundles older than the accepted maximum age
+	ErrExpired = errors.New("bundle is too old")
+)
+
+// Metadata describes the environment a bundle was created from. It is
+// signed but not encrypted, so a bundle can be inspected without its key.
+type Metadata struct {
+	Environment string    `json:"environment"`
+	Project     string    `json:"project,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	Variables   int       `json:"variables"`
+}
+
+// Bundle is the content of a bundle file
+type Bundle struct {
+	Version  int      `json:"version"`
+	Metadata Metadata `json:"metadata"`
+	// KeyID identifies the public key the bundle is signed for
+	KeyID      string `json:"key_id"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+	Signature  []byte `json:"signature,omitempty"`
+}
+
+// payload is the encrypted part of a bundle
+type payload struct {
+	Variables   map[string]string `json:"variables"`
+	Order       []string          `json:"order,omitempty"`
+	Fingerprint string            `json:"fingerprint"`
+}
+
+// Fingerprint returns a digest of the variables, independent of their order
+func Fingerprint(vars map[string]string) string {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(h, "%d:%s=%d:%s\n", len(key), key, len(vars[key]), vars[key])
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// Create encrypts vars with key and signs the result. order is the order of
+// the variables to restore on extract and may be nil.
+func Create(meta Metadata, vars map[string]string, order []string, key []byte, signer ed25519.PrivateKey) (*Bundle, error) {
+	meta.Variables = len(vars)
+	b := &Bundle{
+		Version:  FormatVersion,
+		Metadata: meta,
+		KeyID:    KeyID(signer.Public().(ed25519.PublicKey)),
+	}
+
+	data, err := json.Marshal(payload{Variables: vars, Order: order, Fingerprint: Fingerprint(vars)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	b.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, b.Nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	aad, err := json.Marshal(b.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle metadata: %w", err)
+	}
+	b.Ciphertext = gcm.Seal(nil, b.Nonce, data, aad)
+
+	signed, err := b.signedBytes()
+	if err != nil {
+		return nil, err
+	}
+	b.Signature = ed25519.Sign(signer, signed)
+	return b, nil
+}
+
+// Verify checks that the bundle was signed with the private key of pub
+func (b *Bundle) Verify(pub ed25519.PublicKey) error {
+	if b.Version != FormatVersion {
+		return fmt.Errorf("unsupported bundle version %d", b.Version)
+	}
+	if b.KeyID != KeyID(pub) {
+		return fmt.Errorf("bundle is signed with key %s, not %s: %w", b.KeyID, KeyID(pub), ErrSignature)
+	}
+	signed, err := b.signedBytes()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, signed, b.Signature) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// Open decrypts the variables of a verified bundle and checks them against
+// their fingerprint. It returns the variables and their order.
+func (b *Bundle) Open(key []byte) (map[string]string, []string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	aad, err := json.Marshal(b.Metadata)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode bundle metadata: %w", err)
+	}
+	data, err := gcm.Open(nil, b.Nonce, b.Ciphertext, aad)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt bundle (wrong key?): %w", err)
+	}
+
+	var p payload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode bundle: %w", err)
+	}
+	if p.Variables == nil {
+		p.Variables = map[string]string{}
+	}
+	if Fingerprint(p.Variables) != p.Fingerprint {
+		return nil, nil, fmt.Errorf("bundle variables do not match their fingerprint")
+	}
+	return p.Variables, p.Order, nil
+}
+
+// OpenFile decrypts the variables of a verified bundle into an env file, in
+// their original order. Variables missing from the order follow, sorted.
+func (b *Bundle) OpenFile(key []byte) (*env.File, error) {
+	vars, order, err := b.Open(key)
+	if err != nil {
+		return nil, err
+	}
+
+	envFile := env.NewFile()
+	for _, key := range order {
+		if value, ok := vars[key]; ok {
+			envFile.Set(key, value)
+		}
+	}
+	var rest []string
+	for key := range vars {
+		if _, ok := envFile.Get(key); !ok {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	for _, key := range rest {
+		envFile.Set(key, vars[key])
+	}
+	return envFile, nil
+}
+
+// CheckAge fails if the bundle is older than maxAge. A zero maxAge accepts
+// bundles of any age.
+func (b *Bundle) CheckAge(maxAge time.Duration, now time.Time) error {
+	if maxAge <= 0 {
+		return nil
+	}
+	if age := b.Age(now); age > maxAge {
+		return fmt.Errorf("bundle of %s was created %s ago, more than the maximum age of %s: %w",
+			b.Metadata.Environment, age.Round(time.Second), maxAge, ErrExpired)
+	}
+	return nil
+}
+
+// Age returns how long ago the bundle was created
+func (b *Bundle) Age(now time.Time) time.Duration {
+	return now.Sub(b.Metadata.CreatedAt)
+}
+
+// signedBytes returns the encoding of the bundle the signature covers:
+// everything but the signature itself
+func (b *Bundle) signedBytes() ([]byte, error) {
+	unsigned := *b
+	unsigned.Signature = nil
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle: %w", err)
+	}
+	return data, nil
+}
+
+// Read reads a bundle file
+func Read(path string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%s is not an envy bundle: %w", path, err)
+	}
+	return &b, nil
+}
+
+// Write writes a bundle file, readable only by the current user
+func (b *Bundle) Write(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode bundle: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// newGCM derives the AES-256-GCM cipher of a bundle key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("bundle key is empty")
+	}
+	sum := sha256.Sum256(key)
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize bundle encryption: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize bundle encryption: %w", err)
+	}
+	return gcm, nil
+}
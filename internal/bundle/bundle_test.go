@@ -0,0 +1,92 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundle(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := GenerateKeys(dir)
+	require.NoError(t, err)
+	require.Len(t, paths, 3)
+
+	key, err := LoadKey(paths[0])
+	require.NoError(t, err)
+	signer, err := LoadSigningKey(paths[1])
+	require.NoError(t, err)
+	pub, err := LoadPublicKey(paths[2])
+	require.NoError(t, err)
+
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	vars := map[string]string{"DB_PASSWORD": "hunter2", "PORT": "8080"}
+	b, err := Create(Metadata{Environment: "prod", CreatedAt: created}, vars, []string{"PORT", "DB_PASSWORD"}, key, signer)
+	require.NoError(t, err)
+	assert.Equal(t, 2, b.Metadata.Variables)
+
+	path := filepath.Join(dir, "prod"+Extension)
+	require.NoError(t, b.Write(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.False(t, strings.Contains(string(data), "hunter2"))
+
+	read, err := Read(path)
+	require.NoError(t, err)
+	require.NoError(t, read.Verify(pub))
+	assert.Equal(t, time.Hour, read.Age(created.Add(time.Hour)))
+
+	got, order, err := read.Open(key)
+	require.NoError(t, err)
+	assert.Equal(t, vars, got)
+	assert.Equal(t, []string{"PORT", "DB_PASSWORD"}, order)
+
+	envFile, err := read.OpenFile(key)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PORT", "DB_PASSWORD"}, envFile.Keys())
+
+	assert.NoError(t, read.CheckAge(0, created.Add(1000*time.Hour)))
+	assert.NoError(t, read.CheckAge(2*time.Hour, created.Add(time.Hour)))
+	assert.ErrorIs(t, read.CheckAge(time.Hour, created.Add(2*time.Hour)), ErrExpired)
+
+	_, _, err = read.Open([]byte("wrong"))
+	assert.Error(t, err)
+
+	// Keys are never overwritten
+	_, err = GenerateKeys(dir)
+	assert.Error(t, err)
+}
+
+func TestBundle_Tampered(t *testing.T) {
+	pub, signer, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	key := []byte("secret")
+
+	b, err := Create(Metadata{Environment: "prod", CreatedAt: time.Now()}, map[string]string{"A": "1"}, nil, key, signer)
+	require.NoError(t, err)
+	require.NoError(t, b.Verify(pub))
+
+	// Backdating the bundle breaks the signature and the decryption
+	b.Metadata.CreatedAt = b.Metadata.CreatedAt.Add(24 * time.Hour)
+	assert.ErrorIs(t, b.Verify(pub), ErrSignature)
+	_, _, err = b.Open(key)
+	assert.Error(t, err)
+
+	other, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	assert.ErrorIs(t, b.Verify(other), ErrSignature)
+}
+
+func TestFingerprint(t *testing.T) {
+	a := Fingerprint(map[string]string{"A": "1", "B": "2"})
+	assert.Equal(t, a, Fingerprint(map[string]string{"B": "2", "A": "1"}))
+	assert.NotEqual(t, a, Fingerprint(map[string]string{"A": "1", "B": "3"}))
+	assert.NotEqual(t, Fingerprint(map[string]string{"A": "1=B"}), Fingerprint(map[string]string{"A": "1", "B": ""}))
+	assert.True(t, strings.HasPrefix(a, "sha256:"))
+}
@@ -0,0 +1,152 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Names of the files written by GenerateKeys
+const (
+	KeyFile        = "bundle.key"
+	SigningKeyFile = "signing.pem"
+	PublicKeyFile  = "signing.pub"
+)
+
+// KeyID returns a short identifier of a public key
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// GenerateKeys writes a new encryption key and signing key pair to dir and
+// returns their paths. Existing files are never overwritten.
+func GenerateKeys(dir string) ([]string, error) {
+	paths := []string{
+		filepath.Join(dir, KeyFile),
+		filepath.Join(dir, SigningKeyFile),
+		filepath.Join(dir, PublicKeyFile),
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return nil, fmt.Errorf("%s already exists", path)
+		}
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("failed to generate bundle key: %w", err)
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signing key: %w", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+	files := []struct {
+		data []byte
+		perm os.FileMode
+	}{
+		{[]byte(hex.EncodeToString(random) + "\n"), 0600},
+		{pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600},
+		{pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644},
+	}
+	for i, file := range files {
+		if err := os.WriteFile(paths[i], file.data, file.perm); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", paths[i], err)
+		}
+	}
+	return paths, nil
+}
+
+// LoadKey reads an encryption key file
+func LoadKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle key: %w", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return nil, fmt.Errorf("bundle key file %s is empty", path)
+	}
+	return []byte(key), nil
+}
+
+// LoadSigningKey reads a PEM-encoded Ed25519 private key
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", path, err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not an Ed25519 key", path)
+	}
+	return priv, nil
+}
+
+// LoadPublicKey reads a PEM-encoded Ed25519 public key
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", path, err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is not an Ed25519 key", path)
+	}
+	return pub, nil
+}
+
+func readPEM(path, blockType string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s does not contain a PEM %s", path, blockType)
+	}
+	return block.Bytes, nil
+}
+
+// KeyEnv is the environment variable holding a bundle key, for consumers
+// that are not given a key file
+const KeyEnv = "ENVY_BUNDLE_KEY"
+
+// ResolveKey reads the key file at path, or the key in KeyEnv when path is
+// empty
+func ResolveKey(path string) ([]byte, error) {
+	if path != "" {
+		return LoadKey(path)
+	}
+	if key := strings.TrimSpace(os.Getenv(KeyEnv)); key != "" {
+		return []byte(key), nil
+	}
+	return nil, fmt.Errorf("a bundle key is required: use --key-file or set %s", KeyEnv)
+}
@@ -87,6 +87,9 @@ type CacheStats struct {
 	Entries     int       `json:"entries"`
 	Size        int64     `json:"size"`
 	LastCleanup time.Time `json:"last_cleanup"`
+
+	// Versioned はバージョン付きストアの使用状況（ディスクを使う場合のみ）
+	Versioned *VersionedUsage `json:"versioned,omitempty"`
 }
 
 // HitRate はヒット率を計算
@@ -150,7 +153,7 @@ func NewCache(config *CacheConfig) (Cache, error) {
 		storage: storage,
 		stats:   &CacheStats{},
 		stopCh:  make(chan struct{}),
-		logger:  log.WithContext(zap.String("component", "cache")),
+		logger:  log.Named("cache").With(zap.String("component", "cache")),
 	}
 
 	// 定期的なクリーンアップを開始
@@ -5,10 +5,10 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
-	"strconv"
 	"strings"
 	"time"
 
+	envyconfig "github.com/drapon/envy/internal/config"
 	"github.com/drapon/envy/internal/errors"
 	"github.com/drapon/envy/internal/log"
 	"github.com/spf13/viper"
@@ -30,7 +30,7 @@ func InitGlobalCache(v *viper.Viper) error {
 	// --no-cache フラグが設定されている場合はキャッシュを無効化
 	if v.GetBool("no_cache") {
 		config.Enabled = false
-		log.Debug("--no-cache フラグによりキャッシュが無効化されました")
+		log.Named("cache").Debug("--no-cache フラグによりキャッシュが無効化されました")
 	}
 
 	manager, err := NewCacheManager(config)
@@ -43,9 +43,9 @@ func InitGlobalCache(v *viper.Viper) error {
 	// --clear-cache フラグが設定されている場合はキャッシュをクリア
 	if v.GetBool("clear_cache") {
 		if err := manager.cache.Clear(); err != nil {
-			log.Warn("キャッシュのクリアに失敗しました", zap.Error(err))
+			log.Named("cache").Warn("キャッシュのクリアに失敗しました", zap.Error(err))
 		} else {
-			log.Debug("キャッシュをクリアしました")
+			log.Named("cache").Debug("キャッシュをクリアしました")
 		}
 	}
 
@@ -107,9 +107,9 @@ func LoadCacheConfigFromViper(v *viper.Viper) (*CacheConfig, error) {
 	// TTLの解析
 	ttlStr := v.GetString("cache.ttl")
 	if ttlStr != "" {
-		ttl, err := time.ParseDuration(ttlStr)
+		ttl, err := envyconfig.ParseDuration(ttlStr)
 		if err != nil {
-			return nil, fmt.Errorf("無効なTTL形式: %s", ttlStr)
+			return nil, fmt.Errorf("無効なTTL形式: %w", err)
 		}
 		config.TTL = ttl
 	}
@@ -117,9 +117,9 @@ func LoadCacheConfigFromViper(v *viper.Viper) (*CacheConfig, error) {
 	// 最大サイズの解析
 	maxSizeStr := v.GetString("cache.max_size")
 	if maxSizeStr != "" {
-		maxSize, err := parseSizeString(maxSizeStr)
+		maxSize, err := envyconfig.ParseSize(maxSizeStr)
 		if err != nil {
-			return nil, fmt.Errorf("無効な最大サイズ形式: %s", maxSizeStr)
+			return nil, fmt.Errorf("無効な最大サイズ形式: %w", err)
 		}
 		config.MaxSize = maxSize
 	}
@@ -158,49 +158,6 @@ func LoadCacheConfigFromViper(v *viper.Viper) (*CacheConfig, error) {
 	return config, nil
 }
 
-// parseSizeString はサイズ文字列（例: "100MB", "1GB"）をバイト数に変換
-func parseSizeString(sizeStr string) (int64, error) {
-	sizeStr = strings.ToUpper(strings.TrimSpace(sizeStr))
-
-	// 数値部分と単位部分を分離
-	var numStr string
-	var unit string
-
-	for i, r := range sizeStr {
-		if (r >= '0' && r <= '9') || r == '.' {
-			numStr += string(r)
-		} else {
-			unit = sizeStr[i:]
-			break
-		}
-	}
-
-	if numStr == "" {
-		return 0, fmt.Errorf("無効なサイズ形式: %s", sizeStr)
-	}
-
-	size, err := strconv.ParseFloat(numStr, 64)
-	if err != nil {
-		return 0, fmt.Errorf("サイズの解析エラー: %w", err)
-	}
-
-	// 単位の変換
-	switch unit {
-	case "", "B":
-		return int64(size), nil
-	case "KB":
-		return int64(size * 1024), nil
-	case "MB":
-		return int64(size * 1024 * 1024), nil
-	case "GB":
-		return int64(size * 1024 * 1024 * 1024), nil
-	case "TB":
-		return int64(size * 1024 * 1024 * 1024 * 1024), nil
-	default:
-		return 0, fmt.Errorf("未対応の単位: %s", unit)
-	}
-}
-
 // CacheKeyBuilder はキャッシュキーを構築するヘルパー
 type CacheKeyBuilder struct {
 	prefix string
@@ -232,6 +189,20 @@ func (ckb *CacheKeyBuilder) Build() string {
 	return GenerateKey(ckb.prefix, ckb.parts...)
 }
 
+// IsEnabled はグローバルキャッシュが有効かどうかを返す
+func IsEnabled() bool {
+	return globalManager != nil && globalManager.config.Enabled
+}
+
+// Versioned はグローバルキャッシュのバージョン付きストアを返す。キャッシュが
+// 無効、またはメモリのみの場合は nil
+func Versioned() *VersionedStore {
+	if globalManager == nil || !globalManager.config.Enabled {
+		return nil
+	}
+	return globalManager.versioned
+}
+
 // CachedOperation はキャッシュ付きの操作を実行
 func CachedOperation(key string, ttl time.Duration, operation func() (interface{}, error)) (interface{}, error) {
 	if globalManager == nil || !globalManager.config.Enabled {
@@ -260,7 +231,7 @@ func CachedOperationWithMetadata(key string, ttl time.Duration, metadata map[str
 
 	// メタデータ付きでキャッシュに保存
 	if setErr := globalManager.cache.SetWithMetadata(key, value, ttl, metadata); setErr != nil {
-		log.Warn("キャッシュへの保存に失敗",
+		log.Named("cache").Warn("キャッシュへの保存に失敗",
 			zap.String("key", log.MaskSensitive(key)),
 			zap.Error(setErr))
 	}
@@ -303,6 +274,14 @@ func FormatCacheStats(stats *CacheStats) string {
 	sb.WriteString(fmt.Sprintf("  Entries: %d\n", stats.Entries))
 	sb.WriteString(fmt.Sprintf("  Size: %s\n", formatSize(stats.Size)))
 
+	if v := stats.Versioned; v != nil {
+		sb.WriteString("  Remote Values:\n")
+		sb.WriteString(fmt.Sprintf("    Environments: %d\n", v.Indexes))
+		sb.WriteString(fmt.Sprintf("    Parameters: %d\n", v.References))
+		sb.WriteString(fmt.Sprintf("    Unique Values: %d\n", v.Objects))
+		sb.WriteString(fmt.Sprintf("    Size: %s\n", formatSize(v.Size)))
+	}
+
 	if !stats.LastCleanup.IsZero() {
 		sb.WriteString(fmt.Sprintf("  Last Cleanup: %s\n", stats.LastCleanup.Format("2006-01-02 15:04:05")))
 	}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/drapon/envy/internal/errors"
+)
+
+// RefreshInBackground は環境の値を更新する `envy cache refresh` を別の
+// プロセスで開始する。古い値を返したコマンドは AWS を待たずに終了できる。
+// 同じ環境の更新がすでに実行中の場合は何もしない。
+func RefreshInBackground(store *VersionedStore, envName, path, configFile string) error {
+	if !store.TryRefresh(envName, path) {
+		return nil
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		store.DoneRefresh(envName, path)
+		return errors.New(errors.ErrInternal, "実行ファイルのパスの取得に失敗").WithCause(err)
+	}
+
+	args := []string{"cache", "refresh", "--env", envName, "--quiet", "--no-update-check"}
+	if configFile != "" {
+		args = append(args, "--config", configFile)
+	}
+
+	// 標準入出力は /dev/null に接続される。ロックは子プロセスが解放する。
+	cmd := exec.Command(executable, args...)
+	if err := cmd.Start(); err != nil {
+		store.DoneRefresh(envName, path)
+		return errors.New(errors.ErrInternal, "バックグラウンド更新の開始に失敗").
+			WithCause(err).
+			WithDetails("environment", envName)
+	}
+
+	// 親プロセスが長く動く場合（envy run）に終了した子プロセスを回収する
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
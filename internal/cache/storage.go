@@ -1,13 +1,9 @@
 package cache
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,6 +11,7 @@ import (
 	"time"
 
 	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/envelope"
 	"github.com/drapon/envy/internal/errors"
 	"github.com/drapon/envy/internal/log"
 	"go.uber.org/zap"
@@ -46,7 +43,6 @@ type Storage interface {
 type FileStorage struct {
 	baseDir       string
 	encryptionKey []byte
-	gcm           cipher.AEAD
 	mu            sync.RWMutex
 	logger        *zap.Logger
 }
@@ -55,24 +51,12 @@ type FileStorage struct {
 func NewFileStorage(baseDir string, encryptionKey string) (Storage, error) {
 	storage := &FileStorage{
 		baseDir: baseDir,
-		logger:  log.WithContext(zap.String("component", "file_storage")),
+		logger:  log.Named("cache").With(zap.String("component", "file_storage")),
 	}
 
 	// 暗号化キーが設定されている場合、AES-GCMを初期化
 	if encryptionKey != "" {
-		keyBytes := sha256.Sum256([]byte(encryptionKey))
-		storage.encryptionKey = keyBytes[:]
-
-		block, err := aes.NewCipher(storage.encryptionKey)
-		if err != nil {
-			return nil, errors.New(errors.ErrInternal, "暗号化の初期化に失敗").WithCause(err)
-		}
-
-		gcm, err := cipher.NewGCM(block)
-		if err != nil {
-			return nil, errors.New(errors.ErrInternal, "GCM暗号化の初期化に失敗").WithCause(err)
-		}
-		storage.gcm = gcm
+		storage.encryptionKey = deriveKey(encryptionKey)
 	}
 
 	// ベースディレクトリの作成
@@ -347,7 +331,7 @@ func (fs *FileStorage) serializeEntry(entry *CacheEntry) ([]byte, error) {
 	}
 
 	// 暗号化が必要かつ可能な場合
-	if entry.Encrypted && fs.gcm != nil {
+	if entry.Encrypted && fs.encryptionKey != nil {
 		return fs.encrypt(jsonData)
 	}
 
@@ -359,7 +343,7 @@ func (fs *FileStorage) deserializeEntry(data []byte, entry *CacheEntry) error {
 	var jsonData []byte
 
 	// 暗号化データの可能性がある場合、復号化を試行
-	if fs.gcm != nil {
+	if fs.encryptionKey != nil {
 		if decrypted, decryptErr := fs.decrypt(data); decryptErr == nil {
 			jsonData = decrypted
 		} else {
@@ -423,56 +407,33 @@ func (fs *FileStorage) deserializeEntry(data []byte, entry *CacheEntry) error {
 
 // encrypt はデータを暗号化
 func (fs *FileStorage) encrypt(data []byte) ([]byte, error) {
-	if fs.gcm == nil {
+	if fs.encryptionKey == nil {
 		return data, nil
 	}
-
-	// ランダムなnonceを生成
-	nonce := make([]byte, fs.gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return nil, fmt.Errorf("nonceの生成エラー: %w", err)
-	}
-
-	// 暗号化
-	ciphertext := fs.gcm.Seal(nil, nonce, data, nil)
-
-	// nonceと暗号化データを結合
-	result := make([]byte, len(nonce)+len(ciphertext))
-	copy(result, nonce)
-	copy(result[len(nonce):], ciphertext)
-
-	return result, nil
+	return envelope.Seal(fs.encryptionKey, data)
 }
 
 // decrypt はデータを復号化
 func (fs *FileStorage) decrypt(data []byte) ([]byte, error) {
-	if fs.gcm == nil {
+	if fs.encryptionKey == nil {
 		return data, nil
 	}
+	return envelope.Open(fs.encryptionKey, data)
+}
 
-	nonceSize := fs.gcm.NonceSize()
-	if len(data) < nonceSize {
-		return nil, fmt.Errorf("データが短すぎます")
-	}
-
-	// nonceと暗号化データを分離
-	nonce := data[:nonceSize]
-	ciphertext := data[nonceSize:]
-
-	// 復号化
-	plaintext, err := fs.gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return nil, fmt.Errorf("復号化に失敗: %w", err)
-	}
-
-	return plaintext, nil
+// deriveKey は暗号化キーから AES-256 のキーを導出する。暗号化は envy
+// encrypt やインライン暗号化と同じ envelope パッケージで行う
+func deriveKey(encryptionKey string) []byte {
+	key := sha256.Sum256([]byte(encryptionKey))
+	return key[:]
 }
 
 // CacheManager はキャッシュを管理するヘルパー
 type CacheManager struct {
-	cache  Cache
-	config *CacheConfig
-	logger *zap.Logger
+	cache     Cache
+	versioned *VersionedStore
+	config    *CacheConfig
+	logger    *zap.Logger
 }
 
 // NewCacheManager は新しいキャッシュマネージャーを作成
@@ -482,11 +443,26 @@ func NewCacheManager(config *CacheConfig) (*CacheManager, error) {
 		return nil, err
 	}
 
-	return &CacheManager{
+	manager := &CacheManager{
 		cache:  cache,
 		config: config,
-		logger: log.WithContext(zap.String("component", "cache_manager")),
-	}, nil
+		logger: log.Named("cache").With(zap.String("component", "cache_manager")),
+	}
+
+	// ディスクを使う場合はリモートの値をバージョン付きストアにも保存する
+	if config.Enabled && (config.Type == DiskCache || config.Type == HybridCache) {
+		versioned, err := NewVersionedStore(VersionedDir(config.CacheDir), config.EncryptionKey)
+		if err != nil {
+			manager.logger.Warn("バージョン付きキャッシュの初期化に失敗", zap.Error(err))
+		} else {
+			if _, err := versioned.Prune(VersionedIndexMaxAge); err != nil {
+				manager.logger.Warn("バージョン付きキャッシュの整理に失敗", zap.Error(err))
+			}
+			manager.versioned = versioned
+		}
+	}
+
+	return manager, nil
 }
 
 // GetOrSet はキャッシュから値を取得、存在しない場合は生成関数を実行
@@ -538,13 +514,28 @@ func (cm *CacheManager) Get(key string) (interface{}, bool) {
 	return value, found
 }
 
+// Versioned はバージョン付きストアを返す。メモリのみのキャッシュでは nil
+func (cm *CacheManager) Versioned() *VersionedStore {
+	return cm.versioned
+}
+
 // Stats はキャッシュの統計情報を返す
 func (cm *CacheManager) Stats() *CacheStats {
-	return cm.cache.Stats()
+	stats := cm.cache.Stats()
+	if cm.versioned != nil {
+		usage := cm.versioned.Usage()
+		stats.Versioned = &usage
+	}
+	return stats
 }
 
 // Clear はキャッシュをクリアする
 func (cm *CacheManager) Clear() error {
+	if cm.versioned != nil {
+		if err := cm.versioned.Clear(); err != nil {
+			return err
+		}
+	}
 	return cm.cache.Clear()
 }
 
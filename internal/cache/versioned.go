@@ -0,0 +1,474 @@
+package cache
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/drapon/envy/internal/envelope"
+	"github.com/drapon/envy/internal/errors"
+	"github.com/drapon/envy/internal/log"
+	"go.uber.org/zap"
+)
+
+// VersionedIndexMaxAge は使われていないインデックスを保持する期間
+const VersionedIndexMaxAge = 30 * 24 * time.Hour
+
+// refreshLockTTL はバックグラウンド更新のロックが有効な期間
+const refreshLockTTL = 2 * time.Minute
+
+// VersionedStore はリモートの値を（環境、パス、パラメータのバージョン）で
+// キャッシュするストア。値そのものは内容のハッシュをキーとして一度だけ保存
+// されるため、環境間で同じ値は重複しない。
+//
+// バージョンが一致するエントリだけが使われるので、一部のキーだけを push
+// した後も変更されたパラメータだけが再取得される。
+//
+// ディレクトリ構成:
+//
+//	<dir>/index/<環境とパスのハッシュ>.idx   パラメータ名 → バージョンと値のハッシュ
+//	<dir>/objects/<2文字>/<値のハッシュ>.obj 値（暗号化キーがあれば暗号化）
+type VersionedStore struct {
+	dir    string
+	key    []byte // 値の暗号化とハッシュの HMAC のキー
+	mu     sync.Mutex
+	logger *zap.Logger
+}
+
+// versionedIndex は環境とパスごとのインデックスファイルの内容
+type versionedIndex struct {
+	Environment string                   `json:"environment"`
+	Path        string                   `json:"path"`
+	VerifiedAt  time.Time                `json:"verified_at"` // バージョンを最後にリモートと照合した時刻
+	Entries     map[string]versionedItem `json:"entries"`
+}
+
+// versionedItem はパラメータ1件のバージョンと値のハッシュ
+type versionedItem struct {
+	Version string `json:"version"`
+	Digest  string `json:"digest"`
+}
+
+// VersionedUsage はバージョン付きキャッシュの使用状況
+type VersionedUsage struct {
+	Indexes    int   `json:"indexes"`    // キャッシュされた環境とパスの組
+	References int   `json:"references"` // 値への参照の数
+	Objects    int   `json:"objects"`    // 保存された値の数（重複排除後）
+	Size       int64 `json:"size"`       // 値のファイルの合計バイト数
+}
+
+// VersionedDir はキャッシュディレクトリ内のバージョン付きストアの場所を返す。
+// ストア内のパスはすべてこのディレクトリからの相対パスなので、キャッシュ
+// ディレクトリは別のマシンやコンテナに移しても使える。
+func VersionedDir(cacheDir string) string {
+	return filepath.Join(cacheDir, "versioned")
+}
+
+// NewVersionedStore は dir に新しいバージョン付きストアを作成する。
+// encryptionKey が空でない場合、値は AES-GCM で暗号化され、値のハッシュは
+// そのキーによる HMAC になる。
+func NewVersionedStore(dir, encryptionKey string) (*VersionedStore, error) {
+	store := &VersionedStore{
+		dir:    dir,
+		logger: log.Named("cache").With(zap.String("component", "versioned_store")),
+	}
+
+	if encryptionKey != "" {
+		store.key = deriveKey(encryptionKey)
+	}
+
+	for _, sub := range []string{"index", "objects"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0700); err != nil {
+			return nil, errors.New(errors.ErrFileWrite, "キャッシュディレクトリの作成に失敗").
+				WithCause(err).
+				WithDetails("dir", dir)
+		}
+	}
+
+	return store, nil
+}
+
+// Lookup は versions（パラメータ名 → バージョン）のうち、キャッシュされた
+// バージョンが一致するものの値と、取得し直す必要があるパラメータ名を返す
+func (s *VersionedStore) Lookup(envName, path string, versions map[string]string) (map[string]string, []string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, err := s.readIndex(envName, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := make(map[string]string, len(versions))
+	var missing []string
+	for name, version := range versions {
+		item, ok := index.Entries[name]
+		if !ok || item.Version != version {
+			missing = append(missing, name)
+			continue
+		}
+		value, ok := s.readObject(item.Digest)
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		values[name] = value
+	}
+	sort.Strings(missing)
+
+	// 使われたインデックスは Prune で期限切れにしない
+	now := time.Now()
+	_ = os.Chtimes(s.indexPath(envName, path), now, now)
+
+	return values, missing, nil
+}
+
+// Update は取得した値（fetched）をそのバージョンで記録し、versions と
+// 照合した時刻を更新する。versions にないパラメータは削除されたものとして
+// インデックスから外す。
+func (s *VersionedStore) Update(envName, path string, versions, fetched map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, err := s.readIndex(envName, path)
+	if err != nil {
+		return err
+	}
+
+	index := &versionedIndex{
+		Environment: envName,
+		Path:        path,
+		VerifiedAt:  time.Now(),
+		Entries:     make(map[string]versionedItem, len(versions)),
+	}
+	for name, version := range versions {
+		if value, ok := fetched[name]; ok {
+			digest, err := s.writeObject(value)
+			if err != nil {
+				return err
+			}
+			index.Entries[name] = versionedItem{Version: version, Digest: digest}
+		} else if item, ok := old.Entries[name]; ok && item.Version == version {
+			index.Entries[name] = item
+		}
+	}
+
+	if err := s.writeIndex(index); err != nil {
+		return err
+	}
+
+	// 参照されなくなった値があれば削除
+	for name, item := range old.Entries {
+		if index.Entries[name].Digest != item.Digest {
+			_, err := s.prune(0)
+			return err
+		}
+	}
+	return nil
+}
+
+// Snapshot はリモートと照合せずに、キャッシュされたすべての値と最後に
+// 照合した時刻を返す。一度も照合されていない、または読めない値がある場合は
+// false を返す。
+func (s *VersionedStore) Snapshot(envName, path string) (map[string]string, time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, err := s.readIndex(envName, path)
+	if err != nil || index.VerifiedAt.IsZero() {
+		return nil, time.Time{}, false
+	}
+
+	values := make(map[string]string, len(index.Entries))
+	for name, item := range index.Entries {
+		value, ok := s.readObject(item.Digest)
+		if !ok {
+			return nil, time.Time{}, false
+		}
+		values[name] = value
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(s.indexPath(envName, path), now, now)
+
+	return values, index.VerifiedAt, true
+}
+
+// TryRefresh は環境とパスの更新を始める権利を取得する。ほかのプロセスが
+// 更新中の場合は false を返す。更新が終わったら DoneRefresh を呼ぶ。
+// refreshLockTTL より古いロックは中断された更新のものとして無視する。
+func (s *VersionedStore) TryRefresh(envName, path string) bool {
+	lockPath := s.refreshLockPath(envName, path)
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0700); err != nil {
+		return false
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return true
+		}
+		info, statErr := os.Stat(lockPath)
+		if statErr != nil || time.Since(info.ModTime()) < refreshLockTTL {
+			return false
+		}
+		os.Remove(lockPath)
+	}
+	return false
+}
+
+// DoneRefresh は TryRefresh で取得した権利を手放す
+func (s *VersionedStore) DoneRefresh(envName, path string) {
+	os.Remove(s.refreshLockPath(envName, path))
+}
+
+// Prune は maxAge より長く使われていないインデックスと、それによって
+// 参照されなくなった値を削除し、削除した値の数を返す。maxAge が0の場合
+// インデックスは削除せず、参照されていない値だけを削除する。
+func (s *VersionedStore) Prune(maxAge time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.prune(maxAge)
+}
+
+func (s *VersionedStore) prune(maxAge time.Duration) (int, error) {
+	referenced := make(map[string]bool)
+	expired := 0
+	err := s.walkIndexes(func(path string, info os.FileInfo, index *versionedIndex) {
+		if maxAge > 0 && time.Since(info.ModTime()) > maxAge {
+			os.Remove(path)
+			expired++
+			return
+		}
+		for _, item := range index.Entries {
+			referenced[item.Digest] = true
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// 期限切れのインデックスがなければ値の参照は変わっていない
+	if maxAge > 0 && expired == 0 {
+		return 0, nil
+	}
+
+	removed := 0
+	err = s.walkObjects(func(path, digest string, info os.FileInfo) {
+		if !referenced[digest] {
+			if os.Remove(path) == nil {
+				removed++
+			}
+		}
+	})
+	if removed > 0 {
+		s.logger.Debug("参照されていない値を削除しました", zap.Int("removed_count", removed))
+	}
+	return removed, err
+}
+
+// Usage はバージョン付きキャッシュの使用状況を返す
+func (s *VersionedStore) Usage() VersionedUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var usage VersionedUsage
+	_ = s.walkIndexes(func(path string, info os.FileInfo, index *versionedIndex) {
+		usage.Indexes++
+		usage.References += len(index.Entries)
+	})
+	_ = s.walkObjects(func(path, digest string, info os.FileInfo) {
+		usage.Objects++
+		usage.Size += info.Size()
+	})
+	return usage
+}
+
+// Clear はすべてのインデックスと値を削除する
+func (s *VersionedStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range []string{"index", "objects"} {
+		dir := filepath.Join(s.dir, sub)
+		if err := os.RemoveAll(dir); err != nil {
+			return errors.New(errors.ErrFileWrite, "キャッシュディレクトリのクリアに失敗").
+				WithCause(err).
+				WithDetails("dir", dir)
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return errors.New(errors.ErrFileWrite, "キャッシュディレクトリの作成に失敗").
+				WithCause(err).
+				WithDetails("dir", dir)
+		}
+	}
+	return nil
+}
+
+// digest は値の内容のハッシュを返す。暗号化キーがある場合は HMAC とし、
+// ファイル名から値を推測できないようにする。
+func (s *VersionedStore) digest(value string) string {
+	if s.key != nil {
+		mac := hmac.New(sha256.New, s.key)
+		mac.Write([]byte(value))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// scope は環境とパスの組をファイル名に使えるハッシュにする
+func scope(envName, path string) string {
+	sum := sha256.Sum256([]byte(envName + "\x00" + path))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *VersionedStore) indexPath(envName, path string) string {
+	return filepath.Join(s.dir, "index", scope(envName, path)+".idx")
+}
+
+func (s *VersionedStore) refreshLockPath(envName, path string) string {
+	return filepath.Join(s.dir, "refresh", scope(envName, path)+".lock")
+}
+
+func (s *VersionedStore) objectPath(digest string) string {
+	return filepath.Join(s.dir, "objects", digest[:2], digest+".obj")
+}
+
+// readIndex はインデックスを読み込む。存在しない・壊れている場合は空の
+// インデックスを返す。
+func (s *VersionedStore) readIndex(envName, path string) (*versionedIndex, error) {
+	index := &versionedIndex{Environment: envName, Path: path, Entries: map[string]versionedItem{}}
+
+	data, err := os.ReadFile(s.indexPath(envName, path))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, errors.New(errors.ErrFileRead, "キャッシュインデックスの読み込みに失敗").WithCause(err)
+	}
+
+	var stored versionedIndex
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Environment != envName || stored.Path != path {
+		s.logger.Warn("壊れたキャッシュインデックスを無視します", zap.String("environment", envName))
+		return index, nil
+	}
+	index.VerifiedAt = stored.VerifiedAt
+	if stored.Entries != nil {
+		index.Entries = stored.Entries
+	}
+	return index, nil
+}
+
+func (s *VersionedStore) writeIndex(index *versionedIndex) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return errors.New(errors.ErrInternal, "キャッシュインデックスのシリアライズに失敗").WithCause(err)
+	}
+	return writeFileAtomic(s.indexPath(index.Environment, index.Path), data)
+}
+
+// readObject は値を読み込む。読めない・復号できない値はキャッシュミスとする
+func (s *VersionedStore) readObject(digest string) (string, bool) {
+	if len(digest) < 3 {
+		return "", false
+	}
+	data, err := os.ReadFile(s.objectPath(digest))
+	if err != nil {
+		return "", false
+	}
+	if s.key != nil {
+		if data, err = envelope.Open(s.key, data); err != nil {
+			return "", false
+		}
+	}
+	value := string(data)
+	if s.digest(value) != digest {
+		return "", false
+	}
+	return value, true
+}
+
+// writeObject は値を保存してそのハッシュを返す。同じ値がすでにあれば
+// 書き込まない。
+func (s *VersionedStore) writeObject(value string) (string, error) {
+	digest := s.digest(value)
+	path := s.objectPath(digest)
+	if _, err := os.Stat(path); err == nil {
+		return digest, nil
+	}
+
+	data := []byte(value)
+	if s.key != nil {
+		var err error
+		if data, err = envelope.Seal(s.key, data); err != nil {
+			return "", errors.New(errors.ErrInternal, "値の暗号化に失敗").WithCause(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", errors.New(errors.ErrFileWrite, "キャッシュディレクトリの作成に失敗").WithCause(err)
+	}
+	return digest, writeFileAtomic(path, data)
+}
+
+func (s *VersionedStore) walkIndexes(fn func(path string, info os.FileInfo, index *versionedIndex)) error {
+	return filepath.Walk(filepath.Join(s.dir, "index"), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".idx") {
+			return nil
+		}
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return nil
+		}
+		var index versionedIndex
+		if json.Unmarshal(data, &index) != nil {
+			os.Remove(path)
+			return nil
+		}
+		fn(path, info, &index)
+		return nil
+	})
+}
+
+func (s *VersionedStore) walkObjects(fn func(path, digest string, info os.FileInfo)) error {
+	return filepath.Walk(filepath.Join(s.dir, "objects"), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".obj") {
+			return nil
+		}
+		fn(path, strings.TrimSuffix(filepath.Base(path), ".obj"), info)
+		return nil
+	})
+}
+
+// writeFileAtomic は一時ファイルに書き込んでからリネームする
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return errors.New(errors.ErrFileWrite, "キャッシュファイルの書き込みに失敗").WithCause(err)
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return errors.New(errors.ErrFileWrite, "キャッシュファイルの書き込みに失敗").WithCause(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return errors.New(errors.ErrFileWrite, "キャッシュファイルの書き込みに失敗").WithCause(err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return errors.New(errors.ErrFileWrite, "キャッシュファイルのリネームに失敗").
+			WithCause(err).
+			WithDetails("final_path", path)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionedStore(t *testing.T) {
+	t.Run("環境間で同じ値は一度だけ保存される", func(t *testing.T) {
+		store, err := NewVersionedStore(t.TempDir(), "")
+		require.NoError(t, err)
+
+		versions := map[string]string{"/app/dev/REGION": "1", "/app/dev/API_KEY": "3"}
+		require.NoError(t, store.Update("dev", "/app/dev/", versions, map[string]string{
+			"/app/dev/REGION": "us-east-1", "/app/dev/API_KEY": "dev-key",
+		}))
+		require.NoError(t, store.Update("prod", "/app/prod/", map[string]string{"/app/prod/REGION": "7"}, map[string]string{
+			"/app/prod/REGION": "us-east-1",
+		}))
+
+		usage := store.Usage()
+		assert.Equal(t, 2, usage.Indexes)
+		assert.Equal(t, 3, usage.References)
+		assert.Equal(t, 2, usage.Objects)
+	})
+
+	t.Run("ディレクトリを移動しても使える", func(t *testing.T) {
+		cacheDir := filepath.Join(t.TempDir(), "warm")
+		store, err := NewVersionedStore(VersionedDir(cacheDir), "ci-key")
+		require.NoError(t, err)
+		versions := map[string]string{"A": "1"}
+		require.NoError(t, store.Update("dev", "/app/dev/", versions, map[string]string{"A": "a1"}))
+
+		moved := filepath.Join(t.TempDir(), "restored")
+		require.NoError(t, os.Rename(cacheDir, moved))
+
+		restored, err := NewVersionedStore(VersionedDir(moved), "ci-key")
+		require.NoError(t, err)
+		values, missing, err := restored.Lookup("dev", "/app/dev/", versions)
+		require.NoError(t, err)
+		assert.Empty(t, missing)
+		assert.Equal(t, map[string]string{"A": "a1"}, values)
+
+		// 別のキーでは読めない
+		other, err := NewVersionedStore(VersionedDir(moved), "other-key")
+		require.NoError(t, err)
+		_, missing, err = other.Lookup("dev", "/app/dev/", versions)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"A"}, missing)
+	})
+
+	t.Run("バージョンが変わったパラメータだけを取得し直す", func(t *testing.T) {
+		store, err := NewVersionedStore(t.TempDir(), "")
+		require.NoError(t, err)
+
+		versions := map[string]string{"A": "1", "B": "1", "C": "1"}
+		require.NoError(t, store.Update("dev", "/app/dev/", versions, map[string]string{"A": "a1", "B": "b1", "C": "c1"}))
+
+		// B だけが push された
+		values, missing, err := store.Lookup("dev", "/app/dev/", map[string]string{"A": "1", "B": "2", "C": "1"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"B"}, missing)
+		assert.Equal(t, map[string]string{"A": "a1", "C": "c1"}, values)
+
+		// 取得した B を記録し、削除された C を外す
+		require.NoError(t, store.Update("dev", "/app/dev/", map[string]string{"A": "1", "B": "2"}, map[string]string{"B": "b2"}))
+		values, missing, err = store.Lookup("dev", "/app/dev/", map[string]string{"A": "1", "B": "2"})
+		require.NoError(t, err)
+		assert.Empty(t, missing)
+		assert.Equal(t, map[string]string{"A": "a1", "B": "b2"}, values)
+
+		// 参照されなくなった b1 と c1 は削除されている
+		assert.Equal(t, 2, store.Usage().Objects)
+	})
+
+	t.Run("別の環境とパスのキャッシュは使わない", func(t *testing.T) {
+		store, err := NewVersionedStore(t.TempDir(), "")
+		require.NoError(t, err)
+
+		require.NoError(t, store.Update("dev", "/app/dev/", map[string]string{"A": "1"}, map[string]string{"A": "a"}))
+		_, missing, err := store.Lookup("dev", "/other/dev/", map[string]string{"A": "1"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"A"}, missing)
+	})
+
+	t.Run("暗号化キーがあれば値を暗号化する", func(t *testing.T) {
+		dir := t.TempDir()
+		store, err := NewVersionedStore(dir, "test-key")
+		require.NoError(t, err)
+
+		require.NoError(t, store.Update("dev", "/app/dev/", map[string]string{"A": "1"}, map[string]string{"A": "plain-secret-value"}))
+		err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			require.NoError(t, err)
+			if !info.IsDir() {
+				data, readErr := os.ReadFile(path)
+				require.NoError(t, readErr)
+				assert.NotContains(t, string(data), "plain-secret-value", path)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+
+		values, missing, err := store.Lookup("dev", "/app/dev/", map[string]string{"A": "1"})
+		require.NoError(t, err)
+		assert.Empty(t, missing)
+		assert.Equal(t, "plain-secret-value", values["A"])
+
+		// 別のキーでは復号できずキャッシュミスになる
+		other, err := NewVersionedStore(dir, "other-key")
+		require.NoError(t, err)
+		_, missing, err = other.Lookup("dev", "/app/dev/", map[string]string{"A": "1"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"A"}, missing)
+	})
+
+	t.Run("壊れた値はキャッシュミスになる", func(t *testing.T) {
+		dir := t.TempDir()
+		store, err := NewVersionedStore(dir, "")
+		require.NoError(t, err)
+
+		require.NoError(t, store.Update("dev", "/app/dev/", map[string]string{"A": "1"}, map[string]string{"A": "a"}))
+		err = filepath.Walk(filepath.Join(dir, "objects"), func(path string, info os.FileInfo, err error) error {
+			if err == nil && strings.HasSuffix(path, ".obj") {
+				return os.WriteFile(path, []byte("tampered"), 0600)
+			}
+			return err
+		})
+		require.NoError(t, err)
+
+		_, missing, err := store.Lookup("dev", "/app/dev/", map[string]string{"A": "1"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"A"}, missing)
+	})
+
+	t.Run("Prune は古いインデックスと参照されない値を削除する", func(t *testing.T) {
+		dir := t.TempDir()
+		store, err := NewVersionedStore(dir, "")
+		require.NoError(t, err)
+
+		require.NoError(t, store.Update("dev", "/app/dev/", map[string]string{"A": "1"}, map[string]string{"A": "shared"}))
+		require.NoError(t, store.Update("old", "/app/old/", map[string]string{"A": "1", "B": "1"}, map[string]string{"A": "shared", "B": "only-old"}))
+
+		old := time.Now().Add(-48 * time.Hour)
+		require.NoError(t, os.Chtimes(store.indexPath("old", "/app/old/"), old, old))
+
+		removed, err := store.Prune(24 * time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 1, removed)
+
+		usage := store.Usage()
+		assert.Equal(t, 1, usage.Indexes)
+		assert.Equal(t, 1, usage.Objects)
+	})
+
+	t.Run("Snapshot は照合せずに値と照合時刻を返す", func(t *testing.T) {
+		store, err := NewVersionedStore(t.TempDir(), "")
+		require.NoError(t, err)
+
+		_, _, ok := store.Snapshot("dev", "/app/dev/")
+		assert.False(t, ok)
+
+		before := time.Now()
+		require.NoError(t, store.Update("dev", "/app/dev/", map[string]string{"A": "1"}, map[string]string{"A": "a"}))
+		values, verifiedAt, ok := store.Snapshot("dev", "/app/dev/")
+		require.True(t, ok)
+		assert.Equal(t, map[string]string{"A": "a"}, values)
+		assert.False(t, verifiedAt.Before(before))
+
+		// 何も取得しなくても照合時刻は更新される
+		time.Sleep(10 * time.Millisecond)
+		require.NoError(t, store.Update("dev", "/app/dev/", map[string]string{"A": "1"}, nil))
+		_, reverifiedAt, ok := store.Snapshot("dev", "/app/dev/")
+		require.True(t, ok)
+		assert.True(t, reverifiedAt.After(verifiedAt))
+	})
+
+	t.Run("更新は同時に一つだけ", func(t *testing.T) {
+		store, err := NewVersionedStore(t.TempDir(), "")
+		require.NoError(t, err)
+
+		assert.True(t, store.TryRefresh("dev", "/app/dev/"))
+		assert.False(t, store.TryRefresh("dev", "/app/dev/"))
+		assert.True(t, store.TryRefresh("prod", "/app/prod/"))
+
+		store.DoneRefresh("dev", "/app/dev/")
+		assert.True(t, store.TryRefresh("dev", "/app/dev/"))
+
+		// 中断された更新のロックは期限が切れる
+		old := time.Now().Add(-2 * refreshLockTTL)
+		require.NoError(t, os.Chtimes(store.refreshLockPath("dev", "/app/dev/"), old, old))
+		assert.True(t, store.TryRefresh("dev", "/app/dev/"))
+	})
+
+	t.Run("Clear はすべて削除する", func(t *testing.T) {
+		store, err := NewVersionedStore(t.TempDir(), "")
+		require.NoError(t, err)
+
+		require.NoError(t, store.Update("dev", "/app/dev/", map[string]string{"A": "1"}, map[string]string{"A": "a"}))
+		require.NoError(t, store.Clear())
+		assert.Equal(t, VersionedUsage{}, store.Usage())
+
+		_, missing, err := store.Lookup("dev", "/app/dev/", map[string]string{"A": "1"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"A"}, missing)
+	})
+}
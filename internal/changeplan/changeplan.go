@@ -0,0 +1,152 @@
+// Package changeplan describes what a push would change as a versioned JSON
+// document, so policy engines such as OPA or custom scripts can approve or
+// deny a change before it is applied.
+package changeplan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/drapon/envy/internal/config"
+)
+
+const (
+	// Schema identifies change plan documents
+	Schema = "envy.change-plan"
+	// Version is the schema version. Adding fields keeps the version;
+	// renaming, removing or changing the meaning of one increments it.
+	Version = 1
+)
+
+// Actions of a change
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionUnchanged = "unchanged"
+)
+
+// Plan is the change plan of one environment
+type Plan struct {
+	Schema      string `json:"schema"`
+	Version     int    `json:"version"`
+	Environment string `json:"environment"`
+	Target      Target `json:"target"`
+	// Overwrite is false when existing variables are only updated after
+	// confirmation (push without --force)
+	Overwrite bool     `json:"overwrite"`
+	Changes   []Change `json:"changes"`
+	// RemoteOnly are variables in the remote store that are not pushed;
+	// a push keeps them
+	RemoteOnly []string `json:"remote_only"`
+	Summary    Summary  `json:"summary"`
+}
+
+// Target is where the environment is stored
+type Target struct {
+	// Backend is aws or azure
+	Backend string `json:"backend"`
+	// Service is parameter_store, secrets_manager or key_vault
+	Service string `json:"service"`
+	Path    string `json:"path"`
+	Region  string `json:"region,omitempty"`
+	Vault   string `json:"vault,omitempty"`
+}
+
+// Change is one variable of the push. Values of sensitive variables are
+// never included.
+type Change struct {
+	Key       string  `json:"key"`
+	Action    string  `json:"action"`
+	Sensitive bool    `json:"sensitive"`
+	Value     *string `json:"value,omitempty"`
+	Previous  *string `json:"previous,omitempty"`
+}
+
+// Summary counts the changes by action
+type Summary struct {
+	Create    int `json:"create"`
+	Update    int `json:"update"`
+	Unchanged int `json:"unchanged"`
+}
+
+// NewTarget describes where cfg stores the environment
+func NewTarget(cfg *config.Config, envName string) Target {
+	path := cfg.GetParameterPath(envName)
+	if cfg.UsesAzure() {
+		return Target{Backend: "azure", Service: "key_vault", Path: path, Vault: cfg.Azure.VaultURL}
+	}
+
+	service := cfg.GetAWSService(envName)
+	if envConfig, err := cfg.GetEnvironment(envName); err == nil && envConfig.UseSecretsManager {
+		service = "secrets_manager"
+	}
+	return Target{Backend: "aws", Service: service, Path: path, Region: cfg.GetRegion(envName)}
+}
+
+// New compares the variables to push with the remote ones. Variables only in
+// the remote store are listed as remote-only, since a push never deletes.
+func New(envName string, target Target, local, remote map[string]string, overwrite bool, isSensitive func(string) bool) *Plan {
+	plan := &Plan{
+		Schema:      Schema,
+		Version:     Version,
+		Environment: envName,
+		Target:      target,
+		Overwrite:   overwrite,
+		Changes:     []Change{},
+		RemoteOnly:  []string{},
+	}
+
+	for _, key := range sortedKeys(local) {
+		value := local[key]
+		change := Change{Key: key, Sensitive: isSensitive(key)}
+
+		previous, exists := remote[key]
+		switch {
+		case !exists:
+			change.Action = ActionCreate
+			plan.Summary.Create++
+		case previous != value:
+			change.Action = ActionUpdate
+			plan.Summary.Update++
+		default:
+			change.Action = ActionUnchanged
+			plan.Summary.Unchanged++
+		}
+
+		if !change.Sensitive {
+			change.Value = &value
+			if change.Action == ActionUpdate {
+				change.Previous = &previous
+			}
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	for _, key := range sortedKeys(remote) {
+		if _, ok := local[key]; !ok {
+			plan.RemoteOnly = append(plan.RemoteOnly, key)
+		}
+	}
+	return plan
+}
+
+// Write writes the plan as indented JSON
+func (p *Plan) Write(w io.Writer) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal change plan: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,79 @@
+package changeplan
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func isSensitive(key string) bool {
+	return strings.HasSuffix(key, "_SECRET")
+}
+
+func TestNew(t *testing.T) {
+	local := map[string]string{"API_URL": "https://new.example.org", "PORT": "8080", "JWT_SECRET": "b", "DEBUG": "false"}
+	remote := map[string]string{"API_URL": "https://old.example.org", "PORT": "8080", "JWT_SECRET": "a", "LEGACY": "1"}
+
+	plan := New("prod", Target{Backend: "aws", Service: "parameter_store", Path: "/app/prod/"}, local, remote, false, isSensitive)
+
+	assert.Equal(t, Schema, plan.Schema)
+	assert.Equal(t, Version, plan.Version)
+	assert.Equal(t, Summary{Create: 1, Update: 2, Unchanged: 1}, plan.Summary)
+	assert.Equal(t, []string{"LEGACY"}, plan.RemoteOnly)
+
+	require.Len(t, plan.Changes, 4)
+	assert.Equal(t, "API_URL", plan.Changes[0].Key)
+	assert.Equal(t, ActionUpdate, plan.Changes[0].Action)
+	assert.Equal(t, "https://new.example.org", *plan.Changes[0].Value)
+	assert.Equal(t, "https://old.example.org", *plan.Changes[0].Previous)
+
+	assert.Equal(t, ActionCreate, plan.Changes[1].Action)
+	assert.Nil(t, plan.Changes[1].Previous)
+
+	secret := plan.Changes[2]
+	assert.Equal(t, "JWT_SECRET", secret.Key)
+	assert.Equal(t, ActionUpdate, secret.Action)
+	assert.True(t, secret.Sensitive)
+	assert.Nil(t, secret.Value)
+	assert.Nil(t, secret.Previous)
+
+	assert.Equal(t, ActionUnchanged, plan.Changes[3].Action)
+	assert.Nil(t, plan.Changes[3].Previous)
+}
+
+func TestWrite(t *testing.T) {
+	plan := New("dev", Target{Backend: "aws", Service: "parameter_store", Path: "/app/dev/"}, map[string]string{"A_SECRET": "x"}, nil, true, isSensitive)
+
+	var buf bytes.Buffer
+	require.NoError(t, plan.Write(&buf))
+	assert.NotContains(t, buf.String(), `"x"`)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "envy.change-plan", doc["schema"])
+	assert.Equal(t, float64(1), doc["version"])
+	assert.Equal(t, true, doc["overwrite"])
+	assert.Equal(t, []interface{}{}, doc["remote_only"])
+}
+
+func TestNewTarget(t *testing.T) {
+	cfg := &config.Config{
+		Project: "app",
+		AWS:     config.AWSConfig{Service: "parameter_store", Region: "eu-west-1"},
+		Environments: map[string]config.Environment{
+			"prod":   {Path: "/app/prod/"},
+			"secret": {Path: "/app/secret/", UseSecretsManager: true},
+		},
+	}
+
+	assert.Equal(t, Target{Backend: "aws", Service: "parameter_store", Path: "/app/prod/", Region: "eu-west-1"}, NewTarget(cfg, "prod"))
+	assert.Equal(t, "secrets_manager", NewTarget(cfg, "secret").Service)
+
+	cfg.Azure = &config.AzureConfig{VaultURL: "https://myvault.vault.azure.net"}
+	assert.Equal(t, Target{Backend: "azure", Service: "key_vault", Path: "/app/prod/", Vault: "https://myvault.vault.azure.net"}, NewTarget(cfg, "prod"))
+}
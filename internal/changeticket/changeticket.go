@@ -0,0 +1,294 @@
+// Package changeticket records pushes to production environments in a
+// change management system. The systems are not built in: the requests
+// that open or update a ticket are templates in .envyrc, so Jira,
+// ServiceNow or an internal webhook are configured the same way.
+package changeticket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/drapon/envy/internal/changeplan"
+	"github.com/drapon/envy/internal/config"
+)
+
+// Change is what the request templates see
+type Change struct {
+	Project     string
+	Environment string
+	Target      string // where the environment is stored, e.g. "AWS Parameter Store /myapp/prod/ (us-east-1)"
+	Ticket      string // the ticket given with --ticket, empty when one is created
+	User        string
+	Title       string   // one line, e.g. "envy push to prod: 1 created, 2 updated"
+	Summary     string   // the changes, one per line; sensitive values are masked
+	Owners      []string // mentions of the owners of the changed variables, e.g. "@payments-team"
+	Plan        *changeplan.Plan
+}
+
+// NewChange describes a push from its change plan. mentions returns how to
+// mention the owners of a key, so they are notified; it may be nil.
+func NewChange(project, target, ticket string, plan *changeplan.Plan, mentions func(key string) []string) *Change {
+	change := &Change{
+		Project:     project,
+		Environment: plan.Environment,
+		Target:      target,
+		Ticket:      ticket,
+		Plan:        plan,
+		Title: fmt.Sprintf("envy push to %s: %d created, %d updated",
+			plan.Environment, plan.Summary.Create, plan.Summary.Update),
+	}
+	if u, err := user.Current(); err == nil {
+		change.User = u.Username
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Push to %s (%s)", plan.Environment, target)
+	if change.User != "" {
+		fmt.Fprintf(&b, " by %s", change.User)
+	}
+	b.WriteString("\n")
+	owners := make(map[string]bool)
+	for _, c := range plan.Changes {
+		var line string
+		switch c.Action {
+		case changeplan.ActionCreate:
+			line = fmt.Sprintf("+ %s = %s", c.Key, maskedValue(c.Value))
+		case changeplan.ActionUpdate:
+			if c.Sensitive {
+				line = fmt.Sprintf("~ %s (sensitive value changed)", c.Key)
+			} else {
+				line = fmt.Sprintf("~ %s: %s -> %s", c.Key, maskedValue(c.Previous), maskedValue(c.Value))
+			}
+		default:
+			continue
+		}
+		if mentions != nil {
+			if keyOwners := mentions(c.Key); len(keyOwners) > 0 {
+				line += " " + strings.Join(keyOwners, " ")
+				for _, owner := range keyOwners {
+					owners[owner] = true
+				}
+			}
+		}
+		b.WriteString(line + "\n")
+	}
+	for owner := range owners {
+		change.Owners = append(change.Owners, owner)
+	}
+	sort.Strings(change.Owners)
+	if plan.Summary.Unchanged > 0 {
+		fmt.Fprintf(&b, "%d variable(s) unchanged\n", plan.Summary.Unchanged)
+	}
+	change.Summary = strings.TrimSuffix(b.String(), "\n")
+	return change
+}
+
+// maskedValue shows a value of the plan, which omits sensitive ones
+func maskedValue(value *string) string {
+	if value == nil {
+		return "***"
+	}
+	return *value
+}
+
+// Client sends the requests of change_tickets
+type Client struct {
+	create *request
+	update *request
+	http   *http.Client
+}
+
+// request is a parsed TicketRequest
+type request struct {
+	method  string
+	url     *template.Template
+	headers map[string]*template.Template
+	body    *template.Template
+	idPath  string
+}
+
+// funcs are available in the templates besides the standard ones
+var funcs = template.FuncMap{
+	// json quotes a value for a JSON body, e.g. {"description": {{json .Summary}}}
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	// join joins a list, e.g. {{join .Owners " "}}
+	"join": func(list []string, sep string) string {
+		return strings.Join(list, sep)
+	},
+}
+
+// New parses the request templates, so a mistake in them is reported
+// before anything is pushed
+func New(cfg *config.ChangeTicketConfig) (*Client, error) {
+	c := &Client{http: &http.Client{Timeout: 30 * time.Second}}
+
+	var err error
+	if c.create, err = parseRequest("create", cfg.Create); err != nil {
+		return nil, err
+	}
+	if c.update, err = parseRequest("update", cfg.Update); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func parseRequest(name string, r *config.TicketRequest) (*request, error) {
+	if r == nil {
+		return nil, nil
+	}
+
+	parse := func(field, text string) (*template.Template, error) {
+		t, err := template.New(name + "." + field).Funcs(funcs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid change_tickets.%s.%s: %w", name, field, err)
+		}
+		return t, nil
+	}
+
+	req := &request{method: strings.ToUpper(r.Method), idPath: r.IDPath, headers: make(map[string]*template.Template)}
+	if req.method == "" {
+		req.method = http.MethodPost
+	}
+
+	var err error
+	if req.url, err = parse("url", os.ExpandEnv(r.URL)); err != nil {
+		return nil, err
+	}
+	if req.body, err = parse("body", r.Body); err != nil {
+		return nil, err
+	}
+	for header, value := range r.Headers {
+		if req.headers[header], err = parse("headers."+header, os.ExpandEnv(value)); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// Record adds the change to its ticket, or opens a ticket when it has none.
+// It returns the ticket, which is empty when change_tickets has no request
+// for the case or the response of create has no id_path.
+func (c *Client) Record(ctx context.Context, change *Change) (string, error) {
+	if change.Ticket != "" {
+		if c.update == nil {
+			return "", nil
+		}
+		if _, err := c.send(ctx, c.update, change); err != nil {
+			return "", fmt.Errorf("failed to update change ticket %s: %w", change.Ticket, err)
+		}
+		return change.Ticket, nil
+	}
+
+	if c.create == nil {
+		return "", nil
+	}
+	response, err := c.send(ctx, c.create, change)
+	if err != nil {
+		return "", fmt.Errorf("failed to open a change ticket: %w", err)
+	}
+	if c.create.idPath == "" {
+		return "", nil
+	}
+	id, err := lookup(response, c.create.idPath)
+	if err != nil {
+		return "", fmt.Errorf("opened a change ticket, but %w", err)
+	}
+	return id, nil
+}
+
+// send executes the templates of r and sends the request, returning the
+// response body
+func (c *Client) send(ctx context.Context, r *request, change *Change) ([]byte, error) {
+	var url, body bytes.Buffer
+	if err := r.url.Execute(&url, change); err != nil {
+		return nil, err
+	}
+	if err := r.body.Execute(&body, change); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, strings.TrimSpace(url.String()), &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for header, value := range r.headers {
+		var b strings.Builder
+		if err := value.Execute(&b, change); err != nil {
+			return nil, err
+		}
+		req.Header.Set(header, b.String())
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		text := strings.TrimSpace(string(data))
+		if len(text) > 200 {
+			text = text[:200] + "..."
+		}
+		return nil, fmt.Errorf("%s %s returned %s: %s", r.method, req.URL.Redacted(), resp.Status, text)
+	}
+	return data, nil
+}
+
+// lookup returns the value at a dotted path, such as "result.number" or
+// "issues.0.key", of a JSON document
+func lookup(data []byte, path string) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("its response is not JSON: %w", err)
+	}
+
+	for _, part := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[part]
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return "", fmt.Errorf("its response has no %s", path)
+			}
+			value = v[i]
+		default:
+			value = nil
+		}
+		if value == nil {
+			return "", fmt.Errorf("its response has no %s", path)
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	default:
+		return "", fmt.Errorf("%s in its response is not a string or number", path)
+	}
+}
@@ -0,0 +1,138 @@
+package changeticket
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/drapon/envy/internal/changeplan"
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPlan() *changeplan.Plan {
+	local := map[string]string{"API_URL": "https://api.example.org", "DB_PASSWORD": "new", "PORT": "8080", "REGION": "eu"}
+	remote := map[string]string{"DB_PASSWORD": "old", "PORT": "80", "REGION": "eu"}
+	return changeplan.New("prod", changeplan.Target{Backend: "aws"}, local, remote, true, func(key string) bool { return key == "DB_PASSWORD" })
+}
+
+func TestNewChange(t *testing.T) {
+	change := NewChange("myapp", "AWS Parameter Store /myapp/prod/ (us-east-1)", "", testPlan(), nil)
+
+	assert.Equal(t, "envy push to prod: 1 created, 2 updated", change.Title)
+	assert.Contains(t, change.Summary, "+ API_URL = https://api.example.org\n")
+	assert.Contains(t, change.Summary, "~ DB_PASSWORD (sensitive value changed)\n")
+	assert.Contains(t, change.Summary, "~ PORT: 80 -> 8080\n")
+	assert.Contains(t, change.Summary, "1 variable(s) unchanged")
+	assert.NotContains(t, change.Summary, "old")
+	assert.NotContains(t, change.Summary, "new")
+}
+
+func TestNewChange_Mentions(t *testing.T) {
+	mentions := func(key string) []string {
+		if key == "DB_PASSWORD" {
+			return []string{"@platform"}
+		}
+		return nil
+	}
+	change := NewChange("myapp", "AWS", "", testPlan(), mentions)
+
+	assert.Contains(t, change.Summary, "~ DB_PASSWORD (sensitive value changed) @platform\n")
+	assert.Contains(t, change.Summary, "~ PORT: 80 -> 8080\n")
+	assert.Equal(t, []string{"@platform"}, change.Owners)
+}
+
+func TestRecord(t *testing.T) {
+	t.Setenv("TICKET_TOKEN", "s3cret")
+
+	var requests []*http.Request
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		_ = json.Unmarshal(data, &body)
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		_, _ = w.Write([]byte(`{"result": {"number": "CHG0030001", "sys_id": 42}}`))
+	}))
+	defer server.Close()
+
+	client, err := New(&config.ChangeTicketConfig{
+		Create: &config.TicketRequest{
+			URL:     server.URL + "/api/now/table/change_request",
+			Headers: map[string]string{"authorization": "Bearer ${TICKET_TOKEN}"},
+			Body:    `{"short_description": {{json .Title}}, "description": {{json .Summary}}}`,
+			IDPath:  "result.number",
+		},
+		Update: &config.TicketRequest{
+			Method: "patch",
+			URL:    server.URL + "/api/now/table/change_request/{{.Ticket}}",
+			Body:   `{"work_notes": {{json .Summary}}}`,
+		},
+	})
+	require.NoError(t, err)
+
+	id, err := client.Record(context.Background(), NewChange("myapp", "AWS", "", testPlan(), nil))
+	require.NoError(t, err)
+	assert.Equal(t, "CHG0030001", id)
+	assert.Equal(t, http.MethodPost, requests[0].Method)
+	assert.Equal(t, "Bearer s3cret", requests[0].Header.Get("Authorization"))
+	assert.Equal(t, "envy push to prod: 1 created, 2 updated", bodies[0]["short_description"])
+
+	id, err = client.Record(context.Background(), NewChange("myapp", "AWS", "CHG0029999", testPlan(), nil))
+	require.NoError(t, err)
+	assert.Equal(t, "CHG0029999", id)
+	assert.Equal(t, http.MethodPatch, requests[1].Method)
+	assert.Equal(t, "/api/now/table/change_request/CHG0029999", requests[1].URL.Path)
+	assert.Contains(t, bodies[1]["work_notes"], "~ PORT: 80 -> 8080")
+}
+
+func TestRecord_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/denied" {
+			http.Error(w, `{"error": "not allowed"}`, http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": "10000"}`))
+	}))
+	defer server.Close()
+
+	_, err := New(&config.ChangeTicketConfig{Create: &config.TicketRequest{URL: server.URL, Body: "{{.Title"}})
+	assert.ErrorContains(t, err, "change_tickets.create.body")
+
+	client, err := New(&config.ChangeTicketConfig{Update: &config.TicketRequest{URL: server.URL + "/denied"}})
+	require.NoError(t, err)
+	_, err = client.Record(context.Background(), NewChange("myapp", "AWS", "OPS-1", testPlan(), nil))
+	assert.ErrorContains(t, err, "403 Forbidden")
+
+	// Nothing to do without a create request
+	id, err := client.Record(context.Background(), NewChange("myapp", "AWS", "", testPlan(), nil))
+	require.NoError(t, err)
+	assert.Empty(t, id)
+
+	client, err = New(&config.ChangeTicketConfig{Create: &config.TicketRequest{URL: server.URL, IDPath: "key"}})
+	require.NoError(t, err)
+	_, err = client.Record(context.Background(), NewChange("myapp", "AWS", "", testPlan(), nil))
+	assert.ErrorContains(t, err, "has no key")
+}
+
+func TestLookup(t *testing.T) {
+	data := []byte(`{"key": "OPS-7", "id": 10007, "issues": [{"key": "OPS-8"}]}`)
+
+	for path, want := range map[string]string{"key": "OPS-7", "id": "10007", "issues.0.key": "OPS-8"} {
+		got, err := lookup(data, path)
+		require.NoError(t, err, path)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := lookup(data, "issues.1.key")
+	assert.Error(t, err)
+	_, err = lookup(data, "issues")
+	assert.Error(t, err)
+	_, err = lookup([]byte("<html>"), "key")
+	assert.ErrorContains(t, err, "not JSON")
+}
@@ -0,0 +1,188 @@
+// Package chaos injects synthetic AWS failures so retry, resume and locking
+// behaviour can be exercised in staging pipelines. It is enabled with the
+// hidden --chaos flag or ENVY_CHAOS, e.g. "throttling=0.2,timeout=0.05".
+package chaos
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault is a kind of synthetic failure
+type Fault string
+
+const (
+	// FaultThrottling simulates a ThrottlingException
+	FaultThrottling Fault = "throttling"
+	// FaultTimeout simulates a request that timed out
+	FaultTimeout Fault = "timeout"
+)
+
+// errorCodes are the AWS error codes reported for each fault. The SDK
+// retries both, so injected failures go through the normal retry path.
+var errorCodes = map[Fault]string{
+	FaultThrottling: "ThrottlingException",
+	FaultTimeout:    "RequestTimeout",
+}
+
+// Error is a synthetic AWS failure. It satisfies smithy.APIError, so
+// callers and the SDK treat it like the real error.
+type Error struct {
+	Fault     Fault
+	Operation string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("chaos: injected %s for %s", e.ErrorCode(), e.Operation)
+}
+
+// ErrorCode returns the AWS error code of the fault
+func (e *Error) ErrorCode() string {
+	return errorCodes[e.Fault]
+}
+
+// ErrorMessage returns the error message
+func (e *Error) ErrorMessage() string {
+	if e.Fault == FaultThrottling {
+		return "Rate exceeded"
+	}
+	return "Request timed out"
+}
+
+// ErrorFault reports the fault as server-side, like the real errors
+func (e *Error) ErrorFault() int {
+	// smithy.FaultServer
+	return 2
+}
+
+// Timeout reports whether the error is a timeout
+func (e *Error) Timeout() bool {
+	return e.Fault == FaultTimeout
+}
+
+// Injector fails operations at random with the configured probabilities
+type Injector struct {
+	rates map[Fault]float64
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// Parse builds an injector from a spec such as "throttling=0.2,timeout=0.05".
+// An empty spec returns nil, meaning chaos is disabled.
+func Parse(spec string) (*Injector, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	rates := make(map[Fault]float64)
+	for _, part := range strings.Split(spec, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid chaos spec %q: expected fault=probability", part)
+		}
+
+		fault := Fault(strings.ToLower(strings.TrimSpace(name)))
+		if _, known := errorCodes[fault]; !known {
+			return nil, fmt.Errorf("unknown chaos fault %q (supported: %s)", name, strings.Join(supportedFaults(), ", "))
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("invalid probability %q for %s: must be between 0 and 1", value, fault)
+		}
+		rates[fault] = rate
+	}
+
+	var total float64
+	for _, rate := range rates {
+		total += rate
+	}
+	if total > 1 {
+		return nil, fmt.Errorf("chaos probabilities add up to %.2f, more than 1", total)
+	}
+
+	return NewInjector(rates, time.Now().UnixNano()), nil
+}
+
+// NewInjector returns an injector with the given fault probabilities and
+// random seed
+func NewInjector(rates map[Fault]float64, seed int64) *Injector {
+	return &Injector{
+		rates: rates,
+		rand:  rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Error returns a synthetic failure for operation, or nil if the call
+// should go through
+func (i *Injector) Error(operation string) error {
+	if i == nil {
+		return nil
+	}
+
+	i.mu.Lock()
+	roll := i.rand.Float64()
+	i.mu.Unlock()
+
+	// Faults are checked in a fixed order so a seed is reproducible
+	for _, name := range supportedFaults() {
+		fault := Fault(name)
+		rate := i.rates[fault]
+		if roll < rate {
+			return &Error{Fault: fault, Operation: operation}
+		}
+		roll -= rate
+	}
+	return nil
+}
+
+// String returns the spec of the injector
+func (i *Injector) String() string {
+	if i == nil {
+		return ""
+	}
+
+	parts := make([]string, 0, len(i.rates))
+	for _, name := range supportedFaults() {
+		if rate, ok := i.rates[Fault(name)]; ok {
+			parts = append(parts, fmt.Sprintf("%s=%g", name, rate))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+var (
+	globalMu sync.RWMutex
+	global   *Injector
+)
+
+// Enable sets the process-wide injector used by new AWS clients. Passing
+// nil disables chaos.
+func Enable(injector *Injector) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	global = injector
+}
+
+// Global returns the process-wide injector, or nil when chaos is disabled
+func Global() *Injector {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return global
+}
+
+func supportedFaults() []string {
+	names := make([]string, 0, len(errorCodes))
+	for fault := range errorCodes {
+		names = append(names, string(fault))
+	}
+	sort.Strings(names)
+	return names
+}
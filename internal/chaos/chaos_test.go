@@ -0,0 +1,97 @@
+package chaos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", spec: "", want: ""},
+		{name: "single", spec: "throttling=0.2", want: "throttling=0.2"},
+		{name: "multiple", spec: " timeout=0.05, Throttling=0.2 ", want: "throttling=0.2,timeout=0.05"},
+		{name: "missing probability", spec: "throttling", wantErr: true},
+		{name: "unknown fault", spec: "outage=0.1", wantErr: true},
+		{name: "not a number", spec: "timeout=often", wantErr: true},
+		{name: "out of range", spec: "timeout=1.5", wantErr: true},
+		{name: "total above one", spec: "throttling=0.6,timeout=0.6", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			injector, err := Parse(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, injector.String())
+		})
+	}
+}
+
+func TestInjector_Error(t *testing.T) {
+	t.Run("nil injector never fails", func(t *testing.T) {
+		var injector *Injector
+		assert.NoError(t, injector.Error("ssm.GetParameter"))
+	})
+
+	t.Run("always fails", func(t *testing.T) {
+		injector := NewInjector(map[Fault]float64{FaultThrottling: 1}, 1)
+		err := injector.Error("ssm.GetParameter")
+		require.Error(t, err)
+
+		var chaosErr *Error
+		require.ErrorAs(t, err, &chaosErr)
+		assert.Equal(t, "ThrottlingException", chaosErr.ErrorCode())
+		assert.Equal(t, "ssm.GetParameter", chaosErr.Operation)
+		assert.False(t, chaosErr.Timeout())
+		assert.Contains(t, err.Error(), "Throttling")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		injector := NewInjector(map[Fault]float64{FaultTimeout: 1}, 1)
+		var chaosErr *Error
+		require.ErrorAs(t, injector.Error("op"), &chaosErr)
+		assert.True(t, chaosErr.Timeout())
+		assert.Equal(t, "RequestTimeout", chaosErr.ErrorCode())
+	})
+
+	t.Run("rate is roughly respected", func(t *testing.T) {
+		injector := NewInjector(map[Fault]float64{FaultThrottling: 0.2, FaultTimeout: 0.05}, 42)
+
+		counts := make(map[Fault]int)
+		const calls = 10000
+		for i := 0; i < calls; i++ {
+			if err := injector.Error("op"); err != nil {
+				counts[err.(*Error).Fault]++
+			}
+		}
+		assert.InDelta(t, 0.2, float64(counts[FaultThrottling])/calls, 0.02)
+		assert.InDelta(t, 0.05, float64(counts[FaultTimeout])/calls, 0.01)
+	})
+
+	t.Run("same seed is reproducible", func(t *testing.T) {
+		a := NewInjector(map[Fault]float64{FaultThrottling: 0.5}, 7)
+		b := NewInjector(map[Fault]float64{FaultThrottling: 0.5}, 7)
+		for i := 0; i < 100; i++ {
+			assert.Equal(t, a.Error("op") == nil, b.Error("op") == nil)
+		}
+	})
+}
+
+func TestEnable(t *testing.T) {
+	t.Cleanup(func() { Enable(nil) })
+
+	assert.Nil(t, Global())
+	injector := NewInjector(map[Fault]float64{FaultTimeout: 1}, 1)
+	Enable(injector)
+	assert.Same(t, injector, Global())
+}
@@ -0,0 +1,51 @@
+//go:build !slim
+
+package color
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/viper"
+)
+
+var (
+	// Color functions for different message types.
+	Success = color.New(color.FgGreen).SprintFunc()
+	Error   = color.New(color.FgRed).SprintFunc()
+	Warning = color.New(color.FgYellow).SprintFunc()
+	Info    = color.New(color.FgCyan).SprintFunc()
+	Bold    = color.New(color.Bold).SprintFunc()
+
+	// Formatted print functions.
+	SuccessF = color.New(color.FgGreen).SprintfFunc()
+	ErrorF   = color.New(color.FgRed).SprintfFunc()
+	WarningF = color.New(color.FgYellow).SprintfFunc()
+	InfoF    = color.New(color.FgCyan).SprintfFunc()
+	BoldF    = color.New(color.Bold).SprintfFunc()
+)
+
+// Initialize checks environment for color settings.
+func Initialize() {
+	// Check if colors should be disabled
+	// Check environment variable first as viper might not be initialized
+	if os.Getenv("NO_COLOR") != "" {
+		color.NoColor = true
+		return
+	}
+
+	// Check viper config if available
+	if viper.IsSet("no_color") && viper.GetBool("no_color") {
+		color.NoColor = true
+	}
+}
+
+// DisableColors disables all color output.
+func DisableColors() {
+	color.NoColor = true
+}
+
+// EnableColors enables color output.
+func EnableColors() {
+	color.NoColor = false
+}
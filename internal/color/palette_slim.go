@@ -0,0 +1,32 @@
+//go:build slim
+
+package color
+
+import (
+	"fmt"
+)
+
+var (
+	// Color functions for different message types.
+	Success = fmt.Sprint
+	Error   = fmt.Sprint
+	Warning = fmt.Sprint
+	Info    = fmt.Sprint
+	Bold    = fmt.Sprint
+
+	// Formatted print functions.
+	SuccessF = fmt.Sprintf
+	ErrorF   = fmt.Sprintf
+	WarningF = fmt.Sprintf
+	InfoF    = fmt.Sprintf
+	BoldF    = fmt.Sprintf
+)
+
+// Initialize is a no-op in the slim build; output is always plain text.
+func Initialize() {}
+
+// DisableColors is a no-op in the slim build.
+func DisableColors() {}
+
+// EnableColors is a no-op in the slim build.
+func EnableColors() {}
@@ -0,0 +1,279 @@
+// Package compliance evaluates environments against a compliance profile,
+// such as a company's secrets standard, and reports the results for
+// auditors.
+package compliance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/gitops"
+)
+
+// Profile is a compliance standard, e.g.
+//
+//	name: Internal secrets standard
+//	environments: [prod]
+//	secure_string: true
+//	customer_managed_key: true
+//	required_tags: [owner, data-classification]
+//	rotation: true
+//	max_rotation_days: 90
+//	no_plaintext_in_git: true
+//
+// Checks that are not enabled are left out of the report.
+type Profile struct {
+	Name         string   `yaml:"name"`
+	Environments []string `yaml:"environments,omitempty"` // default: every environment
+
+	SecureString       bool     `yaml:"secure_string,omitempty"`        // sensitive parameters are SecureString
+	CustomerManagedKey bool     `yaml:"customer_managed_key,omitempty"` // encrypted with a customer managed KMS key
+	KMSKeys            []string `yaml:"kms_keys,omitempty"`             // allowed keys (IDs, ARNs or aliases); any CMK if empty
+	RequiredTags       []string `yaml:"required_tags,omitempty"`
+	Rotation           bool     `yaml:"rotation,omitempty"`          // Secrets Manager rotation is enabled
+	MaxRotationDays    int64    `yaml:"max_rotation_days,omitempty"` // longest allowed rotation interval
+	NoPlaintextInGit   bool     `yaml:"no_plaintext_in_git,omitempty"`
+}
+
+// LoadProfile reads a profile from a YAML file
+func LoadProfile(filename string) (*Profile, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compliance profile: %w", err)
+	}
+
+	var profile Profile
+	if err := yaml.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse compliance profile: %w", err)
+	}
+	if profile.Name == "" {
+		profile.Name = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+	if profile.MaxRotationDays < 0 {
+		return nil, fmt.Errorf("invalid compliance profile %s: max_rotation_days must not be negative", filename)
+	}
+	if !profile.SecureString && !profile.CustomerManagedKey && len(profile.RequiredTags) == 0 &&
+		!profile.Rotation && !profile.NoPlaintextInGit {
+		return nil, fmt.Errorf("invalid compliance profile %s: no checks enabled", filename)
+	}
+	return &profile, nil
+}
+
+// Leak is a sensitive value committed to git
+type Leak struct {
+	File   string `json:"file"`
+	Commit string `json:"commit"`
+	Key    string `json:"key"`
+}
+
+// Environment is what is known about an environment for evaluation
+type Environment struct {
+	Name      string
+	Resources []*aws.Resource
+	Sensitive func(key string) bool // classifies variable names
+	Leaks     []Leak                // sensitive values found in git history
+}
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip" // the check does not apply
+)
+
+// Check is the result of one check for one environment
+type Check struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Status   Status   `json:"status"`
+	Note     string   `json:"note,omitempty"`
+	Findings []string `json:"findings,omitempty"`
+}
+
+// EnvironmentReport holds the checks of one environment
+type EnvironmentReport struct {
+	Name   string  `json:"name"`
+	Status Status  `json:"status"`
+	Checks []Check `json:"checks"`
+}
+
+// Ownership is who is accountable for the variables matching a key or
+// pattern
+type Ownership struct {
+	Variables string   `json:"variables"`
+	Owners    []string `json:"owners"`
+	// Contacts are the owners with their email and chat channel, from the
+	// roster
+	Contacts []string `json:"contacts,omitempty"`
+}
+
+// Report is the result of evaluating environments against a profile
+type Report struct {
+	Standard     string              `json:"standard"`
+	Generated    time.Time           `json:"generated"`
+	Status       Status              `json:"status"`
+	Environments []EnvironmentReport `json:"environments"`
+	// Owners tells auditors whom to ask about the variables
+	Owners []Ownership `json:"owners,omitempty"`
+}
+
+// Passed reports whether every environment passed
+func (r *Report) Passed() bool {
+	return r.Status == StatusPass
+}
+
+// Evaluate runs the profile's checks on each environment
+func Evaluate(profile *Profile, envs []Environment, now time.Time) *Report {
+	report := &Report{
+		Standard:  profile.Name,
+		Generated: now.UTC(),
+		Status:    StatusPass,
+	}
+
+	for _, env := range envs {
+		envReport := EnvironmentReport{Name: env.Name, Status: StatusPass}
+		for _, check := range profile.checks(env) {
+			if check.Status == StatusFail {
+				envReport.Status = StatusFail
+				report.Status = StatusFail
+			}
+			envReport.Checks = append(envReport.Checks, check)
+		}
+		report.Environments = append(report.Environments, envReport)
+	}
+	return report
+}
+
+// checks returns the enabled checks for an environment
+func (p *Profile) checks(env Environment) []Check {
+	var checks []Check
+	if p.SecureString {
+		checks = append(checks, checkSecureString(env))
+	}
+	if p.CustomerManagedKey {
+		checks = append(checks, p.checkCustomerManagedKey(env))
+	}
+	if len(p.RequiredTags) > 0 {
+		checks = append(checks, p.checkTags(env))
+	}
+	if p.Rotation {
+		checks = append(checks, p.checkRotation(env))
+	}
+	if p.NoPlaintextInGit {
+		checks = append(checks, checkGit(env))
+	}
+	return checks
+}
+
+func checkSecureString(env Environment) Check {
+	check := Check{ID: "secure_string", Title: "Secrets are stored as SecureString"}
+	for _, r := range env.Resources {
+		if r.Service == "parameter_store" && r.Type != "SecureString" && env.Sensitive != nil && env.Sensitive(r.Key) {
+			check.Findings = append(check.Findings, fmt.Sprintf("%s is a %s parameter", r.Name, r.Type))
+		}
+	}
+	return check.done()
+}
+
+func (p *Profile) checkCustomerManagedKey(env Environment) Check {
+	check := Check{ID: "customer_managed_key", Title: "Secrets are encrypted with a customer managed KMS key"}
+	for _, r := range env.Resources {
+		if r.Service == "parameter_store" && r.Type != "SecureString" {
+			continue
+		}
+		switch {
+		case isAWSManagedKey(r.KMSKeyID):
+			check.Findings = append(check.Findings, fmt.Sprintf("%s uses the AWS managed key", r.Name))
+		case len(p.KMSKeys) > 0 && !matchesKey(r.KMSKeyID, p.KMSKeys):
+			check.Findings = append(check.Findings, fmt.Sprintf("%s uses %s, which is not an allowed key", r.Name, r.KMSKeyID))
+		}
+	}
+	return check.done()
+}
+
+func (p *Profile) checkTags(env Environment) Check {
+	check := Check{ID: "required_tags", Title: "Resources carry the required tags (" + strings.Join(p.RequiredTags, ", ") + ")"}
+	for _, r := range env.Resources {
+		var missing []string
+		for _, tag := range p.RequiredTags {
+			if r.Tags[tag] == "" {
+				missing = append(missing, tag)
+			}
+		}
+		if len(missing) > 0 {
+			check.Findings = append(check.Findings, fmt.Sprintf("%s is missing %s", r.Name, strings.Join(missing, ", ")))
+		}
+	}
+	return check.done()
+}
+
+func (p *Profile) checkRotation(env Environment) Check {
+	check := Check{ID: "rotation", Title: "Secrets rotate automatically"}
+	if p.MaxRotationDays > 0 {
+		check.Title = fmt.Sprintf("Secrets rotate automatically at least every %d days", p.MaxRotationDays)
+	}
+
+	secrets := 0
+	for _, r := range env.Resources {
+		if r.Service != "secrets_manager" {
+			continue
+		}
+		secrets++
+		switch {
+		case !r.RotationEnabled:
+			check.Findings = append(check.Findings, fmt.Sprintf("%s has rotation disabled", r.Name))
+		case p.MaxRotationDays > 0 && r.RotationDays > p.MaxRotationDays:
+			check.Findings = append(check.Findings, fmt.Sprintf("%s rotates every %d days", r.Name, r.RotationDays))
+		}
+	}
+	if secrets == 0 && len(env.Resources) > 0 {
+		check.Status = StatusSkip
+		check.Note = "Parameter Store has no managed rotation"
+		return check
+	}
+	return check.done()
+}
+
+func checkGit(env Environment) Check {
+	check := Check{ID: "no_plaintext_in_git", Title: "No secrets in git history"}
+	for _, leak := range env.Leaks {
+		check.Findings = append(check.Findings, fmt.Sprintf("%s in %s at %s", leak.Key, leak.File, gitops.ShortSHA(leak.Commit)))
+	}
+	return check.done()
+}
+
+// done sets the status from the findings
+func (c Check) done() Check {
+	sort.Strings(c.Findings)
+	c.Status = StatusPass
+	if len(c.Findings) > 0 {
+		c.Status = StatusFail
+	}
+	return c
+}
+
+// isAWSManagedKey reports whether a key ID is empty (the service default)
+// or an aws/ alias
+func isAWSManagedKey(keyID string) bool {
+	return keyID == "" || strings.Contains(keyID, "alias/aws/")
+}
+
+// matchesKey reports whether a key ID or ARN is one of keys, which may be
+// given as IDs, ARNs or aliases
+func matchesKey(keyID string, keys []string) bool {
+	for _, key := range keys {
+		if keyID == key || strings.HasSuffix(keyID, "/"+key) || strings.HasSuffix(keyID, ":"+key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package compliance
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drapon/envy/internal/aws"
+)
+
+func testProfile() *Profile {
+	return &Profile{
+		Name:               "internal",
+		SecureString:       true,
+		CustomerManagedKey: true,
+		RequiredTags:       []string{"owner"},
+		Rotation:           true,
+		MaxRotationDays:    90,
+		NoPlaintextInGit:   true,
+	}
+}
+
+func isSensitive(key string) bool {
+	return strings.HasSuffix(key, "_PASSWORD") || strings.HasSuffix(key, "_KEY")
+}
+
+func TestEvaluate_ParameterStore(t *testing.T) {
+	env := Environment{
+		Name:      "prod",
+		Sensitive: isSensitive,
+		Resources: []*aws.Resource{
+			{Name: "/app/prod/DB_PASSWORD", Key: "DB_PASSWORD", Service: "parameter_store", Type: "String", Tags: map[string]string{"owner": "data"}},
+			{Name: "/app/prod/API_KEY", Key: "API_KEY", Service: "parameter_store", Type: "SecureString", KMSKeyID: "alias/aws/ssm"},
+			{Name: "/app/prod/STRIPE_KEY", Key: "STRIPE_KEY", Service: "parameter_store", Type: "SecureString", KMSKeyID: "alias/app", Tags: map[string]string{"owner": "payments"}},
+			{Name: "/app/prod/PORT", Key: "PORT", Service: "parameter_store", Type: "String", Tags: map[string]string{"owner": "web"}},
+		},
+		Leaks: []Leak{{File: ".env.prod", Commit: "0123456789abcdef", Key: "DB_PASSWORD"}},
+	}
+
+	report := Evaluate(testProfile(), []Environment{env}, time.Now())
+	assert.False(t, report.Passed())
+	require.Len(t, report.Environments, 1)
+
+	checks := make(map[string]Check)
+	for _, check := range report.Environments[0].Checks {
+		checks[check.ID] = check
+	}
+	assert.Equal(t, []string{"/app/prod/DB_PASSWORD is a String parameter"}, checks["secure_string"].Findings)
+	assert.Equal(t, []string{"/app/prod/API_KEY uses the AWS managed key"}, checks["customer_managed_key"].Findings)
+	assert.Equal(t, []string{"/app/prod/API_KEY is missing owner"}, checks["required_tags"].Findings)
+	assert.Equal(t, StatusSkip, checks["rotation"].Status)
+	assert.Equal(t, []string{"DB_PASSWORD in .env.prod at 0123456789ab"}, checks["no_plaintext_in_git"].Findings)
+}
+
+func TestEvaluate_SecretsManager(t *testing.T) {
+	profile := testProfile()
+	profile.KMSKeys = []string{"alias/app"}
+
+	secret := &aws.Resource{
+		Name:            "app-prod",
+		Service:         "secrets_manager",
+		KMSKeyID:        "arn:aws:kms:us-east-1:123456789012:alias/app",
+		Tags:            map[string]string{"owner": "platform"},
+		RotationEnabled: true,
+		RotationDays:    30,
+	}
+	report := Evaluate(profile, []Environment{{Name: "prod", Resources: []*aws.Resource{secret}}}, time.Now())
+	assert.True(t, report.Passed())
+
+	secret.RotationDays = 365
+	secret.KMSKeyID = "arn:aws:kms:us-east-1:123456789012:key/other"
+	report = Evaluate(profile, []Environment{{Name: "prod", Resources: []*aws.Resource{secret}}}, time.Now())
+	assert.False(t, report.Passed())
+
+	markdown := report.Markdown()
+	assert.Contains(t, markdown, "# Compliance report: internal")
+	assert.Contains(t, markdown, "## prod (fail)")
+	assert.Contains(t, markdown, "- app-prod rotates every 365 days\n")
+	assert.Contains(t, markdown, "which is not an allowed key")
+
+	doc := report.Document()
+	assert.Equal(t, "Compliance report: internal", doc.Title)
+	require.Len(t, doc.Sections, 1)
+	assert.Equal(t, "prod", doc.Sections[0].Title)
+	assert.False(t, doc.Sections[0].Collapsed, "failed environments start open")
+	assert.Contains(t, doc.Sections[0].Items, "Secrets rotate automatically at least every 90 days: app-prod rotates every 365 days")
+}
+
+func TestReportOwners(t *testing.T) {
+	report := Evaluate(testProfile(), nil, time.Now())
+	report.Owners = []Ownership{
+		{Variables: "STRIPE_*", Owners: []string{"payments"}, Contacts: []string{"payments <payments@acme.com> #payments"}},
+		{Variables: "DB_*", Owners: []string{"platform"}},
+	}
+
+	markdown := report.Markdown()
+	assert.Contains(t, markdown, "## Owners")
+	assert.Contains(t, markdown, "| `STRIPE_*` | payments <payments@acme.com> #payments |\n")
+	assert.Contains(t, markdown, "| `DB_*` | platform |\n")
+
+	doc := report.Document()
+	require.Len(t, doc.Sections, 1)
+	assert.Equal(t, "Owners", doc.Sections[0].Title)
+	assert.Equal(t, [][]string{{"STRIPE_*", "payments <payments@acme.com> #payments"}, {"DB_*", "platform"}}, doc.Sections[0].Rows)
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "internal.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte("environments: [prod]\nrequired_tags: [owner]\n"), 0644))
+
+	profile, err := LoadProfile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "internal", profile.Name)
+	assert.Equal(t, []string{"prod"}, profile.Environments)
+
+	require.NoError(t, os.WriteFile(filename, []byte("name: empty\n"), 0644))
+	_, err = LoadProfile(filename)
+	assert.ErrorContains(t, err, "no checks enabled")
+}
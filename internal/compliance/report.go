@@ -0,0 +1,104 @@
+package compliance
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/drapon/envy/internal/report"
+)
+
+// statusMarks label statuses in markdown
+var statusMarks = map[Status]string{
+	StatusPass: "✅ pass",
+	StatusFail: "❌ fail",
+	StatusSkip: "➖ n/a",
+}
+
+// Markdown renders the report for humans, e.g. to attach to an audit
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Compliance report: %s\n\n", r.Standard)
+	fmt.Fprintf(&b, "Generated %s. Overall result: **%s**.\n", r.Generated.Format("2006-01-02 15:04 MST"), strings.ToUpper(string(r.Status)))
+
+	for _, env := range r.Environments {
+		fmt.Fprintf(&b, "\n## %s (%s)\n\n", env.Name, env.Status)
+		b.WriteString("| Check | Result |\n|---|---|\n")
+		for _, check := range env.Checks {
+			fmt.Fprintf(&b, "| %s | %s |\n", check.Title, statusMarks[check.Status])
+		}
+
+		for _, check := range env.Checks {
+			if check.Note == "" && len(check.Findings) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "\n**%s**", check.Title)
+			if check.Note != "" {
+				fmt.Fprintf(&b, ": %s", check.Note)
+			}
+			b.WriteString("\n\n")
+			for _, finding := range check.Findings {
+				fmt.Fprintf(&b, "- %s\n", finding)
+			}
+		}
+	}
+
+	if len(r.Owners) > 0 {
+		b.WriteString("\n## Owners\n\n| Variables | Owners |\n|---|---|\n")
+		for _, o := range r.Owners {
+			fmt.Fprintf(&b, "| `%s` | %s |\n", o.Variables, strings.Join(o.contacts(), ", "))
+		}
+	}
+	return b.String()
+}
+
+// contacts returns the owners with their contact details when known
+func (o Ownership) contacts() []string {
+	if len(o.Contacts) > 0 {
+		return o.Contacts
+	}
+	return o.Owners
+}
+
+// reportStatuses map check statuses to report statuses
+var reportStatuses = map[Status]report.Status{
+	StatusPass: report.StatusPass,
+	StatusFail: report.StatusFail,
+	StatusSkip: report.StatusInfo,
+}
+
+// Document describes the report for the renderers of the report package,
+// with one section per environment. Environments that passed start
+// collapsed, so the failures stand out.
+func (r *Report) Document() *report.Document {
+	doc := &report.Document{
+		Title:     "Compliance report: " + r.Standard,
+		Summary:   []string{"Overall result: " + strings.ToUpper(string(r.Status))},
+		Status:    reportStatuses[r.Status],
+		Generated: r.Generated,
+	}
+
+	for _, env := range r.Environments {
+		section := report.Section{
+			Title:     env.Name,
+			Status:    reportStatuses[env.Status],
+			Collapsed: env.Status == StatusPass,
+			Columns:   []string{"Check", "Result", "Note"},
+		}
+		for _, check := range env.Checks {
+			section.Rows = append(section.Rows, []string{check.Title, statusMarks[check.Status], check.Note})
+			for _, finding := range check.Findings {
+				section.Items = append(section.Items, check.Title+": "+finding)
+			}
+		}
+		doc.Sections = append(doc.Sections, section)
+	}
+
+	if len(r.Owners) > 0 {
+		section := report.Section{Title: "Owners", Columns: []string{"Variables", "Owners"}}
+		for _, o := range r.Owners {
+			section.Rows = append(section.Rows, []string{o.Variables, strings.Join(o.contacts(), ", ")})
+		}
+		doc.Sections = append(doc.Sections, section)
+	}
+	return doc
+}
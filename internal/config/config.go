@@ -2,23 +2,75 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
+// validNamespace matches namespaces that are valid in parameter names
+var validNamespace = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
 // Config represents the envy configuration
 type Config struct {
 	Project            string                 `mapstructure:"project"`
 	DefaultEnvironment string                 `mapstructure:"default_environment"`
 	AWS                AWSConfig              `mapstructure:"aws"`
+	Azure              *AzureConfig           `mapstructure:"azure"`
 	Cache              CacheConfig            `mapstructure:"cache"`
 	Memory             MemoryConfig           `mapstructure:"memory"`
 	Performance        PerformanceConfig      `mapstructure:"performance"`
+	SensitivePatterns  []string               `mapstructure:"sensitive_patterns"`
+	Sensitivity        SensitivityConfig      `mapstructure:"sensitivity"`
+	Placeholders       PlaceholderConfig      `mapstructure:"placeholders"`
+	Agent              AgentConfig            `mapstructure:"agent"`
+	History            HistoryConfig          `mapstructure:"history"`
+	InlineEncryption   InlineEncryptionConfig `mapstructure:"inline_encryption"`
+	Variables          []VariableConfig       `mapstructure:"variables"`
 	Environments       map[string]Environment `mapstructure:"environments"`
+
+	// ValidateOnPush runs the validation rules before every push, as with
+	// push --validate, and before every other write to AWS
+	ValidateOnPush bool `mapstructure:"validate_on_push"`
+
+	// Namespace prefixes every key as "<namespace>.KEY" in AWS, so several
+	// applications can share a Parameter Store path or secret. Keys are
+	// prefixed on push and stripped on pull; keys of other namespaces are
+	// ignored.
+	Namespace string `mapstructure:"namespace"`
+
+	// EnforceWriters makes push and the other writing commands fail, rather
+	// than warn, when the caller is not an allowed writer of a variable
+	EnforceWriters bool `mapstructure:"enforce_writers"`
+
+	// ChangeTickets records pushes to production environments in a change
+	// management system such as Jira or ServiceNow
+	ChangeTickets *ChangeTicketConfig `mapstructure:"change_tickets"`
+
+	// Policies are rules every push is checked against before anything is
+	// written, e.g. that pushes to prod need --force and a change ticket
+	Policies []PolicyConfig `mapstructure:"policies"`
+
+	// Roster holds contact details of the owners of variables
+	Roster *RosterConfig `mapstructure:"roster"`
+
+	// SecretsManager sets how environments stored in Secrets Manager are
+	// laid out
+	SecretsManager SecretsManagerConfig `mapstructure:"secrets_manager"`
+
+	// Roles are named command sets. The role of a machine, from ENVY_ROLE,
+	// 'envy role use' or DefaultRole, limits which commands it shows and runs.
+	Roles       map[string]RoleConfig `mapstructure:"roles"`
+	DefaultRole string                `mapstructure:"default_role"`
+
+	// Commands holds default flag values per command, keyed by command path
+	// such as "push" or "replicate status". Flags given on the command line
+	// take precedence.
+	Commands map[string]map[string]interface{} `mapstructure:"commands"`
 }
 
 // AWSConfig represents AWS-specific configuration
@@ -26,6 +78,111 @@ type AWSConfig struct {
 	Service string `mapstructure:"service"` // parameter_store or secrets_manager
 	Region  string `mapstructure:"region"`
 	Profile string `mapstructure:"profile"`
+
+	// LargeValuesToSecretsManager stores values over the Parameter Store
+	// size limit in Secrets Manager, with a reference in the parameter
+	LargeValuesToSecretsManager bool `mapstructure:"large_values_to_secrets_manager"`
+
+	// OIDC exchanges the CI provider's OIDC token for role credentials, so
+	// CI needs no long-lived AWS secrets
+	OIDC *OIDCConfig `mapstructure:"oidc"`
+
+	// RoleARN is assumed with the profile's credentials before using AWS.
+	// ExternalID and MFASerial are passed to every role envy assumes,
+	// including the role_arn of environments; with MFASerial set, the code
+	// of the device is asked for once per run.
+	RoleARN    string `mapstructure:"role_arn"`
+	ExternalID string `mapstructure:"external_id"`
+	MFASerial  string `mapstructure:"mfa_serial"`
+}
+
+// OIDCConfig represents keyless authentication from CI with
+// AssumeRoleWithWebIdentity
+type OIDCConfig struct {
+	RoleARN     string `mapstructure:"role_arn"`
+	Provider    string `mapstructure:"provider"`     // auto, github, gitlab or circleci
+	Audience    string `mapstructure:"audience"`     // GitHub token audience, default sts.amazonaws.com
+	TokenEnv    string `mapstructure:"token_env"`    // variable holding the token, for GitLab id_tokens
+	SessionName string `mapstructure:"session_name"` // default envy-<provider>
+	Duration    string `mapstructure:"duration"`     // session duration like "1h"
+}
+
+// ChangeTicketConfig opens a change ticket for each push to a ticketed
+// environment, or adds the change to the ticket given with push --ticket.
+// With RequireTicket, pushes to them need --ticket.
+type ChangeTicketConfig struct {
+	Environments  []string       `mapstructure:"environments"` // default: the protected environments
+	RequireTicket bool           `mapstructure:"require_ticket"`
+	Create        *TicketRequest `mapstructure:"create"` // for pushes without --ticket
+	Update        *TicketRequest `mapstructure:"update"` // for pushes with --ticket
+}
+
+// TicketRequest is an HTTP request to the ticket system. URL, header values
+// and Body are Go templates of the change; ${VAR} in the URL and header
+// values is replaced from the environment, so tokens stay out of .envyrc.
+type TicketRequest struct {
+	Method  string            `mapstructure:"method"` // default POST
+	URL     string            `mapstructure:"url"`
+	Headers map[string]string `mapstructure:"headers"`
+	Body    string            `mapstructure:"body"`
+	IDPath  string            `mapstructure:"id_path"` // dotted path of the ticket ID in the JSON response, e.g. "key" or "result.number"
+}
+
+// PolicyConfig is a rule for pushes to its environments. A push breaking
+// it is refused, or only warned about with action: warn.
+type PolicyConfig struct {
+	Name         string   `mapstructure:"name" yaml:"name,omitempty"`
+	Environments []string `mapstructure:"environments" yaml:"environments,omitempty"` // default: every environment
+	Action       string   `mapstructure:"action" yaml:"action,omitempty"`             // deny (default) or warn
+
+	RequireForce bool     `mapstructure:"require_force" yaml:"require_force,omitempty"` // the push needs --force
+	RequireEnv   []string `mapstructure:"require_env" yaml:"require_env,omitempty"`     // variables the pushing shell must set, e.g. CHANGE_TICKET
+
+	// PlaintextKeys (keys or patterns) and PlaintextValues (regular
+	// expressions) match variables that must not be stored unencrypted,
+	// such as String parameters
+	PlaintextKeys   []string `mapstructure:"plaintext_keys" yaml:"plaintext_keys,omitempty"`
+	PlaintextValues []string `mapstructure:"plaintext_values" yaml:"plaintext_values,omitempty"`
+}
+
+// RosterConfig is where the team roster is read from: a YAML file, or an
+// HTTP endpoint returning the same document as YAML or JSON
+type RosterConfig struct {
+	File    string            `mapstructure:"file"`
+	URL     string            `mapstructure:"url"`
+	Headers map[string]string `mapstructure:"headers"` // ${VAR} is replaced from the environment
+}
+
+// RoleConfig lists the commands a role may run by command path, such as
+// "pull" or "replicate status". A parent allows all its subcommands and
+// "*" allows every command.
+type RoleConfig struct {
+	Description string   `mapstructure:"description"`
+	Commands    []string `mapstructure:"commands"`
+}
+
+// Layouts of an environment in Secrets Manager
+const (
+	// SecretsManagerBundle stores every variable in one JSON secret
+	SecretsManagerBundle = "bundle"
+	// SecretsManagerPerVariable stores each variable as its own secret
+	// under the environment's path, so IAM can grant access per variable
+	SecretsManagerPerVariable = "per_variable"
+)
+
+// SecretsManagerConfig configures environments stored in Secrets Manager
+type SecretsManagerConfig struct {
+	Mode string `mapstructure:"mode"` // bundle (default) or per_variable
+}
+
+// AzureConfig stores the environments in Azure Key Vault instead of AWS.
+// Credentials come from AZURE_CLIENT_SECRET or a federated token with
+// AZURE_TENANT_ID and AZURE_CLIENT_ID, the Azure CLI login, or a managed
+// identity.
+type AzureConfig struct {
+	VaultURL string `mapstructure:"vault_url"` // e.g. https://myvault.vault.azure.net
+	TenantID string `mapstructure:"tenant_id"` // default AZURE_TENANT_ID
+	ClientID string `mapstructure:"client_id"` // default AZURE_CLIENT_ID; selects a user-assigned managed identity
 }
 
 // CacheConfig represents cache-specific configuration
@@ -61,11 +218,131 @@ type PerformanceConfig struct {
 	MaxLineSize      int  `mapstructure:"max_line_size"`
 }
 
+// SensitivityConfig overrides the built-in sensitive key detection.
+// Entries are keys or patterns (e.g. "STRIPE_*").
+type SensitivityConfig struct {
+	Sensitive []string `mapstructure:"sensitive"` // always treated as sensitive
+	Plain     []string `mapstructure:"plain"`     // never treated as sensitive
+}
+
+// PlaceholderConfig lists keys or patterns whose placeholder-like values
+// are intentional, so push does not block them
+type PlaceholderConfig struct {
+	Allow []string `mapstructure:"allow"`
+}
+
+// HistoryConfig configures the local journal of pushes used by envy undo
+type HistoryConfig struct {
+	Disabled bool   `mapstructure:"disabled"` // do not record pushes
+	Dir      string `mapstructure:"dir"`      // journal directory, default .envy/history
+	Keep     int    `mapstructure:"keep"`     // records kept per environment, default 50
+	KeyFile  string `mapstructure:"key_file"` // encryption key, generated on first use
+}
+
+// InlineEncryptionConfig configures the ENC[...] values envy seal writes
+// into committed .env files. Values are encrypted with the KMS key, else
+// for the age recipients, else with the local key file.
+type InlineEncryptionConfig struct {
+	KMSKeyID        string   `mapstructure:"kms_key_id"`        // key ID, ARN or alias, e.g. alias/app
+	AgeRecipients   []string `mapstructure:"age_recipients"`    // age1... public keys of everyone who decrypts
+	AgeIdentityFile string   `mapstructure:"age_identity_file"` // age-keygen key file; default ENVY_AGE_IDENTITY
+	KeyFile         string   `mapstructure:"key_file"`          // hex key from envy seal --generate-key; default ENVY_INLINE_KEY
+}
+
+// AgentConfig configures the envy agent
+type AgentConfig struct {
+	Socket  string         `mapstructure:"socket"`  // Unix socket path
+	Refresh string         `mapstructure:"refresh"` // duration string like "5m"
+	ACL     []AgentACLRule `mapstructure:"acl"`     // access rules; empty allows only the agent's own user
+}
+
+// AgentACLRule grants matching processes access to variables. Every
+// criterion that is set must match.
+type AgentACLRule struct {
+	Process      string   `mapstructure:"process"`      // executable path or glob
+	UIDs         []int    `mapstructure:"uids"`         // allowed user IDs
+	TokenEnv     string   `mapstructure:"token_env"`    // environment variable holding a bearer token
+	Environments []string `mapstructure:"environments"` // empty allows all
+	Keys         []string `mapstructure:"keys"`         // keys or patterns; empty allows all
+}
+
+// VariableConfig annotates variables matching Key, which may be a key or a
+// pattern (e.g. "AWS_SESSION_*"). It is a list rather than a map because
+// configuration map keys are case-insensitive.
+type VariableConfig struct {
+	Key     string `mapstructure:"key" yaml:"key"`
+	Refresh string `mapstructure:"refresh" yaml:"refresh,omitempty"` // re-fetch interval in run --watch and agent, like "5m"
+
+	// Owners are the teams or people accountable for the variables, shown
+	// by list. AllowedWriters are the AWS principals (role or user names,
+	// ARNs or ARN patterns) that may push them.
+	Owners         []string `mapstructure:"owners" yaml:"owners,omitempty"`
+	AllowedWriters []string `mapstructure:"allowed_writers" yaml:"allowed_writers,omitempty"`
+
+	// Rotate is how envy rotate generates new values for the variables
+	Rotate *RotateConfig `mapstructure:"rotate" yaml:"rotate,omitempty"`
+}
+
+// RotateConfig describes the values envy rotate generates: Length random
+// characters from Charset, or a random match of the regular expression
+// Pattern, e.g. "sk_live_[A-Za-z0-9]{32}".
+type RotateConfig struct {
+	Length  int    `mapstructure:"length" yaml:"length,omitempty"`   // default 32
+	Charset string `mapstructure:"charset" yaml:"charset,omitempty"` // alnum (default), hex, base64url, digits, ascii
+	Pattern string `mapstructure:"pattern" yaml:"pattern,omitempty"`
+}
+
 // Environment represents an environment configuration
 type Environment struct {
 	Files             []string `mapstructure:"files"`
 	Path              string   `mapstructure:"path"`
 	UseSecretsManager bool     `mapstructure:"use_secrets_manager"`
+	FallbackRegions   []string `mapstructure:"fallback_regions"` // regions read from when the primary is down or slow
+	RegionTimeout     string   `mapstructure:"region_timeout"`   // duration string like "5s"; per-region read timeout with fallbacks
+	Protected         bool     `mapstructure:"protected"`        // push refuses placeholder values
+
+	// Region, Profile and RoleARN override aws.region and aws.profile, and
+	// assume a role, for this environment, so it can live in another
+	// account or region than the others
+	Region  string `mapstructure:"region"`
+	Profile string `mapstructure:"profile"`
+	RoleARN string `mapstructure:"role_arn"`
+
+	// CacheSoftTTL, when set, serves cached values without asking AWS until
+	// they are this old, then serves them while refreshing in the
+	// background until CacheHardTTL, after which reads wait for AWS
+	CacheSoftTTL string `mapstructure:"cache_soft_ttl"`
+	CacheHardTTL string `mapstructure:"cache_hard_ttl"`
+
+	// KMSKeyID encrypts the environment's SecureString parameters instead of
+	// the account default key. Tags ("Key=Value") are added to the
+	// parameters envy creates. envy diff --metadata reports parameters that
+	// differ from both.
+	KMSKeyID string   `mapstructure:"kms_key_id"`
+	Tags     []string `mapstructure:"tags"`
+}
+
+// OwnAccount reports whether the environment overrides the region,
+// profile or role of aws
+func (e Environment) OwnAccount() bool {
+	return e.Region != "" || e.Profile != "" || e.RoleARN != ""
+}
+
+// ParameterTags returns the environment's tags as a map
+func (e Environment) ParameterTags() (map[string]string, error) {
+	if len(e.Tags) == 0 {
+		return nil, nil
+	}
+	tags := make(map[string]string, len(e.Tags))
+	for _, tag := range e.Tags {
+		key, value, ok := strings.Cut(tag, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid tag %q (use Key=Value)", tag)
+		}
+		tags[key] = strings.TrimSpace(value)
+	}
+	return tags, nil
 }
 
 // DefaultConfig returns the default configuration
@@ -114,6 +391,31 @@ func DefaultConfig() *Config {
 	}
 }
 
+// FindFile returns the absolute path of the configuration file Load reads:
+// configFile if given, otherwise the nearest .envyrc in the current
+// directory or its parents. It returns os.ErrNotExist when there is none.
+func FindFile(configFile string) (string, error) {
+	if configFile != "" {
+		return filepath.Abs(configFile)
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	for {
+		path := filepath.Join(dir, ".envyrc")
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", os.ErrNotExist
+		}
+		dir = parent
+	}
+}
+
 // Load loads the configuration from file
 func Load(configFile string) (*Config, error) {
 	v := viper.New()
@@ -178,29 +480,10 @@ func Load(configFile string) (*Config, error) {
 		cfg.Environments = make(map[string]Environment)
 		for key, value := range envMap {
 			if envConfig, ok := value.(map[string]interface{}); ok {
-				env := Environment{}
-
 				// Check if this is a properly formed environment config
-				if files, hasFiles := envConfig["files"]; hasFiles {
+				if _, hasFiles := envConfig["files"]; hasFiles {
 					// This is a complete environment configuration
-					if fileList, ok := files.([]interface{}); ok {
-						env.Files = make([]string, 0, len(fileList))
-						for _, f := range fileList {
-							if str, ok := f.(string); ok {
-								env.Files = append(env.Files, str)
-							}
-						}
-					}
-
-					if path, ok := envConfig["path"].(string); ok {
-						env.Path = path
-					}
-
-					if useSecretsManager, ok := envConfig["use_secrets_manager"].(bool); ok {
-						env.UseSecretsManager = useSecretsManager
-					}
-
-					cfg.Environments[key] = env
+					cfg.Environments[key] = parseEnvironment(envConfig)
 				} else {
 					// This might be a nested structure due to dots in the name
 					// We need to check for nested environments
@@ -209,26 +492,7 @@ func Load(configFile string) (*Config, error) {
 							if _, hasFiles := nestedEnvConfig["files"]; hasFiles {
 								// This is an environment with a dotted name
 								fullKey := key + "." + nestedKey
-								env := Environment{}
-
-								if files, ok := nestedEnvConfig["files"].([]interface{}); ok {
-									env.Files = make([]string, 0, len(files))
-									for _, f := range files {
-										if str, ok := f.(string); ok {
-											env.Files = append(env.Files, str)
-										}
-									}
-								}
-
-								if path, ok := nestedEnvConfig["path"].(string); ok {
-									env.Path = path
-								}
-
-								if useSecretsManager, ok := nestedEnvConfig["use_secrets_manager"].(bool); ok {
-									env.UseSecretsManager = useSecretsManager
-								}
-
-								cfg.Environments[fullKey] = env
+								cfg.Environments[fullKey] = parseEnvironment(nestedEnvConfig)
 							}
 						}
 					}
@@ -237,9 +501,81 @@ func Load(configFile string) (*Config, error) {
 		}
 	}
 
+	// Report malformed durations and sizes now rather than on first use
+	if err := cfg.validateUnits(); err != nil {
+		return nil, fmt.Errorf("invalid configuration in %s: %w", v.ConfigFileUsed(), err)
+	}
+
 	return cfg, nil
 }
 
+// parseEnvironment builds an environment from its raw configuration map
+func parseEnvironment(envConfig map[string]interface{}) Environment {
+	env := Environment{
+		Files:           stringList(envConfig["files"]),
+		FallbackRegions: stringList(envConfig["fallback_regions"]),
+	}
+
+	if path, ok := envConfig["path"].(string); ok {
+		env.Path = path
+	}
+
+	if useSecretsManager, ok := envConfig["use_secrets_manager"].(bool); ok {
+		env.UseSecretsManager = useSecretsManager
+	}
+
+	if timeout, ok := envConfig["region_timeout"].(string); ok {
+		env.RegionTimeout = timeout
+	}
+
+	if protected, ok := envConfig["protected"].(bool); ok {
+		env.Protected = protected
+	}
+
+	if ttl, ok := envConfig["cache_soft_ttl"].(string); ok {
+		env.CacheSoftTTL = ttl
+	}
+
+	if ttl, ok := envConfig["cache_hard_ttl"].(string); ok {
+		env.CacheHardTTL = ttl
+	}
+
+	if region, ok := envConfig["region"].(string); ok {
+		env.Region = region
+	}
+
+	if profile, ok := envConfig["profile"].(string); ok {
+		env.Profile = profile
+	}
+
+	if roleARN, ok := envConfig["role_arn"].(string); ok {
+		env.RoleARN = roleARN
+	}
+
+	if keyID, ok := envConfig["kms_key_id"].(string); ok {
+		env.KMSKeyID = keyID
+	}
+	env.Tags = stringList(envConfig["tags"])
+
+	return env
+}
+
+// stringList returns the strings in a raw YAML list, or nil if value is not a list
+func stringList(value interface{}) []string {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		if str, ok := item.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
+}
+
 // Save saves the configuration to file
 func (c *Config) Save(filename string) error {
 	if filename == "" {
@@ -255,6 +591,58 @@ func (c *Config) Save(filename string) error {
 	v.Set("cache", c.Cache)
 	v.Set("memory", c.Memory)
 	v.Set("performance", c.Performance)
+	if len(c.SensitivePatterns) > 0 {
+		v.Set("sensitive_patterns", c.SensitivePatterns)
+	}
+	if len(c.Sensitivity.Sensitive) > 0 || len(c.Sensitivity.Plain) > 0 {
+		v.Set("sensitivity", map[string]interface{}{
+			"sensitive": c.Sensitivity.Sensitive,
+			"plain":     c.Sensitivity.Plain,
+		})
+	}
+	if len(c.Placeholders.Allow) > 0 {
+		v.Set("placeholders", map[string]interface{}{"allow": c.Placeholders.Allow})
+	}
+	if inline := c.InlineEncryption; inline.KMSKeyID != "" || len(inline.AgeRecipients) > 0 || inline.AgeIdentityFile != "" || inline.KeyFile != "" {
+		v.Set("inline_encryption", map[string]interface{}{
+			"kms_key_id":        inline.KMSKeyID,
+			"age_recipients":    inline.AgeRecipients,
+			"age_identity_file": inline.AgeIdentityFile,
+			"key_file":          inline.KeyFile,
+		})
+	}
+	if c.Agent.Socket != "" || c.Agent.Refresh != "" || len(c.Agent.ACL) > 0 {
+		v.Set("agent", c.Agent)
+	}
+	if c.History != (HistoryConfig{}) {
+		v.Set("history", map[string]interface{}{
+			"disabled": c.History.Disabled,
+			"dir":      c.History.Dir,
+			"keep":     c.History.Keep,
+			"key_file": c.History.KeyFile,
+		})
+	}
+	if len(c.Variables) > 0 {
+		v.Set("variables", c.Variables)
+	}
+	if len(c.Policies) > 0 {
+		v.Set("policies", c.Policies)
+	}
+	if c.ValidateOnPush {
+		v.Set("validate_on_push", true)
+	}
+	if c.EnforceWriters {
+		v.Set("enforce_writers", true)
+	}
+	if c.Namespace != "" {
+		v.Set("namespace", c.Namespace)
+	}
+	if c.SecretsManager.Mode != "" {
+		v.Set("secrets_manager", map[string]interface{}{"mode": c.SecretsManager.Mode})
+	}
+	if len(c.Commands) > 0 {
+		v.Set("commands", c.Commands)
+	}
 	v.Set("environments", c.Environments)
 
 	// WriteConfigAs requires the file extension to determine the type
@@ -285,6 +673,11 @@ func (c *Config) GetEnvironment(name string) (*Environment, error) {
 	return &env, nil
 }
 
+// UsesAzure reports whether the environments are stored in Azure Key Vault
+func (c *Config) UsesAzure() bool {
+	return c.Azure != nil && c.Azure.VaultURL != ""
+}
+
 // GetAWSService returns the AWS service to use for the given environment
 func (c *Config) GetAWSService(envName string) string {
 	env, err := c.GetEnvironment(envName)
@@ -299,6 +692,62 @@ func (c *Config) GetAWSService(envName string) string {
 	return c.AWS.Service
 }
 
+// GetRegion returns the AWS region of an environment: its own region, or
+// aws.region
+func (c *Config) GetRegion(envName string) string {
+	if env, err := c.GetEnvironment(envName); err == nil && env.Region != "" {
+		return env.Region
+	}
+	return c.AWS.Region
+}
+
+// assumesRole reports whether any role is assumed with the profile's
+// credentials
+func (c *Config) assumesRole() bool {
+	if c.AWS.RoleARN != "" {
+		return true
+	}
+	for _, env := range c.Environments {
+		if env.RoleARN != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRoleARN returns the role assumed for an environment: its own role_arn,
+// or aws.role_arn
+func (c *Config) GetRoleARN(envName string) string {
+	if env, err := c.GetEnvironment(envName); err == nil && env.RoleARN != "" {
+		return env.RoleARN
+	}
+	return c.AWS.RoleARN
+}
+
+// ChangeTicketed reports whether pushes to envName are recorded in change
+// tickets
+func (c *Config) ChangeTicketed(envName string) bool {
+	if c.ChangeTickets == nil {
+		return false
+	}
+	if len(c.ChangeTickets.Environments) == 0 {
+		env, ok := c.Environments[envName]
+		return ok && env.Protected
+	}
+	for _, name := range c.ChangeTickets.Environments {
+		if name == envName {
+			return true
+		}
+	}
+	return false
+}
+
+// SecretPerVariable reports whether Secrets Manager environments store each
+// variable as its own secret
+func (c *Config) SecretPerVariable() bool {
+	return c.SecretsManager.Mode == SecretsManagerPerVariable
+}
+
 // GetParameterPath returns the AWS parameter path for the given environment
 func (c *Config) GetParameterPath(envName string) string {
 	env, err := c.GetEnvironment(envName)
@@ -388,6 +837,79 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("aws.service must be either 'parameter_store' or 'secrets_manager'")
 	}
 
+	if oidc := c.AWS.OIDC; oidc != nil {
+		if oidc.RoleARN == "" {
+			return fmt.Errorf("aws.oidc.role_arn is required")
+		}
+		switch oidc.Provider {
+		case "", "auto", "github", "gitlab", "circleci":
+		default:
+			return fmt.Errorf("aws.oidc.provider must be one of auto, github, gitlab or circleci")
+		}
+	}
+
+	if c.AWS.RoleARN != "" && !strings.HasPrefix(c.AWS.RoleARN, "arn:") {
+		return fmt.Errorf("aws.role_arn must be an IAM role ARN")
+	}
+	if (c.AWS.ExternalID != "" || c.AWS.MFASerial != "") && !c.assumesRole() {
+		return fmt.Errorf("aws.external_id and aws.mfa_serial need aws.role_arn or the role_arn of an environment")
+	}
+
+	if azure := c.Azure; azure != nil {
+		u, err := url.Parse(azure.VaultURL)
+		if azure.VaultURL == "" || err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("azure.vault_url must be the vault's https URL, e.g. https://myvault.vault.azure.net")
+		}
+	}
+
+	if tickets := c.ChangeTickets; tickets != nil {
+		for _, name := range tickets.Environments {
+			if _, ok := c.Environments[name]; !ok {
+				return fmt.Errorf("change_tickets.environments: environment '%s' not found", name)
+			}
+		}
+		if tickets.Create != nil && tickets.Create.URL == "" {
+			return fmt.Errorf("change_tickets.create.url is required")
+		}
+		if tickets.Update != nil && tickets.Update.URL == "" {
+			return fmt.Errorf("change_tickets.update.url is required")
+		}
+	}
+
+	for i, policy := range c.Policies {
+		for _, name := range policy.Environments {
+			if _, ok := c.Environments[name]; !ok {
+				return fmt.Errorf("policies[%d].environments: environment '%s' not found", i, name)
+			}
+		}
+		if policy.Action != "" && policy.Action != "deny" && policy.Action != "warn" {
+			return fmt.Errorf("policies[%d].action must be either 'deny' or 'warn'", i)
+		}
+	}
+
+	if roster := c.Roster; roster != nil && (roster.File == "") == (roster.URL == "") {
+		return fmt.Errorf("roster needs either file or url")
+	}
+
+	for name, role := range c.Roles {
+		if len(role.Commands) == 0 {
+			return fmt.Errorf("roles.%s.commands must list at least one command", name)
+		}
+	}
+	if _, ok := c.Roles[c.DefaultRole]; c.DefaultRole != "" && !ok {
+		return fmt.Errorf("default_role: role '%s' not found in roles", c.DefaultRole)
+	}
+
+	switch c.SecretsManager.Mode {
+	case "", SecretsManagerBundle, SecretsManagerPerVariable:
+	default:
+		return fmt.Errorf("secrets_manager.mode must be either '%s' or '%s'", SecretsManagerBundle, SecretsManagerPerVariable)
+	}
+
+	if c.Namespace != "" && !validNamespace.MatchString(c.Namespace) {
+		return fmt.Errorf("namespace must contain only letters, digits, '_' and '-'")
+	}
+
 	if len(c.Environments) == 0 {
 		return fmt.Errorf("at least one environment must be defined")
 	}
@@ -399,6 +921,18 @@ func (c *Config) Validate() error {
 		if env.Path == "" {
 			return fmt.Errorf("environment '%s' must have a path", name)
 		}
+		for _, region := range env.FallbackRegions {
+			if region == "" || region == c.GetRegion(name) {
+				return fmt.Errorf("environment '%s' fallback_regions must not be empty or repeat its region", name)
+			}
+		}
+		if env.RoleARN != "" && !strings.HasPrefix(env.RoleARN, "arn:") {
+			return fmt.Errorf("environment '%s' role_arn must be an IAM role ARN", name)
+		}
+	}
+
+	if err := c.validateUnits(); err != nil {
+		return err
 	}
 
 	// Validate memory configuration
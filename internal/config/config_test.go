@@ -96,6 +96,197 @@ func TestLoad(t *testing.T) {
 		assert.True(t, prodEnv.UseSecretsManager)
 	})
 
+	t.Run("sensitivity_overrides", func(t *testing.T) {
+		configContent := `project: myapp
+default_environment: dev
+
+sensitivity:
+  sensitive:
+    - SESSION_SALT
+  plain:
+    - "*_PUBLIC_KEY"
+
+environments:
+  dev:
+    files:
+      - .env.dev
+`
+		configPath := helper.CreateTempFile(".envyrc", configContent)
+
+		cfg, err := config.Load(configPath)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"SESSION_SALT"}, cfg.Sensitivity.Sensitive)
+		assert.Equal(t, []string{"*_PUBLIC_KEY"}, cfg.Sensitivity.Plain)
+	})
+
+	t.Run("agent_acl", func(t *testing.T) {
+		configContent := `project: myapp
+default_environment: dev
+
+agent:
+  socket: /run/envy/agent.sock
+  refresh: 5m
+  acl:
+    - process: /usr/local/bin/api
+      uids: [1000]
+      environments: [prod]
+      keys: ["DB_*"]
+
+environments:
+  dev:
+    files:
+      - .env.dev
+`
+		configPath := helper.CreateTempFile(".envyrc", configContent)
+
+		cfg, err := config.Load(configPath)
+
+		require.NoError(t, err)
+		assert.Equal(t, "/run/envy/agent.sock", cfg.Agent.Socket)
+		assert.Equal(t, "5m", cfg.Agent.Refresh)
+		require.Len(t, cfg.Agent.ACL, 1)
+		assert.Equal(t, "/usr/local/bin/api", cfg.Agent.ACL[0].Process)
+		assert.Equal(t, []int{1000}, cfg.Agent.ACL[0].UIDs)
+		assert.Equal(t, []string{"prod"}, cfg.Agent.ACL[0].Environments)
+		assert.Equal(t, []string{"DB_*"}, cfg.Agent.ACL[0].Keys)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		configContent := `project: myapp
+default_environment: dev
+
+history:
+  dir: /var/lib/envy/history
+  keep: 10
+  key_file: /etc/envy/history.key
+
+environments:
+  dev:
+    files:
+      - .env.dev
+`
+		configPath := helper.CreateTempFile(".envyrc", configContent)
+
+		cfg, err := config.Load(configPath)
+
+		require.NoError(t, err)
+		assert.False(t, cfg.History.Disabled)
+		assert.Equal(t, "/var/lib/envy/history", cfg.History.Dir)
+		assert.Equal(t, 10, cfg.History.Keep)
+		assert.Equal(t, "/etc/envy/history.key", cfg.History.KeyFile)
+	})
+
+	t.Run("variable_annotations", func(t *testing.T) {
+		configContent := `project: myapp
+default_environment: dev
+
+variables:
+  - key: DB_AUTH_TOKEN
+    refresh: 5m
+  - key: "AWS_SESSION_*"
+    refresh: 15m
+  - key: "STRIPE_*"
+    owners: payments
+    allowed_writers: [payments-deploy, "arn:aws:iam::*:role/admin"]
+enforce_writers: true
+
+environments:
+  dev:
+    files:
+      - .env.dev
+`
+		configPath := helper.CreateTempFile(".envyrc", configContent)
+
+		cfg, err := config.Load(configPath)
+
+		require.NoError(t, err)
+		require.Len(t, cfg.Variables, 3)
+		assert.Equal(t, "DB_AUTH_TOKEN", cfg.Variables[0].Key)
+		assert.Equal(t, "5m", cfg.Variables[0].Refresh)
+		assert.Equal(t, "AWS_SESSION_*", cfg.Variables[1].Key)
+		assert.Equal(t, []string{"payments"}, cfg.Variables[2].Owners)
+		assert.Equal(t, []string{"payments-deploy", "arn:aws:iam::*:role/admin"}, cfg.Variables[2].AllowedWriters)
+		assert.True(t, cfg.EnforceWriters)
+	})
+
+	t.Run("fallback_regions", func(t *testing.T) {
+		configContent := `project: myapp
+default_environment: prod
+
+aws:
+  region: us-east-1
+
+environments:
+  prod:
+    files:
+      - .env.prod
+    path: /myapp/prod/
+    fallback_regions:
+      - us-west-2
+      - eu-west-1
+    region_timeout: 3s
+    protected: true
+    cache_soft_ttl: 5m
+    cache_hard_ttl: 1h
+    kms_key_id: alias/prod-secrets
+    tags: ["Team=payments", "CostCenter=42"]
+  prod.eu:
+    files:
+      - .env.prod.eu
+    fallback_regions: [eu-central-1]
+    region: eu-west-1
+    profile: prod
+    role_arn: arn:aws:iam::210987654321:role/envy
+`
+		configPath := helper.CreateTempFile(".envyrc", configContent)
+
+		cfg, err := config.Load(configPath)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"us-west-2", "eu-west-1"}, cfg.Environments["prod"].FallbackRegions)
+		assert.Equal(t, "3s", cfg.Environments["prod"].RegionTimeout)
+		assert.True(t, cfg.Environments["prod"].Protected)
+		assert.Equal(t, "5m", cfg.Environments["prod"].CacheSoftTTL)
+		assert.Equal(t, "1h", cfg.Environments["prod"].CacheHardTTL)
+		assert.Equal(t, "alias/prod-secrets", cfg.Environments["prod"].KMSKeyID)
+		tags, err := cfg.Environments["prod"].ParameterTags()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"Team": "payments", "CostCenter": "42"}, tags)
+		assert.False(t, cfg.Environments["prod.eu"].Protected)
+		assert.Equal(t, []string{"eu-central-1"}, cfg.Environments["prod.eu"].FallbackRegions)
+		assert.Equal(t, "eu-west-1", cfg.GetRegion("prod.eu"))
+		assert.Equal(t, "us-east-1", cfg.GetRegion("prod"))
+		assert.Equal(t, "arn:aws:iam::210987654321:role/envy", cfg.Environments["prod.eu"].RoleARN)
+		assert.True(t, cfg.Environments["prod.eu"].OwnAccount())
+		assert.False(t, cfg.Environments["prod"].OwnAccount())
+	})
+
+	t.Run("invalid_units", func(t *testing.T) {
+		configContent := `project: myapp
+default_environment: dev
+
+aws:
+  region: us-east-1
+
+cache:
+  ttl: 1 hour
+
+environments:
+  dev:
+    files:
+      - .env.dev
+    path: /myapp/dev/
+`
+		configPath := helper.CreateTempFile(".envyrc", configContent)
+
+		_, err := config.Load(configPath)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cache.ttl")
+		assert.Contains(t, err.Error(), configPath)
+	})
+
 	t.Run("environments_with_dots", func(t *testing.T) {
 		// Create config file with dotted environment names
 		configContent := `project: myapp
@@ -603,6 +794,272 @@ func TestConfig_Validate(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("invalid_fallback_regions", func(t *testing.T) {
+		newConfig := func(env config.Environment) *config.Config {
+			env.Files = []string{".env.prod"}
+			env.Path = "/myapp/prod/"
+			return &config.Config{
+				Project:            "myapp",
+				DefaultEnvironment: "prod",
+				AWS: config.AWSConfig{
+					Service: "parameter_store",
+					Region:  "us-east-1",
+				},
+				Environments: map[string]config.Environment{"prod": env},
+			}
+		}
+
+		assert.NoError(t, newConfig(config.Environment{FallbackRegions: []string{"us-west-2"}, RegionTimeout: "2s"}).Validate())
+
+		err := newConfig(config.Environment{FallbackRegions: []string{"us-east-1"}}).Validate()
+		assert.ErrorContains(t, err, "fallback_regions")
+
+		err = newConfig(config.Environment{FallbackRegions: []string{"us-west-2"}, RegionTimeout: "soon"}).Validate()
+		assert.ErrorContains(t, err, "region_timeout")
+
+		assert.NoError(t, newConfig(config.Environment{Region: "eu-west-1", FallbackRegions: []string{"us-east-1"}}).Validate())
+		err = newConfig(config.Environment{Region: "eu-west-1", FallbackRegions: []string{"eu-west-1"}}).Validate()
+		assert.ErrorContains(t, err, "fallback_regions")
+	})
+
+	t.Run("invalid_role_arn", func(t *testing.T) {
+		cfg := &config.Config{
+			Project:            "myapp",
+			DefaultEnvironment: "prod",
+			AWS: config.AWSConfig{
+				Service: "parameter_store",
+				Region:  "us-east-1",
+			},
+			Environments: map[string]config.Environment{
+				"prod": {Files: []string{".env.prod"}, Path: "/myapp/prod/", RoleARN: "envy-prod"},
+			},
+		}
+		assert.ErrorContains(t, cfg.Validate(), "role_arn")
+	})
+
+	t.Run("namespace", func(t *testing.T) {
+		cfg := &config.Config{
+			Project:            "myapp",
+			DefaultEnvironment: "dev",
+			Namespace:          "billing-service",
+			AWS: config.AWSConfig{
+				Service: "parameter_store",
+				Region:  "us-east-1",
+			},
+			Environments: map[string]config.Environment{
+				"dev": {Files: []string{".env.dev"}, Path: "/shared/dev/"},
+			},
+		}
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Namespace = "billing/service"
+		assert.ErrorContains(t, cfg.Validate(), "namespace")
+	})
+
+	t.Run("cache_ttls", func(t *testing.T) {
+		newConfig := func(env config.Environment) *config.Config {
+			env.Files = []string{".env.dev"}
+			env.Path = "/myapp/dev/"
+			return &config.Config{
+				Project:            "myapp",
+				DefaultEnvironment: "dev",
+				AWS: config.AWSConfig{
+					Service: "parameter_store",
+					Region:  "us-east-1",
+				},
+				Environments: map[string]config.Environment{"dev": env},
+			}
+		}
+
+		assert.NoError(t, newConfig(config.Environment{CacheSoftTTL: "5m", CacheHardTTL: "1h"}).Validate())
+		assert.NoError(t, newConfig(config.Environment{CacheSoftTTL: "5m"}).Validate())
+
+		err := newConfig(config.Environment{CacheSoftTTL: "soon"}).Validate()
+		assert.ErrorContains(t, err, "cache_soft_ttl")
+
+		err = newConfig(config.Environment{CacheHardTTL: "1h"}).Validate()
+		assert.ErrorContains(t, err, "requires cache_soft_ttl")
+
+		err = newConfig(config.Environment{CacheSoftTTL: "2h", CacheHardTTL: "1h"}).Validate()
+		assert.ErrorContains(t, err, "exceeds cache_hard_ttl")
+	})
+
+	t.Run("azure", func(t *testing.T) {
+		newConfig := func(azure *config.AzureConfig) *config.Config {
+			return &config.Config{
+				Project:            "myapp",
+				DefaultEnvironment: "dev",
+				AWS: config.AWSConfig{
+					Service: "parameter_store",
+					Region:  "us-east-1",
+				},
+				Azure: azure,
+				Environments: map[string]config.Environment{
+					"dev": {Files: []string{".env.dev"}, Path: "/myapp/dev/"},
+				},
+			}
+		}
+
+		cfg := newConfig(&config.AzureConfig{VaultURL: "https://myvault.vault.azure.net"})
+		assert.NoError(t, cfg.Validate())
+		assert.True(t, cfg.UsesAzure())
+		assert.False(t, newConfig(nil).UsesAzure())
+
+		for _, vaultURL := range []string{"", "myvault.vault.azure.net", "http://myvault.vault.azure.net", "https://"} {
+			err := newConfig(&config.AzureConfig{VaultURL: vaultURL}).Validate()
+			assert.ErrorContains(t, err, "azure.vault_url", vaultURL)
+		}
+	})
+
+	t.Run("oidc", func(t *testing.T) {
+		newConfig := func(oidc *config.OIDCConfig) *config.Config {
+			return &config.Config{
+				Project:            "myapp",
+				DefaultEnvironment: "prod",
+				AWS: config.AWSConfig{
+					Service: "parameter_store",
+					Region:  "us-east-1",
+					OIDC:    oidc,
+				},
+				Environments: map[string]config.Environment{
+					"prod": {Files: []string{".env.prod"}, Path: "/myapp/prod/"},
+				},
+			}
+		}
+
+		assert.NoError(t, newConfig(&config.OIDCConfig{RoleARN: "arn:aws:iam::123456789012:role/envy", Provider: "github", Duration: "30m"}).Validate())
+		assert.ErrorContains(t, newConfig(&config.OIDCConfig{Provider: "github"}).Validate(), "role_arn")
+		assert.ErrorContains(t, newConfig(&config.OIDCConfig{RoleARN: "arn", Provider: "jenkins"}).Validate(), "aws.oidc.provider")
+		assert.ErrorContains(t, newConfig(&config.OIDCConfig{RoleARN: "arn", Duration: "soon"}).Validate(), "aws.oidc.duration")
+	})
+
+	t.Run("assume_role", func(t *testing.T) {
+		newConfig := func(aws config.AWSConfig, envRole string) *config.Config {
+			aws.Service, aws.Region = "parameter_store", "us-east-1"
+			return &config.Config{
+				Project:            "myapp",
+				DefaultEnvironment: "prod",
+				AWS:                aws,
+				Environments: map[string]config.Environment{
+					"prod": {Files: []string{".env.prod"}, Path: "/myapp/prod/", RoleARN: envRole},
+				},
+			}
+		}
+
+		assert.NoError(t, newConfig(config.AWSConfig{RoleARN: "arn:aws:iam::123456789012:role/envy", ExternalID: "acme", MFASerial: "arn:aws:iam::123456789012:mfa/alice"}, "").Validate())
+		assert.NoError(t, newConfig(config.AWSConfig{MFASerial: "arn:aws:iam::123456789012:mfa/alice"}, "arn:aws:iam::210987654321:role/envy").Validate())
+		assert.ErrorContains(t, newConfig(config.AWSConfig{RoleARN: "envy"}, "").Validate(), "aws.role_arn")
+		assert.ErrorContains(t, newConfig(config.AWSConfig{MFASerial: "arn:aws:iam::123456789012:mfa/alice"}, "").Validate(), "aws.mfa_serial")
+
+		cfg := newConfig(config.AWSConfig{RoleARN: "arn:aws:iam::123456789012:role/envy"}, "arn:aws:iam::210987654321:role/envy")
+		assert.Equal(t, "arn:aws:iam::210987654321:role/envy", cfg.GetRoleARN("prod"))
+		cfg.Environments["prod"] = config.Environment{Files: []string{".env.prod"}, Path: "/myapp/prod/"}
+		assert.Equal(t, "arn:aws:iam::123456789012:role/envy", cfg.GetRoleARN("prod"))
+	})
+
+	t.Run("change_tickets", func(t *testing.T) {
+		cfg := &config.Config{
+			Project:            "myapp",
+			DefaultEnvironment: "dev",
+			AWS:                config.AWSConfig{Service: "parameter_store", Region: "us-east-1"},
+			Environments: map[string]config.Environment{
+				"dev":  {Files: []string{".env.dev"}, Path: "/myapp/dev/"},
+				"prod": {Files: []string{".env.prod"}, Path: "/myapp/prod/", Protected: true},
+			},
+			ChangeTickets: &config.ChangeTicketConfig{RequireTicket: true},
+		}
+		assert.NoError(t, cfg.Validate())
+		assert.True(t, cfg.ChangeTicketed("prod"))
+		assert.False(t, cfg.ChangeTicketed("dev"))
+
+		cfg.ChangeTickets.Environments = []string{"dev"}
+		assert.True(t, cfg.ChangeTicketed("dev"))
+		assert.False(t, cfg.ChangeTicketed("prod"))
+
+		cfg.ChangeTickets.Environments = []string{"staging"}
+		assert.ErrorContains(t, cfg.Validate(), "change_tickets.environments")
+
+		cfg.ChangeTickets = &config.ChangeTicketConfig{Create: &config.TicketRequest{}}
+		assert.ErrorContains(t, cfg.Validate(), "change_tickets.create.url")
+	})
+
+	t.Run("policies", func(t *testing.T) {
+		cfg := &config.Config{
+			Project:            "myapp",
+			DefaultEnvironment: "dev",
+			AWS:                config.AWSConfig{Service: "parameter_store", Region: "us-east-1"},
+			Environments: map[string]config.Environment{
+				"dev": {Files: []string{".env.dev"}, Path: "/myapp/dev/"},
+			},
+			Policies: []config.PolicyConfig{{Name: "force", Environments: []string{"dev"}, RequireForce: true}},
+		}
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Policies[0].Environments = []string{"prod"}
+		assert.ErrorContains(t, cfg.Validate(), "policies[0].environments")
+
+		cfg.Policies[0] = config.PolicyConfig{Action: "block", RequireForce: true}
+		assert.ErrorContains(t, cfg.Validate(), "policies[0].action")
+	})
+
+	t.Run("roster", func(t *testing.T) {
+		cfg := &config.Config{
+			Project:            "myapp",
+			DefaultEnvironment: "dev",
+			AWS:                config.AWSConfig{Service: "parameter_store", Region: "us-east-1"},
+			Environments: map[string]config.Environment{
+				"dev": {Files: []string{".env.dev"}, Path: "/myapp/dev/"},
+			},
+			Roster: &config.RosterConfig{File: "teams.yaml"},
+		}
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Roster = &config.RosterConfig{}
+		assert.ErrorContains(t, cfg.Validate(), "roster")
+		cfg.Roster = &config.RosterConfig{File: "teams.yaml", URL: "https://people.acme.com/roster"}
+		assert.ErrorContains(t, cfg.Validate(), "roster")
+	})
+
+	t.Run("secrets_manager_mode", func(t *testing.T) {
+		cfg := &config.Config{
+			Project:            "myapp",
+			DefaultEnvironment: "dev",
+			AWS:                config.AWSConfig{Service: "secrets_manager", Region: "us-east-1"},
+			Environments: map[string]config.Environment{
+				"dev": {Files: []string{".env.dev"}, Path: "/myapp/dev/"},
+			},
+			SecretsManager: config.SecretsManagerConfig{Mode: config.SecretsManagerPerVariable},
+		}
+		assert.NoError(t, cfg.Validate())
+		assert.True(t, cfg.SecretPerVariable())
+
+		cfg.SecretsManager.Mode = "per-key"
+		assert.ErrorContains(t, cfg.Validate(), "secrets_manager.mode")
+	})
+
+	t.Run("roles", func(t *testing.T) {
+		cfg := &config.Config{
+			Project:            "myapp",
+			DefaultEnvironment: "dev",
+			AWS:                config.AWSConfig{Service: "parameter_store", Region: "us-east-1"},
+			Environments: map[string]config.Environment{
+				"dev": {Files: []string{".env.dev"}, Path: "/myapp/dev/"},
+			},
+			Roles: map[string]config.RoleConfig{
+				"developer": {Commands: []string{"pull", "run"}},
+			},
+			DefaultRole: "developer",
+		}
+		assert.NoError(t, cfg.Validate())
+
+		cfg.DefaultRole = "operator"
+		assert.ErrorContains(t, cfg.Validate(), "default_role")
+
+		cfg.DefaultRole = ""
+		cfg.Roles["operator"] = config.RoleConfig{}
+		assert.ErrorContains(t, cfg.Validate(), "roles.operator.commands")
+	})
+
 	t.Run("missing_project", func(t *testing.T) {
 		cfg := &config.Config{
 			Project:            "",
@@ -953,3 +1410,147 @@ func BenchmarkConfig_GetParameterPath(b *testing.B) {
 		_ = cfg.GetParameterPath("dev")
 	}
 }
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"30s", 30 * time.Second},
+		{"1h30m", 90 * time.Minute},
+		{"7d", 7 * 24 * time.Hour},
+		{"1d12h", 36 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{" 5m ", 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		got, err := config.ParseDuration(tt.input)
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, got, tt.input)
+	}
+
+	for _, input := range []string{"", "1 hour", "soon", "5days", "0s", "-1m"} {
+		_, err := config.ParseDuration(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"512", 512},
+		{"512B", 512},
+		{"64KB", 64 << 10},
+		{"100MB", 100 << 20},
+		{"100mb", 100 << 20},
+		{"1.5GiB", 3 << 29},
+		{"2 G", 2 << 30},
+		{"1TB", 1 << 40},
+	}
+	for _, tt := range tests {
+		got, err := config.ParseSize(tt.input)
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, got, tt.input)
+	}
+
+	_, err := config.ParseSize("100MBs")
+	assert.ErrorContains(t, err, "unknown unit")
+	_, err = config.ParseSize("lots")
+	assert.Error(t, err)
+
+	assert.Equal(t, "100MB", config.FormatSize(100<<20))
+	assert.Equal(t, "1536KB", config.FormatSize(3<<19))
+	assert.Equal(t, "1000B", config.FormatSize(1000))
+}
+
+func TestUnitAccessors(t *testing.T) {
+	cache := config.CacheConfig{TTL: "1d", MaxSize: "1GB"}
+	assert.Equal(t, 24*time.Hour, cache.TTLDuration())
+	assert.Equal(t, int64(1<<30), cache.MaxSizeBytes())
+
+	assert.Equal(t, config.DefaultAgentRefresh, config.AgentConfig{}.RefreshInterval())
+	assert.Equal(t, time.Minute, config.AgentConfig{Refresh: "1m"}.RefreshInterval())
+	assert.Equal(t, config.DefaultRegionTimeout, config.Environment{}.Timeout())
+
+	_, err := config.Environment{Tags: []string{"Team"}}.ParameterTags()
+	assert.ErrorContains(t, err, "Key=Value")
+
+	soft, hard := config.Environment{}.CacheTTLs()
+	assert.Zero(t, soft)
+	assert.Zero(t, hard)
+	soft, hard = config.Environment{CacheSoftTTL: "5m"}.CacheTTLs()
+	assert.Equal(t, 5*time.Minute, soft)
+	assert.Equal(t, config.DefaultCacheHardTTL, hard)
+	_, hard = config.Environment{CacheSoftTTL: "5m", CacheHardTTL: "2h"}.CacheTTLs()
+	assert.Equal(t, 2*time.Hour, hard)
+}
+
+func TestSetValues(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".envyrc")
+	require.NoError(t, os.WriteFile(filename, []byte(`# Project settings
+project: myapp
+environments:
+  dev:
+    files: [.env.dev]
+  prod:
+    files: [.env.prod] # production
+    path: /myapp/prod/
+`), 0600))
+
+	require.NoError(t, config.SetValues(filename, map[string]string{
+		"project":                "newapp",
+		"environments.prod.path": "/newapp/prod/",
+		"environments.dev.path":  "/newapp/dev/",
+	}))
+
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Project settings")
+	assert.Contains(t, string(data), "# production")
+
+	cfg, err := config.Load(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "newapp", cfg.Project)
+	assert.Equal(t, "/newapp/prod/", cfg.Environments["prod"].Path)
+	assert.Equal(t, "/newapp/dev/", cfg.Environments["dev"].Path)
+	assert.Equal(t, []string{".env.prod"}, cfg.Environments["prod"].Files)
+
+	assert.Error(t, config.SetValues(filename, map[string]string{"environments": "x"}), "not a scalar")
+
+	require.NoError(t, config.SetSettings(filename, map[string]interface{}{
+		"environments.prod.use_secrets_manager": true,
+		"aws.service":                           "parameter_store",
+	}))
+	data, err = os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "use_secrets_manager: true\n")
+	cfg, err = config.Load(filename)
+	require.NoError(t, err)
+	assert.True(t, cfg.Environments["prod"].UseSecretsManager)
+	assert.Equal(t, "parameter_store", cfg.AWS.Service)
+}
+
+func TestFindFile(t *testing.T) {
+	path, err := config.FindFile("testdata/.envyrc")
+	require.NoError(t, err)
+	assert.True(t, filepath.IsAbs(path))
+	assert.Equal(t, ".envyrc", filepath.Base(path))
+
+	// Without a path the nearest .envyrc above the current directory is used
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".envyrc"), []byte("project: myapp\n"), 0600))
+	sub := filepath.Join(root, "services", "api")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(wd)
+	require.NoError(t, os.Chdir(sub))
+
+	path, err = config.FindFile("")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, ".envyrc"), path)
+}
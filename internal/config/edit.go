@@ -0,0 +1,112 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SetValues updates scalar settings in a configuration file in place,
+// keeping its comments and the order of its keys. Keys are dotted paths
+// such as "project" or "environments.prod.path"; missing mappings along the
+// path are created.
+func SetValues(filename string, values map[string]string) error {
+	settings := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		settings[key] = value
+	}
+	return SetSettings(filename, settings)
+}
+
+// SetSettings is SetValues for settings of other types: strings and
+// booleans, such as "environments.prod.use_secrets_manager"
+func SetSettings(filename string, values map[string]interface{}) error {
+	if filename == "" {
+		filename = ".envyrc"
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a YAML mapping", filename)
+	}
+
+	for key, value := range values {
+		scalar, err := scalarNode(value)
+		if err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+		if err := setValue(doc.Content[0], strings.Split(key, "."), scalar); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, buf.Bytes(), info.Mode().Perm())
+}
+
+// scalarNode returns the YAML scalar of a setting value
+func scalarNode(value interface{}) (*yaml.Node, error) {
+	switch v := value.(type) {
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}, nil
+	}
+	return nil, fmt.Errorf("unsupported value %v", value)
+}
+
+// setValue sets the scalar at path below a mapping node
+func setValue(node *yaml.Node, path []string, value *yaml.Node) error {
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		if node.Content[i].Value != path[0] {
+			continue
+		}
+		child := node.Content[i+1]
+		if len(path) == 1 {
+			if child.Kind != yaml.ScalarNode {
+				return fmt.Errorf("%s is not a scalar", path[0])
+			}
+			child.Value = value.Value
+			child.Tag = value.Tag
+			return nil
+		}
+		if child.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s is not a mapping", path[0])
+		}
+		return setValue(child, path[1:], value)
+	}
+
+	// Not found: append the key, with mappings for the rest of the path
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[0]}
+	if len(path) == 1 {
+		node.Content = append(node.Content, key, value)
+		return nil
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	node.Content = append(node.Content, key, child)
+	return setValue(child, path[1:], value)
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/drapon/envy/internal/format"
+)
+
+const (
+	// DefaultAgentRefresh is used when neither --refresh nor agent.refresh is set
+	DefaultAgentRefresh = 5 * time.Minute
+
+	// DefaultRegionTimeout bounds each region's read when an environment has
+	// fallback regions but no region_timeout
+	DefaultRegionTimeout = 10 * time.Second
+
+	// DefaultCacheHardTTL is how long cached values may be served stale
+	// when an environment has cache_soft_ttl but no cache_hard_ttl
+	DefaultCacheHardTTL = 24 * time.Hour
+)
+
+// sizeUnits maps size units to bytes. Decimal and binary units are both
+// powers of 1024, as envy has always treated "MB".
+var sizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"K":   1 << 10,
+	"KB":  1 << 10,
+	"KIB": 1 << 10,
+	"M":   1 << 20,
+	"MB":  1 << 20,
+	"MIB": 1 << 20,
+	"G":   1 << 30,
+	"GB":  1 << 30,
+	"GIB": 1 << 30,
+	"T":   1 << 40,
+	"TB":  1 << 40,
+	"TIB": 1 << 40,
+}
+
+var sizePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([A-Za-z]*)$`)
+
+// ParseDuration parses a duration like "30s", "1h30m" or "7d". On top of
+// the units time.ParseDuration accepts, "d" (24h) and "w" (7d) are allowed.
+// Durations must be positive.
+func ParseDuration(s string) (time.Duration, error) {
+	d, err := format.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", s)
+	}
+	return d, nil
+}
+
+// ParseSize parses a size like "100MB", "1.5GiB" or "512K" into bytes.
+// Units are case-insensitive; a plain number is bytes.
+func ParseSize(s string) (int64, error) {
+	match := sizePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return 0, fmt.Errorf("invalid size %q (use a number and unit such as 512KB, 100MB or 1GB)", s)
+	}
+
+	unit, ok := sizeUnits[strings.ToUpper(match[2])]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q (use B, KB, MB, GB or TB)", s, match[2])
+	}
+
+	n, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+	if n*float64(unit) > math.MaxInt64 {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// FormatSize formats bytes with the largest unit that divides them, e.g.
+// 104857600 as "100MB"
+func FormatSize(bytes int64) string {
+	for _, unit := range []string{"TB", "GB", "MB", "KB"} {
+		size := sizeUnits[unit]
+		if bytes >= size && bytes%size == 0 {
+			return strconv.FormatInt(bytes/size, 10) + unit
+		}
+	}
+	return strconv.FormatInt(bytes, 10) + "B"
+}
+
+// TTLDuration returns the cache TTL, or 0 if it is not set. The value is
+// validated when the configuration is loaded.
+func (c CacheConfig) TTLDuration() time.Duration {
+	if c.TTL == "" {
+		return 0
+	}
+	d, _ := ParseDuration(c.TTL)
+	return d
+}
+
+// MaxSizeBytes returns the cache size limit in bytes, or 0 if it is not set.
+// The value is validated when the configuration is loaded.
+func (c CacheConfig) MaxSizeBytes() int64 {
+	if c.MaxSize == "" {
+		return 0
+	}
+	n, _ := ParseSize(c.MaxSize)
+	return n
+}
+
+// RefreshInterval returns the agent refresh interval, or
+// DefaultAgentRefresh if it is not set
+func (c AgentConfig) RefreshInterval() time.Duration {
+	if c.Refresh == "" {
+		return DefaultAgentRefresh
+	}
+	d, _ := ParseDuration(c.Refresh)
+	return d
+}
+
+// SessionDuration returns the OIDC role session duration, or zero to use
+// the STS default (one hour)
+func (o OIDCConfig) SessionDuration() time.Duration {
+	if o.Duration == "" {
+		return 0
+	}
+	d, _ := ParseDuration(o.Duration)
+	return d
+}
+
+// Timeout returns the per-region read timeout, or DefaultRegionTimeout if
+// it is not set
+func (e Environment) Timeout() time.Duration {
+	if e.RegionTimeout == "" {
+		return DefaultRegionTimeout
+	}
+	d, _ := ParseDuration(e.RegionTimeout)
+	return d
+}
+
+// CacheTTLs returns how long cached values are served without asking AWS
+// (soft) and how long they may be served while being refreshed (hard).
+// soft is 0 when stale-while-revalidate is not configured.
+func (e Environment) CacheTTLs() (soft, hard time.Duration) {
+	if e.CacheSoftTTL == "" {
+		return 0, 0
+	}
+	soft, _ = ParseDuration(e.CacheSoftTTL)
+	hard = DefaultCacheHardTTL
+	if e.CacheHardTTL != "" {
+		hard, _ = ParseDuration(e.CacheHardTTL)
+	}
+	return soft, hard
+}
+
+// validateUnits checks every duration and size in the configuration, so
+// mistakes are reported when it is loaded rather than when first used
+func (c *Config) validateUnits() error {
+	if c.Cache.TTL != "" {
+		if _, err := ParseDuration(c.Cache.TTL); err != nil {
+			return fmt.Errorf("cache.ttl: %w", err)
+		}
+	}
+	if c.Cache.MaxSize != "" {
+		if _, err := ParseSize(c.Cache.MaxSize); err != nil {
+			return fmt.Errorf("cache.max_size: %w", err)
+		}
+	}
+	if c.AWS.OIDC != nil && c.AWS.OIDC.Duration != "" {
+		if _, err := ParseDuration(c.AWS.OIDC.Duration); err != nil {
+			return fmt.Errorf("aws.oidc.duration: %w", err)
+		}
+	}
+	if c.Agent.Refresh != "" {
+		if _, err := ParseDuration(c.Agent.Refresh); err != nil {
+			return fmt.Errorf("agent.refresh: %w", err)
+		}
+	}
+	for _, v := range c.Variables {
+		if v.Refresh != "" {
+			if _, err := ParseDuration(v.Refresh); err != nil {
+				return fmt.Errorf("variables: refresh for %s: %w", v.Key, err)
+			}
+		}
+	}
+	for name, env := range c.Environments {
+		if env.RegionTimeout != "" {
+			if _, err := ParseDuration(env.RegionTimeout); err != nil {
+				return fmt.Errorf("environment '%s' region_timeout: %w", name, err)
+			}
+		}
+		if err := env.validateCacheTTLs(); err != nil {
+			return fmt.Errorf("environment '%s' %w", name, err)
+		}
+		if _, err := env.ParameterTags(); err != nil {
+			return fmt.Errorf("environment '%s' tags: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// validateCacheTTLs checks cache_soft_ttl and cache_hard_ttl
+func (e Environment) validateCacheTTLs() error {
+	for field, value := range map[string]string{"cache_soft_ttl": e.CacheSoftTTL, "cache_hard_ttl": e.CacheHardTTL} {
+		if value == "" {
+			continue
+		}
+		if _, err := ParseDuration(value); err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+	}
+	if e.CacheHardTTL != "" && e.CacheSoftTTL == "" {
+		return fmt.Errorf("cache_hard_ttl requires cache_soft_ttl")
+	}
+	if soft, hard := e.CacheTTLs(); soft > hard {
+		return fmt.Errorf("cache_soft_ttl %s exceeds cache_hard_ttl %s", e.CacheSoftTTL, hard)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package cost
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// month is the period call volumes are scaled to
+const month = 30 * 24 * time.Hour
+
+// Event is a Parameter Store or Secrets Manager API call from CloudTrail
+type Event struct {
+	Source string // ssm.amazonaws.com or secretsmanager.amazonaws.com
+	Name   string // API action, e.g. GetParameter
+	Time   time.Time
+	// Resources are the parameter names, path or secret ID of the request
+	Resources []string
+}
+
+// cloudTrailRecord is the part of a CloudTrail record the estimate uses
+type cloudTrailRecord struct {
+	EventSource       string    `json:"eventSource"`
+	EventName         string    `json:"eventName"`
+	EventTime         time.Time `json:"eventTime"`
+	RequestParameters struct {
+		Name     string   `json:"name"`
+		Names    []string `json:"names"`
+		Path     string   `json:"path"`
+		SecretID string   `json:"secretId"`
+	} `json:"requestParameters"`
+}
+
+// ParseCloudTrail reads the Parameter Store and Secrets Manager calls from a
+// CloudTrail log file ({"Records": [...]}) or the output of 'aws cloudtrail
+// lookup-events' ({"Events": [{"CloudTrailEvent": "..."}]})
+func ParseCloudTrail(r io.Reader) ([]Event, error) {
+	var doc struct {
+		Records []cloudTrailRecord `json:"Records"`
+		Events  []struct {
+			CloudTrailEvent string `json:"CloudTrailEvent"`
+		} `json:"Events"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse CloudTrail events: %w", err)
+	}
+
+	records := doc.Records
+	for _, e := range doc.Events {
+		var record cloudTrailRecord
+		if err := json.Unmarshal([]byte(e.CloudTrailEvent), &record); err != nil {
+			return nil, fmt.Errorf("failed to parse CloudTrail event: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	var events []Event
+	for _, record := range records {
+		if record.EventSource != "ssm.amazonaws.com" && record.EventSource != "secretsmanager.amazonaws.com" {
+			continue
+		}
+		params := record.RequestParameters
+		event := Event{Source: record.EventSource, Name: record.EventName, Time: record.EventTime}
+		for _, resource := range append([]string{params.Name, params.Path, params.SecretID}, params.Names...) {
+			if resource != "" {
+				event.Resources = append(event.Resources, resource)
+			}
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// MonthlyCalls scales the calls of events on an environment to a month.
+// isAdvanced reports whether a parameter name or path touches an Advanced
+// parameter, isSecret whether a secret ID is one of the environment's
+// secrets. Logs covering less than a day are scaled as one day.
+func MonthlyCalls(events []Event, isAdvanced, isSecret func(string) bool) *Calls {
+	calls := &Calls{}
+	var first, last time.Time
+	var params, secrets float64
+
+	for _, event := range events {
+		match := isAdvanced
+		if event.Source == "secretsmanager.amazonaws.com" {
+			match = isSecret
+		}
+		if !anyMatch(event.Resources, match) {
+			continue
+		}
+
+		if event.Source == "secretsmanager.amazonaws.com" {
+			secrets++
+		} else {
+			params++
+		}
+		if first.IsZero() || event.Time.Before(first) {
+			first = event.Time
+		}
+		if event.Time.After(last) {
+			last = event.Time
+		}
+	}
+	if params == 0 && secrets == 0 {
+		return calls
+	}
+
+	span := last.Sub(first)
+	if span < 24*time.Hour {
+		span = 24 * time.Hour
+	}
+	scale := float64(month) / float64(span)
+	calls.AdvancedParameters = params * scale
+	calls.Secrets = secrets * scale
+	return calls
+}
+
+func anyMatch(resources []string, match func(string) bool) bool {
+	for _, resource := range resources {
+		if match(resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// SecretMatcher matches secret IDs given as names or ARNs against names.
+// ARNs end in the name and a random six character suffix.
+func SecretMatcher(names []string) func(string) bool {
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+	return func(id string) bool {
+		if !strings.HasPrefix(id, "arn:") {
+			return known[id]
+		}
+		parts := strings.SplitN(id, ":", 7)
+		if len(parts) < 7 {
+			return false
+		}
+		name := parts[6]
+		if known[name] {
+			return true
+		}
+		i := strings.LastIndex(name, "-")
+		return i > 0 && len(name)-i == 7 && known[name[:i]]
+	}
+}
+
+// ParameterMatcher matches parameter names, and paths read with
+// GetParametersByPath, that include any of names
+func ParameterMatcher(names []string) func(string) bool {
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+	return func(resource string) bool {
+		if known[resource] {
+			return true
+		}
+		prefix := strings.TrimSuffix(resource, "/") + "/"
+		for _, name := range names {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,58 @@
+package cost
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCloudTrail(t *testing.T) {
+	records := `{"Records": [
+		{"eventSource": "ssm.amazonaws.com", "eventName": "GetParameter", "eventTime": "2024-05-01T00:00:00Z", "requestParameters": {"name": "/app/prod/CERT"}},
+		{"eventSource": "ssm.amazonaws.com", "eventName": "GetParameters", "eventTime": "2024-05-01T01:00:00Z", "requestParameters": {"names": ["/app/prod/A", "/app/prod/B"]}},
+		{"eventSource": "s3.amazonaws.com", "eventName": "GetObject", "eventTime": "2024-05-01T02:00:00Z"}
+	]}`
+	events, err := ParseCloudTrail(strings.NewReader(records))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, []string{"/app/prod/CERT"}, events[0].Resources)
+	assert.Equal(t, []string{"/app/prod/A", "/app/prod/B"}, events[1].Resources)
+
+	lookup := `{"Events": [{"CloudTrailEvent": "{\"eventSource\":\"secretsmanager.amazonaws.com\",\"eventName\":\"GetSecretValue\",\"eventTime\":\"2024-05-01T00:00:00Z\",\"requestParameters\":{\"secretId\":\"app-prod\"}}"}]}`
+	events, err = ParseCloudTrail(strings.NewReader(lookup))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "GetSecretValue", events[0].Name)
+
+	_, err = ParseCloudTrail(strings.NewReader("not json"))
+	assert.Error(t, err)
+}
+
+func TestMonthlyCalls(t *testing.T) {
+	events, err := ParseCloudTrail(strings.NewReader(`{"Records": [
+		{"eventSource": "ssm.amazonaws.com", "eventTime": "2024-05-01T00:00:00Z", "requestParameters": {"name": "/app/prod/CERT"}},
+		{"eventSource": "ssm.amazonaws.com", "eventTime": "2024-05-02T00:00:00Z", "requestParameters": {"path": "/app/prod"}},
+		{"eventSource": "ssm.amazonaws.com", "eventTime": "2024-05-02T00:00:00Z", "requestParameters": {"name": "/app/prod/PORT"}},
+		{"eventSource": "secretsmanager.amazonaws.com", "eventTime": "2024-05-03T00:00:00Z", "requestParameters": {"secretId": "arn:aws:secretsmanager:us-east-1:123456789012:secret:envy/large-values/app/prod/BUNDLE-AbCdEf"}},
+		{"eventSource": "secretsmanager.amazonaws.com", "eventTime": "2024-05-03T00:00:00Z", "requestParameters": {"secretId": "other"}}
+	]}`))
+	require.NoError(t, err)
+
+	calls := MonthlyCalls(events, ParameterMatcher([]string{"/app/prod/CERT"}), SecretMatcher([]string{"envy/large-values/app/prod/BUNDLE"}))
+	// 3 matching calls over 2 days
+	assert.InDelta(t, 2*15.0, calls.AdvancedParameters, 1e-9)
+	assert.InDelta(t, 15.0, calls.Secrets, 1e-9)
+
+	assert.Equal(t, &Calls{}, MonthlyCalls(nil, ParameterMatcher(nil), SecretMatcher(nil)))
+}
+
+func TestSecretMatcher(t *testing.T) {
+	match := SecretMatcher([]string{"app-prod"})
+	assert.True(t, match("app-prod"))
+	assert.True(t, match("arn:aws:secretsmanager:us-east-1:123456789012:secret:app-prod-a1B2c3"))
+	assert.True(t, match("arn:aws:secretsmanager:us-east-1:123456789012:secret:app-prod"))
+	assert.False(t, match("arn:aws:secretsmanager:us-east-1:123456789012:secret:app-prod-API_KEY-a1B2c3"))
+	assert.False(t, match("app"))
+}
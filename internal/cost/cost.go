@@ -0,0 +1,180 @@
+// Package cost estimates the monthly AWS cost of an environment's storage
+// choices and points out cheaper ones, such as consolidating small secrets
+// into one JSON secret.
+package cost
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Size limits of the storage options, in bytes
+const (
+	StandardMaxSize = 4096
+	AdvancedMaxSize = 8192
+	SecretMaxSize   = 65536
+)
+
+// Prices are monthly list prices in USD
+type Prices struct {
+	AdvancedParameter float64 // per Advanced tier parameter
+	Secret            float64 // per secret
+	APICalls          float64 // per 10,000 Advanced parameter or Secrets Manager API calls
+}
+
+// DefaultPrices are the us-east-1 list prices. Most regions charge the same;
+// check the AWS pricing pages for others.
+var DefaultPrices = Prices{
+	AdvancedParameter: 0.05,
+	Secret:            0.40,
+	APICalls:          0.05,
+}
+
+// Item is a parameter or secret with the size of its value
+type Item struct {
+	Name string
+	Size int
+}
+
+// Inventory is what an environment stores, and how often it is called
+type Inventory struct {
+	Environment string
+	Service     string // parameter_store or secrets_manager
+
+	StandardParameters int
+	AdvancedParameters []Item
+	// LargeValues are Parameter Store values over 4 KB kept in Secrets
+	// Manager, one secret each
+	LargeValues []Item
+	// Secrets are the Secrets Manager secrets of the environment
+	Secrets []Item
+
+	// Calls are the API calls per month from audit logs, or nil when no
+	// audit logs were given
+	Calls *Calls
+}
+
+// Calls counts the billed API calls per month
+type Calls struct {
+	AdvancedParameters float64
+	Secrets            float64
+}
+
+// Line is one item of the estimate
+type Line struct {
+	Item     string  `json:"item"`
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+	Monthly  float64 `json:"monthly"`
+}
+
+// Suggestion is a cheaper storage choice
+type Suggestion struct {
+	Message string  `json:"message"`
+	Savings float64 `json:"monthly_savings"`
+}
+
+// Estimate is the monthly cost of an environment
+type Estimate struct {
+	Environment string       `json:"environment"`
+	Lines       []Line       `json:"lines"`
+	Total       float64      `json:"monthly_total"`
+	Suggestions []Suggestion `json:"suggestions"`
+	Notes       []string     `json:"notes"`
+}
+
+// Calculate estimates the monthly cost of inv at prices
+func Calculate(inv *Inventory, prices Prices) *Estimate {
+	est := &Estimate{Environment: inv.Environment, Lines: []Line{}, Suggestions: []Suggestion{}, Notes: []string{}}
+	add := func(item string, quantity float64, unit string, price float64) {
+		if quantity == 0 {
+			return
+		}
+		line := Line{Item: item, Quantity: quantity, Unit: unit, Monthly: quantity * price}
+		est.Lines = append(est.Lines, line)
+		est.Total += line.Monthly
+	}
+
+	if inv.StandardParameters > 0 {
+		est.Lines = append(est.Lines, Line{Item: "Standard parameters", Quantity: float64(inv.StandardParameters), Unit: "parameters"})
+	}
+	add("Advanced parameters", float64(len(inv.AdvancedParameters)), "parameters", prices.AdvancedParameter)
+	add("Secrets", float64(len(inv.Secrets)), "secrets", prices.Secret)
+	add("Large value secrets", float64(len(inv.LargeValues)), "secrets", prices.Secret)
+
+	if inv.Calls != nil {
+		add("Advanced parameter API calls", inv.Calls.AdvancedParameters, "calls", prices.APICalls/10000)
+		add("Secrets Manager API calls", inv.Calls.Secrets, "calls", prices.APICalls/10000)
+	} else if len(inv.AdvancedParameters) > 0 || len(inv.Secrets) > 0 || len(inv.LargeValues) > 0 {
+		est.Notes = append(est.Notes, "API calls are not included; pass CloudTrail logs with --cloudtrail to estimate them")
+	}
+	if len(inv.AdvancedParameters) > 0 {
+		est.Notes = append(est.Notes, "Standard parameter API calls are assumed free (standard throughput)")
+	}
+
+	est.Suggestions = suggest(inv, prices)
+	return est
+}
+
+// suggest finds cheaper storage for the environment
+func suggest(inv *Inventory, prices Prices) []Suggestion {
+	suggestions := []Suggestion{}
+
+	if fit := fitting(inv.AdvancedParameters, StandardMaxSize); len(fit) > 0 {
+		suggestions = append(suggestions, Suggestion{
+			Message: fmt.Sprintf("%d Advanced parameter(s) fit in the Standard tier (4 KB), which is free: %s. Parameters cannot be moved down a tier, so recreate them.", len(fit), names(fit)),
+			Savings: float64(len(fit)) * prices.AdvancedParameter,
+		})
+	}
+
+	if fit := fitting(inv.LargeValues, AdvancedMaxSize); len(fit) > 0 {
+		suggestions = append(suggestions, Suggestion{
+			Message: fmt.Sprintf("%d large value(s) fit in an Advanced parameter (8 KB) instead of a secret: %s", len(fit), names(fit)),
+			Savings: float64(len(fit)) * (prices.Secret - prices.AdvancedParameter),
+		})
+	}
+
+	if len(inv.Secrets) > 1 {
+		total := 0
+		for _, secret := range inv.Secrets {
+			total += secret.Size
+		}
+		if total <= SecretMaxSize {
+			suggestions = append(suggestions, Suggestion{
+				Message: fmt.Sprintf("The %d secrets of %s hold %d bytes together and fit in one JSON secret (64 KB)", len(inv.Secrets), inv.Environment, total),
+				Savings: float64(len(inv.Secrets)-1) * prices.Secret,
+			})
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Savings > suggestions[j].Savings
+	})
+	return suggestions
+}
+
+func fitting(items []Item, maxSize int) []Item {
+	var fit []Item
+	for _, item := range items {
+		if item.Size <= maxSize {
+			fit = append(fit, item)
+		}
+	}
+	return fit
+}
+
+// names lists up to five item names
+func names(items []Item) string {
+	const shown = 5
+	s := ""
+	for i, item := range items {
+		if i == shown {
+			return s + fmt.Sprintf(" and %d more", len(items)-shown)
+		}
+		if i > 0 {
+			s += ", "
+		}
+		s += item.Name
+	}
+	return s
+}
@@ -0,0 +1,61 @@
+package cost
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculate_ParameterStore(t *testing.T) {
+	inv := &Inventory{
+		Environment:        "prod",
+		Service:            "parameter_store",
+		StandardParameters: 40,
+		AdvancedParameters: []Item{{Name: "/app/prod/SMALL", Size: 100}, {Name: "/app/prod/CERT", Size: 6000}},
+		LargeValues:        []Item{{Name: "envy/large-values/app/prod/BUNDLE", Size: 7000}, {Name: "envy/large-values/app/prod/HUGE", Size: 20000}},
+	}
+
+	est := Calculate(inv, DefaultPrices)
+	assert.InDelta(t, 2*0.05+2*0.40, est.Total, 1e-9)
+	require.Len(t, est.Lines, 3)
+	assert.Equal(t, "Standard parameters", est.Lines[0].Item)
+	assert.Zero(t, est.Lines[0].Monthly)
+
+	require.Len(t, est.Suggestions, 2)
+	assert.Contains(t, est.Suggestions[0].Message, "1 large value(s) fit in an Advanced parameter")
+	assert.InDelta(t, 0.35, est.Suggestions[0].Savings, 1e-9)
+	assert.Contains(t, est.Suggestions[1].Message, "/app/prod/SMALL")
+	assert.NotContains(t, est.Suggestions[1].Message, "CERT")
+
+	assert.Contains(t, strings.Join(est.Notes, "\n"), "--cloudtrail")
+}
+
+func TestCalculate_ConsolidateSecrets(t *testing.T) {
+	inv := &Inventory{
+		Environment: "prod",
+		Service:     "secrets_manager",
+		Secrets:     []Item{{Name: "app-prod", Size: 800}, {Name: "app-prod-API_KEY", Size: 40}, {Name: "app-prod-DB_PASSWORD", Size: 30}},
+		Calls:       &Calls{Secrets: 200000},
+	}
+
+	est := Calculate(inv, DefaultPrices)
+	assert.InDelta(t, 3*0.40+20*0.05, est.Total, 1e-9)
+	require.Len(t, est.Suggestions, 1)
+	assert.Contains(t, est.Suggestions[0].Message, "fit in one JSON secret")
+	assert.InDelta(t, 0.80, est.Suggestions[0].Savings, 1e-9)
+
+	// Too large together
+	inv.Secrets[0].Size = SecretMaxSize
+	assert.Empty(t, Calculate(inv, DefaultPrices).Suggestions)
+}
+
+func TestNames(t *testing.T) {
+	items := make([]Item, 7)
+	for i := range items {
+		items[i].Name = string(rune('A' + i))
+	}
+	assert.Equal(t, "A, B, C, D, E and 2 more", names(items))
+	assert.Equal(t, "A", names(items[:1]))
+}
@@ -0,0 +1,112 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// annotationPrefix starts a comment holding envy annotations, e.g.
+//
+//	# envy: consumers=web,worker
+//	STRIPE_KEY=sk_live_...
+//
+// or inline: STRIPE_KEY=sk_live_... # envy: consumers=web
+const annotationPrefix = "envy:"
+
+// AllConsumers tags a variable for every consumer
+const AllConsumers = "*"
+
+// Annotations returns the envy annotations of a variable, from its inline
+// comment or the comment on the line above. Inline annotations win.
+func (f *File) Annotations(key string) map[string]string {
+	variable, ok := f.Variables[key]
+	if !ok {
+		return nil
+	}
+
+	annotations := make(map[string]string)
+	if variable.Line > 1 {
+		parseAnnotations(f.Comments[variable.Line-1], annotations)
+	}
+	parseAnnotations(variable.Comment, annotations)
+	if len(annotations) == 0 {
+		return nil
+	}
+	return annotations
+}
+
+// Consumers returns the processes a variable is tagged for with
+// "consumers=", or nil if it is not tagged
+func (f *File) Consumers(key string) []string {
+	value, ok := f.Annotations(key)["consumers"]
+	if !ok {
+		return nil
+	}
+
+	var consumers []string
+	for _, consumer := range strings.Split(value, ",") {
+		if consumer = strings.TrimSpace(consumer); consumer != "" {
+			consumers = append(consumers, consumer)
+		}
+	}
+	return consumers
+}
+
+// ConsumedBy reports whether consumers includes consumer, or AllConsumers
+func ConsumedBy(consumers []string, consumer string) bool {
+	for _, c := range consumers {
+		if c == consumer || c == AllConsumers {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultMountMode is the permission of mounted files without a mode
+const DefaultMountMode os.FileMode = 0400
+
+// Mount is a file a variable's value is written to, for secrets that
+// applications read from files, such as TLS certificates
+type Mount struct {
+	Path string
+	Mode os.FileMode
+}
+
+// Mount returns the file a variable is mounted at with
+// "# envy: mount=/path mode=0400". A relative path is relative to a
+// private directory created for the run.
+func (f *File) Mount(key string) (Mount, bool, error) {
+	annotations := f.Annotations(key)
+	path, ok := annotations["mount"]
+	if !ok {
+		return Mount{}, false, nil
+	}
+	if path == "" {
+		return Mount{}, false, fmt.Errorf("variable %s: mount needs a path", key)
+	}
+
+	mount := Mount{Path: path, Mode: DefaultMountMode}
+	if mode, ok := annotations["mode"]; ok {
+		perm, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil || perm > 0777 {
+			return Mount{}, false, fmt.Errorf("variable %s: invalid mode %q (expected octal, e.g. 0400)", key, mode)
+		}
+		mount.Mode = os.FileMode(perm)
+	}
+	return mount, true, nil
+}
+
+// parseAnnotations adds the key=value pairs of an "envy:" comment
+func parseAnnotations(comment string, annotations map[string]string) {
+	comment = strings.TrimSpace(comment)
+	if !strings.HasPrefix(comment, annotationPrefix) {
+		return
+	}
+	for _, field := range strings.Fields(strings.TrimPrefix(comment, annotationPrefix)) {
+		if key, value, ok := strings.Cut(field, "="); ok && key != "" {
+			annotations[key] = value
+		}
+	}
+}
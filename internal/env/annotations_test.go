@@ -0,0 +1,76 @@
+package env
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_Consumers(t *testing.T) {
+	file, err := Parse(strings.NewReader(`# Shared settings
+LOG_LEVEL=info
+
+# envy: consumers=web,worker
+DATABASE_URL=postgresql://localhost/app
+STRIPE_KEY=sk_test_123 # envy: consumers=web
+# envy: consumers=*
+SENTRY_DSN=https://sentry.example.com/1
+# envy: consumers=worker
+QUEUE_URL=sqs://queue # envy: consumers=worker,cron
+`))
+	require.NoError(t, err)
+
+	assert.Nil(t, file.Consumers("LOG_LEVEL"))
+	assert.Nil(t, file.Consumers("MISSING"))
+	assert.Equal(t, []string{"web", "worker"}, file.Consumers("DATABASE_URL"))
+	assert.Equal(t, []string{"web"}, file.Consumers("STRIPE_KEY"))
+	assert.Equal(t, []string{"worker", "cron"}, file.Consumers("QUEUE_URL"), "inline annotations win")
+
+	assert.True(t, ConsumedBy(file.Consumers("SENTRY_DSN"), "cron"))
+	assert.True(t, ConsumedBy(file.Consumers("DATABASE_URL"), "worker"))
+	assert.False(t, ConsumedBy(file.Consumers("STRIPE_KEY"), "worker"))
+	assert.False(t, ConsumedBy(file.Consumers("LOG_LEVEL"), "web"))
+}
+
+func TestParseAnnotations(t *testing.T) {
+	annotations := make(map[string]string)
+	parseAnnotations("envy: consumers=web owner=payments bogus", annotations)
+	assert.Equal(t, map[string]string{"consumers": "web", "owner": "payments"}, annotations)
+
+	annotations = make(map[string]string)
+	parseAnnotations("not an annotation: consumers=web", annotations)
+	assert.Empty(t, annotations)
+}
+
+func TestFile_Mount(t *testing.T) {
+	file, err := Parse(strings.NewReader(`# envy: mount=/run/secrets/tls.pem mode=0440
+TLS_CERT=-----BEGIN CERTIFICATE-----
+GCP_CREDENTIALS={} # envy: mount=gcp.json consumers=worker
+PLAIN=1
+BAD_MODE=x # envy: mount=a.pem mode=999
+NO_PATH=x # envy: mount=
+`))
+	require.NoError(t, err)
+
+	mount, ok, err := file.Mount("TLS_CERT")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Mount{Path: "/run/secrets/tls.pem", Mode: 0440}, mount)
+
+	mount, ok, err = file.Mount("GCP_CREDENTIALS")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Mount{Path: "gcp.json", Mode: os.FileMode(0400)}, mount)
+
+	_, ok, err = file.Mount("PLAIN")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = file.Mount("BAD_MODE")
+	assert.ErrorContains(t, err, "invalid mode")
+	_, _, err = file.Mount("NO_PATH")
+	assert.ErrorContains(t, err, "needs a path")
+}
@@ -0,0 +1,55 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// backupTimeLayout is the timestamp in backup file names
+const backupTimeLayout = "20060102_150405.000"
+
+// BackupFilename returns the name of a backup of original taken at t, e.g.
+// .env.backup_20240601_120000.000.dev for .env.dev
+func BackupFilename(original string, t time.Time) string {
+	ext := filepath.Ext(original)
+	base := strings.TrimSuffix(original, ext)
+	return fmt.Sprintf("%s.backup_%s%s", base, t.Format(backupTimeLayout), ext)
+}
+
+// LatestBackup returns the most recent backup of original taken before the
+// given time, and when it was taken
+func LatestBackup(original string, before time.Time) (string, time.Time, error) {
+	dir := filepath.Dir(original)
+	ext := filepath.Ext(original)
+	prefix := strings.TrimSuffix(filepath.Base(original), ext) + ".backup_"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var latest string
+	var latestTime time.Time
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		taken, err := time.ParseInLocation(backupTimeLayout, stamp, time.Local)
+		if err != nil || !taken.Before(before) {
+			continue
+		}
+		if latest == "" || taken.After(latestTime) {
+			latest, latestTime = filepath.Join(dir, name), taken
+		}
+	}
+
+	if latest == "" {
+		return "", time.Time{}, fmt.Errorf("no backup of %s from before %s", original, before.Format("2006-01-02 15:04"))
+	}
+	return latest, latestTime, nil
+}
@@ -0,0 +1,47 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupFilename(t *testing.T) {
+	taken := time.Date(2024, 6, 1, 12, 30, 0, 0, time.Local)
+	assert.Equal(t, ".env.backup_20240601_123000.000.dev", BackupFilename(".env.dev", taken))
+	assert.Equal(t, "envfile.backup_20240601_123000.000", BackupFilename("envfile", taken))
+}
+
+func TestLatestBackup(t *testing.T) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, ".env.dev")
+
+	may := time.Date(2024, 5, 20, 9, 0, 0, 0, time.Local)
+	june := time.Date(2024, 6, 1, 18, 0, 0, 0, time.Local)
+	july := time.Date(2024, 7, 1, 9, 0, 0, 0, time.Local)
+	for _, name := range []string{
+		BackupFilename(original, may),
+		BackupFilename(original, june),
+		BackupFilename(original, july),
+		BackupFilename(filepath.Join(dir, ".env.prod"), june),
+		filepath.Join(dir, ".env.backup_garbage.dev"),
+	} {
+		require.NoError(t, os.WriteFile(name, []byte("A=1\n"), 0600))
+	}
+
+	backup, taken, err := LatestBackup(original, time.Date(2024, 6, 2, 0, 0, 0, 0, time.Local))
+	require.NoError(t, err)
+	assert.Equal(t, BackupFilename(original, june), backup)
+	assert.True(t, taken.Equal(june))
+
+	backup, _, err = LatestBackup(original, june)
+	require.NoError(t, err)
+	assert.Equal(t, BackupFilename(original, may), backup)
+
+	_, _, err = LatestBackup(original, may)
+	assert.ErrorContains(t, err, "no backup of")
+}
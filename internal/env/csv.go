@@ -0,0 +1,202 @@
+package env
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CSV column names. Columns are matched case-insensitively and any other
+// columns (e.g. notes added in a spreadsheet) are ignored on import.
+const (
+	CSVColumnKey       = "key"
+	CSVColumnValue     = "value"
+	CSVColumnSensitive = "sensitive"
+)
+
+// utf8BOM is prepended by some spreadsheet applications when saving CSV
+const utf8BOM = "\ufeff"
+
+// csvEscape is prepended to values a spreadsheet would evaluate as a formula.
+// Spreadsheets treat it as a text marker and hide it; ParseCSV strips it.
+const csvEscape = "'"
+
+var csvKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// escapeCSVValue neutralizes values starting with a formula character.
+// Values already starting with the escape are escaped too, so stripping one
+// escape on import always restores the original value.
+func escapeCSVValue(value string) string {
+	if value != "" && strings.ContainsRune("=+-@"+csvEscape, rune(value[0])) {
+		return csvEscape + value
+	}
+	return value
+}
+
+// unescapeCSVValue reverses escapeCSVValue
+func unescapeCSVValue(value string) string {
+	return strings.TrimPrefix(value, csvEscape)
+}
+
+// WriteCSV writes variables as CSV with key, value and sensitive columns.
+// isSensitive may be nil, in which case the sensitive column is left empty.
+// Values starting with =, +, - or @ are prefixed with ' so spreadsheets do
+// not evaluate them as formulas.
+func WriteCSV(w io.Writer, f *File, isSensitive func(string) bool) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{CSVColumnKey, CSVColumnValue, CSVColumnSensitive}); err != nil {
+		return err
+	}
+
+	for _, key := range f.SortedKeys() {
+		value, _ := f.Get(key)
+		sensitive := ""
+		if isSensitive != nil {
+			sensitive = strconv.FormatBool(isSensitive(key))
+		}
+		if err := writer.Write([]string{key, escapeCSVValue(value), sensitive}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// ParseCSV reads variables from CSV written by WriteCSV or edited in a
+// spreadsheet, along with the keys marked true in the optional sensitive
+// column. A leading ' is stripped from values. All problems found are
+// reported together, each with its row number, so a whole sheet can be
+// fixed in one pass.
+func ParseCSV(r io.Reader) (*File, []string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // Validated per row below
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("CSV is empty")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	keyCol, valueCol, sensitiveCol, err := csvColumns(header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file := NewFile()
+	var sensitive []string
+	var problems []error
+	seen := make(map[string]int)
+
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("row %d: %w", row, err)
+		}
+
+		if isBlankRecord(record) {
+			continue
+		}
+
+		if len(record) <= keyCol || len(record) <= valueCol {
+			problems = append(problems, fmt.Errorf("row %d: expected at least %d columns, got %d", row, max(keyCol, valueCol)+1, len(record)))
+			continue
+		}
+
+		key := strings.TrimSpace(record[keyCol])
+		if !csvKeyPattern.MatchString(key) {
+			problems = append(problems, fmt.Errorf("row %d: invalid key %q", row, key))
+			continue
+		}
+
+		if first, ok := seen[key]; ok {
+			problems = append(problems, fmt.Errorf("row %d: duplicate key %s (first defined on row %d)", row, key, first))
+			continue
+		}
+		seen[key] = row
+
+		if sensitiveCol != -1 && sensitiveCol < len(record) {
+			if flag := strings.TrimSpace(record[sensitiveCol]); flag != "" {
+				marked, err := strconv.ParseBool(flag)
+				if err != nil {
+					problems = append(problems, fmt.Errorf("row %d: invalid sensitive value %q (use true or false)", row, flag))
+					continue
+				}
+				if marked {
+					sensitive = append(sensitive, key)
+				}
+			}
+		}
+
+		file.Set(key, unescapeCSVValue(record[valueCol]))
+	}
+
+	if len(problems) > 0 {
+		return nil, nil, errors.Join(problems...)
+	}
+
+	return file, sensitive, nil
+}
+
+// csvColumns locates the key, value and sensitive columns in a header row.
+// The sensitive column is optional and -1 when absent.
+func csvColumns(header []string) (int, int, int, error) {
+	keyCol, valueCol, sensitiveCol := -1, -1, -1
+	seen := make(map[string]bool)
+
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, utf8BOM)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		if seen[name] {
+			return 0, 0, 0, fmt.Errorf("duplicate column %q in CSV header", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case CSVColumnKey:
+			keyCol = i
+		case CSVColumnValue:
+			valueCol = i
+		case CSVColumnSensitive:
+			sensitiveCol = i
+		}
+	}
+
+	var missing []string
+	if keyCol == -1 {
+		missing = append(missing, CSVColumnKey)
+	}
+	if valueCol == -1 {
+		missing = append(missing, CSVColumnValue)
+	}
+	if len(missing) > 0 {
+		return 0, 0, 0, fmt.Errorf("CSV header is missing required column(s): %s", strings.Join(missing, ", "))
+	}
+
+	return keyCol, valueCol, sensitiveCol, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package env_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/drapon/envy/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCSV(t *testing.T) {
+	file := env.NewFile()
+	file.Set("PORT", "8080")
+	file.Set("API_TOKEN", "abc")
+	file.Set("GREETING", "hello, \"world\"")
+
+	var buf bytes.Buffer
+	err := env.WriteCSV(&buf, file, func(key string) bool { return key == "API_TOKEN" })
+	require.NoError(t, err)
+
+	expected := "key,value,sensitive\n" +
+		"API_TOKEN,abc,true\n" +
+		"GREETING,\"hello, \"\"world\"\"\",false\n" +
+		"PORT,8080,false\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	file := env.NewFile()
+	file.Set("MULTILINE", "line1\nline2")
+	file.Set("EMPTY", "")
+	file.Set("URL", "https://example.com/?a=1,b=2")
+
+	var buf bytes.Buffer
+	require.NoError(t, env.WriteCSV(&buf, file, nil))
+
+	parsed, _, err := env.ParseCSV(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, file.ToMap(), parsed.ToMap())
+}
+
+func TestCSVFormulaEscaping(t *testing.T) {
+	file := env.NewFile()
+	file.Set("FORMULA", "=HYPERLINK(\"http://evil\")")
+	file.Set("PLUS", "+1")
+	file.Set("NEGATIVE", "-5")
+	file.Set("AT", "@SUM(A1)")
+	file.Set("QUOTED", "'already quoted'")
+	file.Set("PLAIN", "a=b")
+
+	var buf bytes.Buffer
+	require.NoError(t, env.WriteCSV(&buf, file, nil))
+
+	out := buf.String()
+	assert.Contains(t, out, "FORMULA,\"'=HYPERLINK(\"\"http://evil\"\")\"")
+	assert.Contains(t, out, "PLUS,'+1,")
+	assert.Contains(t, out, "NEGATIVE,'-5,")
+	assert.Contains(t, out, "AT,'@SUM(A1),")
+	assert.Contains(t, out, "QUOTED,''already quoted',")
+	assert.Contains(t, out, "PLAIN,a=b,")
+
+	parsed, _, err := env.ParseCSV(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, file.ToMap(), parsed.ToMap())
+}
+
+func TestParseCSVSensitiveColumn(t *testing.T) {
+	input := "key,value,sensitive\n" +
+		"API_TOKEN,abc,true\n" +
+		"PORT,8080,false\n" +
+		"HOST,localhost,\n"
+
+	file, sensitive, err := env.ParseCSV(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Len(t, file.Keys(), 3)
+	assert.Equal(t, []string{"API_TOKEN"}, sensitive)
+
+	_, _, err = env.ParseCSV(strings.NewReader("key,value,sensitive\nPORT,8080,maybe\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "row 2: invalid sensitive value \"maybe\"")
+}
+
+func TestParseCSV(t *testing.T) {
+	t.Run("spreadsheet export", func(t *testing.T) {
+		// BOM, reordered and extra columns, blank rows
+		input := "\ufeffNotes, Value ,KEY\n" +
+			"owner: ops,30,TIMEOUT\n" +
+			",,\n" +
+			",true,FEATURE_X\n"
+
+		file, _, err := env.ParseCSV(strings.NewReader(input))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"TIMEOUT", "FEATURE_X"}, file.Keys())
+
+		value, _ := file.Get("TIMEOUT")
+		assert.Equal(t, "30", value)
+	})
+
+	t.Run("missing columns", func(t *testing.T) {
+		_, _, err := env.ParseCSV(strings.NewReader("name,value\nA,1\n"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "key")
+	})
+
+	t.Run("duplicate header", func(t *testing.T) {
+		_, _, err := env.ParseCSV(strings.NewReader("key,value,key\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, _, err := env.ParseCSV(strings.NewReader(""))
+		assert.Error(t, err)
+	})
+
+	t.Run("row problems are reported together", func(t *testing.T) {
+		input := "key,value\n" +
+			"GOOD,1\n" +
+			"BAD-KEY,2\n" +
+			"GOOD,3\n" +
+			"SHORT\n"
+
+		_, _, err := env.ParseCSV(strings.NewReader(input))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "row 3: invalid key \"BAD-KEY\"")
+		assert.Contains(t, err.Error(), "row 4: duplicate key GOOD (first defined on row 2)")
+		assert.Contains(t, err.Error(), "row 5: expected at least 2 columns")
+	})
+}
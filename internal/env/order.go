@@ -0,0 +1,105 @@
+package env
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Key orders for files written from a remote store, where variables have
+// no order of their own
+const (
+	// OrderAlphabetical sorts keys by name
+	OrderAlphabetical = "alphabetical"
+	// OrderPath groups keys by their segments, the parts between "_" that
+	// the "/" of parameter names become: at each level the keys ending
+	// there come first, then each group, all sorted by name
+	OrderPath = "path"
+	// OrderRules follows the order the rules file names variables in;
+	// the others follow alphabetically
+	OrderRules = "rules"
+)
+
+// ParseOrder checks a key order name, defaulting to alphabetical
+func ParseOrder(name string) (string, error) {
+	switch name {
+	case "":
+		return OrderAlphabetical, nil
+	case OrderAlphabetical, OrderPath, OrderRules:
+		return name, nil
+	}
+	return "", fmt.Errorf("unsupported key order: %s (use alphabetical, path or rules)", name)
+}
+
+// SortKeys returns keys in the given order. named is the order of the
+// rules file, used by OrderRules.
+func SortKeys(keys []string, order string, named []string) []string {
+	sorted := append([]string{}, keys...)
+	switch order {
+	case OrderPath:
+		sort.Slice(sorted, func(i, j int) bool {
+			return pathLess(strings.Split(sorted[i], "_"), strings.Split(sorted[j], "_"))
+		})
+	case OrderRules:
+		rank := make(map[string]int, len(named))
+		for i, name := range named {
+			rank[name] = i
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			ri, iNamed := rank[sorted[i]]
+			rj, jNamed := rank[sorted[j]]
+			switch {
+			case iNamed && jNamed:
+				return ri < rj
+			case iNamed != jNamed:
+				return iNamed
+			}
+			return sorted[i] < sorted[j]
+		})
+	default:
+		sort.Strings(sorted)
+	}
+	return sorted
+}
+
+// pathLess compares keys split into segments, putting the keys that end at
+// a level before the groups continuing below it
+func pathLess(a, b []string) bool {
+	for len(a) > 0 && len(b) > 0 {
+		aLeaf, bLeaf := len(a) == 1, len(b) == 1
+		if aLeaf != bLeaf {
+			return aLeaf
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// Reorder puts the variables of the file in the order of keys and numbers
+// their lines afresh after any comments. Variables missing from keys keep
+// their order after the others.
+func (f *File) Reorder(keys []string) {
+	line := 0
+	for lineNum := range f.Comments {
+		if lineNum > line {
+			line = lineNum
+		}
+	}
+
+	order := make([]string, 0, len(f.Order))
+	placed := make(map[string]bool, len(f.Order))
+	for _, key := range append(append([]string{}, keys...), f.Order...) {
+		variable, ok := f.Variables[key]
+		if !ok || placed[key] {
+			continue
+		}
+		placed[key] = true
+		line++
+		variable.Line = line
+		order = append(order, key)
+	}
+	f.Order = order
+}
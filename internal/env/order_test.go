@@ -0,0 +1,49 @@
+package env
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortKeys(t *testing.T) {
+	keys := []string{"REDIS_URL", "DB_POOL_MAX", "PORT", "DB_HOST", "DEBUG", "DB_PORT"}
+
+	assert.Equal(t, []string{"DB_HOST", "DB_POOL_MAX", "DB_PORT", "DEBUG", "PORT", "REDIS_URL"},
+		SortKeys(keys, OrderAlphabetical, nil))
+	assert.Equal(t, []string{"DEBUG", "PORT", "DB_HOST", "DB_PORT", "DB_POOL_MAX", "REDIS_URL"},
+		SortKeys(keys, OrderPath, nil))
+	assert.Equal(t, []string{"PORT", "DEBUG", "DB_HOST", "DB_POOL_MAX", "DB_PORT", "REDIS_URL"},
+		SortKeys(keys, OrderRules, []string{"PORT", "MISSING", "DEBUG"}))
+
+	assert.Equal(t, []string{"REDIS_URL", "DB_POOL_MAX", "PORT", "DB_HOST", "DEBUG", "DB_PORT"}, keys, "keys are not changed")
+}
+
+func TestParseOrder(t *testing.T) {
+	order, err := ParseOrder("")
+	require.NoError(t, err)
+	assert.Equal(t, OrderAlphabetical, order)
+
+	order, err = ParseOrder("rules")
+	require.NoError(t, err)
+	assert.Equal(t, OrderRules, order)
+
+	_, err = ParseOrder("random")
+	assert.ErrorContains(t, err, "use alphabetical, path or rules")
+}
+
+func TestReorder(t *testing.T) {
+	file := NewFile()
+	file.Set("PORT", "80")
+	file.Set("API_URL", "https://api.example.org")
+	file.Set("DEBUG", "false")
+
+	file.Reorder([]string{"DEBUG", "API_URL"})
+	assert.Equal(t, []string{"DEBUG", "API_URL", "PORT"}, file.Keys())
+
+	var buf bytes.Buffer
+	require.NoError(t, file.Write(&buf))
+	assert.Equal(t, "DEBUG=false\nAPI_URL=https://api.example.org\nPORT=80\n", buf.String())
+}
@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
 
+	"github.com/drapon/envy/internal/log"
 	"github.com/drapon/envy/internal/memory"
+	"go.uber.org/zap"
 )
 
 // Variable represents an environment variable with metadata
@@ -66,15 +69,33 @@ func ParseWithContext(ctx context.Context, r io.Reader) (*File, error) {
 	commentPattern := regexp.MustCompile(`^\s*#(.*)$`)
 	emptyPattern := regexp.MustCompile(`^\s*$`)
 
-	for scanner.Scan() {
+	// Lines read ahead for an unterminated multiline value are pushed back
+	// here to be parsed again
+	var pushedBack []string
+	next := func() (string, bool) {
+		if len(pushedBack) > 0 {
+			line := pushedBack[0]
+			pushedBack = pushedBack[1:]
+			return line, true
+		}
+		if !scanner.Scan() {
+			return "", false
+		}
+		return scanner.Text(), true
+	}
+
+	for {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		default:
 		}
 
+		line, ok := next()
+		if !ok {
+			break
+		}
 		lineNum++
-		line := scanner.Text()
 
 		// Handle empty lines
 		if emptyPattern.MatchString(line) {
@@ -92,53 +113,51 @@ func ParseWithContext(ctx context.Context, r io.Reader) (*File, error) {
 			key := matches[1]
 			value := matches[2]
 
-			// Handle inline comments (but not inside quotes)
+			// A quoted value that is not closed on its line continues on the
+			// next ones, as in PEM keys and formatted JSON
 			var comment string
-			// Check if value starts with a quote
-			if strings.HasPrefix(strings.TrimSpace(value), "\"") || strings.HasPrefix(strings.TrimSpace(value), "'") {
-				// If quoted, remove quotes first, then check for comments after the closing quote
-				trimmedValue := strings.TrimSpace(value)
-				if len(trimmedValue) >= 2 {
-					quote := trimmedValue[0]
-					// Find the closing quote
-					closeIdx := strings.LastIndexByte(trimmedValue[1:], quote)
-					if closeIdx != -1 {
-						closeIdx++ // Adjust for the slice offset
-						// Check for comment after the closing quote
-						afterQuote := trimmedValue[closeIdx+1:]
-						if commentIdx := strings.Index(afterQuote, " #"); commentIdx != -1 {
-							comment = strings.TrimSpace(afterQuote[commentIdx+2:])
-							value = trimmedValue[:closeIdx+1]
-						}
-					}
-				}
-			} else {
-				// Not quoted, check for inline comment
-				if idx := strings.Index(value, " #"); idx != -1 {
-					comment = strings.TrimSpace(value[idx+2:])
-					value = strings.TrimSpace(value[:idx])
+			startLine := lineNum
+			multiline := false
+			if quote, ok := openQuote(value); ok {
+				lines, tail, closed := readMultiline(next, quote)
+				if closed {
+					value = strings.TrimSpace(value) + "\n" + strings.Join(lines, "\n")
+					comment = tailComment(tail)
+					lineNum += len(lines)
+					multiline = true
+				} else {
+					// Never closed: parse the lines read ahead on their own
+					pushedBack = append(lines, pushedBack...)
 				}
 			}
+			if !multiline {
+				value, comment = splitComment(value)
+			}
 
 			// Remove quotes if present
-			value = trimQuotes(value)
+			value = unquote(value)
 
 			variable := &Variable{
 				Key:     key,
 				Value:   value,
 				Comment: comment,
-				Line:    lineNum,
+				Line:    startLine,
 			}
 
 			file.Variables[key] = variable
 			file.Order = append(file.Order, key)
+			continue
 		}
+
+		// Never log the line itself, it may hold a secret
+		log.Named("env").Debug("Ignoring line that is not a variable", zap.Int("line", lineNum))
 	}
 
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("error reading file: %w", err)
 	}
 
+	log.Named("env").Debug("Parsed env file", zap.Int("variables", len(file.Order)), zap.Int("lines", lineNum))
 	return file, nil
 }
 
@@ -235,19 +254,46 @@ func (f *File) WriteWithContext(ctx context.Context, w io.Writer) error {
 	return nil
 }
 
-// WriteFile writes the file content to disk
-func (f *File) WriteFile(filename string) error {
-	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+// WriteFile writes the file content to disk. The content goes to a
+// temporary file next to filename that is renamed over it once complete,
+// so an interrupted write leaves the previous file untouched. An existing
+// file keeps its permissions, and a symlink keeps pointing at its target.
+func (f *File) WriteFile(filename string) (err error) {
+	if target, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = target
+	}
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-*")
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
 	defer func() {
-		if closeErr := file.Close(); closeErr != nil && err == nil {
-			err = fmt.Errorf("failed to close file: %w", closeErr)
+		if err != nil {
+			file.Close()
+			os.Remove(file.Name())
 		}
 	}()
 
-	return f.Write(file)
+	if err := f.Write(file); err != nil {
+		return err
+	}
+	if err := file.Chmod(mode); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+	if err := os.Rename(file.Name(), filename); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filename, err)
+	}
+	return nil
 }
 
 // Get returns the value of a variable
@@ -284,6 +330,31 @@ func (f *File) Delete(key string) {
 	}
 }
 
+// Rename changes the key of a variable, keeping its position, value and
+// comment. It returns false if oldKey does not exist or newKey already does.
+func (f *File) Rename(oldKey, newKey string) bool {
+	variable, ok := f.Variables[oldKey]
+	if !ok {
+		return false
+	}
+	if _, exists := f.Variables[newKey]; exists {
+		return false
+	}
+
+	variable.Key = newKey
+	delete(f.Variables, oldKey)
+	f.Variables[newKey] = variable
+
+	for i, k := range f.Order {
+		if k == oldKey {
+			f.Order[i] = newKey
+			break
+		}
+	}
+
+	return true
+}
+
 // ToMap converts variables to a simple map
 func (f *File) ToMap() map[string]string {
 	// Use memory pool for map if available
@@ -347,6 +418,133 @@ func (f *File) Merge(other *File) {
 	}
 }
 
+// splitComment separates the inline comment of a single-line value, which
+// follows " #" outside of quotes
+func splitComment(value string) (string, string) {
+	var comment string
+	// Check if value starts with a quote
+	if strings.HasPrefix(strings.TrimSpace(value), "\"") || strings.HasPrefix(strings.TrimSpace(value), "'") {
+		// If quoted, remove quotes first, then check for comments after the closing quote
+		trimmedValue := strings.TrimSpace(value)
+		if len(trimmedValue) >= 2 {
+			quote := trimmedValue[0]
+			// Find the closing quote
+			closeIdx := strings.LastIndexByte(trimmedValue[1:], quote)
+			if closeIdx != -1 {
+				closeIdx++ // Adjust for the slice offset
+				// Check for comment after the closing quote
+				afterQuote := trimmedValue[closeIdx+1:]
+				if commentIdx := strings.Index(afterQuote, " #"); commentIdx != -1 {
+					comment = strings.TrimSpace(afterQuote[commentIdx+2:])
+					value = trimmedValue[:closeIdx+1]
+				}
+			}
+		}
+	} else {
+		// Not quoted, check for inline comment
+		if idx := strings.Index(value, " #"); idx != -1 {
+			comment = strings.TrimSpace(value[idx+2:])
+			value = strings.TrimSpace(value[:idx])
+		}
+	}
+	return value, comment
+}
+
+// openQuote returns the quote a value starts with when it is not closed on
+// the same line
+func openQuote(value string) (byte, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		return 0, false
+	}
+	return value[0], closeQuote(value[1:], value[0]) == -1
+}
+
+// closeQuote returns the index of the quote closing a value in line, or -1.
+// Quotes escaped with a backslash do not close double-quoted values.
+func closeQuote(line string, quote byte) int {
+	for i := 0; i < len(line); i++ {
+		switch {
+		case quote == '"' && line[i] == '\\':
+			i++
+		case line[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// readMultiline reads the lines of a multiline value up to its closing
+// quote. It returns the lines, the last one ending with the quote, and the
+// text after the quote. A value is not closed if the input ends first or
+// anything but a comment follows the quote; the lines are then returned
+// as they were read.
+func readMultiline(next func() (string, bool), quote byte) ([]string, string, bool) {
+	var lines []string
+	for {
+		line, ok := next()
+		if !ok {
+			return lines, "", false
+		}
+		idx := closeQuote(line, quote)
+		if idx == -1 {
+			lines = append(lines, line)
+			continue
+		}
+		tail := line[idx+1:]
+		if t := strings.TrimSpace(tail); t != "" && !strings.HasPrefix(t, "#") {
+			return append(lines, line), "", false
+		}
+		return append(lines, line[:idx+1]), tail, true
+	}
+}
+
+// tailComment returns the comment in the text after a closing quote
+func tailComment(tail string) string {
+	tail = strings.TrimSpace(tail)
+	if !strings.HasPrefix(tail, "#") {
+		return ""
+	}
+	return strings.TrimSpace(tail[1:])
+}
+
+// unquote removes the quotes of a value. Escape sequences are expanded in
+// double-quoted values only: \n, \r, \t, \" and \\. Other backslashes are
+// kept as they are.
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return trimQuotes(s)
+	}
+	s = s[1 : len(s)-1]
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '"', '\\':
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
 // trimQuotes removes surrounding quotes from a value
 func trimQuotes(s string) string {
 	if len(s) >= 2 {
@@ -357,23 +555,25 @@ func trimQuotes(s string) string {
 	return s
 }
 
-// formatValue adds quotes if necessary
+// formatValue adds quotes if necessary. Newlines are escaped so that
+// multiline values are written on one line and read back unchanged.
 func formatValue(value string) string {
 	// Check if value needs quotes
 	needsQuotes := false
-	if strings.ContainsAny(value, " \t#\"'") || value == "" {
+	if strings.ContainsAny(value, " \t#\"'\n\r") || value == "" {
 		needsQuotes = true
 	}
 
 	if needsQuotes {
-		// Escape existing quotes
-		value = strings.ReplaceAll(value, `"`, `\"`)
-		return fmt.Sprintf(`"%s"`, value)
+		return `"` + valueEscaper.Replace(value) + `"`
 	}
 
 	return value
 }
 
+// valueEscaper escapes a value for double quotes, the reverse of unquote
+var valueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+
 // ParseLarge parses a large .env file using streaming approach
 func ParseLarge(r io.Reader) (*File, error) {
 	return ParseLargeWithContext(context.Background(), r)
@@ -0,0 +1,105 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// ErrNoAgeIdentity is returned by Keyring.Age when no age identity is set
+var ErrNoAgeIdentity = errors.New("no age identity is configured")
+
+// AgeWrapper wraps data keys for age recipients, so each of them can
+// unwrap the keys with their own identity and no key has to be shared
+type AgeWrapper struct {
+	recipients []age.Recipient
+	identities []age.Identity
+	keyID      string
+}
+
+// NewAgeWrapper returns a wrapper encrypting to age recipients, given as
+// age1... public keys
+func NewAgeWrapper(recipients []string) (*AgeWrapper, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no age recipients")
+	}
+	w := &AgeWrapper{}
+	for _, text := range recipients {
+		recipient, err := age.ParseX25519Recipient(strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %q: %w", text, err)
+		}
+		w.recipients = append(w.recipients, recipient)
+	}
+	w.keyID = "age:" + ageFingerprint(recipients)
+	return w, nil
+}
+
+// ParseAgeIdentities reads age identities, one AGE-SECRET-KEY-1... per line
+// as in the key files of age-keygen
+func ParseAgeIdentities(r io.Reader) ([]age.Identity, error) {
+	identities, err := age.ParseIdentities(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid age identity: %w", err)
+	}
+	return identities, nil
+}
+
+// KeyID returns age:<fingerprint of the recipients>
+func (w *AgeWrapper) KeyID() string {
+	return w.keyID
+}
+
+// Wrap encrypts a data key to every recipient
+func (w *AgeWrapper) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	if len(w.recipients) == 0 {
+		return nil, fmt.Errorf("no age recipients to encrypt to")
+	}
+	var buf bytes.Buffer
+	out, err := age.Encrypt(&buf, w.recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt data key with age: %w", err)
+	}
+	if _, err := out.Write(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to encrypt data key with age: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encrypt data key with age: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Unwrap decrypts a data key with any of the identities
+func (w *AgeWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if len(w.identities) == 0 {
+		return nil, ErrNoAgeIdentity
+	}
+	in, err := age.Decrypt(bytes.NewReader(wrapped), w.identities...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key with age: %w", err)
+	}
+	dataKey, err := io.ReadAll(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key with age: %w", err)
+	}
+	return dataKey, nil
+}
+
+// ageFingerprint identifies a set of recipients, whatever their order
+func ageFingerprint(recipients []string) string {
+	sorted := make([]string, 0, len(recipients))
+	for _, recipient := range recipients {
+		sorted = append(sorted, strings.TrimSpace(recipient))
+	}
+	sort.Strings(sorted)
+	sum := sha256.Sum256([]byte("envy age recipients\x00" + strings.Join(sorted, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
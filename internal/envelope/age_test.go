@@ -0,0 +1,56 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgeWrapper(t *testing.T) {
+	alice, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	bob, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	recipients := []string{alice.Recipient().String(), bob.Recipient().String()}
+
+	wrapper, err := NewAgeWrapper(recipients)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(wrapper.KeyID(), "age:"))
+	reversed, err := NewAgeWrapper([]string{recipients[1], recipients[0]})
+	require.NoError(t, err)
+	assert.Equal(t, wrapper.KeyID(), reversed.KeyID(), "the order of recipients does not matter")
+
+	dataKey, err := NewDataKey()
+	require.NoError(t, err)
+	wrapped, err := wrapper.Wrap(context.Background(), dataKey)
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(wrapped, dataKey))
+
+	// Every recipient unwraps the key with their own identity
+	for _, identity := range []*age.X25519Identity{alice, bob} {
+		keyring := &Keyring{AgeIdentities: func() ([]age.Identity, error) {
+			return ParseAgeIdentities(strings.NewReader(identity.String() + "\n"))
+		}}
+		unwrapper, err := keyring.Wrapper(wrapper.KeyID())
+		require.NoError(t, err)
+		unwrapped, err := unwrapper.Unwrap(context.Background(), wrapped)
+		require.NoError(t, err)
+		assert.Equal(t, dataKey, unwrapped)
+	}
+
+	stranger, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	keyring := &Keyring{AgeIdentities: func() ([]age.Identity, error) { return []age.Identity{stranger}, nil }}
+	unwrapper, err := keyring.Wrapper(wrapper.KeyID())
+	require.NoError(t, err)
+	_, err = unwrapper.Unwrap(context.Background(), wrapped)
+	assert.Error(t, err)
+
+	_, err = NewAgeWrapper([]string{"age1notakey"})
+	assert.Error(t, err)
+}
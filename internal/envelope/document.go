@@ -0,0 +1,71 @@
+package envelope
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// documentVersion is the version of the document format
+const documentVersion = 1
+
+// Document is data encrypted with its own data key, written by envy
+// encrypt as JSON, so it can be committed or attached to a ticket
+type Document struct {
+	Version    int    `json:"envy_envelope"`
+	Key        string `json:"key"`      // the key encryption key, as Wrapper.KeyID
+	DataKey    []byte `json:"data_key"` // the wrapped data key
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Encrypt encrypts plaintext under a new data key wrapped by w
+func Encrypt(ctx context.Context, w Wrapper, plaintext []byte) (*Document, error) {
+	dataKey, err := NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := w.Wrap(ctx, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := Seal(dataKey, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{Version: documentVersion, Key: w.KeyID(), DataKey: wrapped, Ciphertext: ciphertext}, nil
+}
+
+// Decrypt returns the plaintext of the document, unwrapping its data key
+// with the key of keyring it names
+func (d *Document) Decrypt(ctx context.Context, keyring *Keyring) ([]byte, error) {
+	w, err := keyring.Wrapper(d.Key)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := w.Unwrap(ctx, d.DataKey)
+	if err != nil {
+		return nil, err
+	}
+	return Open(dataKey, d.Ciphertext)
+}
+
+// Marshal encodes the document as indented JSON
+func (d *Document) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// ParseDocument decodes a document written by Marshal
+func ParseDocument(data []byte) (*Document, error) {
+	var d Document
+	if err := json.Unmarshal(data, &d); err != nil || d.Version == 0 {
+		return nil, fmt.Errorf("not an envy envelope")
+	}
+	if d.Version != documentVersion {
+		return nil, fmt.Errorf("unsupported envelope version %d", d.Version)
+	}
+	return &d, nil
+}
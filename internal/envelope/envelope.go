@@ -0,0 +1,315 @@
+// Package envelope encrypts data with AES-256-GCM under a random data key
+// that is itself encrypted, or wrapped, by a key encryption key: a KMS key,
+// age recipients, or a local key file for machines without AWS. Only the
+// wrapped data key is kept with the data, so the key encryption key never
+// leaves KMS, the age identities or the key file.
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+
+	"github.com/drapon/envy/internal/aws/kms"
+)
+
+// KeySize is the size of data keys and local keys
+const KeySize = 32
+
+// ErrDecrypt is returned when data cannot be decrypted: it was changed, or
+// encrypted with another key
+var ErrDecrypt = errors.New("decryption failed: the data was modified or encrypted with another key")
+
+// Wrapper encrypts data keys with a key encryption key
+type Wrapper interface {
+	// KeyID names the key encryption key, as kms:<key ARN>,
+	// age:<fingerprint> or file:<fingerprint>, so data records which key it
+	// needs
+	KeyID() string
+	Wrap(ctx context.Context, dataKey []byte) ([]byte, error)
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// NewDataKey returns a random data key
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	return key, nil
+}
+
+// Seal encrypts plaintext with AES-256-GCM, returning the random nonce
+// followed by the ciphertext
+func Seal(key, plaintext []byte) ([]byte, error) {
+	return SealWithData(key, plaintext, nil)
+}
+
+// SealWithData is Seal binding additionalData to the ciphertext: the data
+// is not encrypted, but OpenWithData fails unless it is given unchanged
+func SealWithData(key, plaintext, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Open decrypts the output of Seal
+func Open(key, sealed []byte) ([]byte, error) {
+	return OpenWithData(key, sealed, nil)
+}
+
+// OpenWithData decrypts the output of SealWithData
+func OpenWithData(key, sealed, additionalData []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], additionalData)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key size %d (want %d bytes)", len(key), KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// kmsContext is bound to the data keys wrapped with KMS, so KMS refuses to
+// decrypt them for anything that asks without it
+var kmsContext = map[string]string{"envy": "data-key"}
+
+// KMSWrapper wraps data keys with a symmetric KMS key
+type KMSWrapper struct {
+	client *kms.Client
+	keyID  string
+}
+
+// NewKMSWrapper returns a wrapper using a KMS key given by ID, ARN or alias
+func NewKMSWrapper(client *kms.Client, keyID string) *KMSWrapper {
+	return &KMSWrapper{client: client, keyID: keyID}
+}
+
+// KeyID returns kms:<key>. After the first Wrap the key is the ARN KMS
+// reported, which also names its region.
+func (w *KMSWrapper) KeyID() string {
+	return "kms:" + w.keyID
+}
+
+// Wrap encrypts a data key with KMS
+func (w *KMSWrapper) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	wrapped, arn, err := w.client.Encrypt(ctx, w.keyID, dataKey, kmsContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt data key with %s: %w", w.keyID, err)
+	}
+	if arn != "" {
+		w.keyID = arn
+	}
+	return wrapped, nil
+}
+
+// Unwrap decrypts a data key with KMS
+func (w *KMSWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	dataKey, err := w.client.Decrypt(ctx, w.keyID, wrapped, kmsContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key with %s: %w", w.keyID, err)
+	}
+	return dataKey, nil
+}
+
+// ErrNoLocalKey is returned by Keyring.Local when no local key is set
+var ErrNoLocalKey = errors.New("no local key is configured")
+
+// Keyring finds the wrappers of key encryption keys, so data can be
+// decrypted with the key it names
+type Keyring struct {
+	// KMSClient returns a client for a KMS key. It is called once; keys
+	// given by ARN are used in their own region.
+	KMSClient func(keyID string) (*kms.Client, error)
+	// LocalKey returns the local key, or an error wrapping ErrNoLocalKey
+	// when there is none. It is called once.
+	LocalKey func() ([]byte, error)
+	// AgeIdentities returns the age identities, or an error wrapping
+	// ErrNoAgeIdentity when there are none. It is called once.
+	AgeIdentities func() ([]age.Identity, error)
+
+	client *kms.Client
+	local  *LocalWrapper
+	age    *AgeWrapper
+}
+
+// KMS returns a wrapper using a KMS key given by ID, ARN or alias
+func (k *Keyring) KMS(keyID string) (*KMSWrapper, error) {
+	if k.client == nil {
+		if k.KMSClient == nil {
+			return nil, fmt.Errorf("KMS is not available")
+		}
+		client, err := k.KMSClient(keyID)
+		if err != nil {
+			return nil, err
+		}
+		k.client = client
+	}
+	return NewKMSWrapper(k.client, keyID), nil
+}
+
+// Local returns a wrapper using the local key
+func (k *Keyring) Local() (*LocalWrapper, error) {
+	if k.local == nil {
+		if k.LocalKey == nil {
+			return nil, ErrNoLocalKey
+		}
+		key, err := k.LocalKey()
+		if err != nil {
+			return nil, err
+		}
+		if k.local, err = NewLocalWrapper(key); err != nil {
+			return nil, err
+		}
+	}
+	return k.local, nil
+}
+
+// Age returns a wrapper decrypting with the age identities
+func (k *Keyring) Age() (*AgeWrapper, error) {
+	if k.age == nil {
+		if k.AgeIdentities == nil {
+			return nil, ErrNoAgeIdentity
+		}
+		identities, err := k.AgeIdentities()
+		if err != nil {
+			return nil, err
+		}
+		k.age = &AgeWrapper{identities: identities}
+	}
+	return k.age, nil
+}
+
+// Wrapper returns the wrapper of a key named as by Wrapper.KeyID
+func (k *Keyring) Wrapper(keyID string) (Wrapper, error) {
+	kind, id, _ := strings.Cut(keyID, ":")
+	switch kind {
+	case "kms":
+		return k.KMS(id)
+	case "age":
+		wrapper, err := k.Age()
+		if err != nil {
+			return nil, fmt.Errorf("encrypted for age recipients %s: %w", id, err)
+		}
+		return wrapper, nil
+	case "file":
+		local, err := k.Local()
+		if err != nil {
+			return nil, fmt.Errorf("encrypted with local key %s: %w", id, err)
+		}
+		if local.KeyID() != keyID {
+			return nil, fmt.Errorf("encrypted with local key %s, but the configured key is %s", id, strings.TrimPrefix(local.KeyID(), "file:"))
+		}
+		return local, nil
+	}
+	return nil, fmt.Errorf("unsupported key %s", keyID)
+}
+
+// LocalWrapper wraps data keys with a key read from a file
+type LocalWrapper struct {
+	key []byte
+}
+
+// NewLocalWrapper returns a wrapper using a 32-byte local key
+func NewLocalWrapper(key []byte) (*LocalWrapper, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid local key size %d (want %d bytes)", len(key), KeySize)
+	}
+	return &LocalWrapper{key: key}, nil
+}
+
+// KeyID returns file:<fingerprint>
+func (w *LocalWrapper) KeyID() string {
+	return "file:" + Fingerprint(w.key)
+}
+
+// Wrap encrypts a data key with the local key
+func (w *LocalWrapper) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	return Seal(w.key, dataKey)
+}
+
+// Unwrap decrypts a data key with the local key
+func (w *LocalWrapper) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	return Open(w.key, wrapped)
+}
+
+// Fingerprint identifies a local key without revealing it
+func Fingerprint(key []byte) string {
+	sum := sha256.Sum256(append([]byte("envy local key\x00"), key...))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParseKey decodes a hex-encoded local key, as in key files
+func ParseKey(text string) ([]byte, error) {
+	key, err := hex.DecodeString(strings.TrimSpace(text))
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("invalid local key: want %d hex-encoded bytes", KeySize)
+	}
+	return key, nil
+}
+
+// LoadKeyFile reads a local key file
+func LoadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	key, err := ParseKey(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// GenerateKeyFile writes a new local key to path, readable only by the
+// user. An existing file is never overwritten.
+func GenerateKeyFile(path string) error {
+	key, err := NewDataKey()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create key file: %w", err)
+	}
+	if _, err := fmt.Fprintln(file, hex.EncodeToString(key)); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	return file.Close()
+}
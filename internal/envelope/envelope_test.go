@@ -0,0 +1,90 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	key, err := NewDataKey()
+	require.NoError(t, err)
+
+	sealed, err := Seal(key, []byte("postgres://app:hunter2@db/app"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "hunter2")
+
+	plaintext, err := Open(key, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://app:hunter2@db/app", string(plaintext))
+
+	sealed[len(sealed)-1] ^= 1
+	_, err = Open(key, sealed)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	other, err := NewDataKey()
+	require.NoError(t, err)
+	sealed, err = Seal(key, []byte("x"))
+	require.NoError(t, err)
+	_, err = Open(other, sealed)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = Seal([]byte("short"), []byte("x"))
+	assert.Error(t, err)
+}
+
+func TestSealOpenWithData(t *testing.T) {
+	key, err := NewDataKey()
+	require.NoError(t, err)
+
+	sealed, err := SealWithData(key, []byte("hunter2"), []byte("DB_PASSWORD"))
+	require.NoError(t, err)
+
+	plaintext, err := OpenWithData(key, sealed, []byte("DB_PASSWORD"))
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", string(plaintext))
+
+	_, err = OpenWithData(key, sealed, []byte("API_KEY"))
+	assert.ErrorIs(t, err, ErrDecrypt)
+	_, err = Open(key, sealed)
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestLocalWrapper(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "inline.key")
+	require.NoError(t, GenerateKeyFile(path))
+	assert.Error(t, GenerateKeyFile(path), "existing keys are never overwritten")
+
+	key, err := LoadKeyFile(path)
+	require.NoError(t, err)
+	wrapper, err := NewLocalWrapper(key)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(wrapper.KeyID(), "file:"))
+	assert.Len(t, wrapper.KeyID(), len("file:")+16)
+
+	dataKey, err := NewDataKey()
+	require.NoError(t, err)
+	wrapped, err := wrapper.Wrap(context.Background(), dataKey)
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(wrapped, dataKey))
+
+	unwrapped, err := wrapper.Unwrap(context.Background(), wrapped)
+	require.NoError(t, err)
+	assert.Equal(t, dataKey, unwrapped)
+}
+
+func TestParseKey(t *testing.T) {
+	_, err := ParseKey("not hex")
+	assert.Error(t, err)
+	_, err = ParseKey("abcd")
+	assert.Error(t, err)
+
+	key, err := ParseKey(strings.Repeat("ab", KeySize) + "\n")
+	require.NoError(t, err)
+	assert.Len(t, key, KeySize)
+}
@@ -0,0 +1,17 @@
+//go:build !slim
+
+package errors
+
+import (
+	"github.com/fatih/color"
+)
+
+// Color helpers used by the formatter. The slim build replaces these with
+// plain fmt equivalents (see colors_slim.go).
+var (
+	redString    = color.RedString
+	yellowString = color.YellowString
+	cyanString   = color.CyanString
+	greenString  = color.GreenString
+	errorOutput  = color.Error
+)
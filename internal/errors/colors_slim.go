@@ -0,0 +1,17 @@
+//go:build slim
+
+package errors
+
+import (
+	"fmt"
+	"os"
+)
+
+// Plain-text stand-ins for the colored helpers used by the formatter.
+var (
+	redString    = fmt.Sprintf
+	yellowString = fmt.Sprintf
+	cyanString   = fmt.Sprintf
+	greenString  = fmt.Sprintf
+	errorOutput  = os.Stderr
+)
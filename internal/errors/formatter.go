@@ -4,8 +4,6 @@ import (
 	stderrors "errors"
 	"fmt"
 	"strings"
-
-	"github.com/fatih/color"
 )
 
 // Formatter provides error formatting functionality
@@ -38,7 +36,7 @@ func (f *Formatter) Format(err error) string {
 
 	// Error header with code
 	if f.useColor {
-		builder.WriteString(color.RedString("エラー [%s]: ", envyErr.Code))
+		builder.WriteString(redString("エラー [%s]: ", envyErr.Code))
 	} else {
 		builder.WriteString(fmt.Sprintf("エラー [%s]: ", envyErr.Code))
 	}
@@ -66,7 +64,7 @@ func (f *Formatter) Format(err error) string {
 	if suggestion != "" {
 		builder.WriteString("\n")
 		if f.useColor {
-			builder.WriteString(color.YellowString("対処法: "))
+			builder.WriteString(yellowString("対処法: "))
 		} else {
 			builder.WriteString("対処法: ")
 		}
@@ -78,7 +76,7 @@ func (f *Formatter) Format(err error) string {
 	if envyErr.Retriable {
 		builder.WriteString("\n")
 		if f.useColor {
-			builder.WriteString(color.CyanString("このエラーは一時的なものです。再試行してください。"))
+			builder.WriteString(cyanString("このエラーは一時的なものです。再試行してください。"))
 		} else {
 			builder.WriteString("このエラーは一時的なものです。再試行してください。")
 		}
@@ -100,7 +98,7 @@ func (f *Formatter) FormatShort(err error) string {
 	}
 
 	if f.useColor {
-		return color.RedString("[%s] %s", envyErr.Code, envyErr.UserMessage())
+		return redString("[%s] %s", envyErr.Code, envyErr.UserMessage())
 	}
 	return fmt.Sprintf("[%s] %s", envyErr.Code, envyErr.UserMessage())
 }
@@ -114,7 +112,7 @@ func (f *Formatter) FormatMultiple(errors []error) string {
 	var builder strings.Builder
 
 	if f.useColor {
-		builder.WriteString(color.RedString("複数のエラーが発生しました:\n"))
+		builder.WriteString(redString("複数のエラーが発生しました:\n"))
 	} else {
 		builder.WriteString("複数のエラーが発生しました:\n")
 	}
@@ -181,28 +179,28 @@ func (f *Formatter) getSuggestion(err *EnvyError) string {
 // PrintError prints an error to stderr with formatting.
 func PrintError(err error) {
 	formatter := NewFormatter(true, false)
-	_, _ = fmt.Fprintln(color.Error, formatter.Format(err))
+	_, _ = fmt.Fprintln(errorOutput, formatter.Format(err))
 }
 
 // PrintErrorVerbose prints an error with verbose information.
 func PrintErrorVerbose(err error) {
 	formatter := NewFormatter(true, true)
-	_, _ = fmt.Fprintln(color.Error, formatter.Format(err))
+	_, _ = fmt.Fprintln(errorOutput, formatter.Format(err))
 }
 
 // PrintWarning prints a warning message.
 func PrintWarning(message string) {
-	_, _ = fmt.Fprintln(color.Error, color.YellowString("警告: %s", message))
+	_, _ = fmt.Fprintln(errorOutput, yellowString("警告: %s", message))
 }
 
 // PrintSuccess prints a success message.
 func PrintSuccessf(format string, args ...interface{}) {
-	fmt.Println(color.GreenString("✓ "+format, args...))
+	fmt.Println(greenString("✓ "+format, args...))
 }
 
 // PrintInfo prints an info message.
 func PrintInfof(format string, args ...interface{}) {
-	fmt.Println(color.CyanString("ℹ "+format, args...))
+	fmt.Println(cyanString("ℹ "+format, args...))
 }
 
 // ErrorContext provides additional context for errors
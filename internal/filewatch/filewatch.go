@@ -0,0 +1,99 @@
+// Package filewatch detects changes to a set of files by polling them.
+// Polling behaves the same on every platform, notices files that are created
+// or deleted, and survives editors that save by replacing the file.
+package filewatch
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"sort"
+	"time"
+)
+
+// DefaultInterval is how often files are checked unless told otherwise
+const DefaultInterval = time.Second
+
+// state is what is known of a file at the last check
+type state struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+	sum     [sha256.Size]byte
+}
+
+// Watcher reports files whose content changed since the previous check
+type Watcher struct {
+	interval time.Duration
+	states   map[string]state
+}
+
+// New returns a watcher of paths, recording their current state. Paths that
+// do not exist yet are reported once they are created.
+func New(paths []string, interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	w := &Watcher{interval: interval, states: make(map[string]state, len(paths))}
+	for _, path := range paths {
+		w.states[path] = read(path, state{})
+	}
+	return w
+}
+
+// Check returns the files, sorted, that were created, deleted or whose
+// content changed since the previous check. Files whose modification time
+// changed but whose content did not are not reported.
+func (w *Watcher) Check() []string {
+	var changed []string
+	for path, previous := range w.states {
+		current := read(path, previous)
+		w.states[path] = current
+		if current.exists != previous.exists || current.sum != previous.sum {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Run checks the files every interval until ctx is cancelled, calling
+// onChange with the files that changed
+func (w *Watcher) Run(ctx context.Context, onChange func(changed []string)) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if changed := w.Check(); len(changed) > 0 {
+			onChange(changed)
+		}
+	}
+}
+
+// read returns the state of a file. The content is only hashed again when
+// its size or modification time differ from previous.
+func read(path string, previous state) state {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return state{}
+	}
+
+	current := state{exists: true, size: info.Size(), modTime: info.ModTime()}
+	if previous.exists && previous.size == current.size && previous.modTime.Equal(current.modTime) {
+		current.sum = previous.sum
+		return current
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// e.g. removed between the stat and the read; check again next time
+		return state{}
+	}
+	current.sum = sha256.Sum256(data)
+	return current
+}
@@ -0,0 +1,55 @@
+package filewatch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher_Check(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, ".env")
+	missing := filepath.Join(dir, ".env.local")
+	require.NoError(t, os.WriteFile(existing, []byte("A=1\n"), 0600))
+
+	w := New([]string{existing, missing}, 0)
+	assert.Empty(t, w.Check())
+
+	// Touching a file without changing it is not a change
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(existing, later, later))
+	assert.Empty(t, w.Check())
+
+	require.NoError(t, os.WriteFile(existing, []byte("A=2\n"), 0600))
+	require.NoError(t, os.WriteFile(missing, []byte("B=1\n"), 0600))
+	assert.Equal(t, []string{existing, missing}, w.Check())
+	assert.Empty(t, w.Check())
+
+	require.NoError(t, os.Remove(missing))
+	assert.Equal(t, []string{missing}, w.Check())
+}
+
+func TestWatcher_Run(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("A=1\n"), 0600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan []string, 1)
+	w := New([]string{path}, 10*time.Millisecond)
+	go w.Run(ctx, func(changed []string) { changes <- changed })
+
+	require.NoError(t, os.WriteFile(path, []byte("A=22\n"), 0600))
+	select {
+	case changed := <-changes:
+		assert.Equal(t, []string{path}, changed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("change was not reported")
+	}
+}
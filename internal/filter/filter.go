@@ -0,0 +1,187 @@
+// Package filter provides the variable selection shared by push, pull,
+// export, list and diff.
+//
+// A key is selected when it passes every configured criterion:
+//
+//   - Include: matches at least one pattern (if any are given)
+//   - Keys: is one of the listed names (if any are given)
+//   - Exclude: matches none of the patterns
+//   - Sensitivity: is (or is not) classified as sensitive
+//
+// Patterns use the syntax of the pattern package, and sensitivity the
+// classification of the sensitive package.
+package filter
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/pattern"
+	"github.com/drapon/envy/internal/sensitive"
+)
+
+// Sensitivity selects variables by their sensitivity classification
+type Sensitivity int
+
+const (
+	// SensitivityAll keeps variables regardless of classification
+	SensitivityAll Sensitivity = iota
+	// SensitivityOnly keeps only sensitive variables
+	SensitivityOnly
+	// SensitivityNone drops sensitive variables
+	SensitivityNone
+)
+
+// Options describes a filter. The zero value matches every key.
+type Options struct {
+	Include     []string
+	Exclude     []string
+	Keys        []string
+	Sensitivity Sensitivity
+}
+
+// Filter selects environment variables by key
+type Filter struct {
+	include     []pattern.Matcher
+	exclude     []pattern.Matcher
+	keys        map[string]bool
+	sensitivity Sensitivity
+	isSensitive func(string) bool
+}
+
+// New compiles filter options, returning an error for invalid patterns
+func New(opts Options) (*Filter, error) {
+	f := &Filter{
+		sensitivity: opts.Sensitivity,
+		isSensitive: sensitive.IsSensitive,
+	}
+
+	var err error
+	if f.include, err = pattern.CompileAll(opts.Include); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = pattern.CompileAll(opts.Exclude); err != nil {
+		return nil, err
+	}
+
+	for _, key := range splitList(opts.Keys) {
+		if f.keys == nil {
+			f.keys = make(map[string]bool)
+		}
+		f.keys[key] = true
+	}
+
+	return f, nil
+}
+
+// WithClassifier replaces the function used to classify sensitive keys
+func (f *Filter) WithClassifier(isSensitive func(string) bool) *Filter {
+	if isSensitive != nil {
+		f.isSensitive = isSensitive
+	}
+	return f
+}
+
+// Active reports whether the filter can drop any key
+func (f *Filter) Active() bool {
+	if f == nil {
+		return false
+	}
+	return len(f.include) > 0 || len(f.exclude) > 0 || len(f.keys) > 0 || f.sensitivity != SensitivityAll
+}
+
+// Match reports whether a key passes the filter
+func (f *Filter) Match(key string) bool {
+	if !f.Active() {
+		return true
+	}
+
+	if len(f.include) > 0 && !pattern.MatchAny(f.include, key) {
+		return false
+	}
+
+	if len(f.keys) > 0 && !f.keys[key] {
+		return false
+	}
+
+	if pattern.MatchAny(f.exclude, key) {
+		return false
+	}
+
+	switch f.sensitivity {
+	case SensitivityOnly:
+		return f.isSensitive(key)
+	case SensitivityNone:
+		return !f.isSensitive(key)
+	}
+
+	return true
+}
+
+// ApplyFile returns a new file with only the matching variables, keeping
+// their order and comments
+func (f *Filter) ApplyFile(file *env.File) *env.File {
+	if !f.Active() || file == nil {
+		return file
+	}
+
+	result := env.NewFile()
+	for _, key := range file.Order {
+		variable, ok := file.Variables[key]
+		if !ok || !f.Match(key) {
+			continue
+		}
+		copied := *variable
+		result.Variables[key] = &copied
+		result.Order = append(result.Order, key)
+	}
+
+	return result
+}
+
+// ApplyMap returns a new map with only the matching variables
+func (f *Filter) ApplyMap(vars map[string]string) map[string]string {
+	if !f.Active() {
+		return vars
+	}
+
+	result := make(map[string]string, len(vars))
+	for key, value := range vars {
+		if f.Match(key) {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// MissingKeys returns the explicitly listed keys not present in file
+func (f *Filter) MissingKeys(file *env.File) []string {
+	if f == nil || len(f.keys) == 0 {
+		return nil
+	}
+
+	var missing []string
+	for key := range f.keys {
+		if _, ok := file.Get(key); !ok {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+
+	return missing
+}
+
+// splitList flattens comma-separated entries and drops blanks
+func splitList(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
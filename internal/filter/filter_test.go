@@ -0,0 +1,122 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"glob prefix", "DB_*", "DB_HOST", true},
+		{"glob is case-insensitive", "db_*", "DB_HOST", true},
+		{"glob matches whole key", "DB_*", "MY_DB_HOST", false},
+		{"glob single char", "API_?", "API_1", true},
+		{"slash regex", "/^(DB|REDIS)_/", "REDIS_URL", true},
+		{"re prefix", "re:_URL$", "DATABASE_URL", true},
+		{"glob prefix without wildcard", "glob:DB", "MY_DB_HOST", false},
+		{"detected regex", "^DB_", "MY_DB_HOST", false},
+		{"substring", "DB", "MY_DB_HOST", true},
+		{"substring is case-insensitive", "db_", "DB_HOST", true},
+		{"substring no match", "DB_", "APP_NAME", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := New(Options{Include: []string{tt.pattern}})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, f.Match(tt.key))
+		})
+	}
+}
+
+func TestInvalidPatterns(t *testing.T) {
+	_, err := New(Options{Include: []string{"/(unclosed/"}})
+	assert.Error(t, err)
+
+	_, err = New(Options{Exclude: []string{"DB_[*"}})
+	assert.Error(t, err)
+}
+
+func TestCombinedCriteria(t *testing.T) {
+	f, err := New(Options{
+		Include: []string{"DB_*", "APP_*"},
+		Exclude: []string{"PASSWORD"},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, f.Match("DB_HOST"))
+	assert.True(t, f.Match("APP_NAME"))
+	assert.False(t, f.Match("DB_PASSWORD"))
+	assert.False(t, f.Match("REDIS_URL"))
+}
+
+func TestKeys(t *testing.T) {
+	f, err := New(Options{Keys: []string{"API_KEY, DATABASE_URL", "PORT"}})
+	require.NoError(t, err)
+
+	assert.True(t, f.Match("API_KEY"))
+	assert.True(t, f.Match("PORT"))
+	assert.False(t, f.Match("api_key"))
+	assert.False(t, f.Match("DEBUG"))
+
+	file := env.NewFile()
+	file.Set("API_KEY", "x")
+	file.Set("PORT", "8080")
+	assert.Equal(t, []string{"DATABASE_URL"}, f.MissingKeys(file))
+}
+
+func TestSensitivity(t *testing.T) {
+	only, err := New(Options{Sensitivity: SensitivityOnly})
+	require.NoError(t, err)
+	assert.True(t, only.Match("DB_PASSWORD"))
+	assert.False(t, only.Match("APP_NAME"))
+
+	none, err := New(Options{Sensitivity: SensitivityNone})
+	require.NoError(t, err)
+	assert.False(t, none.Match("DB_PASSWORD"))
+	assert.True(t, none.Match("APP_NAME"))
+
+	custom := none.WithClassifier(func(key string) bool { return key == "APP_NAME" })
+	assert.False(t, custom.Match("APP_NAME"))
+	assert.True(t, custom.Match("DB_PASSWORD"))
+}
+
+func TestInactiveFilter(t *testing.T) {
+	f, err := New(Options{Include: []string{"", "  "}})
+	require.NoError(t, err)
+	assert.False(t, f.Active())
+	assert.True(t, f.Match("ANYTHING"))
+
+	var nilFilter *Filter
+	assert.True(t, nilFilter.Match("ANYTHING"))
+}
+
+func TestApplyFile(t *testing.T) {
+	file := env.NewFile()
+	file.Set("B_VAR", "b")
+	file.Set("A_VAR", "a")
+	file.Set("OTHER", "o")
+
+	f, err := New(Options{Include: []string{"*_VAR"}})
+	require.NoError(t, err)
+
+	result := f.ApplyFile(file)
+	assert.Equal(t, []string{"B_VAR", "A_VAR"}, result.Keys())
+	assert.Len(t, file.Keys(), 3, "source file must not be modified")
+}
+
+func TestApplyMap(t *testing.T) {
+	f, err := New(Options{Exclude: []string{"SECRET"}})
+	require.NoError(t, err)
+
+	result := f.ApplyMap(map[string]string{"KEY1": "1", "SECRET_KEY": "s"})
+	assert.Equal(t, map[string]string{"KEY1": "1"}, result)
+}
@@ -0,0 +1,108 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/drapon/envy/internal/pattern"
+	"github.com/spf13/pflag"
+)
+
+// AddFlags registers the shared --filter, --exclude and --keys flags
+func AddFlags(fs *pflag.FlagSet, opts *Options) {
+	AddFlagsP(fs, opts, "", "")
+}
+
+// AddFlagsP is like AddFlags, but accepts shorthands for --filter and
+// --exclude for commands that already used them
+func AddFlagsP(fs *pflag.FlagSet, opts *Options, filterShorthand, excludeShorthand string) {
+	fs.StringArrayVarP(&opts.Include, "filter", filterShorthand, nil, "Only include keys matching pattern (glob, /regex/ or substring; repeatable)")
+	fs.StringArrayVarP(&opts.Exclude, "exclude", excludeShorthand, nil, "Exclude keys matching pattern (glob, /regex/ or substring; repeatable)")
+	fs.StringArrayVar(&opts.Keys, "keys", nil, "Comma-separated list of exact keys to include")
+}
+
+// AddDeprecatedAlias registers a hidden alias for one of the shared flags so
+// older command lines keep working
+func AddDeprecatedAlias(fs *pflag.FlagSet, name, shorthand, replacement string, target *[]string) {
+	fs.StringArrayVarP(target, name, shorthand, nil, "")
+	_ = fs.MarkDeprecated(name, "use --"+replacement+" instead")
+}
+
+// AddRegexFlags registers the shared flags for export, whose --include and
+// --exclude took regular expressions before the shared syntax existed. Both
+// keep doing so, --include as a deprecated alias of --filter; values written
+// as "/expr/", "re:expr" or "glob:pattern" are used as given.
+func AddRegexFlags(fs *pflag.FlagSet, opts *Options, includeShorthand, excludeShorthand string) {
+	fs.StringArrayVar(&opts.Include, "filter", nil, "Only include keys matching pattern (glob, /regex/ or substring; repeatable)")
+	fs.VarP(&regexArrayValue{target: &opts.Exclude}, "exclude", excludeShorthand, "Exclude keys matching regular expression (or glob:pattern; repeatable)")
+	fs.StringArrayVar(&opts.Keys, "keys", nil, "Comma-separated list of exact keys to include")
+	fs.VarP(&regexArrayValue{target: &opts.Include}, "include", includeShorthand, "")
+	_ = fs.MarkDeprecated("include", "use --filter instead")
+}
+
+// regexArrayValue is a repeatable flag whose values are regular
+// expressions unless they say otherwise
+type regexArrayValue struct {
+	target *[]string
+	values []string
+}
+
+func (v *regexArrayValue) String() string {
+	return "[" + strings.Join(v.values, ",") + "]"
+}
+
+func (v *regexArrayValue) Set(value string) error {
+	v.values = append(v.values, value)
+	if value != "" && !pattern.HasExplicitSyntax(value) {
+		value = "re:" + value
+	}
+	*v.target = append(*v.target, value)
+	return nil
+}
+
+func (v *regexArrayValue) Type() string {
+	return "stringArray"
+}
+
+// AddSensitivityFlags registers the --only-secrets and --no-secrets flags
+func AddSensitivityFlags(fs *pflag.FlagSet, opts *Options) {
+	only := fs.VarPF(&sensitivityValue{opts: opts, mode: SensitivityOnly}, "only-secrets", "", "Only include variables classified as sensitive")
+	only.NoOptDefVal = "true"
+	none := fs.VarPF(&sensitivityValue{opts: opts, mode: SensitivityNone}, "no-secrets", "", "Exclude variables classified as sensitive")
+	none.NoOptDefVal = "true"
+}
+
+// sensitivityValue is a boolean flag that selects a Sensitivity mode
+type sensitivityValue struct {
+	opts *Options
+	mode Sensitivity
+}
+
+func (v *sensitivityValue) String() string {
+	return strconv.FormatBool(v.opts != nil && v.opts.Sensitivity == v.mode)
+}
+
+func (v *sensitivityValue) Set(value string) error {
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+
+	if !enabled {
+		if v.opts.Sensitivity == v.mode {
+			v.opts.Sensitivity = SensitivityAll
+		}
+		return nil
+	}
+
+	if v.opts.Sensitivity != SensitivityAll && v.opts.Sensitivity != v.mode {
+		return fmt.Errorf("--only-secrets and --no-secrets cannot be used together")
+	}
+	v.opts.Sensitivity = v.mode
+	return nil
+}
+
+func (v *sensitivityValue) Type() string {
+	return "bool"
+}
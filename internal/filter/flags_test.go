@@ -0,0 +1,69 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddFlags(t *testing.T) {
+	var opts Options
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddFlagsP(fs, &opts, "f", "")
+	AddDeprecatedAlias(fs, "vars", "v", "keys", &opts.Keys)
+
+	err := fs.Parse([]string{
+		"-f", "DB_*",
+		"--filter", "/^(A|B)_/",
+		"--exclude", "PASSWORD",
+		"--vars", "API_KEY,PORT",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"DB_*", "/^(A|B)_/"}, opts.Include)
+	assert.Equal(t, []string{"PASSWORD"}, opts.Exclude)
+	assert.Equal(t, []string{"API_KEY,PORT"}, opts.Keys)
+	assert.True(t, fs.Lookup("vars").Hidden)
+}
+
+func TestAddRegexFlags(t *testing.T) {
+	var opts Options
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddRegexFlags(fs, &opts, "i", "x")
+
+	err := fs.Parse([]string{
+		"--filter", "DB_*",
+		"-i", "DB_.*",
+		"-x", "PASS",
+		"--exclude", "glob:*_TOKEN",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"DB_*", "re:DB_.*"}, opts.Include)
+	assert.Equal(t, []string{"re:PASS", "glob:*_TOKEN"}, opts.Exclude)
+	assert.True(t, fs.Lookup("include").Hidden)
+
+	f, err := New(opts)
+	require.NoError(t, err)
+	assert.True(t, f.Match("DB_HOST"))
+	assert.False(t, f.Match("DB_PASSWORD"))
+	assert.False(t, f.Match("DB_API_TOKEN"))
+	// Regular expressions stay case-sensitive
+	assert.True(t, f.Match("DB_pass"))
+}
+
+func TestAddSensitivityFlags(t *testing.T) {
+	var opts Options
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddSensitivityFlags(fs, &opts)
+
+	require.NoError(t, fs.Parse([]string{"--only-secrets"}))
+	assert.Equal(t, SensitivityOnly, opts.Sensitivity)
+
+	opts = Options{}
+	fs = pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddSensitivityFlags(fs, &opts)
+	assert.Error(t, fs.Parse([]string{"--only-secrets", "--no-secrets"}))
+}
@@ -0,0 +1,328 @@
+// Package format checks and normalizes values of the variable types that
+// validation rules can declare, such as int, url, hostname or uuid.
+package format
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is a built-in variable type
+type Format struct {
+	Name string
+	// Description completes "must be ...", e.g. "a valid hostname"
+	Description string
+	Example     string
+
+	check     func(value string) bool
+	normalize func(value string) string // nil when values have one spelling
+	fake      func(r *rand.Rand) string
+}
+
+var (
+	emailPattern    = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+	labelPattern    = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+	semverPattern   = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	arnPattern      = regexp.MustCompile(`^arn:aws[a-z-]*:[a-z0-9-]+:[a-z0-9-]*:(\d{12})?:.+$`)
+	durationPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)([dw])`)
+)
+
+var formats = map[string]Format{
+	"string": {
+		Name:        "string",
+		Description: "a string",
+		Example:     "hello",
+		check:       func(string) bool { return true },
+		fake:        func(r *rand.Rand) string { return randomString(r, 16) },
+	},
+	"int": {
+		Name:        "int",
+		Description: "an integer",
+		Example:     "42",
+		check: func(v string) bool {
+			_, err := strconv.Atoi(v)
+			return err == nil
+		},
+		normalize: func(v string) string {
+			n, _ := strconv.Atoi(v)
+			return strconv.Itoa(n)
+		},
+		fake: func(r *rand.Rand) string { return strconv.Itoa(r.Intn(1000)) },
+	},
+	"float": {
+		Name:        "float",
+		Description: "a float",
+		Example:     "0.5",
+		check: func(v string) bool {
+			_, err := strconv.ParseFloat(v, 64)
+			return err == nil
+		},
+		fake: func(r *rand.Rand) string { return strconv.FormatFloat(float64(r.Intn(10000))/100, 'f', 2, 64) },
+	},
+	"bool": {
+		Name:        "bool",
+		Description: "a boolean (true/false/1/0)",
+		Example:     "true",
+		check: func(v string) bool {
+			switch strings.ToLower(v) {
+			case "true", "false", "1", "0":
+				return true
+			}
+			return false
+		},
+		normalize: strings.ToLower,
+		fake:      func(r *rand.Rand) string { return strconv.FormatBool(r.Intn(2) == 0) },
+	},
+	"url": {
+		Name:        "url",
+		Description: "a valid URL",
+		Example:     "https://example.com/path",
+		check: func(v string) bool {
+			u, err := url.Parse(v)
+			return err == nil && u.Scheme != "" && u.Host != ""
+		},
+		fake: func(r *rand.Rand) string { return "https://" + word(r) + ".example.com/" + word(r) },
+	},
+	"email": {
+		Name:        "email",
+		Description: "a valid email address",
+		Example:     "ops@example.com",
+		check:       emailPattern.MatchString,
+		fake:        func(r *rand.Rand) string { return word(r) + "." + word(r) + "@example.com" },
+	},
+	"json": {
+		Name:        "json",
+		Description: "valid JSON",
+		Example:     `{"enabled": true}`,
+		check: func(v string) bool {
+			var js json.RawMessage
+			return json.Unmarshal([]byte(v), &js) == nil
+		},
+		fake: func(r *rand.Rand) string { return fmt.Sprintf(`{"%s": %d}`, word(r), r.Intn(100)) },
+	},
+	"hostname": {
+		Name:        "hostname",
+		Description: "a valid hostname",
+		Example:     "db.internal.example.com",
+		check:       isHostname,
+		normalize: func(v string) string {
+			return strings.ToLower(strings.TrimSuffix(v, "."))
+		},
+		fake: func(r *rand.Rand) string { return word(r) + "." + word(r) + ".example.internal" },
+	},
+	"ip": {
+		Name:        "ip",
+		Description: "a valid IPv4 or IPv6 address",
+		Example:     "10.0.0.1",
+		check:       func(v string) bool { return net.ParseIP(v) != nil },
+		normalize:   func(v string) string { return net.ParseIP(v).String() },
+		fake:        func(r *rand.Rand) string { return fmt.Sprintf("10.%d.%d.%d", r.Intn(256), r.Intn(256), 1+r.Intn(254)) },
+	},
+	"cidr": {
+		Name:        "cidr",
+		Description: "a valid CIDR block",
+		Example:     "10.0.0.0/16",
+		check: func(v string) bool {
+			_, _, err := net.ParseCIDR(v)
+			return err == nil
+		},
+		fake: func(r *rand.Rand) string { return fmt.Sprintf("10.%d.0.0/16", r.Intn(256)) },
+	},
+	"port": {
+		Name:        "port",
+		Description: "a port number (1-65535)",
+		Example:     "8080",
+		check: func(v string) bool {
+			n, err := strconv.Atoi(v)
+			return err == nil && n >= 1 && n <= 65535
+		},
+		normalize: func(v string) string {
+			n, _ := strconv.Atoi(v)
+			return strconv.Itoa(n)
+		},
+		fake: func(r *rand.Rand) string { return strconv.Itoa(1024 + r.Intn(64512)) },
+	},
+	"duration": {
+		Name:        "duration",
+		Description: "a duration such as 30s, 5m, 1h30m or 7d",
+		Example:     "1h30m",
+		check: func(v string) bool {
+			_, err := ParseDuration(v)
+			return err == nil
+		},
+		fake: func(r *rand.Rand) string { return strconv.Itoa(1+r.Intn(59)) + "m" },
+	},
+	"semver": {
+		Name:        "semver",
+		Description: "a semantic version such as 1.2.3",
+		Example:     "1.4.0-rc.1",
+		check:       semverPattern.MatchString,
+		fake:        func(r *rand.Rand) string { return fmt.Sprintf("%d.%d.%d", 1+r.Intn(9), r.Intn(20), r.Intn(20)) },
+	},
+	"base64": {
+		Name:        "base64",
+		Description: "valid base64",
+		Example:     "aGVsbG8=",
+		check:       isBase64,
+		fake: func(r *rand.Rand) string {
+			b := make([]byte, 24)
+			r.Read(b)
+			return base64.StdEncoding.EncodeToString(b)
+		},
+	},
+	"uuid": {
+		Name:        "uuid",
+		Description: "a valid UUID",
+		Example:     "3f2b8c1e-9a4d-4f6b-8e2a-1c5d7e9f0a3b",
+		check:       uuidPattern.MatchString,
+		normalize:   strings.ToLower,
+		fake: func(r *rand.Rand) string {
+			b := make([]byte, 16)
+			r.Read(b)
+			b[6] = b[6]&0x0f | 0x40
+			b[8] = b[8]&0x3f | 0x80
+			return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+		},
+	},
+	"aws-arn": {
+		Name:        "aws-arn",
+		Description: "a valid AWS ARN",
+		Example:     "arn:aws:iam::123456789012:role/app",
+		check:       arnPattern.MatchString,
+		fake:        func(r *rand.Rand) string { return fmt.Sprintf("arn:aws:iam::%012d:role/%s", r.Int63n(1e12), word(r)) },
+	},
+}
+
+// Lookup returns the format with the given type name
+func Lookup(name string) (Format, bool) {
+	f, ok := formats[name]
+	return f, ok
+}
+
+// Names returns the names of all formats, sorted
+func Names() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Valid reports whether value is of the format
+func (f Format) Valid(value string) bool {
+	return f.check(value)
+}
+
+// Fake returns a realistic random value of the format, e.g. for test
+// fixtures
+func (f Format) Fake(r *rand.Rand) string {
+	return f.fake(r)
+}
+
+// Normalize returns value in its canonical spelling, e.g. a hostname in
+// lowercase, with surrounding whitespace removed. Values that would still
+// be invalid are returned unchanged, so validation reports them as given.
+func (f Format) Normalize(value string) string {
+	if f.Name == "string" || f.Name == "json" {
+		return value
+	}
+
+	normalized := strings.TrimSpace(value)
+	if !f.check(normalized) {
+		return value
+	}
+	if f.normalize != nil {
+		normalized = f.normalize(normalized)
+	}
+	return normalized
+}
+
+// ParseDuration parses a duration like "30s", "1h30m" or "7d". On top of
+// the units time.ParseDuration accepts, "d" (24h) and "w" (7d) are allowed.
+func ParseDuration(s string) (time.Duration, error) {
+	value := strings.TrimSpace(s)
+	if value == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	var days float64
+	rest := durationPattern.ReplaceAllStringFunc(value, func(match string) string {
+		n, _ := strconv.ParseFloat(match[:len(match)-1], 64)
+		if strings.HasSuffix(match, "w") {
+			n *= 7
+		}
+		days += n
+		return ""
+	})
+
+	var d time.Duration
+	if rest != "" {
+		var err error
+		if d, err = time.ParseDuration(rest); err != nil {
+			return 0, fmt.Errorf("invalid duration %q (use a number and unit such as 30s, 5m, 1h30m or 7d)", s)
+		}
+	}
+	if days > 0 {
+		if days*24 > math.MaxInt64/float64(time.Hour) {
+			return 0, fmt.Errorf("duration %q is too long", s)
+		}
+		d += time.Duration(days * 24 * float64(time.Hour))
+	}
+	return d, nil
+}
+
+// isHostname checks a hostname as defined by RFC 1123, optionally
+// fully qualified with a trailing dot
+func isHostname(v string) bool {
+	v = strings.TrimSuffix(v, ".")
+	if v == "" || len(v) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(v, ".") {
+		if !labelPattern.MatchString(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// isBase64 accepts standard and URL-safe base64, padded or not
+func isBase64(v string) bool {
+	if v == "" {
+		return false
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if _, err := enc.DecodeString(v); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// words make fake values read like real ones
+var words = []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel", "india", "juliet", "kilo", "lima"}
+
+func word(r *rand.Rand) string {
+	return words[r.Intn(len(words))]
+}
+
+func randomString(r *rand.Rand, n int) string {
+	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = chars[r.Intn(len(chars))]
+	}
+	return string(b)
+}
@@ -0,0 +1,103 @@
+package format
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat_Valid(t *testing.T) {
+	tests := []struct {
+		format  string
+		valid   []string
+		invalid []string
+	}{
+		{"int", []string{"0", "-3", "42"}, []string{"4.2", "abc", ""}},
+		{"bool", []string{"true", "FALSE", "1", "0"}, []string{"yes", ""}},
+		{"url", []string{"https://example.com", "postgres://db:5432/app"}, []string{"example.com", "not a url"}},
+		{"hostname", []string{"localhost", "db.internal.example.com", "example.com.", "a-1.b"}, []string{"", "-bad.com", "bad-.com", "under_score.com", "a..b"}},
+		{"ip", []string{"10.0.0.1", "::1", "2001:db8::1"}, []string{"10.0.0.256", "10.0.0", "host"}},
+		{"cidr", []string{"10.0.0.0/16", "2001:db8::/32"}, []string{"10.0.0.0", "10.0.0.0/33"}},
+		{"port", []string{"1", "8080", "65535"}, []string{"0", "65536", "-1", "http"}},
+		{"duration", []string{"30s", "1h30m", "7d", "2w"}, []string{"", "30", "soon"}},
+		{"semver", []string{"1.2.3", "v1.2.3", "1.0.0-rc.1", "1.0.0+build.5"}, []string{"1.2", "01.2.3", "1.2.3-"}},
+		{"base64", []string{"aGVsbG8=", "aGVsbG8", "-_8="}, []string{"", "not base64!"}},
+		{"uuid", []string{"3f2b8c1e-9a4d-4f6b-8e2a-1c5d7e9f0a3b", "3F2B8C1E-9A4D-4F6B-8E2A-1C5D7E9F0A3B"}, []string{"3f2b8c1e9a4d4f6b8e2a1c5d7e9f0a3b", "not-a-uuid"}},
+		{"aws-arn", []string{"arn:aws:iam::123456789012:role/app", "arn:aws:s3:::bucket/key", "arn:aws-cn:sqs:cn-north-1:123456789012:queue"}, []string{"arn:aws:iam::1234:role/app", "role/app", "arn:gcp:iam::123456789012:role/app"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			f, ok := Lookup(tt.format)
+			require.True(t, ok)
+			for _, v := range tt.valid {
+				assert.True(t, f.Valid(v), "%q should be a valid %s", v, tt.format)
+			}
+			for _, v := range tt.invalid {
+				assert.False(t, f.Valid(v), "%q should not be a valid %s", v, tt.format)
+			}
+		})
+	}
+}
+
+func TestFormat_Fake(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, name := range Names() {
+		f, _ := Lookup(name)
+		for i := 0; i < 20; i++ {
+			v := f.Fake(r)
+			assert.True(t, f.Valid(v), "fake %q should be a valid %s", v, name)
+		}
+	}
+}
+
+func TestFormat_Normalize(t *testing.T) {
+	tests := []struct {
+		format string
+		value  string
+		want   string
+	}{
+		{"hostname", " DB.Example.com. ", "db.example.com"},
+		{"uuid", "3F2B8C1E-9A4D-4F6B-8E2A-1C5D7E9F0A3B", "3f2b8c1e-9a4d-4f6b-8e2a-1c5d7e9f0a3b"},
+		{"ip", "2001:0db8::0001", "2001:db8::1"},
+		{"port", "08080", "8080"},
+		{"bool", "TRUE", "true"},
+		{"int", " 42 ", "42"},
+		{"port", " http ", " http "}, // invalid values are kept as given
+		{"string", " padded ", " padded "},
+	}
+
+	for _, tt := range tests {
+		f, ok := Lookup(tt.format)
+		require.True(t, ok)
+		assert.Equal(t, tt.want, f.Normalize(tt.value), "%s %q", tt.format, tt.value)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	_, ok := Lookup("unknown")
+	assert.False(t, ok)
+
+	for _, name := range Names() {
+		f, ok := Lookup(name)
+		require.True(t, ok)
+		assert.Equal(t, name, f.Name)
+		assert.True(t, f.Valid(f.Example), "example for %s", name)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	d, err := ParseDuration("1d12h")
+	require.NoError(t, err)
+	assert.Equal(t, 36*time.Hour, d)
+
+	d, err = ParseDuration("0s")
+	require.NoError(t, err)
+	assert.Zero(t, d)
+
+	_, err = ParseDuration("5 minutes")
+	assert.Error(t, err)
+}
@@ -0,0 +1,200 @@
+// Package framework detects the web frameworks of a project so envy init can
+// classify their conventional variables and pick matching defaults.
+package framework
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/drapon/envy/internal/config"
+)
+
+// Framework holds the conventions of a detected framework
+type Framework struct {
+	Name string
+
+	// Public lists the keys or patterns the framework exposes to the
+	// browser. They are not secrets, so they are classified as plain.
+	Public []string
+
+	// Plain and Sensitive are the framework's own settings that are
+	// never, or always, secrets
+	Plain     []string
+	Sensitive []string
+
+	// Export holds default flags for envy export
+	Export map[string]interface{}
+
+	// Notes are shown by init when the framework is detected
+	Notes []string
+}
+
+// dotenvExport writes KEY=VALUE lines, the format the frameworks' .env
+// loaders read
+var dotenvExport = map[string]interface{}{"format": "docker"}
+
+// Detect returns the frameworks used by the project in dir, in a fixed
+// order
+func Detect(dir string) []Framework {
+	deps := packageDependencies(dir)
+
+	var found []Framework
+	if deps["next"] || exists(dir, "next.config.js", "next.config.mjs", "next.config.ts") {
+		found = append(found, Framework{
+			Name:      "Next.js",
+			Public:    []string{"NEXT_PUBLIC_*"},
+			Plain:     []string{"NODE_ENV"},
+			Sensitive: []string{"NEXTAUTH_SECRET", "AUTH_SECRET"},
+			Export:    dotenvExport,
+			Notes:     []string{"NEXT_PUBLIC_ variables are inlined into the browser bundle; never give them secret values"},
+		})
+	}
+	if deps["vite"] || exists(dir, "vite.config.js", "vite.config.mjs", "vite.config.ts") {
+		found = append(found, Framework{
+			Name:   "Vite",
+			Public: []string{"VITE_*"},
+			Plain:  []string{"NODE_ENV"},
+			Export: dotenvExport,
+			Notes:  []string{"VITE_ variables are exposed to client code; never give them secret values"},
+		})
+	}
+	if exists(dir, filepath.Join("config", "application.rb")) || contains(dir, "Gemfile", "rails") {
+		rails := Framework{
+			Name:      "Rails",
+			Plain:     []string{"RAILS_ENV", "RACK_ENV", "RAILS_LOG_TO_STDOUT", "RAILS_SERVE_STATIC_FILES", "RAILS_MAX_THREADS"},
+			Sensitive: []string{"RAILS_MASTER_KEY", "SECRET_KEY_BASE"},
+			Export:    dotenvExport,
+		}
+		if exists(dir, filepath.Join("config", "credentials.yml.enc")) || glob(dir, filepath.Join("config", "credentials", "*.yml.enc")) {
+			rails.Notes = append(rails.Notes, "Rails credentials found: keep them encrypted in the repository and store RAILS_MASTER_KEY (config/master.key) with envy")
+		}
+		found = append(found, rails)
+	}
+	if exists(dir, "manage.py") || contains(dir, "requirements.txt", "django") || contains(dir, "pyproject.toml", "django") {
+		found = append(found, Framework{
+			Name:      "Django",
+			Plain:     []string{"DJANGO_SETTINGS_MODULE", "DJANGO_DEBUG", "DJANGO_ALLOWED_HOSTS", "DEBUG", "ALLOWED_HOSTS"},
+			Sensitive: []string{"DJANGO_SECRET_KEY", "SECRET_KEY"},
+			Export:    dotenvExport,
+			Notes:     []string{"Read settings from the environment in settings.py (e.g. os.environ[\"DJANGO_SECRET_KEY\"]) instead of hard-coding them"},
+		})
+	}
+	return found
+}
+
+// Apply adds the frameworks' classifications and export defaults to cfg.
+// Existing entries are kept, and the first framework's export default wins.
+func Apply(cfg *config.Config, frameworks []Framework) {
+	for _, fw := range frameworks {
+		cfg.Sensitivity.Plain = appendMissing(cfg.Sensitivity.Plain, fw.Public...)
+		cfg.Sensitivity.Plain = appendMissing(cfg.Sensitivity.Plain, fw.Plain...)
+		cfg.Sensitivity.Sensitive = appendMissing(cfg.Sensitivity.Sensitive, fw.Sensitive...)
+
+		if len(fw.Export) == 0 {
+			continue
+		}
+		if cfg.Commands == nil {
+			cfg.Commands = make(map[string]map[string]interface{})
+		}
+		if cfg.Commands["export"] == nil {
+			cfg.Commands["export"] = make(map[string]interface{})
+		}
+		for flag, value := range fw.Export {
+			if _, ok := cfg.Commands["export"][flag]; !ok {
+				cfg.Commands["export"][flag] = value
+			}
+		}
+	}
+}
+
+// Names returns the names of frameworks
+func Names(frameworks []Framework) []string {
+	names := make([]string, len(frameworks))
+	for i, fw := range frameworks {
+		names[i] = fw.Name
+	}
+	return names
+}
+
+func appendMissing(list []string, items ...string) []string {
+	for _, item := range items {
+		present := false
+		for _, existing := range list {
+			if existing == item {
+				present = true
+				break
+			}
+		}
+		if !present {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// packageDependencies returns the dependencies and dev dependencies of the
+// package.json in dir
+func packageDependencies(dir string) map[string]bool {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil
+	}
+
+	deps := make(map[string]bool)
+	for name := range pkg.Dependencies {
+		deps[name] = true
+	}
+	for name := range pkg.DevDependencies {
+		deps[name] = true
+	}
+	return deps
+}
+
+func exists(dir string, names ...string) bool {
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func glob(dir, pattern string) bool {
+	matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+	return len(matches) > 0
+}
+
+// contains reports whether the file mentions the package name as a whole
+// word, case-insensitively
+func contains(dir, name, pkg string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return false
+	}
+	content := strings.ToLower(string(data))
+	for i := strings.Index(content, pkg); i != -1; {
+		end := i + len(pkg)
+		if (i == 0 || !isWordChar(content[i-1])) && (end == len(content) || !isWordChar(content[end])) {
+			return true
+		}
+		next := strings.Index(content[i+1:], pkg)
+		if next == -1 {
+			break
+		}
+		i += next + 1
+	}
+	return false
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
@@ -0,0 +1,76 @@
+package framework
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{"empty", nil, []string{}},
+		{"next dependency", map[string]string{"package.json": `{"dependencies": {"next": "14.0.0", "react": "18.0.0"}}`}, []string{"Next.js"}},
+		{"vite dev dependency", map[string]string{"package.json": `{"devDependencies": {"vite": "^5.0.0"}}`}, []string{"Vite"}},
+		{"vite config", map[string]string{"vite.config.ts": "export default {}"}, []string{"Vite"}},
+		{"rails", map[string]string{"Gemfile": "source \"https://rubygems.org\"\ngem \"rails\", \"~> 7.1\"\n"}, []string{"Rails"}},
+		{"rails helper gem only", map[string]string{"Gemfile": "gem \"rails-html-sanitizer\"\n"}, []string{}},
+		{"django", map[string]string{"manage.py": "", "requirements.txt": "Django==5.0\n"}, []string{"Django"}},
+		{"django-environ only", map[string]string{"requirements.txt": "django-environ\n"}, []string{}},
+		{"next and django", map[string]string{"next.config.mjs": "", "pyproject.toml": "dependencies = [\"django>=5\"]"}, []string{"Next.js", "Django"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			assert.Equal(t, tt.want, Names(Detect(dir)))
+		})
+	}
+}
+
+func TestDetect_RailsCredentials(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config/application.rb":                 "",
+		"config/credentials/production.yml.enc": "",
+	})
+	frameworks := Detect(dir)
+	require.Len(t, frameworks, 1)
+	assert.Contains(t, frameworks[0].Sensitive, "RAILS_MASTER_KEY")
+	require.Len(t, frameworks[0].Notes, 1)
+	assert.Contains(t, frameworks[0].Notes[0], "RAILS_MASTER_KEY")
+}
+
+func TestApply(t *testing.T) {
+	cfg := &config.Config{
+		Sensitivity: config.SensitivityConfig{Plain: []string{"NODE_ENV"}},
+		Commands:    map[string]map[string]interface{}{"export": {"format": "json"}},
+	}
+	dir := writeFiles(t, map[string]string{"package.json": `{"dependencies": {"next": "14.0.0", "vite": "5.0.0"}}`})
+
+	Apply(cfg, Detect(dir))
+	assert.Equal(t, []string{"NODE_ENV", "NEXT_PUBLIC_*", "VITE_*"}, cfg.Sensitivity.Plain)
+	assert.Equal(t, []string{"NEXTAUTH_SECRET", "AUTH_SECRET"}, cfg.Sensitivity.Sensitive)
+	assert.Equal(t, "json", cfg.Commands["export"]["format"])
+
+	cfg = &config.Config{}
+	Apply(cfg, Detect(dir))
+	assert.Equal(t, "docker", cfg.Commands["export"]["format"])
+}
@@ -0,0 +1,267 @@
+package fuzzy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/drapon/envy/internal/color"
+	"golang.org/x/term"
+)
+
+// maxHeight is the most matches the finder lists at once
+const maxHeight = 10
+
+// ErrCancelled is returned when the user leaves the finder without
+// choosing an item
+var ErrCancelled = errors.New("nothing picked")
+
+// Keys the finder understands
+type key int
+
+const (
+	keyRune key = iota
+	keyEnter
+	keyCancel
+	keyBackspace
+	keyClear
+	keyDeleteWord
+	keyUp
+	keyDown
+	keyIgnore
+)
+
+// Pick lets the user choose one of items by typing a few of its
+// characters, like fzf: arrows, Tab or Ctrl-N/P move, Enter picks, and Esc
+// or Ctrl-C leave. query starts the search. Keys are read from standard
+// input, which must be a terminal, and the finder is drawn on standard
+// error, so the pick can be piped.
+func Pick(prompt, query string, items []string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("the finder needs a terminal")
+	}
+	if len(items) == 0 {
+		return "", fmt.Errorf("nothing to pick from")
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the terminal: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	width := 0
+	if w, _, err := term.GetSize(int(os.Stderr.Fd())); err == nil {
+		width = w
+	}
+	f := newFinder(prompt, query, items, width)
+	return f.run(bufio.NewReader(os.Stdin), os.Stderr)
+}
+
+// finder is the state of the search while the user types
+type finder struct {
+	prompt  string
+	items   []string
+	query   []rune
+	matches []Result
+	cursor  int
+	width   int
+}
+
+func newFinder(prompt, query string, items []string, width int) *finder {
+	f := &finder{prompt: prompt, items: items, query: []rune(query), width: width}
+	f.search()
+	return f
+}
+
+// run reads keys from in and redraws the finder on out until the user
+// picks an item or leaves
+func (f *finder) run(in *bufio.Reader, out io.Writer) (string, error) {
+	defer fmt.Fprint(out, "\r\x1b[J")
+	for {
+		f.draw(out)
+		k, r, err := readKey(in)
+		if err != nil {
+			return "", ErrCancelled
+		}
+		if picked, done := f.handle(k, r); done {
+			if picked == "" {
+				return "", ErrCancelled
+			}
+			return picked, nil
+		}
+	}
+}
+
+// handle applies a key to the search. It reports whether the finder is
+// done, with the item picked if any.
+func (f *finder) handle(k key, r rune) (string, bool) {
+	switch k {
+	case keyEnter:
+		if len(f.matches) == 0 {
+			return "", false
+		}
+		return f.matches[f.cursor].Text, true
+	case keyCancel:
+		return "", true
+	case keyUp:
+		if f.cursor > 0 {
+			f.cursor--
+		}
+	case keyDown:
+		if f.cursor < len(f.matches)-1 {
+			f.cursor++
+		}
+	case keyBackspace:
+		if len(f.query) > 0 {
+			f.query = f.query[:len(f.query)-1]
+			f.search()
+		}
+	case keyClear:
+		f.query = nil
+		f.search()
+	case keyDeleteWord:
+		q := strings.TrimRight(string(f.query), " ")
+		f.query = []rune(q[:strings.LastIndex(q, " ")+1])
+		f.search()
+	case keyRune:
+		f.query = append(f.query, r)
+		f.search()
+	}
+	return "", false
+}
+
+// search refreshes the matches for the query and puts the cursor back on
+// the best one. Words of the query separated by spaces must all match.
+func (f *finder) search() {
+	words := strings.Fields(string(f.query))
+	if len(words) == 0 {
+		f.matches = Filter("", f.items)
+	} else {
+		f.matches = Filter(words[0], f.items)
+		for _, word := range words[1:] {
+			kept := f.matches[:0]
+			for _, m := range f.matches {
+				if extra, ok := Match(word, m.Text); ok {
+					m.Score += extra.Score
+					m.Positions = append(m.Positions, extra.Positions...)
+					kept = append(kept, m)
+				}
+			}
+			f.matches = kept
+		}
+	}
+	f.cursor = 0
+}
+
+// draw shows the query line, the match count and the best matches below
+// it, then puts the terminal cursor back at the end of the query
+func (f *finder) draw(out io.Writer) {
+	var b strings.Builder
+	b.WriteString("\r\x1b[J")
+	fmt.Fprintf(&b, "%s> %s", f.prompt, string(f.query))
+	fmt.Fprintf(&b, "\r\n  %s", color.FormatInfo(fmt.Sprintf("%d/%d", len(f.matches), len(f.items))))
+
+	lines := 1
+	for i, m := range f.matches {
+		if i == maxHeight {
+			break
+		}
+		marker := "  "
+		if i == f.cursor {
+			marker = color.FormatBold("> ")
+		}
+		b.WriteString("\r\n" + marker + f.highlight(m))
+		lines++
+	}
+
+	fmt.Fprintf(&b, "\x1b[%dA\r", lines)
+	if col := len([]rune(f.prompt)) + 2 + len(f.query); col > 0 {
+		fmt.Fprintf(&b, "\x1b[%dC", col)
+	}
+	fmt.Fprint(out, b.String())
+}
+
+// highlight marks the matched characters of an item, cut to the width of
+// the terminal
+func (f *finder) highlight(m Result) string {
+	text := []rune(m.Text)
+	if f.width > 3 && len(text) > f.width-3 {
+		text = append(text[:f.width-4], '…')
+	}
+	matched := make(map[int]bool, len(m.Positions))
+	for _, p := range m.Positions {
+		matched[p] = true
+	}
+
+	var b strings.Builder
+	for i, r := range text {
+		if matched[i] {
+			b.WriteString(color.FormatSuccess(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// readKey reads one key press from a terminal in raw mode
+func readKey(in *bufio.Reader) (key, rune, error) {
+	r, _, err := in.ReadRune()
+	if err != nil {
+		return keyIgnore, 0, err
+	}
+	switch r {
+	case '\r', '\n':
+		return keyEnter, r, nil
+	case 3, 4, 7: // Ctrl-C, Ctrl-D, Ctrl-G
+		return keyCancel, r, nil
+	case 127, 8:
+		return keyBackspace, r, nil
+	case 21: // Ctrl-U
+		return keyClear, r, nil
+	case 23: // Ctrl-W
+		return keyDeleteWord, r, nil
+	case 16, 11: // Ctrl-P, Ctrl-K
+		return keyUp, r, nil
+	case 14, '\t': // Ctrl-N, Tab
+		return keyDown, r, nil
+	case 0x1b:
+		// A lone Esc leaves; arrows arrive as Esc [ A in the same read
+		if in.Buffered() == 0 {
+			return keyCancel, r, nil
+		}
+		next, _, err := in.ReadRune()
+		if err != nil {
+			return keyCancel, r, nil
+		}
+		if next != '[' && next != 'O' {
+			return keyIgnore, next, nil
+		}
+		code, _, err := in.ReadRune()
+		if err != nil {
+			return keyIgnore, 0, nil
+		}
+		switch code {
+		case 'A':
+			return keyUp, code, nil
+		case 'B':
+			return keyDown, code, nil
+		}
+		// Skip the rest of longer sequences such as Delete (Esc [ 3 ~)
+		for code >= '0' && code <= '9' || code == ';' {
+			if code, _, err = in.ReadRune(); err != nil {
+				break
+			}
+		}
+		return keyIgnore, 0, nil
+	}
+	if r < ' ' {
+		return keyIgnore, r, nil
+	}
+	return keyRune, r, nil
+}
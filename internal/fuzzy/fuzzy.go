@@ -0,0 +1,138 @@
+// Package fuzzy finds variable names by a few of their characters, like
+// fzf, so keys can be picked without remembering their exact names.
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Scores of a match: every matched character counts, more so at the
+// start of a word or right after the previous match, and gaps cost
+const (
+	scoreMatch       = 16
+	bonusConsecutive = 8
+	bonusWordStart   = 10
+	bonusFirstChar   = 6
+	penaltyGapStart  = 3
+	penaltyGapExtend = 1
+)
+
+// Result is an item that matches a pattern
+type Result struct {
+	Text      string
+	Score     int
+	Positions []int // indexes of the matched runes of Text
+}
+
+// Match reports whether the runes of pattern appear in text in order, and
+// how well. Matching ignores case unless pattern has upper case letters.
+func Match(pattern, text string) (Result, bool) {
+	result := Result{Text: text}
+	p := []rune(pattern)
+	if len(p) == 0 {
+		return result, true
+	}
+	t := []rune(text)
+
+	caseSensitive := strings.IndexFunc(pattern, unicode.IsUpper) >= 0
+	equal := func(a, b rune) bool {
+		if caseSensitive {
+			return a == b
+		}
+		return unicode.ToLower(a) == unicode.ToLower(b)
+	}
+
+	// Match greedily from every place the pattern could start and keep the
+	// best, so DB in UNUSED_DB_HOST scores the D of DB rather than of UNUSED
+	best, found := result, false
+	for start := range t {
+		if !equal(t[start], p[0]) {
+			continue
+		}
+		candidate := Result{Text: text}
+		prev, pi := -1, 0
+		for ti := start; ti < len(t) && pi < len(p); ti++ {
+			if !equal(t[ti], p[pi]) {
+				continue
+			}
+			candidate.Score += score(t, ti, pi, prev)
+			candidate.Positions = append(candidate.Positions, ti)
+			prev = ti
+			pi++
+		}
+		if pi < len(p) {
+			break // later starts cannot match either
+		}
+		if !found || candidate.Score > best.Score {
+			best, found = candidate, true
+		}
+	}
+	return best, found
+}
+
+// score is what matching the rune at ti to the pi-th rune of the pattern
+// adds, given the position of the previous match
+func score(t []rune, ti, pi, prev int) int {
+	s := scoreMatch
+	if wordStart(t, ti) {
+		s += bonusWordStart
+		if pi == 0 {
+			s += bonusFirstChar
+		}
+	}
+	if prev >= 0 {
+		if ti == prev+1 {
+			s += bonusConsecutive
+		} else {
+			s -= penaltyGapStart + penaltyGapExtend*(ti-prev-2)
+		}
+	}
+	return s
+}
+
+// wordStart reports whether the rune at i begins a word of a key, such as
+// the H of DB_HOST or of dbHost
+func wordStart(t []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev := t[i-1]
+	switch {
+	case prev == '_' || prev == '-' || prev == '.' || prev == '/' || unicode.IsSpace(prev):
+		return true
+	case unicode.IsLower(prev) && unicode.IsUpper(t[i]):
+		return true
+	case !unicode.IsDigit(prev) && unicode.IsDigit(t[i]):
+		return true
+	}
+	return false
+}
+
+// Filter returns the items matching pattern, best first. Equal scores go
+// to the shorter item, then in alphabetical order; an empty pattern keeps
+// every item in its order.
+func Filter(pattern string, items []string) []Result {
+	results := make([]Result, 0, len(items))
+	for _, item := range items {
+		if result, ok := Match(pattern, item); ok {
+			results = append(results, result)
+		}
+	}
+	if pattern == "" {
+		return results
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if len(a.Text) != len(b.Text) {
+			return len(a.Text) < len(b.Text)
+		}
+		return a.Text < b.Text
+	})
+	return results
+}
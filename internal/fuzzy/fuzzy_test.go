@@ -0,0 +1,101 @@
+package fuzzy
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var keys = []string{"API_URL", "DATABASE_URL", "DB_HOST", "DB_PASSWORD", "DEBUG", "REDIS_URL", "STRIPE_SECRET_KEY"}
+
+func texts(results []Result) []string {
+	var out []string
+	for _, r := range results {
+		out = append(out, r.Text)
+	}
+	return out
+}
+
+func TestMatch(t *testing.T) {
+	r, ok := Match("dbp", "DB_PASSWORD")
+	require.True(t, ok)
+	assert.Equal(t, []int{0, 1, 3}, r.Positions)
+
+	_, ok = Match("pdb", "DB_PASSWORD")
+	assert.False(t, ok, "characters must appear in order")
+
+	_, ok = Match("Db", "DB_HOST")
+	assert.False(t, ok, "upper case in the pattern matches case")
+
+	// The best match is scored, not the first one found
+	r, ok = Match("url", "UNUSED_RELAY_URL")
+	require.True(t, ok)
+	assert.Equal(t, []int{13, 14, 15}, r.Positions)
+	r, _ = Match("db", "UNUSED_DB_HOST")
+	assert.Equal(t, []int{7, 8}, r.Positions)
+
+	// Word starts and runs of characters score higher than scattered ones
+	words, _ := Match("sk", "STRIPE_SECRET_KEY")
+	scattered, _ := Match("sk", "SOME_TASK")
+	assert.Greater(t, words.Score, scattered.Score)
+
+	camel, _ := Match("dh", "dbHost")
+	assert.Equal(t, []int{0, 2}, camel.Positions)
+}
+
+func TestFilter(t *testing.T) {
+	assert.Equal(t, keys, texts(Filter("", keys)), "an empty pattern keeps every item in order")
+	assert.Equal(t, []string{"DB_HOST", "DUMB_HASH"}, texts(Filter("dbh", append(keys, "DUMB_HASH"))))
+	assert.Equal(t, "DB_PASSWORD", Filter("dbpass", keys)[0].Text)
+	assert.Equal(t, []string{"API_URL", "REDIS_URL", "DATABASE_URL"}, texts(Filter("url", keys)))
+	assert.Empty(t, Filter("zzz", keys))
+}
+
+func TestFinder(t *testing.T) {
+	pick := func(query, input string) (string, error) {
+		f := newFinder("Key", query, keys, 80)
+		return f.run(bufio.NewReader(strings.NewReader(input)), io.Discard)
+	}
+
+	got, err := pick("", "dbp\r")
+	require.NoError(t, err)
+	assert.Equal(t, "DB_PASSWORD", got)
+
+	got, err = pick("url", "\x1b[B\x1b[B\x1b[A\r")
+	require.NoError(t, err)
+	assert.Equal(t, "REDIS_URL", got, "arrows move between matches")
+
+	got, err = pick("", "redis\x7f\x7f\x7f\x7f\x7fdeb\r")
+	require.NoError(t, err)
+	assert.Equal(t, "DEBUG", got, "backspace edits the query")
+
+	got, err = pick("", "db url\r")
+	require.NoError(t, err)
+	assert.Equal(t, "DATABASE_URL", got, "every word must match")
+
+	got, err = pick("zzz", "\r\x15db\x0e\r")
+	require.NoError(t, err)
+	assert.Equal(t, "DB_PASSWORD", got, "Enter without matches waits, Ctrl-U clears")
+
+	_, err = pick("db", "\x03")
+	assert.ErrorIs(t, err, ErrCancelled)
+	_, err = pick("db", "\x1b")
+	assert.ErrorIs(t, err, ErrCancelled, "a lone Esc leaves")
+	_, err = pick("db", "")
+	assert.ErrorIs(t, err, ErrCancelled)
+}
+
+func TestFinderDraw(t *testing.T) {
+	f := newFinder("Key", "url", keys, 12)
+	var out strings.Builder
+	f.draw(&out)
+
+	assert.Contains(t, out.String(), "Key> url")
+	assert.Contains(t, out.String(), "3/7")
+	assert.Contains(t, out.String(), "DATABASE…")
+	assert.Contains(t, out.String(), "\x1b[4A", "the cursor goes back to the query")
+}
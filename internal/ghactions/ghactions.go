@@ -0,0 +1,142 @@
+// Package ghactions writes GitHub Actions workflow commands, so that issues
+// found in a workflow run show up as annotations on the pull request.
+package ghactions
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/validator"
+)
+
+// Level is the kind of annotation
+type Level string
+
+const (
+	LevelError   Level = "error"
+	LevelWarning Level = "warning"
+	LevelNotice  Level = "notice"
+)
+
+// Annotation is a message attached to a file, and optionally a line, in the
+// workflow run
+type Annotation struct {
+	Level   Level
+	File    string
+	Line    int
+	Title   string
+	Message string
+}
+
+// Enabled reports whether envy runs in a GitHub Actions workflow
+func Enabled() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// Write writes an annotation as a workflow command, e.g.
+// ::error file=.env,line=3,title=type_error::variable PORT must be a port number
+func Write(w io.Writer, a Annotation) {
+	var props []string
+	if a.File != "" {
+		props = append(props, "file="+escapeProperty(a.File))
+		if a.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", a.Line))
+		}
+	}
+	if a.Title != "" {
+		props = append(props, "title="+escapeProperty(a.Title))
+	}
+
+	command := "::" + string(a.Level)
+	if len(props) > 0 {
+		command += " " + strings.Join(props, ",")
+	}
+	fmt.Fprintf(w, "%s::%s\n", command, escapeData(a.Message))
+}
+
+// Emit writes annotations to stdout when running in GitHub Actions
+func Emit(annotations ...Annotation) {
+	if !Enabled() {
+		return
+	}
+	for _, a := range annotations {
+		Write(os.Stdout, a)
+	}
+}
+
+// Location is where a variable is set
+type Location struct {
+	File string
+	Line int
+}
+
+// Locate returns where each variable in files is set. Later files override
+// earlier ones, as when they are loaded together. Unreadable files are
+// skipped.
+func Locate(files []string) map[string]Location {
+	locations := make(map[string]Location)
+	for _, name := range files {
+		file, err := env.ParseFile(name)
+		if err != nil {
+			continue
+		}
+		for key, variable := range file.Variables {
+			locations[key] = Location{File: name, Line: variable.Line}
+		}
+	}
+	return locations
+}
+
+// ValidationAnnotations turns validation issues into annotations on the
+// files that set the variables. Issues about variables that are not set,
+// such as missing required ones, are attached to the first file.
+func ValidationAnnotations(result *validator.ValidationResult, files []string) []Annotation {
+	locations := Locate(files)
+	fallback := ""
+	if len(files) > 0 {
+		fallback = files[0]
+	}
+
+	var annotations []Annotation
+	add := func(level Level, issues []validator.ValidationError) {
+		for _, issue := range issues {
+			location, ok := locations[issue.Variable]
+			if !ok {
+				location = Location{File: fallback}
+			}
+
+			message := issue.Message
+			if issue.Details != "" {
+				message += "\n" + issue.Details
+			}
+			annotations = append(annotations, Annotation{
+				Level:   level,
+				File:    location.File,
+				Line:    location.Line,
+				Title:   issue.Type,
+				Message: message,
+			})
+		}
+	}
+	add(LevelError, result.Errors)
+	add(LevelWarning, result.Warnings)
+	add(LevelNotice, result.Infos)
+	return annotations
+}
+
+// escapeData escapes a command's message
+func escapeData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeProperty escapes a command property value
+func escapeProperty(s string) string {
+	s = escapeData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
@@ -0,0 +1,79 @@
+package ghactions
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drapon/envy/internal/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnabled(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	assert.True(t, Enabled())
+
+	t.Setenv("GITHUB_ACTIONS", "")
+	assert.False(t, Enabled())
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation Annotation
+		want       string
+	}{
+		{
+			name:       "file and line",
+			annotation: Annotation{Level: LevelError, File: ".env", Line: 3, Title: "type_error", Message: "variable PORT must be a port number"},
+			want:       "::error file=.env,line=3,title=type_error::variable PORT must be a port number\n",
+		},
+		{
+			name:       "no properties",
+			annotation: Annotation{Level: LevelWarning, Message: "drift"},
+			want:       "::warning::drift\n",
+		},
+		{
+			name:       "escaping",
+			annotation: Annotation{Level: LevelNotice, Title: "a:b,c", Message: "100%\nnext"},
+			want:       "::notice title=a%3Ab%2Cc::100%25%0Anext\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			Write(&buf, tt.annotation)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestValidationAnnotations(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, ".env")
+	local := filepath.Join(dir, ".env.local")
+	require.NoError(t, os.WriteFile(base, []byte("# App\nPORT=http\nLOG_LEVEL=loud\n"), 0600))
+	require.NoError(t, os.WriteFile(local, []byte("LOG_LEVEL=verbose\n"), 0600))
+
+	result := &validator.ValidationResult{
+		Errors: []validator.ValidationError{
+			{Variable: "PORT", Message: "variable PORT must be a port number", Type: "type_error", Details: "Expected type: port"},
+			{Variable: "API_KEY", Message: "Required variable API_KEY is missing", Type: "missing_required"},
+		},
+		Warnings: []validator.ValidationError{
+			{Variable: "LOG_LEVEL", Message: "variable LOG_LEVEL has invalid value 'verbose'", Type: "enum_error"},
+		},
+	}
+
+	annotations := ValidationAnnotations(result, []string{base, local, filepath.Join(dir, "missing")})
+	require.Len(t, annotations, 3)
+
+	assert.Equal(t, Annotation{Level: LevelError, File: base, Line: 2, Title: "type_error", Message: "variable PORT must be a port number\nExpected type: port"}, annotations[0])
+	assert.Equal(t, Annotation{Level: LevelError, File: base, Title: "missing_required", Message: "Required variable API_KEY is missing"}, annotations[1])
+	assert.Equal(t, LevelWarning, annotations[2].Level)
+	assert.Equal(t, local, annotations[2].File)
+	assert.Equal(t, 1, annotations[2].Line)
+}
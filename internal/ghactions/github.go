@@ -0,0 +1,171 @@
+package ghactions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Input returns an action input, passed as INPUT_<NAME> like GitHub does for
+// Docker and JavaScript actions
+func Input(name string) string {
+	return strings.TrimSpace(os.Getenv("INPUT_" + strings.ToUpper(strings.ReplaceAll(name, " ", "_"))))
+}
+
+// SetOutput sets a step output, readable as steps.<id>.outputs.<name>
+func SetOutput(name, value string) error {
+	return appendToFile(os.Getenv("GITHUB_OUTPUT"), fmt.Sprintf("%s<<ENVY_EOF\n%s\nENVY_EOF\n", name, value))
+}
+
+// AddStepSummary appends markdown to the job summary
+func AddStepSummary(markdown string) error {
+	return appendToFile(os.Getenv("GITHUB_STEP_SUMMARY"), markdown+"\n")
+}
+
+// appendToFile appends to one of the files the runner reads commands from.
+// Outside a workflow run the path is empty and nothing is written.
+func appendToFile(path, content string) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(content)
+	return err
+}
+
+// PullRequestNumber returns the number of the pull request that triggered
+// the run, from the event payload
+func PullRequestNumber() (int, bool) {
+	path := os.Getenv("GITHUB_EVENT_PATH")
+	if path == "" {
+		return 0, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+
+	var event struct {
+		PullRequest *struct {
+			Number int `json:"number"`
+		} `json:"pull_request"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil || event.PullRequest == nil {
+		return 0, false
+	}
+	return event.PullRequest.Number, true
+}
+
+// Client posts to the GitHub REST API
+type Client struct {
+	BaseURL    string
+	Token      string
+	Repository string // owner/name
+	HTTP       *http.Client
+}
+
+// NewClientFromEnv creates a client for the repository of the workflow run.
+// The token is read from GITHUB_TOKEN, or the github-token input.
+func NewClientFromEnv() (*Client, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = Input("github-token")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("no GitHub token: set GITHUB_TOKEN or the github-token input")
+	}
+
+	repository := os.Getenv("GITHUB_REPOSITORY")
+	if repository == "" {
+		return nil, fmt.Errorf("GITHUB_REPOSITORY is not set")
+	}
+
+	baseURL := os.Getenv("GITHUB_API_URL")
+	if baseURL == "" {
+		baseURL = "https://api.github.com"
+	}
+
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Token:      token,
+		Repository: repository,
+		HTTP:       &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+type issueComment struct {
+	ID   int64  `json:"id,omitempty"`
+	Body string `json:"body"`
+}
+
+// UpsertComment posts body on a pull request, or edits the comment that
+// already contains marker, so repeated runs keep a single comment
+func (c *Client) UpsertComment(ctx context.Context, number int, marker, body string) error {
+	var comments []issueComment
+	listURL := fmt.Sprintf("%s/repos/%s/issues/%d/comments?per_page=100", c.BaseURL, c.Repository, number)
+	if err := c.do(ctx, http.MethodGet, listURL, nil, &comments); err != nil {
+		return fmt.Errorf("failed to list comments: %w", err)
+	}
+
+	for _, comment := range comments {
+		if strings.Contains(comment.Body, marker) {
+			editURL := fmt.Sprintf("%s/repos/%s/issues/comments/%d", c.BaseURL, c.Repository, comment.ID)
+			if err := c.do(ctx, http.MethodPatch, editURL, issueComment{Body: body}, nil); err != nil {
+				return fmt.Errorf("failed to update comment: %w", err)
+			}
+			return nil
+		}
+	}
+
+	createURL := fmt.Sprintf("%s/repos/%s/issues/%d/comments", c.BaseURL, c.Repository, number)
+	if err := c.do(ctx, http.MethodPost, createURL, issueComment{Body: body}, nil); err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, url string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", method, url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
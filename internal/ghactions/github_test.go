@@ -0,0 +1,141 @@
+package ghactions
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInput(t *testing.T) {
+	t.Setenv("INPUT_GITHUB-TOKEN", " secret ")
+	t.Setenv("INPUT_ENVIRONMENT", "prod")
+
+	assert.Equal(t, "secret", Input("github-token"))
+	assert.Equal(t, "prod", Input("environment"))
+	assert.Equal(t, "", Input("missing"))
+}
+
+func TestSetOutputAndSummary(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "output")
+	summary := filepath.Join(dir, "summary")
+	t.Setenv("GITHUB_OUTPUT", output)
+	t.Setenv("GITHUB_STEP_SUMMARY", summary)
+
+	require.NoError(t, SetOutput("changes", "3"))
+	require.NoError(t, SetOutput("added", "1"))
+	require.NoError(t, AddStepSummary("### plan"))
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "changes<<ENVY_EOF\n3\nENVY_EOF\nadded<<ENVY_EOF\n1\nENVY_EOF\n", string(data))
+
+	data, err = os.ReadFile(summary)
+	require.NoError(t, err)
+	assert.Equal(t, "### plan\n", string(data))
+
+	// Outside a workflow run nothing is written
+	t.Setenv("GITHUB_OUTPUT", "")
+	assert.NoError(t, SetOutput("changes", "3"))
+}
+
+func TestPullRequestNumber(t *testing.T) {
+	dir := t.TempDir()
+
+	prEvent := filepath.Join(dir, "pr.json")
+	require.NoError(t, os.WriteFile(prEvent, []byte(`{"action":"opened","pull_request":{"number":42}}`), 0644))
+	pushEvent := filepath.Join(dir, "push.json")
+	require.NoError(t, os.WriteFile(pushEvent, []byte(`{"ref":"refs/heads/main"}`), 0644))
+
+	t.Setenv("GITHUB_EVENT_PATH", prEvent)
+	number, ok := PullRequestNumber()
+	assert.True(t, ok)
+	assert.Equal(t, 42, number)
+
+	t.Setenv("GITHUB_EVENT_PATH", pushEvent)
+	_, ok = PullRequestNumber()
+	assert.False(t, ok)
+
+	t.Setenv("GITHUB_EVENT_PATH", "")
+	_, ok = PullRequestNumber()
+	assert.False(t, ok)
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("INPUT_GITHUB-TOKEN", "")
+	_, err := NewClientFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv("INPUT_GITHUB-TOKEN", "token")
+	t.Setenv("GITHUB_REPOSITORY", "drapon/envy")
+	t.Setenv("GITHUB_API_URL", "https://github.example.com/api/v3/")
+	client, err := NewClientFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "token", client.Token)
+	assert.Equal(t, "https://github.example.com/api/v3", client.BaseURL)
+}
+
+// fakeGitHub serves the issue comment endpoints UpsertComment uses
+type fakeGitHub struct {
+	comments []issueComment
+	requests []string
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/drapon/envy/issues/7/comments":
+		_ = json.NewEncoder(w).Encode(f.comments)
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/drapon/envy/issues/7/comments":
+		var c issueComment
+		_ = json.NewDecoder(r.Body).Decode(&c)
+		c.ID = int64(len(f.comments) + 1)
+		f.comments = append(f.comments, c)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPatch && r.URL.Path == "/repos/drapon/envy/issues/comments/1":
+		var c issueComment
+		_ = json.NewDecoder(r.Body).Decode(&c)
+		f.comments[0].Body = c.Body
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestClient_UpsertComment(t *testing.T) {
+	fake := &fakeGitHub{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := &Client{BaseURL: server.URL, Token: "token", Repository: "drapon/envy", HTTP: server.Client()}
+	ctx := context.Background()
+
+	require.NoError(t, client.UpsertComment(ctx, 7, "<!-- envy -->", "<!-- envy -->\nfirst"))
+	require.NoError(t, client.UpsertComment(ctx, 7, "<!-- envy -->", "<!-- envy -->\nsecond"))
+
+	require.Len(t, fake.comments, 1)
+	assert.Equal(t, "<!-- envy -->\nsecond", fake.comments[0].Body)
+	assert.Equal(t, []string{
+		"GET /repos/drapon/envy/issues/7/comments",
+		"POST /repos/drapon/envy/issues/7/comments",
+		"GET /repos/drapon/envy/issues/7/comments",
+		"PATCH /repos/drapon/envy/issues/comments/1",
+	}, fake.requests)
+
+	client.Token = "wrong"
+	err := client.UpsertComment(ctx, 7, "<!-- envy -->", "body")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
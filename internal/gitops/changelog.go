@@ -0,0 +1,74 @@
+package gitops
+
+import (
+	"context"
+	"sort"
+)
+
+// ChangelogEntry is the net change of one variable over a range of commits
+type ChangelogEntry struct {
+	Key string `json:"key"`
+	// Change is added, modified or removed
+	Change string `json:"change"`
+	// Commits changed the variable, oldest first
+	Commits []Commit `json:"commits"`
+}
+
+// Last returns the most recent commit that changed the variable, or the zero
+// Commit if none is known
+func (e ChangelogEntry) Last() Commit {
+	if len(e.Commits) == 0 {
+		return Commit{}
+	}
+	return e.Commits[len(e.Commits)-1]
+}
+
+// Changelog lists the variables that differ between from and to, with the
+// commits in between that changed them. Variables changed and then changed
+// back are left out.
+func Changelog(ctx context.Context, repo *Repo, from, to string, files []string) ([]ChangelogEntry, error) {
+	commits, err := repo.Log(ctx, from, to, files)
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := LoadAt(ctx, repo, from, files)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make(map[string][]Commit)
+	previous := start
+	for _, commit := range commits {
+		current, err := LoadAt(ctx, repo, commit.SHA, files)
+		if err != nil {
+			return nil, err
+		}
+
+		delta := NewDelta("", previous, current)
+		for _, keys := range [][]string{delta.Added, delta.Modified, delta.Deleted} {
+			for _, key := range keys {
+				history[key] = append(history[key], commit)
+			}
+		}
+		previous = current
+	}
+
+	net := NewDelta("", start, previous)
+	var entries []ChangelogEntry
+	for change, keys := range map[string][]string{"added": net.Added, "modified": net.Modified, "removed": net.Deleted} {
+		for _, key := range keys {
+			entries = append(entries, ChangelogEntry{Key: key, Change: change, Commits: history[key]})
+		}
+	}
+
+	// Most recent first, as in release notes
+	sort.Slice(entries, func(i, j int) bool {
+		ti, tj := entries[i].Last().Time, entries[j].Last().Time
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return entries[i].Key < entries[j].Key
+	})
+	return entries, nil
+}
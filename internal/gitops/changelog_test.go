@@ -0,0 +1,68 @@
+package gitops
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepo_Log(t *testing.T) {
+	repo := newTestRepo(t)
+	first := commit(t, repo, map[string]string{".env": "A=1\n"})
+	second := commit(t, repo, map[string]string{".env": "A=2\n"})
+	commit(t, repo, map[string]string{"README.md": "docs\n"})
+	third := commit(t, repo, map[string]string{".env": "A=3\n"})
+
+	commits, err := repo.Log(context.Background(), first, third, []string{".env"})
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, second, commits[0].SHA)
+	assert.Equal(t, third, commits[1].SHA)
+	assert.Equal(t, "test", commits[0].Author)
+	assert.Equal(t, "test@example.com", commits[0].Email)
+	assert.Equal(t, "update", commits[0].Subject)
+	assert.False(t, commits[0].Time.IsZero())
+}
+
+func TestRepo_History(t *testing.T) {
+	repo := newTestRepo(t)
+	added := commit(t, repo, map[string]string{".env.prod": "A=1\n"})
+	commit(t, repo, map[string]string{"README.md": "docs\n"})
+	removed := commit(t, repo, map[string]string{".env.prod": ""})
+
+	commits, err := repo.History(context.Background(), []string{".env.prod"})
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, added, commits[0].SHA)
+	assert.Equal(t, removed, commits[1].SHA)
+
+	commits, err = repo.History(context.Background(), []string{".env.dev"})
+	require.NoError(t, err)
+	assert.Empty(t, commits)
+}
+
+func TestChangelog(t *testing.T) {
+	repo := newTestRepo(t)
+	base := commit(t, repo, map[string]string{".env": "A=1\nB=2\nC=3\n"})
+	addD := commit(t, repo, map[string]string{".env": "A=1\nB=2\nC=3\nD=4\n"})
+	commit(t, repo, map[string]string{".env": "A=9\nB=2\nC=3\nD=4\n"})
+	last := commit(t, repo, map[string]string{".env": "A=1\nB=5\nD=4\n"})
+
+	entries, err := Changelog(context.Background(), repo, base, last, []string{".env"})
+	require.NoError(t, err)
+
+	byKey := make(map[string]ChangelogEntry)
+	for _, entry := range entries {
+		byKey[entry.Key] = entry
+	}
+
+	assert.NotContains(t, byKey, "A", "changed and changed back")
+	assert.Equal(t, "added", byKey["D"].Change)
+	assert.Equal(t, addD, byKey["D"].Last().SHA)
+	assert.Equal(t, "modified", byKey["B"].Change)
+	assert.Equal(t, last, byKey["B"].Last().SHA)
+	assert.Equal(t, "removed", byKey["C"].Change)
+	assert.Len(t, entries, 3)
+}
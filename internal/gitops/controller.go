@@ -0,0 +1,265 @@
+package gitops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/drapon/envy/internal/env"
+	"go.uber.org/zap"
+)
+
+// Remote reads and writes an environment's variables. It is satisfied by
+// *aws.Manager.
+type Remote interface {
+	PullEnvironment(ctx context.Context, envName string) (*env.File, error)
+	ApplyChanges(ctx context.Context, envName string, set *env.File, deleted []string) error
+}
+
+// Controller continuously reconciles remote environments with the env
+// files committed at a git ref. Unlike apply, which pushes the changes made
+// between two commits, each reconcile compares the committed files with
+// the actual remote state, so manual edits in AWS (drift) are corrected too.
+type Controller struct {
+	Repo   *Repo
+	Remote Remote
+	// Environments maps environment names to their files, in merge order
+	Environments map[string][]string
+	// FetchRemote is fetched before each sync; empty skips fetching
+	FetchRemote string
+	// Ref is the commit-ish to reconcile to, e.g. "origin/main"
+	Ref      string
+	Interval time.Duration
+	// Prune deletes remote variables that are not in git. Without it they
+	// are only reported as unmanaged.
+	Prune  bool
+	DryRun bool
+	// Lease enables leader election when set; only the holder reconciles,
+	// and it renews the lease before each write
+	Lease   *Lease
+	Metrics *Metrics
+	// Check is called before a delta is written; an error skips the write
+	Check func(ctx context.Context, envName string, delta *Delta) error
+	// OnApplied is called after an environment is reconciled to a new commit
+	OnApplied func(ctx context.Context, envName, sha string) error
+	Logger    *zap.Logger
+
+	applied map[string]string
+}
+
+// Result describes one environment's reconcile
+type Result struct {
+	Commit string
+	Delta  *Delta
+	// Unmanaged are remote variables not in git that were left in place
+	Unmanaged []string
+}
+
+// Run reconciles every Interval until ctx is cancelled. Errors from a
+// single sync are logged and retried on the next tick.
+func (c *Controller) Run(ctx context.Context) error {
+	logger := c.logger()
+	if c.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+
+	defer func() {
+		if c.Lease == nil {
+			return
+		}
+		// Use a fresh context: ctx is already cancelled at shutdown
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := c.Lease.Release(releaseCtx); err != nil {
+			logger.Warn("Failed to release lease", zap.Error(err))
+		}
+	}()
+
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Sync(ctx); err != nil && ctx.Err() == nil {
+			logger.Error("Sync failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync performs one pass: acquire the lease, fetch, and reconcile every
+// environment to Ref. Environments are reconciled independently; their
+// errors are joined.
+func (c *Controller) Sync(ctx context.Context) error {
+	logger := c.logger()
+
+	if c.Lease != nil {
+		leader, err := c.Lease.TryAcquire(ctx)
+		if err != nil {
+			c.setLeader(false)
+			return err
+		}
+		c.setLeader(leader)
+		if !leader {
+			logger.Debug("Not the leader, skipping sync")
+			return nil
+		}
+	} else {
+		c.setLeader(true)
+	}
+
+	if c.FetchRemote != "" {
+		if err := c.Repo.Fetch(ctx, c.FetchRemote); err != nil {
+			return err
+		}
+	}
+
+	sha, err := c.Repo.ResolveRef(ctx, c.Ref)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(c.Environments))
+	for name := range c.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		if _, err := c.Reconcile(ctx, name, sha); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reconcile makes one environment's remote variables match the files
+// committed at sha
+func (c *Controller) Reconcile(ctx context.Context, envName, sha string) (*Result, error) {
+	logger := c.logger().With(zap.String("environment", envName), zap.String("commit", ShortSHA(sha)))
+
+	result, corrected, err := c.reconcile(ctx, envName, sha)
+	if c.Metrics != nil {
+		drifted, unmanaged := 0, 0
+		if result != nil {
+			drifted = len(result.Delta.Added) + len(result.Delta.Modified) + len(result.Delta.Deleted)
+			unmanaged = len(result.Unmanaged)
+		}
+		c.Metrics.ObserveReconcile(envName, err, drifted, unmanaged, corrected, time.Now())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !result.Delta.Empty() {
+		msg := "Corrected drift"
+		if c.DryRun {
+			msg = "Drift detected"
+		}
+		logger.Info(msg,
+			zap.Strings("added", result.Delta.Added),
+			zap.Strings("modified", result.Delta.Modified),
+			zap.Strings("deleted", result.Delta.Deleted))
+	}
+	if len(result.Unmanaged) > 0 {
+		logger.Debug("Remote variables not in git", zap.Strings("keys", result.Unmanaged))
+	}
+
+	return result, nil
+}
+
+func (c *Controller) reconcile(ctx context.Context, envName, sha string) (*Result, int, error) {
+	files, ok := c.Environments[envName]
+	if !ok {
+		return nil, 0, fmt.Errorf("environment %s is not managed by this controller", envName)
+	}
+
+	desired, err := LoadAt(ctx, c.Repo, sha, files)
+	if err != nil {
+		return nil, 0, err
+	}
+	actual, err := c.Remote.PullEnvironment(ctx, envName)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read remote variables: %w", err)
+	}
+
+	result := &Result{Commit: sha, Delta: NewDelta(envName, actual, desired)}
+	if !c.Prune {
+		result.Unmanaged = result.Delta.Deleted
+		result.Delta.Deleted = nil
+	}
+
+	if c.DryRun {
+		return result, 0, nil
+	}
+
+	corrected := 0
+	if !result.Delta.Empty() {
+		if c.Check != nil {
+			if err := c.Check(ctx, envName, result.Delta); err != nil {
+				return nil, 0, err
+			}
+		}
+		if err := c.renewLease(ctx); err != nil {
+			return nil, 0, err
+		}
+		if err := c.Remote.ApplyChanges(ctx, envName, result.Delta.Set(), result.Delta.Deleted); err != nil {
+			return nil, 0, fmt.Errorf("failed to apply changes: %w", err)
+		}
+		corrected = len(result.Delta.Added) + len(result.Delta.Modified) + len(result.Delta.Deleted)
+	}
+
+	if c.applied == nil {
+		c.applied = make(map[string]string)
+	}
+	if c.applied[envName] != sha {
+		if c.OnApplied != nil {
+			if err := c.renewLease(ctx); err != nil {
+				return nil, corrected, err
+			}
+			if err := c.OnApplied(ctx, envName, sha); err != nil {
+				return nil, corrected, err
+			}
+		}
+		c.applied[envName] = sha
+		if c.Metrics != nil {
+			c.Metrics.SetAppliedCommit(envName, sha)
+		}
+	}
+
+	return result, corrected, nil
+}
+
+// renewLease confirms this replica still leads before a write
+func (c *Controller) renewLease(ctx context.Context) error {
+	if c.Lease == nil {
+		return nil
+	}
+	if err := c.Lease.Renew(ctx); err != nil {
+		if errors.Is(err, ErrLeaseLost) {
+			c.setLeader(false)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *Controller) setLeader(leader bool) {
+	if c.Metrics != nil {
+		c.Metrics.SetLeader(leader)
+	}
+}
+
+func (c *Controller) logger() *zap.Logger {
+	if c.Logger == nil {
+		return zap.NewNop()
+	}
+	return c.Logger
+}
@@ -0,0 +1,241 @@
+package gitops
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/drapon/envy/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRemote stores environments in memory
+type fakeRemote struct {
+	mu      sync.Mutex
+	envs    map[string]map[string]string
+	applies int
+}
+
+func (r *fakeRemote) PullEnvironment(ctx context.Context, envName string) (*env.File, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	file := env.NewFile()
+	for k, v := range r.envs[envName] {
+		file.Set(k, v)
+	}
+	return file, nil
+}
+
+func (r *fakeRemote) ApplyChanges(ctx context.Context, envName string, set *env.File, deleted []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.applies++
+	if r.envs[envName] == nil {
+		r.envs[envName] = make(map[string]string)
+	}
+	for k, v := range set.ToMap() {
+		r.envs[envName][k] = v
+	}
+	for _, k := range deleted {
+		delete(r.envs[envName], k)
+	}
+	return nil
+}
+
+func (r *fakeRemote) applyCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.applies
+}
+
+func TestController_Reconcile(t *testing.T) {
+	repo := newTestRepo(t)
+	sha := commit(t, repo, map[string]string{".env.prod": "A=1\nB=2\n"})
+
+	remote := &fakeRemote{envs: map[string]map[string]string{
+		"prod": {"A": "changed-in-console", "EXTRA": "x"},
+	}}
+	var recorded []string
+	c := &Controller{
+		Repo:         repo,
+		Remote:       remote,
+		Environments: map[string][]string{"prod": {".env.prod"}},
+		Metrics:      NewMetrics(),
+		OnApplied: func(ctx context.Context, envName, sha string) error {
+			recorded = append(recorded, envName+"@"+sha)
+			return nil
+		},
+	}
+
+	result, err := c.Reconcile(context.Background(), "prod", sha)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"B"}, result.Delta.Added)
+	assert.Equal(t, []string{"A"}, result.Delta.Modified)
+	assert.Empty(t, result.Delta.Deleted)
+	assert.Equal(t, []string{"EXTRA"}, result.Unmanaged)
+	assert.Equal(t, map[string]string{"A": "1", "B": "2", "EXTRA": "x"}, remote.envs["prod"])
+	assert.Equal(t, []string{"prod@" + sha}, recorded)
+
+	// A second pass finds no drift and does not re-record the commit
+	result, err = c.Reconcile(context.Background(), "prod", sha)
+	require.NoError(t, err)
+	assert.True(t, result.Delta.Empty())
+	assert.Equal(t, 1, remote.applies)
+	assert.Len(t, recorded, 1)
+}
+
+func TestController_ReconcilePruneAndDryRun(t *testing.T) {
+	repo := newTestRepo(t)
+	sha := commit(t, repo, map[string]string{".env.prod": "A=1\n"})
+
+	remote := &fakeRemote{envs: map[string]map[string]string{
+		"prod": {"A": "1", "EXTRA": "x"},
+	}}
+	c := &Controller{
+		Repo:         repo,
+		Remote:       remote,
+		Environments: map[string][]string{"prod": {".env.prod"}},
+		Prune:        true,
+		DryRun:       true,
+	}
+
+	result, err := c.Reconcile(context.Background(), "prod", sha)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"EXTRA"}, result.Delta.Deleted)
+	assert.Equal(t, 0, remote.applies, "dry run does not write")
+
+	c.DryRun = false
+	_, err = c.Reconcile(context.Background(), "prod", sha)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "1"}, remote.envs["prod"])
+}
+
+func TestController_CheckBlocksWrite(t *testing.T) {
+	repo := newTestRepo(t)
+	sha := commit(t, repo, map[string]string{".env.prod": "A=changeme\n"})
+
+	remote := &fakeRemote{envs: map[string]map[string]string{"prod": {"A": "1"}}}
+	var checked []string
+	c := &Controller{
+		Repo:         repo,
+		Remote:       remote,
+		Environments: map[string][]string{"prod": {".env.prod"}},
+		Check: func(ctx context.Context, envName string, delta *Delta) error {
+			checked = append(checked, delta.Modified...)
+			return assert.AnError
+		},
+	}
+
+	_, err := c.Reconcile(context.Background(), "prod", sha)
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, []string{"A"}, checked)
+	assert.Equal(t, 0, remote.applies)
+	assert.Equal(t, map[string]string{"A": "1"}, remote.envs["prod"])
+}
+
+func TestController_SyncRequiresLease(t *testing.T) {
+	repo := newTestRepo(t)
+	commit(t, repo, map[string]string{".env.prod": "A=1\n"})
+
+	store := newMemoryLeaseStore()
+	now := time.Now()
+	other := newTestLease(store, "other", &now)
+	leader, err := other.TryAcquire(context.Background())
+	require.NoError(t, err)
+	require.True(t, leader)
+
+	remote := &fakeRemote{envs: map[string]map[string]string{}}
+	metrics := NewMetrics()
+	c := &Controller{
+		Repo:         repo,
+		Remote:       remote,
+		Environments: map[string][]string{"prod": {".env.prod"}},
+		Ref:          "HEAD",
+		Lease:        newTestLease(store, "me", &now),
+		Metrics:      metrics,
+	}
+
+	require.NoError(t, c.Sync(context.Background()))
+	assert.Equal(t, 0, remote.applies, "follower does not reconcile")
+
+	require.NoError(t, other.Release(context.Background()))
+	require.NoError(t, c.Sync(context.Background()))
+	assert.Equal(t, map[string]string{"A": "1"}, remote.envs["prod"])
+}
+
+// stealingRemote lets another replica take the lease while a pull is in
+// flight, as happens when a sync outlives the lease
+type stealingRemote struct {
+	*fakeRemote
+	steal func()
+}
+
+func (r *stealingRemote) PullEnvironment(ctx context.Context, envName string) (*env.File, error) {
+	r.steal()
+	return r.fakeRemote.PullEnvironment(ctx, envName)
+}
+
+func TestController_StopsWritingWhenLeaseLost(t *testing.T) {
+	repo := newTestRepo(t)
+	commit(t, repo, map[string]string{".env.prod": "A=1\n"})
+
+	store := newMemoryLeaseStore()
+	now := time.Now()
+	lease := newTestLease(store, "me", &now)
+	other := newTestLease(store, "other", &now)
+
+	remote := &stealingRemote{
+		fakeRemote: &fakeRemote{envs: map[string]map[string]string{}},
+		steal: func() {
+			now = now.Add(time.Minute)
+			leader, err := other.TryAcquire(context.Background())
+			require.NoError(t, err)
+			require.True(t, leader)
+		},
+	}
+	metrics := NewMetrics()
+	c := &Controller{
+		Repo:         repo,
+		Remote:       remote,
+		Environments: map[string][]string{"prod": {".env.prod"}},
+		Ref:          "HEAD",
+		Lease:        lease,
+		Metrics:      metrics,
+	}
+
+	err := c.Sync(context.Background())
+	assert.ErrorIs(t, err, ErrLeaseLost)
+	assert.Equal(t, 0, remote.applyCount(), "a replica that lost the lease does not write")
+	assert.Equal(t, float64(0), metrics.leader)
+}
+
+func TestController_RunStopsOnCancel(t *testing.T) {
+	repo := newTestRepo(t)
+	commit(t, repo, map[string]string{".env.prod": "A=1\n"})
+
+	remote := &fakeRemote{envs: map[string]map[string]string{}}
+	c := &Controller{
+		Repo:         repo,
+		Remote:       remote,
+		Environments: map[string][]string{"prod": {".env.prod"}},
+		Ref:          "HEAD",
+		Interval:     time.Hour,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return remote.applyCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+
+	assert.Error(t, (&Controller{}).Run(context.Background()), "interval is required")
+}
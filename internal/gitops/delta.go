@@ -0,0 +1,158 @@
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/drapon/envy/internal/env"
+)
+
+// Delta describes how an environment's variables changed between two commits
+type Delta struct {
+	Environment string
+	// Files are the environment's files that changed in the range
+	Files []string
+	// Added, Modified and Deleted are sorted variable names
+	Added    []string
+	Modified []string
+	Deleted  []string
+	// Previous and Current are the merged variables at each commit
+	Previous *env.File
+	Current  *env.File
+}
+
+// Empty reports whether the delta contains no variable changes
+func (d *Delta) Empty() bool {
+	return len(d.Added) == 0 && len(d.Modified) == 0 && len(d.Deleted) == 0
+}
+
+// Set returns the added and modified variables with their new values
+func (d *Delta) Set() *env.File {
+	set := env.NewFile()
+	for _, key := range d.Current.Keys() {
+		if contains(d.Added, key) || contains(d.Modified, key) {
+			value, _ := d.Current.Get(key)
+			set.Set(key, value)
+		}
+	}
+	return set
+}
+
+// NewDelta compares two sets of variables. Keys only in previous are
+// reported as deleted and keys only in current as added.
+func NewDelta(envName string, previous, current *env.File) *Delta {
+	delta := &Delta{
+		Environment: envName,
+		Previous:    previous,
+		Current:     current,
+	}
+
+	for _, key := range current.Keys() {
+		newValue, _ := current.Get(key)
+		oldValue, existed := previous.Get(key)
+		switch {
+		case !existed:
+			delta.Added = append(delta.Added, key)
+		case oldValue != newValue:
+			delta.Modified = append(delta.Modified, key)
+		}
+	}
+	for _, key := range previous.Keys() {
+		if _, ok := current.Get(key); !ok {
+			delta.Deleted = append(delta.Deleted, key)
+		}
+	}
+
+	sort.Strings(delta.Added)
+	sort.Strings(delta.Modified)
+	sort.Strings(delta.Deleted)
+
+	return delta
+}
+
+// AffectedFiles returns the entries of files that appear in changed
+func AffectedFiles(changed, files []string) []string {
+	changedSet := make(map[string]bool, len(changed))
+	for _, name := range changed {
+		changedSet[path.Clean(name)] = true
+	}
+
+	var affected []string
+	for _, name := range files {
+		if changedSet[path.Clean(name)] {
+			affected = append(affected, name)
+		}
+	}
+	return affected
+}
+
+// ComputeDelta compares the merged contents of files at two commits. Files
+// are merged in order, later files overriding earlier ones, the same way
+// push loads them from disk. A file missing at a commit contributes nothing.
+func ComputeDelta(ctx context.Context, repo *Repo, envName, from, to string, files []string) (*Delta, error) {
+	changed, err := repo.ChangedFiles(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	affected := AffectedFiles(changed, files)
+	if len(affected) == 0 {
+		// Nothing to load: the environment is identical at both commits
+		return NewDelta(envName, env.NewFile(), env.NewFile()), nil
+	}
+
+	previous, err := LoadAt(ctx, repo, from, files)
+	if err != nil {
+		return nil, err
+	}
+	current, err := LoadAt(ctx, repo, to, files)
+	if err != nil {
+		return nil, err
+	}
+
+	delta := NewDelta(envName, previous, current)
+	delta.Files = affected
+	return delta, nil
+}
+
+// LoadAt parses and merges files as they were at ref. Files missing at ref
+// contribute nothing.
+func LoadAt(ctx context.Context, repo *Repo, ref string, files []string) (*env.File, error) {
+	result := env.NewFile()
+	for _, name := range files {
+		content, ok, err := repo.ShowFile(ctx, ref, name)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		file, err := env.Parse(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s at %s: %w", name, ShortSHA(ref), err)
+		}
+		result.Merge(file)
+	}
+	return result, nil
+}
+
+// ShortSHA abbreviates a commit SHA for display
+func ShortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package gitops
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRepo creates an empty git repository in a temp directory
+func newTestRepo(t *testing.T) *Repo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "user.name", "test")
+	runGit(t, dir, "config", "commit.gpgsign", "false")
+	return NewRepo(dir)
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+// commit writes files (removing those with empty content) and commits them
+func commit(t *testing.T, repo *Repo, files map[string]string) string {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(repo.Dir, name)
+		if content == "" {
+			require.NoError(t, os.Remove(path))
+			continue
+		}
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	runGit(t, repo.Dir, "add", "-A")
+	runGit(t, repo.Dir, "commit", "-q", "--allow-empty", "-m", "update")
+
+	sha, err := repo.ResolveRef(context.Background(), "HEAD")
+	require.NoError(t, err)
+	return sha
+}
+
+func TestRepo_ResolveRef(t *testing.T) {
+	repo := newTestRepo(t)
+	sha := commit(t, repo, map[string]string{".env": "A=1\n"})
+
+	resolved, err := repo.ResolveRef(context.Background(), sha[:8])
+	require.NoError(t, err)
+	assert.Equal(t, sha, resolved)
+
+	_, err = repo.ResolveRef(context.Background(), "does-not-exist")
+	assert.Error(t, err)
+}
+
+func TestRepo_RejectsOptionRefs(t *testing.T) {
+	repo := newTestRepo(t)
+	sha := commit(t, repo, map[string]string{".env": "A=1\n"})
+	ctx := context.Background()
+
+	_, err := repo.ResolveRef(ctx, "--output=/tmp/pwned")
+	assert.ErrorContains(t, err, "must not start with '-'")
+
+	_, err = repo.ChangedFiles(ctx, sha, "--output=/tmp/pwned")
+	assert.ErrorContains(t, err, "must not start with '-'")
+
+	_, _, err = repo.ShowFile(ctx, "-p", ".env")
+	assert.ErrorContains(t, err, "must not start with '-'")
+
+	_, err = repo.Log(ctx, "--all", sha, nil)
+	assert.ErrorContains(t, err, "must not start with '-'")
+
+	assert.ErrorContains(t, repo.Fetch(ctx, "--upload-pack=touch /tmp/pwned"), "must not start with '-'")
+}
+
+func TestRepo_ShowFile(t *testing.T) {
+	repo := newTestRepo(t)
+	first := commit(t, repo, map[string]string{".env": "A=1\n"})
+	second := commit(t, repo, map[string]string{".env": "", "config/.env.dev": "B=2\n"})
+
+	content, ok, err := repo.ShowFile(context.Background(), first, ".env")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "A=1\n", string(content))
+
+	_, ok, err = repo.ShowFile(context.Background(), second, ".env")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	content, ok, err = repo.ShowFile(context.Background(), second, "./config/.env.dev")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "B=2\n", string(content))
+}
+
+func TestComputeDelta(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	from := commit(t, repo, map[string]string{
+		".env.dev":       "KEEP=1\nCHANGE=old\nREMOVE=x\n",
+		".env.dev.local": "OVERRIDE=base\n",
+		"README.md":      "docs\n",
+	})
+	to := commit(t, repo, map[string]string{
+		".env.dev":       "KEEP=1\nCHANGE=new\nADD=y\n",
+		".env.dev.local": "OVERRIDE=local\n",
+		"README.md":      "more docs\n",
+	})
+
+	delta, err := ComputeDelta(ctx, repo, "dev", from, to, []string{".env.dev", ".env.dev.local"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "dev", delta.Environment)
+	assert.Equal(t, []string{".env.dev", ".env.dev.local"}, delta.Files)
+	assert.Equal(t, []string{"ADD"}, delta.Added)
+	assert.Equal(t, []string{"CHANGE", "OVERRIDE"}, delta.Modified)
+	assert.Equal(t, []string{"REMOVE"}, delta.Deleted)
+	assert.False(t, delta.Empty())
+
+	set := delta.Set()
+	assert.ElementsMatch(t, []string{"ADD", "CHANGE", "OVERRIDE"}, set.Keys())
+	value, _ := set.Get("CHANGE")
+	assert.Equal(t, "new", value)
+}
+
+func TestComputeDelta_Unaffected(t *testing.T) {
+	repo := newTestRepo(t)
+	from := commit(t, repo, map[string]string{".env.prod": "A=1\n", "main.go": "package main\n"})
+	to := commit(t, repo, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+
+	delta, err := ComputeDelta(context.Background(), repo, "prod", from, to, []string{".env.prod"})
+	require.NoError(t, err)
+	assert.Empty(t, delta.Files)
+	assert.True(t, delta.Empty())
+}
+
+func TestComputeDelta_FileCreatedAndRemoved(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	first := commit(t, repo, map[string]string{"main.go": "package main\n"})
+	second := commit(t, repo, map[string]string{".env.new": "A=1\nB=2\n"})
+	third := commit(t, repo, map[string]string{".env.new": ""})
+
+	delta, err := ComputeDelta(ctx, repo, "new", first, second, []string{".env.new"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A", "B"}, delta.Added)
+
+	delta, err = ComputeDelta(ctx, repo, "new", second, third, []string{".env.new"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A", "B"}, delta.Deleted)
+}
+
+func TestAffectedFiles(t *testing.T) {
+	changed := []string{"config/.env", ".env.prod", "main.go"}
+	files := []string{"./config/.env", ".env.dev", ".env.prod"}
+
+	assert.Equal(t, []string{"./config/.env", ".env.prod"}, AffectedFiles(changed, files))
+	assert.Empty(t, AffectedFiles(nil, files))
+}
+
+func TestShortSHA(t *testing.T) {
+	assert.Equal(t, "0123456789ab", ShortSHA("0123456789abcdef0123456789abcdef01234567"))
+	assert.Equal(t, "abc", ShortSHA("abc"))
+}
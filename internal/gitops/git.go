@@ -0,0 +1,178 @@
+// Package gitops computes environment variable changes between git commits
+// so that merges, rather than manual pushes, drive remote configuration.
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Repo runs git commands against a working tree
+type Repo struct {
+	// Dir is the directory git runs in. Paths passed to Repo methods are
+	// relative to it, matching the file paths in .envyrc.
+	Dir string
+}
+
+// NewRepo returns a Repo rooted at dir
+func NewRepo(dir string) *Repo {
+	if dir == "" {
+		dir = "."
+	}
+	return &Repo{Dir: dir}
+}
+
+// checkRef rejects refs git would parse as an option. Commands also pass
+// --end-of-options before refs, so this only makes the error clearer.
+func checkRef(refs ...string) error {
+	for _, ref := range refs {
+		if ref == "" {
+			return fmt.Errorf("empty git revision")
+		}
+		if strings.HasPrefix(ref, "-") {
+			return fmt.Errorf("invalid git revision %q: must not start with '-'", ref)
+		}
+	}
+	return nil
+}
+
+// ResolveRef resolves a ref (branch, tag, SHA, HEAD~1, ...) to a full commit SHA
+func (r *Repo) ResolveRef(ctx context.Context, ref string) (string, error) {
+	if err := checkRef(ref); err != nil {
+		return "", err
+	}
+	out, err := r.git(ctx, "rev-parse", "--verify", "--quiet", "--end-of-options", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown git revision %q", ref)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ChangedFiles lists the files under Dir that differ between two commits
+func (r *Repo) ChangedFiles(ctx context.Context, from, to string) ([]string, error) {
+	if err := checkRef(from, to); err != nil {
+		return nil, err
+	}
+	out, err := r.git(ctx, "diff", "--name-only", "--relative", "--end-of-options", from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// ShowFile returns the contents of path at the given commit. The boolean is
+// false when the file does not exist in that commit.
+func (r *Repo) ShowFile(ctx context.Context, ref, path string) ([]byte, bool, error) {
+	if err := checkRef(ref); err != nil {
+		return nil, false, err
+	}
+	spec := ref + ":./" + strings.TrimPrefix(path, "./")
+
+	// Check existence first so a missing file is not reported as an error
+	if _, err := r.git(ctx, "cat-file", "-e", "--end-of-options", spec); err != nil {
+		return nil, false, nil
+	}
+
+	out, err := r.git(ctx, "show", "--end-of-options", spec)
+	if err != nil {
+		return nil, false, err
+	}
+	return out, true, nil
+}
+
+// Commit is a commit as listed by Log
+type Commit struct {
+	SHA     string    `json:"sha"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	Time    time.Time `json:"time"`
+	Subject string    `json:"subject"`
+}
+
+// Log lists the commits after from up to and including to that touch any of
+// paths, oldest first
+func (r *Repo) Log(ctx context.Context, from, to string, paths []string) ([]Commit, error) {
+	if err := checkRef(from, to); err != nil {
+		return nil, err
+	}
+	args := []string{"log", "--reverse", "--format=" + logFormat, "--end-of-options", from + ".." + to, "--"}
+	out, err := r.git(ctx, append(args, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(out)
+}
+
+// History lists every commit on any ref that touches any of paths, oldest
+// first, including commits that deleted them
+func (r *Repo) History(ctx context.Context, paths []string) ([]Commit, error) {
+	args := []string{"log", "--all", "--reverse", "--format=" + logFormat, "--"}
+	out, err := r.git(ctx, append(args, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(out)
+}
+
+// logFormat separates the fields parseLog reads with unit separators
+const logFormat = "%H%x1f%an%x1f%ae%x1f%aI%x1f%s"
+
+// parseLog parses git log output in logFormat
+func parseLog(out []byte) ([]Commit, error) {
+	var commits []Commit
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		when, err := time.Parse(time.RFC3339, fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected commit date %q: %w", fields[3], err)
+		}
+		commits = append(commits, Commit{
+			SHA:     fields[0],
+			Author:  fields[1],
+			Email:   fields[2],
+			Time:    when,
+			Subject: fields[4],
+		})
+	}
+	return commits, nil
+}
+
+// Fetch updates remote-tracking refs from the named remote
+func (r *Repo) Fetch(ctx context.Context, remote string) error {
+	if err := checkRef(remote); err != nil {
+		return err
+	}
+	_, err := r.git(ctx, "fetch", "--quiet", "--prune", "--end-of-options", remote)
+	return err
+}
+
+func (r *Repo) git(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.Dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
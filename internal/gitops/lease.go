@@ -0,0 +1,163 @@
+package gitops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLeaseLost is returned when a replica no longer holds the lease it
+// needs to write
+var ErrLeaseLost = errors.New("lease lost")
+
+// LeaseStore persists a lease record. Create must fail (returning false)
+// when the record already exists so that only one replica can create it.
+type LeaseStore interface {
+	Get(ctx context.Context, name string) (string, bool, error)
+	Create(ctx context.Context, name, value string) (bool, error)
+	Put(ctx context.Context, name, value string) error
+}
+
+// LeaseRecord is the stored state of a lease
+type LeaseRecord struct {
+	Holder    string    `json:"holder"`
+	RenewTime time.Time `json:"renewTime"`
+	// DurationSeconds is how long the lease stays valid after RenewTime
+	DurationSeconds int `json:"leaseDurationSeconds"`
+}
+
+// Expired reports whether the lease has lapsed at now
+func (r *LeaseRecord) Expired(now time.Time) bool {
+	return !now.Before(r.RenewTime.Add(time.Duration(r.DurationSeconds) * time.Second))
+}
+
+// Lease implements leader election over a LeaseStore. The holder renews the
+// record on every TryAcquire; other replicas take it over only once it has
+// expired. Stores without conditional writes make takeover last-writer-wins
+// followed by a read-back, so two replicas may briefly both believe they
+// lead. Writers call Renew before each write, which stops a replica whose
+// takeover was overwritten, or whose lease expired during a long sync.
+type Lease struct {
+	Store    LeaseStore
+	Name     string
+	Identity string
+	Duration time.Duration
+
+	now func() time.Time
+}
+
+// NewLease returns a lease held under identity for duration
+func NewLease(store LeaseStore, name, identity string, duration time.Duration) *Lease {
+	return &Lease{
+		Store:    store,
+		Name:     name,
+		Identity: identity,
+		Duration: duration,
+		now:      time.Now,
+	}
+}
+
+// TryAcquire acquires or renews the lease and reports whether this replica
+// is the leader
+func (l *Lease) TryAcquire(ctx context.Context) (bool, error) {
+	record, found, err := l.get(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if !found {
+		created, err := l.Store.Create(ctx, l.Name, l.encode(l.now()))
+		if err != nil {
+			return false, fmt.Errorf("failed to create lease %s: %w", l.Name, err)
+		}
+		if created {
+			return true, nil
+		}
+		// Another replica created it first
+		if record, found, err = l.get(ctx); err != nil || !found {
+			return false, err
+		}
+	}
+
+	if record.Holder == l.Identity {
+		if err := l.Store.Put(ctx, l.Name, l.encode(l.now())); err != nil {
+			return false, fmt.Errorf("failed to renew lease %s: %w", l.Name, err)
+		}
+		return true, nil
+	}
+
+	if !record.Expired(l.now()) {
+		return false, nil
+	}
+
+	// Take over the expired lease, then confirm no other replica overwrote it
+	if err := l.Store.Put(ctx, l.Name, l.encode(l.now())); err != nil {
+		return false, fmt.Errorf("failed to take over lease %s: %w", l.Name, err)
+	}
+	record, found, err = l.get(ctx)
+	if err != nil || !found {
+		return false, err
+	}
+	return record.Holder == l.Identity, nil
+}
+
+// Renew extends the lease if this replica still holds it and it has not
+// expired, and returns ErrLeaseLost otherwise
+func (l *Lease) Renew(ctx context.Context) error {
+	record, found, err := l.get(ctx)
+	if err != nil {
+		return err
+	}
+	if !found || record.Holder != l.Identity || record.Expired(l.now()) {
+		return fmt.Errorf("%s: %w", l.Name, ErrLeaseLost)
+	}
+
+	if err := l.Store.Put(ctx, l.Name, l.encode(l.now())); err != nil {
+		return fmt.Errorf("failed to renew lease %s: %w", l.Name, err)
+	}
+	return nil
+}
+
+// Release gives up the lease if this replica holds it, so another replica
+// can take over without waiting for it to expire
+func (l *Lease) Release(ctx context.Context) error {
+	record, found, err := l.get(ctx)
+	if err != nil || !found || record.Holder != l.Identity {
+		return err
+	}
+
+	released := LeaseRecord{Holder: l.Identity}
+	data, err := json.Marshal(released)
+	if err != nil {
+		return err
+	}
+	return l.Store.Put(ctx, l.Name, string(data))
+}
+
+func (l *Lease) get(ctx context.Context) (*LeaseRecord, bool, error) {
+	value, found, err := l.Store.Get(ctx, l.Name)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read lease %s: %w", l.Name, err)
+	}
+	if !found {
+		return nil, false, nil
+	}
+
+	var record LeaseRecord
+	if err := json.Unmarshal([]byte(value), &record); err != nil {
+		// Treat an unreadable record as expired so it gets replaced
+		return &LeaseRecord{}, true, nil
+	}
+	return &record, true, nil
+}
+
+func (l *Lease) encode(now time.Time) string {
+	data, _ := json.Marshal(LeaseRecord{
+		Holder:          l.Identity,
+		RenewTime:       now.UTC(),
+		DurationSeconds: int(l.Duration / time.Second),
+	})
+	return string(data)
+}
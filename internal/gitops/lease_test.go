@@ -0,0 +1,148 @@
+package gitops
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryLeaseStore is an in-memory LeaseStore
+type memoryLeaseStore struct {
+	values map[string]string
+}
+
+func newMemoryLeaseStore() *memoryLeaseStore {
+	return &memoryLeaseStore{values: make(map[string]string)}
+}
+
+func (s *memoryLeaseStore) Get(ctx context.Context, name string) (string, bool, error) {
+	value, ok := s.values[name]
+	return value, ok, nil
+}
+
+func (s *memoryLeaseStore) Create(ctx context.Context, name, value string) (bool, error) {
+	if _, ok := s.values[name]; ok {
+		return false, nil
+	}
+	s.values[name] = value
+	return true, nil
+}
+
+func (s *memoryLeaseStore) Put(ctx context.Context, name, value string) error {
+	s.values[name] = value
+	return nil
+}
+
+func newTestLease(store LeaseStore, identity string, now *time.Time) *Lease {
+	lease := NewLease(store, "/lease", identity, 30*time.Second)
+	lease.now = func() time.Time { return *now }
+	return lease
+}
+
+func TestLease_TryAcquire(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryLeaseStore()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a := newTestLease(store, "a", &now)
+	b := newTestLease(store, "b", &now)
+
+	leader, err := a.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, leader, "first replica creates the lease")
+
+	leader, err = b.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, leader, "lease is held by a")
+
+	// a renews before expiry
+	now = now.Add(20 * time.Second)
+	leader, err = a.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, leader)
+
+	now = now.Add(20 * time.Second)
+	leader, err = b.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, leader, "renewal extended the lease")
+
+	// a stops renewing; b takes over after expiry
+	now = now.Add(15 * time.Second)
+	leader, err = b.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, leader)
+
+	leader, err = a.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, leader)
+}
+
+func TestLease_Renew(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryLeaseStore()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a := newTestLease(store, "a", &now)
+	b := newTestLease(store, "b", &now)
+
+	assert.ErrorIs(t, a.Renew(ctx), ErrLeaseLost, "no lease yet")
+
+	leader, err := a.TryAcquire(ctx)
+	require.NoError(t, err)
+	require.True(t, leader)
+
+	now = now.Add(20 * time.Second)
+	require.NoError(t, a.Renew(ctx))
+	assert.ErrorIs(t, b.Renew(ctx), ErrLeaseLost, "b never held the lease")
+
+	// The renewal moved the expiry, so b cannot take over yet
+	now = now.Add(20 * time.Second)
+	leader, err = b.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, leader)
+
+	// a stalls past the expiry and b takes over; a must stop writing
+	now = now.Add(31 * time.Second)
+	assert.ErrorIs(t, a.Renew(ctx), ErrLeaseLost, "expired leases are not renewed")
+	leader, err = b.TryAcquire(ctx)
+	require.NoError(t, err)
+	require.True(t, leader)
+	assert.ErrorIs(t, a.Renew(ctx), ErrLeaseLost)
+}
+
+func TestLease_Release(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryLeaseStore()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a := newTestLease(store, "a", &now)
+	b := newTestLease(store, "b", &now)
+
+	leader, err := a.TryAcquire(ctx)
+	require.NoError(t, err)
+	require.True(t, leader)
+
+	// Releasing a lease held by someone else is a no-op
+	require.NoError(t, b.Release(ctx))
+	leader, err = b.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, leader)
+
+	require.NoError(t, a.Release(ctx))
+	leader, err = b.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, leader, "released lease can be taken immediately")
+}
+
+func TestLease_CorruptRecord(t *testing.T) {
+	store := newMemoryLeaseStore()
+	store.values["/lease"] = "not json"
+	now := time.Now()
+
+	leader, err := newTestLease(store, "a", &now).TryAcquire(context.Background())
+	require.NoError(t, err)
+	assert.True(t, leader)
+}
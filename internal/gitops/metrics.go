@@ -0,0 +1,166 @@
+package gitops
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Metrics collects controller metrics and serves them in the Prometheus
+// text exposition format
+type Metrics struct {
+	mu               sync.Mutex
+	reconciles       map[[2]string]float64 // environment, result
+	driftCorrections map[string]float64
+	driftedVariables map[string]float64
+	unmanaged        map[string]float64
+	lastReconcile    map[string]float64
+	appliedCommit    map[string]string
+	leader           float64
+}
+
+// NewMetrics returns an empty metrics collection
+func NewMetrics() *Metrics {
+	return &Metrics{
+		reconciles:       make(map[[2]string]float64),
+		driftCorrections: make(map[string]float64),
+		driftedVariables: make(map[string]float64),
+		unmanaged:        make(map[string]float64),
+		lastReconcile:    make(map[string]float64),
+		appliedCommit:    make(map[string]string),
+	}
+}
+
+// ObserveReconcile records the outcome of reconciling an environment. drifted
+// is the number of variables that differed from git, unmanaged the number of
+// remote variables not in git, and corrected how many were written or deleted.
+func (m *Metrics) ObserveReconcile(envName string, err error, drifted, unmanaged, corrected int, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	m.reconciles[[2]string{envName, result}]++
+	if err != nil {
+		return
+	}
+
+	m.driftedVariables[envName] = float64(drifted)
+	m.unmanaged[envName] = float64(unmanaged)
+	m.driftCorrections[envName] += float64(corrected)
+	m.lastReconcile[envName] = float64(at.Unix())
+}
+
+// SetAppliedCommit records the commit an environment was last reconciled to
+func (m *Metrics) SetAppliedCommit(envName, sha string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.appliedCommit[envName] = sha
+}
+
+// SetLeader records whether this replica currently holds the lease
+func (m *Metrics) SetLeader(leader bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.leader = 0
+	if leader {
+		m.leader = 1
+	}
+}
+
+// WriteTo writes all metrics in the Prometheus text format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+
+	writeHeader(&b, "envy_gitops_reconciles_total", "counter", "Reconcile attempts by environment and result.")
+	keys := make([][2]string, 0, len(m.reconciles))
+	for k := range m.reconciles {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, k := range keys {
+		writeSample(&b, "envy_gitops_reconciles_total", m.reconciles[k], "environment", k[0], "result", k[1])
+	}
+
+	writeEnvMetric(&b, "envy_gitops_drift_corrections_total", "counter", "Variables written or deleted to correct drift.", m.driftCorrections)
+	writeEnvMetric(&b, "envy_gitops_drifted_variables", "gauge", "Variables that differed from git at the last reconcile.", m.driftedVariables)
+	writeEnvMetric(&b, "envy_gitops_unmanaged_variables", "gauge", "Remote variables not present in git at the last reconcile.", m.unmanaged)
+	writeEnvMetric(&b, "envy_gitops_last_reconcile_timestamp_seconds", "gauge", "Unix time of the last successful reconcile.", m.lastReconcile)
+
+	writeHeader(&b, "envy_gitops_applied_commit_info", "gauge", "Commit each environment was last reconciled to.")
+	for _, envName := range sortedKeys(m.appliedCommit) {
+		writeSample(&b, "envy_gitops_applied_commit_info", 1, "environment", envName, "commit", m.appliedCommit[envName])
+	}
+
+	writeHeader(&b, "envy_gitops_leader", "gauge", "Whether this replica holds the controller lease.")
+	writeSample(&b, "envy_gitops_leader", m.leader)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Handler serves the metrics over HTTP. Failed writes, usually a scraper
+// that disconnected, are logged to logger if it is not nil.
+func (m *Metrics) Handler(logger *zap.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if _, err := m.WriteTo(w); err != nil && logger != nil {
+			logger.Debug("Failed to write metrics response", zap.Error(err))
+		}
+	})
+}
+
+func writeEnvMetric(b *strings.Builder, name, kind, help string, values map[string]float64) {
+	writeHeader(b, name, kind, help)
+	for _, envName := range sortedKeys(values) {
+		writeSample(b, name, values[envName], "environment", envName)
+	}
+}
+
+func writeHeader(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// writeSample writes one sample; labels are name/value pairs
+func writeSample(b *strings.Builder, name string, value float64, labels ...string) {
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=%q", labels[i], labels[i+1])
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	b.WriteByte('\n')
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,49 @@
+package gitops
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_WriteTo(t *testing.T) {
+	m := NewMetrics()
+	at := time.Unix(1700000000, 0)
+
+	m.ObserveReconcile("prod", nil, 2, 1, 2, at)
+	m.ObserveReconcile("prod", nil, 0, 1, 0, at)
+	m.ObserveReconcile("dev", errors.New("boom"), 0, 0, 0, at)
+	m.SetAppliedCommit("prod", "abc123")
+	m.SetLeader(true)
+
+	var b strings.Builder
+	_, err := m.WriteTo(&b)
+	require.NoError(t, err)
+	out := b.String()
+
+	assert.Contains(t, out, "# TYPE envy_gitops_reconciles_total counter\n")
+	assert.Contains(t, out, `envy_gitops_reconciles_total{environment="dev",result="error"} 1`)
+	assert.Contains(t, out, `envy_gitops_reconciles_total{environment="prod",result="success"} 2`)
+	assert.Contains(t, out, `envy_gitops_drift_corrections_total{environment="prod"} 2`)
+	assert.Contains(t, out, `envy_gitops_drifted_variables{environment="prod"} 0`)
+	assert.Contains(t, out, `envy_gitops_unmanaged_variables{environment="prod"} 1`)
+	assert.Contains(t, out, `envy_gitops_last_reconcile_timestamp_seconds{environment="prod"} 1.7e+09`)
+	assert.Contains(t, out, `envy_gitops_applied_commit_info{environment="prod",commit="abc123"} 1`)
+	assert.Contains(t, out, "envy_gitops_leader 1\n")
+	assert.NotContains(t, out, `envy_gitops_drifted_variables{environment="dev"}`)
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := NewMetrics()
+	rec := httptest.NewRecorder()
+	m.Handler(nil).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, 200, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, rec.Body.String(), "envy_gitops_leader 0")
+}
@@ -0,0 +1,216 @@
+// Package history keeps a local journal of the changes pushed to each
+// environment, with the values before and after encrypted at rest, so a
+// push can be undone without relying on AWS version history.
+package history
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os/user"
+	"sort"
+	"time"
+
+	"github.com/drapon/envy/internal/config"
+)
+
+// DefaultDir is where the journal is kept, relative to the project
+const DefaultDir = ".envy/history"
+
+// DefaultKeep is how many records are kept per environment
+const DefaultKeep = 50
+
+// Change is one variable changed by a push. A nil Before means the
+// variable did not exist; a nil After means it was deleted.
+type Change struct {
+	Key    string  `json:"key"`
+	Before *string `json:"before,omitempty"`
+	After  *string `json:"after,omitempty"`
+}
+
+// Record is one applied push
+type Record struct {
+	ID          string    `json:"id"`
+	Environment string    `json:"environment"`
+	Time        time.Time `json:"time"`
+	By          string    `json:"by,omitempty"`
+	Command     string    `json:"command"`
+	Changes     []Change  `json:"changes"`
+}
+
+// NewRecord describes a push that wrote set and deleted the keys in
+// deleted, given the remote values before it. Variables whose value did
+// not change are left out.
+func NewRecord(envName, command string, before, set map[string]string, deleted []string, now time.Time) *Record {
+	var changes []Change
+	for key, value := range set {
+		old, existed := before[key]
+		if existed && old == value {
+			continue
+		}
+		change := Change{Key: key, After: stringPtr(value)}
+		if existed {
+			change.Before = stringPtr(old)
+		}
+		changes = append(changes, change)
+	}
+	for _, key := range deleted {
+		if old, existed := before[key]; existed {
+			changes = append(changes, Change{Key: key, Before: stringPtr(old)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+
+	by := ""
+	if u, err := user.Current(); err == nil {
+		by = u.Username
+	}
+	return &Record{
+		Environment: envName,
+		Time:        now.UTC(),
+		By:          by,
+		Command:     command,
+		Changes:     changes,
+	}
+}
+
+// Revert returns the changes that undo the record: variables to restore to
+// their previous value and variables the push created, to delete
+func (r *Record) Revert() (map[string]string, []string) {
+	set := make(map[string]string)
+	var deleted []string
+	for _, change := range r.Changes {
+		if change.Before == nil {
+			deleted = append(deleted, change.Key)
+		} else {
+			set[change.Key] = *change.Before
+		}
+	}
+	return set, deleted
+}
+
+// Drifted returns the keys whose current value is no longer the one the
+// push wrote, which undoing the push would overwrite
+func (r *Record) Drifted(current map[string]string) []string {
+	var drifted []string
+	for _, change := range r.Changes {
+		value, exists := current[change.Key]
+		if change.After == nil {
+			if exists {
+				drifted = append(drifted, change.Key)
+			}
+		} else if !exists || value != *change.After {
+			drifted = append(drifted, change.Key)
+		}
+	}
+	return drifted
+}
+
+// OpenConfig opens the journal configured in .envyrc. It returns nil when
+// history is disabled.
+func OpenConfig(cfg *config.Config) (*Journal, error) {
+	if cfg.History.Disabled {
+		return nil, nil
+	}
+
+	keyFile := cfg.History.KeyFile
+	if keyFile == "" {
+		var err error
+		if keyFile, err = DefaultKeyFile(); err != nil {
+			return nil, err
+		}
+	}
+	key, err := LoadKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := cfg.History.Dir
+	if dir == "" {
+		dir = DefaultDir
+	}
+	keep := cfg.History.Keep
+	if keep == 0 {
+		keep = DefaultKeep
+	}
+	return Open(dir, key, keep)
+}
+
+// Fetcher reads the current values of some variables of an environment
+type Fetcher interface {
+	GetVariables(ctx context.Context, envName string, keys []string) (map[string]string, error)
+}
+
+// Pending is a push about to be applied, holding the values from before it
+type Pending struct {
+	journal *Journal
+	fetcher Fetcher
+	envName string
+	command string
+	keys    []string
+	before  map[string]string
+}
+
+// Begin reads the variables a push is about to change, so that the push
+// can be recorded with Commit once it has been applied. Only keys are
+// read, not the whole environment. It returns nil when history is
+// disabled.
+func Begin(ctx context.Context, cfg *config.Config, fetcher Fetcher, envName, command string, keys []string) (*Pending, error) {
+	journal, err := OpenConfig(cfg)
+	if err != nil || journal == nil {
+		return nil, err
+	}
+	before, err := fetcher.GetVariables(ctx, envName, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read current values: %w", err)
+	}
+	return &Pending{journal: journal, fetcher: fetcher, envName: envName, command: command, keys: keys, before: before}, nil
+}
+
+// Commit records the applied push. Pushes that changed nothing are not
+// recorded.
+func (p *Pending) Commit(set map[string]string, deleted []string) error {
+	if p == nil {
+		return nil
+	}
+	rec := NewRecord(p.envName, p.command, p.before, set, deleted, time.Now())
+	if len(rec.Changes) == 0 {
+		return nil
+	}
+	return p.journal.Append(rec)
+}
+
+// CommitApplied reads the keys passed to Begin again and records what the
+// push actually changed, for pushes that may skip some keys, e.g. existing
+// ones without --force
+func (p *Pending) CommitApplied(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	after, err := p.fetcher.GetVariables(ctx, p.envName, p.keys)
+	if err != nil {
+		return fmt.Errorf("failed to read the pushed values: %w", err)
+	}
+
+	var deleted []string
+	for _, key := range p.keys {
+		if _, ok := after[key]; !ok {
+			deleted = append(deleted, key)
+		}
+	}
+	return p.Commit(after, deleted)
+}
+
+// newID returns a time-ordered identifier
+func newID(now time.Time) (string, error) {
+	random := make([]byte, 2)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate history ID: %w", err)
+	}
+	return now.UTC().Format("20060102-150405.000") + "-" + hex.EncodeToString(random), nil
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
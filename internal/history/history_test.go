@@ -0,0 +1,125 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRecord(t *testing.T) {
+	before := map[string]string{"A": "1", "B": "2", "C": "3"}
+	set := map[string]string{"A": "1", "B": "20", "D": "4"}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	rec := NewRecord("prod", "push", before, set, []string{"C", "MISSING"}, now)
+	assert.Equal(t, "prod", rec.Environment)
+	assert.Equal(t, "push", rec.Command)
+	assert.Equal(t, now, rec.Time)
+
+	require.Len(t, rec.Changes, 3)
+	assert.Equal(t, Change{Key: "B", Before: stringPtr("2"), After: stringPtr("20")}, rec.Changes[0])
+	assert.Equal(t, Change{Key: "C", Before: stringPtr("3")}, rec.Changes[1])
+	assert.Equal(t, Change{Key: "D", After: stringPtr("4")}, rec.Changes[2])
+
+	set, deleted := rec.Revert()
+	assert.Equal(t, map[string]string{"B": "2", "C": "3"}, set)
+	assert.Equal(t, []string{"D"}, deleted)
+}
+
+func TestRecord_Drifted(t *testing.T) {
+	rec := &Record{Changes: []Change{
+		{Key: "A", Before: stringPtr("1"), After: stringPtr("2")},
+		{Key: "B", Before: stringPtr("1")},
+		{Key: "C", After: stringPtr("3")},
+	}}
+
+	assert.Empty(t, rec.Drifted(map[string]string{"A": "2", "C": "3"}))
+	assert.Equal(t, []string{"A", "B", "C"}, rec.Drifted(map[string]string{"A": "5", "B": "1"}))
+}
+
+func TestOpenConfig(t *testing.T) {
+	journal, err := OpenConfig(&config.Config{History: config.HistoryConfig{Disabled: true}})
+	require.NoError(t, err)
+	assert.Nil(t, journal)
+
+	dir := t.TempDir()
+	journal, err = OpenConfig(&config.Config{History: config.HistoryConfig{
+		Dir:     filepath.Join(dir, "history"),
+		KeyFile: filepath.Join(dir, "history.key"),
+	}})
+	require.NoError(t, err)
+	require.NotNil(t, journal)
+	assert.Equal(t, DefaultKeep, journal.keep)
+	assert.FileExists(t, filepath.Join(dir, "history.key"))
+}
+
+type fakeFetcher map[string]string
+
+func (f fakeFetcher) GetVariables(ctx context.Context, envName string, keys []string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, key := range keys {
+		if value, ok := f[key]; ok {
+			vars[key] = value
+		}
+	}
+	return vars, nil
+}
+
+func TestBeginCommit(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := &config.Config{History: config.HistoryConfig{
+		Dir:     filepath.Join(dir, "history"),
+		KeyFile: filepath.Join(dir, "history.key"),
+	}}
+
+	pending, err := Begin(ctx, cfg, fakeFetcher{"A": "1"}, "prod", "push", []string{"A"})
+	require.NoError(t, err)
+	require.NoError(t, pending.Commit(map[string]string{"A": "1"}, nil))
+	require.NoError(t, pending.Commit(map[string]string{"A": "2"}, nil))
+
+	journal, err := OpenConfig(cfg)
+	require.NoError(t, err)
+	records, err := journal.List("prod")
+	require.NoError(t, err)
+	require.Len(t, records, 1, "a push that changed nothing is not recorded")
+	assert.Equal(t, "1", *records[0].Changes[0].Before)
+
+	// Disabled history records nothing
+	pending, err = Begin(ctx, &config.Config{History: config.HistoryConfig{Disabled: true}}, fakeFetcher{}, "prod", "push", nil)
+	require.NoError(t, err)
+	assert.Nil(t, pending)
+	assert.NoError(t, pending.Commit(map[string]string{"A": "3"}, nil))
+}
+
+func TestCommitApplied(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := &config.Config{History: config.HistoryConfig{
+		Dir:     filepath.Join(dir, "history"),
+		KeyFile: filepath.Join(dir, "history.key"),
+	}}
+
+	remote := fakeFetcher{"EXISTING": "old", "OTHER": "untouched"}
+	pending, err := Begin(ctx, cfg, remote, "prod", "push", []string{"EXISTING", "NEW"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"EXISTING": "old"}, pending.before, "only the pushed keys are read")
+
+	// The push skipped EXISTING and created NEW
+	remote["NEW"] = "1"
+	require.NoError(t, pending.CommitApplied(ctx))
+
+	journal, err := OpenConfig(cfg)
+	require.NoError(t, err)
+	records, err := journal.List("prod")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Len(t, records[0].Changes, 1)
+	assert.Equal(t, "NEW", records[0].Changes[0].Key)
+	assert.Nil(t, records[0].Changes[0].Before)
+}
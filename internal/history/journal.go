@@ -0,0 +1,202 @@
+package history
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Journal stores records as encrypted files, one directory per environment
+type Journal struct {
+	dir  string
+	keep int
+	gcm  cipher.AEAD
+}
+
+// Open returns the journal in dir, encrypted with key. Only the newest
+// keep records of each environment are kept.
+func Open(dir string, key []byte, keep int) (*Journal, error) {
+	sum := sha256.Sum256(key)
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize history encryption: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize history encryption: %w", err)
+	}
+	return &Journal{dir: dir, keep: keep, gcm: gcm}, nil
+}
+
+// Append adds a record to its environment's journal, assigning it an ID
+func (j *Journal) Append(rec *Record) error {
+	id, err := newID(rec.Time)
+	if err != nil {
+		return err
+	}
+	rec.ID = id
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history record: %w", err)
+	}
+	nonce := make([]byte, j.gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	dir := j.envDir(rec.Environment)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, rec.ID+".enc"), j.gcm.Seal(nonce, nonce, data, nil), 0600); err != nil {
+		return fmt.Errorf("failed to write history record: %w", err)
+	}
+	return j.prune(rec.Environment)
+}
+
+// List returns the records of an environment, newest first
+func (j *Journal) List(envName string) ([]*Record, error) {
+	ids, err := j.ids(envName)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*Record, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		rec, err := j.read(envName, ids[i])
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// Last returns the newest record of an environment. The boolean is false
+// when there is none.
+func (j *Journal) Last(envName string) (*Record, bool, error) {
+	ids, err := j.ids(envName)
+	if err != nil || len(ids) == 0 {
+		return nil, false, err
+	}
+	rec, err := j.read(envName, ids[len(ids)-1])
+	if err != nil {
+		return nil, false, err
+	}
+	return rec, true, nil
+}
+
+// Remove deletes a record, e.g. once it has been undone
+func (j *Journal) Remove(envName, id string) error {
+	err := os.Remove(filepath.Join(j.envDir(envName), filepath.Base(id)+".enc"))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no history record %s for %s", id, envName)
+	}
+	return err
+}
+
+func (j *Journal) read(envName, id string) (*Record, error) {
+	data, err := os.ReadFile(filepath.Join(j.envDir(envName), id+".enc"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history record: %w", err)
+	}
+
+	nonceSize := j.gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("history record %s is corrupt", id)
+	}
+	plaintext, err := j.gcm.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt history record %s (was the key changed?): %w", id, err)
+	}
+
+	var rec Record
+	if err := json.Unmarshal(plaintext, &rec); err != nil {
+		return nil, fmt.Errorf("failed to parse history record %s: %w", id, err)
+	}
+	return &rec, nil
+}
+
+// ids returns the record IDs of an environment, oldest first
+func (j *Journal) ids(envName string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(j.envDir(envName), "*.enc"))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(files))
+	for _, file := range files {
+		ids = append(ids, strings.TrimSuffix(filepath.Base(file), ".enc"))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func (j *Journal) prune(envName string) error {
+	if j.keep <= 0 {
+		return nil
+	}
+	ids, err := j.ids(envName)
+	if err != nil {
+		return err
+	}
+	for len(ids) > j.keep {
+		if err := j.Remove(envName, ids[0]); err != nil {
+			return err
+		}
+		ids = ids[1:]
+	}
+	return nil
+}
+
+func (j *Journal) envDir(envName string) string {
+	return filepath.Join(j.dir, filepath.Base(envName))
+}
+
+// DefaultKeyFile returns the key file used when history.key_file is not
+// set, outside the project so the journal cannot be read from a copy of it
+func DefaultKeyFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the user config directory: %w", err)
+	}
+	return filepath.Join(dir, "envy", "history.key"), nil
+}
+
+// LoadKey reads the journal key, generating it on first use
+func LoadKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key := strings.TrimSpace(string(data))
+		if key == "" {
+			return nil, fmt.Errorf("history key file %s is empty", path)
+		}
+		return []byte(key), nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read history key: %w", err)
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("failed to generate history key: %w", err)
+	}
+	key := hex.EncodeToString(random)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create history key directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(key+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write history key: %w", err)
+	}
+	return []byte(key), nil
+}
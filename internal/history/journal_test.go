@@ -0,0 +1,76 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal(t *testing.T) {
+	dir := t.TempDir()
+	journal, err := Open(dir, []byte("secret"), 2)
+	require.NoError(t, err)
+
+	_, ok, err := journal.Last("prod")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, value := range []string{"one", "two", "three"} {
+		rec := NewRecord("prod", "push", nil, map[string]string{"TOKEN": value}, nil, now.Add(time.Duration(i)*time.Minute))
+		require.NoError(t, journal.Append(rec))
+		assert.NotEmpty(t, rec.ID)
+	}
+
+	// Only the newest two are kept, and values are not stored in clear
+	files, err := filepath.Glob(filepath.Join(dir, "prod", "*.enc"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.False(t, strings.Contains(string(data), "TOKEN"))
+
+	records, err := journal.List("prod")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "three", *records[0].Changes[0].After)
+	assert.Equal(t, "two", *records[1].Changes[0].After)
+
+	last, ok, err := journal.Last("prod")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, records[0].ID, last.ID)
+
+	require.NoError(t, journal.Remove("prod", last.ID))
+	assert.Error(t, journal.Remove("prod", last.ID))
+	last, _, err = journal.Last("prod")
+	require.NoError(t, err)
+	assert.Equal(t, "two", *last.Changes[0].After)
+
+	// A different key cannot read the journal
+	other, err := Open(dir, []byte("other"), 2)
+	require.NoError(t, err)
+	_, err = other.List("prod")
+	assert.Error(t, err)
+}
+
+func TestLoadKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "envy", "history.key")
+
+	key, err := LoadKey(path)
+	require.NoError(t, err)
+	assert.Len(t, key, 64)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	again, err := LoadKey(path)
+	require.NoError(t, err)
+	assert.Equal(t, key, again)
+}
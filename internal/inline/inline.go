@@ -0,0 +1,285 @@
+// Package inline encrypts single values of .env files in place, so a file
+// can be committed with its secrets as ENC[...] ciphertext next to the
+// plain settings. Each value is self-contained:
+//
+//	ENC[v1,<key>,<wrapped data key>,<ciphertext>]
+//
+// where <key> names the key encryption key (kms:<key ARN>,
+// age:<fingerprint> or file:<fingerprint>) and the data key and ciphertext
+// are base64. The
+// values of one envy seal share a data key, so decrypting a file takes one
+// KMS call. Values are not bound to their key names and survive renames.
+package inline
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+
+	"github.com/drapon/envy/internal/artifact"
+	"github.com/drapon/envy/internal/aws/kms"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/envelope"
+)
+
+const (
+	prefix  = "ENC["
+	suffix  = "]"
+	version = "v1"
+
+	// KeyEnv holds a hex local key, for machines without the key file
+	KeyEnv = "ENVY_INLINE_KEY"
+
+	// AgeIdentityEnv holds age identities, for machines without the
+	// identity file
+	AgeIdentityEnv = "ENVY_AGE_IDENTITY"
+)
+
+// IsEncrypted reports whether value is an ENC[...] value
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix) && strings.HasSuffix(value, suffix)
+}
+
+// Encrypted returns the keys of file whose values are encrypted
+func Encrypted(file *env.File) []string {
+	var keys []string
+	for _, key := range file.Keys() {
+		if value, _ := file.Get(key); IsEncrypted(value) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Codec encrypts and decrypts values with the keys configured in .envyrc
+type Codec struct {
+	ctx           context.Context
+	keyring       *envelope.Keyring
+	kmsKeyID      string
+	ageRecipients []string
+
+	// the data key of values encrypted by this codec, and its wrapped form
+	dataKey []byte
+	wrapped string
+	kek     string
+
+	// data keys already unwrapped, by wrapped form
+	unwrapped map[string][]byte
+}
+
+// FromConfig returns a codec for the inline_encryption settings of cfg.
+// Keys and AWS clients are loaded when first needed, so files with only
+// local values decrypt without AWS and the reverse.
+func FromConfig(ctx context.Context, cfg *config.Config) *Codec {
+	c := &Codec{ctx: ctx, keyring: Keyring(ctx, cfg), unwrapped: make(map[string][]byte)}
+	if cfg != nil {
+		c.kmsKeyID = cfg.InlineEncryption.KMSKeyID
+		c.ageRecipients = cfg.InlineEncryption.AgeRecipients
+	}
+	return c
+}
+
+// Keyring returns the keys of the inline_encryption settings of cfg: KMS
+// keys with the AWS settings of .envyrc, the age identities of
+// ENVY_AGE_IDENTITY or age_identity_file, and the local key of
+// ENVY_INLINE_KEY or key_file. envy encrypt and decrypt use it too.
+func Keyring(ctx context.Context, cfg *config.Config) *envelope.Keyring {
+	keyFile, identityFile := "", ""
+	if cfg != nil {
+		keyFile = cfg.InlineEncryption.KeyFile
+		identityFile = cfg.InlineEncryption.AgeIdentityFile
+	}
+	return &envelope.Keyring{
+		KMSClient: func(keyID string) (*kms.Client, error) {
+			awsClient, err := artifact.ConfigClient(ctx, cfg)(keyID)
+			if err != nil {
+				return nil, err
+			}
+			return kms.NewClient(awsClient), nil
+		},
+		LocalKey: func() ([]byte, error) {
+			switch {
+			case os.Getenv(KeyEnv) != "":
+				key, err := envelope.ParseKey(os.Getenv(KeyEnv))
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", KeyEnv, err)
+				}
+				return key, nil
+			case keyFile != "":
+				return envelope.LoadKeyFile(keyFile)
+			}
+			return nil, fmt.Errorf("%w: set inline_encryption.key_file in .envyrc or %s", envelope.ErrNoLocalKey, KeyEnv)
+		},
+		AgeIdentities: func() ([]age.Identity, error) {
+			switch {
+			case os.Getenv(AgeIdentityEnv) != "":
+				identities, err := envelope.ParseAgeIdentities(strings.NewReader(os.Getenv(AgeIdentityEnv)))
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", AgeIdentityEnv, err)
+				}
+				return identities, nil
+			case identityFile != "":
+				file, err := os.Open(identityFile)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read age identity file: %w", err)
+				}
+				defer file.Close()
+				identities, err := envelope.ParseAgeIdentities(file)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", identityFile, err)
+				}
+				return identities, nil
+			}
+			return nil, fmt.Errorf("%w: set inline_encryption.age_identity_file in .envyrc or %s", envelope.ErrNoAgeIdentity, AgeIdentityEnv)
+		},
+	}
+}
+
+// Decrypt replaces the ENC[...] values of file with their plaintext. Files
+// without encrypted values are left alone without loading any key.
+func Decrypt(ctx context.Context, cfg *config.Config, file *env.File) error {
+	if len(Encrypted(file)) == 0 {
+		return nil
+	}
+	_, err := FromConfig(ctx, cfg).OpenFile(file)
+	return err
+}
+
+// Encrypt returns value as an ENC[...] value
+func (c *Codec) Encrypt(value string) (string, error) {
+	if c.dataKey == nil {
+		if err := c.newDataKey(); err != nil {
+			return "", err
+		}
+	}
+	sealed, err := envelope.Seal(c.dataKey, []byte(value))
+	if err != nil {
+		return "", err
+	}
+	return prefix + strings.Join([]string{version, c.kek, c.wrapped, base64.StdEncoding.EncodeToString(sealed)}, ",") + suffix, nil
+}
+
+// Decrypt returns the plaintext of an ENC[...] value
+func (c *Codec) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", fmt.Errorf("not an encrypted value")
+	}
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(value, prefix), suffix), ",")
+	if len(parts) != 4 {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	if parts[0] != version {
+		return "", fmt.Errorf("unsupported encrypted value version %s", parts[0])
+	}
+	kek, wrapped := parts[1], parts[2]
+
+	dataKey, ok := c.unwrapped[wrapped]
+	if !ok {
+		wrapper, err := c.keyring.Wrapper(kek)
+		if err != nil {
+			return "", err
+		}
+		blob, err := base64.StdEncoding.DecodeString(wrapped)
+		if err != nil {
+			return "", fmt.Errorf("malformed encrypted value: %w", err)
+		}
+		if dataKey, err = wrapper.Unwrap(c.ctx, blob); err != nil {
+			return "", err
+		}
+		c.unwrapped[wrapped] = dataKey
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	plaintext, err := envelope.Open(dataKey, sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// SealFile encrypts the plaintext values of the selected keys in file and
+// returns the keys it encrypted. Values that are already encrypted or
+// empty are left alone.
+func (c *Codec) SealFile(file *env.File, selected func(string) bool) ([]string, error) {
+	var sealed []string
+	for _, key := range file.Keys() {
+		value, _ := file.Get(key)
+		if value == "" || IsEncrypted(value) || !selected(key) {
+			continue
+		}
+		encrypted, err := c.Encrypt(value)
+		if err != nil {
+			return sealed, fmt.Errorf("failed to encrypt %s: %w", key, err)
+		}
+		file.Set(key, encrypted)
+		sealed = append(sealed, key)
+	}
+	return sealed, nil
+}
+
+// OpenFile decrypts the encrypted values of file in place and returns
+// their keys
+func (c *Codec) OpenFile(file *env.File) ([]string, error) {
+	keys := Encrypted(file)
+	for _, key := range keys {
+		value, _ := file.Get(key)
+		plaintext, err := c.Decrypt(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", key, err)
+		}
+		file.Set(key, plaintext)
+	}
+	return keys, nil
+}
+
+// newDataKey creates the data key for values encrypted by this codec and
+// wraps it with the KMS key, else for the age recipients, else with the
+// local key
+func (c *Codec) newDataKey() error {
+	var wrapper envelope.Wrapper
+	if c.kmsKeyID != "" {
+		kmsWrapper, err := c.keyring.KMS(c.kmsKeyID)
+		if err != nil {
+			return err
+		}
+		wrapper = kmsWrapper
+	} else if len(c.ageRecipients) > 0 {
+		ageWrapper, err := envelope.NewAgeWrapper(c.ageRecipients)
+		if err != nil {
+			return fmt.Errorf("inline_encryption.age_recipients: %w", err)
+		}
+		wrapper = ageWrapper
+	} else {
+		local, err := c.keyring.Local()
+		if errors.Is(err, envelope.ErrNoLocalKey) {
+			return fmt.Errorf("inline encryption is not configured: set inline_encryption.kms_key_id, age_recipients or key_file in .envyrc, or %s", KeyEnv)
+		}
+		if err != nil {
+			return err
+		}
+		wrapper = local
+	}
+
+	dataKey, err := envelope.NewDataKey()
+	if err != nil {
+		return err
+	}
+	wrapped, err := wrapper.Wrap(c.ctx, dataKey)
+	if err != nil {
+		return err
+	}
+	c.dataKey = dataKey
+	c.wrapped = base64.StdEncoding.EncodeToString(wrapped)
+	c.kek = wrapper.KeyID()
+	c.unwrapped[c.wrapped] = dataKey
+	return nil
+}
@@ -0,0 +1,141 @@
+package inline
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/envelope"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func localConfig(t *testing.T) *config.Config {
+	keyFile := filepath.Join(t.TempDir(), "inline.key")
+	require.NoError(t, envelope.GenerateKeyFile(keyFile))
+	return &config.Config{InlineEncryption: config.InlineEncryptionConfig{KeyFile: keyFile}}
+}
+
+func TestSealAndOpenFile(t *testing.T) {
+	cfg := localConfig(t)
+	file := env.NewFile()
+	file.Set("PORT", "8080")
+	file.Set("DATABASE_PASSWORD", "hunter2")
+	file.Set("API_KEY", "key with spaces")
+	file.Set("EMPTY_SECRET", "")
+
+	codec := FromConfig(context.Background(), cfg)
+	sealed, err := codec.SealFile(file, func(key string) bool { return key != "PORT" })
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DATABASE_PASSWORD", "API_KEY"}, sealed)
+	assert.Equal(t, sealed, Encrypted(file))
+
+	password, _ := file.Get("DATABASE_PASSWORD")
+	apiKey, _ := file.Get("API_KEY")
+	assert.True(t, IsEncrypted(password))
+	assert.True(t, strings.HasPrefix(password, "ENC[v1,file:"))
+	assert.Equal(t, strings.Split(password, ",")[2], strings.Split(apiKey, ",")[2], "one data key per codec")
+
+	// The values survive a write and parse
+	var buf bytes.Buffer
+	require.NoError(t, file.Write(&buf))
+	assert.NotContains(t, buf.String(), "hunter2")
+	assert.Contains(t, buf.String(), "PORT=8080\n")
+	parsed, err := env.Parse(&buf)
+	require.NoError(t, err)
+
+	// Sealing again leaves encrypted values alone
+	again, err := FromConfig(context.Background(), cfg).SealFile(parsed, func(string) bool { return true })
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PORT"}, again)
+	parsed.Set("PORT", "8080")
+
+	require.NoError(t, Decrypt(context.Background(), cfg, parsed))
+	assert.Equal(t, map[string]string{
+		"PORT":              "8080",
+		"DATABASE_PASSWORD": "hunter2",
+		"API_KEY":           "key with spaces",
+		"EMPTY_SECRET":      "",
+	}, parsed.ToMap())
+}
+
+func TestDecryptErrors(t *testing.T) {
+	cfg := localConfig(t)
+	value, err := FromConfig(context.Background(), cfg).Encrypt("secret")
+	require.NoError(t, err)
+
+	file := env.NewFile()
+	file.Set("SECRET", value)
+
+	// No key configured
+	err = Decrypt(context.Background(), &config.Config{}, file)
+	assert.ErrorContains(t, err, "failed to decrypt SECRET")
+	assert.ErrorContains(t, err, KeyEnv)
+
+	// Another local key
+	other := make([]byte, envelope.KeySize)
+	t.Setenv(KeyEnv, hex.EncodeToString(other))
+	assert.ErrorContains(t, Decrypt(context.Background(), cfg, file), "but the configured key is")
+
+	// A changed value
+	t.Setenv(KeyEnv, "")
+	tampered := value[:len(value)-3] + "AA]"
+	_, err = FromConfig(context.Background(), cfg).Decrypt(tampered)
+	assert.Error(t, err)
+
+	_, err = FromConfig(context.Background(), cfg).Decrypt("ENC[v2,file:00,AA,AA]")
+	assert.ErrorContains(t, err, "unsupported encrypted value version")
+	_, err = FromConfig(context.Background(), cfg).Decrypt("ENC[v1,file:00]")
+	assert.ErrorContains(t, err, "malformed")
+}
+
+func TestDecryptPlainFile(t *testing.T) {
+	file := env.NewFile()
+	file.Set("PORT", "8080")
+	require.NoError(t, Decrypt(context.Background(), nil, file), "files without encrypted values need no key")
+}
+
+func TestEncryptNotConfigured(t *testing.T) {
+	_, err := FromConfig(context.Background(), &config.Config{}).Encrypt("x")
+	assert.ErrorContains(t, err, "inline encryption is not configured")
+}
+
+func TestAgeRecipients(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identityFile := filepath.Join(t.TempDir(), "age.txt")
+	require.NoError(t, os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600))
+	t.Setenv(AgeIdentityEnv, "")
+
+	cfg := &config.Config{InlineEncryption: config.InlineEncryptionConfig{
+		AgeRecipients: []string{identity.Recipient().String()},
+	}}
+	value, err := FromConfig(context.Background(), cfg).Encrypt("hunter2")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value, "ENC[v1,age:"))
+
+	file := env.NewFile()
+	file.Set("DATABASE_PASSWORD", value)
+	err = Decrypt(context.Background(), cfg, file)
+	assert.ErrorContains(t, err, "age_identity_file")
+
+	cfg.InlineEncryption.AgeIdentityFile = identityFile
+	require.NoError(t, Decrypt(context.Background(), cfg, file))
+	password, _ := file.Get("DATABASE_PASSWORD")
+	assert.Equal(t, "hunter2", password)
+
+	// The identity can come from the environment instead
+	cfg.InlineEncryption.AgeIdentityFile = ""
+	t.Setenv(AgeIdentityEnv, identity.String())
+	file.Set("DATABASE_PASSWORD", value)
+	require.NoError(t, Decrypt(context.Background(), cfg, file))
+	password, _ = file.Get("DATABASE_PASSWORD")
+	assert.Equal(t, "hunter2", password)
+}
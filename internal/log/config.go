@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/drapon/envy/internal/sensitive"
 	"github.com/spf13/viper"
 )
 
@@ -53,6 +54,9 @@ type Config struct {
 
 	// SensitiveKeys センシティブな情報として扱うキーのリスト
 	SensitiveKeys []string `mapstructure:"sensitive_keys"`
+
+	// Subsystems サブシステムごとのログレベル (例: aws: debug)
+	Subsystems map[string]LogLevel `mapstructure:"subsystems"`
 }
 
 // DefaultConfig はデフォルトのログ設定を返します.
@@ -112,9 +116,25 @@ func LoadFromViper(v *viper.Viper) (*Config, error) {
 		}
 	}
 
-	// 環境変数の優先
-	if level := os.Getenv("ENVY_LOG_LEVEL"); level != "" {
-		config.Level = LogLevel(strings.ToLower(level))
+	// 環境変数と --log-level の優先。どちらも "warn,aws=debug" のように
+	// サブシステムごとのレベルを指定できる
+	for _, spec := range []string{os.Getenv("ENVY_LOG_LEVEL"), v.GetString("log_level")} {
+		if spec == "" {
+			continue
+		}
+		level, subsystems, err := ParseLevels(spec)
+		if err != nil {
+			return nil, fmt.Errorf("ログレベルの解析エラー: %w", err)
+		}
+		if level != "" {
+			config.Level = level
+		}
+		for name, subsystemLevel := range subsystems {
+			if config.Subsystems == nil {
+				config.Subsystems = make(map[string]LogLevel)
+			}
+			config.Subsystems[name] = subsystemLevel
+		}
 	}
 
 	if format := os.Getenv("ENVY_LOG_FORMAT"); format != "" {
@@ -162,6 +182,14 @@ func (c *Config) Validate() error {
 	if !validLevels[c.Level] {
 		return fmt.Errorf("無効なログレベル: %s", c.Level)
 	}
+	for name, level := range c.Subsystems {
+		if err := checkSubsystem(strings.ToLower(name)); err != nil {
+			return err
+		}
+		if !validLevels[level] {
+			return fmt.Errorf("無効なログレベル: %s=%s", name, level)
+		}
+	}
 
 	// フォーマットの検証
 	validFormats := map[string]bool{
@@ -206,7 +234,11 @@ func (c *Config) GetLogFilePath() string {
 }
 
 // IsSensitiveKey は指定されたキーがセンシティブかどうかを判定します
+// (sensitive パッケージの組み込みルールにも従います)
 func (c *Config) IsSensitiveKey(key string) bool {
+	if sensitive.IsSensitive(key) {
+		return true
+	}
 	lowerKey := strings.ToLower(key)
 	for _, sensitive := range c.SensitiveKeys {
 		if strings.Contains(lowerKey, strings.ToLower(sensitive)) {
@@ -247,6 +279,11 @@ log:
   max_age: 28        # 日数
   compress: false    # 古いファイルを圧縮
   
+  # サブシステムごとのログレベル（--log-level aws=debug,cache=warn でも指定可能）
+  subsystems:
+    aws: debug
+    env: warn
+  
   # センシティブな情報として扱うキー
   sensitive_keys:
     - password
@@ -30,6 +30,7 @@ func InitializeLogger(v *viper.Viper) error {
 		Field("level", string(config.Level)),
 		Field("format", config.Format),
 		Field("output", config.Output),
+		Field("subsystems", FormatSubsystemLevels()),
 	)
 
 	return nil
@@ -40,7 +40,13 @@ func Init(config *Config) error {
 	if err != nil {
 		return fmt.Errorf("ログレベルの解析エラー: %w", err)
 	}
-	cfg.Level = zap.NewAtomicLevelAt(level)
+	// サブシステムに全体より低いレベルがあれば、そのレベルまで出力する
+	// ロガーを作り、全体のレベルはフィルタで適用する
+	lowest, err := setSubsystemLevels(level, config.Subsystems)
+	if err != nil {
+		return fmt.Errorf("ログレベルの解析エラー: %w", err)
+	}
+	cfg.Level = zap.NewAtomicLevelAt(lowest)
 
 	// エンコーディングの設定
 	cfg.Encoding = config.Format
@@ -55,7 +61,7 @@ func Init(config *Config) error {
 	// 開発モードの設定
 	if config.Development {
 		cfg = zap.NewDevelopmentConfig()
-		cfg.Level = zap.NewAtomicLevelAt(level)
+		cfg.Level = zap.NewAtomicLevelAt(lowest)
 		cfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 
 		// コンソール出力の場合、より読みやすいフォーマットに
@@ -94,6 +100,10 @@ func Init(config *Config) error {
 	}
 
 	// グローバルロガーの設定
+	baseLogger = logger
+	if lowest != level {
+		logger = withLevel(logger, level)
+	}
 	globalLogger = logger
 	globalSugar = logger.Sugar()
 
@@ -201,6 +211,8 @@ func InitTestLogger() *zap.Logger {
 	logger, _ := cfg.Build()
 	globalLogger = logger
 	globalSugar = logger.Sugar()
+	baseLogger, subsystemLevels = nil, nil
+	resetNamed()
 
 	return logger
 }
@@ -274,9 +286,11 @@ func SetLevel(level LogLevel) error {
 		return fmt.Errorf("ロガーの再構築エラー: %w", err)
 	}
 
-	// グローバルロガーを更新
+	// グローバルロガーを更新。サブシステムごとのレベルは解除される
 	globalLogger = logger
 	globalSugar = logger.Sugar()
+	baseLogger, subsystemLevels = nil, nil
+	resetNamed()
 	zap.ReplaceGlobals(logger)
 
 	return nil
@@ -0,0 +1,181 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Subsystems は名前付きロガーを持つパッケージ。"aws.ssm" のように "." で
+// 区切った下位の名前も使え、最も長く一致した名前のレベルが適用されます。
+var Subsystems = []string{"aws", "cache", "env", "parallel", "updater"}
+
+var (
+	// baseLogger はすべてのサブシステムで最も低いレベルまで出力するロガー。
+	// globalLogger と名前付きロガーはこれにレベルのフィルタをかけたもの
+	baseLogger *zap.Logger
+	// subsystemLevels はサブシステムごとのログレベル
+	subsystemLevels map[string]zapcore.Level
+
+	namedMu      sync.Mutex
+	namedLoggers = map[string]*zap.Logger{}
+)
+
+// Named はサブシステムのロガーを返します。ログ設定の subsystems や
+// --log-level aws=debug でそのサブシステムだけのレベルを指定できます。
+func Named(name string) *zap.Logger {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	if logger, ok := namedLoggers[name]; ok {
+		return logger
+	}
+
+	logger := GetLogger()
+	if level, ok := subsystemLevel(name); ok && baseLogger != nil {
+		logger = withLevel(baseLogger, level)
+	}
+	logger = logger.Named(name)
+	namedLoggers[name] = logger
+	return logger
+}
+
+// ParseLevels は "warn,aws=debug,cache=error" のようなレベル指定を解析し、
+// 全体のレベル（指定がなければ空）とサブシステムごとのレベルを返します。
+func ParseLevels(spec string) (LogLevel, map[string]LogLevel, error) {
+	var level LogLevel
+	subsystems := make(map[string]LogLevel)
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(part, "=")
+		if !found {
+			if level != "" {
+				return "", nil, fmt.Errorf("ログレベルが複数指定されています: %s", spec)
+			}
+			level = LogLevel(strings.ToLower(part))
+			if _, err := parseLogLevel(level); err != nil {
+				return "", nil, err
+			}
+			continue
+		}
+
+		name = strings.ToLower(strings.TrimSpace(name))
+		if err := checkSubsystem(name); err != nil {
+			return "", nil, err
+		}
+		subsystems[name] = LogLevel(strings.ToLower(strings.TrimSpace(value)))
+		if _, err := parseLogLevel(subsystems[name]); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return level, subsystems, nil
+}
+
+// checkSubsystem はサブシステム名が Subsystems のいずれか、またはその下位の
+// 名前であることを確認します。
+func checkSubsystem(name string) error {
+	root, _, _ := strings.Cut(name, ".")
+	for _, subsystem := range Subsystems {
+		if root == subsystem {
+			return nil
+		}
+	}
+	return fmt.Errorf("不明なサブシステム: %s (%s)", name, strings.Join(Subsystems, ", "))
+}
+
+// setSubsystemLevels はサブシステムごとのレベルを設定し、全サブシステムの
+// 最も低いレベルを返します。
+func setSubsystemLevels(defaultLevel zapcore.Level, levels map[string]LogLevel) (zapcore.Level, error) {
+	lowest := defaultLevel
+	parsed := make(map[string]zapcore.Level, len(levels))
+	for name, level := range levels {
+		zapLevel, err := parseLogLevel(level)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", name, err)
+		}
+		parsed[strings.ToLower(name)] = zapLevel
+		if zapLevel < lowest {
+			lowest = zapLevel
+		}
+	}
+
+	subsystemLevels = parsed
+	resetNamed()
+	return lowest, nil
+}
+
+// subsystemLevel は name に最も長く一致するサブシステムのレベルを返します。
+func subsystemLevel(name string) (zapcore.Level, bool) {
+	for name != "" {
+		if level, ok := subsystemLevels[name]; ok {
+			return level, true
+		}
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return 0, false
+}
+
+// resetNamed はロガーの再初期化後に名前付きロガーを作り直させます。
+func resetNamed() {
+	namedMu.Lock()
+	namedLoggers = map[string]*zap.Logger{}
+	namedMu.Unlock()
+}
+
+// FormatSubsystemLevels はサブシステムごとのレベルを "aws=debug,cache=warn"
+// の形式で返します。
+func FormatSubsystemLevels() string {
+	names := make([]string, 0, len(subsystemLevels))
+	for name := range subsystemLevels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + subsystemLevels[name].String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// withLevel は level 以上のエントリだけを出力するロガーを返します。
+func withLevel(logger *zap.Logger, level zapcore.Level) *zap.Logger {
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &levelCore{Core: core, level: level}
+	}))
+}
+
+// levelCore は下位の Core のうち level 以上のエントリだけを出力します。
+type levelCore struct {
+	zapcore.Core
+	level zapcore.Level
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return level >= c.level && c.Core.Enabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
@@ -0,0 +1,78 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestParseLevels(t *testing.T) {
+	level, subsystems, err := ParseLevels("aws=debug, cache=WARN,env=info")
+	require.NoError(t, err)
+	assert.Equal(t, LogLevel(""), level)
+	assert.Equal(t, map[string]LogLevel{"aws": DebugLevel, "cache": WarnLevel, "env": InfoLevel}, subsystems)
+
+	level, subsystems, err = ParseLevels("error,aws.ssm=debug")
+	require.NoError(t, err)
+	assert.Equal(t, ErrorLevel, level)
+	assert.Equal(t, map[string]LogLevel{"aws.ssm": DebugLevel}, subsystems)
+
+	for _, spec := range []string{"s3=debug", "aws=loud", "info,warn", "verbose"} {
+		_, _, err := ParseLevels(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestNamed(t *testing.T) {
+	defer InitTestLogger()
+
+	lowest, err := setSubsystemLevels(zapcore.WarnLevel, map[string]LogLevel{"aws": DebugLevel, "cache": ErrorLevel})
+	require.NoError(t, err)
+	assert.Equal(t, zapcore.DebugLevel, lowest)
+
+	core, logs := observer.New(lowest)
+	baseLogger = zap.New(core)
+	globalLogger = withLevel(baseLogger, zapcore.WarnLevel)
+
+	Named("aws").Debug("aws debug")
+	Named("aws.ssm").Debug("ssm debug")
+	Named("cache").Warn("cache warn")
+	Named("cache").Error("cache error")
+	Named("env").Info("env info")
+	Named("env").Warn("env warn")
+	Info("root info")
+
+	var messages []string
+	for _, entry := range logs.All() {
+		messages = append(messages, entry.LoggerName+": "+entry.Message)
+	}
+	assert.Equal(t, []string{"aws: aws debug", "aws.ssm: ssm debug", "cache: cache error", "env: env warn"}, messages)
+	assert.Equal(t, "aws=debug,cache=error", FormatSubsystemLevels())
+}
+
+func TestLoadFromViperSubsystems(t *testing.T) {
+	t.Setenv("ENVY_LOG_LEVEL", "")
+
+	v := viper.New()
+	v.Set("log.subsystems", map[string]interface{}{"cache": "warn"})
+	v.Set("log_level", "error,aws=debug")
+
+	config, err := LoadFromViper(v)
+	require.NoError(t, err)
+	assert.Equal(t, ErrorLevel, config.Level)
+	assert.Equal(t, map[string]LogLevel{"aws": DebugLevel, "cache": WarnLevel}, config.Subsystems)
+
+	v.Set("log_level", "s3=debug")
+	_, err = LoadFromViper(v)
+	assert.Error(t, err)
+
+	v = viper.New()
+	v.Set("log.subsystems", map[string]interface{}{"aws": "loud"})
+	_, err = LoadFromViper(v)
+	assert.Error(t, err)
+}
@@ -0,0 +1,129 @@
+// Package ownership resolves who owns a variable and which AWS principals
+// may write it, from the variables section of .envyrc.
+package ownership
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/filter"
+)
+
+// Rule assigns owners and allowed writers to keys matching Pattern
+type Rule struct {
+	Pattern string
+	Owners  []string
+	Writers []string
+
+	match *filter.Filter
+}
+
+// ParseRules builds rules from the variables section of .envyrc, skipping
+// entries without owners or allowed writers
+func ParseRules(variables []config.VariableConfig) ([]Rule, error) {
+	var rules []Rule
+	for _, v := range variables {
+		if len(v.Owners) == 0 && len(v.AllowedWriters) == 0 {
+			continue
+		}
+		if v.Key == "" {
+			return nil, fmt.Errorf("variables: owners or allowed_writers without a key")
+		}
+		for _, writer := range v.AllowedWriters {
+			if _, err := path.Match(writer, ""); err != nil {
+				return nil, fmt.Errorf("variables: allowed writer %q for %s: %w", writer, v.Key, err)
+			}
+		}
+
+		match, err := filter.New(filter.Options{Include: []string{v.Key}})
+		if err != nil {
+			return nil, fmt.Errorf("variables: %w", err)
+		}
+		rules = append(rules, Rule{Pattern: v.Key, Owners: v.Owners, Writers: v.AllowedWriters, match: match})
+	}
+	return rules, nil
+}
+
+// Owners returns the owners of a key from the first matching rule that
+// names owners
+func Owners(rules []Rule, key string) []string {
+	for _, rule := range rules {
+		if len(rule.Owners) > 0 && rule.match.Match(key) {
+			return rule.Owners
+		}
+	}
+	return nil
+}
+
+// Writers returns the principals allowed to write a key from the first
+// matching rule that restricts writers. The boolean is false when anyone
+// may write it.
+func Writers(rules []Rule, key string) ([]string, bool) {
+	for _, rule := range rules {
+		if len(rule.Writers) > 0 && rule.match.Match(key) {
+			return rule.Writers, true
+		}
+	}
+	return nil, false
+}
+
+// Restricted reports whether any rule restricts writers
+func Restricted(rules []Rule) bool {
+	for _, rule := range rules {
+		if len(rule.Writers) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed reports whether the principal with the given ARN is one of
+// writers. A writer matches the full ARN, an ARN pattern such as
+// "arn:aws:iam::*:role/deploy-*", or the role or user name, so
+// "deployer" matches both arn:aws:iam::123456789012:role/deployer and the
+// sessions assumed from it.
+func Allowed(writers []string, principalARN string) bool {
+	name := PrincipalName(principalARN)
+	for _, writer := range writers {
+		if writer == principalARN || writer == name {
+			return true
+		}
+		if ok, _ := path.Match(writer, principalARN); ok {
+			return true
+		}
+		if strings.HasPrefix(writer, "arn:") && strings.Contains(writer, ":role/") && assumedRoleARN(principalARN) == writer {
+			return true
+		}
+	}
+	return false
+}
+
+// PrincipalName returns the role or user name in an IAM or STS ARN
+func PrincipalName(arn string) string {
+	resource := arn[strings.LastIndex(arn, ":")+1:]
+	parts := strings.Split(resource, "/")
+	switch {
+	case len(parts) >= 2 && (parts[0] == "assumed-role" || parts[0] == "role" || parts[0] == "user"):
+		// Roles and users may have paths; the name is the last element,
+		// except for assumed roles where a session name follows it
+		if parts[0] == "assumed-role" {
+			return parts[1]
+		}
+		return parts[len(parts)-1]
+	default:
+		return resource
+	}
+}
+
+// assumedRoleARN returns the IAM role ARN of an assumed-role session ARN,
+// e.g. arn:aws:iam::123456789012:role/deployer for
+// arn:aws:sts::123456789012:assumed-role/deployer/session
+func assumedRoleARN(arn string) string {
+	fields := strings.SplitN(arn, ":", 6)
+	if len(fields) != 6 || fields[2] != "sts" || !strings.HasPrefix(fields[5], "assumed-role/") {
+		return ""
+	}
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", fields[1], fields[4], PrincipalName(arn))
+}
@@ -0,0 +1,50 @@
+package ownership
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]config.VariableConfig{
+		{Key: "DB_IAM_TOKEN", Refresh: "10m"},
+		{Key: "STRIPE_*", Owners: []string{"payments"}, AllowedWriters: []string{"payments-deploy"}},
+		{Key: "DB_*", Owners: []string{"platform-team"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, rules, 2, "refresh-only entries are skipped")
+	assert.True(t, Restricted(rules))
+
+	assert.Equal(t, []string{"payments"}, Owners(rules, "STRIPE_KEY"))
+	assert.Equal(t, []string{"platform-team"}, Owners(rules, "DB_IAM_TOKEN"))
+	assert.Nil(t, Owners(rules, "PORT"))
+
+	writers, restricted := Writers(rules, "STRIPE_KEY")
+	assert.True(t, restricted)
+	assert.Equal(t, []string{"payments-deploy"}, writers)
+	_, restricted = Writers(rules, "DB_HOST")
+	assert.False(t, restricted)
+
+	_, err = ParseRules([]config.VariableConfig{{Owners: []string{"x"}}})
+	assert.Error(t, err)
+	_, err = ParseRules([]config.VariableConfig{{Key: "A", AllowedWriters: []string{"arn:["}}})
+	assert.Error(t, err)
+}
+
+func TestAllowed(t *testing.T) {
+	session := "arn:aws:sts::123456789012:assumed-role/deployer/ci-1234"
+	user := "arn:aws:iam::123456789012:user/ops/alice"
+
+	assert.True(t, Allowed([]string{"deployer"}, session))
+	assert.True(t, Allowed([]string{"arn:aws:iam::123456789012:role/deployer"}, session))
+	assert.True(t, Allowed([]string{"arn:aws:sts::*:assumed-role/deploy*/*"}, session))
+	assert.True(t, Allowed([]string{session}, session))
+	assert.False(t, Allowed([]string{"admin", "arn:aws:iam::123456789012:role/other"}, session))
+
+	assert.True(t, Allowed([]string{"alice"}, user))
+	assert.False(t, Allowed([]string{"ops"}, user))
+	assert.False(t, Allowed(nil, user))
+}
@@ -80,7 +80,7 @@ func NewBatchProcessor(ctx context.Context, maxWorkers int, opts ...BatchOption)
 	// Default error handler
 	if b.errorHandler == nil {
 		b.errorHandler = func(item interface{}, err error) {
-			log.Error("Batch processing error",
+			log.Named("parallel").Error("Batch processing error",
 				zap.Any("item", item),
 				zap.Error(err),
 			)
@@ -99,7 +99,7 @@ func (b *BatchProcessor) Process(ctx context.Context, items []interface{}, fn Pr
 		return []Result{}, nil
 	}
 
-	log.Info("Starting batch processing",
+	log.Named("parallel").Info("Starting batch processing",
 		zap.Int("total_items", len(items)),
 		zap.Int("batch_size", b.batchSize),
 		zap.Int("max_workers", b.maxWorkers),
@@ -113,7 +113,7 @@ func (b *BatchProcessor) Process(ctx context.Context, items []interface{}, fn Pr
 	batches := b.createBatches(items)
 	totalBatches := len(batches)
 
-	log.Debug("Batch creation completed",
+	log.Named("parallel").Debug("Batch creation completed",
 		zap.Int("total_batches", totalBatches),
 	)
 
@@ -135,7 +135,7 @@ func (b *BatchProcessor) Process(ctx context.Context, items []interface{}, fn Pr
 				allResults = append(allResults, results...)
 				mu.Unlock()
 
-				log.Debug("Batch processing completed",
+				log.Named("parallel").Debug("Batch processing completed",
 					zap.Int("batch", batchNum),
 					zap.Int("total_batches", totalBatches),
 					zap.Int("items", len(batchItems)),
@@ -158,14 +158,14 @@ func (b *BatchProcessor) Process(ctx context.Context, items []interface{}, fn Pr
 	// Check for batch-level errors
 	for _, result := range poolResults {
 		if result.Error != nil {
-			log.Error("Batch level error",
+			log.Named("parallel").Error("Batch level error",
 				zap.String("batch", result.Task.Name()),
 				zap.Error(result.Error),
 			)
 		}
 	}
 
-	log.Info("Batch processing completed",
+	log.Named("parallel").Info("Batch processing completed",
 		zap.Int("total_results", len(allResults)),
 	)
 
@@ -482,7 +482,7 @@ func (a *AWSBatchProcessor) ProcessAWSOperations(
 	operations []interface{},
 	fn ProcessFunc,
 ) ([]Result, error) {
-	log.Info("Starting AWS batch processing",
+	log.Named("parallel").Info("Starting AWS batch processing",
 		zap.String("service", a.service),
 		zap.Int("operations", len(operations)),
 	)
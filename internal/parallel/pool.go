@@ -160,14 +160,14 @@ func NewWorkerPool(ctx context.Context, opts ...PoolOption) *WorkerPool {
 	// Default error handler
 	if p.errorHandler == nil {
 		p.errorHandler = func(task Task, err error) {
-			log.Error("タスク実行エラー",
+			log.Named("parallel").Error("タスク実行エラー",
 				zap.String("task", task.Name()),
 				zap.Error(err),
 			)
 		}
 	}
 
-	log.Info("ワーカープール初期化",
+	log.Named("parallel").Info("ワーカープール初期化",
 		zap.Int("max_workers", p.maxWorkers),
 		zap.Int("buffer_size", p.bufferSize),
 		zap.Duration("timeout", p.timeout),
@@ -179,7 +179,7 @@ func NewWorkerPool(ctx context.Context, opts ...PoolOption) *WorkerPool {
 
 // Start starts the worker pool
 func (p *WorkerPool) Start() {
-	log.Debug("ワーカープール開始")
+	log.Named("parallel").Debug("ワーカープール開始")
 
 	// Start workers
 	for i := 0; i < p.maxWorkers; i++ {
@@ -197,7 +197,7 @@ func (p *WorkerPool) Submit(task Task) error {
 	case <-p.ctx.Done():
 		return errors.New(errors.ErrInternal, "ワーカープールが停止しています")
 	case p.tasks <- task:
-		log.Debug("タスク送信",
+		log.Named("parallel").Debug("タスク送信",
 			zap.String("task", task.Name()),
 		)
 		return nil
@@ -231,7 +231,7 @@ func (p *WorkerPool) Wait() []Result {
 		results = append(results, result)
 	}
 
-	log.Info("ワーカープール完了",
+	log.Named("parallel").Info("ワーカープール完了",
 		zap.Int64("processed", p.processed.Load()),
 		zap.Int64("failed", p.failed.Load()),
 		zap.Int("results", len(results)),
@@ -242,7 +242,7 @@ func (p *WorkerPool) Wait() []Result {
 
 // Stop stops the worker pool
 func (p *WorkerPool) Stop() {
-	log.Debug("ワーカープール停止中")
+	log.Named("parallel").Debug("ワーカープール停止中")
 	p.cancel()
 	close(p.done)
 }
@@ -258,16 +258,16 @@ func (p *WorkerPool) worker(id int) {
 	p.activeWorkers.Add(1)
 	defer p.activeWorkers.Add(-1)
 
-	log.Debug("ワーカー開始", zap.Int("worker_id", id))
+	log.Named("parallel").Debug("ワーカー開始", zap.Int("worker_id", id))
 
 	for {
 		select {
 		case <-p.ctx.Done():
-			log.Debug("ワーカー停止", zap.Int("worker_id", id))
+			log.Named("parallel").Debug("ワーカー停止", zap.Int("worker_id", id))
 			return
 		case task, ok := <-p.tasks:
 			if !ok {
-				log.Debug("タスクチャネルクローズ", zap.Int("worker_id", id))
+				log.Named("parallel").Debug("タスクチャネルクローズ", zap.Int("worker_id", id))
 				return
 			}
 
@@ -312,7 +312,7 @@ func (p *WorkerPool) executeTask(task Task) {
 		return
 	}
 
-	log.Debug("タスク完了",
+	log.Named("parallel").Debug("タスク完了",
 		zap.String("task", task.Name()),
 		zap.Duration("duration", duration),
 		zap.Bool("success", err == nil),
@@ -384,7 +384,7 @@ func (d *DynamicWorkerPool) adjustWorkerCount() {
 	// Calculate load ratio
 	loadRatio := float64(queueSize) / float64(d.bufferSize)
 
-	log.Debug("動的スケーリング評価",
+	log.Named("parallel").Debug("動的スケーリング評価",
 		zap.Float64("load_ratio", loadRatio),
 		zap.Int("queue_size", queueSize),
 		zap.Int("active_workers", activeWorkers),
@@ -399,7 +399,7 @@ func (d *DynamicWorkerPool) adjustWorkerCount() {
 			go d.worker(i)
 		}
 		d.WorkerPool.maxWorkers = newWorkers
-		log.Info("ワーカー数増加",
+		log.Named("parallel").Info("ワーカー数増加",
 			zap.Int("from", currentWorkers),
 			zap.Int("to", newWorkers),
 		)
@@ -409,7 +409,7 @@ func (d *DynamicWorkerPool) adjustWorkerCount() {
 	if loadRatio < d.scaleDownThreshold && currentWorkers > d.minWorkers {
 		// Note: In a real implementation, we would need a way to signal
 		// specific workers to stop. This is a simplified version.
-		log.Info("ワーカー数削減条件を満たしましたが、実装は簡略化されています",
+		log.Named("parallel").Info("ワーカー数削減条件を満たしましたが、実装は簡略化されています",
 			zap.Int("current", currentWorkers),
 			zap.Int("min", d.minWorkers),
 		)
@@ -8,16 +8,22 @@ import (
 	"time"
 
 	"github.com/drapon/envy/internal/log"
-	"github.com/schollz/progressbar/v3"
 	"go.uber.org/zap"
 )
 
+// progressBar is the subset of the progress bar API used by ProgressTracker.
+type progressBar interface {
+	Add(num int) error
+	Describe(description string)
+	Finish() error
+}
+
 // ProgressTracker tracks progress of parallel operations
 type ProgressTracker struct {
 	total       int64
 	completed   atomic.Int64
 	failed      atomic.Int64
-	bar         *progressbar.ProgressBar
+	bar         progressBar
 	mu          sync.Mutex
 	startTime   time.Time
 	showDetails bool
@@ -25,37 +31,9 @@ type ProgressTracker struct {
 
 // NewProgressTracker creates a new progress tracker
 func NewProgressTracker(total int, description string, showDetails bool) *ProgressTracker {
-	options := []progressbar.Option{
-		progressbar.OptionSetDescription(description),
-		progressbar.OptionSetWidth(50),
-		progressbar.OptionShowCount(),
-		progressbar.OptionShowIts(),
-		progressbar.OptionSetItsString("items"),
-		progressbar.OptionOnCompletion(func() {
-			fmt.Println()
-		}),
-		progressbar.OptionEnableColorCodes(true),
-		progressbar.OptionSetTheme(progressbar.Theme{
-			Saucer:        "[green]█[reset]",
-			SaucerHead:    "[green]>[reset]",
-			SaucerPadding: " ",
-			BarStart:      "[",
-			BarEnd:        "]",
-		}),
-	}
-
-	if showDetails {
-		options = append(options,
-			progressbar.OptionShowElapsedTimeOnFinish(),
-			progressbar.OptionSetPredictTime(true),
-		)
-	}
-
-	bar := progressbar.NewOptions64(int64(total), options...)
-
 	return &ProgressTracker{
 		total:       int64(total),
-		bar:         bar,
+		bar:         newProgressBar(int64(total), description, showDetails),
 		startTime:   time.Now(),
 		showDetails: showDetails,
 	}
@@ -87,7 +65,7 @@ func (p *ProgressTracker) IncrementWithError(err error) {
 
 // Finish finishes the progress tracking
 func (p *ProgressTracker) Finish() {
-	p.bar.Finish()
+	_ = p.bar.Finish()
 
 	duration := time.Since(p.startTime)
 	completed := p.completed.Load()
@@ -266,7 +244,7 @@ func (m *MonitoredPool) monitor() {
 		case <-ticker.C:
 			processed, failed, activeWorkers := m.GetMetrics()
 
-			log.Debug("プール統計",
+			log.Named("parallel").Debug("プール統計",
 				zap.Int64("processed", processed),
 				zap.Int64("failed", failed),
 				zap.Int32("active_workers", activeWorkers),
@@ -0,0 +1,40 @@
+//go:build !slim
+
+package parallel
+
+import (
+	"fmt"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// newProgressBar creates a terminal progress bar
+func newProgressBar(total int64, description string, showDetails bool) progressBar {
+	options := []progressbar.Option{
+		progressbar.OptionSetDescription(description),
+		progressbar.OptionSetWidth(50),
+		progressbar.OptionShowCount(),
+		progressbar.OptionShowIts(),
+		progressbar.OptionSetItsString("items"),
+		progressbar.OptionOnCompletion(func() {
+			fmt.Println()
+		}),
+		progressbar.OptionEnableColorCodes(true),
+		progressbar.OptionSetTheme(progressbar.Theme{
+			Saucer:        "[green]█[reset]",
+			SaucerHead:    "[green]>[reset]",
+			SaucerPadding: " ",
+			BarStart:      "[",
+			BarEnd:        "]",
+		}),
+	}
+
+	if showDetails {
+		options = append(options,
+			progressbar.OptionShowElapsedTimeOnFinish(),
+			progressbar.OptionSetPredictTime(true),
+		)
+	}
+
+	return progressbar.NewOptions64(total, options...)
+}
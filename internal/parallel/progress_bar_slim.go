@@ -0,0 +1,16 @@
+//go:build slim
+
+package parallel
+
+// silentBar discards progress updates; the slim build only prints the
+// summary emitted by ProgressTracker.Finish.
+type silentBar struct{}
+
+func (silentBar) Add(int) error   { return nil }
+func (silentBar) Describe(string) {}
+func (silentBar) Finish() error   { return nil }
+
+// newProgressBar returns a no-op progress bar
+func newProgressBar(total int64, description string, showDetails bool) progressBar {
+	return silentBar{}
+}
@@ -64,7 +64,7 @@ func NewWorker(id int, taskQueue chan Task, resultQueue chan Result, config *Wor
 	// Set default error handler if not provided
 	if w.errorHandler == nil {
 		w.errorHandler = func(task Task, err error) {
-			log.Error("ワーカータスクエラー",
+			log.Named("parallel").Error("ワーカータスクエラー",
 				zap.Int("worker_id", id),
 				zap.String("task", task.Name()),
 				zap.Error(err),
@@ -79,14 +79,14 @@ func NewWorker(id int, taskQueue chan Task, resultQueue chan Result, config *Wor
 func (w *Worker) Start(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	log.Debug("ワーカー開始",
+	log.Named("parallel").Debug("ワーカー開始",
 		zap.Int("worker_id", w.id),
 	)
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Debug("ワーカー停止",
+			log.Named("parallel").Debug("ワーカー停止",
 				zap.Int("worker_id", w.id),
 				zap.Int64("total_processed", w.totalProcessed),
 				zap.Int64("total_failed", w.totalFailed),
@@ -95,7 +95,7 @@ func (w *Worker) Start(ctx context.Context, wg *sync.WaitGroup) {
 
 		case task, ok := <-w.taskQueue:
 			if !ok {
-				log.Debug("タスクキューがクローズされました",
+				log.Named("parallel").Debug("タスクキューがクローズされました",
 					zap.Int("worker_id", w.id),
 				)
 				return
@@ -118,7 +118,7 @@ func (w *Worker) executeTask(ctx context.Context, task Task) {
 		w.beforeExecute(task)
 	}
 
-	log.Debug("タスク実行開始",
+	log.Named("parallel").Debug("タスク実行開始",
 		zap.Int("worker_id", w.id),
 		zap.String("task", task.Name()),
 	)
@@ -154,14 +154,14 @@ func (w *Worker) executeTask(ctx context.Context, task Task) {
 	// Send result
 	select {
 	case w.resultQueue <- result:
-		log.Debug("タスク完了",
+		log.Named("parallel").Debug("タスク完了",
 			zap.Int("worker_id", w.id),
 			zap.String("task", task.Name()),
 			zap.Duration("duration", result.Time),
 			zap.Bool("success", result.Error == nil),
 		)
 	case <-ctx.Done():
-		log.Warn("結果送信がキャンセルされました",
+		log.Named("parallel").Warn("結果送信がキャンセルされました",
 			zap.Int("worker_id", w.id),
 			zap.String("task", task.Name()),
 		)
@@ -272,7 +272,7 @@ func NewWorkerManager(ctx context.Context, numWorkers int, opts ...ManagerOption
 		m.workers = append(m.workers, worker)
 	}
 
-	log.Info("ワーカーマネージャー初期化",
+	log.Named("parallel").Info("ワーカーマネージャー初期化",
 		zap.Int("num_workers", numWorkers),
 		zap.Int("max_queue_size", m.maxQueueSize),
 		zap.Duration("queue_timeout", m.queueTimeout),
@@ -290,7 +290,7 @@ func (m *WorkerManager) Start() error {
 		return errors.New(errors.ErrInternal, "ワーカーマネージャーは既に実行中です")
 	}
 
-	log.Info("ワーカーマネージャー開始",
+	log.Named("parallel").Info("ワーカーマネージャー開始",
 		zap.Int("num_workers", len(m.workers)),
 	)
 
@@ -344,7 +344,7 @@ func (m *WorkerManager) Stop() []Result {
 	m.isRunning = false
 	m.mu.Unlock()
 
-	log.Info("ワーカーマネージャー停止中")
+	log.Named("parallel").Info("ワーカーマネージャー停止中")
 
 	// Close task queue to signal workers to stop
 	close(m.taskQueue)
@@ -364,7 +364,7 @@ func (m *WorkerManager) Stop() []Result {
 		results = append(results, result)
 	}
 
-	log.Info("ワーカーマネージャー停止完了",
+	log.Named("parallel").Info("ワーカーマネージャー停止完了",
 		zap.Int("total_results", len(results)),
 	)
 
@@ -426,14 +426,14 @@ func (m *WorkerManager) AdjustWorkerCount(delta int) error {
 			m.wg.Add(1)
 			go worker.Start(m.ctx, &m.wg)
 		}
-		log.Info("ワーカー追加",
+		log.Named("parallel").Info("ワーカー追加",
 			zap.Int("added", delta),
 			zap.Int("total", newCount),
 		)
 	} else {
 		// Note: Removing workers is more complex and would require
 		// a mechanism to signal specific workers to stop
-		log.Warn("ワーカー削減は現在サポートされていません",
+		log.Named("parallel").Warn("ワーカー削減は現在サポートされていません",
 			zap.Int("requested_delta", delta),
 		)
 	}
@@ -0,0 +1,115 @@
+// Package pattern compiles the key patterns accepted by --filter and the
+// sensitivity settings of .envyrc.
+//
+// Patterns are interpreted as follows:
+//
+//   - "/expr/" or "re:expr": Go regular expression, matched as written
+//   - "glob:pattern": case-insensitive glob over the whole key
+//   - containing * ? or [: case-insensitive glob over the whole key ("DB_*")
+//   - containing ^ $ ( ) | + \ { }: regular expression ("^DB_")
+//   - anything else: case-insensitive substring ("DB" matches "MY_DB_HOST")
+package pattern
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Matcher reports whether a key matches a pattern
+type Matcher func(key string) bool
+
+// CompileAll compiles patterns, skipping blank ones
+func CompileAll(patterns []string) ([]Matcher, error) {
+	var matchers []Matcher
+
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		m, err := Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+
+	return matchers, nil
+}
+
+// Compile compiles a single pattern
+func Compile(pattern string) (Matcher, error) {
+	if expr, ok := regexBody(pattern); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+		}
+		return re.MatchString, nil
+	}
+
+	if glob, ok := strings.CutPrefix(pattern, "glob:"); ok {
+		return compileGlob(glob)
+	}
+
+	if strings.ContainsAny(pattern, "*?[") {
+		return compileGlob(pattern)
+	}
+
+	if strings.ContainsAny(pattern, `^$()|+\{}`) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+		}
+		return re.MatchString, nil
+	}
+
+	needle := strings.ToUpper(pattern)
+	return func(key string) bool {
+		return strings.Contains(strings.ToUpper(key), needle)
+	}, nil
+}
+
+func compileGlob(pattern string) (Matcher, error) {
+	glob := strings.ToUpper(pattern)
+	// Validate the glob once so a malformed pattern fails early
+	if _, err := path.Match(glob, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+	}
+
+	return func(key string) bool {
+		matched, _ := path.Match(glob, strings.ToUpper(key))
+		return matched
+	}, nil
+}
+
+// HasExplicitSyntax reports whether a pattern says how it is matched
+func HasExplicitSyntax(pattern string) bool {
+	if _, ok := regexBody(pattern); ok {
+		return true
+	}
+	return strings.HasPrefix(pattern, "glob:")
+}
+
+// regexBody extracts the expression from "/expr/" or "re:expr"
+func regexBody(pattern string) (string, bool) {
+	if strings.HasPrefix(pattern, "re:") {
+		return strings.TrimPrefix(pattern, "re:"), true
+	}
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		return pattern[1 : len(pattern)-1], true
+	}
+	return "", false
+}
+
+// MatchAny reports whether any matcher matches key
+func MatchAny(matchers []Matcher, key string) bool {
+	for _, m := range matchers {
+		if m(key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+// Package policy checks pushes against the policies of .envyrc before
+// anything is written, e.g.
+//
+//	policies:
+//	  - name: prod-change-control
+//	    environments: [prod]
+//	    require_force: true
+//	    require_env: [CHANGE_TICKET]
+//	  - name: no-plaintext-passwords
+//	    plaintext_keys: ["*PASSWORD*", "*_PASS"]
+//	    plaintext_values: ['^\$2[aby]\$']
+//
+// A push breaking a policy is refused, or only warned about with
+// action: warn.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/pattern"
+)
+
+// Actions taken on a broken policy
+const (
+	ActionDeny = "deny"
+	ActionWarn = "warn"
+)
+
+// Push is a push to be checked
+type Push struct {
+	Environment string
+	Vars        *env.File
+	Force       bool
+
+	// Plaintext reports whether a variable would be stored unencrypted,
+	// such as a String parameter; nil when every value is encrypted
+	Plaintext func(key string) bool
+
+	// Getenv looks up the variables of the pushing process; os.Getenv when
+	// nil
+	Getenv func(key string) string
+}
+
+// Violation is a policy broken by a push
+type Violation struct {
+	Policy  string
+	Action  string
+	Message string
+}
+
+// Denies reports whether the violation refuses the push
+func (v Violation) Denies() bool {
+	return v.Action != ActionWarn
+}
+
+// Rule is a policy ready to be evaluated
+type Rule struct {
+	name   string
+	config config.PolicyConfig
+	keys   []pattern.Matcher
+	values []*regexp.Regexp
+}
+
+// Compile prepares the policies of the configuration. Policies without a
+// name are named by their position.
+func Compile(policies []config.PolicyConfig) ([]*Rule, error) {
+	rules := make([]*Rule, 0, len(policies))
+	for i, policy := range policies {
+		rule := &Rule{name: policy.Name, config: policy}
+		if rule.name == "" {
+			rule.name = fmt.Sprintf("policies[%d]", i)
+		}
+
+		switch policy.Action {
+		case "", ActionDeny, ActionWarn:
+		default:
+			return nil, fmt.Errorf("policy %s: action must be either '%s' or '%s'", rule.name, ActionDeny, ActionWarn)
+		}
+		if !policy.RequireForce && len(policy.RequireEnv) == 0 && len(policy.PlaintextKeys) == 0 && len(policy.PlaintextValues) == 0 {
+			return nil, fmt.Errorf("policy %s has no rules", rule.name)
+		}
+
+		keys, err := pattern.CompileAll(policy.PlaintextKeys)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: invalid plaintext_keys: %w", rule.name, err)
+		}
+		rule.keys = keys
+		for _, expr := range policy.PlaintextValues {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("policy %s: invalid plaintext_values pattern %q: %w", rule.name, expr, err)
+			}
+			rule.values = append(rule.values, re)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Applies reports whether the policy covers pushes to an environment
+func (r *Rule) Applies(envName string) bool {
+	if len(r.config.Environments) == 0 {
+		return true
+	}
+	for _, name := range r.config.Environments {
+		if name == envName {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate returns the ways the push breaks the policy
+func (r *Rule) Evaluate(push Push) []Violation {
+	if !r.Applies(push.Environment) {
+		return nil
+	}
+
+	action := r.config.Action
+	if action == "" {
+		action = ActionDeny
+	}
+	var violations []Violation
+	add := func(format string, args ...interface{}) {
+		violations = append(violations, Violation{Policy: r.name, Action: action, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if r.config.RequireForce && !push.Force {
+		add("pushes to %s need --force", push.Environment)
+	}
+
+	getenv := push.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	for _, name := range r.config.RequireEnv {
+		if strings.TrimSpace(getenv(name)) == "" {
+			add("pushes to %s need %s to be set", push.Environment, name)
+		}
+	}
+
+	if push.Plaintext != nil && push.Vars != nil {
+		// Values are never printed, only the keys holding them
+		var found []string
+		for _, key := range push.Vars.SortedKeys() {
+			value, _ := push.Vars.Get(key)
+			if value != "" && push.Plaintext(key) && r.matchesPlaintext(key, value) {
+				found = append(found, key)
+			}
+		}
+		if len(found) > 0 {
+			add("%d variable(s) would be stored unencrypted: %s (mark them sensitive in .envyrc to store them as SecureString)", len(found), strings.Join(found, ", "))
+		}
+	}
+	return violations
+}
+
+// matchesPlaintext reports whether a variable must not be stored
+// unencrypted
+func (r *Rule) matchesPlaintext(key, value string) bool {
+	if pattern.MatchAny(r.keys, key) {
+		return true
+	}
+	for _, re := range r.values {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate returns the violations of the push against every rule
+func Evaluate(rules []*Rule, push Push) []Violation {
+	var violations []Violation
+	for _, rule := range rules {
+		violations = append(violations, rule.Evaluate(push)...)
+	}
+	return violations
+}
+
+// Check evaluates the policies of cfg for the push and prints the
+// violations. It fails if any of them refuses the push.
+func Check(cfg *config.Config, push Push) error {
+	if len(cfg.Policies) == 0 {
+		return nil
+	}
+	rules, err := Compile(cfg.Policies)
+	if err != nil {
+		return fmt.Errorf("invalid policies in .envyrc: %w", err)
+	}
+
+	var denied []string
+	for _, v := range Evaluate(rules, push) {
+		if !v.Denies() {
+			color.PrintWarningf("Policy %s: %s", v.Policy, v.Message)
+			continue
+		}
+		color.PrintErrorf("Policy %s: %s", v.Policy, v.Message)
+		denied = appendUnique(denied, v.Policy)
+	}
+	if len(denied) > 0 {
+		return fmt.Errorf("push to %s refused by policy %s (see policies in .envyrc)", push.Environment, strings.Join(denied, ", "))
+	}
+	return nil
+}
+
+// appendUnique appends name unless list holds it already
+func appendUnique(list []string, name string) []string {
+	for _, existing := range list {
+		if existing == name {
+			return list
+		}
+	}
+	return append(list, name)
+}
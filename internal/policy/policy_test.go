@@ -0,0 +1,100 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	rules, err := Compile([]config.PolicyConfig{{RequireForce: true}})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, "policies[0]", rules[0].name)
+
+	_, err = Compile([]config.PolicyConfig{{Name: "empty"}})
+	assert.ErrorContains(t, err, "policy empty has no rules")
+
+	_, err = Compile([]config.PolicyConfig{{Name: "strict", Action: "block", RequireForce: true}})
+	assert.ErrorContains(t, err, "action must be either 'deny' or 'warn'")
+
+	_, err = Compile([]config.PolicyConfig{{Name: "bad", PlaintextValues: []string{"("}}})
+	assert.ErrorContains(t, err, "invalid plaintext_values pattern")
+}
+
+func TestEvaluateProdChangeControl(t *testing.T) {
+	rules, err := Compile([]config.PolicyConfig{{
+		Name:         "prod-change-control",
+		Environments: []string{"prod"},
+		RequireForce: true,
+		RequireEnv:   []string{"CHANGE_TICKET"},
+	}})
+	require.NoError(t, err)
+
+	noEnv := func(string) string { return "" }
+	assert.Empty(t, Evaluate(rules, Push{Environment: "dev", Getenv: noEnv}))
+
+	violations := Evaluate(rules, Push{Environment: "prod", Getenv: noEnv})
+	require.Len(t, violations, 2)
+	assert.Equal(t, "pushes to prod need --force", violations[0].Message)
+	assert.Equal(t, "pushes to prod need CHANGE_TICKET to be set", violations[1].Message)
+	assert.True(t, violations[0].Denies())
+
+	ticket := func(name string) string {
+		if name == "CHANGE_TICKET" {
+			return "CHG0031337"
+		}
+		return ""
+	}
+	assert.Empty(t, Evaluate(rules, Push{Environment: "prod", Force: true, Getenv: ticket}))
+}
+
+func TestEvaluatePlaintext(t *testing.T) {
+	vars := env.NewFile()
+	vars.Set("DB_PASSWORD", "hunter2")
+	vars.Set("ADMIN_HASH", "$2b$12$abcdefghijklmnopqrstuv")
+	vars.Set("SMTP_PASSWORD", "s3cret")
+	vars.Set("PORT", "8080")
+	vars.Set("EMPTY_PASSWORD", "")
+
+	rules, err := Compile([]config.PolicyConfig{{
+		Name:            "no-plaintext-passwords",
+		Action:          ActionWarn,
+		PlaintextKeys:   []string{"*PASSWORD*"},
+		PlaintextValues: []string{`^\$2[aby]\$`},
+	}})
+	require.NoError(t, err)
+
+	// SMTP_PASSWORD is classified sensitive, so it is a SecureString
+	plaintext := func(key string) bool { return key != "SMTP_PASSWORD" }
+	violations := Evaluate(rules, Push{Environment: "dev", Vars: vars, Plaintext: plaintext})
+	require.Len(t, violations, 1)
+	assert.Contains(t, violations[0].Message, "2 variable(s) would be stored unencrypted: ADMIN_HASH, DB_PASSWORD ")
+	assert.NotContains(t, violations[0].Message, "hunter2")
+	assert.False(t, violations[0].Denies())
+
+	// Secrets Manager encrypts every value
+	assert.Empty(t, Evaluate(rules, Push{Environment: "dev", Vars: vars}))
+}
+
+func TestCheck(t *testing.T) {
+	cfg := &config.Config{}
+	assert.NoError(t, Check(cfg, Push{Environment: "prod"}))
+
+	cfg.Policies = []config.PolicyConfig{
+		{Name: "force", Environments: []string{"prod"}, RequireForce: true},
+		{Name: "ticket", Action: ActionWarn, RequireEnv: []string{"CHANGE_TICKET"}},
+	}
+	noEnv := func(string) string { return "" }
+	err := Check(cfg, Push{Environment: "prod", Getenv: noEnv})
+	assert.EqualError(t, err, "push to prod refused by policy force (see policies in .envyrc)")
+
+	// Warnings alone do not refuse the push
+	assert.NoError(t, Check(cfg, Push{Environment: "prod", Force: true, Getenv: noEnv}))
+
+	cfg.Policies = []config.PolicyConfig{{Name: "empty"}}
+	assert.ErrorContains(t, Check(cfg, Push{Environment: "prod"}), "invalid policies in .envyrc")
+}
@@ -0,0 +1,151 @@
+// Package precheck holds the checks made before variables are written to
+// AWS. Every command that writes an environment runs them, so a value that
+// push would refuse cannot reach AWS another way.
+package precheck
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/drapon/envy/internal/color"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/ghactions"
+	"github.com/drapon/envy/internal/ownership"
+	"github.com/drapon/envy/internal/validator"
+)
+
+// RulesFile holds the validation rules
+const RulesFile = ".envy-rules.yaml"
+
+// Validate checks the variables about to be written against the
+// environment's validation rules and fails on errors. For a partial write,
+// variables missing from vars are left alone remotely, so they are not
+// reported as missing. files are where the values come from, for GitHub
+// Actions annotations.
+func Validate(ctx context.Context, envName string, vars *env.File, files []string, partial bool) error {
+	rules, err := validator.LoadRulesForEnvironment(RulesFile, envName)
+	if stderrors.Is(err, fs.ErrNotExist) {
+		color.PrintWarningf("No %s found, skipping validation", RulesFile)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load validation rules: %w", err)
+	}
+
+	result := validator.New(rules).Validate(ctx, vars.ToMap())
+	if partial {
+		result = result.Without("missing_required")
+	}
+
+	for _, issue := range result.Errors {
+		fmt.Printf("  %s\n", color.FormatError(issue.Message))
+	}
+	for _, issue := range result.Warnings {
+		fmt.Printf("  %s\n", color.FormatWarning(issue.Message))
+	}
+	ghactions.Emit(ghactions.ValidationAnnotations(result, files)...)
+
+	if result.Exceeds(validator.SeverityWarning) {
+		return fmt.Errorf("validation failed with %d error(s), %s was not written (run 'envy validate --env %s' for details)", len(result.Errors), envName, envName)
+	}
+	color.PrintSuccessf("Validation passed")
+	return nil
+}
+
+// Placeholders reports variables whose values look like placeholders. They
+// block the write to protected environments unless allowed in .envyrc or
+// allow is set.
+func Placeholders(cfg *config.Config, envName string, envConfig *config.Environment, vars *env.File, allow bool) error {
+	allowed, err := filter.New(filter.Options{Include: cfg.Placeholders.Allow})
+	if err != nil {
+		return fmt.Errorf("invalid placeholders.allow: %w", err)
+	}
+
+	var found []string
+	for _, key := range vars.SortedKeys() {
+		value, _ := vars.Get(key)
+		what, ok := validator.Placeholder(value)
+		if !ok || (len(cfg.Placeholders.Allow) > 0 && allowed.Match(key)) {
+			continue
+		}
+		found = append(found, key)
+		color.PrintWarningf("%s looks like a placeholder (%s)", key, what)
+	}
+
+	if len(found) == 0 || !envConfig.Protected || allow {
+		return nil
+	}
+	return fmt.Errorf("%s is protected and %d variable(s) have placeholder values: %s (list intentional ones under placeholders.allow in .envyrc)",
+		envName, len(found), strings.Join(found, ", "))
+}
+
+// CallerIdentity is satisfied by *aws.Manager
+type CallerIdentity interface {
+	CallerARN(ctx context.Context) (string, error)
+}
+
+// Writers reports keys, set or deleted, the caller is not an allowed writer
+// of, per allowed_writers in .envyrc. With enforce_writers the write fails.
+func Writers(ctx context.Context, cfg *config.Config, identity CallerIdentity, keys []string) error {
+	rules, err := ownership.ParseRules(cfg.Variables)
+	if err != nil {
+		return err
+	}
+	if !ownership.Restricted(rules) {
+		return nil
+	}
+
+	report := color.PrintWarningf
+	if cfg.EnforceWriters {
+		report = color.PrintErrorf
+	}
+
+	principal, err := identity.CallerARN(ctx)
+	if err != nil {
+		if cfg.EnforceWriters {
+			return fmt.Errorf("cannot check allowed writers: %w", err)
+		}
+		color.PrintWarningf("Cannot check allowed writers: %v", err)
+		return nil
+	}
+
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+
+	var denied []string
+	for i, key := range sorted {
+		if i > 0 && key == sorted[i-1] {
+			continue
+		}
+		writers, restricted := ownership.Writers(rules, key)
+		if !restricted || ownership.Allowed(writers, principal) {
+			continue
+		}
+		denied = append(denied, key)
+		owners := ""
+		if list := ownership.Owners(rules, key); len(list) > 0 {
+			owners = fmt.Sprintf("; owned by %s", strings.Join(list, ", "))
+		}
+		report("%s may only be written by %s%s", key, strings.Join(writers, ", "), owners)
+	}
+
+	if len(denied) > 0 && cfg.EnforceWriters {
+		return fmt.Errorf("%s is not an allowed writer of %s", principal, strings.Join(denied, ", "))
+	}
+	return nil
+}
+
+// Keys returns the keys a change touches
+func Keys(set *env.File, deleted []string) []string {
+	var keys []string
+	if set != nil {
+		keys = append(keys, set.Keys()...)
+	}
+	return append(keys, deleted...)
+}
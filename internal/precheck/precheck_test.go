@@ -0,0 +1,94 @@
+package precheck
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	file := env.NewFile()
+	file.Set("PORT", "8080")
+
+	// No rules file: nothing to check
+	assert.NoError(t, Validate(context.Background(), "prod", file, nil, false))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, RulesFile), []byte(`required: [API_KEY]
+variables:
+  PORT:
+    type: port
+`), 0644))
+
+	err = Validate(context.Background(), "prod", file, nil, false)
+	assert.ErrorContains(t, err, "prod was not written")
+
+	// A partial write leaves API_KEY alone remotely
+	assert.NoError(t, Validate(context.Background(), "prod", file, nil, true))
+
+	file.Set("PORT", "http")
+	assert.Error(t, Validate(context.Background(), "prod", file, nil, true))
+}
+
+func TestPlaceholders(t *testing.T) {
+	file := env.NewFile()
+	file.Set("API_KEY", "your-api-key-here")
+	file.Set("SUPPORT_EMAIL", "help@example.com")
+	file.Set("PORT", "8080")
+
+	cfg := &config.Config{}
+	assert.NoError(t, Placeholders(cfg, "dev", &config.Environment{}, file, false))
+
+	err := Placeholders(cfg, "prod", &config.Environment{Protected: true}, file, false)
+	assert.ErrorContains(t, err, "prod is protected and 2 variable(s) have placeholder values: API_KEY, SUPPORT_EMAIL")
+
+	cfg.Placeholders.Allow = []string{"SUPPORT_*"}
+	err = Placeholders(cfg, "prod", &config.Environment{Protected: true}, file, false)
+	assert.ErrorContains(t, err, "1 variable(s) have placeholder values: API_KEY ")
+
+	assert.NoError(t, Placeholders(cfg, "prod", &config.Environment{Protected: true}, file, true))
+}
+
+type fakeIdentity struct {
+	arn string
+	err error
+}
+
+func (f fakeIdentity) CallerARN(ctx context.Context) (string, error) {
+	return f.arn, f.err
+}
+
+func TestWriters(t *testing.T) {
+	keys := []string{"STRIPE_KEY", "PORT"}
+
+	cfg := &config.Config{Variables: []config.VariableConfig{
+		{Key: "STRIPE_*", Owners: []string{"payments"}, AllowedWriters: []string{"payments-deploy"}},
+	}}
+	developer := fakeIdentity{arn: "arn:aws:sts::123456789012:assumed-role/developer/alice"}
+	deployer := fakeIdentity{arn: "arn:aws:sts::123456789012:assumed-role/payments-deploy/ci"}
+
+	// Warnings only by default
+	assert.NoError(t, Writers(context.Background(), cfg, developer, keys))
+	assert.NoError(t, Writers(context.Background(), cfg, fakeIdentity{err: assert.AnError}, keys))
+
+	cfg.EnforceWriters = true
+	err := Writers(context.Background(), cfg, developer, keys)
+	assert.ErrorContains(t, err, "is not an allowed writer of STRIPE_KEY")
+	assert.NoError(t, Writers(context.Background(), cfg, deployer, keys))
+	assert.Error(t, Writers(context.Background(), cfg, fakeIdentity{err: assert.AnError}, keys))
+
+	// Deleting a restricted key is a write too
+	err = Writers(context.Background(), cfg, developer, Keys(nil, []string{"STRIPE_OLD"}))
+	assert.ErrorContains(t, err, "STRIPE_OLD")
+}
@@ -0,0 +1,122 @@
+// Package procenv reads the environment of running processes and
+// containers, so envy can tell whether they still run with values that have
+// since been rotated.
+//
+// A process's environment is the one it was started with: /proc and docker
+// inspect do not show variables the process set or changed itself.
+package procenv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Statuses of a mismatch
+const (
+	StatusStale     = "stale"     // the value of an earlier version
+	StatusDifferent = "different" // a value that is not known to have been stored
+	StatusMissing   = "missing"   // not set in the process
+)
+
+// procRoot is where process information is read from
+var procRoot = "/proc"
+
+// Mismatch is a variable whose value in a process is not the expected one
+type Mismatch struct {
+	Key    string `json:"key"`
+	Status string `json:"status"`
+	// Version is the version of the variable the running value is, for
+	// stale values
+	Version int64 `json:"version,omitempty"`
+}
+
+// Process returns the environment process pid was started with. Reading
+// another user's process needs root.
+func Process(pid int) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "environ"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			if _, statErr := os.Stat(procRoot); statErr != nil {
+				return nil, fmt.Errorf("reading the environment of a process needs %s, which only Linux has", procRoot)
+			}
+			return nil, fmt.Errorf("no process with pid %d", pid)
+		}
+		if errors.Is(err, fs.ErrPermission) {
+			return nil, fmt.Errorf("not allowed to read the environment of pid %d (run as its user or root)", pid)
+		}
+		return nil, err
+	}
+	return parseEnviron(data), nil
+}
+
+// Container returns the environment a Docker container was created with
+func Container(ctx context.Context, id string) (map[string]string, error) {
+	cmd := exec.CommandContext(ctx, "docker", "inspect", "--type", "container", "--format", "{{json .Config.Env}}", id)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("docker inspect %s: %s", id, msg)
+		}
+		return nil, fmt.Errorf("docker inspect %s: %w", id, err)
+	}
+	return parseDockerEnv(out)
+}
+
+// parseEnviron parses the NUL-separated KEY=VALUE entries of
+// /proc/PID/environ
+func parseEnviron(data []byte) map[string]string {
+	return parseEntries(strings.Split(string(data), "\x00"))
+}
+
+// parseDockerEnv parses the JSON list of KEY=VALUE entries printed by
+// docker inspect
+func parseDockerEnv(data []byte) (map[string]string, error) {
+	var entries []string
+	if err := json.Unmarshal(bytes.TrimSpace(data), &entries); err != nil {
+		return nil, fmt.Errorf("unexpected output of docker inspect: %w", err)
+	}
+	return parseEntries(entries), nil
+}
+
+func parseEntries(entries []string) map[string]string {
+	vars := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			continue
+		}
+		vars[key] = value
+	}
+	return vars
+}
+
+// Compare returns the expected variables that are missing from actual or
+// have another value there, sorted by key. Variables only in actual, such as
+// PATH, are ignored. Differing values are StatusDifferent until the caller
+// finds them in the variable's history.
+func Compare(expected, actual map[string]string) []Mismatch {
+	var mismatches []Mismatch
+	for key, want := range expected {
+		got, ok := actual[key]
+		switch {
+		case !ok:
+			mismatches = append(mismatches, Mismatch{Key: key, Status: StatusMissing})
+		case got != want:
+			mismatches = append(mismatches, Mismatch{Key: key, Status: StatusDifferent})
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool { return mismatches[i].Key < mismatches[j].Key })
+	return mismatches
+}
@@ -0,0 +1,51 @@
+package procenv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcess(t *testing.T) {
+	root := t.TempDir()
+	defer func(old string) { procRoot = old }(procRoot)
+	procRoot = root
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "42"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "42", "environ"), []byte("PATH=/usr/bin\x00DB_PASSWORD=a=b\x00EMPTY=\x00"), 0o600))
+
+	vars, err := Process(42)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"PATH": "/usr/bin", "DB_PASSWORD": "a=b", "EMPTY": ""}, vars)
+
+	_, err = Process(7)
+	assert.ErrorContains(t, err, "no process with pid 7")
+
+	procRoot = filepath.Join(root, "missing")
+	_, err = Process(42)
+	assert.ErrorContains(t, err, "only Linux")
+}
+
+func TestParseDockerEnv(t *testing.T) {
+	vars, err := parseDockerEnv([]byte(`["PATH=/usr/bin","API_KEY=old"]` + "\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"PATH": "/usr/bin", "API_KEY": "old"}, vars)
+
+	_, err = parseDockerEnv([]byte("Error: no such container"))
+	assert.Error(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	expected := map[string]string{"API_KEY": "new", "DB_HOST": "db", "PORT": "8080"}
+	actual := map[string]string{"API_KEY": "old", "DB_HOST": "db", "PATH": "/usr/bin"}
+
+	assert.Equal(t, []Mismatch{
+		{Key: "API_KEY", Status: StatusDifferent},
+		{Key: "PORT", Status: StatusMissing},
+	}, Compare(expected, actual))
+
+	assert.Empty(t, Compare(expected, expected))
+}
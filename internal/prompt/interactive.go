@@ -1,21 +1,25 @@
-// Package prompt provides interactive prompts and menus for user input.
+//go:build !slim
+
 package prompt
 
 import (
 	"errors"
 	"fmt"
-	"os"
-	"os/exec"
-	"runtime"
+	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/c-bata/go-prompt"
 )
 
 const selectIcon = "▶"
 
 // InteractiveSelect shows an interactive selection menu using arrow keys.
 func InteractiveSelect(title string, options []string, defaultIndex int) (int, error) {
+	if NonInteractive() {
+		return defaultIndex, nil
+	}
+
 	var selected string
 
 	prompt := &survey.Select{
@@ -50,6 +54,10 @@ func InteractiveSelect(title string, options []string, defaultIndex int) (int, e
 
 // InteractiveMultiSelect shows a multi-select menu using arrow keys and space to select.
 func InteractiveMultiSelect(title string, options []string, defaults []int) ([]int, error) {
+	if NonInteractive() {
+		return defaults, nil
+	}
+
 	defaultOptions := make([]string, len(defaults))
 	for i, idx := range defaults {
 		if idx < len(options) {
@@ -90,6 +98,10 @@ func InteractiveMultiSelect(title string, options []string, defaults []int) ([]i
 
 // InteractiveConfirm shows a yes/no confirmation prompt.
 func InteractiveConfirm(message string, defaultYes bool) bool {
+	if NonInteractive() {
+		return confirmDefault(defaultYes)
+	}
+
 	var result bool
 	prompt := &survey.Confirm{
 		Message: message,
@@ -104,25 +116,80 @@ func InteractiveConfirm(message string, defaultYes bool) bool {
 	return result
 }
 
-// ClearScreen clears the terminal screen.
-func ClearScreen() {
-	var cmd *exec.Cmd
-
-	switch runtime.GOOS {
-	case "windows":
-		cmd = exec.Command("cmd", "/c", "cls")
-	default:
-		cmd = exec.Command("clear")
+// InteractiveInput asks for a line of text.
+func InteractiveInput(message string) (string, error) {
+	if NonInteractive() {
+		return "", errNoPrompt(message)
 	}
 
-	cmd.Stdout = os.Stdout
-	_ = cmd.Run() // Ignore error as clearing screen is not critical
+	var result string
+	err := survey.AskOne(&survey.Input{Message: message}, &result)
+	return result, err
 }
 
-// ShowProgress shows a progress spinner.
-func ShowProgress(message string, work func() error) error {
-	// For now, just print the message and do the work
-	// In the future, we can add a spinner
-	fmt.Printf("%s...\n", message)
-	return work()
+// SelectMenu shows an interactive menu and returns the selected value.
+func SelectMenu(title string, options []MenuOption) string {
+	if NonInteractive() {
+		return menuDefault(options)
+	}
+
+	fmt.Println(title)
+
+	// Create a map for quick lookup
+	optionMap := make(map[string]MenuOption)
+	suggestions := []prompt.Suggest{}
+
+	for i, opt := range options {
+		// Use number keys for selection
+		key := fmt.Sprintf("%d", i+1)
+		optionMap[key] = opt
+		suggestions = append(suggestions, prompt.Suggest{
+			Text:        key,
+			Description: fmt.Sprintf("%s - %s", opt.Label, opt.Description),
+		})
+	}
+
+	// Show options
+	fmt.Println()
+	for i, opt := range options {
+		fmt.Printf("  %d) %s", i+1, opt.Label)
+		if opt.Description != "" {
+			fmt.Printf(" - %s", opt.Description)
+		}
+		fmt.Println()
+	}
+
+	completer := func(d prompt.Document) []prompt.Suggest {
+		return prompt.FilterHasPrefix(suggestions, d.GetWordBeforeCursor(), true)
+	}
+
+	fmt.Println()
+	result := prompt.Input("Select option (1-"+fmt.Sprintf("%d", len(options))+"): ", completer,
+		prompt.OptionPrefixTextColor(prompt.Blue),
+		prompt.OptionPreviewSuggestionTextColor(prompt.Green),
+		prompt.OptionSelectedSuggestionBGColor(prompt.DarkGray),
+		prompt.OptionSuggestionBGColor(prompt.DarkBlue),
+		prompt.OptionShowCompletionAtStart(),
+	)
+
+	// Check if it's a valid option
+	if opt, ok := optionMap[result]; ok {
+		return opt.Value
+	}
+
+	// Try to match by label (case insensitive)
+	resultLower := strings.ToLower(strings.TrimSpace(result))
+	for _, opt := range options {
+		if strings.EqualFold(opt.Label, resultLower) {
+			return opt.Value
+		}
+	}
+
+	// Default to first option
+	if len(options) > 0 {
+		fmt.Printf("Invalid selection. Defaulting to: %s\n", options[0].Label)
+		return options[0].Value
+	}
+
+	return ""
 }
@@ -0,0 +1,106 @@
+//go:build slim
+
+package prompt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// InteractiveSelect falls back to a numbered stdin menu in the slim build.
+func InteractiveSelect(title string, options []string, defaultIndex int) (int, error) {
+	if NonInteractive() {
+		return defaultIndex, nil
+	}
+
+	fmt.Println(title)
+	for i, opt := range options {
+		fmt.Printf("  %d) %s\n", i+1, opt)
+	}
+	fmt.Printf("Select option (1-%d) [%d]: ", len(options), defaultIndex+1)
+
+	var response string
+	_, _ = fmt.Scanln(&response) // Empty input selects the default
+	response = strings.TrimSpace(response)
+	if response == "" {
+		return defaultIndex, nil
+	}
+
+	n, err := strconv.Atoi(response)
+	if err != nil || n < 1 || n > len(options) {
+		return defaultIndex, nil
+	}
+	return n - 1, nil
+}
+
+// InteractiveMultiSelect falls back to a comma-separated stdin prompt in the slim build.
+func InteractiveMultiSelect(title string, options []string, defaults []int) ([]int, error) {
+	if NonInteractive() {
+		return defaults, nil
+	}
+
+	fmt.Println(title)
+	for i, opt := range options {
+		fmt.Printf("  %d) %s\n", i+1, opt)
+	}
+	fmt.Print("Select options (comma-separated, empty for defaults): ")
+
+	var response string
+	_, _ = fmt.Scanln(&response)
+	response = strings.TrimSpace(response)
+	if response == "" {
+		return defaults, nil
+	}
+
+	indices := []int{}
+	for _, part := range strings.Split(response, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 1 || n > len(options) {
+			continue
+		}
+		indices = append(indices, n-1)
+	}
+
+	return indices, nil
+}
+
+// InteractiveConfirm falls back to a y/N stdin prompt in the slim build.
+func InteractiveConfirm(message string, defaultYes bool) bool {
+	if NonInteractive() {
+		return confirmDefault(defaultYes)
+	}
+
+	hint := "[y/N]"
+	if defaultYes {
+		hint = "[Y/n]"
+	}
+	fmt.Printf("%s %s: ", message, hint)
+
+	var response string
+	_, _ = fmt.Scanln(&response)
+	response = strings.ToLower(strings.TrimSpace(response))
+	if response == "" {
+		return defaultYes
+	}
+
+	return response == "y" || response == "yes"
+}
+
+// InteractiveInput falls back to a plain stdin prompt in the slim build.
+func InteractiveInput(message string) (string, error) {
+	if NonInteractive() {
+		return "", errNoPrompt(message)
+	}
+
+	fmt.Printf("%s ", message)
+
+	var response string
+	_, _ = fmt.Scanln(&response) // Empty input is returned as ""
+	return strings.TrimSpace(response), nil
+}
+
+// SelectMenu is equivalent to SimpleMenu in the slim build.
+func SelectMenu(title string, options []MenuOption) string {
+	return SimpleMenu(title, options)
+}
@@ -1,10 +1,15 @@
+// Package prompt provides interactive prompts and menus for user input.
+//
+// The arrow-key prompts in interactive.go are excluded from slim builds
+// (-tags slim), which fall back to the plain stdin menus in this file.
 package prompt
 
 import (
 	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
 	"strings"
-
-	"github.com/c-bata/go-prompt"
 )
 
 // MenuOption represents a menu option.
@@ -14,71 +19,12 @@ type MenuOption struct {
 	Description string
 }
 
-// SelectMenu shows an interactive menu and returns the selected value.
-func SelectMenu(title string, options []MenuOption) string {
-	fmt.Println(title)
-
-	// Create a map for quick lookup
-	optionMap := make(map[string]MenuOption)
-	suggestions := []prompt.Suggest{}
-
-	for i, opt := range options {
-		// Use number keys for selection
-		key := fmt.Sprintf("%d", i+1)
-		optionMap[key] = opt
-		suggestions = append(suggestions, prompt.Suggest{
-			Text:        key,
-			Description: fmt.Sprintf("%s - %s", opt.Label, opt.Description),
-		})
-	}
-
-	// Show options
-	fmt.Println()
-	for i, opt := range options {
-		fmt.Printf("  %d) %s", i+1, opt.Label)
-		if opt.Description != "" {
-			fmt.Printf(" - %s", opt.Description)
-		}
-		fmt.Println()
-	}
-
-	completer := func(d prompt.Document) []prompt.Suggest {
-		return prompt.FilterHasPrefix(suggestions, d.GetWordBeforeCursor(), true)
-	}
-
-	fmt.Println()
-	result := prompt.Input("Select option (1-"+fmt.Sprintf("%d", len(options))+"): ", completer,
-		prompt.OptionPrefixTextColor(prompt.Blue),
-		prompt.OptionPreviewSuggestionTextColor(prompt.Green),
-		prompt.OptionSelectedSuggestionBGColor(prompt.DarkGray),
-		prompt.OptionSuggestionBGColor(prompt.DarkBlue),
-		prompt.OptionShowCompletionAtStart(),
-	)
-
-	// Check if it's a valid option
-	if opt, ok := optionMap[result]; ok {
-		return opt.Value
-	}
-
-	// Try to match by label (case insensitive)
-	resultLower := strings.ToLower(strings.TrimSpace(result))
-	for _, opt := range options {
-		if strings.EqualFold(opt.Label, resultLower) {
-			return opt.Value
-		}
-	}
-
-	// Default to first option
-	if len(options) > 0 {
-		fmt.Printf("Invalid selection. Defaulting to: %s\n", options[0].Label)
-		return options[0].Value
-	}
-
-	return ""
-}
-
 // SimpleMenu shows a simple text-based menu without dependencies.
 func SimpleMenu(title string, options []MenuOption) string {
+	if NonInteractive() {
+		return menuDefault(options)
+	}
+
 	fmt.Println(title)
 	fmt.Println()
 
@@ -121,3 +67,58 @@ func SimpleMenu(title string, options []MenuOption) string {
 
 	return ""
 }
+
+// ClearScreen clears the terminal screen.
+func ClearScreen() {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "cls")
+	default:
+		cmd = exec.Command("clear")
+	}
+
+	cmd.Stdout = os.Stdout
+	_ = cmd.Run() // Ignore error as clearing screen is not critical
+}
+
+// ShowProgress shows a progress spinner.
+func ShowProgress(message string, work func() error) error {
+	// For now, just print the message and do the work
+	// In the future, we can add a spinner
+	fmt.Printf("%s...\n", message)
+	return work()
+}
+
+// IsInteractive reports whether stdin and stdout are terminals and prompts
+// are enabled, so prompts can be shown instead of failing in CI or when
+// output is piped.
+func IsInteractive() bool {
+	if NonInteractive() {
+		return false
+	}
+	for _, f := range []*os.File{os.Stdin, os.Stdout} {
+		info, err := f.Stat()
+		if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// OpenURL opens url in the default browser.
+func OpenURL(url string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	case "darwin":
+		cmd = exec.Command("open", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	return cmd.Start()
+}
@@ -0,0 +1,70 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Without prompts, e.g. in pipelines, envy never waits on stdin. --yes
+// answers every confirmation yes; --non-interactive, or CI=true as set by
+// most CI systems, takes the default of every prompt, which for
+// confirmations is no.
+
+// AssumeYes reports whether confirmations are answered yes without asking
+// (--yes or ENVY_YES)
+func AssumeYes() bool {
+	return viper.GetBool("yes")
+}
+
+// NonInteractive reports whether prompts are answered without asking:
+// with --yes, --non-interactive (or ENVY_NON_INTERACTIVE) or CI=true
+func NonInteractive() bool {
+	if AssumeYes() || viper.GetBool("non_interactive") {
+		return true
+	}
+	ci, _ := strconv.ParseBool(os.Getenv("CI"))
+	return ci
+}
+
+// Confirm asks a question answered y or n, defaulting to no. Without
+// prompts the answer is yes with --yes and no otherwise, and it is
+// printed so logs show what was decided.
+func Confirm(question string) bool {
+	fmt.Printf("\n%s [y/N]: ", question)
+	if NonInteractive() {
+		if AssumeYes() {
+			fmt.Println("y (--yes)")
+			return true
+		}
+		fmt.Println("n (prompts are disabled; pass --yes to confirm)")
+		return false
+	}
+
+	var response string
+	_, _ = fmt.Scanln(&response)
+
+	response = strings.ToLower(strings.TrimSpace(response))
+	return response == "y" || response == "yes"
+}
+
+// confirmDefault is the answer to InteractiveConfirm without prompts
+func confirmDefault(defaultYes bool) bool {
+	return AssumeYes() || defaultYes
+}
+
+// menuDefault is the answer to a menu without prompts: its first option
+func menuDefault(options []MenuOption) string {
+	if len(options) == 0 {
+		return ""
+	}
+	return options[0].Value
+}
+
+// errNoPrompt is returned by prompts that have no default answer
+func errNoPrompt(message string) error {
+	return fmt.Errorf("%s needs an answer, but prompts are disabled (--non-interactive, --yes or CI=true)", strings.TrimSuffix(strings.TrimSpace(message), ":"))
+}
@@ -0,0 +1,58 @@
+package prompt
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNonInteractive(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	t.Setenv("CI", "")
+
+	assert.False(t, NonInteractive())
+
+	t.Setenv("CI", "true")
+	assert.True(t, NonInteractive())
+	assert.False(t, AssumeYes())
+	assert.False(t, IsInteractive())
+
+	t.Setenv("CI", "")
+	viper.Set("non_interactive", true)
+	assert.True(t, NonInteractive())
+
+	viper.Reset()
+	viper.Set("yes", true)
+	assert.True(t, NonInteractive())
+	assert.True(t, AssumeYes())
+}
+
+func TestPromptsWithoutPrompting(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	t.Setenv("CI", "true")
+
+	// The defaults are taken, and confirmations declined
+	assert.False(t, Confirm("Delete everything?"))
+	assert.False(t, InteractiveConfirm("Overwrite API_KEY?", false))
+	assert.True(t, InteractiveConfirm("Detect files?", true))
+
+	index, err := InteractiveSelect("What would you like to do?", []string{"Overwrite all", "Skip all"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, index)
+
+	indices, err := InteractiveMultiSelect("Environments", []string{"dev", "prod"}, []int{0})
+	require.NoError(t, err)
+	assert.Equal(t, []int{0}, indices)
+
+	assert.Equal(t, "all", SimpleMenu("What would you like to do?", []MenuOption{{Label: "Overwrite all", Value: "all"}}))
+
+	_, err = InteractiveInput("MFA code:")
+	assert.ErrorContains(t, err, "MFA code needs an answer, but prompts are disabled")
+
+	// --yes confirms
+	viper.Set("yes", true)
+	assert.True(t, Confirm("Delete everything?"))
+	assert.True(t, InteractiveConfirm("Overwrite API_KEY?", false))
+}
@@ -0,0 +1,207 @@
+// Package refresh re-fetches individual variables on their own schedule,
+// for values such as short-lived database tokens that rotate more often
+// than the rest of an environment.
+package refresh
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/filter"
+)
+
+// minInterval guards against accidental hot loops against AWS
+const minInterval = time.Second
+
+// Rule assigns a refresh interval to keys matching Pattern
+type Rule struct {
+	Pattern  string
+	Interval time.Duration
+
+	match *filter.Filter
+}
+
+// Fetcher reads selected variables of an environment. It is satisfied by
+// *aws.Manager.
+type Fetcher interface {
+	GetVariables(ctx context.Context, envName string, keys []string) (map[string]string, error)
+}
+
+// ParseRules builds rules from the variables section of .envyrc, skipping
+// entries without a refresh interval
+func ParseRules(variables []config.VariableConfig) ([]Rule, error) {
+	var rules []Rule
+	for _, v := range variables {
+		if v.Refresh == "" {
+			continue
+		}
+		if v.Key == "" {
+			return nil, fmt.Errorf("variables: refresh %q has no key", v.Refresh)
+		}
+
+		interval, err := config.ParseDuration(v.Refresh)
+		if err != nil {
+			return nil, fmt.Errorf("variables: refresh for %s: %w", v.Key, err)
+		}
+		if interval < minInterval {
+			return nil, fmt.Errorf("variables: refresh for %s must be at least %s", v.Key, minInterval)
+		}
+
+		match, err := filter.New(filter.Options{Include: []string{v.Key}})
+		if err != nil {
+			return nil, fmt.Errorf("variables: %w", err)
+		}
+		rules = append(rules, Rule{Pattern: v.Key, Interval: interval, match: match})
+	}
+	return rules, nil
+}
+
+// Intervals resolves the refresh interval of each key. The first matching
+// rule wins; keys without a rule are omitted.
+func Intervals(rules []Rule, keys []string) map[string]time.Duration {
+	intervals := make(map[string]time.Duration)
+	for _, key := range keys {
+		for _, rule := range rules {
+			if rule.match.Match(key) {
+				intervals[key] = rule.Interval
+				break
+			}
+		}
+	}
+	return intervals
+}
+
+// Refresher re-fetches keys when their interval elapses and reports the
+// ones whose values changed
+type Refresher struct {
+	fetcher     Fetcher
+	environment string
+	intervals   map[string]time.Duration
+	values      map[string]string
+	next        map[string]time.Time
+
+	// OnError is called when a fetch fails; the keys are retried after
+	// their interval. It may be nil.
+	OnError func(error)
+
+	now func() time.Time
+}
+
+// NewRefresher returns a refresher for the keys in intervals, starting from
+// the current values
+func NewRefresher(fetcher Fetcher, envName string, intervals map[string]time.Duration, current map[string]string) *Refresher {
+	r := &Refresher{
+		fetcher:     fetcher,
+		environment: envName,
+		intervals:   intervals,
+		values:      make(map[string]string, len(intervals)),
+		next:        make(map[string]time.Time, len(intervals)),
+		now:         time.Now,
+	}
+
+	for key := range intervals {
+		r.values[key] = current[key]
+	}
+	r.schedule(r.now())
+	return r
+}
+
+// schedule sets every key's next refresh one interval after start
+func (r *Refresher) schedule(start time.Time) {
+	for key, interval := range r.intervals {
+		r.next[key] = start.Add(interval)
+	}
+}
+
+// Run refreshes keys until ctx is cancelled, calling onChange with the new
+// values of keys that changed. Keys due at the same time are fetched in one
+// call.
+func (r *Refresher) Run(ctx context.Context, onChange func(changed map[string]string)) {
+	if len(r.intervals) == 0 {
+		return
+	}
+
+	timer := time.NewTimer(r.untilNext())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		changed, err := r.Refresh(ctx)
+		if err != nil && r.OnError != nil && ctx.Err() == nil {
+			r.OnError(err)
+		}
+		if len(changed) > 0 {
+			onChange(changed)
+		}
+
+		timer.Reset(r.untilNext())
+	}
+}
+
+// Refresh fetches the keys that are due and returns those that changed
+func (r *Refresher) Refresh(ctx context.Context) (map[string]string, error) {
+	now := r.now()
+	due := r.due(now)
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	// Schedule the next fetch first so a failure is retried one interval later
+	for _, key := range due {
+		r.next[key] = now.Add(r.intervals[key])
+	}
+
+	fetched, err := r.fetcher.GetVariables(ctx, r.environment, due)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh %v: %w", due, err)
+	}
+
+	changed := make(map[string]string)
+	for _, key := range due {
+		value, ok := fetched[key]
+		if !ok {
+			// Deleted remotely: keep serving the last value
+			continue
+		}
+		if value != r.values[key] {
+			r.values[key] = value
+			changed[key] = value
+		}
+	}
+	return changed, nil
+}
+
+// due returns the keys whose next refresh is at or before now
+func (r *Refresher) due(now time.Time) []string {
+	var keys []string
+	for key, next := range r.next {
+		if !next.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (r *Refresher) untilNext() time.Duration {
+	var earliest time.Time
+	for _, next := range r.next {
+		if earliest.IsZero() || next.Before(earliest) {
+			earliest = next
+		}
+	}
+
+	wait := earliest.Sub(r.now())
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
@@ -0,0 +1,140 @@
+package refresh
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFetcher returns fixed values and records the keys requested
+type fakeFetcher struct {
+	mu       sync.Mutex
+	values   map[string]string
+	requests [][]string
+	err      error
+}
+
+func (f *fakeFetcher) GetVariables(ctx context.Context, envName string, keys []string) (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, keys)
+	if f.err != nil {
+		return nil, f.err
+	}
+	result := make(map[string]string)
+	for _, key := range keys {
+		if value, ok := f.values[key]; ok {
+			result[key] = value
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeFetcher) set(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[key] = value
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]config.VariableConfig{
+		{Key: "DB_TOKEN", Refresh: "5m"},
+		{Key: "LOG_LEVEL"},
+		{Key: "AWS_SESSION_*", Refresh: "15m"},
+	})
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, 5*time.Minute, rules[0].Interval)
+
+	intervals := Intervals(rules, []string{"DB_TOKEN", "AWS_SESSION_TOKEN", "LOG_LEVEL"})
+	assert.Equal(t, map[string]time.Duration{
+		"DB_TOKEN":          5 * time.Minute,
+		"AWS_SESSION_TOKEN": 15 * time.Minute,
+	}, intervals)
+
+	_, err = ParseRules([]config.VariableConfig{{Key: "A", Refresh: "often"}})
+	assert.Error(t, err)
+	_, err = ParseRules([]config.VariableConfig{{Key: "A", Refresh: "10ms"}})
+	assert.Error(t, err)
+	_, err = ParseRules([]config.VariableConfig{{Refresh: "5m"}})
+	assert.Error(t, err)
+}
+
+func TestRefresher_Refresh(t *testing.T) {
+	ctx := context.Background()
+	fetcher := &fakeFetcher{values: map[string]string{"FAST": "1", "SLOW": "a"}}
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewRefresher(fetcher, "prod", map[string]time.Duration{
+		"FAST": time.Minute,
+		"SLOW": 5 * time.Minute,
+	}, map[string]string{"FAST": "1", "SLOW": "a"})
+	r.now = func() time.Time { return now }
+	r.schedule(now)
+
+	changed, err := r.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, changed, "nothing due yet")
+	assert.Equal(t, time.Minute, r.untilNext())
+
+	now = now.Add(time.Minute)
+	fetcher.set("FAST", "2")
+	fetcher.set("SLOW", "b")
+	changed, err = r.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"FAST": "2"}, changed, "only due keys are fetched")
+	assert.Equal(t, [][]string{{"FAST"}}, fetcher.requests)
+
+	now = now.Add(4 * time.Minute)
+	changed, err = r.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"SLOW": "b"}, changed)
+	assert.Equal(t, []string{"FAST", "SLOW"}, fetcher.requests[1], "keys due together are fetched together")
+}
+
+func TestRefresher_ErrorAndDeletedKey(t *testing.T) {
+	ctx := context.Background()
+	fetcher := &fakeFetcher{values: map[string]string{}, err: errors.New("throttled")}
+
+	now := time.Now()
+	r := NewRefresher(fetcher, "prod", map[string]time.Duration{"TOKEN": time.Minute}, map[string]string{"TOKEN": "x"})
+	r.now = func() time.Time { return now }
+	r.schedule(now)
+
+	now = now.Add(time.Minute)
+	_, err := r.Refresh(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, time.Minute, r.untilNext(), "failed keys are retried after their interval")
+
+	fetcher.mu.Lock()
+	fetcher.err = nil
+	fetcher.mu.Unlock()
+	now = now.Add(time.Minute)
+	changed, err := r.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, changed, "a key deleted remotely keeps its last value")
+}
+
+func TestRefresher_Run(t *testing.T) {
+	fetcher := &fakeFetcher{values: map[string]string{"TOKEN": "new"}}
+	r := NewRefresher(fetcher, "prod", map[string]time.Duration{"TOKEN": 20 * time.Millisecond}, map[string]string{"TOKEN": "old"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan map[string]string, 1)
+	go r.Run(ctx, func(changed map[string]string) { changes <- changed })
+
+	select {
+	case changed := <-changes:
+		assert.Equal(t, map[string]string{"TOKEN": "new"}, changed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported")
+	}
+}
@@ -0,0 +1,23 @@
+package refresh
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ParseSignal parses a signal name such as "HUP", "SIGHUP" or "usr1"
+func ParseSignal(name string) (os.Signal, error) {
+	normalized := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "SIG")
+	if sig, ok := signals[normalized]; ok {
+		return sig, nil
+	}
+
+	names := make([]string, 0, len(signals))
+	for n := range signals {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unsupported signal %q (expected one of %s)", name, strings.Join(names, ", "))
+}
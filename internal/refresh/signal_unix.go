@@ -0,0 +1,16 @@
+//go:build !windows
+
+package refresh
+
+import (
+	"os"
+	"syscall"
+)
+
+var signals = map[string]os.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"TERM": syscall.SIGTERM,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
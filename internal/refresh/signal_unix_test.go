@@ -0,0 +1,22 @@
+//go:build !windows
+
+package refresh
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSignal(t *testing.T) {
+	for _, name := range []string{"HUP", "SIGHUP", "hup", " sighup "} {
+		sig, err := ParseSignal(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, syscall.SIGHUP, sig)
+	}
+
+	_, err := ParseSignal("SIGFOO")
+	assert.Error(t, err)
+}
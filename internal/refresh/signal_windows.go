@@ -0,0 +1,11 @@
+//go:build windows
+
+package refresh
+
+import "os"
+
+// Windows processes can only be interrupted or killed
+var signals = map[string]os.Signal{
+	"INT":  os.Interrupt,
+	"KILL": os.Kill,
+}
@@ -0,0 +1,40 @@
+// Package remote selects where environments are stored: AWS Parameter Store
+// and Secrets Manager, or Azure Key Vault when .envyrc has an azure section.
+package remote
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/drapon/envy/internal/aws"
+	"github.com/drapon/envy/internal/azure"
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/env"
+)
+
+// Backend pushes and pulls environments. *aws.Manager and *azure.Manager
+// implement it; commands type-assert *aws.Manager for AWS-only features
+// such as replication, history and allowed writers.
+type Backend interface {
+	PullEnvironment(ctx context.Context, envName string) (*env.File, error)
+	PushEnvironment(ctx context.Context, envName string, file *env.File, overwrite bool) error
+	ListEnvironmentVariables(ctx context.Context, envName string) (map[string]string, error)
+	IsSensitive(key string) bool
+}
+
+// New returns the backend configured in cfg
+func New(cfg *config.Config) (Backend, error) {
+	if cfg.UsesAzure() {
+		manager, err := azure.NewManager(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure Key Vault manager: %w", err)
+		}
+		return manager, nil
+	}
+
+	manager, err := aws.NewManager(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS manager: %w", err)
+	}
+	return manager, nil
+}
@@ -0,0 +1,58 @@
+package report
+
+import (
+	"html/template"
+	"io"
+)
+
+// htmlTemplate is a self-contained page: styles are inline and nothing is
+// loaded from elsewhere, so it can be attached to a ticket or mailed as is
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem auto; max-width: 60rem; padding: 0 1rem; color: #1f2328; }
+h1 { font-size: 1.6rem; margin-bottom: .25rem; }
+.meta { color: #59636e; margin: .2rem 0; }
+details { border: 1px solid #d1d9e0; border-radius: 6px; margin: 1rem 0; }
+summary { cursor: pointer; font-weight: 600; padding: .6rem .8rem; background: #f6f8fa; border-radius: 6px; }
+details[open] summary { border-bottom: 1px solid #d1d9e0; border-radius: 6px 6px 0 0; }
+.body { padding: .6rem .8rem; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: .35rem .5rem; border-bottom: 1px solid #eaeef2; vertical-align: top; }
+td { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: .9rem; word-break: break-all; }
+.badge { display: inline-block; font-size: .75rem; font-weight: 600; padding: .1rem .45rem; border-radius: 1rem; margin-left: .4rem; text-transform: uppercase; }
+.pass { background: #dafbe1; color: #1a7f37; }
+.fail { background: #ffebe9; color: #cf222e; }
+.warn { background: #fff8c5; color: #9a6700; }
+.info { background: #ddf4ff; color: #0969da; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}{{with .Status}} <span class="badge {{.}}">{{.}}</span>{{end}}</h1>
+{{range .Summary}}<p class="meta">{{.}}</p>
+{{end}}{{if not .Generated.IsZero}}<p class="meta">Generated {{.Generated.Format "2006-01-02 15:04 MST"}}</p>
+{{end}}{{range .Sections}}<details{{if not .Collapsed}} open{{end}}>
+<summary>{{.Title}}{{with .Status}} <span class="badge {{.}}">{{.}}</span>{{end}}</summary>
+<div class="body">
+{{with .Note}}<p>{{.}}</p>
+{{end}}{{if .Rows}}<table>
+{{with .Columns}}<tr>{{range .}}<th>{{.}}</th>{{end}}</tr>
+{{end}}{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}{{with .Items}}<ul>
+{{range .}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}</div>
+</details>
+{{end}}</body>
+</html>
+`))
+
+// renderHTML writes a document as a standalone HTML page with one
+// collapsible section per Section. Text is escaped.
+func renderHTML(w io.Writer, doc *Document) error {
+	return htmlTemplate.Execute(w, doc)
+}
@@ -0,0 +1,105 @@
+// Package report renders the results of diff, validate and compliance as
+// documents for readers outside the terminal, such as an HTML page attached
+// to a change-management ticket.
+//
+// Commands describe their results as a Document and look up the renderer
+// of --format by name, so a new format only needs a Renderer registered
+// with Register. Documents hold what may be shown: commands mask sensitive
+// values before adding them.
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status of a document or section
+type Status string
+
+// Statuses
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	StatusWarn Status = "warn"
+	StatusInfo Status = "info"
+)
+
+// Document is a report: a title, a few summary lines and sections
+type Document struct {
+	Title     string
+	Summary   []string
+	Status    Status
+	Generated time.Time
+	Sections  []Section
+}
+
+// Section is a titled part of a document, holding a table, a list of items
+// or both. Renderers that can fold sections start Collapsed ones folded.
+type Section struct {
+	Title     string
+	Status    Status
+	Note      string
+	Collapsed bool
+	Columns   []string
+	Rows      [][]string
+	Items     []string
+}
+
+// Renderer writes a document in one format
+type Renderer interface {
+	Render(w io.Writer, doc *Document) error
+}
+
+// RendererFunc adapts a function to Renderer
+type RendererFunc func(w io.Writer, doc *Document) error
+
+// Render calls f
+func (f RendererFunc) Render(w io.Writer, doc *Document) error {
+	return f(w, doc)
+}
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = make(map[string]Renderer)
+)
+
+func init() {
+	Register("html", RendererFunc(renderHTML))
+}
+
+// Register makes a renderer available as --format name. It panics if the
+// name is taken, like other registries keyed at init time.
+func Register(name string, r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+
+	if _, ok := renderers[name]; ok {
+		panic(fmt.Sprintf("report: renderer %q registered twice", name))
+	}
+	renderers[name] = r
+}
+
+// Lookup returns the renderer registered as name
+func Lookup(name string) (Renderer, bool) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+
+	r, ok := renderers[name]
+	return r, ok
+}
+
+// Formats returns the names of the registered renderers, sorted
+func Formats() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
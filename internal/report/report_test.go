@@ -0,0 +1,51 @@
+package report
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	r, ok := Lookup("html")
+	require.True(t, ok)
+	assert.NotNil(t, r)
+	assert.Contains(t, Formats(), "html")
+
+	_, ok = Lookup("pdf")
+	assert.False(t, ok)
+
+	assert.Panics(t, func() { Register("html", RendererFunc(func(io.Writer, *Document) error { return nil })) })
+}
+
+func TestRenderHTML(t *testing.T) {
+	doc := &Document{
+		Title:     "Environment changes: dev → prod",
+		Summary:   []string{"1 added, 0 deleted"},
+		Status:    StatusWarn,
+		Generated: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+		Sections: []Section{
+			{Title: "Added", Columns: []string{"Key", "New"}, Rows: [][]string{{"API_KEY", "***"}, {"HTML", "<script>"}}},
+			{Title: "Fixes", Collapsed: true, Items: []string{"PORT: set default"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	r, _ := Lookup("html")
+	require.NoError(t, r.Render(&buf, doc))
+	out := buf.String()
+
+	assert.Contains(t, out, "<title>Environment changes: dev → prod</title>")
+	assert.Contains(t, out, `<span class="badge warn">warn</span>`)
+	assert.Contains(t, out, "Generated 2024-06-01 12:00 UTC")
+	assert.Contains(t, out, "<details open>\n<summary>Added</summary>")
+	assert.Contains(t, out, "<details>\n<summary>Fixes</summary>")
+	assert.Contains(t, out, "<td>***</td>")
+	assert.Contains(t, out, "&lt;script&gt;")
+	assert.NotContains(t, out, "<script>")
+	assert.Contains(t, out, "<li>PORT: set default</li>")
+}
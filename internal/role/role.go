@@ -0,0 +1,159 @@
+// Package role limits the commands a machine shows and runs to the command
+// set of its role, e.g. so a developer laptop cannot push to production by
+// accident. It is a guard rail on the local machine, not access control:
+// IAM decides what a principal may do in AWS.
+package role
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/drapon/envy/internal/config"
+)
+
+// EnvVar selects the role of a shell, overriding the saved role
+const EnvVar = "ENVY_ROLE"
+
+// alwaysAllowed are available in every role, so help can be read, shells
+// can complete commands and the role can be switched
+var alwaysAllowed = []string{"help", "completion", cobraComplete, cobraCompleteNoDesc, "version", "role"}
+
+// Hidden commands cobra runs for shell completion
+const (
+	cobraComplete       = "__complete"
+	cobraCompleteNoDesc = "__completeNoDesc"
+)
+
+// Path returns the file holding the role chosen with 'envy role use'
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the user config directory: %w", err)
+	}
+	return filepath.Join(dir, "envy", "role"), nil
+}
+
+// Saved returns the role chosen with 'envy role use', or "" if none is
+func Saved() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Save makes name the role of this machine. An empty name removes the
+// saved role.
+func Save(name string) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(name+"\n"), 0600)
+}
+
+// Active is the role in effect and where it was chosen
+type Active struct {
+	Name   string
+	Source string // ENVY_ROLE, the saved role file or default_role
+	Role   config.RoleConfig
+}
+
+// Current returns the role in effect: ENVY_ROLE, else the saved role, else
+// the project's default_role. It returns nil when no role applies, so
+// every command is available.
+func Current(roles map[string]config.RoleConfig, defaultRole string) (*Active, error) {
+	if len(roles) == 0 {
+		return nil, nil
+	}
+
+	name, source := os.Getenv(EnvVar), EnvVar
+	if name == "" {
+		saved, err := Saved()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the saved role: %w", err)
+		}
+		name, source = saved, "envy role use"
+	}
+	if name == "" {
+		name, source = defaultRole, "default_role"
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	role, ok := roles[name]
+	if !ok {
+		return nil, fmt.Errorf("role '%s' (from %s) is not defined; roles: %s", name, source, strings.Join(Names(roles), ", "))
+	}
+	return &Active{Name: name, Source: source, Role: role}, nil
+}
+
+// Names returns the names of roles, sorted
+func Names(roles map[string]config.RoleConfig) []string {
+	names := make([]string, 0, len(roles))
+	for name := range roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Allows reports whether the role may run the command at path, e.g.
+// "replicate status"
+func (a *Active) Allows(path string) bool {
+	if a == nil || path == "" {
+		return true
+	}
+	for _, name := range alwaysAllowed {
+		if within(path, name) {
+			return true
+		}
+	}
+	for _, command := range a.Role.Commands {
+		if command == "*" || within(path, command) {
+			return true
+		}
+	}
+	return false
+}
+
+// Shows reports whether the command at path is listed in help: it is
+// allowed, or it is the parent of an allowed command
+func (a *Active) Shows(path string) bool {
+	if a.Allows(path) {
+		return true
+	}
+	for _, command := range a.Role.Commands {
+		if within(command, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether path is command or one of its subcommands
+func within(path, command string) bool {
+	command = strings.Join(strings.Fields(command), " ")
+	return path == command || strings.HasPrefix(path, command+" ")
+}
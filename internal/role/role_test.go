@@ -0,0 +1,74 @@
+package role
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var roles = map[string]config.RoleConfig{
+	"developer": {Commands: []string{"pull", "run", "replicate status"}},
+	"operator":  {Commands: []string{"*"}},
+}
+
+func TestAllows(t *testing.T) {
+	dev := &Active{Name: "developer", Role: roles["developer"]}
+
+	assert.True(t, dev.Allows("pull"))
+	assert.True(t, dev.Allows("replicate status"))
+	assert.False(t, dev.Allows("push"))
+	assert.False(t, dev.Allows("replicate setup"))
+	assert.False(t, dev.Allows("pullall"), "a command is matched by whole words")
+	assert.True(t, dev.Allows("role use"), "the role can always be switched")
+	assert.True(t, dev.Allows("help"))
+
+	assert.True(t, dev.Shows("replicate"), "parents of allowed commands are listed")
+	assert.False(t, dev.Shows("push"))
+
+	ops := &Active{Name: "operator", Role: roles["operator"]}
+	assert.True(t, ops.Allows("replicate setup"))
+
+	var none *Active
+	assert.True(t, none.Allows("push"))
+}
+
+func TestCurrent(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(EnvVar, "")
+
+	active, err := Current(nil, "developer")
+	require.NoError(t, err)
+	assert.Nil(t, active, "without roles every command is available")
+
+	active, err = Current(roles, "")
+	require.NoError(t, err)
+	assert.Nil(t, active)
+
+	active, err = Current(roles, "developer")
+	require.NoError(t, err)
+	assert.Equal(t, "developer", active.Name)
+	assert.Equal(t, "default_role", active.Source)
+
+	require.NoError(t, Save("operator"))
+	active, err = Current(roles, "developer")
+	require.NoError(t, err)
+	assert.Equal(t, "operator", active.Name, "the saved role overrides default_role")
+
+	t.Setenv(EnvVar, "developer")
+	active, err = Current(roles, "")
+	require.NoError(t, err)
+	assert.Equal(t, EnvVar, active.Source, "ENVY_ROLE overrides the saved role")
+
+	t.Setenv(EnvVar, "auditor")
+	_, err = Current(roles, "")
+	assert.ErrorContains(t, err, "developer, operator")
+
+	t.Setenv(EnvVar, "")
+	require.NoError(t, Save(""))
+	saved, err := Saved()
+	require.NoError(t, err)
+	assert.Empty(t, saved)
+}
@@ -0,0 +1,149 @@
+// Package roster maps the owners named in the variables section of .envyrc
+// to contact details, so outputs can say how to reach them and
+// notifications can mention them. The roster is a YAML document kept in a
+// file or served by an HTTP endpoint, e.g.
+//
+//	owners:
+//	  payments-team:
+//	    name: Payments
+//	    email: payments@acme.com
+//	    chat: "#payments"
+//	    mention: "@payments-team"
+package roster
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/drapon/envy/internal/config"
+)
+
+// Contact is how to reach an owner
+type Contact struct {
+	Name    string `yaml:"name" json:"name,omitempty"`
+	Email   string `yaml:"email" json:"email,omitempty"`
+	Chat    string `yaml:"chat" json:"chat,omitempty"`       // channel, e.g. "#payments"
+	Mention string `yaml:"mention" json:"mention,omitempty"` // as notifications mention the owner, e.g. "@payments-team"
+}
+
+// Roster holds the contacts of owners. A nil roster has none.
+type Roster struct {
+	Owners map[string]Contact `yaml:"owners"`
+}
+
+// Load reads the roster of cfg. It returns nil without roster in .envyrc.
+func Load(ctx context.Context, cfg *config.Config) (*Roster, error) {
+	if cfg.Roster == nil {
+		return nil, nil
+	}
+	if cfg.Roster.File != "" {
+		data, err := os.ReadFile(cfg.Roster.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read roster: %w", err)
+		}
+		return Parse(data)
+	}
+	return fetch(ctx, cfg.Roster)
+}
+
+// fetch reads the roster from its HTTP endpoint
+func fetch(ctx context.Context, cfg *config.RosterConfig) (*Roster, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, os.ExpandEnv(cfg.URL), nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid roster url: %w", err)
+	}
+	req.Header.Set("Accept", "application/yaml, application/json")
+	for header, value := range cfg.Headers {
+		req.Header.Set(header, os.ExpandEnv(value))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch roster: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch roster: %s returned %s", req.URL.Redacted(), resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch roster: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse reads a roster document, in YAML or JSON
+func Parse(data []byte) (*Roster, error) {
+	var r Roster
+	if err := yaml.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse roster: %w", err)
+	}
+	return &r, nil
+}
+
+// Contact returns the contact details of owner
+func (r *Roster) Contact(owner string) (Contact, bool) {
+	if r == nil {
+		return Contact{}, false
+	}
+	contact, ok := r.Owners[owner]
+	return contact, ok
+}
+
+// Describe returns owner with its email and chat channel, such as
+// "payments-team <payments@acme.com> #payments"
+func (r *Roster) Describe(owner string) string {
+	contact, ok := r.Contact(owner)
+	if !ok {
+		return owner
+	}
+
+	parts := []string{owner}
+	if contact.Email != "" {
+		parts = append(parts, "<"+contact.Email+">")
+	}
+	if contact.Chat != "" {
+		parts = append(parts, contact.Chat)
+	}
+	return strings.Join(parts, " ")
+}
+
+// DescribeAll describes each of owners
+func (r *Roster) DescribeAll(owners []string) []string {
+	described := make([]string, len(owners))
+	for i, owner := range owners {
+		described[i] = r.Describe(owner)
+	}
+	return described
+}
+
+// Mentions returns how to mention each of owners: their mention in the
+// roster, else "@" and their name. Owners mentioned twice are listed once.
+func (r *Roster) Mentions(owners []string) []string {
+	seen := make(map[string]bool)
+	var mentions []string
+	for _, owner := range owners {
+		mention := "@" + owner
+		if contact, ok := r.Contact(owner); ok && contact.Mention != "" {
+			mention = contact.Mention
+		}
+		if !seen[mention] {
+			seen[mention] = true
+			mentions = append(mentions, mention)
+		}
+	}
+	sort.Strings(mentions)
+	return mentions
+}
@@ -0,0 +1,72 @@
+package roster
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/drapon/envy/internal/config"
+)
+
+const testRoster = `owners:
+  payments-team:
+    name: Payments
+    email: payments@acme.com
+    chat: "#payments"
+    mention: "@payments"
+  platform:
+    email: platform@acme.com
+`
+
+func TestLoad_File(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "teams.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(testRoster), 0644))
+
+	r, err := Load(context.Background(), &config.Config{Roster: &config.RosterConfig{File: file}})
+	require.NoError(t, err)
+
+	contact, ok := r.Contact("payments-team")
+	assert.True(t, ok)
+	assert.Equal(t, "Payments", contact.Name)
+	assert.Equal(t, "payments-team <payments@acme.com> #payments", r.Describe("payments-team"))
+	assert.Equal(t, "platform <platform@acme.com>", r.Describe("platform"))
+	assert.Equal(t, "security", r.Describe("security"))
+	assert.Equal(t, []string{"@payments", "@security"}, r.Mentions([]string{"security", "payments-team", "payments-team"}))
+
+	r, err = Load(context.Background(), &config.Config{})
+	require.NoError(t, err)
+	assert.Nil(t, r)
+	assert.Equal(t, "platform", r.Describe("platform"), "a nil roster has no contacts")
+	assert.Equal(t, []string{"@platform"}, r.Mentions([]string{"platform"}))
+}
+
+func TestLoad_URL(t *testing.T) {
+	t.Setenv("ROSTER_TOKEN", "s3cret")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"owners": {"platform": {"email": "platform@acme.com", "mention": "<!subteam^S0123>"}}}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{Roster: &config.RosterConfig{
+		URL:     server.URL,
+		Headers: map[string]string{"authorization": "Bearer ${ROSTER_TOKEN}"},
+	}}
+	r, err := Load(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"<!subteam^S0123>"}, r.Mentions([]string{"platform"}))
+
+	cfg.Roster.Headers = nil
+	_, err = Load(context.Background(), cfg)
+	assert.ErrorContains(t, err, "401")
+}
@@ -0,0 +1,164 @@
+// Package rotate generates new random values for secrets, following the
+// rotate settings of the variables section of .envyrc.
+package rotate
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"regexp"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/filter"
+	"github.com/drapon/envy/internal/validator"
+)
+
+const (
+	// DefaultLength and DefaultCharset apply to variables without settings
+	DefaultLength  = 32
+	DefaultCharset = "alnum"
+
+	// minLength keeps rotated secrets from being guessable
+	minLength = 8
+	maxLength = 4096
+
+	// patternAttempts bounds how often a pattern value is regenerated until
+	// it matches, e.g. when the pattern has lookalike alternatives
+	patternAttempts = 20
+)
+
+// charsets are the characters values are drawn from. ascii leaves out
+// quotes, spaces and characters that shells or .env files interpret.
+var charsets = map[string]string{
+	"alnum":     "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789",
+	"hex":       "0123456789abcdef",
+	"base64url": "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_",
+	"digits":    "0123456789",
+	"ascii":     "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!%*+,-./:;<=>?@^_~",
+}
+
+// Rule is how values are generated for keys matching Key
+type Rule struct {
+	Key     string
+	Length  int
+	Charset string
+	Pattern string
+
+	match *filter.Filter
+	value *regexp.Regexp
+}
+
+// ParseRules builds rules from the variables section of .envyrc, skipping
+// entries without rotate settings
+func ParseRules(variables []config.VariableConfig) ([]Rule, error) {
+	var rules []Rule
+	for _, v := range variables {
+		if v.Rotate == nil {
+			continue
+		}
+		if v.Key == "" {
+			return nil, fmt.Errorf("variables: rotate settings have no key")
+		}
+
+		rule, err := newRule(v.Key, *v.Rotate)
+		if err != nil {
+			return nil, fmt.Errorf("variables: rotate for %s: %w", v.Key, err)
+		}
+		if rule.match, err = filter.New(filter.Options{Include: []string{v.Key}}); err != nil {
+			return nil, fmt.Errorf("variables: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func newRule(key string, settings config.RotateConfig) (Rule, error) {
+	rule := Rule{Key: key, Length: settings.Length, Charset: settings.Charset, Pattern: settings.Pattern}
+
+	if rule.Pattern != "" {
+		if rule.Length != 0 || rule.Charset != "" {
+			return Rule{}, fmt.Errorf("pattern cannot be combined with length or charset")
+		}
+		value, err := regexp.Compile(`^(?:` + rule.Pattern + `)$`)
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid pattern: %w", err)
+		}
+		rule.value = value
+		return rule, nil
+	}
+
+	if rule.Length == 0 {
+		rule.Length = DefaultLength
+	}
+	if rule.Length < minLength || rule.Length > maxLength {
+		return Rule{}, fmt.Errorf("length must be between %d and %d", minLength, maxLength)
+	}
+	if rule.Charset == "" {
+		rule.Charset = DefaultCharset
+	}
+	if _, ok := charsets[rule.Charset]; !ok {
+		return Rule{}, fmt.Errorf("unknown charset %q (use alnum, hex, base64url, digits or ascii)", rule.Charset)
+	}
+	return rule, nil
+}
+
+// Find returns the first rule matching key, or the default of 32 random
+// alphanumeric characters
+func Find(rules []Rule, key string) Rule {
+	for _, rule := range rules {
+		if rule.match.Match(key) {
+			return rule
+		}
+	}
+	return Rule{Key: key, Length: DefaultLength, Charset: DefaultCharset}
+}
+
+// String describes the values the rule generates
+func (r Rule) String() string {
+	if r.Pattern != "" {
+		return "matching " + r.Pattern
+	}
+	return fmt.Sprintf("%d %s characters", r.Length, r.Charset)
+}
+
+// Generate creates a new random value
+func (r Rule) Generate() (string, error) {
+	random := rand.New(cryptoSource{})
+
+	if r.Pattern == "" {
+		chars := charsets[r.Charset]
+		out := make([]byte, r.Length)
+		for i := range out {
+			out[i] = chars[random.Intn(len(chars))]
+		}
+		return string(out), nil
+	}
+
+	for i := 0; i < patternAttempts; i++ {
+		value, err := validator.Match(r.Pattern, random)
+		if err != nil {
+			return "", err
+		}
+		if r.value.MatchString(value) {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("cannot generate values matching %s", r.Pattern)
+}
+
+// cryptoSource feeds math/rand from crypto/rand, so the regex and charset
+// generators produce unpredictable values
+type cryptoSource struct{}
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+func (s cryptoSource) Int63() int64 { return int64(s.Uint64() >> 1) }
+
+func (cryptoSource) Seed(int64) {}
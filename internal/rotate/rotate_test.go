@@ -0,0 +1,64 @@
+package rotate
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]config.VariableConfig{
+		{Key: "DB_IAM_TOKEN", Refresh: "10m"},
+		{Key: "*_API_KEY", Rotate: &config.RotateConfig{Length: 48, Charset: "hex"}},
+		{Key: "WEBHOOK_SECRET", Rotate: &config.RotateConfig{Pattern: "whsec_[A-Za-z0-9]{32}"}},
+		{Key: "PIN", Rotate: &config.RotateConfig{Charset: "digits"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+
+	assert.Equal(t, "48 hex characters", Find(rules, "STRIPE_API_KEY").String())
+	assert.Equal(t, "matching whsec_[A-Za-z0-9]{32}", Find(rules, "WEBHOOK_SECRET").String())
+	assert.Equal(t, "32 digits characters", Find(rules, "PIN").String())
+	assert.Equal(t, "32 alnum characters", Find(rules, "SESSION_SECRET").String())
+
+	invalid := []config.RotateConfig{
+		{Length: 4},
+		{Charset: "emoji"},
+		{Pattern: "[unclosed"},
+		{Pattern: "x{40}", Length: 40},
+	}
+	for _, settings := range invalid {
+		settings := settings
+		_, err := ParseRules([]config.VariableConfig{{Key: "A", Rotate: &settings}})
+		assert.Error(t, err, "%+v", settings)
+	}
+
+	_, err = ParseRules([]config.VariableConfig{{Rotate: &config.RotateConfig{}}})
+	assert.ErrorContains(t, err, "no key")
+}
+
+func TestGenerate(t *testing.T) {
+	rules, err := ParseRules([]config.VariableConfig{
+		{Key: "HEX", Rotate: &config.RotateConfig{Length: 40, Charset: "hex"}},
+		{Key: "WEBHOOK", Rotate: &config.RotateConfig{Pattern: "whsec_[A-Za-z0-9]{32}"}},
+	})
+	require.NoError(t, err)
+
+	value, err := Find(rules, "HEX").Generate()
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{40}$`), value)
+
+	value, err = Find(rules, "WEBHOOK").Generate()
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^whsec_[A-Za-z0-9]{32}$`), value)
+
+	first, err := Find(rules, "OTHER").Generate()
+	require.NoError(t, err)
+	second, err := Find(rules, "OTHER").Generate()
+	require.NoError(t, err)
+	assert.Len(t, first, DefaultLength)
+	assert.NotEqual(t, first, second)
+}
@@ -0,0 +1,225 @@
+package scan
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/drapon/envy/internal/validator"
+)
+
+// Rule identifiers
+const (
+	RuleAWSAccessKey = "aws-access-key-id"
+	RuleAWSSecretKey = "aws-secret-access-key"
+	RulePrivateKey   = "private-key"
+	RuleSensitiveVar = "sensitive-assignment"
+	RuleHighEntropy  = "high-entropy-string"
+	RuleEnvFile      = "env-file-not-ignored"
+)
+
+const (
+	// minSecretLength is the shortest value of a sensitive variable reported
+	minSecretLength = 8
+	// minEntropyLength is the shortest string checked for randomness
+	minEntropyLength = 20
+	// Entropy thresholds in bits per character. Random base64 of 20
+	// characters averages about 4; English-like identifiers stay under 3.5.
+	base64Entropy = 3.5
+	hexEntropy    = 3.0
+	// redactedPrefixLen is how much of a secret findings show
+	redactedPrefixLen = 4
+)
+
+// Rule describes what a rule finds
+type Rule struct {
+	ID          string
+	Severity    Severity
+	Description string
+}
+
+// Rules lists every rule, for reports that describe them
+var Rules = []Rule{
+	{RuleAWSAccessKey, SeverityError, "AWS access key ID"},
+	{RuleAWSSecretKey, SeverityError, "AWS secret access key"},
+	{RulePrivateKey, SeverityError, "Private key"},
+	{RuleSensitiveVar, SeverityError, "Value assigned to a variable whose name says it is secret"},
+	{RuleHighEntropy, SeverityWarning, "Random-looking string that may be a key or token"},
+	{RuleEnvFile, SeverityError, ".env file that git does not ignore"},
+}
+
+var (
+	awsAccessKeyPattern = regexp.MustCompile(`\b((?:AKIA|ASIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA)[A-Z0-9]{16})\b`)
+	awsSecretKeyPattern = regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*(?::=|=>|[:=])\s*["']?([A-Za-z0-9/+=]{40})\b`)
+	privateKeyPattern   = regexp.MustCompile(`-----BEGIN ((?:RSA|EC|DSA|OPENSSH|ENCRYPTED|PGP) )?PRIVATE KEY( BLOCK)?-----`)
+
+	// quotedAssignment matches name = "value" in most languages and
+	// configuration formats: =, :, :=, => and YAML or JSON keys
+	quotedAssignment = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_.-]*)["']?\s*(?::=|=>|[:=])\s*(?:"([^"]*)"|'([^']*)')`)
+	// envAssignment matches unquoted KEY=value lines of .env files
+	envAssignment = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*=\s*([^\s"'#]+)`)
+	// quotedString matches string literals that could be keys or tokens
+	quotedString = regexp.MustCompile(`["'\x60]([A-Za-z0-9+/=_\-]{20,})["'\x60]`)
+	hexString    = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+)
+
+// scanLine applies the rules to one line. envFile also matches unquoted
+// assignments.
+func scanLine(line string, isSensitive func(string) bool, envFile bool) []Finding {
+	var findings []Finding
+	add := func(rule, message string, column int, secret string) {
+		findings = append(findings, Finding{
+			Rule:     rule,
+			Severity: severityOf(rule),
+			Column:   column + 1,
+			Message:  message,
+			Match:    redact(secret),
+		})
+	}
+
+	for _, m := range awsAccessKeyPattern.FindAllStringSubmatchIndex(line, -1) {
+		key := line[m[2]:m[3]]
+		if _, sample := validator.Placeholder(key); !sample {
+			add(RuleAWSAccessKey, "AWS access key ID", m[2], key)
+		}
+	}
+	if m := awsSecretKeyPattern.FindStringSubmatchIndex(line); m != nil {
+		secret := line[m[2]:m[3]]
+		if _, sample := validator.Placeholder(secret); !sample {
+			add(RuleAWSSecretKey, "AWS secret access key", m[2], secret)
+		}
+	}
+	if m := privateKeyPattern.FindStringIndex(line); m != nil {
+		add(RulePrivateKey, "private key", m[0], "")
+	}
+	if len(findings) > 0 {
+		return findings
+	}
+
+	for _, m := range quotedAssignment.FindAllStringSubmatchIndex(line, -1) {
+		start, end := m[4], m[5]
+		if start == -1 {
+			start, end = m[6], m[7]
+		}
+		name := line[m[2]:m[3]]
+		if value := line[start:end]; secretAssignment(name, value, isSensitive) {
+			add(RuleSensitiveVar, "hard-coded value of "+name, start, value)
+		}
+	}
+	if envFile && len(findings) == 0 {
+		if m := envAssignment.FindStringSubmatchIndex(line); m != nil {
+			name, value := line[m[2]:m[3]], line[m[4]:m[5]]
+			if secretAssignment(name, value, isSensitive) {
+				add(RuleSensitiveVar, "value of "+name, m[4], value)
+			}
+		}
+	}
+	if len(findings) > 0 {
+		return findings
+	}
+
+	for _, m := range quotedString.FindAllStringSubmatchIndex(line, -1) {
+		if value := line[m[2]:m[3]]; highEntropy(value) {
+			add(RuleHighEntropy, "high-entropy string", m[2], value)
+		}
+	}
+	return findings
+}
+
+// secretAssignment reports whether value, assigned to name, looks like a
+// real secret: the name is sensitive and the value is neither a
+// placeholder nor a reference to where the secret really is
+func secretAssignment(name, value string, isSensitive func(string) bool) bool {
+	if len(value) < minSecretLength || !isSensitive(variableName(name)) {
+		return false
+	}
+	if _, placeholder := validator.Placeholder(value); placeholder {
+		return false
+	}
+	for _, ref := range []string{"${", "$(", "{{", "%(", "<%"} {
+		if strings.Contains(value, ref) {
+			return false
+		}
+	}
+	if strings.HasPrefix(value, "$") || strings.HasPrefix(value, "arn:") || strings.Contains(value, " ") {
+		return false
+	}
+	return strings.Trim(value, value[:1]) != ""
+}
+
+// variableName converts a name from code, such as dbPassword, db-password
+// or config.db.password, to the KEY_NAME form the sensitivity rules expect
+func variableName(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i != -1 {
+		name = name[i+1:]
+	}
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r == '-':
+			b.WriteByte('_')
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			b.WriteByte('_')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
+
+// highEntropy reports whether a string is random enough to be a key
+func highEntropy(s string) bool {
+	if len(s) < minEntropyLength {
+		return false
+	}
+	// Identifiers and paths are long but not random, and alphabets are
+	// not secrets
+	if !strings.ContainsAny(s, "0123456789") || strings.Contains(s, "abcdefgh") || strings.Contains(s, "ABCDEFGH") {
+		return false
+	}
+	if hexString.MatchString(s) {
+		return len(s) >= 32 && entropy(s) > hexEntropy
+	}
+	if strings.ToLower(s) == s || strings.ToUpper(s) == s {
+		return false
+	}
+	return entropy(s) > base64Entropy
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// redact keeps the first characters of a secret, enough to find it
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) <= redactedPrefixLen*2 {
+		return "****"
+	}
+	return secret[:redactedPrefixLen] + "****"
+}
+
+func severityOf(rule string) Severity {
+	for _, r := range Rules {
+		if r.ID == rule {
+			return r.Severity
+		}
+	}
+	return SeverityWarning
+}
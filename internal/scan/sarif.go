@@ -0,0 +1,108 @@
+package scan
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// SARIF 2.1.0 documents, as read by GitHub code scanning and other tools.
+// Only the properties envy writes are declared.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string             `json:"id"`
+	ShortDescription sarifMessage       `json:"shortDescription"`
+	DefaultConfig    sarifDefaultConfig `json:"defaultConfiguration"`
+}
+
+type sarifDefaultConfig struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// WriteSARIF writes the findings as a SARIF 2.1.0 log. version is the
+// version of envy reported as the tool's.
+func WriteSARIF(w io.Writer, result *Result, version string) error {
+	driver := sarifDriver{
+		Name:           "envy",
+		Version:        version,
+		InformationURI: "https://github.com/drapon/envy",
+	}
+	for _, rule := range Rules {
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:               rule.ID,
+			ShortDescription: sarifMessage{Text: rule.Description},
+			DefaultConfig:    sarifDefaultConfig{Level: string(rule.Severity)},
+		})
+	}
+
+	run := sarifRun{Tool: sarifTool{Driver: driver}, Results: []sarifResult{}}
+	for _, f := range result.Findings {
+		location := sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: f.File}}
+		if f.Line > 0 {
+			location.Region = &sarifRegion{StartLine: f.Line, StartColumn: f.Column}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    f.Rule,
+			Level:     string(f.Severity),
+			Message:   sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{PhysicalLocation: location}},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
@@ -0,0 +1,224 @@
+// Package scan looks for secrets committed to a source tree: credentials
+// and private keys written in code or configuration, high-entropy strings,
+// and .env files that git does not ignore.
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/drapon/envy/internal/sensitive"
+)
+
+// Severity is how serious a finding is
+type Severity string
+
+const (
+	// SeverityError marks findings that are almost certainly secrets
+	SeverityError Severity = "error"
+	// SeverityWarning marks findings that may be secrets
+	SeverityWarning Severity = "warning"
+)
+
+// AllowMarker on a line suppresses findings on that line
+const AllowMarker = "envy:allow"
+
+// DefaultMaxFileSize is the size above which files are not scanned
+const DefaultMaxFileSize = 1 << 20
+
+// Finding is a possible secret in the tree
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Message  string   `json:"message"`
+	// Match is the start of the secret, never the whole of it
+	Match string `json:"match,omitempty"`
+}
+
+// Options configure a scan
+type Options struct {
+	// IsSensitive classifies variable names; sensitive.IsSensitive when nil
+	IsSensitive func(string) bool
+	// Exclude lists glob patterns of paths, relative to the root, to skip
+	Exclude []string
+	// MaxFileSize skips larger files; DefaultMaxFileSize when zero
+	MaxFileSize int64
+}
+
+// Result is the outcome of a scan
+type Result struct {
+	Findings []Finding `json:"findings"`
+	Files    int       `json:"files"`
+	// Git is false when the root is not in a git work tree, so .env files
+	// could not be checked against .gitignore
+	Git bool `json:"git"`
+}
+
+// skippedDirs are never scanned outside of git
+var skippedDirs = map[string]bool{".git": true, "node_modules": true, "vendor": true, ".terraform": true}
+
+// skippedFiles hold hashes that look like secrets
+var skippedFiles = map[string]bool{
+	"go.sum": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"Cargo.lock": true, "Gemfile.lock": true, "poetry.lock": true, "composer.lock": true,
+}
+
+// Dir scans the tree under root. In a git work tree the files git tracks
+// or would track are scanned, so ignored files are left alone; elsewhere
+// every file is.
+func Dir(root string, opts Options) (*Result, error) {
+	if opts.IsSensitive == nil {
+		opts.IsSensitive = sensitive.IsSensitive
+	}
+	if opts.MaxFileSize == 0 {
+		opts.MaxFileSize = DefaultMaxFileSize
+	}
+
+	files, git, err := listFiles(root)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Git: git}
+	for _, file := range files {
+		if skippedFiles[path.Base(file)] || excluded(file, opts.Exclude) {
+			continue
+		}
+		if git && isEnvFile(file) {
+			result.Findings = append(result.Findings, Finding{
+				Rule:     RuleEnvFile,
+				Severity: SeverityError,
+				File:     file,
+				Message:  fmt.Sprintf("%s is not ignored by git and may be committed; add it to .gitignore", file),
+			})
+		}
+
+		full := filepath.Join(root, filepath.FromSlash(file))
+		info, err := os.Stat(full)
+		if err != nil || !info.Mode().IsRegular() || info.Size() > opts.MaxFileSize {
+			continue
+		}
+		content, err := os.ReadFile(full)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		result.Files++
+		result.Findings = append(result.Findings, File(file, content, opts.IsSensitive)...)
+	}
+
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		a, b := result.Findings[i], result.Findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return result, nil
+}
+
+// File scans the content of one file, named name in the findings. Binary
+// files are skipped.
+func File(name string, content []byte, isSensitive func(string) bool) []Finding {
+	if bytes.IndexByte(content[:min(len(content), 8000)], 0) != -1 {
+		return nil
+	}
+
+	var findings []Finding
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), DefaultMaxFileSize)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if strings.Contains(line, AllowMarker) {
+			continue
+		}
+		for _, f := range scanLine(line, isSensitive, isEnvFile(name)) {
+			f.File = name
+			f.Line = lineNum
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// isEnvFile reports whether a file is a .env file holding real values, as
+// opposed to a committed template such as .env.example
+func isEnvFile(file string) bool {
+	base := path.Base(file)
+	if base != ".env" && !strings.HasPrefix(base, ".env.") {
+		return false
+	}
+	for _, template := range []string{".example", ".sample", ".template", ".dist", ".defaults"} {
+		if strings.HasSuffix(base, template) {
+			return false
+		}
+	}
+	return true
+}
+
+// excluded reports whether file matches one of the exclude patterns, as a
+// whole or by any of its parent directories
+func excluded(file string, patterns []string) bool {
+	for _, pattern := range patterns {
+		for p := file; p != "." && p != "/"; p = path.Dir(p) {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+			if ok, _ := path.Match(pattern, path.Base(p)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// listFiles returns the files under root relative to it, with slashes.
+// In a git work tree these are the tracked files and the untracked ones
+// that are not ignored.
+func listFiles(root string) ([]string, bool, error) {
+	cmd := exec.Command("git", "-C", root, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if out, err := cmd.Output(); err == nil {
+		var files []string
+		seen := make(map[string]bool)
+		for _, file := range strings.Split(string(out), "\x00") {
+			if file != "" && !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+		return files, true, nil
+	}
+
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list files: %w", err)
+	}
+	return files, false, nil
+}
@@ -0,0 +1,168 @@
+// Package schedule delays the application of change sets, leaving a window
+// in which a risky change can be cancelled before it reaches AWS.
+package schedule
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Plan is a change set for one environment: variables to write and keys
+// to delete
+type Plan struct {
+	Environment string            `json:"environment"`
+	Set         map[string]string `json:"set,omitempty"`
+	Delete      []string          `json:"delete,omitempty"`
+	// Force records push --force, for the policies of .envyrc checked
+	// again when the plan is applied
+	Force bool `json:"force,omitempty"`
+}
+
+// LoadPlan reads a plan from a JSON file
+func LoadPlan(filename string) (*Plan, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %w", filename, err)
+	}
+	if plan.Environment == "" {
+		return nil, fmt.Errorf("plan %s has no environment", filename)
+	}
+	if len(plan.Set) == 0 && len(plan.Delete) == 0 {
+		return nil, fmt.Errorf("plan %s has no changes", filename)
+	}
+	for _, key := range plan.Delete {
+		if _, ok := plan.Set[key]; ok {
+			return nil, fmt.Errorf("plan %s both sets and deletes %s", filename, key)
+		}
+	}
+	return &plan, nil
+}
+
+// Save writes the plan to a JSON file readable only by the owner, since it
+// holds values
+func (p *Plan) Save(filename string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %w", err)
+	}
+	if err := os.WriteFile(filename, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
+
+// Keys returns the keys the plan changes, sorted
+func (p *Plan) Keys() []string {
+	keys := make([]string, 0, len(p.Set)+len(p.Delete))
+	for key := range p.Set {
+		keys = append(keys, key)
+	}
+	keys = append(keys, p.Delete...)
+	sort.Strings(keys)
+	return keys
+}
+
+// Entry is a plan waiting to be applied
+type Entry struct {
+	ID        string    `json:"id"`
+	Plan      Plan      `json:"plan"`
+	Scheduled time.Time `json:"scheduled"`
+	Due       time.Time `json:"due"`
+	By        string    `json:"by,omitempty"`
+}
+
+// IsDue reports whether the entry's delay has passed at now
+func (e *Entry) IsDue(now time.Time) bool {
+	return !now.Before(e.Due)
+}
+
+// Store keeps scheduled entries until they are applied or cancelled.
+// Deleting an entry cancels it.
+type Store interface {
+	Put(ctx context.Context, entry *Entry) error
+	Get(ctx context.Context, id string) (*Entry, bool, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]*Entry, error)
+}
+
+// New schedules a plan to be applied after delay
+func New(ctx context.Context, store Store, plan *Plan, delay time.Duration, by string, now time.Time) (*Entry, error) {
+	id, err := newID(now)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{
+		ID:        id,
+		Plan:      *plan,
+		Scheduled: now.UTC(),
+		Due:       now.Add(delay).UTC(),
+		By:        by,
+	}
+	if err := store.Put(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to schedule plan: %w", err)
+	}
+	return entry, nil
+}
+
+// Wait blocks until the entry is due, checking every poll interval whether
+// it was cancelled. It returns the entry as stored when it became due, or
+// false if it was cancelled in the meantime.
+func Wait(ctx context.Context, store Store, id string, poll time.Duration, now func() time.Time) (*Entry, bool, error) {
+	for {
+		entry, ok, err := store.Get(ctx, id)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		remaining := entry.Due.Sub(now())
+		if remaining <= 0 {
+			return entry, true, nil
+		}
+		if remaining > poll {
+			remaining = poll
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		case <-time.After(remaining):
+		}
+	}
+}
+
+// Due returns the stored entries whose delay has passed, oldest first
+func Due(ctx context.Context, store Store, now time.Time) ([]*Entry, error) {
+	entries, err := store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var due []*Entry
+	for _, entry := range entries {
+		if entry.IsDue(now) {
+			due = append(due, entry)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].Due.Before(due[j].Due) })
+	return due, nil
+}
+
+// newID returns a short, time-ordered identifier
+func newID(now time.Time) (string, error) {
+	random := make([]byte, 3)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate schedule ID: %w", err)
+	}
+	return now.UTC().Format("20060102-150405") + "-" + hex.EncodeToString(random), nil
+}
@@ -0,0 +1,97 @@
+package schedule
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan_SaveLoad(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "plan.json")
+	plan := &Plan{Environment: "prod", Set: map[string]string{"B": "2", "A": "1"}, Delete: []string{"OLD"}}
+	require.NoError(t, plan.Save(filename))
+
+	info, err := os.Stat(filename)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := LoadPlan(filename)
+	require.NoError(t, err)
+	assert.Equal(t, plan, loaded)
+	assert.Equal(t, []string{"A", "B", "OLD"}, loaded.Keys())
+}
+
+func TestLoadPlan_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"no environment": `{"set": {"A": "1"}}`,
+		"no changes":     `{"environment": "prod"}`,
+		"conflict":       `{"environment": "prod", "set": {"A": "1"}, "delete": ["A"]}`,
+		"not json":       `environment: prod`,
+	} {
+		filename := filepath.Join(dir, "plan.json")
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0600))
+		_, err := LoadPlan(filename)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestDirStore(t *testing.T) {
+	ctx := context.Background()
+	store := &DirStore{Dir: filepath.Join(t.TempDir(), "scheduled")}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	plan := &Plan{Environment: "prod", Set: map[string]string{"A": "1"}}
+
+	first, err := New(ctx, store, plan, 30*time.Minute, "alice", now)
+	require.NoError(t, err)
+	second, err := New(ctx, store, plan, 5*time.Minute, "bob", now)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.Equal(t, now.Add(30*time.Minute), first.Due)
+
+	entry, ok, err := store.Get(ctx, first.ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "alice", entry.By)
+	assert.Equal(t, "1", entry.Plan.Set["A"])
+
+	due, err := Due(ctx, store, now.Add(10*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, second.ID, due[0].ID)
+
+	require.NoError(t, store.Delete(ctx, second.ID))
+	assert.Error(t, store.Delete(ctx, second.ID), "already cancelled")
+	entries, err := store.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestWait(t *testing.T) {
+	ctx := context.Background()
+	store := &DirStore{Dir: t.TempDir()}
+	plan := &Plan{Environment: "prod", Set: map[string]string{"A": "1"}}
+
+	entry, err := New(ctx, store, plan, 20*time.Millisecond, "", time.Now())
+	require.NoError(t, err)
+	due, ok, err := Wait(ctx, store, entry.ID, 5*time.Millisecond, time.Now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, entry.ID, due.ID)
+
+	// Cancelled while waiting
+	entry, err = New(ctx, store, plan, time.Hour, "", time.Now())
+	require.NoError(t, err)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = store.Delete(ctx, entry.ID)
+	}()
+	_, ok, err = Wait(ctx, store, entry.ID, 5*time.Millisecond, time.Now)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
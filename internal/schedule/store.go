@@ -0,0 +1,82 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultDir is where DirStore keeps entries, relative to the project
+const DefaultDir = ".envy/scheduled"
+
+// DirStore keeps entries as files in a directory, so they can only be
+// cancelled from the same machine
+type DirStore struct {
+	Dir string
+}
+
+// Put writes an entry, readable only by the owner since plans hold values
+func (s *DirStore) Put(ctx context.Context, entry *Entry) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(entry.ID), data, 0600)
+}
+
+// Get reads an entry. The boolean is false when it does not exist.
+func (s *DirStore) Get(ctx context.Context, id string) (*Entry, bool, error) {
+	data, err := os.ReadFile(s.path(id))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false, fmt.Errorf("failed to parse scheduled entry %s: %w", id, err)
+	}
+	return &entry, true, nil
+}
+
+// Delete removes an entry. Deleting a missing entry is an error, so that
+// a cancel or claim that lost a race is noticed.
+func (s *DirStore) Delete(ctx context.Context, id string) error {
+	err := os.Remove(s.path(id))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no scheduled change %s", id)
+	}
+	return err
+}
+
+// List returns every stored entry
+func (s *DirStore) List(ctx context.Context) ([]*Entry, error) {
+	files, err := filepath.Glob(filepath.Join(s.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for _, file := range files {
+		entry, ok, err := s.Get(ctx, strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (s *DirStore) path(id string) string {
+	return filepath.Join(s.Dir, filepath.Base(id)+".json")
+}
@@ -0,0 +1,102 @@
+// Package sensitive decides which variables hold secrets. Every command
+// uses it to mask values, choose SecureString parameters and redact
+// exports, so patterns configured once in .envyrc apply everywhere:
+//
+//	sensitive_patterns:   # added to the built-in rules
+//	  - "*_DSN"
+//	sensitivity:
+//	  sensitive: [SESSION_SALT]   # always sensitive
+//	  plain: ["*_PUBLIC_KEY"]     # never sensitive
+//
+// Patterns use the syntax of the pattern package.
+package sensitive
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/pattern"
+)
+
+var (
+	// exactMatches are sensitive names (case-insensitive)
+	exactMatches = []string{
+		"password", "passwd", "pwd", "secret", "token",
+		"api_key", "apikey", "access_key", "accesskey",
+		"private_key", "privatekey", "auth_token", "authtoken",
+	}
+
+	// suffixPatterns are endings of sensitive names
+	suffixPatterns = []string{
+		"_password", "_passwd", "_pwd", "_secret", "_token",
+		"_key", "_auth", "_credential", "_private",
+	}
+
+	// prefixPatterns are beginnings of sensitive names
+	prefixPatterns = []string{
+		"secret_", "private_", "auth_",
+	}
+)
+
+// IsSensitive applies the built-in rules, without the patterns of .envyrc
+func IsSensitive(key string) bool {
+	keyLower := strings.ToLower(key)
+
+	for _, pattern := range exactMatches {
+		if keyLower == pattern {
+			return true
+		}
+	}
+
+	for _, pattern := range suffixPatterns {
+		if strings.HasSuffix(keyLower, pattern) {
+			return true
+		}
+	}
+
+	for _, pattern := range prefixPatterns {
+		if strings.HasPrefix(keyLower, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// New returns a classifier that applies per-key overrides before falling
+// back to IsSensitive. Sensitive wins if a key matches both lists.
+func New(sensitive, plain []string) (func(string) bool, error) {
+	sensitiveMatchers, err := pattern.CompileAll(sensitive)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sensitive pattern: %w", err)
+	}
+	plainMatchers, err := pattern.CompileAll(plain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plain pattern: %w", err)
+	}
+
+	if len(sensitiveMatchers) == 0 && len(plainMatchers) == 0 {
+		return IsSensitive, nil
+	}
+
+	return func(key string) bool {
+		if pattern.MatchAny(sensitiveMatchers, key) {
+			return true
+		}
+		if pattern.MatchAny(plainMatchers, key) {
+			return false
+		}
+		return IsSensitive(key)
+	}, nil
+}
+
+// FromConfig returns the classifier configured in .envyrc: the built-in
+// rules, sensitive_patterns and the sensitivity overrides
+func FromConfig(cfg *config.Config) (func(string) bool, error) {
+	if cfg == nil {
+		return IsSensitive, nil
+	}
+	patterns := append(append([]string(nil), cfg.SensitivePatterns...), cfg.Sensitivity.Sensitive...)
+	return New(patterns, cfg.Sensitivity.Plain)
+}
@@ -0,0 +1,51 @@
+package sensitive
+
+import (
+	"testing"
+
+	"github.com/drapon/envy/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSensitive(t *testing.T) {
+	for _, key := range []string{"PASSWORD", "DB_PASSWORD", "api_key", "SECRET_VALUE", "AUTH_HEADER", "GITHUB_TOKEN"} {
+		assert.True(t, IsSensitive(key), key)
+	}
+	for _, key := range []string{"PORT", "DATABASE_URL", "KEYBOARD_LAYOUT", "APP_NAME"} {
+		assert.False(t, IsSensitive(key), key)
+	}
+}
+
+func TestNew(t *testing.T) {
+	classify, err := New([]string{"SESSION_SALT"}, []string{"*_PUBLIC_KEY", "SESSION_SALT"})
+	require.NoError(t, err)
+
+	assert.True(t, classify("SESSION_SALT"), "sensitive override wins over plain")
+	assert.False(t, classify("STRIPE_PUBLIC_KEY"))
+	assert.True(t, classify("STRIPE_SECRET_KEY"))
+	assert.False(t, classify("APP_NAME"))
+
+	_, err = New([]string{"/(bad/"}, nil)
+	assert.Error(t, err)
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := &config.Config{
+		SensitivePatterns: []string{"*_DSN", "re:^STRIPE_"},
+		Sensitivity:       config.SensitivityConfig{Plain: []string{"STRIPE_PUBLISHABLE_KEY"}},
+	}
+	classify, err := FromConfig(cfg)
+	require.NoError(t, err)
+
+	assert.True(t, classify("SENTRY_DSN"))
+	assert.True(t, classify("STRIPE_ACCOUNT"))
+	assert.True(t, classify("STRIPE_PUBLISHABLE_KEY"), "a configured pattern wins over plain")
+	assert.True(t, classify("DB_PASSWORD"))
+	assert.False(t, classify("PORT"))
+	assert.Empty(t, cfg.Sensitivity.Sensitive, "the configuration is not modified")
+
+	classify, err = FromConfig(nil)
+	require.NoError(t, err)
+	assert.True(t, classify("API_TOKEN"))
+}
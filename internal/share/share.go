@@ -0,0 +1,172 @@
+// Package share hands a single secret to another person through a
+// short-lived Secrets Manager secret. The value is encrypted with a random
+// key that only travels in the share token, so reading the secret alone
+// does not reveal it, and the receiver deletes the secret on the first read.
+package share
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/drapon/envy/internal/envelope"
+)
+
+const (
+	// Prefix is the name prefix of the secrets holding shares
+	Prefix = "envy/share/"
+	// ExpiresTag is the tag holding when a share expires, for prune
+	ExpiresTag = "envy:share-expires-at"
+	// MaxTTL is the longest a share can be kept
+	MaxTTL = 7 * 24 * time.Hour
+	// FormatVersion is the version of the share envelope
+	FormatVersion = 1
+)
+
+var (
+	// ErrExpired is returned when a share is opened after its expiry
+	ErrExpired = errors.New("share has expired")
+	// ErrToken is returned for malformed share tokens
+	ErrToken = errors.New("invalid share token")
+)
+
+// Token is what the receiver of a share needs to read it: where the share
+// is and the key its value is encrypted with
+type Token struct {
+	Region string
+	ID     string
+	Key    []byte
+}
+
+// String encodes the token as <region>.<id>.<key>
+func (t Token) String() string {
+	return t.Region + "." + t.ID + "." + base64.RawURLEncoding.EncodeToString(t.Key)
+}
+
+// SecretName returns the name of the secret holding the share
+func (t Token) SecretName() string {
+	return Prefix + t.ID
+}
+
+// ParseToken decodes a token written by Token.String
+func ParseToken(s string) (Token, error) {
+	parts := strings.Split(strings.TrimSpace(s), ".")
+	if len(parts) != 3 || parts[0] == "" {
+		return Token{}, ErrToken
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil || len(parts[1]) != 24 {
+		return Token{}, ErrToken
+	}
+	key, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(key) != envelope.KeySize {
+		return Token{}, ErrToken
+	}
+	return Token{Region: parts[0], ID: parts[1], Key: key}, nil
+}
+
+// Metadata describes a shared value. It is stored in clear so expired
+// shares can be recognized without their key, but authenticated with the
+// value.
+type Metadata struct {
+	Variable    string    `json:"variable"`
+	Environment string    `json:"environment"`
+	SharedBy    string    `json:"shared_by,omitempty"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Envelope is the content of a share secret
+type Envelope struct {
+	Version    int      `json:"version"`
+	Metadata   Metadata `json:"metadata"`
+	Ciphertext []byte   `json:"ciphertext"` // sealed with the token's key
+}
+
+// Seal encrypts value with a new random key and returns the envelope to
+// store and the token to give the receiver
+func Seal(region string, meta Metadata, value string) (*Envelope, Token, error) {
+	id := make([]byte, 12)
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		return nil, Token{}, fmt.Errorf("failed to generate share ID: %w", err)
+	}
+	key, err := envelope.NewDataKey()
+	if err != nil {
+		return nil, Token{}, err
+	}
+	token := Token{Region: region, ID: hex.EncodeToString(id), Key: key}
+
+	e := &Envelope{Version: FormatVersion, Metadata: meta}
+	aad, err := e.additionalData(token)
+	if err != nil {
+		return nil, Token{}, err
+	}
+	if e.Ciphertext, err = envelope.SealWithData(key, []byte(value), aad); err != nil {
+		return nil, Token{}, err
+	}
+	return e, token, nil
+}
+
+// Open decrypts the value of a share that has not expired
+func (e *Envelope) Open(token Token, now time.Time) (string, error) {
+	if e.Version != FormatVersion {
+		return "", fmt.Errorf("unsupported share version %d", e.Version)
+	}
+	if e.Expired(now) {
+		return "", fmt.Errorf("share of %s expired at %s: %w", e.Metadata.Variable, e.Metadata.ExpiresAt.Format(time.RFC3339), ErrExpired)
+	}
+
+	aad, err := e.additionalData(token)
+	if err != nil {
+		return "", err
+	}
+	value, err := envelope.OpenWithData(token.Key, e.Ciphertext, aad)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt share (wrong token?): %w", err)
+	}
+	return string(value), nil
+}
+
+// Expired reports whether the share has expired at now
+func (e *Envelope) Expired(now time.Time) bool {
+	return !now.Before(e.Metadata.ExpiresAt)
+}
+
+// Marshal encodes the envelope for storage
+func (e *Envelope) Marshal() (string, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode share: %w", err)
+	}
+	return string(data), nil
+}
+
+// ParseEnvelope decodes a stored envelope
+func ParseEnvelope(s string) (*Envelope, error) {
+	var e Envelope
+	if err := json.Unmarshal([]byte(s), &e); err != nil {
+		return nil, fmt.Errorf("secret is not an envy share: %w", err)
+	}
+	return &e, nil
+}
+
+// ExpiredTags reports whether the tags of a share secret say it has expired
+// at now. Secrets without a readable expiry are not considered expired.
+func ExpiredTags(tags map[string]string, now time.Time) bool {
+	expires, err := time.Parse(time.RFC3339, tags[ExpiresTag])
+	return err == nil && !now.Before(expires)
+}
+
+// additionalData binds the metadata and the share's ID to the ciphertext,
+// so neither can be changed without breaking decryption
+func (e *Envelope) additionalData(token Token) ([]byte, error) {
+	meta, err := json.Marshal(e.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode share metadata: %w", err)
+	}
+	return append([]byte(token.ID+"\n"), meta...), nil
+}
@@ -0,0 +1,60 @@
+package share
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	meta := Metadata{Variable: "API_KEY", Environment: "staging", ExpiresAt: now.Add(10 * time.Minute)}
+
+	e, token, err := Seal("eu-west-1", meta, "sk_live_123")
+	require.NoError(t, err)
+	assert.Equal(t, Prefix+token.ID, token.SecretName())
+
+	stored, err := e.Marshal()
+	require.NoError(t, err)
+	assert.NotContains(t, stored, "sk_live_123")
+
+	parsed, err := ParseToken(token.String())
+	require.NoError(t, err)
+	assert.Equal(t, token, parsed)
+
+	read, err := ParseEnvelope(stored)
+	require.NoError(t, err)
+	value, err := read.Open(parsed, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "sk_live_123", value)
+
+	_, err = read.Open(parsed, now.Add(10*time.Minute))
+	assert.ErrorIs(t, err, ErrExpired)
+
+	// Extending the expiry breaks decryption
+	read.Metadata.ExpiresAt = now.Add(time.Hour)
+	_, err = read.Open(parsed, now.Add(30*time.Minute))
+	assert.Error(t, err)
+
+	// So does another share's key
+	_, other, err := Seal("eu-west-1", meta, "x")
+	require.NoError(t, err)
+	_, err = e.Open(other, now)
+	assert.Error(t, err)
+}
+
+func TestParseToken(t *testing.T) {
+	for _, s := range []string{"", "eu-west-1", "eu-west-1.abc.key", ".0123456789abcdef01234567.AAAA", "eu-west-1.0123456789abcdef01234567.short"} {
+		_, err := ParseToken(s)
+		assert.ErrorIs(t, err, ErrToken, s)
+	}
+}
+
+func TestExpiredTags(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.True(t, ExpiredTags(map[string]string{ExpiresTag: "2026-03-01T11:59:00Z"}, now))
+	assert.False(t, ExpiredTags(map[string]string{ExpiresTag: "2026-03-01T12:10:00Z"}, now))
+	assert.False(t, ExpiredTags(nil, now))
+}
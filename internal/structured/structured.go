@@ -0,0 +1,136 @@
+// Package structured selects the output of commands for scripts. The
+// global --output flag, or ENVY_OUTPUT, asks commands for a JSON or YAML
+// document in place of the text they print for people.
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is an output format selected with --output
+type Format string
+
+// Output formats
+const (
+	Default Format = ""      // the command's own output, as --format selects
+	JSON    Format = "json"  // a JSON document
+	YAML    Format = "yaml"  // the same document in YAML
+	Table   Format = "table" // the text output, for people
+)
+
+// Parse returns the format named s; the empty string is the default
+func Parse(s string) (Format, error) {
+	switch f := Format(s); f {
+	case Default, JSON, YAML, Table:
+		return f, nil
+	}
+	return Default, fmt.Errorf("unsupported output format: %s (use json, yaml or table)", s)
+}
+
+// IsFormat reports whether s names a format. Commands whose own --output
+// flag is a file take such values as the format, so "envy pull --output
+// json" is not written to a file named json; use ./json for that.
+func IsFormat(s string) bool {
+	f, err := Parse(s)
+	return err == nil && f != Default
+}
+
+// FromCommand returns the format selected for cmd: the value of its
+// --output flag when that names a format, else the global setting.
+func FromCommand(cmd *cobra.Command) (Format, error) {
+	if flag := cmd.Flags().Lookup("output"); flag != nil && flag.Changed && IsFormat(flag.Value.String()) {
+		return Format(flag.Value.String()), nil
+	}
+	return Parse(viper.GetString("output"))
+}
+
+// ResolveFormat returns the value of cmd's --format flag to use: the one
+// given on the command line, else the format selected with --output under
+// the name commands give it (text for table), else the flag's default.
+func ResolveFormat(cmd *cobra.Command) (string, error) {
+	flag := cmd.Flags().Lookup("format")
+	if flag == nil {
+		return "", fmt.Errorf("%s has no --format flag", cmd.CommandPath())
+	}
+	f, err := FromCommand(cmd)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case flag.Changed || f == Default:
+		return flag.Value.String(), nil
+	case f == Table:
+		return "text", nil
+	}
+	return string(f), nil
+}
+
+// IsDocument reports whether f is written as a document rather than text
+func (f Format) IsDocument() bool {
+	return f == JSON || f == YAML
+}
+
+// Write encodes v to w as indented JSON or as YAML. YAML is converted from
+// the JSON encoding, so fields keep the names and order of their json tags
+// in both formats.
+func Write(w io.Writer, f Format, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	switch f {
+	case JSON:
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case YAML:
+		return writeYAML(w, data)
+	}
+	return fmt.Errorf("%s output is not a document", f)
+}
+
+// writeYAML re-encodes a JSON document as block-style YAML
+func writeYAML(w io.Writer, data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	plain(&doc)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// plain drops the flow style and quotes of the JSON syntax; the encoder
+// quotes the strings that need it
+func plain(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		plain(child)
+	}
+}
+
+// KeepStdout sends everything else a command prints to stderr, so stdout
+// carries only its document. It returns the real stdout, for the
+// document, and a function restoring it.
+func KeepStdout() (io.Writer, func()) {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	return stdout, func() { os.Stdout = stdout }
+}
@@ -0,0 +1,125 @@
+package structured
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type document struct {
+	Name    string            `json:"name"`
+	Count   int               `json:"count"`
+	Flags   []string          `json:"flags"`
+	Values  map[string]string `json:"values,omitempty"`
+	Missing string            `json:"missing,omitempty"`
+}
+
+func TestParse(t *testing.T) {
+	for _, s := range []string{"", "json", "yaml", "table"} {
+		f, err := Parse(s)
+		require.NoError(t, err)
+		assert.Equal(t, Format(s), f)
+	}
+	_, err := Parse("xml")
+	assert.ErrorContains(t, err, "use json, yaml or table")
+
+	assert.True(t, IsFormat("yaml"))
+	assert.False(t, IsFormat(""))
+	assert.False(t, IsFormat(".env.prod"))
+}
+
+func TestWrite(t *testing.T) {
+	doc := document{Name: "prod", Count: 2, Flags: []string{"a"}, Values: map[string]string{"DEBUG": "true", "PORT": "8080", "URL": "http://x:1"}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, JSON, doc))
+	assert.JSONEq(t, `{"name":"prod","count":2,"flags":["a"],"values":{"DEBUG":"true","PORT":"8080","URL":"http://x:1"}}`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, YAML, doc))
+	assert.Equal(t, `name: prod
+count: 2
+flags:
+  - a
+values:
+  DEBUG: "true"
+  PORT: "8080"
+  URL: http://x:1
+`, buf.String())
+
+	var decoded document
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, "true", decoded.Values["DEBUG"], "strings stay strings")
+
+	assert.Error(t, Write(&buf, Table, doc))
+}
+
+func TestFromCommand(t *testing.T) {
+	defer viper.Reset()
+
+	cmd := &cobra.Command{Use: "pull"}
+	cmd.Flags().StringP("output", "o", "", "Output file path")
+
+	f, err := FromCommand(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, Default, f)
+
+	viper.Set("output", "yaml")
+	f, err = FromCommand(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, YAML, f)
+
+	// A file name falls back to the global setting
+	require.NoError(t, cmd.Flags().Set("output", ".env.prod"))
+	f, err = FromCommand(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, YAML, f)
+
+	require.NoError(t, cmd.Flags().Set("output", "json"))
+	f, err = FromCommand(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, JSON, f)
+
+	viper.Set("output", "xml")
+	_, err = FromCommand(&cobra.Command{Use: "list"})
+	assert.Error(t, err)
+}
+
+func TestResolveFormat(t *testing.T) {
+	defer viper.Reset()
+
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{Use: "list"}
+		cmd.Flags().String("format", "text", "Output format")
+		return cmd
+	}
+
+	format, err := ResolveFormat(newCmd())
+	require.NoError(t, err)
+	assert.Equal(t, "text", format)
+
+	viper.Set("output", "json")
+	format, err = ResolveFormat(newCmd())
+	require.NoError(t, err)
+	assert.Equal(t, "json", format)
+
+	viper.Set("output", "table")
+	format, err = ResolveFormat(newCmd())
+	require.NoError(t, err)
+	assert.Equal(t, "text", format)
+
+	// --format on the command line wins
+	cmd := newCmd()
+	require.NoError(t, cmd.Flags().Set("format", "tree"))
+	format, err = ResolveFormat(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, "tree", format)
+
+	_, err = ResolveFormat(&cobra.Command{Use: "push"})
+	assert.Error(t, err)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/drapon/envy/internal/chaos"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -445,6 +446,7 @@ func getSecretId(secretId, versionId, versionStage *string) string {
 // MockErrorInjector allows injecting errors for testing error handling
 type MockErrorInjector struct {
 	errors map[string]error
+	chaos  *chaos.Injector
 	mu     sync.RWMutex
 }
 
@@ -463,12 +465,25 @@ func (m *MockErrorInjector) InjectError(operation string, err error) {
 	m.errors[operation] = err
 }
 
-// GetError returns the injected error for an operation
+// SetChaos makes operations without an injected error fail at random, the
+// same way --chaos does against real AWS. Passing nil disables it.
+func (m *MockErrorInjector) SetChaos(injector *chaos.Injector) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.chaos = injector
+}
+
+// GetError returns the injected error for an operation, falling back to a
+// random chaos failure when SetChaos is used
 func (m *MockErrorInjector) GetError(operation string) error {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	return m.errors[operation]
+	if err, ok := m.errors[operation]; ok {
+		return err
+	}
+	return m.chaos.Error(operation)
 }
 
 // ClearErrors clears all injected errors
@@ -76,7 +76,7 @@ func (u *Updater) CheckForUpdate(ctx context.Context, currentVersion string) (*R
 	// Check cache first
 	if cached, err := u.loadCache(); err == nil {
 		if time.Since(cached.LastCheck) < UpdateCheckInterval {
-			log.Debug("Using cached update check results")
+			log.Named("updater").Debug("Using cached update check results")
 			if version.IsNewer(cached.LatestVersion, currentVersion) {
 				return &Release{
 					Version:      cached.LatestVersion,
@@ -88,7 +88,7 @@ func (u *Updater) CheckForUpdate(ctx context.Context, currentVersion string) (*R
 	}
 
 	// Fetch latest release from GitHub
-	log.Debug("Fetching latest release info from GitHub")
+	log.Named("updater").Debug("Fetching latest release info from GitHub")
 	release, err := u.fetchLatestRelease(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch latest release: %w", err)
@@ -96,7 +96,7 @@ func (u *Updater) CheckForUpdate(ctx context.Context, currentVersion string) (*R
 
 	// Skip pre-releases and drafts
 	if release.Draft || release.Prerelease {
-		log.Debug("Skipping latest release as it's a draft or pre-release")
+		log.Named("updater").Debug("Skipping latest release as it's a draft or pre-release")
 		return nil, nil
 	}
 
@@ -106,12 +106,12 @@ func (u *Updater) CheckForUpdate(ctx context.Context, currentVersion string) (*R
 		LatestVersion: release.Version,
 		ReleaseNotes:  release.ReleaseNotes,
 	}); err != nil {
-		log.Debug("Failed to save update cache", log.ErrorField(err))
+		log.Named("updater").Debug("Failed to save update cache", log.ErrorField(err))
 	}
 
 	// Check if newer version is available
 	if version.IsNewer(release.Version, currentVersion) {
-		log.Info("New version available", log.Field("new_version", release.Version))
+		log.Named("updater").Info("New version available", log.Field("new_version", release.Version))
 		return release, nil
 	}
 
@@ -135,7 +135,7 @@ func (u *Updater) fetchLatestRelease(ctx context.Context) (*Release, error) {
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
-			log.Debug("Failed to close response body", log.ErrorField(closeErr))
+			log.Named("updater").Debug("Failed to close response body", log.ErrorField(closeErr))
 		}
 	}()
 
@@ -173,7 +173,7 @@ func (u *Updater) DownloadBinary(ctx context.Context, release *Release, destPath
 		return err
 	}
 
-	log.Debug("Downloading binary", log.Field("url", downloadURL))
+	log.Named("updater").Debug("Downloading binary", log.Field("url", downloadURL))
 
 	req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
 	if err != nil {
@@ -188,7 +188,7 @@ func (u *Updater) DownloadBinary(ctx context.Context, release *Release, destPath
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
-			log.Debug("Failed to close response body", log.ErrorField(closeErr))
+			log.Named("updater").Debug("Failed to close response body", log.ErrorField(closeErr))
 		}
 	}()
 
@@ -203,7 +203,7 @@ func (u *Updater) DownloadBinary(ctx context.Context, release *Release, destPath
 	}
 	defer func() {
 		if removeErr := os.Remove(tempFile.Name()); removeErr != nil && !os.IsNotExist(removeErr) {
-			log.Debug("Failed to remove temp file", log.ErrorField(removeErr))
+			log.Named("updater").Debug("Failed to remove temp file", log.ErrorField(removeErr))
 		}
 	}()
 
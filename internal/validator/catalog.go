@@ -0,0 +1,129 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Catalog is a team's list of standard variables, used by envy init to
+// start new services with the same variables and rules:
+//
+//	name: acme
+//	variables:
+//	  - name: DATABASE_URL
+//	    description: Primary database connection string
+//	    type: url
+//	    required: true
+//	    default: postgresql://localhost/app
+//	  - name: SESSION_SECRET
+//	    generate: random_hex(32)
+type Catalog struct {
+	Name      string            `yaml:"name,omitempty"`
+	Variables []CatalogVariable `yaml:"variables"`
+}
+
+// CatalogVariable is a catalog entry: a variable rule with a name and a
+// description
+type CatalogVariable struct {
+	Name         string `yaml:"name"`
+	Description  string `yaml:"description,omitempty"`
+	VariableRule `yaml:",inline"`
+}
+
+// ParseCatalog parses a catalog and checks its variables the way rules
+// files are checked
+func ParseCatalog(data []byte) (*Catalog, error) {
+	var catalog Catalog
+	if err := yaml.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog: %w", err)
+	}
+	if len(catalog.Variables) == 0 {
+		return nil, fmt.Errorf("catalog has no variables")
+	}
+
+	seen := make(map[string]bool)
+	for _, v := range catalog.Variables {
+		if v.Name == "" {
+			return nil, fmt.Errorf("catalog variable without a name")
+		}
+		if seen[v.Name] {
+			return nil, fmt.Errorf("catalog variable %s is listed twice", v.Name)
+		}
+		seen[v.Name] = true
+	}
+
+	rules := catalog.Rules()
+	for _, check := range []func() error{rules.validateSeverities, rules.validateGenerators} {
+		if err := check(); err != nil {
+			return nil, fmt.Errorf("invalid catalog: %w", err)
+		}
+	}
+	return &catalog, nil
+}
+
+// Rules returns validation rules for the catalog's variables. Entries that
+// only document a variable, without a type or any check, get no rule.
+func (c *Catalog) Rules() *Rules {
+	rules := &Rules{Variables: make(map[string]*VariableRule)}
+	for _, v := range c.Variables {
+		rule := v.VariableRule
+		if rule.Required {
+			rules.Required = append(rules.Required, v.Name)
+		}
+		if rule.Type == "" && rule.Pattern == "" && rule.Min == nil && rule.Max == nil &&
+			len(rule.Enum) == 0 && rule.Default == "" && rule.Generate == "" && !rule.Secret {
+			continue
+		}
+		if rule.Type == "" {
+			rule.Type = "string"
+		}
+		rules.Variables[v.Name] = &rule
+	}
+	return rules
+}
+
+// EnvFile returns an env file with every catalog variable, preceded by its
+// description. Variables with a generator get a generated value, and the
+// names of those are returned; others get their default.
+func (c *Catalog) EnvFile() (string, []string, error) {
+	var b strings.Builder
+	if c.Name != "" {
+		fmt.Fprintf(&b, "# Variables from the %s catalog\n", c.Name)
+	} else {
+		b.WriteString("# Variables from the catalog\n")
+	}
+
+	var generated []string
+	for _, v := range c.Variables {
+		value := v.Default
+		if v.Generate != "" {
+			rule := v.VariableRule
+			generatedValue, err := GenerateValue(v.Name, &rule)
+			if err != nil {
+				return "", nil, err
+			}
+			value = generatedValue
+			generated = append(generated, v.Name)
+		}
+
+		b.WriteString("\n")
+		for _, line := range strings.Split(strings.TrimSpace(v.Description), "\n") {
+			if line != "" {
+				fmt.Fprintf(&b, "# %s\n", line)
+			}
+		}
+		fmt.Fprintf(&b, "%s=%s\n", v.Name, quoteValue(value))
+	}
+	return b.String(), generated, nil
+}
+
+// quoteValue quotes values with spaces, quotes or '#', like env files are
+// written
+func quoteValue(value string) string {
+	if !strings.ContainsAny(value, " \t#\"'") {
+		return value
+	}
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
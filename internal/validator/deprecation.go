@@ -0,0 +1,90 @@
+package validator
+
+import (
+	"fmt"
+	"time"
+)
+
+// sunsetLayout is the date format of WarningRule.Sunset
+const sunsetLayout = "2006-01-02"
+
+// SunsetDate returns the day the variable stops being accepted, if set
+func (w WarningRule) SunsetDate() (time.Time, bool) {
+	if w.Sunset == "" {
+		return time.Time{}, false
+	}
+	date, err := time.ParseInLocation(sunsetLayout, w.Sunset, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// Sunsetted reports whether the sunset date has been reached at now
+func (w WarningRule) Sunsetted(now time.Time) bool {
+	date, ok := w.SunsetDate()
+	return ok && !now.Before(date)
+}
+
+// issue describes the warning for a variable that is set at now. Past the
+// sunset date it is always an error.
+func (w WarningRule) issue(now time.Time) (Severity, ValidationError) {
+	issue := ValidationError{
+		Variable: w.Name,
+		Message:  w.Message,
+		Type:     "deprecated",
+	}
+	if issue.Message == "" {
+		issue.Message = fmt.Sprintf("Variable %s is deprecated", w.Name)
+		if w.Replacement != "" {
+			issue.Message += fmt.Sprintf(", use %s instead", w.Replacement)
+		}
+	}
+	if w.Replacement != "" {
+		issue.Details = fmt.Sprintf("Run 'envy migrate-vars' to move it to %s", w.Replacement)
+	}
+
+	if w.Sunsetted(now) {
+		issue.Type = "sunset"
+		issue.Message += fmt.Sprintf(" (no longer accepted since %s)", w.Sunset)
+		return SeverityError, issue
+	}
+	if w.Sunset != "" {
+		issue.Message += fmt.Sprintf(" (accepted until %s)", w.Sunset)
+	}
+	return w.Severity, issue
+}
+
+// Deprecations returns the warnings that name a replacement variable
+func (r *Rules) Deprecations() []WarningRule {
+	var deprecations []WarningRule
+	for _, warning := range r.Warnings {
+		if warning.Replacement != "" {
+			deprecations = append(deprecations, warning)
+		}
+	}
+	return deprecations
+}
+
+// validateDeprecations checks the replacements and sunset dates of warnings
+func (r *Rules) validateDeprecations() error {
+	for _, warning := range r.Warnings {
+		if warning.Replacement == warning.Name && warning.Name != "" {
+			return fmt.Errorf("warning for %s: replacement must be a different variable", warning.Name)
+		}
+		if warning.Sunset != "" {
+			if _, err := time.Parse(sunsetLayout, warning.Sunset); err != nil {
+				return fmt.Errorf("warning for %s: invalid sunset date %q (use YYYY-MM-DD)", warning.Name, warning.Sunset)
+			}
+		}
+	}
+	for envName, section := range r.Environments {
+		if section == nil {
+			continue
+		}
+		if err := section.validateDeprecations(); err != nil {
+			return fmt.Errorf("environment %s: %w", envName, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package validator
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultClientPrefixes are the prefixes frameworks use for variables they
+// bundle into browser code
+var DefaultClientPrefixes = []string{"NEXT_PUBLIC_", "VITE_", "REACT_APP_"}
+
+// ClientExposureRule guards variables that frameworks expose to the
+// browser, e.g.
+//
+//	client_exposure:
+//	  prefixes: [NEXT_PUBLIC_, VITE_, PUBLIC_]
+//	  allow: [NEXT_PUBLIC_STRIPE_PUBLISHABLE_KEY]
+//
+// Such variables must not hold secret-looking values, and secrets must not
+// carry their prefixes. The check runs with the default prefixes when the
+// section is absent.
+type ClientExposureRule struct {
+	Prefixes []string `yaml:"prefixes,omitempty"` // default NEXT_PUBLIC_, VITE_, REACT_APP_
+	Allow    []string `yaml:"allow,omitempty"`    // keys or glob patterns that are not checked
+	Severity Severity `yaml:"severity,omitempty"` // default error
+	Disabled bool     `yaml:"disabled,omitempty"`
+}
+
+// secretValues match values that are credentials whatever their name, with
+// a description of each
+var secretValues = []struct {
+	re   *regexp.Regexp
+	what string
+}{
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`), "a private key"},
+	{regexp.MustCompile(`^(sk|rk)_live_`), "a Stripe secret key"},
+	{regexp.MustCompile(`^sk-(proj-|ant-)?[A-Za-z0-9_-]{20,}$`), "an API secret key"},
+	{regexp.MustCompile(`^(ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{30,}$|^github_pat_`), "a GitHub token"},
+	{regexp.MustCompile(`^xox[abposr]-`), "a Slack token"},
+	{regexp.MustCompile(`^(AKIA|ASIA)[A-Z0-9]{16}$`), "an AWS access key ID"},
+	{regexp.MustCompile(`^[a-z][a-z0-9+.-]*://[^/:@\s]+:[^/@\s]+@`), "a URL with a password"},
+}
+
+// publicSuffixes end the names of keys that are commonly bundled into
+// browser code, such as publishable or site keys. They alone do not make a
+// client-exposed variable a secret.
+var publicSuffixes = []string{"_KEY", "_TOKEN"}
+
+// SecretValue reports whether a value looks like a credential, e.g. a
+// private key or a live API key, and describes it
+func SecretValue(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	for _, s := range secretValues {
+		if s.re.MatchString(value) {
+			return s.what, true
+		}
+	}
+	return "", false
+}
+
+// ClientPrefixes returns the prefixes of variables exposed to the browser
+func (r *Rules) ClientPrefixes() []string {
+	if r == nil || r.ClientExposure == nil || len(r.ClientExposure.Prefixes) == 0 {
+		return DefaultClientPrefixes
+	}
+	return r.ClientExposure.Prefixes
+}
+
+// ClientExposure returns the issues of the client-exposed variables of vars
+// that hold secrets
+func (v *Validator) ClientExposure(vars map[string]string) []ValidationError {
+	result := &ValidationResult{}
+	v.checkClientExposure(vars, result)
+	return append(append(result.Errors, result.Warnings...), result.Infos...)
+}
+
+// clientPrefix returns the client-exposure prefix of name, if it has one
+func (r *ClientExposureRule) clientPrefix(name string) (string, bool) {
+	prefixes := DefaultClientPrefixes
+	if r != nil && len(r.Prefixes) > 0 {
+		prefixes = r.Prefixes
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+func (r *ClientExposureRule) allowed(name string) bool {
+	if r == nil {
+		return false
+	}
+	for _, pattern := range r.Allow {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkClientExposure reports client-exposed variables that hold secrets
+func (v *Validator) checkClientExposure(vars map[string]string, result *ValidationResult) {
+	rule := v.rules.ClientExposure
+	if rule != nil && rule.Disabled {
+		return
+	}
+	var severity Severity
+	if rule != nil {
+		severity = rule.Severity
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prefix, exposed := rule.clientPrefix(name)
+		if !exposed || rule.allowed(name) {
+			continue
+		}
+
+		if reason := v.secretName(name, prefix); reason != "" {
+			result.add(severity, SeverityError, ValidationError{
+				Variable: name,
+				Message:  fmt.Sprintf("Secret %s must not use the client-exposed prefix %s: %s", name, prefix, reason),
+				Details:  fmt.Sprintf("%s variables are bundled into browser code; rename it without the prefix", prefix),
+				Type:     "client_exposed_secret",
+			})
+			continue
+		}
+		if what, ok := SecretValue(vars[name]); ok {
+			result.add(severity, SeverityError, ValidationError{
+				Variable: name,
+				Message:  fmt.Sprintf("%s is exposed to the browser by its prefix %s but holds %s", name, prefix, what),
+				Details:  "Move the value to a variable without the prefix and use it on the server only",
+				Type:     "client_exposed_secret",
+			})
+		}
+	}
+}
+
+// secretName returns why a client-exposed variable is a secret by its rules
+// or the sensitivity classifier, or "" if it is not. A name the classifier
+// marks only for a trailing _KEY or _TOKEN is not a secret's.
+func (v *Validator) secretName(name, prefix string) string {
+	if _, ok := v.strengthRule(name, v.rules.Variables[name]); ok {
+		return "its rules mark it as a secret"
+	}
+	if v.isSensitive(name) && (v.isSensitive(withoutPublicSuffix(name)) || v.isSensitive(withoutPublicSuffix(strings.TrimPrefix(name, prefix)))) {
+		return "it is classified as sensitive"
+	}
+	return ""
+}
+
+// withoutPublicSuffix returns name without a trailing _KEY or _TOKEN
+func withoutPublicSuffix(name string) string {
+	for _, suffix := range publicSuffixes {
+		if strings.HasSuffix(strings.ToUpper(name), suffix) {
+			return name[:len(name)-len(suffix)]
+		}
+	}
+	return name
+}
+
+// validateClientExposure checks the client exposure rules in rules
+func (r *Rules) validateClientExposure() error {
+	sections := map[string]*Rules{"": r}
+	for envName, section := range r.Environments {
+		sections[envName] = section
+	}
+	for envName, section := range sections {
+		if section == nil || section.ClientExposure == nil {
+			continue
+		}
+		rule := section.ClientExposure
+		where := "client_exposure"
+		if envName != "" {
+			where = fmt.Sprintf("environment %s: client_exposure", envName)
+		}
+		for _, prefix := range rule.Prefixes {
+			if prefix == "" {
+				return fmt.Errorf("%s: empty prefix", where)
+			}
+		}
+		for _, pattern := range rule.Allow {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%s: invalid allow pattern %s: %w", where, pattern, err)
+			}
+		}
+		if rule.Severity != "" {
+			if _, err := ParseSeverity(string(rule.Severity)); err != nil {
+				return fmt.Errorf("%s: %w", where, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package validator
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/drapon/envy/internal/format"
+)
+
+// maxRepeat caps open-ended repetitions (*, +, {n,}) in generated matches
+const maxRepeat = 8
+
+// Faker creates fakes for the variables of a rule set. Each variable's fake
+// is derived from its name and the seed, so it is the same on every run.
+type Faker struct {
+	rules *Rules
+	seed  string
+}
+
+// NewFaker creates a faker for the given rules, which may be nil
+func NewFaker(rules *Rules, seed string) *Faker {
+	if rules == nil {
+		rules = &Rules{}
+	}
+	return &Faker{rules: rules, seed: seed}
+}
+
+// Fake returns the fake for a variable's value
+func (f *Faker) Fake(name, original string) (string, error) {
+	h := fnv.New64a()
+	h.Write([]byte(f.seed + "\x00" + name))
+	r := rand.New(rand.NewSource(int64(h.Sum64())))
+	return FakeValue(name, original, f.rules.Variables[name], r)
+}
+
+// FakeValue creates a realistic stand-in for a variable's value that passes
+// the rule's checks, e.g. to share test fixtures without the real secret.
+// Without a rule the original value is scrambled, keeping its shape.
+func FakeValue(name, original string, rule *VariableRule, r *rand.Rand) (string, error) {
+	if rule == nil {
+		return scramble(original, r), nil
+	}
+
+	v := New(&Rules{})
+	var lastErr error
+	for i := 0; i < generateAttempts; i++ {
+		value, err := fakeCandidate(original, rule, r)
+		if err != nil {
+			return "", fmt.Errorf("variable %s: %w", name, err)
+		}
+		if lastErr = v.checkValue(name, value, rule); lastErr == nil {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("cannot create a valid fake value for %s: %w", name, lastErr)
+}
+
+func fakeCandidate(original string, rule *VariableRule, r *rand.Rand) (string, error) {
+	switch {
+	case len(rule.Enum) > 0:
+		return rule.Enum[r.Intn(len(rule.Enum))], nil
+	case rule.Pattern != "":
+		return Match(rule.Pattern, r)
+	case (rule.Type == TypeInt || rule.Type == TypeFloat) && (rule.Min != nil || rule.Max != nil):
+		return fakeNumber(rule, r), nil
+	case rule.Generate != "":
+		return Generate(rule.Generate)
+	}
+
+	if f, ok := format.Lookup(rule.Type); ok && rule.Type != "string" {
+		return f.Fake(r), nil
+	}
+	return scramble(original, r), nil
+}
+
+// fakeNumber picks a number within the rule's min and max
+func fakeNumber(rule *VariableRule, r *rand.Rand) string {
+	lo, hi := 0.0, 1000.0
+	switch {
+	case rule.Min != nil && rule.Max != nil:
+		lo, hi = *rule.Min, *rule.Max
+	case rule.Min != nil:
+		lo, hi = *rule.Min, *rule.Min+1000
+	case rule.Max != nil:
+		lo, hi = *rule.Max-1000, *rule.Max
+	}
+
+	if rule.Type == TypeInt {
+		ilo, ihi := int(math.Ceil(lo)), int(math.Floor(hi))
+		if ihi < ilo {
+			return strconv.Itoa(ilo)
+		}
+		return strconv.Itoa(ilo + r.Intn(ihi-ilo+1))
+	}
+	return strconv.FormatFloat(lo+r.Float64()*(hi-lo), 'f', 2, 64)
+}
+
+// scramble replaces letters and digits with random ones of the same kind,
+// so a fake keeps the length and punctuation of the original
+func scramble(value string, r *rand.Rand) string {
+	const lower, upper, digits = "abcdefghijklmnopqrstuvwxyz", "ABCDEFGHIJKLMNOPQRSTUVWXYZ", "0123456789"
+
+	var b strings.Builder
+	for _, c := range value {
+		switch {
+		case c >= 'a' && c <= 'z':
+			b.WriteByte(lower[r.Intn(len(lower))])
+		case c >= 'A' && c <= 'Z':
+			b.WriteByte(upper[r.Intn(len(upper))])
+		case c >= '0' && c <= '9':
+			b.WriteByte(digits[r.Intn(len(digits))])
+		case unicode.IsLetter(c):
+			b.WriteByte(lower[r.Intn(len(lower))])
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// Match creates a random string that matches the regular expression
+func Match(pattern string, r *rand.Rand) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", fmt.Errorf("invalid regex pattern: %w", err)
+	}
+
+	var b strings.Builder
+	writeMatch(&b, re.Simplify(), r)
+	return b.String(), nil
+}
+
+func writeMatch(b *strings.Builder, re *syntax.Regexp, r *rand.Rand) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		b.WriteRune(classRune(re.Rune, r))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteByte(alnum[r.Intn(len(alnum))])
+	case syntax.OpCapture:
+		writeMatch(b, re.Sub[0], r)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			writeMatch(b, sub, r)
+		}
+	case syntax.OpAlternate:
+		writeMatch(b, re.Sub[r.Intn(len(re.Sub))], r)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		lo, hi := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			lo, hi = 0, -1
+		case syntax.OpPlus:
+			lo, hi = 1, -1
+		case syntax.OpQuest:
+			lo, hi = 0, 1
+		}
+		if hi < 0 {
+			hi = lo + maxRepeat
+		}
+		for i := lo + r.Intn(hi-lo+1); i > 0; i-- {
+			writeMatch(b, re.Sub[0], r)
+		}
+	}
+	// Anchors, word boundaries and empty matches add nothing
+}
+
+// classRune picks a rune from a character class, preferring printable ASCII
+// so that negated classes such as [^"] don't yield control characters
+func classRune(ranges []rune, r *rand.Rand) rune {
+	var printable []rune
+	for i := 0; i+1 < len(ranges); i += 2 {
+		for c := max(ranges[i], '!'); c <= min(ranges[i+1], '~'); c++ {
+			printable = append(printable, c)
+		}
+	}
+	if len(printable) > 0 {
+		return printable[r.Intn(len(printable))]
+	}
+	if len(ranges) == 0 {
+		return 'x'
+	}
+	return ranges[0]
+}
@@ -0,0 +1,139 @@
+package validator
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+)
+
+// generateAttempts bounds how often a value is regenerated until it matches
+// the rule's pattern, e.g. one requiring a letter in a random hex string
+const generateAttempts = 20
+
+const alnum = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+var generatorSpec = regexp.MustCompile(`^([a-z0-9_]+)(?:\((\d+)\))?$`)
+
+// generators create random values. The length is the number of random bytes
+// for random_hex and random_base64, as with openssl rand, and the number of
+// characters for random_alnum.
+var generators = map[string]struct {
+	defaultLength int
+	generate      func(n int) (string, error)
+}{
+	"random_hex": {32, func(n int) (string, error) {
+		b, err := randomBytes(n)
+		return hex.EncodeToString(b), err
+	}},
+	"random_base64": {32, func(n int) (string, error) {
+		b, err := randomBytes(n)
+		return base64.StdEncoding.EncodeToString(b), err
+	}},
+	"random_alnum": {32, func(n int) (string, error) {
+		out := make([]byte, n)
+		for i := range out {
+			idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(alnum))))
+			if err != nil {
+				return "", err
+			}
+			out[i] = alnum[idx.Int64()]
+		}
+		return string(out), nil
+	}},
+	"uuid": {0, func(int) (string, error) {
+		b, err := randomBytes(16)
+		if err != nil {
+			return "", err
+		}
+		b[6] = b[6]&0x0f | 0x40 // version 4
+		b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+		h := hex.EncodeToString(b)
+		return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+	}},
+}
+
+// Generate creates a random value from a generator spec such as
+// "random_hex(32)" or "uuid"
+func Generate(spec string) (string, error) {
+	match := generatorSpec.FindStringSubmatch(spec)
+	if match == nil {
+		return "", fmt.Errorf("invalid generator %q (e.g. random_hex(32) or uuid)", spec)
+	}
+
+	gen, ok := generators[match[1]]
+	if !ok {
+		return "", fmt.Errorf("unknown generator %q (use random_hex, random_base64, random_alnum or uuid)", match[1])
+	}
+
+	n := gen.defaultLength
+	if match[2] != "" {
+		if gen.defaultLength == 0 {
+			return "", fmt.Errorf("generator %s takes no length", match[1])
+		}
+		var err error
+		if n, err = strconv.Atoi(match[2]); err != nil || n < 1 || n > 4096 {
+			return "", fmt.Errorf("invalid length in generator %q (1-4096)", spec)
+		}
+	}
+
+	value, err := gen.generate(n)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate value: %w", err)
+	}
+	return value, nil
+}
+
+// GenerateValue creates a value for a variable with the rule's generator
+// that passes the rule's own checks
+func GenerateValue(name string, rule *VariableRule) (string, error) {
+	if rule == nil || rule.Generate == "" {
+		return "", fmt.Errorf("no generator for variable %s", name)
+	}
+
+	v := New(&Rules{})
+	var lastErr error
+	for i := 0; i < generateAttempts; i++ {
+		value, err := Generate(rule.Generate)
+		if err != nil {
+			return "", err
+		}
+		if lastErr = v.checkValue(name, value, rule); lastErr == nil {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("generator %s does not produce valid values for %s: %w", rule.Generate, name, lastErr)
+}
+
+// validateGenerators checks that every generator in the rules produces
+// values its rule accepts
+func (r *Rules) validateGenerators() error {
+	for name, rule := range r.Variables {
+		if rule == nil || rule.Generate == "" {
+			continue
+		}
+		if _, err := GenerateValue(name, rule); err != nil {
+			return fmt.Errorf("variable %s: %w", name, err)
+		}
+	}
+	for envName, section := range r.Environments {
+		if section == nil {
+			continue
+		}
+		if err := section.validateGenerators(); err != nil {
+			return fmt.Errorf("environment %s: %w", envName, err)
+		}
+	}
+	return nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -1,8 +1,12 @@
 package validator
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -12,6 +16,62 @@ type Rules struct {
 	Required  []string                 `yaml:"required"`
 	Variables map[string]*VariableRule `yaml:"variables"`
 	Warnings  []WarningRule            `yaml:"warnings"`
+
+	// Strength sets minimum lengths and entropy for secret values
+	Strength []StrengthRule `yaml:"strength,omitempty"`
+
+	// ClientExposure guards variables exposed to the browser
+	ClientExposure *ClientExposureRule `yaml:"client_exposure,omitempty"`
+
+	// Environments holds rules added for a single environment, merged over
+	// the top-level rules by ForEnvironment.
+	Environments map[string]*Rules `yaml:"environments,omitempty"`
+
+	// order lists the variables in the order the file names them
+	order []string
+}
+
+// UnmarshalYAML decodes rules, recording the order of their variables
+func (r *Rules) UnmarshalYAML(node *yaml.Node) error {
+	type plain Rules
+	if err := node.Decode((*plain)(r)); err != nil {
+		return err
+	}
+	r.order = variableOrder(node)
+	return nil
+}
+
+// variableOrder returns the variables named under required and variables,
+// in the order they appear
+func variableOrder(node *yaml.Node) []string {
+	var order []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			order = append(order, name)
+		}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		section := node.Content[i+1]
+		switch node.Content[i].Value {
+		case "required":
+			for _, item := range section.Content {
+				add(item.Value)
+			}
+		case "variables":
+			for j := 0; j+1 < len(section.Content); j += 2 {
+				add(section.Content[j].Value)
+			}
+		}
+	}
+	return order
+}
+
+// Order returns the variables of the rules in the order the rules files
+// name them, those of the environment following the top-level ones
+func (r *Rules) Order() []string {
+	return append([]string{}, r.order...)
 }
 
 // VariableRule represents validation rules for a single variable.
@@ -23,12 +83,20 @@ type VariableRule struct {
 	Enum     []string `yaml:"enum,omitempty"`
 	Required bool     `yaml:"required,omitempty"`
 	Default  string   `yaml:"default,omitempty"`
+	Generate string   `yaml:"generate,omitempty"` // e.g. random_hex(32), for missing values
+	Severity Severity `yaml:"severity,omitempty"` // of failed checks; default error
+	Secret   bool     `yaml:"secret,omitempty"`   // check the value's strength
 }
 
 // WarningRule represents a warning for deprecated or problematic variables.
+// With a sunset date the warning becomes an error from that day on, and a
+// replacement lets envy migrate-vars move the value to the new name.
 type WarningRule struct {
-	Name    string `yaml:"name"`
-	Message string `yaml:"message"`
+	Name        string   `yaml:"name"`
+	Message     string   `yaml:"message,omitempty"`
+	Severity    Severity `yaml:"severity,omitempty"` // default warning
+	Replacement string   `yaml:"replacement,omitempty"`
+	Sunset      string   `yaml:"sunset,omitempty"` // YYYY-MM-DD
 }
 
 // LoadRulesFromFile loads validation rules from a YAML file.
@@ -43,24 +111,92 @@ func LoadRulesFromFile(filename string) (*Rules, error) {
 		return nil, fmt.Errorf("failed to parse rules file: %w", err)
 	}
 
-	// Initialize maps if nil
-	if rules.Variables == nil {
-		rules.Variables = make(map[string]*VariableRule)
+	for _, check := range []func() error{rules.validateSeverities, rules.validateDeprecations, rules.validateGenerators, rules.validateStrength, rules.validateClientExposure} {
+		if err := check(); err != nil {
+			return nil, fmt.Errorf("invalid rules file %s: %w", filename, err)
+		}
 	}
 
-	// Process required variables to ensure they have rules
-	for _, reqVar := range rules.Required {
-		if _, exists := rules.Variables[reqVar]; !exists {
-			rules.Variables[reqVar] = &VariableRule{
+	rules.markRequired()
+	return &rules, nil
+}
+
+// LoadRulesForEnvironment loads the rules that apply to an environment:
+// the rules in filename, then its environments section for envName, then
+// the environment's own file (e.g. .envy-rules.prod.yaml), each taking
+// precedence over the last. Either file may be missing, but not both; the
+// error then wraps fs.ErrNotExist.
+func LoadRulesForEnvironment(filename, envName string) (*Rules, error) {
+	base, err := LoadRulesFromFile(filename)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	var envRules *Rules
+	if envName != "" {
+		envRules, err = LoadRulesFromFile(EnvironmentRulesFile(filename, envName))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	if base == nil && envRules == nil {
+		return nil, fmt.Errorf("no rules file %s: %w", filename, fs.ErrNotExist)
+	}
+	if base == nil {
+		base = &Rules{}
+	}
+
+	rules := base.ForEnvironment(envName)
+	if envRules != nil {
+		rules = MergeRules(rules, envRules)
+		rules.markRequired()
+	}
+	return rules, nil
+}
+
+// EnvironmentRulesFile returns the rules file for an environment next to
+// filename, e.g. .envy-rules.prod.yaml for .envy-rules.yaml.
+func EnvironmentRulesFile(filename, envName string) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "." + envName + ext
+}
+
+// EnvironmentRulesFiles returns the environment rules files that exist next
+// to filename.
+func EnvironmentRulesFiles(filename string) ([]string, error) {
+	return filepath.Glob(EnvironmentRulesFile(filename, "*"))
+}
+
+// ForEnvironment returns the top-level rules with the environment's
+// section, if any, merged over them.
+func (r *Rules) ForEnvironment(envName string) *Rules {
+	merged := MergeRules(r, r.Environments[envName])
+	merged.markRequired()
+	return merged
+}
+
+// markRequired ensures every required variable has a rule marked required.
+// Rules are copied before being changed since merged rule sets share them.
+func (r *Rules) markRequired() {
+	if r.Variables == nil {
+		r.Variables = make(map[string]*VariableRule)
+	}
+
+	for _, reqVar := range r.Required {
+		rule, exists := r.Variables[reqVar]
+		switch {
+		case !exists:
+			r.Variables[reqVar] = &VariableRule{
 				Type:     "string",
 				Required: true,
 			}
-		} else {
-			rules.Variables[reqVar].Required = true
+		case !rule.Required:
+			required := *rule
+			required.Required = true
+			r.Variables[reqVar] = &required
 		}
 	}
-
-	return &rules, nil
 }
 
 // SaveRulesToFile saves validation rules to a YAML file.
@@ -77,6 +213,72 @@ func SaveRulesToFile(rules *Rules, filename string) error {
 	return nil
 }
 
+// RenameVariable renames a variable in the required list, variable rules and
+// warnings. It returns true if any entry was changed, and an error without
+// changing anything if the new name already has a variable rule.
+func (r *Rules) RenameVariable(oldName, newName string) (bool, error) {
+	if err := r.checkRename(oldName, newName); err != nil {
+		return false, err
+	}
+	return r.renameVariable(oldName, newName), nil
+}
+
+// checkRename reports a variable rule for newName that renaming oldName
+// would collide with
+func (r *Rules) checkRename(oldName, newName string) error {
+	if _, ok := r.Variables[oldName]; ok {
+		if _, exists := r.Variables[newName]; exists {
+			return fmt.Errorf("both %s and %s have a variable rule", oldName, newName)
+		}
+	}
+
+	for envName, section := range r.Environments {
+		if section == nil {
+			continue
+		}
+		if err := section.checkRename(oldName, newName); err != nil {
+			return fmt.Errorf("environment %s: %w", envName, err)
+		}
+	}
+	return nil
+}
+
+func (r *Rules) renameVariable(oldName, newName string) bool {
+	changed := false
+
+	for i, name := range r.Required {
+		if name == oldName {
+			r.Required[i] = newName
+			changed = true
+		}
+	}
+
+	if rule, ok := r.Variables[oldName]; ok {
+		r.Variables[newName] = rule
+		delete(r.Variables, oldName)
+		changed = true
+	}
+
+	for i := range r.Warnings {
+		if r.Warnings[i].Name == oldName {
+			r.Warnings[i].Name = newName
+			changed = true
+		}
+		if r.Warnings[i].Replacement == oldName {
+			r.Warnings[i].Replacement = newName
+			changed = true
+		}
+	}
+
+	for _, section := range r.Environments {
+		if section != nil && section.renameVariable(oldName, newName) {
+			changed = true
+		}
+	}
+
+	return changed
+}
+
 // DefaultRules returns a set of default validation rules.
 func DefaultRules() *Rules {
 	return &Rules{
@@ -229,6 +431,7 @@ func MergeRules(base, override *Rules) *Rules {
 		Required:  append([]string{}, base.Required...),
 		Variables: make(map[string]*VariableRule),
 		Warnings:  append([]WarningRule{}, base.Warnings...),
+		order:     append([]string{}, base.order...),
 	}
 
 	// Copy base variables
@@ -252,6 +455,21 @@ func MergeRules(base, override *Rules) *Rules {
 
 		// Merge warnings
 		merged.Warnings = append(merged.Warnings, override.Warnings...)
+
+		// Strength rules of the override are matched first
+		merged.Strength = append(merged.Strength, override.Strength...)
+
+		for _, name := range override.order {
+			if !contains(merged.order, name) {
+				merged.order = append(merged.order, name)
+			}
+		}
+	}
+	merged.Strength = append(merged.Strength, base.Strength...)
+
+	merged.ClientExposure = base.ClientExposure
+	if override != nil && override.ClientExposure != nil {
+		merged.ClientExposure = override.ClientExposure
 	}
 
 	return merged
@@ -0,0 +1,117 @@
+package validator
+
+import "fmt"
+
+// Severity is how serious a validation issue is. Rules can lower the
+// severity of their checks to introduce them gradually, e.g. as warnings
+// before enforcing them.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// ParseSeverity parses a severity name
+func ParseSeverity(s string) (Severity, error) {
+	switch severity := Severity(s); severity {
+	case SeverityError, SeverityWarning, SeverityInfo:
+		return severity, nil
+	default:
+		return "", fmt.Errorf("invalid severity %q (must be error, warning or info)", s)
+	}
+}
+
+// rank orders severities from info (1) to error (3)
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 3
+	case SeverityWarning:
+		return 2
+	case SeverityInfo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// add records an issue with the rule's severity, or fallback when the rule
+// sets none
+func (r *ValidationResult) add(severity, fallback Severity, issue ValidationError) {
+	if severity == "" {
+		severity = fallback
+	}
+	issue.Severity = severity
+
+	switch severity {
+	case SeverityInfo:
+		r.Infos = append(r.Infos, issue)
+	case SeverityWarning:
+		r.Warnings = append(r.Warnings, issue)
+	default:
+		r.Errors = append(r.Errors, issue)
+	}
+}
+
+// Exceeds reports whether any issue is more severe than max, which is the
+// highest severity tolerated, e.g. by a CI check
+func (r *ValidationResult) Exceeds(max Severity) bool {
+	switch {
+	case len(r.Errors) > 0:
+		return SeverityError.rank() > max.rank()
+	case len(r.Warnings) > 0:
+		return SeverityWarning.rank() > max.rank()
+	case len(r.Infos) > 0:
+		return SeverityInfo.rank() > max.rank()
+	}
+	return false
+}
+
+// validateSeverities checks the severities set in rules
+func (r *Rules) validateSeverities() error {
+	for name, rule := range r.Variables {
+		if rule != nil && rule.Severity != "" {
+			if _, err := ParseSeverity(string(rule.Severity)); err != nil {
+				return fmt.Errorf("variable %s: %w", name, err)
+			}
+		}
+	}
+	for _, warning := range r.Warnings {
+		if warning.Severity != "" {
+			if _, err := ParseSeverity(string(warning.Severity)); err != nil {
+				return fmt.Errorf("warning for %s: %w", warning.Name, err)
+			}
+		}
+	}
+	for envName, section := range r.Environments {
+		if section == nil {
+			continue
+		}
+		if err := section.validateSeverities(); err != nil {
+			return fmt.Errorf("environment %s: %w", envName, err)
+		}
+	}
+	return nil
+}
+
+// Without returns the result without issues of the given type, e.g.
+// "missing_required" when only some of an environment's variables are checked
+func (r *ValidationResult) Without(issueType string) *ValidationResult {
+	keep := func(issues []ValidationError) []ValidationError {
+		kept := []ValidationError{}
+		for _, issue := range issues {
+			if issue.Type != issueType {
+				kept = append(kept, issue)
+			}
+		}
+		return kept
+	}
+
+	filtered := *r
+	filtered.Errors = keep(r.Errors)
+	filtered.Warnings = keep(r.Warnings)
+	filtered.Infos = keep(r.Infos)
+	return &filtered
+}
@@ -0,0 +1,129 @@
+package validator
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// Thresholds for secrets without a matching strength rule
+const (
+	DefaultMinSecretLength  = 12
+	DefaultMinSecretEntropy = 48 // bits
+)
+
+// StrengthRule sets the minimum strength of secret values whose names match
+// a glob pattern, e.g.
+//
+//	strength:
+//	  - pattern: "*_API_KEY"
+//	    min_length: 32
+//	    min_entropy: 128
+//
+// Variables matching a pattern are checked even without secret: true. The
+// first matching rule applies.
+type StrengthRule struct {
+	Pattern    string   `yaml:"pattern"`
+	MinLength  int      `yaml:"min_length,omitempty"`
+	MinEntropy float64  `yaml:"min_entropy,omitempty"` // bits
+	Severity   Severity `yaml:"severity,omitempty"`    // default warning
+}
+
+// weakSecrets are fragments of placeholder and well-known passwords. A
+// secret containing one is reported regardless of its length.
+var weakSecrets = []string{
+	"password", "passwd", "changeme", "change_me", "placeholder", "example",
+	"dummy", "secret", "qwerty", "letmein", "123456", "abcdef", "replace",
+	"your_", "todo", "xxxx",
+}
+
+// strengthRule returns the strength rule for a variable, and whether its
+// value should be checked at all
+func (v *Validator) strengthRule(name string, rule *VariableRule) (StrengthRule, bool) {
+	for _, strength := range v.rules.Strength {
+		if ok, _ := filepath.Match(strength.Pattern, name); ok {
+			return strength, true
+		}
+	}
+	if rule != nil && rule.Secret {
+		return StrengthRule{Severity: rule.Severity}, true
+	}
+	return StrengthRule{}, false
+}
+
+// checkStrength returns why a secret value is weak, or "" if it is not
+func checkStrength(value string, rule StrengthRule) string {
+	minLength := rule.MinLength
+	if minLength == 0 {
+		minLength = DefaultMinSecretLength
+	}
+	minEntropy := rule.MinEntropy
+	if minEntropy == 0 {
+		minEntropy = DefaultMinSecretEntropy
+	}
+
+	lower := strings.ToLower(value)
+	for _, weak := range weakSecrets {
+		if strings.Contains(lower, weak) {
+			return fmt.Sprintf("contains the common placeholder %q", weak)
+		}
+	}
+	if length := len([]rune(value)); length < minLength {
+		return fmt.Sprintf("is %d characters long, expected at least %d", length, minLength)
+	}
+	if entropy := Entropy(value); entropy < minEntropy {
+		return fmt.Sprintf("has about %.0f bits of entropy, expected at least %.0f", entropy, minEntropy)
+	}
+	return ""
+}
+
+// Entropy estimates the entropy of a value in bits, from the frequency of
+// its characters. Repeated characters and short values score low.
+func Entropy(value string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range value {
+		counts[r]++
+		total++
+	}
+	if total == 0 {
+		return 0
+	}
+
+	var perChar float64
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		perChar -= p * math.Log2(p)
+	}
+	return perChar * float64(total)
+}
+
+// validateStrength checks the strength rules in rules
+func (r *Rules) validateStrength() error {
+	for _, strength := range r.Strength {
+		if strength.Pattern == "" {
+			return fmt.Errorf("strength rule without a pattern")
+		}
+		if _, err := filepath.Match(strength.Pattern, ""); err != nil {
+			return fmt.Errorf("strength rule %s: invalid pattern: %w", strength.Pattern, err)
+		}
+		if strength.MinLength < 0 || strength.MinEntropy < 0 {
+			return fmt.Errorf("strength rule %s: thresholds must not be negative", strength.Pattern)
+		}
+		if strength.Severity != "" {
+			if _, err := ParseSeverity(string(strength.Severity)); err != nil {
+				return fmt.Errorf("strength rule %s: %w", strength.Pattern, err)
+			}
+		}
+	}
+	for envName, section := range r.Environments {
+		if section == nil {
+			continue
+		}
+		if err := section.validateStrength(); err != nil {
+			return fmt.Errorf("environment %s: %w", envName, err)
+		}
+	}
+	return nil
+}
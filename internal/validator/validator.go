@@ -3,40 +3,55 @@ package validator
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/drapon/envy/internal/format"
+	"github.com/drapon/envy/internal/sensitive"
 )
 
 // Validator validates environment variables against defined rules.
 type Validator struct {
-	rules *Rules
+	rules       *Rules
+	now         func() time.Time  // for sunset dates
+	isSensitive func(string) bool // for client-exposed secrets
 }
 
 // New creates a new validator with the given rules.
 func New(rules *Rules) *Validator {
 	return &Validator{
-		rules: rules,
+		rules:       rules,
+		now:         time.Now,
+		isSensitive: sensitive.IsSensitive,
 	}
 }
 
+// WithSensitive makes the validator classify secrets with isSensitive, as
+// returned by sensitive.FromConfig, instead of the built-in rules
+func (v *Validator) WithSensitive(isSensitive func(string) bool) *Validator {
+	v.isSensitive = isSensitive
+	return v
+}
+
 // ValidationResult contains the results of validation.
 type ValidationResult struct {
 	Errors       []ValidationError `json:"errors"`
 	Warnings     []ValidationError `json:"warnings"`
+	Infos        []ValidationError `json:"infos"`
 	Fixes        []Fix             `json:"fixes"`
 	AppliedFixes []Fix             `json:"applied_fixes,omitempty"`
 }
 
 // ValidationError represents a validation error or warning.
 type ValidationError struct {
-	Variable string `json:"variable"`
-	Message  string `json:"message"`
-	Details  string `json:"details,omitempty"`
-	Type     string `json:"type"`
+	Variable string   `json:"variable"`
+	Message  string   `json:"message"`
+	Details  string   `json:"details,omitempty"`
+	Type     string   `json:"type"`
+	Severity Severity `json:"severity"`
 }
 
 // Fix represents a suggested or applied fix.
@@ -54,6 +69,7 @@ const (
 	FixTypeSetDefault     FixType = "set_default"
 	FixTypeCorrectValue   FixType = "correct_value"
 	FixTypeRemoveVariable FixType = "remove_variable"
+	FixTypeGenerate       FixType = "generate" // value created when applied
 
 	// Common type names
 	TypeInt   = "int"
@@ -65,6 +81,7 @@ func (v *Validator) Validate(ctx context.Context, vars map[string]string) *Valid
 	result := &ValidationResult{
 		Errors:   []ValidationError{},
 		Warnings: []ValidationError{},
+		Infos:    []ValidationError{},
 		Fixes:    []Fix{},
 	}
 
@@ -73,7 +90,7 @@ func (v *Validator) Validate(ctx context.Context, vars map[string]string) *Valid
 	for _, required := range v.rules.Required {
 		requiredMap[required] = true
 		if _, exists := vars[required]; !exists {
-			result.Errors = append(result.Errors, ValidationError{
+			result.add(v.severityOf(required), SeverityError, ValidationError{
 				Variable: required,
 				Message:  fmt.Sprintf("Required variable %s is missing", required),
 				Type:     "missing_required",
@@ -87,7 +104,7 @@ func (v *Validator) Validate(ctx context.Context, vars map[string]string) *Valid
 
 		// Check if required (but skip if already checked in required list)
 		if varRule.Required && !exists && !requiredMap[varName] {
-			result.Errors = append(result.Errors, ValidationError{
+			result.add(varRule.Severity, SeverityError, ValidationError{
 				Variable: varName,
 				Message:  fmt.Sprintf("Required variable %s is missing", varName),
 				Type:     "missing_required",
@@ -101,6 +118,12 @@ func (v *Validator) Validate(ctx context.Context, vars map[string]string) *Valid
 					Value:       varRule.Default,
 					Description: fmt.Sprintf("Set default value: %s", varRule.Default),
 				})
+			} else if varRule.Generate != "" {
+				result.Fixes = append(result.Fixes, Fix{
+					Variable:    varName,
+					Type:        FixTypeGenerate,
+					Description: fmt.Sprintf("Generate value: %s", varRule.Generate),
+				})
 			}
 			continue
 		}
@@ -115,13 +138,19 @@ func (v *Validator) Validate(ctx context.Context, vars map[string]string) *Valid
 					Value:       varRule.Default,
 					Description: fmt.Sprintf("Add optional variable with default value: %s", varRule.Default),
 				})
+			} else if varRule.Generate != "" {
+				result.Fixes = append(result.Fixes, Fix{
+					Variable:    varName,
+					Type:        FixTypeGenerate,
+					Description: fmt.Sprintf("Add optional variable with generated value: %s", varRule.Generate),
+				})
 			}
 			continue
 		}
 
 		// Validate type
 		if err := v.validateType(varName, value, varRule); err != nil {
-			result.Errors = append(result.Errors, ValidationError{
+			result.add(varRule.Severity, SeverityError, ValidationError{
 				Variable: varName,
 				Message:  err.Error(),
 				Type:     "type_error",
@@ -132,7 +161,7 @@ func (v *Validator) Validate(ctx context.Context, vars map[string]string) *Valid
 		// Validate pattern
 		if varRule.Pattern != "" {
 			if err := v.validatePattern(varName, value, varRule.Pattern); err != nil {
-				result.Errors = append(result.Errors, ValidationError{
+				result.add(varRule.Severity, SeverityError, ValidationError{
 					Variable: varName,
 					Message:  err.Error(),
 					Type:     "pattern_error",
@@ -144,7 +173,7 @@ func (v *Validator) Validate(ctx context.Context, vars map[string]string) *Valid
 		// Validate enum
 		if len(varRule.Enum) > 0 {
 			if err := v.validateEnum(varName, value, varRule.Enum); err != nil {
-				result.Errors = append(result.Errors, ValidationError{
+				result.add(varRule.Severity, SeverityError, ValidationError{
 					Variable: varName,
 					Message:  err.Error(),
 					Type:     "enum_error",
@@ -156,7 +185,7 @@ func (v *Validator) Validate(ctx context.Context, vars map[string]string) *Valid
 		// Validate range for numeric types
 		if varRule.Type == TypeInt || varRule.Type == TypeFloat {
 			if err := v.validateRange(varName, value, varRule); err != nil {
-				result.Errors = append(result.Errors, ValidationError{
+				result.add(varRule.Severity, SeverityError, ValidationError{
 					Variable: varName,
 					Message:  err.Error(),
 					Type:     "range_error",
@@ -165,16 +194,31 @@ func (v *Validator) Validate(ctx context.Context, vars map[string]string) *Valid
 		}
 	}
 
+	// Check the strength of secrets
+	for varName, value := range vars {
+		strength, ok := v.strengthRule(varName, v.rules.Variables[varName])
+		if !ok || value == "" {
+			continue
+		}
+		if reason := checkStrength(value, strength); reason != "" {
+			result.add(strength.Severity, SeverityWarning, ValidationError{
+				Variable: varName,
+				Message:  fmt.Sprintf("Secret %s looks weak: it %s", varName, reason),
+				Type:     "weak_secret",
+			})
+		}
+	}
+
+	// Check variables exposed to the browser
+	v.checkClientExposure(vars, result)
+
 	// Check for warnings
 	for varName := range vars {
 		// Check if variable is deprecated
 		for _, warning := range v.rules.Warnings {
 			if warning.Name == varName {
-				result.Warnings = append(result.Warnings, ValidationError{
-					Variable: varName,
-					Message:  warning.Message,
-					Type:     "deprecated",
-				})
+				severity, issue := warning.issue(v.now())
+				result.add(severity, SeverityWarning, issue)
 			}
 		}
 
@@ -182,7 +226,7 @@ func (v *Validator) Validate(ctx context.Context, vars map[string]string) *Valid
 		if _, defined := v.rules.Variables[varName]; !defined && !v.isInRequired(varName) {
 			// Check if it's a common variable that might be missing rules
 			if v.isCommonVariable(varName) {
-				result.Warnings = append(result.Warnings, ValidationError{
+				result.add("", SeverityWarning, ValidationError{
 					Variable: varName,
 					Message:  fmt.Sprintf("Variable %s has no validation rules defined", varName),
 					Type:     "undefined",
@@ -194,51 +238,37 @@ func (v *Validator) Validate(ctx context.Context, vars map[string]string) *Valid
 	return result
 }
 
-func (v *Validator) validateType(name, value string, rule *VariableRule) error {
-	switch rule.Type {
-	case "string":
-		// String is always valid
-		return nil
-
-	case TypeInt:
-		if _, err := strconv.Atoi(value); err != nil {
-			return fmt.Errorf("variable %s must be an integer", name)
-		}
-
-	case "float":
-		if _, err := strconv.ParseFloat(value, 64); err != nil {
-			return fmt.Errorf("variable %s must be a float", name)
-		}
-
-	case "bool":
-		lower := strings.ToLower(value)
-		if lower != "true" && lower != "false" && lower != "1" && lower != "0" {
-			return fmt.Errorf("variable %s must be a boolean (true/false/1/0)", name)
-		}
-
-	case "url":
-		u, err := url.Parse(value)
-		if err != nil || u.Scheme == "" || u.Host == "" {
-			return fmt.Errorf("variable %s must be a valid URL", name)
-		}
-
-	case "email":
-		emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-		if !emailRegex.MatchString(value) {
-			return fmt.Errorf("variable %s must be a valid email address", name)
+// checkValue runs a rule's type, pattern, enum and range checks on a value
+func (v *Validator) checkValue(name, value string, rule *VariableRule) error {
+	if err := v.validateType(name, value, rule); err != nil {
+		return err
+	}
+	if rule.Pattern != "" {
+		if err := v.validatePattern(name, value, rule.Pattern); err != nil {
+			return err
 		}
-
-	case "json":
-		var js json.RawMessage
-		if err := json.Unmarshal([]byte(value), &js); err != nil {
-			return fmt.Errorf("variable %s must be valid JSON", name)
+	}
+	if len(rule.Enum) > 0 {
+		if err := v.validateEnum(name, value, rule.Enum); err != nil {
+			return err
 		}
+	}
+	if rule.Type == TypeInt || rule.Type == TypeFloat {
+		return v.validateRange(name, value, rule)
+	}
+	return nil
+}
 
-	default:
+func (v *Validator) validateType(name, value string, rule *VariableRule) error {
+	f, ok := format.Lookup(rule.Type)
+	if !ok {
 		// Unknown type, skip validation
 		return nil
 	}
 
+	if !f.Valid(value) {
+		return fmt.Errorf("variable %s must be %s", name, f.Description)
+	}
 	return nil
 }
 
@@ -297,6 +327,14 @@ func (v *Validator) validateRange(name, value string, rule *VariableRule) error
 	return nil
 }
 
+// severityOf returns the severity set in a variable's rule, if any
+func (v *Validator) severityOf(name string) Severity {
+	if rule, ok := v.rules.Variables[name]; ok && rule != nil {
+		return rule.Severity
+	}
+	return ""
+}
+
 func (v *Validator) isInRequired(name string) bool {
 	for _, r := range v.rules.Required {
 		if r == name {
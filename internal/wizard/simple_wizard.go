@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/framework"
 )
 
 // SimpleWizard provides a simple interactive configuration wizard
@@ -53,6 +54,12 @@ func RunSimpleInteractive(projectName string) error {
 		return fmt.Errorf("wizard failed: %w", err)
 	}
 
+	// Classify the variables of the frameworks in use
+	if frameworks := framework.Detect("."); len(frameworks) > 0 {
+		fmt.Printf("Detected %s\n", strings.Join(framework.Names(frameworks), ", "))
+		framework.Apply(cfg, frameworks)
+	}
+
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		return fmt.Errorf("invalid configuration: %w", err)
@@ -8,6 +8,7 @@ import (
 
 	"github.com/c-bata/go-prompt"
 	"github.com/drapon/envy/internal/config"
+	"github.com/drapon/envy/internal/framework"
 )
 
 // ConfigWizard provides an interactive configuration wizard
@@ -264,6 +265,12 @@ func InteractiveInitWithPrompt(projectName string) error {
 		return fmt.Errorf("wizard failed: %w", err)
 	}
 
+	// Classify the variables of the frameworks in use
+	if frameworks := framework.Detect("."); len(frameworks) > 0 {
+		fmt.Printf("Detected %s\n", strings.Join(framework.Names(frameworks), ", "))
+		framework.Apply(cfg, frameworks)
+	}
+
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		return fmt.Errorf("invalid configuration: %w", err)